@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"ypeskov/kkal-tracker/internal/config"
+	"ypeskov/kkal-tracker/internal/database"
+	"ypeskov/kkal-tracker/internal/logger"
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	var command, email string
+	flag.StringVar(&command, "cmd", "", "Admin command: grant, revoke")
+	flag.StringVar(&email, "email", "", "Email of the user")
+	flag.Parse()
+
+	if email == "" {
+		fmt.Println("Usage: admin -cmd=grant|revoke -email=user@example.com")
+		os.Exit(1)
+	}
+
+	err := godotenv.Load()
+	if err != nil {
+		log.Printf("Warning: Error loading .env file: %v", err)
+	}
+
+	cfg := config.New()
+
+	appLogger := logger.New(cfg)
+
+	db, err := database.New(cfg.DatabasePath, appLogger)
+	if err != nil {
+		appLogger.Error("Failed to initialize database", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	dialect := repositories.DialectSQLite
+	if cfg.DatabaseType == "postgres" {
+		dialect = repositories.DialectPostgres
+	}
+	userRepo := repositories.NewUserRepository(db, appLogger, dialect)
+
+	var role string
+	switch command {
+	case "grant":
+		role = models.RoleAdmin
+	case "revoke":
+		role = models.RoleUser
+	default:
+		fmt.Printf("Unknown command: %s\n", command)
+		fmt.Println("Available commands: grant, revoke")
+		os.Exit(1)
+	}
+
+	user, err := userRepo.GetByEmail(email)
+	if err != nil {
+		log.Fatalf("Failed to find user %s: %v", email, err)
+	}
+
+	if err := userRepo.SetRole(user.ID, role); err != nil {
+		log.Fatalf("Failed to set role: %v", err)
+	}
+
+	fmt.Printf("User %s now has role %q\n", email, role)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"ypeskov/kkal-tracker/internal/config"
+	"ypeskov/kkal-tracker/internal/database"
+	"ypeskov/kkal-tracker/internal/logger"
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/openfoodfacts"
+	"ypeskov/kkal-tracker/internal/repositories"
+	ingredientservice "ypeskov/kkal-tracker/internal/services/ingredient"
+
+	"github.com/joho/godotenv"
+)
+
+// summary counts what happened to the products of a dump
+type summary struct {
+	read     int
+	imported int
+	updated  int
+	skipped  map[string]int // Reason -> count
+	errors   int
+}
+
+func (s *summary) skip(reason string) {
+	s.skipped[reason]++
+}
+
+func main() {
+	var file, format string
+	var batchSize, limit int
+	var update, dryRun bool
+	flag.StringVar(&file, "file", "", "Open Food Facts dump (.csv or .jsonl, optionally .gz)")
+	flag.StringVar(&format, "format", "auto", "Dump format: auto, csv, jsonl")
+	flag.IntVar(&batchSize, "batch", 500, "Number of products inserted per transaction")
+	flag.IntVar(&limit, "limit", 0, "Stop after reading this many products (0 = no limit)")
+	flag.BoolVar(&update, "update", false, "Update products already imported from Open Food Facts")
+	flag.BoolVar(&dryRun, "dry-run", false, "Read and convert the dump without writing to the database")
+	flag.Parse()
+
+	if file == "" || batchSize < 1 {
+		fmt.Println("Usage: offimport -file=products.jsonl.gz [-format=auto|csv|jsonl] [-batch=500] [-limit=0] [-update] [-dry-run]")
+		os.Exit(1)
+	}
+
+	dumpFormat := openfoodfacts.Format(format)
+	if format == "auto" {
+		detected, err := openfoodfacts.DetectFormat(file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		dumpFormat = detected
+	}
+
+	err := godotenv.Load()
+	if err != nil {
+		log.Printf("Warning: Error loading .env file: %v", err)
+	}
+
+	cfg := config.New()
+
+	appLogger := logger.New(cfg)
+
+	db, err := database.New(cfg.DatabasePath, appLogger)
+	if err != nil {
+		appLogger.Error("Failed to initialize database", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	dialect := repositories.DialectSQLite
+	if cfg.DatabaseType == "postgres" {
+		dialect = repositories.DialectPostgres
+	}
+	ingredientRepo := repositories.NewIngredientRepository(db, appLogger, dialect)
+	calorieRepo := repositories.NewCalorieEntryRepository(db, appLogger, dialect)
+	ingredientService := ingredientservice.New(ingredientRepo, calorieRepo, appLogger)
+
+	reader, err := openfoodfacts.Open(file, dumpFormat)
+	if err != nil {
+		log.Fatalf("Failed to open dump: %v", err)
+	}
+	defer reader.Close()
+
+	stats := &summary{skipped: make(map[string]int)}
+	seen := make(map[string]bool)
+	batch := make([]*models.GlobalIngredient, 0, batchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if !dryRun {
+			if _, err := ingredientRepo.CreateGlobalIngredients(batch, nil); err != nil {
+				log.Fatalf("Failed to insert batch ending at line %d: %v", reader.Line(), err)
+			}
+		}
+		stats.imported += len(batch)
+		batch = batch[:0]
+		fmt.Printf("Read %d products, imported %d, updated %d\n", stats.read, stats.imported, stats.updated)
+	}
+
+	for limit == 0 || stats.read < limit {
+		product, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			log.Printf("Skipping unreadable product: %v", err)
+			stats.errors++
+			continue
+		}
+		stats.read++
+
+		ingredient, err := openfoodfacts.ToGlobalIngredient(product)
+		if err != nil {
+			stats.skip(err.Error())
+			continue
+		}
+		barcode := *ingredient.Barcode
+		if seen[barcode] {
+			stats.skip("duplicate barcode in dump")
+			continue
+		}
+		seen[barcode] = true
+
+		existing, err := ingredientRepo.GetGlobalIngredientByBarcode(barcode)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Fatalf("Failed to look up barcode %s: %v", barcode, err)
+		}
+		if existing == nil {
+			batch = append(batch, ingredient)
+			if len(batch) == batchSize {
+				flush()
+			}
+			continue
+		}
+
+		// Curated items keep their values even when the barcode is in the dump
+		if !update || existing.Source != models.IngredientSourceOpenFoodFacts {
+			stats.skip("already in catalog")
+			continue
+		}
+		if !dryRun {
+			_, err := ingredientRepo.UpdateGlobalIngredient(existing.ID, ingredient.KcalPer100g, ingredient.Fats, ingredient.Carbs,
+				ingredient.Proteins, ingredient.Nutrients, ingredient.Barcode, ingredient.Names, nil)
+			if err != nil {
+				log.Printf("Failed to update barcode %s: %v", barcode, err)
+				stats.errors++
+				continue
+			}
+			if _, err := ingredientService.PropagateGlobalIngredient(existing.ID); err != nil {
+				log.Printf("Failed to propagate barcode %s: %v", barcode, err)
+			}
+		}
+		stats.updated++
+	}
+	flush()
+
+	if dryRun {
+		fmt.Println("Dry run, nothing was written")
+	}
+	fmt.Printf("Read: %d\nImported: %d\nUpdated: %d\nErrors: %d\n", stats.read, stats.imported, stats.updated, stats.errors)
+
+	reasons := make([]string, 0, len(stats.skipped))
+	for reason := range stats.skipped {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Printf("Skipped (%s): %d\n", reason, stats.skipped[reason])
+	}
+}
@@ -14,6 +14,8 @@ type AIConfig struct {
 	Model        string
 	UseMaxTokens bool // Whether to limit response tokens
 	MaxTokens    int  // Maximum completion tokens (if UseMaxTokens is true)
+	CacheTTLHours int // How long analysis results are reused for unchanged input data
+	PromptsDir    string // Optional directory with prompt template overrides
 }
 
 type Config struct {
@@ -31,6 +33,19 @@ type Config struct {
 	SMTPPassword string
 	SMTPFrom     string
 	AppURL       string
+	// Export jobs
+	ExportJobTTLHours int // How long files of finished background exports can be downloaded
+	// Account deletion
+	AccountDeletionGraceDays int // Days between a deletion request and the purge of the account
+	// Webhooks
+	WebhookAllowPrivateNetworks bool // Whether webhook endpoints may be on loopback or private networks
+	// Change feed
+	SyncTombstoneRetentionDays int // Days deleted entities stay in the change feed
+	// Idempotency keys
+	IdempotencyKeyTTLHours int // How long responses to requests with an Idempotency-Key are replayed
+	// Trash
+	TrashRetentionDays int // Days deleted calorie entries and weigh-ins can be restored before they are purged
+	UndoWindowSeconds  int // Seconds after a delete during which it can be undone
 	// AI Configuration
 	AI AIConfig
 }
@@ -86,6 +101,19 @@ func New() *Config {
 		SMTPPassword: getEnv("SMTP_PASSWORD", ""), // SMTP_PASSWORD is the default SMTP password
 		SMTPFrom:     getEnv("SMTP_FROM", "noreply@kkal-tracker.com"),
 		AppURL:       getEnv("APP_URL", "http://localhost:8080"), // http://localhost:8080 is the default app URL
+		// Export jobs
+		ExportJobTTLHours: getEnvInt("EXPORT_JOB_TTL_HOURS", 24),
+		// Account deletion
+		AccountDeletionGraceDays: getEnvInt("ACCOUNT_DELETION_GRACE_DAYS", 30),
+		// Webhooks
+		WebhookAllowPrivateNetworks: getEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+		// Change feed
+		SyncTombstoneRetentionDays: getEnvInt("SYNC_TOMBSTONE_RETENTION_DAYS", 90),
+		// Idempotency keys
+		IdempotencyKeyTTLHours: getEnvInt("IDEMPOTENCY_KEY_TTL_HOURS", 24),
+		// Trash
+		TrashRetentionDays: getEnvInt("TRASH_RETENTION_DAYS", 30),
+		UndoWindowSeconds:  getEnvInt("UNDO_WINDOW_SECONDS", 30),
 		// AI Configuration
 		AI: AIConfig{
 			APIKey:       getEnv("OPENAI_API_KEY", ""), // OPENAI_API_KEY is the default OpenAI API key
@@ -93,6 +121,8 @@ func New() *Config {
 			Model:        getEnv("OPENAI_MODEL", "gpt-5.2"), // gpt-5.2 is the default OpenAI model
 			UseMaxTokens: getEnvBool("AI_USE_MAX_TOKENS", false), // AI_USE_MAX_TOKENS is the default AI use max tokens
 			MaxTokens:    getEnvInt("AI_MAX_TOKENS", 2000), // AI_MAX_TOKENS is the default AI max tokens
+			CacheTTLHours: getEnvInt("AI_CACHE_TTL_HOURS", 168), // 168 hours = 7 days
+			PromptsDir:    getEnv("AI_PROMPTS_DIR", ""), // empty means built-in prompts only
 		},
 	}
 }
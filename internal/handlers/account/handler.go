@@ -0,0 +1,106 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	accountservice "ypeskov/kkal-tracker/internal/services/account"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	accountService accountservice.Servicer
+	logger         *slog.Logger
+}
+
+type DeletionRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type DeletionResponse struct {
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"` // Null when no deletion is pending
+}
+
+func New(accountService accountservice.Servicer, logger *slog.Logger) *Handler {
+	return &Handler{
+		accountService: accountService,
+		logger:         logger.With("handler", "account"),
+	}
+}
+
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.GET("/archive", h.Archive)
+	g.GET("/deletion", h.GetDeletion)
+	g.POST("/deletion", h.RequestDeletion)
+	g.DELETE("/deletion", h.CancelDeletion)
+}
+
+// Archive downloads a ZIP file with all of the user's data
+func (h *Handler) Archive(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	archive, err := h.accountService.Archive(userID)
+	if err != nil {
+		h.logger.Error("Failed to create account archive", "error", err, "user_id", userID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create account archive")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"%s\"", archive.FileName))
+	return c.Blob(http.StatusOK, "application/zip", archive.FileBytes)
+}
+
+// GetDeletion returns when the account will be deleted, if deletion was requested
+func (h *Handler) GetDeletion(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	scheduledAt, err := h.accountService.DeletionScheduledAt(userID)
+	if err != nil {
+		h.logger.Error("Failed to get account deletion", "error", err, "user_id", userID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get account deletion")
+	}
+
+	return c.JSON(http.StatusOK, DeletionResponse{DeletionScheduledAt: scheduledAt})
+}
+
+// RequestDeletion schedules the account for deletion after the password is confirmed
+func (h *Handler) RequestDeletion(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	var req DeletionRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	scheduledAt, err := h.accountService.RequestDeletion(userID, req.Password)
+	if err != nil {
+		if errors.Is(err, accountservice.ErrInvalidPassword) {
+			return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+		}
+		h.logger.Error("Failed to request account deletion", "error", err, "user_id", userID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to request account deletion")
+	}
+
+	return c.JSON(http.StatusAccepted, DeletionResponse{DeletionScheduledAt: &scheduledAt})
+}
+
+// CancelDeletion keeps the account during the grace period
+func (h *Handler) CancelDeletion(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	if err := h.accountService.CancelDeletion(userID); err != nil {
+		if errors.Is(err, accountservice.ErrDeletionNotRequested) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		h.logger.Error("Failed to cancel account deletion", "error", err, "user_id", userID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to cancel account deletion")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,26 @@
+package admin
+
+import "ypeskov/kkal-tracker/internal/models"
+
+// GlobalIngredientRequest is the body for creating or updating a global ingredient
+type GlobalIngredientRequest struct {
+	KcalPer100g float64           `json:"kcalPer100g" validate:"min=0"`
+	Fats        *float64          `json:"fats,omitempty" validate:"omitempty,min=0"`
+	Carbs       *float64          `json:"carbs,omitempty" validate:"omitempty,min=0"`
+	Proteins    *float64          `json:"proteins,omitempty" validate:"omitempty,min=0"`
+	Nutrients   models.Nutrients  `json:"nutrients,omitempty"`             // Extended nutrients per 100 g
+	Barcode     *string           `json:"barcode,omitempty"`               // EAN/UPC; on update, omit to keep and "" to clear
+	Names       map[string]string `json:"names" validate:"required,min=1"` // language_code -> name
+}
+
+// ImportRequest is the body for a bulk import of global ingredients.
+// Items are validated by the service so errors can point at their index.
+type ImportRequest struct {
+	Ingredients []GlobalIngredientRequest `json:"ingredients" validate:"required,min=1"`
+}
+
+// ImportErrorResponse lists the invalid items of a rejected bulk import
+type ImportErrorResponse struct {
+	Message string `json:"message"`
+	Errors  any    `json:"errors"`
+}
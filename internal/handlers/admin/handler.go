@@ -0,0 +1,244 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	adminservice "ypeskov/kkal-tracker/internal/services/admin"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	adminService adminservice.Servicer
+	logger       *slog.Logger
+}
+
+func New(adminService adminservice.Servicer, logger *slog.Logger) *Handler {
+	return &Handler{
+		adminService: adminService,
+		logger:       logger.With("handler", "admin"),
+	}
+}
+
+// RegisterRoutes registers admin routes; the group must require auth and the admin role
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.GET("/ingredients", h.ListGlobalIngredients)
+	g.POST("/ingredients", h.CreateGlobalIngredient)
+	g.POST("/ingredients/import", h.ImportGlobalIngredients)
+	g.GET("/ingredients/:id", h.GetGlobalIngredient)
+	g.PUT("/ingredients/:id", h.UpdateGlobalIngredient)
+	g.DELETE("/ingredients/:id", h.DeleteGlobalIngredient)
+	g.GET("/audit", h.GetAuditLog)
+}
+
+// ListGlobalIngredients lists or searches the global ingredient catalog
+func (h *Handler) ListGlobalIngredients(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("ListGlobalIngredients called", "user_id", userID)
+
+	limit, offset, err := parsePaging(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	page, err := h.adminService.ListGlobalIngredients(adminservice.GlobalIngredientFilter{
+		Query:        c.QueryParam("q"),
+		LanguageCode: c.QueryParam("lang"),
+		Limit:        limit,
+		Offset:       offset,
+	})
+	if err != nil {
+		return h.handleError(err, "Failed to list global ingredients")
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
+
+// GetGlobalIngredient returns a single global ingredient with all its names
+func (h *Handler) GetGlobalIngredient(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ingredient ID")
+	}
+
+	ingredient, err := h.adminService.GetGlobalIngredient(id)
+	if err != nil {
+		return h.handleError(err, "Failed to get global ingredient")
+	}
+
+	return c.JSON(http.StatusOK, ingredient)
+}
+
+// CreateGlobalIngredient adds an ingredient to the global catalog
+func (h *Handler) CreateGlobalIngredient(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("CreateGlobalIngredient called", "user_id", userID)
+
+	var req GlobalIngredientRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	ingredient, err := h.adminService.CreateGlobalIngredient(userID, toInput(req))
+	if err != nil {
+		return h.handleError(err, "Failed to create global ingredient")
+	}
+
+	return c.JSON(http.StatusCreated, ingredient)
+}
+
+// UpdateGlobalIngredient replaces nutrition values and names of a global ingredient
+func (h *Handler) UpdateGlobalIngredient(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ingredient ID")
+	}
+	h.logger.Debug("UpdateGlobalIngredient called", "user_id", userID, "ingredient_id", id)
+
+	var req GlobalIngredientRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	ingredient, err := h.adminService.UpdateGlobalIngredient(userID, id, toInput(req))
+	if err != nil {
+		return h.handleError(err, "Failed to update global ingredient")
+	}
+
+	return c.JSON(http.StatusOK, ingredient)
+}
+
+// DeleteGlobalIngredient removes an ingredient from the global catalog
+func (h *Handler) DeleteGlobalIngredient(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ingredient ID")
+	}
+	h.logger.Debug("DeleteGlobalIngredient called", "user_id", userID, "ingredient_id", id)
+
+	if err := h.adminService.DeleteGlobalIngredient(userID, id); err != nil {
+		return h.handleError(err, "Failed to delete global ingredient")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ImportGlobalIngredients adds many global ingredients in one all-or-nothing request
+func (h *Handler) ImportGlobalIngredients(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("ImportGlobalIngredients called", "user_id", userID)
+
+	var req ImportRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	inputs := make([]*adminservice.GlobalIngredientInput, 0, len(req.Ingredients))
+	for _, item := range req.Ingredients {
+		inputs = append(inputs, toInput(item))
+	}
+
+	result, err := h.adminService.ImportGlobalIngredients(userID, inputs)
+	if err != nil {
+		var importErr *adminservice.ImportValidationError
+		if errors.As(err, &importErr) {
+			return c.JSON(http.StatusBadRequest, ImportErrorResponse{
+				Message: "Import contains invalid ingredients",
+				Errors:  importErr.Errors,
+			})
+		}
+		return h.handleError(err, "Failed to import global ingredients")
+	}
+
+	return c.JSON(http.StatusCreated, result)
+}
+
+// GetAuditLog returns the admin audit trail, newest first
+func (h *Handler) GetAuditLog(c echo.Context) error {
+	limit, offset, err := parsePaging(c)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	var entityID int
+	if value := c.QueryParam("entity_id"); value != "" {
+		entityID, err = strconv.Atoi(value)
+		if err != nil || entityID < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid entity_id")
+		}
+	}
+
+	entries, err := h.adminService.GetAuditLog(adminservice.AuditFilter{
+		EntityType: c.QueryParam("entity_type"),
+		EntityID:   entityID,
+		Limit:      limit,
+		Offset:     offset,
+	})
+	if err != nil {
+		return h.handleError(err, "Failed to get audit log")
+	}
+
+	return c.JSON(http.StatusOK, entries)
+}
+
+// handleError maps admin service errors to HTTP errors
+func (h *Handler) handleError(err error, message string) error {
+	switch {
+	case errors.Is(err, adminservice.ErrIngredientNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, "Global ingredient not found")
+	case errors.Is(err, adminservice.ErrInvalidIngredient),
+		errors.Is(err, adminservice.ErrEmptyImport),
+		errors.Is(err, adminservice.ErrImportTooLarge):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Error(message, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+// parsePaging reads optional limit and offset query parameters
+func parsePaging(c echo.Context) (int, int, error) {
+	var limit, offset int
+	var err error
+
+	if value := c.QueryParam("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return 0, 0, errors.New("invalid limit")
+		}
+	}
+	if value := c.QueryParam("offset"); value != "" {
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("invalid offset")
+		}
+	}
+
+	return limit, offset, nil
+}
+
+func toInput(req GlobalIngredientRequest) *adminservice.GlobalIngredientInput {
+	return &adminservice.GlobalIngredientInput{
+		KcalPer100g: req.KcalPer100g,
+		Fats:        req.Fats,
+		Carbs:       req.Carbs,
+		Proteins:    req.Proteins,
+		Nutrients:   req.Nutrients,
+		Barcode:     req.Barcode,
+		Names:       req.Names,
+	}
+}
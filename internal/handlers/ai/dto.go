@@ -1,5 +1,7 @@
 package ai
 
+import "ypeskov/kkal-tracker/internal/models"
+
 // AnalyzeRequest represents the request body for AI analysis
 type AnalyzeRequest struct {
 	PeriodDays int    `json:"period_days" validate:"required,min=1,max=365"`
@@ -12,10 +14,21 @@ type StatusResponse struct {
 	Model     string `json:"model,omitempty"`
 }
 
-// AnalysisResultResponse represents the AI analysis result
-type AnalysisResultResponse struct {
-	Analysis   string `json:"analysis"`
-	Model      string `json:"model"`
-	TokensUsed int    `json:"tokens_used,omitempty"`
-	DurationMs int64  `json:"duration_ms"`
+// GenerateMealPlanRequest represents the request body for meal plan generation
+type GenerateMealPlanRequest struct {
+	Days        int    `json:"days" validate:"required,min=1,max=7"`
+	MealsPerDay int    `json:"meals_per_day,omitempty" validate:"omitempty,min=2,max=6"`
+	Preferences string `json:"preferences,omitempty" validate:"max=500"`
+}
+
+// ApplyMealPlanRequest represents the request body for applying a meal plan day
+type ApplyMealPlanRequest struct {
+	Day      int    `json:"day" validate:"required,min=1"`
+	Date     string `json:"date" validate:"required,datetime=2006-01-02"`
+	Timezone string `json:"timezone,omitempty"` // IANA name, e.g. "Europe/Kyiv"; defaults to UTC
+}
+
+// ApplyMealPlanResponse lists calorie entries created from a meal plan day
+type ApplyMealPlanResponse struct {
+	Entries []*models.CalorieEntry `json:"entries"`
 }
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"sort"
 	"time"
 
 	"ypeskov/kkal-tracker/internal/models"
@@ -101,10 +102,43 @@ func (h *Handler) Analyze(c echo.Context) error {
 	weightData := h.convertWeightData(weightHistory)
 
 	// Build user context
+	userContext := h.buildUserContext(user, weightData)
+
+	// Build analysis request
+	analysisReq := aiservice.AnalysisRequest{
+		UserID:        userID,
+		UserContext:   userContext,
+		NutritionData: nutritionData,
+		WeightData:    weightData,
+		Query:         "", // Query is disabled for now; may be enabled in future versions
+		PeriodDays:    req.PeriodDays,
+	}
+
+	// Perform analysis with timeout to prevent hanging requests
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
+	defer cancel()
+
+	result, err := h.aiService.Analyze(ctx, analysisReq)
+	if err != nil {
+		if errors.Is(err, aiservice.ErrProviderNotAvailable) {
+			return echo.NewHTTPError(http.StatusServiceUnavailable, "AI provider is not configured")
+		}
+		h.logger.Error("AI analysis failed", slog.String("error", err.Error()))
+		return echo.NewHTTPError(http.StatusInternalServerError, "AI analysis failed")
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// buildUserContext collects profile and weight goal information for AI prompts.
+// weightData is expected in chronological order; the last point is the current weight.
+func (h *Handler) buildUserContext(user *models.User, weightData []aiservice.WeightDataPoint) aiservice.UserContext {
 	userContext := aiservice.UserContext{
-		Age:      user.Age,
-		Height:   user.Height,
-		Language: "en_US",
+		Age:           user.Age,
+		Height:        user.Height,
+		Gender:        user.Gender,
+		ActivityLevel: user.ActivityLevel,
+		Language:      "en_US",
 	}
 	if user.Language != nil {
 		userContext.Language = *user.Language
@@ -157,34 +191,7 @@ func (h *Handler) Analyze(c echo.Context) error {
 		}
 	}
 
-	// Build analysis request
-	analysisReq := aiservice.AnalysisRequest{
-		UserContext:   userContext,
-		NutritionData: nutritionData,
-		WeightData:    weightData,
-		Query:         "", // Query is disabled for now; may be enabled in future versions
-		PeriodDays:    req.PeriodDays,
-	}
-
-	// Perform analysis with timeout to prevent hanging requests
-	ctx, cancel := context.WithTimeout(c.Request().Context(), 30*time.Second)
-	defer cancel()
-
-	result, err := h.aiService.Analyze(ctx, analysisReq)
-	if err != nil {
-		if errors.Is(err, aiservice.ErrProviderNotAvailable) {
-			return echo.NewHTTPError(http.StatusServiceUnavailable, "AI provider is not configured")
-		}
-		h.logger.Error("AI analysis failed", slog.String("error", err.Error()))
-		return echo.NewHTTPError(http.StatusInternalServerError, "AI analysis failed")
-	}
-
-	return c.JSON(http.StatusOK, AnalysisResultResponse{
-		Analysis:   result.Analysis,
-		Model:      result.Model,
-		TokensUsed: result.TokensUsed,
-		DurationMs: result.DurationMs,
-	})
+	return userContext
 }
 
 // aggregateNutritionData groups calorie entries by day
@@ -224,11 +231,14 @@ func (h *Handler) aggregateNutritionData(entries []*models.CalorieEntry) []aiser
 		}
 	}
 
-	// Convert map to slice
+	// Convert map to slice, sorted by date so the same data always yields the same request
 	result := make([]aiservice.NutritionDataPoint, 0, len(byDate))
 	for _, point := range byDate {
 		result = append(result, *point)
 	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Date < result[j].Date
+	})
 
 	return result
 }
@@ -251,4 +261,13 @@ func (h *Handler) convertWeightData(history []*models.WeightHistory) []aiservice
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.GET("/status", h.GetStatus)
 	g.POST("/analyze", h.Analyze)
+	g.POST("/meal-plans", h.GenerateMealPlan)
+}
+
+// RegisterMealPlanRoutes registers routes for saved meal plans
+func (h *Handler) RegisterMealPlanRoutes(g *echo.Group) {
+	g.GET("", h.GetMealPlans)
+	g.GET("/:id", h.GetMealPlan)
+	g.DELETE("/:id", h.DeleteMealPlan)
+	g.POST("/:id/apply", h.ApplyMealPlan)
 }
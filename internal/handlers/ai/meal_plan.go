@@ -0,0 +1,166 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	aiservice "ypeskov/kkal-tracker/internal/services/ai"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GenerateMealPlan creates a meal plan that fits the user's daily targets
+func (h *Handler) GenerateMealPlan(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	var req GenerateMealPlanRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	user, err := h.userRepo.GetByID(userID)
+	if err != nil {
+		h.logger.Error("Failed to get user", slog.String("error", err.Error()))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user profile")
+	}
+
+	h.logger.Debug("Meal plan requested",
+		slog.Int("user_id", userID),
+		slog.Int("days", req.Days))
+
+	generateReq := aiservice.GenerateMealPlanRequest{
+		UserID:      userID,
+		UserContext: h.buildUserContext(user, nil),
+		Days:        req.Days,
+		MealsPerDay: req.MealsPerDay,
+		Preferences: req.Preferences,
+	}
+
+	// Plans are much longer than analyses, so allow more time for the model
+	ctx, cancel := context.WithTimeout(c.Request().Context(), 90*time.Second)
+	defer cancel()
+
+	result, err := h.aiService.GenerateMealPlan(ctx, generateReq)
+	if err != nil {
+		switch {
+		case errors.Is(err, aiservice.ErrProviderNotAvailable):
+			return echo.NewHTTPError(http.StatusServiceUnavailable, "AI provider is not configured")
+		case errors.Is(err, aiservice.ErrTargetsUnavailable):
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, "Age, height, gender and at least one weight entry are required to plan meals")
+		case errors.Is(err, aiservice.ErrInvalidMealPlan):
+			return echo.NewHTTPError(http.StatusBadGateway, "AI returned an unusable meal plan, please try again")
+		}
+		h.logger.Error("Meal plan generation failed", slog.String("error", err.Error()))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Meal plan generation failed")
+	}
+
+	return c.JSON(http.StatusCreated, result)
+}
+
+// GetMealPlans returns the user's saved meal plans
+func (h *Handler) GetMealPlans(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	plans, err := h.aiService.GetMealPlans(userID)
+	if err != nil {
+		h.logger.Error("Failed to get meal plans", slog.String("error", err.Error()))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get meal plans")
+	}
+
+	return c.JSON(http.StatusOK, plans)
+}
+
+// GetMealPlan returns a saved meal plan with its items and daily totals
+func (h *Handler) GetMealPlan(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid meal plan ID")
+	}
+
+	result, err := h.aiService.GetMealPlan(userID, id)
+	if err != nil {
+		if errors.Is(err, aiservice.ErrMealPlanNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Meal plan not found")
+		}
+		h.logger.Error("Failed to get meal plan", slog.String("error", err.Error()))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get meal plan")
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// DeleteMealPlan removes a saved meal plan
+func (h *Handler) DeleteMealPlan(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid meal plan ID")
+	}
+
+	if err := h.aiService.DeleteMealPlan(userID, id); err != nil {
+		if errors.Is(err, aiservice.ErrMealPlanNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Meal plan not found")
+		}
+		h.logger.Error("Failed to delete meal plan", slog.String("error", err.Error()))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete meal plan")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ApplyMealPlan logs one day of a meal plan as calorie entries on the given date
+func (h *Handler) ApplyMealPlan(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid meal plan ID")
+	}
+
+	var req ApplyMealPlanRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	location := time.UTC
+	if req.Timezone != "" {
+		location, err = time.LoadLocation(req.Timezone)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid timezone")
+		}
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", req.Date, location)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid date format, expected YYYY-MM-DD")
+	}
+
+	entries, err := h.aiService.ApplyMealPlanDay(userID, id, req.Day, date)
+	if err != nil {
+		switch {
+		case errors.Is(err, aiservice.ErrMealPlanNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, "Meal plan not found")
+		case errors.Is(err, aiservice.ErrInvalidPlanDay):
+			return echo.NewHTTPError(http.StatusBadRequest, "Day is out of the plan range")
+		}
+		h.logger.Error("Failed to apply meal plan", slog.String("error", err.Error()))
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to apply meal plan")
+	}
+
+	return c.JSON(http.StatusCreated, ApplyMealPlanResponse{Entries: entries})
+}
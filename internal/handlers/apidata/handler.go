@@ -37,6 +37,8 @@ type FoodEntry struct {
 	Carbs        *float64  `json:"carbs,omitempty"`
 	Proteins     *float64  `json:"proteins,omitempty"`
 	MealDatetime time.Time `json:"meal_datetime"`
+	// Extended nutrients per 100 g, only the known ones
+	Nutrients models.Nutrients `json:"nutrients,omitempty"`
 }
 
 func New(calorieService calorieservice.Servicer, weightService weightservice.Servicer, logger *slog.Logger) *Handler {
@@ -122,6 +124,7 @@ func mapFoodEntries(data []*models.CalorieEntry) []FoodEntry {
 			Carbs:        e.Carbs,
 			Proteins:     e.Proteins,
 			MealDatetime: e.MealDatetime,
+			Nutrients:    e.Nutrients,
 		}
 	}
 	return entries
@@ -0,0 +1,35 @@
+package batch
+
+import "ypeskov/kkal-tracker/internal/models"
+
+type BatchRequest struct {
+	// When set, nothing is applied unless every operation succeeds
+	Atomic     bool                `json:"atomic"`
+	Operations []*OperationRequest `json:"operations" validate:"required,min=1,max=100,dive,required"`
+}
+
+type OperationRequest struct {
+	Action string `json:"action" validate:"required"`
+	Type   string `json:"type" validate:"required"`
+	ID     int    `json:"id,omitempty"`
+	// Version an updated or deleted entry must have, as If-Match on PUT and DELETE
+	Version int               `json:"version,omitempty" validate:"min=0"`
+	Data    *OperationDataDTO `json:"data,omitempty"`
+}
+
+// OperationDataDTO takes the fields of POST /api/calories for calorie entries
+// and those of POST /api/weight for weigh-ins
+type OperationDataDTO struct {
+	Food         string           `json:"food,omitempty"`
+	Calories     int              `json:"calories,omitempty"`
+	Weight       float64          `json:"weight"`
+	KcalPer100g  float64          `json:"kcalPer100g,omitempty"`
+	Fats         *float64         `json:"fats,omitempty"`
+	Carbs        *float64         `json:"carbs,omitempty"`
+	Proteins     *float64         `json:"proteins,omitempty"`
+	Nutrients    models.Nutrients `json:"nutrients,omitempty"`
+	MealDatetime string           `json:"meal_datetime,omitempty"`
+	Quantity     *float64         `json:"quantity,omitempty"`
+	Unit         string           `json:"unit,omitempty"`
+	RecordedAt   string           `json:"recorded_at,omitempty"`
+}
@@ -0,0 +1,72 @@
+package batch
+
+import (
+	"log/slog"
+	"net/http"
+
+	batchservice "ypeskov/kkal-tracker/internal/services/batch"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	batchService batchservice.Servicer
+	logger       *slog.Logger
+}
+
+func New(batchService batchservice.Servicer, logger *slog.Logger) *Handler {
+	return &Handler{
+		batchService: batchService,
+		logger:       logger.With("handler", "batch"),
+	}
+}
+
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.POST("/batch", h.ApplyBatch)
+}
+
+// ApplyBatch applies a list of calorie entry and weigh-in writes in one transaction
+func (h *Handler) ApplyBatch(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	var req BatchRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	serviceReq := &batchservice.BatchRequest{
+		Operations: make([]*batchservice.Operation, 0, len(req.Operations)),
+		Atomic:     req.Atomic,
+	}
+	for _, op := range req.Operations {
+		operation := &batchservice.Operation{Action: op.Action, Type: op.Type, ID: op.ID, Version: op.Version}
+		if op.Data != nil {
+			operation.Data = &batchservice.OperationData{
+				Food:         op.Data.Food,
+				Calories:     op.Data.Calories,
+				Weight:       op.Data.Weight,
+				KcalPer100g:  op.Data.KcalPer100g,
+				Fats:         op.Data.Fats,
+				Carbs:        op.Data.Carbs,
+				Proteins:     op.Data.Proteins,
+				Nutrients:    op.Data.Nutrients,
+				MealDatetime: op.Data.MealDatetime,
+				Quantity:     op.Data.Quantity,
+				Unit:         op.Data.Unit,
+				RecordedAt:   op.Data.RecordedAt,
+			}
+		}
+		serviceReq.Operations = append(serviceReq.Operations, operation)
+	}
+
+	result, err := h.batchService.Apply(userID, serviceReq)
+	if err != nil {
+		h.logger.Error("Failed to apply batch", "user_id", userID, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to apply batch")
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
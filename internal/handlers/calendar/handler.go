@@ -0,0 +1,139 @@
+package calendar
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	calendarservice "ypeskov/kkal-tracker/internal/services/calendar"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	calendarService calendarservice.Servicer
+	logger          *slog.Logger
+}
+
+type Request struct {
+	Timezone string `json:"timezone" validate:"omitempty,max=64"`         // IANA name, defaults to UTC
+	PastDays int    `json:"past_days" validate:"omitempty,min=1,max=365"` // Defaults to 30
+}
+
+func New(calendarService calendarservice.Servicer, logger *slog.Logger) *Handler {
+	return &Handler{
+		calendarService: calendarService,
+		logger:          logger.With("handler", "calendar"),
+	}
+}
+
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.GET("/feed", h.GetFeed)
+	g.POST("/feed", h.CreateFeed)
+	g.PUT("/feed", h.UpdateFeed)
+	g.DELETE("/feed", h.DeleteFeed)
+}
+
+// RegisterFeedRoutes registers the public route calendar apps subscribe to
+func (h *Handler) RegisterFeedRoutes(g *echo.Group) {
+	g.GET("/:token", h.Feed)
+}
+
+func (h *Handler) GetFeed(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	feed, err := h.calendarService.GetFeed(userID)
+	if err != nil {
+		return h.feedError(err, "Failed to get calendar feed")
+	}
+
+	return c.JSON(http.StatusOK, feed)
+}
+
+// CreateFeed creates the feed, or replaces it with a new URL. The URL is only returned here.
+func (h *Handler) CreateFeed(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	req, err := h.bindRequest(c)
+	if err != nil {
+		return err
+	}
+
+	feed, err := h.calendarService.CreateFeed(userID, req)
+	if err != nil {
+		return h.feedError(err, "Failed to create calendar feed")
+	}
+
+	return c.JSON(http.StatusCreated, feed)
+}
+
+func (h *Handler) UpdateFeed(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	req, err := h.bindRequest(c)
+	if err != nil {
+		return err
+	}
+
+	feed, err := h.calendarService.UpdateFeed(userID, req)
+	if err != nil {
+		return h.feedError(err, "Failed to update calendar feed")
+	}
+
+	return c.JSON(http.StatusOK, feed)
+}
+
+func (h *Handler) DeleteFeed(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	if err := h.calendarService.DeleteFeed(userID); err != nil {
+		return h.feedError(err, "Failed to delete calendar feed")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// Feed serves the iCalendar document; the token in the URL identifies the feed
+func (h *Handler) Feed(c echo.Context) error {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	data, err := h.calendarService.Render(token)
+	if err != nil {
+		return h.feedError(err, "Failed to render calendar feed")
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, "inline; filename=\"kkal-tracker.ics\"")
+	c.Response().Header().Set("Cache-Control", "private, max-age=900")
+	return c.Blob(http.StatusOK, "text/calendar; charset=utf-8", data)
+}
+
+// bindRequest reads and validates the feed settings
+func (h *Handler) bindRequest(c echo.Context) (*calendarservice.FeedRequest, error) {
+	var req Request
+	if err := c.Bind(&req); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	return &calendarservice.FeedRequest{
+		Timezone: req.Timezone,
+		PastDays: req.PastDays,
+	}, nil
+}
+
+// feedError maps service errors to HTTP errors
+func (h *Handler) feedError(err error, message string) error {
+	switch {
+	case errors.Is(err, calendarservice.ErrFeedNotFound),
+		errors.Is(err, calendarservice.ErrInvalidToken):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, calendarservice.ErrInvalidTimezone),
+		errors.Is(err, calendarservice.ErrInvalidPastDays):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	h.logger.Error(message, "error", err)
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
@@ -1,12 +1,20 @@
 package calories
 
+import "ypeskov/kkal-tracker/internal/models"
+
 type CreateEntryRequest struct {
 	Food         string   `json:"food" validate:"required"`
-	Calories     int      `json:"calories" validate:"required,min=1"`
-	Weight       float64  `json:"weight" validate:"required,min=0.1"`
+	Calories     int      `json:"calories" validate:"required_without=Unit,omitempty,min=1"`
+	Weight       float64  `json:"weight" validate:"required_without=Unit,omitempty,min=0.1"`
 	KcalPer100g  float64  `json:"kcalPer100g" validate:"required,min=0.1"`
 	Fats         *float64 `json:"fats,omitempty"`
 	Carbs        *float64 `json:"carbs,omitempty"`
 	Proteins     *float64 `json:"proteins,omitempty"`
 	MealDatetime string   `json:"meal_datetime" validate:"required"`
+	// Extended nutrients per 100 g; when omitted, the ingredient's values are used
+	Nutrients models.Nutrients `json:"nutrients,omitempty"`
+	// Optional quantity in a serving or unit (e.g. 2 "egg", 1 "cup"); weight and calories
+	// are then computed on the server
+	Quantity *float64 `json:"quantity,omitempty" validate:"required_with=Unit,omitempty,gt=0"`
+	Unit     string   `json:"unit,omitempty" validate:"omitempty,max=50"`
 }
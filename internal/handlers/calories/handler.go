@@ -1,13 +1,17 @@
 package calories
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
+	"ypeskov/kkal-tracker/internal/handlers/etag"
 	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
 	calorieservice "ypeskov/kkal-tracker/internal/services/calorie"
+	ingredientservice "ypeskov/kkal-tracker/internal/services/ingredient"
 
 	"github.com/labstack/echo/v4"
 )
@@ -49,6 +53,28 @@ func (h *Handler) GetEntries(c echo.Context) error {
 	return c.JSON(http.StatusOK, entries)
 }
 
+// GetEntry returns one entry with its ETag
+func (h *Handler) GetEntry(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	entryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid entry ID")
+	}
+
+	entry, err := h.calorieService.GetEntry(entryID, userID)
+	if err != nil {
+		if errors.Is(err, calorieservice.ErrEntryNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Entry not found")
+		}
+		h.logger.Error("Failed to get calorie entry", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	etag.Set(c, entry.ID, entry.Version)
+	return c.JSON(http.StatusOK, entry)
+}
+
 func (h *Handler) CreateEntry(c echo.Context) error {
 	userID := c.Get("user_id").(int)
 
@@ -75,15 +101,22 @@ func (h *Handler) CreateEntry(c echo.Context) error {
 		Fats:         req.Fats,
 		Carbs:        req.Carbs,
 		Proteins:     req.Proteins,
+		Nutrients:    req.Nutrients,
 		MealDatetime: mealDatetime,
+		Quantity:     req.Quantity,
+		Unit:         req.Unit,
 	}
 
 	result, err := h.calorieService.CreateEntry(serviceReq)
 	if err != nil {
+		if isUnitError(err) || errors.Is(err, ingredientservice.ErrInvalidNutrients) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		h.logger.Error("Failed to create calorie entry", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
 	}
 
+	etag.Set(c, result.Entry.ID, result.Entry.Version)
 	return c.JSON(http.StatusCreated, result)
 }
 
@@ -96,8 +129,14 @@ func (h *Handler) DeleteEntry(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid entry ID")
 	}
 
-	err = h.calorieService.DeleteEntry(entryID, userID)
+	err = h.calorieService.DeleteEntry(entryID, userID, etag.IfMatch(c, entryID))
 	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, "Entry not found")
+		case errors.Is(err, repositories.ErrVersionConflict):
+			return h.entryConflict(c, entryID, userID)
+		}
 		h.logger.Error("Failed to delete calorie entry", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
 	}
@@ -138,21 +177,55 @@ func (h *Handler) UpdateEntry(c echo.Context) error {
 		Fats:         req.Fats,
 		Carbs:        req.Carbs,
 		Proteins:     req.Proteins,
+		Nutrients:    req.Nutrients,
 		MealDatetime: mealDatetime,
+		Quantity:     req.Quantity,
+		Unit:         req.Unit,
+		Version:      etag.IfMatch(c, entryID),
 	}
 
 	entry, err := h.calorieService.UpdateEntry(serviceReq)
 	if err != nil {
+		switch {
+		case isUnitError(err) || errors.Is(err, ingredientservice.ErrInvalidNutrients):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, repositories.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, "Entry not found")
+		case errors.Is(err, repositories.ErrVersionConflict):
+			return h.entryConflict(c, entryID, userID)
+		}
 		h.logger.Error("Failed to update calorie entry", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
 	}
 
+	etag.Set(c, entry.ID, entry.Version)
 	return c.JSON(http.StatusOK, entry)
 }
 
+// entryConflict answers a write made against an outdated version of an entry with the current entry
+func (h *Handler) entryConflict(c echo.Context, entryID, userID int) error {
+	current, err := h.calorieService.GetEntry(entryID, userID)
+	if err != nil {
+		if errors.Is(err, calorieservice.ErrEntryNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Entry not found")
+		}
+		h.logger.Error("Failed to get calorie entry", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+	return etag.PreconditionFailed(c, current.ID, current.Version, current)
+}
+
+// isUnitError reports whether a quantity could not be converted to grams
+func isUnitError(err error) bool {
+	return errors.Is(err, ingredientservice.ErrInvalidQuantity) ||
+		errors.Is(err, ingredientservice.ErrUnknownUnit) ||
+		errors.Is(err, ingredientservice.ErrDensityRequired)
+}
+
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.GET("", h.GetEntries)
 	g.POST("", h.CreateEntry)
+	g.GET("/:id", h.GetEntry)
 	g.PUT("/:id", h.UpdateEntry)
 	g.DELETE("/:id", h.DeleteEntry)
 }
@@ -0,0 +1,56 @@
+package changefeed
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	changefeedservice "ypeskov/kkal-tracker/internal/services/changefeed"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	changefeedService changefeedservice.Servicer
+	logger            *slog.Logger
+}
+
+func New(changefeedService changefeedservice.Servicer, logger *slog.Logger) *Handler {
+	return &Handler{
+		changefeedService: changefeedService,
+		logger:            logger.With("handler", "changefeed"),
+	}
+}
+
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.GET("/changes", h.GetChanges)
+}
+
+// GetChanges returns the calorie entries, weigh-ins and ingredients changed after the cursor
+func (h *Handler) GetChanges(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	limit := 0
+	if value := c.QueryParam("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid limit")
+		}
+	}
+
+	page, err := h.changefeedService.Changes(userID, c.QueryParam("cursor"), limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, changefeedservice.ErrInvalidCursor):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		case errors.Is(err, changefeedservice.ErrCursorExpired):
+			return echo.NewHTTPError(http.StatusGone, err.Error())
+		}
+		h.logger.Error("Failed to get changes", "user_id", userID, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get changes")
+	}
+
+	return c.JSON(http.StatusOK, page)
+}
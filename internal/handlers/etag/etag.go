@@ -0,0 +1,59 @@
+// Package etag builds and checks the ETags of versioned resources, so clients
+// can make updates and deletes conditional with If-Match.
+package etag
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderETag    = "ETag"
+	HeaderIfMatch = "If-Match"
+)
+
+// NoMatch is the version expected by an If-Match header that names another
+// resource or is not one of our ETags; no resource has it
+const NoMatch = -1
+
+// Format returns the ETag of a version of the resource with the given ID
+func Format(id, version int) string {
+	return fmt.Sprintf(`"%d-%d"`, id, version)
+}
+
+// Set adds the ETag of a resource to the response
+func Set(c echo.Context, id, version int) {
+	c.Response().Header().Set(HeaderETag, Format(id, version))
+}
+
+// IfMatch returns the version the request expects the resource with the given
+// ID to have. Without an If-Match header, or with "*", it returns 0: the
+// request is not conditional.
+func IfMatch(c echo.Context, id int) int {
+	header := strings.TrimSpace(c.Request().Header.Get(HeaderIfMatch))
+	if header == "" || header == "*" {
+		return 0
+	}
+
+	for _, value := range strings.Split(header, ",") {
+		var taggedID, version int
+		// Weak ETags never match under the strong comparison If-Match uses
+		if _, err := fmt.Sscanf(strings.TrimSpace(value), `"%d-%d"`, &taggedID, &version); err != nil {
+			continue
+		}
+		if taggedID == id && version > 0 {
+			return version
+		}
+	}
+	return NoMatch
+}
+
+// PreconditionFailed answers a conditional request made against an outdated
+// version with the current resource and its ETag
+func PreconditionFailed(c echo.Context, id, version int, current any) error {
+	Set(c, id, version)
+	return c.JSON(http.StatusPreconditionFailed, current)
+}
@@ -0,0 +1,82 @@
+package etag
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+func newContext(ifMatch string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPut, "/api/calories/42", nil)
+	if ifMatch != "" {
+		req.Header.Set(HeaderIfMatch, ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	return echo.New().NewContext(req, rec), rec
+}
+
+func TestFormat(t *testing.T) {
+	if got, want := Format(42, 7), `"42-7"`; got != want {
+		t.Errorf("Format(42, 7) = %s, want %s", got, want)
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    int
+	}{
+		{"no header", "", 0},
+		{"any version", "*", 0},
+		{"any version with spaces", "  *  ", 0},
+		{"our ETag", `"42-7"`, 7},
+		{"returned by Format", Format(42, 3), 3},
+		{"list", `"41-2", "42-5"`, 5},
+		{"list without spaces", `"1-1","42-9"`, 9},
+		{"another resource", `"41-7"`, NoMatch},
+		{"weak ETag", `W/"42-7"`, NoMatch},
+		{"unquoted", `42-7`, NoMatch},
+		{"not ours", `"abc"`, NoMatch},
+		{"version zero", `"42-0"`, NoMatch},
+		{"negative version", `"42--1"`, NoMatch},
+		{"garbage before a match", `xyz, "42-4"`, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newContext(tt.ifMatch)
+			if got := IfMatch(c, 42); got != tt.want {
+				t.Errorf("IfMatch(%q) = %d, want %d", tt.ifMatch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	c, rec := newContext("")
+	Set(c, 42, 7)
+	if got := rec.Header().Get(HeaderETag); got != `"42-7"` {
+		t.Errorf("ETag = %s, want %s", got, `"42-7"`)
+	}
+}
+
+func TestPreconditionFailed(t *testing.T) {
+	c, rec := newContext(`"42-6"`)
+	if err := PreconditionFailed(c, 42, 7, map[string]int{"version": 7}); err != nil {
+		t.Fatalf("PreconditionFailed: %v", err)
+	}
+
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusPreconditionFailed)
+	}
+	if got := rec.Header().Get(HeaderETag); got != `"42-7"` {
+		t.Errorf("ETag = %s, want the current version", got)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != `{"version":7}` {
+		t.Errorf("body = %s, want the current resource", body)
+	}
+}
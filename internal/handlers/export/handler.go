@@ -1,9 +1,11 @@
 package export
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"ypeskov/kkal-tracker/internal/repositories"
 	exportservice "ypeskov/kkal-tracker/internal/services/export"
@@ -21,6 +23,7 @@ type Request struct {
 	DateFrom     string `json:"date_from" validate:"required,datetime=2006-01-02"`
 	DateTo       string `json:"date_to" validate:"required,datetime=2006-01-02"`
 	DataType     string `json:"data_type" validate:"required,oneof=weight food both"`
+	Format       string `json:"format" validate:"omitempty,oneof=xlsx csv ndjson pdf"` // Defaults to xlsx
 	DeliveryType string `json:"delivery_type" validate:"required,oneof=download email"`
 }
 
@@ -32,27 +35,24 @@ func New(exportService exportservice.Servicer, userRepo repositories.UserReposit
 	}
 }
 
-// Export handles data export requests
-func (h *Handler) Export(c echo.Context) error {
-	userID := c.Get("user_id").(int)
-	h.logger.Debug("Export called", "user_id", userID)
-
+// bindRequest reads and validates an export request and fills in the user's email and language
+func (h *Handler) bindRequest(c echo.Context, userID int) (*exportservice.ExportRequest, error) {
 	var req Request
 	if err := c.Bind(&req); err != nil {
 		h.logger.Error("Failed to bind request", "error", err)
-		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
 
 	if err := c.Validate(req); err != nil {
 		h.logger.Error("Validation failed", "error", err)
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
 	// Get user info for email and language
 	user, err := h.userRepo.GetByID(userID)
 	if err != nil {
 		h.logger.Error("Failed to get user", "error", err)
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user info")
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user info")
 	}
 
 	// Determine language
@@ -61,39 +61,145 @@ func (h *Handler) Export(c echo.Context) error {
 		language = *user.Language
 	}
 
-	// Build service request
-	serviceReq := &exportservice.ExportRequest{
+	return &exportservice.ExportRequest{
 		UserID:       userID,
 		DateFrom:     req.DateFrom,
 		DateTo:       req.DateTo,
 		DataType:     exportservice.ExportDataType(req.DataType),
+		Format:       exportservice.Format(req.Format),
 		DeliveryType: exportservice.DeliveryType(req.DeliveryType),
 		Language:     language,
 		UserEmail:    user.Email,
+	}, nil
+}
+
+// Export handles data export requests. Downloads are streamed in the request;
+// email delivery is queued for the background worker.
+func (h *Handler) Export(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("Export called", "user_id", userID)
+
+	serviceReq, err := h.bindRequest(c, userID)
+	if err != nil {
+		return err
 	}
 
-	// Call export service
-	result, err := h.exportService.Export(serviceReq)
+	// Downloads are streamed to the client as they are written
+	if serviceReq.DeliveryType == exportservice.DeliveryDownload {
+		stream, err := h.exportService.Stream(serviceReq)
+		if err != nil {
+			h.logger.Error("Export failed", "error", err, "user_id", userID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Export failed")
+		}
+
+		c.Response().Header().Set(echo.HeaderContentType, stream.ContentType)
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"%s\"", stream.FileName))
+		c.Response().WriteHeader(http.StatusOK)
+		if err := stream.Write(c.Response()); err != nil {
+			// Headers are already sent, so the client only sees a truncated file
+			h.logger.Error("Export stream failed", "error", err, "user_id", userID)
+		}
+		return nil
+	}
+
+	job, err := h.exportService.EnqueueJob(serviceReq)
 	if err != nil {
-		h.logger.Error("Export failed", "error", err, "user_id", userID)
+		h.logger.Error("Failed to queue export", "error", err, "user_id", userID)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Export failed")
 	}
 
-	// If email delivery, return success message
-	if req.DeliveryType == "email" {
-		return c.JSON(http.StatusOK, map[string]string{
-			"message": "Export sent to email",
-		})
+	return c.JSON(http.StatusAccepted, map[string]any{
+		"message": "Export will be sent to email",
+		"job":     job,
+	})
+}
+
+// CreateJob queues an export for the background worker
+func (h *Handler) CreateJob(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("CreateJob called", "user_id", userID)
+
+	serviceReq, err := h.bindRequest(c, userID)
+	if err != nil {
+		return err
 	}
 
-	// For download, return the file
-	c.Response().Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", result.FileName))
+	job, err := h.exportService.EnqueueJob(serviceReq)
+	if err != nil {
+		h.logger.Error("Failed to queue export", "error", err, "user_id", userID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to queue export")
+	}
+
+	return c.JSON(http.StatusAccepted, job)
+}
+
+// ListJobs returns the user's recent export jobs
+func (h *Handler) ListJobs(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("ListJobs called", "user_id", userID)
+
+	jobs, err := h.exportService.ListJobs(userID)
+	if err != nil {
+		h.logger.Error("Failed to list export jobs", "error", err, "user_id", userID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list export jobs")
+	}
+
+	return c.JSON(http.StatusOK, jobs)
+}
+
+// GetJob returns the status of an export job
+func (h *Handler) GetJob(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	jobID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid job ID")
+	}
+	h.logger.Debug("GetJob called", "user_id", userID, "job_id", jobID)
+
+	job, err := h.exportService.GetJob(userID, jobID)
+	if err != nil {
+		if errors.Is(err, exportservice.ErrJobNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Export job not found")
+		}
+		h.logger.Error("Failed to get export job", "error", err, "user_id", userID, "job_id", jobID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get export job")
+	}
+
+	return c.JSON(http.StatusOK, job)
+}
+
+// DownloadJob returns the file of a finished export job
+func (h *Handler) DownloadJob(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	jobID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid job ID")
+	}
+	h.logger.Debug("DownloadJob called", "user_id", userID, "job_id", jobID)
+
+	result, err := h.exportService.GetJobFile(userID, jobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, exportservice.ErrJobNotFound), errors.Is(err, exportservice.ErrJobNoFile):
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		case errors.Is(err, exportservice.ErrJobNotReady), errors.Is(err, exportservice.ErrJobFailed):
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		case errors.Is(err, exportservice.ErrJobExpired):
+			return echo.NewHTTPError(http.StatusGone, err.Error())
+		}
+		h.logger.Error("Failed to get export file", "error", err, "user_id", userID, "job_id", jobID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get export file")
+	}
 
-	return c.Blob(http.StatusOK, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", result.FileBytes)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"%s\"", result.FileName))
+	return c.Blob(http.StatusOK, result.ContentType, result.FileBytes)
 }
 
 // RegisterRoutes registers all export-related routes
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.POST("", h.Export)
+	g.POST("/jobs", h.CreateJob)
+	g.GET("/jobs", h.ListJobs)
+	g.GET("/jobs/:id", h.GetJob)
+	g.GET("/jobs/:id/download", h.DownloadJob)
 }
@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	importservice "ypeskov/kkal-tracker/internal/services/importer"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MaxFileSize is the largest export accepted for import
+const MaxFileSize = 10 << 20
+
+type Handler struct {
+	importService importservice.Servicer
+	logger        *slog.Logger
+}
+
+func New(importService importservice.Servicer, logger *slog.Logger) *Handler {
+	return &Handler{
+		importService: importService,
+		logger:        logger.With("handler", "import"),
+	}
+}
+
+// Import handles an uploaded diary export (CSV from another app or our own Excel
+// export) sent as multipart form data with the fields file, format (optional),
+// timezone (optional) and dry_run (optional)
+func (h *Handler) Import(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("Import called", "user_id", userID)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "file is required")
+	}
+	if fileHeader.Size > MaxFileSize {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "file must be at most 10 MB")
+	}
+
+	format := importservice.Format(c.FormValue("format"))
+	switch format {
+	case "", "auto":
+		format = ""
+	case importservice.FormatMyFitnessPal, importservice.FormatCronometer, importservice.FormatLoseIt, importservice.FormatExcel:
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, "format must be auto, myfitnesspal, cronometer, loseit or excel")
+	}
+
+	location := time.UTC
+	if timezone := c.FormValue("timezone"); timezone != "" {
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid timezone")
+		}
+	}
+
+	dryRun := false
+	if value := c.FormValue("dry_run"); value != "" {
+		dryRun, err = strconv.ParseBool(value)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "dry_run must be true or false")
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		h.logger.Error("Failed to open uploaded file", "error", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to read file")
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, MaxFileSize+1))
+	if err != nil {
+		h.logger.Error("Failed to read uploaded file", "error", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "Failed to read file")
+	}
+	if len(data) > MaxFileSize {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, "file must be at most 10 MB")
+	}
+
+	result, err := h.importService.Import(&importservice.ImportRequest{
+		UserID:   userID,
+		Data:     data,
+		Format:   format,
+		Location: location,
+		DryRun:   dryRun,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, importservice.ErrEmptyFile),
+			errors.Is(err, importservice.ErrInvalidFile),
+			errors.Is(err, importservice.ErrUnknownFormat),
+			errors.Is(err, importservice.ErrTooManyRows):
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		h.logger.Error("Import failed", "error", err, "user_id", userID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Import failed")
+	}
+
+	status := http.StatusOK
+	if !dryRun && result.Created > 0 {
+		status = http.StatusCreated
+	}
+	return c.JSON(status, result)
+}
+
+// RegisterRoutes registers all import-related routes
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.POST("", h.Import)
+}
@@ -2,10 +2,12 @@ package ingredients
 
 import (
 	"database/sql"
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
 
+	"ypeskov/kkal-tracker/internal/models"
 	ingredientservice "ypeskov/kkal-tracker/internal/services/ingredient"
 
 	"github.com/labstack/echo/v4"
@@ -29,6 +31,10 @@ type CreateRequest struct {
 	Fats        *float64 `json:"fats,omitempty" validate:"omitempty,min=0"`
 	Carbs       *float64 `json:"carbs,omitempty" validate:"omitempty,min=0"`
 	Proteins    *float64 `json:"proteins,omitempty" validate:"omitempty,min=0"`
+	// Extended nutrients per 100 g keyed by code; omitted codes are unknown
+	Nutrients models.Nutrients `json:"nutrients,omitempty"`
+	// EAN/UPC barcode; an empty string clears it
+	Barcode *string `json:"barcode,omitempty"`
 }
 
 type UpdateRequest struct {
@@ -37,6 +43,10 @@ type UpdateRequest struct {
 	Fats        *float64 `json:"fats,omitempty" validate:"omitempty,min=0"`
 	Carbs       *float64 `json:"carbs,omitempty" validate:"omitempty,min=0"`
 	Proteins    *float64 `json:"proteins,omitempty" validate:"omitempty,min=0"`
+	// Extended nutrients per 100 g keyed by code; omitted codes are unknown
+	Nutrients models.Nutrients `json:"nutrients,omitempty"`
+	// EAN/UPC barcode; an empty string clears it
+	Barcode *string `json:"barcode,omitempty"`
 }
 
 // GetAllIngredients Get all user ingredients for session storage caching
@@ -100,10 +110,18 @@ func (h *Handler) CreateIngredient(c echo.Context) error {
 		Fats:        req.Fats,
 		Carbs:       req.Carbs,
 		Proteins:    req.Proteins,
+		Nutrients:   req.Nutrients,
+		Barcode:     req.Barcode,
 	}
 
 	ingredient, err := h.ingredientService.CreateIngredient(serviceReq)
 	if err != nil {
+		if errors.Is(err, ingredientservice.ErrInvalidNutrients) || errors.Is(err, ingredientservice.ErrInvalidBarcode) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, ingredientservice.ErrBarcodeTaken) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
 		h.logger.Error("Failed to create user ingredient", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
 	}
@@ -141,10 +159,18 @@ func (h *Handler) UpdateIngredient(c echo.Context) error {
 		Fats:         req.Fats,
 		Carbs:        req.Carbs,
 		Proteins:     req.Proteins,
+		Nutrients:    req.Nutrients,
+		Barcode:      req.Barcode,
 	}
 
 	ingredient, err := h.ingredientService.UpdateIngredient(serviceReq)
 	if err != nil {
+		if errors.Is(err, ingredientservice.ErrInvalidNutrients) || errors.Is(err, ingredientservice.ErrInvalidBarcode) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, ingredientservice.ErrBarcodeTaken) {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
 		if err == sql.ErrNoRows {
 			return echo.NewHTTPError(http.StatusNotFound, "Ingredient not found")
 		}
@@ -179,8 +205,345 @@ func (h *Handler) DeleteIngredient(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// SearchIngredients Search user ingredients ranked by match quality and usage
+func (h *Handler) SearchIngredients(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	query := c.QueryParam("q")
+	cursor := c.QueryParam("cursor")
+	h.logger.Debug("SearchIngredients called", "user_id", userID, "query", query, "cursor", cursor)
+
+	limit, err := parseLimit(c.QueryParam("limit"))
+	if err != nil {
+		h.logger.Debug("SearchIngredients failed - invalid limit", "user_id", userID, "limit_param", c.QueryParam("limit"), "error", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid limit")
+	}
+
+	page, err := h.ingredientService.SearchIngredients(&ingredientservice.SearchRequest{
+		UserID: userID,
+		Query:  query,
+		Limit:  limit,
+		Cursor: cursor,
+	})
+	if err != nil {
+		if errors.Is(err, ingredientservice.ErrEmptyQuery) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Search query is required")
+		}
+		if errors.Is(err, ingredientservice.ErrInvalidCursor) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid cursor")
+		}
+		h.logger.Error("Failed to search user ingredients", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	h.logger.Debug("SearchIngredients returning results", "user_id", userID, "count", len(page.Items))
+	return c.JSON(http.StatusOK, page)
+}
+
+// GetRecentIngredients Get the user's most frequently and recently used ingredients
+func (h *Handler) GetRecentIngredients(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("GetRecentIngredients called", "user_id", userID)
+
+	limit, err := parseLimit(c.QueryParam("limit"))
+	if err != nil {
+		h.logger.Debug("GetRecentIngredients failed - invalid limit", "user_id", userID, "limit_param", c.QueryParam("limit"), "error", err)
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid limit")
+	}
+
+	ingredients, err := h.ingredientService.GetRecentIngredients(userID, limit)
+	if err != nil {
+		h.logger.Error("Failed to get recent user ingredients", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	h.logger.Debug("GetRecentIngredients returning ingredients", "user_id", userID, "count", len(ingredients))
+	return c.JSON(http.StatusOK, ingredients)
+}
+
+// SyncIngredients Bring the user's copies of catalog ingredients up to date
+func (h *Handler) SyncIngredients(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("SyncIngredients called", "user_id", userID)
+
+	report, err := h.ingredientService.SyncWithCatalog(userID)
+	if err != nil {
+		h.logger.Error("Failed to sync user ingredients", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	h.logger.Debug("SyncIngredients successful", "user_id", userID, "updated", report.Updated, "renamed", report.Renamed)
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetPendingUpdates Get catalog updates for ingredients the user customized
+func (h *Handler) GetPendingUpdates(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("GetPendingUpdates called", "user_id", userID)
+
+	updates, err := h.ingredientService.GetPendingUpdates(userID)
+	if err != nil {
+		h.logger.Error("Failed to get pending catalog updates", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, updates)
+}
+
+// ApplyPendingUpdate Replace a customized ingredient with the catalog values
+func (h *Handler) ApplyPendingUpdate(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	ingredientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ingredient ID")
+	}
+	h.logger.Debug("ApplyPendingUpdate called", "user_id", userID, "ingredient_id", ingredientID)
+
+	ingredient, err := h.ingredientService.ApplyPendingUpdate(userID, ingredientID)
+	if err != nil {
+		if errors.Is(err, ingredientservice.ErrNoPendingUpdate) {
+			return echo.NewHTTPError(http.StatusNotFound, "No pending update for this ingredient")
+		}
+		if errors.Is(err, ingredientservice.ErrNameConflict) {
+			return echo.NewHTTPError(http.StatusConflict, "An ingredient with the catalog name already exists")
+		}
+		if errors.Is(err, ingredientservice.ErrIngredientChanged) {
+			return echo.NewHTTPError(http.StatusConflict, "Ingredient was changed, reload it and try again")
+		}
+		h.logger.Error("Failed to apply catalog update", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, ingredient)
+}
+
+// DismissPendingUpdate Keep the customized ingredient and hide the catalog update
+func (h *Handler) DismissPendingUpdate(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	ingredientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ingredient ID")
+	}
+	h.logger.Debug("DismissPendingUpdate called", "user_id", userID, "ingredient_id", ingredientID)
+
+	if err := h.ingredientService.DismissPendingUpdate(userID, ingredientID); err != nil {
+		if errors.Is(err, ingredientservice.ErrNoPendingUpdate) {
+			return echo.NewHTTPError(http.StatusNotFound, "No pending update for this ingredient")
+		}
+		if errors.Is(err, ingredientservice.ErrIngredientChanged) {
+			return echo.NewHTTPError(http.StatusConflict, "Ingredient was changed, reload it and try again")
+		}
+		h.logger.Error("Failed to dismiss catalog update", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RelocalizeIngredients Rename catalog ingredients to the given language and add missing ones
+func (h *Handler) RelocalizeIngredients(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("RelocalizeIngredients called", "user_id", userID)
+
+	var req struct {
+		Language string `json:"language" validate:"required,oneof=en_US uk_UA ru_UA bg_BG"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	report, err := h.ingredientService.Relocalize(&ingredientservice.RelocalizeRequest{
+		UserID:   userID,
+		Language: req.Language,
+	})
+	if err != nil {
+		if errors.Is(err, ingredientservice.ErrUnsupportedLanguage) {
+			return echo.NewHTTPError(http.StatusBadRequest, "Unsupported language")
+		}
+		h.logger.Error("Failed to relocalize user ingredients", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	h.logger.Debug("RelocalizeIngredients successful", "user_id", userID, "renamed", len(report.Renamed), "added", len(report.Added))
+	return c.JSON(http.StatusOK, report)
+}
+
+// GetIngredientUnits Get the servings and density of an ingredient
+func (h *Handler) GetIngredientUnits(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	ingredientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ingredient ID")
+	}
+	h.logger.Debug("GetIngredientUnits called", "user_id", userID, "ingredient_id", ingredientID)
+
+	units, err := h.ingredientService.GetIngredientUnits(userID, ingredientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Ingredient not found")
+		}
+		h.logger.Error("Failed to get ingredient units", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, units)
+}
+
+// SetIngredientUnits Replace the servings and density of an ingredient
+func (h *Handler) SetIngredientUnits(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	ingredientID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid ingredient ID")
+	}
+	h.logger.Debug("SetIngredientUnits called", "user_id", userID, "ingredient_id", ingredientID)
+
+	var req struct {
+		DensityGPerML *float64 `json:"density_g_per_ml"`
+		Servings      []struct {
+			Name  string  `json:"name"`
+			Grams float64 `json:"grams"`
+		} `json:"servings"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+
+	serviceReq := &ingredientservice.SetUnitsRequest{
+		UserID:        userID,
+		IngredientID:  ingredientID,
+		DensityGPerML: req.DensityGPerML,
+	}
+	for _, serving := range req.Servings {
+		serviceReq.Servings = append(serviceReq.Servings, ingredientservice.ServingInput{Name: serving.Name, Grams: serving.Grams})
+	}
+
+	units, err := h.ingredientService.SetIngredientUnits(serviceReq)
+	if err != nil {
+		if errors.Is(err, ingredientservice.ErrInvalidServing) || errors.Is(err, ingredientservice.ErrInvalidDensity) ||
+			errors.Is(err, ingredientservice.ErrInvalidIngredientID) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Ingredient not found")
+		}
+		h.logger.Error("Failed to set ingredient units", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	h.logger.Debug("SetIngredientUnits successful", "user_id", userID, "ingredient_id", ingredientID, "servings", len(units.Servings))
+	return c.JSON(http.StatusOK, units)
+}
+
+// LookupBarcode Get the food for a scanned barcode: the user's own ingredient first, then
+// the global catalog. The optional lang query parameter picks the catalog name language.
+func (h *Handler) LookupBarcode(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	code := c.Param("code")
+	h.logger.Debug("LookupBarcode called", "user_id", userID, "barcode", code)
+
+	result, err := h.ingredientService.LookupBarcode(userID, code, c.QueryParam("lang"))
+	if err != nil {
+		if errors.Is(err, ingredientservice.ErrInvalidBarcode) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, ingredientservice.ErrBarcodeNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		h.logger.Error("Failed to look up barcode", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// GetNutrientCatalog Get the extended nutrients that can be tracked, with units and limits
+func (h *Handler) GetNutrientCatalog(c echo.Context) error {
+	return c.JSON(http.StatusOK, models.NutrientCatalog)
+}
+
+// FindDuplicates Get groups of ingredients that likely describe the same food
+func (h *Handler) FindDuplicates(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("FindDuplicates called", "user_id", userID)
+
+	groups, err := h.ingredientService.FindDuplicates(userID)
+	if err != nil {
+		h.logger.Error("Failed to find duplicate ingredients", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	return c.JSON(http.StatusOK, groups)
+}
+
+// MergeIngredients Merge duplicate ingredients into the kept one
+func (h *Handler) MergeIngredients(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	var req struct {
+		KeepID   int   `json:"keep_id" validate:"required"`
+		MergeIDs []int `json:"merge_ids" validate:"required,min=1"`
+	}
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request")
+	}
+	if err := c.Validate(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	h.logger.Debug("MergeIngredients called", "user_id", userID, "keep_id", req.KeepID, "merge_ids", req.MergeIDs)
+
+	result, err := h.ingredientService.MergeIngredients(&ingredientservice.MergeRequest{
+		UserID:   userID,
+		KeepID:   req.KeepID,
+		MergeIDs: req.MergeIDs,
+	})
+	if err != nil {
+		if errors.Is(err, ingredientservice.ErrInvalidMerge) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return echo.NewHTTPError(http.StatusNotFound, "Ingredient not found")
+		}
+		h.logger.Error("Failed to merge user ingredients", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+	}
+
+	h.logger.Debug("MergeIngredients successful", "user_id", userID, "keep_id", req.KeepID, "entries_renamed", result.EntriesRenamed)
+	return c.JSON(http.StatusOK, result)
+}
+
+// parseLimit parses an optional positive limit query parameter; 0 means default
+func parseLimit(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if limit < 1 {
+		return 0, errors.New("limit must be positive")
+	}
+	return limit, nil
+}
+
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.GET("", h.GetAllIngredients)
+	g.GET("/search", h.SearchIngredients)
+	g.GET("/recent", h.GetRecentIngredients)
+	g.GET("/nutrients", h.GetNutrientCatalog)
+	g.GET("/barcode/:code", h.LookupBarcode)
+	g.POST("/sync", h.SyncIngredients)
+	g.POST("/relocalize", h.RelocalizeIngredients)
+	g.GET("/duplicates", h.FindDuplicates)
+	g.POST("/merge", h.MergeIngredients)
+	g.GET("/updates", h.GetPendingUpdates)
+	g.POST("/:id/updates/apply", h.ApplyPendingUpdate)
+	g.POST("/:id/updates/dismiss", h.DismissPendingUpdate)
+	g.GET("/:id/units", h.GetIngredientUnits)
+	g.PUT("/:id/units", h.SetIngredientUnits)
 	g.GET("/:id", h.GetIngredientByID)
 	g.POST("", h.CreateIngredient)
 	g.PUT("/:id", h.UpdateIngredient)
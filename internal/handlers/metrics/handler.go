@@ -1,6 +1,7 @@
 package metrics
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 
@@ -36,7 +37,25 @@ func (h *Handler) GetHealthMetrics(c echo.Context) error {
 	return c.JSON(http.StatusOK, metrics)
 }
 
+// GetDailyTargets returns recommended daily calorie and macronutrient targets
+func (h *Handler) GetDailyTargets(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	h.logger.Debug("GetDailyTargets called", "user_id", userID)
+
+	targets, err := h.metricsService.GetDailyTargets(userID)
+	if err != nil {
+		if errors.Is(err, metricsservice.ErrInsufficientData) {
+			return echo.NewHTTPError(http.StatusUnprocessableEntity, "Age, height, gender and at least one weight entry are required")
+		}
+		h.logger.Error("Failed to get daily targets", "user_id", userID, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get daily targets")
+	}
+
+	return c.JSON(http.StatusOK, targets)
+}
+
 // RegisterRoutes registers the metrics routes
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.GET("/metrics", h.GetHealthMetrics)
+	g.GET("/metrics/targets", h.GetDailyTargets)
 }
@@ -5,6 +5,8 @@ import (
 	"log/slog"
 	"net/http"
 
+	"ypeskov/kkal-tracker/internal/handlers/etag"
+	"ypeskov/kkal-tracker/internal/repositories"
 	profileservice "ypeskov/kkal-tracker/internal/services/profile"
 
 	"github.com/labstack/echo/v4"
@@ -35,6 +37,7 @@ func (h *Handler) GetProfile(c echo.Context) error {
 	}
 
 	h.logger.Debug("GetProfile returning profile", "user_id", userID, "email", profile.Email)
+	etag.Set(c, userID, profile.Version)
 	return c.JSON(http.StatusOK, profile)
 }
 
@@ -66,18 +69,41 @@ func (h *Handler) UpdateProfile(c echo.Context) error {
 	// Always use current email - users cannot change their login email
 	// Service layer still supports email updates for potential future admin functionality
 	req.Email = currentProfile.Email
+	req.Version = etag.IfMatch(c, userID)
 
 	h.logger.Debug("UpdateProfile request", "user_id", userID, "email", req.Email, "first_name", req.FirstName, "last_name", req.LastName)
 
 	// Pass DTO directly to service - service handles conversion
-	if err := h.profileService.UpdateProfile(userID, &req); err != nil {
+	report, err := h.profileService.UpdateProfile(userID, &req)
+	if err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			return h.profileConflict(c, userID)
+		}
 		h.logger.Error("Failed to update profile", "user_id", userID, "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update profile")
 	}
 
 	// Return updated profile
+	profile, err := h.profileService.GetProfile(userID)
+	if err != nil {
+		h.logger.Error("Failed to get user profile", "user_id", userID, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user profile")
+	}
+	profile.IngredientRelocalization = report
+
 	h.logger.Debug("UpdateProfile successful", "user_id", userID)
-	return h.GetProfile(c)
+	etag.Set(c, userID, profile.Version)
+	return c.JSON(http.StatusOK, profile)
+}
+
+// profileConflict answers an update made against an outdated version of the profile with the current profile
+func (h *Handler) profileConflict(c echo.Context, userID int) error {
+	current, err := h.profileService.GetProfile(userID)
+	if err != nil {
+		h.logger.Error("Failed to get user profile", "user_id", userID, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get user profile")
+	}
+	return etag.PreconditionFailed(c, userID, current.Version, current)
 }
 
 // SetWeightGoal sets a weight goal for the current user
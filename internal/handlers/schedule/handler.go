@@ -0,0 +1,167 @@
+package schedule
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+	scheduleservice "ypeskov/kkal-tracker/internal/services/schedule"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	scheduleService scheduleservice.Servicer
+	userRepo        repositories.UserRepository
+	logger          *slog.Logger
+}
+
+type Request struct {
+	Kind       string `json:"kind" validate:"required,oneof=export digest"`
+	Frequency  string `json:"frequency" validate:"required,oneof=daily weekly monthly"`
+	DayOfWeek  *int   `json:"day_of_week" validate:"omitempty,min=0,max=6"`   // Required for weekly schedules, 0 = Sunday
+	DayOfMonth *int   `json:"day_of_month" validate:"omitempty,min=1,max=31"` // Required for monthly schedules
+	Hour       int    `json:"hour" validate:"min=0,max=23"`
+	Timezone   string `json:"timezone" validate:"omitempty,max=64"` // IANA name, defaults to UTC
+	DataType   string `json:"data_type" validate:"omitempty,oneof=weight food both"`
+	Format     string `json:"format" validate:"omitempty,oneof=xlsx csv ndjson pdf"`
+	Enabled    *bool  `json:"enabled"` // Defaults to true
+}
+
+func New(scheduleService scheduleservice.Servicer, userRepo repositories.UserRepository, logger *slog.Logger) *Handler {
+	return &Handler{
+		scheduleService: scheduleService,
+		userRepo:        userRepo,
+		logger:          logger.With("handler", "schedule"),
+	}
+}
+
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.GET("", h.List)
+	g.POST("", h.Create)
+	g.PUT("/:id", h.Update)
+	g.DELETE("/:id", h.Delete)
+}
+
+// RegisterUnsubscribeRoutes registers the public pages unsubscribe links in emails point to
+func (h *Handler) RegisterUnsubscribeRoutes(g *echo.Group) {
+	g.GET("/:token", h.UnsubscribePage)
+	g.POST("/:token", h.Unsubscribe)
+}
+
+func (h *Handler) List(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	schedules, err := h.scheduleService.List(userID)
+	if err != nil {
+		h.logger.Error("Failed to list schedules", "error", err, "user_id", userID)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list schedules")
+	}
+
+	return c.JSON(http.StatusOK, schedules)
+}
+
+func (h *Handler) Create(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	req, err := h.bindRequest(c)
+	if err != nil {
+		return err
+	}
+
+	schedule, err := h.scheduleService.Create(userID, req)
+	if err != nil {
+		return h.scheduleError(err, "Failed to create schedule")
+	}
+
+	return c.JSON(http.StatusCreated, schedule)
+}
+
+func (h *Handler) Update(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid schedule ID")
+	}
+
+	req, err := h.bindRequest(c)
+	if err != nil {
+		return err
+	}
+
+	schedule, err := h.scheduleService.Update(userID, id, req)
+	if err != nil {
+		return h.scheduleError(err, "Failed to update schedule")
+	}
+
+	return c.JSON(http.StatusOK, schedule)
+}
+
+func (h *Handler) Delete(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid schedule ID")
+	}
+
+	if err := h.scheduleService.Delete(userID, id); err != nil {
+		return h.scheduleError(err, "Failed to delete schedule")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// bindRequest reads and validates a schedule request
+func (h *Handler) bindRequest(c echo.Context) (*scheduleservice.ScheduleRequest, error) {
+	var req Request
+	if err := c.Bind(&req); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	return &scheduleservice.ScheduleRequest{
+		Kind:       req.Kind,
+		Frequency:  req.Frequency,
+		DayOfWeek:  req.DayOfWeek,
+		DayOfMonth: req.DayOfMonth,
+		Hour:       req.Hour,
+		Timezone:   req.Timezone,
+		DataType:   req.DataType,
+		Format:     req.Format,
+		Enabled:    enabled,
+	}, nil
+}
+
+// scheduleError maps service errors to HTTP errors
+func (h *Handler) scheduleError(err error, message string) error {
+	switch {
+	case errors.Is(err, scheduleservice.ErrScheduleNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, scheduleservice.ErrInvalidTimezone),
+		errors.Is(err, scheduleservice.ErrDayOfWeekRequired),
+		errors.Is(err, scheduleservice.ErrDayOfMonthRequired):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	h.logger.Error(message, "error", err)
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
+
+// language returns the language of a schedule's owner for the unsubscribe pages
+func (h *Handler) language(schedule *models.ExportSchedule) string {
+	user, err := h.userRepo.GetByID(schedule.UserID)
+	if err != nil || user.Language == nil {
+		return "en_US"
+	}
+	return *user.Language
+}
@@ -0,0 +1,121 @@
+package schedule
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+
+	"ypeskov/kkal-tracker/internal/i18n"
+	"ypeskov/kkal-tracker/internal/models"
+	scheduleservice "ypeskov/kkal-tracker/internal/services/schedule"
+
+	"github.com/labstack/echo/v4"
+)
+
+// unsubscribeTemplate is the page unsubscribe links open. It asks for
+// confirmation, so link scanners that follow URLs in emails do not
+// unsubscribe anybody; mail clients use the one-click POST instead.
+const unsubscribeTemplate = `<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <title>{{t "email.unsubscribe.title"}}</title>
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+    <div style="background-color: #f8f9fa; padding: 20px; border-radius: 8px;">
+        <h1 style="color: #3b82f6; margin-bottom: 20px;">{{t "email.unsubscribe.title"}}</h1>
+        <p>{{.Message}}</p>
+        {{if .Confirm}}
+        <form method="POST">
+            <button type="submit" style="background-color: #3b82f6; color: white; padding: 12px 30px; border: none; border-radius: 6px; font-size: 16px; cursor: pointer;">
+                {{t "email.unsubscribe.confirm"}}
+            </button>
+        </form>
+        {{end}}
+    </div>
+</body>
+</html>
+`
+
+// unsubscribePageData contains data for the unsubscribe page
+type unsubscribePageData struct {
+	Message string
+	Confirm bool
+}
+
+// UnsubscribePage asks the user to confirm turning off a scheduled email
+func (h *Handler) UnsubscribePage(c echo.Context) error {
+	schedule, err := h.scheduleService.GetByUnsubscribeToken(c.Param("token"))
+	if err != nil {
+		return h.unsubscribeError(c, err)
+	}
+
+	language := h.language(schedule)
+	t := i18n.GetTranslator()
+	if !schedule.Enabled {
+		return h.renderUnsubscribePage(c, http.StatusOK, language, unsubscribePageData{
+			Message: t.Get(language, "email.unsubscribe.done"),
+		})
+	}
+
+	key := "email.unsubscribe.question_export"
+	if schedule.Kind == models.ScheduleKindDigest {
+		key = "email.unsubscribe.question_digest"
+	}
+	return h.renderUnsubscribePage(c, http.StatusOK, language, unsubscribePageData{
+		Message: t.Get(language, key),
+		Confirm: true,
+	})
+}
+
+// Unsubscribe turns off a scheduled email. It serves both the confirmation
+// form and one-click unsubscribe requests from mail clients (RFC 8058).
+func (h *Handler) Unsubscribe(c echo.Context) error {
+	token := c.Param("token")
+	schedule, err := h.scheduleService.GetByUnsubscribeToken(token)
+	if err != nil {
+		return h.unsubscribeError(c, err)
+	}
+
+	if err := h.scheduleService.Unsubscribe(token); err != nil {
+		return h.unsubscribeError(c, err)
+	}
+
+	language := h.language(schedule)
+	return h.renderUnsubscribePage(c, http.StatusOK, language, unsubscribePageData{
+		Message: i18n.GetTranslator().Get(language, "email.unsubscribe.done"),
+	})
+}
+
+// unsubscribeError shows an invalid link as a page, since users open it from an email
+func (h *Handler) unsubscribeError(c echo.Context, err error) error {
+	if errors.Is(err, scheduleservice.ErrInvalidToken) {
+		return h.renderUnsubscribePage(c, http.StatusNotFound, "en_US", unsubscribePageData{
+			Message: i18n.GetTranslator().Get("en_US", "email.unsubscribe.invalid"),
+		})
+	}
+	h.logger.Error("Failed to unsubscribe", "error", err)
+	return echo.NewHTTPError(http.StatusInternalServerError, "Failed to unsubscribe")
+}
+
+// renderUnsubscribePage renders the unsubscribe page in the given language
+func (h *Handler) renderUnsubscribePage(c echo.Context, status int, language string, data unsubscribePageData) error {
+	translator := i18n.GetTranslator()
+	tmpl, err := template.New("unsubscribe").Funcs(template.FuncMap{
+		"t": func(key string) string { return translator.Get(language, key) },
+	}).Parse(unsubscribeTemplate)
+	if err != nil {
+		h.logger.Error("Failed to parse unsubscribe template", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to render page")
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		h.logger.Error("Failed to execute unsubscribe template", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to render page")
+	}
+
+	return c.HTMLBlob(status, body.Bytes())
+}
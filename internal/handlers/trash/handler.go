@@ -0,0 +1,105 @@
+package trash
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"ypeskov/kkal-tracker/internal/handlers/etag"
+	"ypeskov/kkal-tracker/internal/repositories"
+	trashservice "ypeskov/kkal-tracker/internal/services/trash"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	trashService trashservice.Servicer
+	logger       *slog.Logger
+}
+
+func New(trashService trashservice.Servicer, logger *slog.Logger) *Handler {
+	return &Handler{
+		trashService: trashService,
+		logger:       logger.With("handler", "trash"),
+	}
+}
+
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.GET("", h.List)
+	g.POST("/undo", h.Undo)
+	g.POST("/calories/:id/restore", h.RestoreCalorieEntry)
+	g.POST("/weight/:id/restore", h.RestoreWeightEntry)
+}
+
+// List returns the user's deleted calorie entries and weigh-ins
+func (h *Handler) List(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	trash, err := h.trashService.List(userID)
+	if err != nil {
+		h.logger.Error("Failed to list trash", "user_id", userID, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to list trash")
+	}
+
+	return c.JSON(http.StatusOK, trash)
+}
+
+// Undo restores the entry the user deleted last, within the undo window
+func (h *Handler) Undo(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	restored, err := h.trashService.Undo(userID)
+	if err != nil {
+		if errors.Is(err, trashservice.ErrNothingToUndo) {
+			return echo.NewHTTPError(http.StatusNotFound, err.Error())
+		}
+		h.logger.Error("Failed to undo delete", "user_id", userID, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to undo delete")
+	}
+
+	etag.Set(c, restored.ID, restored.Version)
+	return c.JSON(http.StatusOK, restored)
+}
+
+// RestoreCalorieEntry takes a calorie entry out of the trash
+func (h *Handler) RestoreCalorieEntry(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	entryID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid entry ID")
+	}
+
+	entry, err := h.trashService.RestoreCalorieEntry(entryID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Entry not found in trash")
+		}
+		h.logger.Error("Failed to restore calorie entry", "entry_id", entryID, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to restore entry")
+	}
+
+	etag.Set(c, entry.ID, entry.Version)
+	return c.JSON(http.StatusOK, entry)
+}
+
+// RestoreWeightEntry takes a weigh-in out of the trash
+func (h *Handler) RestoreWeightEntry(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid weight entry ID")
+	}
+
+	entry, err := h.trashService.RestoreWeightEntry(id, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Weight entry not found in trash")
+		}
+		h.logger.Error("Failed to restore weight entry", "id", id, "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to restore weight entry")
+	}
+
+	etag.Set(c, entry.ID, entry.Version)
+	return c.JSON(http.StatusOK, entry)
+}
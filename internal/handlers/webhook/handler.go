@@ -0,0 +1,197 @@
+package webhook
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	webhookservice "ypeskov/kkal-tracker/internal/services/webhook"
+
+	"github.com/labstack/echo/v4"
+)
+
+type Handler struct {
+	webhookService webhookservice.Servicer
+	logger         *slog.Logger
+}
+
+type Request struct {
+	URL     string   `json:"url" validate:"required,max=2048"`
+	Events  []string `json:"events" validate:"required,min=1,dive,oneof=weight.created calorie_entry.created calorie_entry.updated goal.reached daily_target.exceeded"`
+	Enabled *bool    `json:"enabled"` // Defaults to true
+}
+
+type SecretResponse struct {
+	Secret string `json:"secret"`
+}
+
+func New(webhookService webhookservice.Servicer, logger *slog.Logger) *Handler {
+	return &Handler{
+		webhookService: webhookService,
+		logger:         logger.With("handler", "webhook"),
+	}
+}
+
+func (h *Handler) RegisterRoutes(g *echo.Group) {
+	g.GET("", h.List)
+	g.POST("", h.Create)
+	g.GET("/:id", h.Get)
+	g.PUT("/:id", h.Update)
+	g.DELETE("/:id", h.Delete)
+	g.POST("/:id/secret", h.RotateSecret)
+	g.GET("/:id/deliveries", h.Deliveries)
+}
+
+func (h *Handler) List(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	webhooks, err := h.webhookService.List(userID)
+	if err != nil {
+		return h.webhookError(err, "Failed to list webhooks")
+	}
+
+	return c.JSON(http.StatusOK, webhooks)
+}
+
+// Create adds a webhook. The signing secret is only returned here and by RotateSecret.
+func (h *Handler) Create(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	req, err := h.bindRequest(c)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := h.webhookService.Create(userID, req)
+	if err != nil {
+		return h.webhookError(err, "Failed to create webhook")
+	}
+
+	return c.JSON(http.StatusCreated, webhook)
+}
+
+func (h *Handler) Get(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	webhook, err := h.webhookService.Get(userID, id)
+	if err != nil {
+		return h.webhookError(err, "Failed to get webhook")
+	}
+
+	return c.JSON(http.StatusOK, webhook)
+}
+
+func (h *Handler) Update(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	req, err := h.bindRequest(c)
+	if err != nil {
+		return err
+	}
+
+	webhook, err := h.webhookService.Update(userID, id, req)
+	if err != nil {
+		return h.webhookError(err, "Failed to update webhook")
+	}
+
+	return c.JSON(http.StatusOK, webhook)
+}
+
+func (h *Handler) Delete(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	if err := h.webhookService.Delete(userID, id); err != nil {
+		return h.webhookError(err, "Failed to delete webhook")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RotateSecret replaces the signing secret; deliveries are signed with the new one right away
+func (h *Handler) RotateSecret(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	secret, err := h.webhookService.RotateSecret(userID, id)
+	if err != nil {
+		return h.webhookError(err, "Failed to rotate webhook secret")
+	}
+
+	return c.JSON(http.StatusOK, SecretResponse{Secret: secret})
+}
+
+// Deliveries returns the latest deliveries of a webhook, newest first
+func (h *Handler) Deliveries(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid webhook ID")
+	}
+
+	deliveries, err := h.webhookService.Deliveries(userID, id)
+	if err != nil {
+		return h.webhookError(err, "Failed to get webhook deliveries")
+	}
+
+	return c.JSON(http.StatusOK, deliveries)
+}
+
+// bindRequest reads and validates the webhook settings
+func (h *Handler) bindRequest(c echo.Context) (*webhookservice.WebhookRequest, error) {
+	var req Request
+	if err := c.Bind(&req); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+	}
+	if err := c.Validate(&req); err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	return &webhookservice.WebhookRequest{
+		URL:     req.URL,
+		Events:  req.Events,
+		Enabled: enabled,
+	}, nil
+}
+
+// webhookError maps service errors to HTTP errors
+func (h *Handler) webhookError(err error, message string) error {
+	switch {
+	case errors.Is(err, webhookservice.ErrWebhookNotFound):
+		return echo.NewHTTPError(http.StatusNotFound, err.Error())
+	case errors.Is(err, webhookservice.ErrInvalidURL),
+		errors.Is(err, webhookservice.ErrPrivateURL),
+		errors.Is(err, webhookservice.ErrInvalidEvent),
+		errors.Is(err, webhookservice.ErrNoEvents):
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	case errors.Is(err, webhookservice.ErrTooManyWebhooks):
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	h.logger.Error(message, "error", err)
+	return echo.NewHTTPError(http.StatusInternalServerError, message)
+}
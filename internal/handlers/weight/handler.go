@@ -1,11 +1,14 @@
 package weight
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 	"strconv"
 	"time"
 
+	"ypeskov/kkal-tracker/internal/handlers/etag"
+	"ypeskov/kkal-tracker/internal/repositories"
 	weightservice "ypeskov/kkal-tracker/internal/services/weight"
 
 	"github.com/labstack/echo/v4"
@@ -67,6 +70,27 @@ func (h *Handler) GetWeightHistoryByDateRange(c echo.Context) error {
 	return c.JSON(http.StatusOK, history)
 }
 
+// GetWeightEntry returns one weight entry with its ETag
+func (h *Handler) GetWeightEntry(c echo.Context) error {
+	userID := c.Get("user_id").(int)
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "Invalid weight entry ID")
+	}
+
+	entry, err := h.weightService.GetWeightEntry(id, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Weight entry not found")
+		}
+		h.logger.Error("Failed to get weight entry", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get weight entry")
+	}
+
+	etag.Set(c, entry.ID, entry.Version)
+	return c.JSON(http.StatusOK, entry)
+}
+
 // CreateWeightEntry creates a new weight entry
 func (h *Handler) CreateWeightEntry(c echo.Context) error {
 	userID := c.Get("user_id").(int)
@@ -103,6 +127,7 @@ func (h *Handler) CreateWeightEntry(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to create weight entry")
 	}
 
+	etag.Set(c, entry.ID, entry.Version)
 	return c.JSON(http.StatusCreated, entry)
 }
 
@@ -141,12 +166,19 @@ func (h *Handler) UpdateWeightEntry(c echo.Context) error {
 		"user_id", userID,
 		"weight", req.Weight)
 
-	entry, err := h.weightService.UpdateWeightEntry(id, userID, req.Weight, recordedAt)
+	entry, err := h.weightService.UpdateWeightEntry(id, userID, req.Weight, recordedAt, etag.IfMatch(c, id))
 	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, "Weight entry not found")
+		case errors.Is(err, repositories.ErrVersionConflict):
+			return h.entryConflict(c, id, userID)
+		}
 		h.logger.Error("Failed to update weight entry", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to update weight entry")
 	}
 
+	etag.Set(c, entry.ID, entry.Version)
 	return c.JSON(http.StatusOK, entry)
 }
 
@@ -162,7 +194,13 @@ func (h *Handler) DeleteWeightEntry(c echo.Context) error {
 		"id", id,
 		"user_id", userID)
 
-	if err := h.weightService.DeleteWeightEntry(id, userID); err != nil {
+	if err := h.weightService.DeleteWeightEntry(id, userID, etag.IfMatch(c, id)); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrNotFound):
+			return echo.NewHTTPError(http.StatusNotFound, "Weight entry not found")
+		case errors.Is(err, repositories.ErrVersionConflict):
+			return h.entryConflict(c, id, userID)
+		}
 		h.logger.Error("Failed to delete weight entry", "error", err)
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to delete weight entry")
 	}
@@ -170,10 +208,24 @@ func (h *Handler) DeleteWeightEntry(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// entryConflict answers a write made against an outdated version of a weight entry with the current entry
+func (h *Handler) entryConflict(c echo.Context, id, userID int) error {
+	current, err := h.weightService.GetWeightEntry(id, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return echo.NewHTTPError(http.StatusNotFound, "Weight entry not found")
+		}
+		h.logger.Error("Failed to get weight entry", "error", err)
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to get weight entry")
+	}
+	return etag.PreconditionFailed(c, current.ID, current.Version, current)
+}
+
 // RegisterRoutes registers all weight-related routes
 func (h *Handler) RegisterRoutes(g *echo.Group) {
 	g.GET("", h.GetWeightHistoryByDateRange)
 	g.POST("", h.CreateWeightEntry)
+	g.GET("/:id", h.GetWeightEntry)
 	g.PUT("/:id", h.UpdateWeightEntry)
 	g.DELETE("/:id", h.DeleteWeightEntry)
 }
\ No newline at end of file
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	adminservice "ypeskov/kkal-tracker/internal/services/admin"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AdminMiddleware struct {
+	adminService adminservice.Servicer
+	logger       *slog.Logger
+}
+
+func NewAdminMiddleware(adminService adminservice.Servicer, logger *slog.Logger) *AdminMiddleware {
+	return &AdminMiddleware{
+		adminService: adminService,
+		logger:       logger,
+	}
+}
+
+// RequireAdmin must run after RequireAuth; it rejects users without the admin role
+func (m *AdminMiddleware) RequireAdmin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return echo.NewHTTPError(http.StatusUnauthorized, "Authentication required")
+		}
+
+		isAdmin, err := m.adminService.IsAdmin(userID)
+		if err != nil {
+			m.logger.Error("Failed to check admin role", "error", err, "user_id", userID)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+		}
+
+		if !isAdmin {
+			m.logger.Warn("Admin access denied", "user_id", userID, "path", c.Path())
+			return echo.NewHTTPError(http.StatusForbidden, "Admin access required")
+		}
+
+		return next(c)
+	}
+}
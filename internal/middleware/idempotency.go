@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	idempotencyservice "ypeskov/kkal-tracker/internal/services/idempotency"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentResponseSize = 1 << 20 // Larger responses are not kept; the key is released instead
+)
+
+// Response headers replayed with a stored response
+var replayedHeaders = []string{
+	echo.HeaderContentType,
+	echo.HeaderContentDisposition,
+	echo.HeaderLocation,
+	"ETag",
+}
+
+type IdempotencyMiddleware struct {
+	idempotencyService idempotencyservice.Servicer
+	logger             *slog.Logger
+}
+
+func NewIdempotencyMiddleware(idempotencyService idempotencyservice.Servicer, logger *slog.Logger) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		idempotencyService: idempotencyService,
+		logger:             logger,
+	}
+}
+
+// Handle makes writes sent with an Idempotency-Key header safe to retry: the
+// first response is stored and later requests with the same key get it again
+// without the write being repeated. Must run after authentication.
+func (m *IdempotencyMiddleware) Handle(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		key := req.Header.Get(IdempotencyKeyHeader)
+		if key == "" || !isWriteMethod(req.Method) {
+			return next(c)
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			return echo.NewHTTPError(http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+		}
+
+		userID, ok := c.Get("user_id").(int)
+		if !ok {
+			return next(c)
+		}
+
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		stored, err := m.idempotencyService.Begin(userID, key, requestHash(req, body))
+		if err != nil {
+			switch {
+			case errors.Is(err, idempotencyservice.ErrKeyReused):
+				return echo.NewHTTPError(http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			case errors.Is(err, idempotencyservice.ErrRequestInProgress):
+				return echo.NewHTTPError(http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+			}
+			m.logger.Error("Failed to check idempotency key", "user_id", userID, "error", err)
+			return echo.NewHTTPError(http.StatusInternalServerError, "Internal server error")
+		}
+
+		res := c.Response()
+		if stored != nil {
+			for name, value := range stored.ResponseHeaders {
+				res.Header().Set(name, value)
+			}
+			res.Header().Set(IdempotentReplayedHeader, "true")
+			res.WriteHeader(stored.StatusCode)
+			_, err := res.Write(stored.ResponseBody)
+			return err
+		}
+
+		recorder := &responseRecorder{ResponseWriter: res.Writer}
+		res.Writer = recorder
+
+		// Errors are rendered here so their response is stored as well
+		if err := next(c); err != nil {
+			c.Error(err)
+		}
+
+		// Server errors and rate limits are worth retrying, and large or missing
+		// responses are not kept
+		status := res.Status
+		if !res.Committed || status >= http.StatusInternalServerError || status == http.StatusTooManyRequests || recorder.overflow {
+			if err := m.idempotencyService.Release(userID, key); err != nil {
+				m.logger.Error("Failed to release idempotency key", "user_id", userID, "error", err)
+			}
+			return nil
+		}
+
+		headers := make(map[string]string)
+		for _, name := range replayedHeaders {
+			if value := res.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+		if err := m.idempotencyService.Complete(userID, key, status, headers, recorder.body.Bytes()); err != nil {
+			m.logger.Error("Failed to store idempotent response", "user_id", userID, "error", err)
+		}
+		return nil
+	}
+}
+
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestHash identifies a request, so a key reused for another one is detected
+func requestHash(req *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body while writing it through
+type responseRecorder struct {
+	http.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.overflow {
+		if r.body.Len()+len(b) > maxIdempotentResponseSize {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
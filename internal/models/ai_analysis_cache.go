@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// AIAnalysisCache stores a validated AI analysis keyed by a hash of its input data
+type AIAnalysisCache struct {
+	ID         int       `json:"id"`
+	UserID     int       `json:"user_id"`
+	InputHash  string    `json:"input_hash"`
+	Model      string    `json:"model"`
+	Response   string    `json:"response"` // JSON encoded analysis
+	TokensUsed int       `json:"tokens_used"`
+	CreatedAt  time.Time `json:"created_at"`
+}
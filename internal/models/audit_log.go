@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// AuditLogEntry records a change made by an admin to shared data
+type AuditLogEntry struct {
+	ID          int       `json:"id"`
+	ActorUserID *int      `json:"actor_user_id"` // Nil once the admin's account is deleted
+	Action      string    `json:"action"`        // create, update, delete, import
+	EntityType  string    `json:"entity_type"`   // e.g. global_ingredient
+	EntityID    *int      `json:"entity_id,omitempty"`
+	Changes     *string   `json:"changes,omitempty"` // JSON encoded before/after state
+	CreatedAt   time.Time `json:"created_at"`
+}
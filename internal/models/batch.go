@@ -0,0 +1,22 @@
+package models
+
+// Actions of batch operations
+const (
+	BatchActionCreate = "create"
+	BatchActionUpdate = "update"
+	BatchActionDelete = "delete"
+)
+
+// BatchOperation is one write of a batch to a calorie entry or weigh-in.
+// Entity is SyncEntityCalorieEntry or SyncEntityWeight, and CalorieEntry or
+// Weight holds the values to write for creates and updates. The ID of a
+// created entity is filled in when the batch is applied.
+type BatchOperation struct {
+	Action       string
+	Entity       string
+	ID           int
+	Version      int // Version the entity must have for an update or delete; 0 skips the check
+	CalorieEntry *CalorieEntry
+	Weight       *WeightHistory
+	Err          error // Why the operation was not applied
+}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// CalendarFeed is a user's iCalendar feed of meals and weigh-ins. The feed URL
+// contains a token of which only the hash is stored.
+type CalendarFeed struct {
+	ID             int        `json:"id"`
+	UserID         int        `json:"-"`
+	TokenHash      string     `json:"-"`
+	TokenPrefix    string     `json:"token_prefix"`
+	Timezone       string     `json:"timezone"`  // IANA name the meal times are shown in
+	PastDays       int        `json:"past_days"` // Days before today covered by the feed
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -6,16 +6,34 @@ import (
 
 // CalorieEntry is a pure data structure representing a calorie entry
 type CalorieEntry struct {
-	ID           int       `json:"id"`
-	UserID       int       `json:"user_id"`
-	Food         string    `json:"food"`
-	Calories     int       `json:"calories"`
-	Weight       float64   `json:"weight"`
-	KcalPer100g  float64   `json:"kcalPer100g"`
-	Fats         *float64  `json:"fats,omitempty"`
-	Carbs        *float64  `json:"carbs,omitempty"`
-	Proteins     *float64  `json:"proteins,omitempty"`
-	MealDatetime time.Time `json:"meal_datetime"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	CreatedAt    time.Time `json:"created_at"`
-}
\ No newline at end of file
+	ID           int        `json:"id"`
+	UserID       int        `json:"user_id"`
+	Food         string     `json:"food"`
+	Calories     int        `json:"calories"`
+	Weight       float64    `json:"weight"`             // Always in grams
+	Quantity     *float64   `json:"quantity,omitempty"` // Quantity and unit the entry was logged with, if not grams
+	Unit         *string    `json:"unit,omitempty"`
+	KcalPer100g  float64    `json:"kcalPer100g"`
+	Fats         *float64   `json:"fats,omitempty"`
+	Carbs        *float64   `json:"carbs,omitempty"`
+	Proteins     *float64   `json:"proteins,omitempty"`
+	Nutrients    Nutrients  `json:"nutrients,omitempty"` // Snapshot per 100 g
+	MealDatetime time.Time  `json:"meal_datetime"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Version      int        `json:"version"`              // Incremented by every update; the entry's ETag
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"` // Set while the entry is in the trash
+}
+
+// ImportedCalorieEntry is a calorie entry created from a row of another app's export
+type ImportedCalorieEntry struct {
+	Entry     *CalorieEntry
+	ImportKey string // Fingerprint of the source row, unique per user
+}
+
+// FoodUsage tells how often and when last a food was logged
+type FoodUsage struct {
+	Food       string
+	Count      int
+	LastUsedAt time.Time
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Export job statuses
+const (
+	ExportJobPending   = "pending"
+	ExportJobRunning   = "running"
+	ExportJobCompleted = "completed"
+	ExportJobFailed    = "failed"
+	ExportJobExpired   = "expired" // The file was deleted after the download window
+)
+
+// ExportJob is an export generated in the background. The file itself is
+// stored with the job but only loaded for downloads.
+type ExportJob struct {
+	ID           int        `json:"id"`
+	UserID       int        `json:"-"`
+	Status       string     `json:"status"`
+	DateFrom     string     `json:"date_from"`
+	DateTo       string     `json:"date_to"`
+	DataType     string     `json:"data_type"`
+	Format       string     `json:"format"`
+	DeliveryType string     `json:"delivery_type"`
+	Language     string     `json:"-"`
+	Email        string     `json:"-"`
+	FileName     *string    `json:"file_name,omitempty"`
+	ContentType  *string    `json:"content_type,omitempty"`
+	FileSize     int        `json:"file_size"`
+	Error        *string    `json:"error,omitempty"`
+	Attempts     int        `json:"attempts"`
+	CreatedAt    time.Time  `json:"created_at"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
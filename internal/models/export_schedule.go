@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Export schedule kinds
+const (
+	ScheduleKindExport = "export" // Emails an export file
+	ScheduleKindDigest = "digest" // Emails a summary of the covered days against the daily targets
+)
+
+// Export schedule frequencies
+const (
+	ScheduleDaily   = "daily"
+	ScheduleWeekly  = "weekly"
+	ScheduleMonthly = "monthly"
+)
+
+// ExportSchedule is a recurring email sent to the user. Runs are computed in
+// the schedule's time zone; the lease fields belong to the scheduler.
+type ExportSchedule struct {
+	ID               int        `json:"id"`
+	UserID           int        `json:"-"`
+	Kind             string     `json:"kind"`
+	Frequency        string     `json:"frequency"`
+	DayOfWeek        *int       `json:"day_of_week,omitempty"`  // 0 = Sunday, for weekly schedules
+	DayOfMonth       *int       `json:"day_of_month,omitempty"` // For monthly schedules; short months use their last day
+	Hour             int        `json:"hour"`
+	Timezone         string     `json:"timezone"`
+	DataType         string     `json:"data_type"`
+	Format           string     `json:"format"`
+	Enabled          bool       `json:"enabled"`
+	UnsubscribeToken string     `json:"-"`
+	NextRunAt        time.Time  `json:"next_run_at"`
+	LastRunAt        *time.Time `json:"last_run_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
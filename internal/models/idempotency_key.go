@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// IdempotencyKey is a mutating request sent with an Idempotency-Key header and,
+// once it was handled, the response to replay when the request is retried
+type IdempotencyKey struct {
+	UserID          int
+	Key             string
+	RequestHash     string // Hash of the method, URL and body of the request
+	StatusCode      int    // Zero while the request is being handled
+	ResponseHeaders map[string]string
+	ResponseBody    []byte
+	CreatedAt       time.Time
+	ExpiresAt       time.Time
+}
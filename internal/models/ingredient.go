@@ -4,6 +4,12 @@ import (
 	"time"
 )
 
+// Sources of global ingredients
+const (
+	IngredientSourceManual        = "manual"        // Curated catalog, copied to every user
+	IngredientSourceOpenFoodFacts = "openfoodfacts" // Imported products, only found by barcode
+)
+
 // GlobalIngredient represents admin-managed ingredients
 type GlobalIngredient struct {
 	ID          int               `json:"id"`
@@ -11,7 +17,11 @@ type GlobalIngredient struct {
 	Fats        *float64          `json:"fats,omitempty"`
 	Carbs       *float64          `json:"carbs,omitempty"`
 	Proteins    *float64          `json:"proteins,omitempty"`
-	Names       map[string]string `json:"names"` // language_code -> name
+	Nutrients   Nutrients         `json:"nutrients,omitempty"`
+	Barcode     *string           `json:"barcode,omitempty"`
+	Source      string            `json:"source"`
+	Names       map[string]string `json:"names"`   // language_code -> name
+	Version     int               `json:"version"` // Bumped on every update
 	CreatedAt   time.Time         `json:"created_at"`
 	UpdatedAt   time.Time         `json:"updated_at"`
 }
@@ -25,7 +35,47 @@ type UserIngredient struct {
 	Fats               *float64  `json:"fats,omitempty"`
 	Carbs              *float64  `json:"carbs,omitempty"`
 	Proteins           *float64  `json:"proteins,omitempty"`
+	Nutrients          Nutrients `json:"nutrients,omitempty"`
+	Barcode            *string   `json:"barcode,omitempty"`
 	GlobalIngredientID *int      `json:"global_ingredient_id,omitempty"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
-}
\ No newline at end of file
+}
+
+// IngredientServing is a named portion of an ingredient, e.g. "egg" = 50 g
+type IngredientServing struct {
+	ID           int     `json:"id"`
+	IngredientID int     `json:"ingredient_id"`
+	Name         string  `json:"name"`
+	Grams        float64 `json:"grams"`
+}
+
+// IngredientUnits describes how quantities of an ingredient convert to grams
+type IngredientUnits struct {
+	IngredientID  int                  `json:"ingredient_id"`
+	DensityGPerML *float64             `json:"density_g_per_ml,omitempty"` // For milliliter-based units
+	Servings      []*IngredientServing `json:"servings"`
+}
+
+// IngredientValues are the fields kept in sync between a global ingredient and its user copies
+type IngredientValues struct {
+	Name        string    `json:"name"`
+	KcalPer100g float64   `json:"kcalPer100g"`
+	Fats        *float64  `json:"fats,omitempty"`
+	Carbs       *float64  `json:"carbs,omitempty"`
+	Proteins    *float64  `json:"proteins,omitempty"`
+	Nutrients   Nutrients `json:"nutrients,omitempty"`
+}
+
+// LinkedUserIngredient is a user ingredient copied from the global catalog, together
+// with the global version and values it was last synced to
+type LinkedUserIngredient struct {
+	ID                 int
+	UserID             int
+	Language           string // Language of the owning user
+	GlobalIngredientID int
+	GlobalVersion      int
+	Values             IngredientValues
+	Synced             *IngredientValues // nil when the copy has never been synced
+	ChangeVersion      int64             // Sync change version the copy was read at; updates only apply while it is unchanged
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// MealPlan represents an AI-generated multi-day meal plan
+type MealPlan struct {
+	ID             int             `json:"id"`
+	UserID         int             `json:"user_id"`
+	Days           int             `json:"days"`
+	TargetCalories float64         `json:"target_calories"`
+	TargetProteins *float64        `json:"target_proteins,omitempty"`
+	TargetFats     *float64        `json:"target_fats,omitempty"`
+	TargetCarbs    *float64        `json:"target_carbs,omitempty"`
+	Notes          *string         `json:"notes,omitempty"`
+	Model          string          `json:"model"`
+	Items          []*MealPlanItem `json:"items,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// MealPlanItem is a single food portion within a meal plan day.
+// Macronutrients are stored per 100g, the same way as in calorie entries.
+type MealPlanItem struct {
+	ID           int      `json:"id"`
+	PlanID       int      `json:"plan_id"`
+	DayNumber    int      `json:"day_number"`
+	MealType     string   `json:"meal_type"`
+	IngredientID *int     `json:"ingredient_id,omitempty"`
+	Food         string   `json:"food"`
+	Weight       float64  `json:"weight"`
+	KcalPer100g  float64  `json:"kcalPer100g"`
+	Calories     int      `json:"calories"`
+	Fats         *float64 `json:"fats,omitempty"`
+	Carbs        *float64 `json:"carbs,omitempty"`
+	Proteins     *float64 `json:"proteins,omitempty"`
+}
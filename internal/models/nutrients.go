@@ -0,0 +1,153 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Nutrients holds extended nutrient values per 100 g, keyed by nutrient code.
+// A missing key means the value is unknown; zero means known to be absent.
+// Stored as a JSON object in a TEXT column, NULL when no value is known.
+type Nutrients map[string]float64
+
+// NutrientInfo describes a nutrient that can be tracked
+type NutrientInfo struct {
+	Code string  `json:"code"`
+	Unit string  `json:"unit"` // g, mg or µg per 100 g
+	Max  float64 `json:"max"`  // Upper bound per 100 g
+}
+
+// Nutrient units
+const (
+	UnitGram      = "g"
+	UnitMilligram = "mg"
+	UnitMicrogram = "µg"
+)
+
+// NutrientCatalog lists the supported nutrients in display order
+var NutrientCatalog = []NutrientInfo{
+	{Code: "fiber", Unit: UnitGram, Max: 100},
+	{Code: "sugars", Unit: UnitGram, Max: 100},
+	{Code: "saturated_fat", Unit: UnitGram, Max: 100},
+	{Code: "trans_fat", Unit: UnitGram, Max: 100},
+	{Code: "salt", Unit: UnitGram, Max: 100},
+	{Code: "sodium", Unit: UnitMilligram, Max: 40000},
+	{Code: "cholesterol", Unit: UnitMilligram, Max: 5000},
+	{Code: "potassium", Unit: UnitMilligram, Max: 20000},
+	{Code: "calcium", Unit: UnitMilligram, Max: 40000},
+	{Code: "iron", Unit: UnitMilligram, Max: 1000},
+	{Code: "magnesium", Unit: UnitMilligram, Max: 10000},
+	{Code: "vitamin_a", Unit: UnitMicrogram, Max: 100000},
+	{Code: "vitamin_c", Unit: UnitMilligram, Max: 10000},
+	{Code: "vitamin_d", Unit: UnitMicrogram, Max: 10000},
+	{Code: "vitamin_b12", Unit: UnitMicrogram, Max: 10000},
+}
+
+// saltPerSodiumMg converts sodium in mg to salt in g (salt = sodium x 2.5)
+const saltPerSodiumMg = 2.5 / 1000
+
+// LookupNutrient returns the catalog entry for a nutrient code
+func LookupNutrient(code string) (NutrientInfo, bool) {
+	for _, info := range NutrientCatalog {
+		if info.Code == code {
+			return info, true
+		}
+	}
+	return NutrientInfo{}, false
+}
+
+// Get returns the value of a nutrient, or nil if it is unknown
+func (n Nutrients) Get(code string) *float64 {
+	value, ok := n[code]
+	if !ok {
+		return nil
+	}
+	return &value
+}
+
+// With returns a copy with the nutrient set, or removed when value is nil
+func (n Nutrients) With(code string, value *float64) Nutrients {
+	result := make(Nutrients, len(n)+1)
+	for k, v := range n {
+		result[k] = v
+	}
+	if value == nil {
+		delete(result, code)
+	} else {
+		result[code] = *value
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Validate checks that every nutrient is known and within its range
+func (n Nutrients) Validate() error {
+	for code, value := range n {
+		info, ok := LookupNutrient(code)
+		if !ok {
+			return fmt.Errorf("unknown nutrient %q", code)
+		}
+		if math.IsNaN(value) || value < 0 || value > info.Max {
+			return fmt.Errorf("%s must be between 0 and %g %s per 100 g", code, info.Max, info.Unit)
+		}
+	}
+	return nil
+}
+
+// Complete returns a copy where salt and sodium are derived from each other
+// when only one of them is known
+func (n Nutrients) Complete() Nutrients {
+	salt, hasSalt := n["salt"]
+	sodium, hasSodium := n["sodium"]
+	switch {
+	case hasSodium && !hasSalt:
+		salt = math.Round(sodium*saltPerSodiumMg*1000) / 1000
+		return n.With("salt", &salt)
+	case hasSalt && !hasSodium:
+		sodium = math.Round(salt / saltPerSodiumMg)
+		return n.With("sodium", &sodium)
+	}
+	return n
+}
+
+// Value implements driver.Valuer
+func (n Nutrients) Value() (driver.Value, error) {
+	if len(n) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner
+func (n *Nutrients) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case nil:
+		*n = nil
+		return nil
+	case string:
+		data = []byte(v)
+	case []byte:
+		data = v
+	default:
+		return fmt.Errorf("cannot scan %T into Nutrients", src)
+	}
+
+	var values Nutrients
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		values = nil
+	}
+	*n = values
+	return nil
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Entities in the change feed
+const (
+	SyncEntityCalorieEntry = "calorie_entry"
+	SyncEntityWeight       = "weight"
+	SyncEntityIngredient   = "ingredient"
+)
+
+// SyncChange is the latest change of an entity. Versions are assigned per
+// user and only grow; a deleted entity is kept as a tombstone for a while.
+type SyncChange struct {
+	UserID    int
+	Entity    string
+	EntityID  int
+	Version   int64
+	Deleted   bool
+	ChangedAt time.Time
+}
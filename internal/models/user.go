@@ -6,12 +6,19 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 // User is a pure data structure representing a user
 type User struct {
 	ID            int       `json:"id"`
 	Email         string    `json:"email"`
 	PasswordHash  string    `json:"-"`
 	IsActive      bool      `json:"is_active"`
+	Role          string    `json:"role"`
 	FirstName     *string   `json:"first_name,omitempty"`
 	LastName      *string   `json:"last_name,omitempty"`
 	Age           *int      `json:"age,omitempty"`
@@ -27,6 +34,17 @@ type User struct {
 	TargetDate          *time.Time `json:"target_date,omitempty"`           // Optional target date
 	GoalSetAt           *time.Time `json:"goal_set_at,omitempty"`           // When the goal was set
 	InitialWeightAtGoal *float64   `json:"initial_weight_at_goal,omitempty"` // Weight when goal was set
+
+	// DeletionScheduledAt is when the account will be purged, if the user asked to delete it
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty"`
+
+	// ProfileVersion is incremented by every change to the profile or weight goal
+	ProfileVersion int `json:"-"`
+}
+
+// IsAdmin reports whether the user can manage shared data
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
 }
 
 // SetPassword hashes and sets the user's password
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Webhook events
+const (
+	WebhookWeightCreated       = "weight.created"
+	WebhookCalorieEntryCreated = "calorie_entry.created"
+	WebhookCalorieEntryUpdated = "calorie_entry.updated"
+	WebhookGoalReached         = "goal.reached"          // A weigh-in reached the target weight
+	WebhookDailyTargetExceeded = "daily_target.exceeded" // A day's calories went over the daily target
+)
+
+// WebhookEvents lists the events endpoints can subscribe to
+var WebhookEvents = []string{
+	WebhookWeightCreated,
+	WebhookCalorieEntryCreated,
+	WebhookCalorieEntryUpdated,
+	WebhookGoalReached,
+	WebhookDailyTargetExceeded,
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending" // Waiting for its first or next attempt
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // Out of attempts, or the endpoint was disabled
+)
+
+// Webhook is an endpoint that receives signed POST requests for the events it
+// subscribes to
+type Webhook struct {
+	ID            int        `json:"id"`
+	UserID        int        `json:"-"`
+	URL           string     `json:"url"`
+	Secret        string     `json:"-"`
+	Events        []string   `json:"events"`
+	Enabled       bool       `json:"enabled"`
+	FailureCount  int        `json:"failure_count"` // Consecutive failed attempts
+	DisabledAt    *time.Time `json:"disabled_at,omitempty"`
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Subscribes reports whether the webhook receives the given event
+func (w *Webhook) Subscribes(event string) bool {
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event sent, or to be sent, to one webhook
+type WebhookDelivery struct {
+	ID             int        `json:"id"`
+	WebhookID      int        `json:"webhook_id"`
+	UserID         int        `json:"-"`
+	Event          string     `json:"event"`
+	EventID        string     `json:"event_id"`
+	EventKey       *string    `json:"-"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	ResponseStatus *int       `json:"response_status,omitempty"` // HTTP status of the last attempt
+	Error          *string    `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+// WebhookCheck asks the webhook worker to work out whether an event that
+// depends on totals happened, e.g. whether a day went over the calorie target
+type WebhookCheck struct {
+	ID            int
+	UserID        int
+	Event         string
+	Subject       string // The date or weigh-in ID the event is about
+	Attempts      int    // Failed attempts so far
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+}
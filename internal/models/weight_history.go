@@ -6,9 +6,11 @@ import (
 
 // WeightHistory represents a weight measurement for a user at a specific point in time
 type WeightHistory struct {
-	ID         int       `json:"id"`
-	UserID     int       `json:"user_id"`
-	Weight     float64   `json:"weight"` // Weight in kg
-	RecordedAt time.Time `json:"recorded_at"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID         int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Weight     float64    `json:"weight"` // Weight in kg
+	RecordedAt time.Time  `json:"recorded_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Version    int        `json:"version"`              // Incremented by every update; the entry's ETag
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"` // Set while the entry is in the trash
 }
\ No newline at end of file
@@ -0,0 +1,163 @@
+package openfoodfacts
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"ypeskov/kkal-tracker/internal/models"
+	ingredientservice "ypeskov/kkal-tracker/internal/services/ingredient"
+)
+
+// Reasons a product is not imported
+var (
+	ErrNoBarcode     = errors.New("no valid barcode")
+	ErrNoName        = errors.New("no product name")
+	ErrNoEnergy      = errors.New("no energy value")
+	ErrInvalidEnergy = errors.New("energy out of range")
+)
+
+const (
+	maxNameLength      = 255
+	kjPerKcal          = 4.184
+	maxKcalPer100g     = 900.0
+	maxMacroPer100g    = 100.0
+	defaultOFFLanguage = "en"
+)
+
+// Languages maps Open Food Facts language codes to the app's language codes
+var Languages = map[string]string{
+	"en": "en_US",
+	"uk": "uk_UA",
+	"ru": "ru_UA",
+	"bg": "bg_BG",
+}
+
+// nutrientMapping maps an Open Food Facts nutriment to an app nutrient. Dumps
+// store every nutriment in grams, so Scale converts to the app's unit.
+type nutrientMapping struct {
+	Key   string
+	Code  string
+	Scale float64
+}
+
+var nutrientMappings = []nutrientMapping{
+	{Key: "fiber_100g", Code: "fiber", Scale: 1},
+	{Key: "sugars_100g", Code: "sugars", Scale: 1},
+	{Key: "saturated-fat_100g", Code: "saturated_fat", Scale: 1},
+	{Key: "trans-fat_100g", Code: "trans_fat", Scale: 1},
+	{Key: "salt_100g", Code: "salt", Scale: 1},
+	{Key: "sodium_100g", Code: "sodium", Scale: 1e3},
+	{Key: "cholesterol_100g", Code: "cholesterol", Scale: 1e3},
+	{Key: "potassium_100g", Code: "potassium", Scale: 1e3},
+	{Key: "calcium_100g", Code: "calcium", Scale: 1e3},
+	{Key: "iron_100g", Code: "iron", Scale: 1e3},
+	{Key: "magnesium_100g", Code: "magnesium", Scale: 1e3},
+	{Key: "vitamin-c_100g", Code: "vitamin_c", Scale: 1e3},
+	{Key: "vitamin-a_100g", Code: "vitamin_a", Scale: 1e6},
+	{Key: "vitamin-d_100g", Code: "vitamin_d", Scale: 1e6},
+	{Key: "vitamin-b12_100g", Code: "vitamin_b12", Scale: 1e6},
+}
+
+// ToGlobalIngredient converts a product to a global ingredient with source
+// openfoodfacts. Values outside the app's ranges are dropped; the product is
+// skipped when it has no valid barcode, name or energy.
+func ToGlobalIngredient(p *Product) (*models.GlobalIngredient, error) {
+	barcode, err := ingredientservice.NormalizeBarcode(p.Code)
+	if err != nil {
+		return nil, ErrNoBarcode
+	}
+
+	names := productNames(p)
+	if len(names) == 0 {
+		return nil, ErrNoName
+	}
+
+	kcal, ok := p.Nutriments["energy-kcal_100g"]
+	if !ok {
+		kj, ok := p.Nutriments["energy_100g"]
+		if !ok {
+			return nil, ErrNoEnergy
+		}
+		kcal = kj / kjPerKcal
+	}
+	if kcal < 0 || kcal > maxKcalPer100g || math.IsNaN(kcal) {
+		return nil, ErrInvalidEnergy
+	}
+
+	var nutrients models.Nutrients
+	for _, mapping := range nutrientMappings {
+		value, ok := p.Nutriments[mapping.Key]
+		if !ok {
+			continue
+		}
+		single := models.Nutrients{}.With(mapping.Code, round(value*mapping.Scale))
+		if single.Validate() != nil {
+			continue
+		}
+		nutrients = nutrients.With(mapping.Code, single.Get(mapping.Code))
+	}
+
+	return &models.GlobalIngredient{
+		KcalPer100g: *round(kcal),
+		Fats:        macro(p, "fat_100g"),
+		Carbs:       macro(p, "carbohydrates_100g"),
+		Proteins:    macro(p, "proteins_100g"),
+		Nutrients:   nutrients.Complete(),
+		Barcode:     &barcode,
+		Source:      models.IngredientSourceOpenFoodFacts,
+		Names:       names,
+	}, nil
+}
+
+// productNames returns the product names in the app's languages. A product named
+// only in other languages is listed under the default language so it can still
+// be found by barcode.
+func productNames(p *Product) map[string]string {
+	names := make(map[string]string)
+	for offLang, appLang := range Languages {
+		if name := cleanName(p.Names[offLang], p.Brand); name != "" {
+			names[appLang] = name
+		}
+	}
+	if len(names) == 0 {
+		fallback := p.Names[p.Lang]
+		if fallback == "" {
+			fallback = p.Names[defaultOFFLanguage]
+		}
+		if name := cleanName(fallback, p.Brand); name != "" {
+			names[Languages[defaultOFFLanguage]] = name
+		}
+	}
+	return names
+}
+
+// cleanName appends the brand to tell similar products apart and truncates the
+// result to the column size
+func cleanName(name, brand string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if name == "" {
+		return ""
+	}
+	if brand != "" && !strings.Contains(strings.ToLower(name), strings.ToLower(brand)) {
+		name += " (" + brand + ")"
+	}
+	if utf8.RuneCountInString(name) > maxNameLength {
+		name = strings.TrimSpace(string([]rune(name)[:maxNameLength]))
+	}
+	return name
+}
+
+func macro(p *Product, key string) *float64 {
+	value, ok := p.Nutriments[key]
+	if !ok || value < 0 || value > maxMacroPer100g {
+		return nil
+	}
+	return round(value)
+}
+
+func round(value float64) *float64 {
+	rounded := math.Round(value*1000) / 1000
+	return &rounded
+}
@@ -0,0 +1,296 @@
+// Package openfoodfacts reads Open Food Facts data dumps and maps products to
+// global ingredients. It works on local files only and never calls the network.
+package openfoodfacts
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Format is the layout of a dump file
+type Format string
+
+const (
+	FormatCSV   Format = "csv"   // Tab-separated products export (en.openfoodfacts.org.products.csv)
+	FormatJSONL Format = "jsonl" // One product document per line (openfoodfacts-products.jsonl)
+)
+
+// Product is the part of an Open Food Facts product needed for the catalog
+type Product struct {
+	Code       string
+	Lang       string             // Main language of the product
+	Names      map[string]string  // Open Food Facts language code -> product name
+	Brand      string             // First listed brand
+	Nutriments map[string]float64 // Keys as in the dump, e.g. "energy-kcal_100g"
+}
+
+// DetectFormat guesses the format from the file name; a .gz suffix is ignored
+func DetectFormat(path string) (Format, error) {
+	name := strings.TrimSuffix(strings.ToLower(path), ".gz")
+	switch {
+	case strings.HasSuffix(name, ".csv"), strings.HasSuffix(name, ".tsv"):
+		return FormatCSV, nil
+	case strings.HasSuffix(name, ".jsonl"), strings.HasSuffix(name, ".json"), strings.HasSuffix(name, ".ndjson"):
+		return FormatJSONL, nil
+	}
+	return "", fmt.Errorf("cannot detect the format of %s, use .csv or .jsonl (optionally .gz)", path)
+}
+
+// Reader streams products from a dump file, decompressing .gz files on the fly
+type Reader struct {
+	file    *os.File
+	gzip    *gzip.Reader
+	next    func() (*Product, error)
+	line    int
+	columns map[string]int
+}
+
+// Open opens a dump file in the given format
+func Open(path string, format Format) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reader{file: file}
+	var source io.Reader = file
+	if strings.HasSuffix(strings.ToLower(path), ".gz") {
+		r.gzip, err = gzip.NewReader(file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		source = r.gzip
+	}
+
+	buffered := bufio.NewReaderSize(source, 1<<20)
+	switch format {
+	case FormatCSV:
+		err = r.initCSV(buffered)
+	case FormatJSONL:
+		r.initJSONL(buffered)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Next returns the next product, or io.EOF at the end of the file
+func (r *Reader) Next() (*Product, error) {
+	return r.next()
+}
+
+// Line returns the line (or CSV record) number of the last product read
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// Close closes the underlying file
+func (r *Reader) Close() error {
+	if r.gzip != nil {
+		r.gzip.Close()
+	}
+	return r.file.Close()
+}
+
+// initCSV reads the header of a CSV export. The official export is tab-separated
+// and not consistently quoted, so quotes are taken literally.
+func (r *Reader) initCSV(source *bufio.Reader) error {
+	header, err := source.ReadString('\n')
+	if err != nil && header == "" {
+		return fmt.Errorf("reading CSV header: %w", err)
+	}
+	header = strings.TrimRight(header, "\r\n")
+
+	comma := '\t'
+	if !strings.Contains(header, "\t") {
+		comma = ','
+	}
+
+	r.columns = make(map[string]int)
+	for i, column := range strings.Split(header, string(comma)) {
+		r.columns[strings.Trim(strings.TrimSpace(column), `"`)] = i
+	}
+	if _, ok := r.columns["code"]; !ok {
+		return errors.New("CSV header has no code column")
+	}
+
+	reader := csv.NewReader(source)
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	if comma == '\t' {
+		reader.LazyQuotes = true
+	}
+
+	r.line = 1
+	r.next = func() (*Product, error) {
+		record, err := reader.Read()
+		r.line++
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return r.productFromRecord(record), nil
+	}
+	return nil
+}
+
+func (r *Reader) productFromRecord(record []string) *Product {
+	field := func(name string) string {
+		i, ok := r.columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	product := &Product{
+		Code:       field("code"),
+		Lang:       field("lang"),
+		Brand:      firstBrand(field("brands")),
+		Names:      make(map[string]string),
+		Nutriments: make(map[string]float64),
+	}
+	for column, i := range r.columns {
+		if i >= len(record) {
+			continue
+		}
+		value := strings.TrimSpace(record[i])
+		if value == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(column, "product_name_"):
+			product.Names[strings.TrimPrefix(column, "product_name_")] = value
+		case strings.HasSuffix(column, "_100g"):
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				product.Nutriments[column] = number
+			}
+		}
+	}
+	if name := field("product_name"); name != "" && product.Lang != "" && product.Names[product.Lang] == "" {
+		product.Names[product.Lang] = name
+	}
+
+	return product
+}
+
+// initJSONL prepares reading one JSON document per line. Lines can be very long,
+// so they are read without a size limit.
+func (r *Reader) initJSONL(source *bufio.Reader) {
+	r.next = func() (*Product, error) {
+		for {
+			line, err := source.ReadBytes('\n')
+			if len(line) == 0 && err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil, io.EOF
+				}
+				return nil, err
+			}
+			r.line++
+
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+			product, parseErr := productFromJSON(line)
+			if parseErr != nil {
+				return nil, fmt.Errorf("line %d: %w", r.line, parseErr)
+			}
+			return product, nil
+		}
+	}
+}
+
+// jsonProduct holds the fixed fields of a product document
+type jsonProduct struct {
+	Code       json.RawMessage            `json:"code"`
+	Lang       string                     `json:"lang"`
+	Brands     any                        `json:"brands"`
+	Nutriments map[string]json.RawMessage `json:"nutriments"`
+}
+
+func productFromJSON(line []byte) (*Product, error) {
+	var doc jsonProduct
+	if err := json.Unmarshal(line, &doc); err != nil {
+		return nil, err
+	}
+	// Names live in product_name and product_name_<lang> keys
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return nil, err
+	}
+
+	product := &Product{
+		Code:       rawString(doc.Code),
+		Lang:       doc.Lang,
+		Names:      make(map[string]string),
+		Nutriments: make(map[string]float64),
+	}
+	if brands, ok := doc.Brands.(string); ok {
+		product.Brand = firstBrand(brands)
+	}
+
+	for key, value := range fields {
+		if key != "product_name" && !strings.HasPrefix(key, "product_name_") {
+			continue
+		}
+		name := strings.TrimSpace(rawString(value))
+		if name == "" {
+			continue
+		}
+		if key == "product_name" {
+			if product.Lang != "" && product.Names[product.Lang] == "" {
+				product.Names[product.Lang] = name
+			}
+			continue
+		}
+		product.Names[strings.TrimPrefix(key, "product_name_")] = name
+	}
+
+	for key, value := range doc.Nutriments {
+		if !strings.HasSuffix(key, "_100g") {
+			continue
+		}
+		if number, err := strconv.ParseFloat(rawString(value), 64); err == nil {
+			product.Nutriments[key] = number
+		}
+	}
+
+	return product, nil
+}
+
+// rawString returns a JSON string or number as text
+func rawString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		return n.String()
+	}
+	return ""
+}
+
+func firstBrand(brands string) string {
+	brand, _, _ := strings.Cut(brands, ",")
+	return strings.TrimSpace(brand)
+}
@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+type AIAnalysisCacheRepositoryImpl struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	sqlLoader *SqlLoaderInstance
+}
+
+// NewAIAnalysisCacheRepository creates a new AI analysis cache repository
+func NewAIAnalysisCacheRepository(db *sql.DB, dialect Dialect, logger *slog.Logger) *AIAnalysisCacheRepositoryImpl {
+	return &AIAnalysisCacheRepositoryImpl{
+		db:        db,
+		logger:    logger.With("repository", "ai_analysis_cache"),
+		sqlLoader: NewSqlLoader(dialect),
+	}
+}
+
+// Get retrieves a cached analysis by user and input hash
+func (r *AIAnalysisCacheRepositoryImpl) Get(userID int, inputHash string) (*models.AIAnalysisCache, error) {
+	query, err := r.sqlLoader.Load(QueryGetAIAnalysisCache)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	var entry models.AIAnalysisCache
+	err = r.db.QueryRow(query, userID, inputHash).Scan(
+		&entry.ID,
+		&entry.UserID,
+		&entry.InputHash,
+		&entry.Model,
+		&entry.Response,
+		&entry.TokensUsed,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		r.logger.Error("Failed to get cached analysis", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Save stores an analysis, replacing any previous entry with the same input hash
+func (r *AIAnalysisCacheRepositoryImpl) Save(userID int, inputHash, model, response string, tokensUsed int) error {
+	query, err := r.sqlLoader.Load(QueryUpsertAIAnalysisCache)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	if _, err := r.db.Exec(query, userID, inputHash, model, response, tokensUsed, time.Now().UTC()); err != nil {
+		r.logger.Error("Failed to save cached analysis", "user_id", userID, "error", err)
+		return err
+	}
+
+	r.logger.Debug("Analysis cached", "user_id", userID)
+	return nil
+}
+
+// DeleteOlderThan removes a user's cached analyses created before the given time
+func (r *AIAnalysisCacheRepositoryImpl) DeleteOlderThan(userID int, before time.Time) error {
+	query, err := r.sqlLoader.Load(QueryDeleteAIAnalysisCacheOlderThan)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	if _, err := r.db.Exec(query, userID, before.UTC()); err != nil {
+		r.logger.Error("Failed to delete stale cached analyses", "user_id", userID, "error", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+type AuditLogRepositoryImpl struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	sqlLoader *SqlLoaderInstance
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *sql.DB, dialect Dialect, logger *slog.Logger) *AuditLogRepositoryImpl {
+	return &AuditLogRepositoryImpl{
+		db:        db,
+		logger:    logger.With("repository", "audit_log"),
+		sqlLoader: NewSqlLoader(dialect),
+	}
+}
+
+// Create stores an audit log entry
+func (r *AuditLogRepositoryImpl) Create(entry *models.AuditLogEntry) error {
+	query, err := r.sqlLoader.Load(QueryCreateAuditLogEntry)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+
+	_, err = r.db.Exec(query,
+		entry.ActorUserID,
+		entry.Action,
+		entry.EntityType,
+		entry.EntityID,
+		entry.Changes,
+		entry.CreatedAt,
+	)
+	if err != nil {
+		r.logger.Error("Failed to create audit log entry", "action", entry.Action, "entity_type", entry.EntityType, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// auditRecorder stores the audit log entry of a write in the write's
+// transaction, so a change is never committed without its entry
+type auditRecorder struct {
+	sqlLoader *SqlLoaderInstance
+}
+
+func newAuditRecorder(sqlLoader *SqlLoaderInstance) *auditRecorder {
+	return &auditRecorder{sqlLoader: sqlLoader}
+}
+
+// record builds the entry of a write with audit and stores it; a nil audit records nothing
+func (a *auditRecorder) record(tx *sql.Tx, audit AuditFunc, entityID *int, after any) error {
+	if audit == nil {
+		return nil
+	}
+
+	entry, err := audit(entityID, after)
+	if err != nil {
+		return err
+	}
+
+	query, err := a.sqlLoader.Load(QueryCreateAuditLogEntry)
+	if err != nil {
+		return err
+	}
+
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+
+	_, err = tx.Exec(query, entry.ActorUserID, entry.Action, entry.EntityType, entry.EntityID, entry.Changes, entry.CreatedAt)
+	return err
+}
+
+// GetEntries returns audit log entries, newest first. Empty entityType and zero
+// entityID disable the corresponding filter.
+func (r *AuditLogRepositoryImpl) GetEntries(entityType string, entityID, limit, offset int) ([]*models.AuditLogEntry, error) {
+	query, err := r.sqlLoader.Load(QueryGetAuditLogEntries)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	var rows *sql.Rows
+	if r.sqlLoader.Dialect == DialectSQLite {
+		rows, err = r.db.Query(query, entityType, entityType, entityID, entityID, limit, offset)
+	} else {
+		rows, err = r.db.Query(query, entityType, entityID, limit, offset)
+	}
+	if err != nil {
+		r.logger.Error("Failed to get audit log entries", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditLogEntry
+	for rows.Next() {
+		var entry models.AuditLogEntry
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ActorUserID,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&entry.Changes,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+type BatchRepositoryImpl struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	sqlLoader *SqlLoaderInstance
+	calories  *CalorieEntryRepositoryImpl
+	weights   *WeightHistoryRepositoryImpl
+}
+
+// NewBatchRepository creates a new repository for batches of calorie entry and weigh-in writes
+func NewBatchRepository(db *sql.DB, dialect Dialect, logger *slog.Logger) *BatchRepositoryImpl {
+	return &BatchRepositoryImpl{
+		db:        db,
+		logger:    logger.With("repository", "batch"),
+		sqlLoader: NewSqlLoader(dialect),
+		calories:  NewCalorieEntryRepository(db, logger, dialect),
+		weights:   NewWeightHistoryRepository(db, logger, dialect),
+	}
+}
+
+// Apply runs the user's operations in order in one transaction. Operations that
+// already have an error are skipped. An operation on an entity the user does
+// not have gets ErrNotFound, and one on an entity with another version than
+// expected gets ErrVersionConflict. Such an operation is undone on its own,
+// unless atomic is set, in which case nothing is committed. Returns whether the transaction was committed.
+// Created and updated calorie entries are read back once it was.
+func (r *BatchRepositoryImpl) Apply(userID int, ops []*models.BatchOperation, atomic bool) (bool, error) {
+	r.logger.Debug("Applying batch", slog.Int("user_id", userID), slog.Int("operations", len(ops)))
+
+	queries := make(map[string]string)
+	for _, name := range []string{QueryBatchSavepoint, QueryBatchRollbackSavepoint, QueryBatchReleaseSavepoint} {
+		query, err := r.sqlLoader.Load(name)
+		if err != nil {
+			return false, err
+		}
+		queries[name] = query
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	for _, op := range ops {
+		if op.Err != nil {
+			continue
+		}
+
+		if _, err := tx.Exec(queries[QueryBatchSavepoint]); err != nil {
+			return false, err
+		}
+
+		err := r.applyOperation(tx, userID, op)
+		switch {
+		case errors.Is(err, ErrNotFound), errors.Is(err, ErrVersionConflict):
+			op.Err = err
+			if atomic {
+				return false, nil
+			}
+			if _, err := tx.Exec(queries[QueryBatchRollbackSavepoint]); err != nil {
+				return false, err
+			}
+		case err != nil:
+			r.logger.Error("Failed to apply batch operation", "user_id", userID, "action", op.Action, "entity", op.Entity, "error", err)
+			return false, err
+		}
+
+		if _, err := tx.Exec(queries[QueryBatchReleaseSavepoint]); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	for _, op := range ops {
+		if op.Err != nil || op.Entity != models.SyncEntityCalorieEntry || op.Action == models.BatchActionDelete {
+			continue
+		}
+		entry, err := r.calories.GetByID(op.ID)
+		if err != nil {
+			// The batch is saved; a missing read-back only leaves the result without data
+			r.logger.Warn("Failed to read back calorie entry", "id", op.ID, "error", err)
+			continue
+		}
+		op.CalorieEntry = entry
+	}
+
+	return true, nil
+}
+
+// applyOperation runs one operation in the transaction
+func (r *BatchRepositoryImpl) applyOperation(tx *sql.Tx, userID int, op *models.BatchOperation) error {
+	switch op.Entity {
+	case models.SyncEntityCalorieEntry:
+		switch op.Action {
+		case models.BatchActionCreate:
+			op.CalorieEntry.UserID = userID
+			id, err := r.calories.insertTx(tx, op.CalorieEntry)
+			if err != nil {
+				return err
+			}
+			op.ID = id
+			op.CalorieEntry.ID = id
+			return nil
+		case models.BatchActionUpdate:
+			op.CalorieEntry.ID = op.ID
+			op.CalorieEntry.UserID = userID
+			op.CalorieEntry.Version = op.Version
+			return r.calories.updateTx(tx, op.CalorieEntry)
+		case models.BatchActionDelete:
+			return r.calories.deleteTx(tx, op.ID, userID, op.Version)
+		}
+	case models.SyncEntityWeight:
+		switch op.Action {
+		case models.BatchActionCreate:
+			op.Weight.UserID = userID
+			if err := r.weights.insertTx(tx, op.Weight); err != nil {
+				return err
+			}
+			op.ID = op.Weight.ID
+			return nil
+		case models.BatchActionUpdate:
+			op.Weight.ID = op.ID
+			op.Weight.UserID = userID
+			op.Weight.Version = op.Version
+			return r.weights.updateTx(tx, op.Weight)
+		case models.BatchActionDelete:
+			return r.weights.deleteTx(tx, op.ID, userID, op.Version)
+		}
+	}
+	return fmt.Errorf("unsupported batch operation %q on %q", op.Action, op.Entity)
+}
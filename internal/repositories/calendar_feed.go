@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+type CalendarFeedRepositoryImpl struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	sqlLoader *SqlLoaderInstance
+}
+
+// NewCalendarFeedRepository creates a new calendar feed repository
+func NewCalendarFeedRepository(db *sql.DB, dialect Dialect, logger *slog.Logger) *CalendarFeedRepositoryImpl {
+	return &CalendarFeedRepositoryImpl{
+		db:        db,
+		logger:    logger.With("repository", "calendar_feed"),
+		sqlLoader: NewSqlLoader(dialect),
+	}
+}
+
+// Save stores the user's feed, replacing an existing one and with it the old
+// token, and sets its ID and timestamps
+func (r *CalendarFeedRepositoryImpl) Save(feed *models.CalendarFeed) error {
+	query, err := r.sqlLoader.Load(QuerySaveCalendarFeed)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	now := time.Now().UTC()
+	feed.CreatedAt = now
+	feed.UpdatedAt = now
+	feed.LastAccessedAt = nil
+	err = r.db.QueryRow(query, feed.UserID, feed.TokenHash, feed.TokenPrefix, feed.Timezone, feed.PastDays,
+		feed.CreatedAt, feed.UpdatedAt).Scan(&feed.ID)
+	if err != nil {
+		r.logger.Error("Failed to save calendar feed", "user_id", feed.UserID, "error", err)
+		return err
+	}
+
+	r.logger.Debug("Calendar feed saved", "id", feed.ID, "user_id", feed.UserID)
+	return nil
+}
+
+// GetByUserID retrieves the user's feed
+func (r *CalendarFeedRepositoryImpl) GetByUserID(userID int) (*models.CalendarFeed, error) {
+	query, err := r.sqlLoader.Load(QueryGetCalendarFeedByUserID)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	feed, err := r.scanCalendarFeed(r.db.QueryRow(query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		r.logger.Error("Failed to get calendar feed", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// GetByTokenHash retrieves the feed a token belongs to
+func (r *CalendarFeedRepositoryImpl) GetByTokenHash(tokenHash string) (*models.CalendarFeed, error) {
+	query, err := r.sqlLoader.Load(QueryGetCalendarFeedByTokenHash)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	feed, err := r.scanCalendarFeed(r.db.QueryRow(query, tokenHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		r.logger.Error("Failed to get calendar feed by token", "error", err)
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// UpdateSettings changes the time zone and window of the user's feed; the token stays the same
+func (r *CalendarFeedRepositoryImpl) UpdateSettings(feed *models.CalendarFeed) error {
+	query, err := r.sqlLoader.Load(QueryUpdateCalendarFeedSettings)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	feed.UpdatedAt = time.Now().UTC()
+	result, err := r.db.Exec(query, feed.Timezone, feed.PastDays, feed.UpdatedAt, feed.UserID)
+	if err != nil {
+		r.logger.Error("Failed to update calendar feed", "user_id", feed.UserID, "error", err)
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes the user's feed, which revokes its URL
+func (r *CalendarFeedRepositoryImpl) Delete(userID int) error {
+	query, err := r.sqlLoader.Load(QueryDeleteCalendarFeed)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	result, err := r.db.Exec(query, userID)
+	if err != nil {
+		r.logger.Error("Failed to delete calendar feed", "user_id", userID, "error", err)
+		return err
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrNotFound
+	}
+
+	r.logger.Debug("Calendar feed deleted", "user_id", userID)
+	return nil
+}
+
+// MarkAccessed records when a calendar app last fetched the feed
+func (r *CalendarFeedRepositoryImpl) MarkAccessed(id int, at time.Time) error {
+	query, err := r.sqlLoader.Load(QueryMarkCalendarFeedAccessed)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	if _, err := r.db.Exec(query, at.UTC(), id); err != nil {
+		r.logger.Error("Failed to mark calendar feed as accessed", "id", id, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// scanCalendarFeed scans a single feed from a row
+func (r *CalendarFeedRepositoryImpl) scanCalendarFeed(row scanner) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	var lastAccessedAt sql.NullTime
+
+	err := row.Scan(
+		&feed.ID,
+		&feed.UserID,
+		&feed.TokenHash,
+		&feed.TokenPrefix,
+		&feed.Timezone,
+		&feed.PastDays,
+		&lastAccessedAt,
+		&feed.CreatedAt,
+		&feed.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastAccessedAt.Valid {
+		feed.LastAccessedAt = &lastAccessedAt.Time
+	}
+
+	return &feed, nil
+}
@@ -12,6 +12,7 @@ type CalorieEntryRepositoryImpl struct {
 	db        *sql.DB
 	logger    *slog.Logger
 	sqlLoader *SqlLoaderInstance
+	changes   *changeRecorder
 }
 
 func NewCalorieEntryRepository(db *sql.DB, logger *slog.Logger, dialect Dialect) *CalorieEntryRepositoryImpl {
@@ -21,36 +22,72 @@ func NewCalorieEntryRepository(db *sql.DB, logger *slog.Logger, dialect Dialect)
 		db:        db,
 		logger:    logger,
 		sqlLoader: sqlLoader,
+		changes:   newChangeRecorder(sqlLoader),
 	}
 }
 
 func (r *CalorieEntryRepositoryImpl) Create(userID int,
 	food string, calories int, weight float64, kcalPer100g float64, fats, carbs,
-	proteins *float64, mealDatetime time.Time) (*models.CalorieEntry, error) {
+	proteins *float64, nutrients models.Nutrients, quantity *float64, unit *string, mealDatetime time.Time) (*models.CalorieEntry, error) {
 
 	r.logger.Debug("Creating calorie entry",
 		slog.Int("user_id", userID),
 		slog.String("food", food),
 		slog.Int("calories", calories))
 
-	query, err := r.sqlLoader.Load(QueryInsertCalorieEntry)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	id, err := r.insertTx(tx, &models.CalorieEntry{
+		UserID:       userID,
+		Food:         food,
+		Calories:     calories,
+		Weight:       weight,
+		KcalPer100g:  kcalPer100g,
+		Fats:         fats,
+		Carbs:        carbs,
+		Proteins:     proteins,
+		Nutrients:    nutrients,
+		Quantity:     quantity,
+		Unit:         unit,
+		MealDatetime: mealDatetime,
+	})
 	if err != nil {
 		return nil, err
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	r.logger.Debug("Calorie entry created", slog.Int("id", id))
+	return r.GetByID(id)
+}
+
+// insertTx inserts an entry in the transaction and returns its ID
+func (r *CalorieEntryRepositoryImpl) insertTx(tx *sql.Tx, e *models.CalorieEntry) (int, error) {
+	query, err := r.sqlLoader.Load(QueryInsertCalorieEntry)
+	if err != nil {
+		return 0, err
+	}
 
 	now := time.Now().UTC()
-	result, err := r.db.Exec(query, userID, food, calories, weight, kcalPer100g, fats, carbs, proteins, mealDatetime, now)
+	result, err := tx.Exec(query, e.UserID, e.Food, e.Calories, e.Weight, e.KcalPer100g, e.Fats, e.Carbs, e.Proteins, e.Nutrients, e.Quantity, e.Unit, e.MealDatetime, now)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	r.logger.Debug("Calorie entry created", slog.Int64("id", id))
-	return r.GetByID(int(id))
+	if err := r.changes.record(tx, e.UserID, models.SyncEntityCalorieEntry, int(id), false); err != nil {
+		return 0, err
+	}
+	return int(id), nil
 }
 
 func (r *CalorieEntryRepositoryImpl) GetByID(id int) (*models.CalorieEntry, error) {
@@ -72,9 +109,13 @@ func (r *CalorieEntryRepositoryImpl) GetByID(id int) (*models.CalorieEntry, erro
 		&entry.Fats,
 		&entry.Carbs,
 		&entry.Proteins,
+		&entry.Nutrients,
+		&entry.Quantity,
+		&entry.Unit,
 		&entry.MealDatetime,
 		&entry.UpdatedAt,
 		&entry.CreatedAt,
+		&entry.Version,
 	)
 
 	if err != nil {
@@ -111,9 +152,13 @@ func (r *CalorieEntryRepositoryImpl) GetByUserID(userID int) ([]*models.CalorieE
 			&entry.Fats,
 			&entry.Carbs,
 			&entry.Proteins,
+			&entry.Nutrients,
+			&entry.Quantity,
+			&entry.Unit,
 			&entry.MealDatetime,
 			&entry.UpdatedAt,
 			&entry.CreatedAt,
+			&entry.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -157,9 +202,13 @@ func (r *CalorieEntryRepositoryImpl) GetByUserIDAndDateRange(userID int, dateFro
 			&entry.Fats,
 			&entry.Carbs,
 			&entry.Proteins,
+			&entry.Nutrients,
+			&entry.Quantity,
+			&entry.Unit,
 			&entry.MealDatetime,
 			&entry.UpdatedAt,
 			&entry.CreatedAt,
+			&entry.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -170,45 +219,152 @@ func (r *CalorieEntryRepositoryImpl) GetByUserIDAndDateRange(userID int, dateFro
 	return entries, nil
 }
 
-func (r *CalorieEntryRepositoryImpl) Update(id, userID int, food string, calories int, weight float64, kcalPer100g float64, fats, carbs, proteins *float64, mealDatetime time.Time) (*models.CalorieEntry, error) {
+// GetFoodUsage counts the user's entries in the date range by food name, with
+// the time each food was last logged
+func (r *CalorieEntryRepositoryImpl) GetFoodUsage(userID int, dateFrom, dateTo string) ([]*models.FoodUsage, error) {
+	query, err := r.sqlLoader.Load(QueryGetFoodUsage)
+	if err != nil {
+		return nil, err
+	}
+
+	// Same date conditions as GetByUserIDAndDateRange
+	var rows *sql.Rows
+	if r.sqlLoader.Dialect == DialectSQLite {
+		rows, err = r.db.Query(query, userID, dateFrom, dateTo, dateFrom, dateTo)
+	} else {
+		rows, err = r.db.Query(query, userID, dateFrom, dateTo)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []*models.FoodUsage
+	for rows.Next() {
+		food := &models.FoodUsage{}
+		if err := rows.Scan(&food.Food, &food.Count, &food.LastUsedAt); err != nil {
+			return nil, err
+		}
+		usage = append(usage, food)
+	}
+
+	return usage, rows.Err()
+}
+
+// Update overwrites an entry of the user. A non-zero version makes the update
+// conditional: ErrVersionConflict is returned if the entry has another version.
+func (r *CalorieEntryRepositoryImpl) Update(id, userID int, food string, calories int, weight float64, kcalPer100g float64, fats, carbs, proteins *float64, nutrients models.Nutrients, quantity *float64, unit *string, mealDatetime time.Time, version int) (*models.CalorieEntry, error) {
 	r.logger.Debug("Updating calorie entry",
 		slog.Int("id", id),
 		slog.Int("user_id", userID),
 		slog.String("food", food))
 
-	query, err := r.sqlLoader.Load(QueryUpdateCalorieEntry)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
 	}
-
-	now := time.Now().UTC()
-	result, err := r.db.Exec(query, food, calories, weight, kcalPer100g, fats, carbs, proteins, mealDatetime, now, id, userID)
+	defer tx.Rollback()
+
+	err = r.updateTx(tx, &models.CalorieEntry{
+		ID:           id,
+		UserID:       userID,
+		Food:         food,
+		Calories:     calories,
+		Weight:       weight,
+		KcalPer100g:  kcalPer100g,
+		Fats:         fats,
+		Carbs:        carbs,
+		Proteins:     proteins,
+		Nutrients:    nutrients,
+		Quantity:     quantity,
+		Unit:         unit,
+		MealDatetime: mealDatetime,
+		Version:      version,
+	})
 	if err != nil {
 		return nil, err
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
-	rowsAffected, err := result.RowsAffected()
+	return r.GetByID(id)
+}
+
+// updateTx updates an entry of the user in the transaction and sets its new
+// version. A non-zero e.Version is the version the entry must have. Returns
+// ErrNotFound if there is no such entry and ErrVersionConflict if it has another version.
+func (r *CalorieEntryRepositoryImpl) updateTx(tx *sql.Tx, e *models.CalorieEntry) error {
+	query, err := r.sqlLoader.Load(QueryUpdateCalorieEntry)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	if rowsAffected == 0 {
-		return nil, ErrNotFound
+
+	now := time.Now().UTC()
+	err = tx.QueryRow(query, e.Food, e.Calories, e.Weight, e.KcalPer100g, e.Fats, e.Carbs, e.Proteins, e.Nutrients, e.Quantity, e.Unit, e.MealDatetime, now,
+		e.ID, e.UserID, e.Version, e.Version).Scan(&e.Version)
+	if err == sql.ErrNoRows {
+		return r.missedWriteTx(tx, e.ID, e.UserID, e.Version)
+	}
+	if err != nil {
+		return err
 	}
 
-	return r.GetByID(id)
+	return r.changes.record(tx, e.UserID, models.SyncEntityCalorieEntry, e.ID, false)
+}
+
+// missedWriteTx tells why a write of an entry matched no row: ErrVersionConflict
+// if it was conditional and the entry exists, ErrNotFound otherwise
+func (r *CalorieEntryRepositoryImpl) missedWriteTx(tx *sql.Tx, id, userID, version int) error {
+	if version == 0 {
+		return ErrNotFound
+	}
+
+	query, err := r.sqlLoader.Load(QueryGetCalorieEntryVersion)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	err = tx.QueryRow(query, id, userID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return ErrVersionConflict
 }
 
-func (r *CalorieEntryRepositoryImpl) Delete(id, userID int) error {
+// Delete moves an entry of the user to the trash. A non-zero version makes the
+// delete conditional: ErrVersionConflict is returned if the entry has another version.
+func (r *CalorieEntryRepositoryImpl) Delete(id, userID, version int) error {
 	r.logger.Debug("Deleting calorie entry",
 		slog.Int("id", id),
 		slog.Int("user_id", userID))
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.deleteTx(tx, id, userID, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteTx moves an entry of the user to the trash in the transaction. A non-zero
+// version is the version the entry must have. Returns ErrNotFound if there is no such entry
+// and ErrVersionConflict if it has another version.
+func (r *CalorieEntryRepositoryImpl) deleteTx(tx *sql.Tx, id, userID, version int) error {
 	query, err := r.sqlLoader.Load(QueryDeleteCalorieEntry)
 	if err != nil {
 		return err
 	}
 
-	result, err := r.db.Exec(query, id, userID)
+	result, err := tx.Exec(query, time.Now().UTC(), id, userID, version, version)
 	if err != nil {
 		return err
 	}
@@ -218,8 +374,175 @@ func (r *CalorieEntryRepositoryImpl) Delete(id, userID int) error {
 		return err
 	}
 	if rowsAffected == 0 {
-		return ErrNotFound
+		return r.missedWriteTx(tx, id, userID, version)
+	}
+
+	return r.changes.record(tx, userID, models.SyncEntityCalorieEntry, id, true)
+}
+
+// GetTrashed returns the user's entries in the trash, most recently deleted first
+func (r *CalorieEntryRepositoryImpl) GetTrashed(userID int) ([]*models.CalorieEntry, error) {
+	r.logger.Debug("Getting trashed calorie entries", slog.Int("user_id", userID))
+
+	query, err := r.sqlLoader.Load(QueryGetTrashedCalorieEntries)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.CalorieEntry
+	for rows.Next() {
+		entry := &models.CalorieEntry{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.UserID,
+			&entry.Food,
+			&entry.Calories,
+			&entry.Weight,
+			&entry.KcalPer100g,
+			&entry.Fats,
+			&entry.Carbs,
+			&entry.Proteins,
+			&entry.Nutrients,
+			&entry.Quantity,
+			&entry.Unit,
+			&entry.MealDatetime,
+			&entry.UpdatedAt,
+			&entry.CreatedAt,
+			&entry.Version,
+			&entry.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// Restore takes an entry of the user out of the trash and gives it a new
+// version. Returns ErrNotFound if the entry is not in the trash.
+func (r *CalorieEntryRepositoryImpl) Restore(id, userID int) (*models.CalorieEntry, error) {
+	r.logger.Debug("Restoring calorie entry",
+		slog.Int("id", id),
+		slog.Int("user_id", userID))
+
+	query, err := r.sqlLoader.Load(QueryRestoreCalorieEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, time.Now().UTC(), id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+
+	if err := r.changes.record(tx, userID, models.SyncEntityCalorieEntry, id, false); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(id)
+}
+
+// PurgeTrashed permanently deletes the entries of all users that were moved to
+// the trash before the given time. Their tombstones were recorded when they were deleted.
+func (r *CalorieEntryRepositoryImpl) PurgeTrashed(before time.Time) (int64, error) {
+	query, err := r.sqlLoader.Load(QueryPurgeTrashedCalorieEntries)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.Exec(query, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// createImportedTx inserts imported entries in the transaction and returns how
+// many were created. Entries whose import key is in existing are skipped.
+func (r *CalorieEntryRepositoryImpl) createImportedTx(tx *sql.Tx, userID int, entries []*models.ImportedCalorieEntry, existing map[string]bool) (int, error) {
+	query, err := r.sqlLoader.Load(QueryInsertImportedCalorieEntry)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	created := 0
+	for _, imported := range entries {
+		if existing[imported.ImportKey] {
+			continue
+		}
+		e := imported.Entry
+		result, err := stmt.Exec(userID, e.Food, e.Calories, e.Weight, e.KcalPer100g, e.Fats, e.Carbs, e.Proteins, e.Nutrients,
+			e.Quantity, e.Unit, e.MealDatetime, now, imported.ImportKey)
+		if err != nil {
+			return 0, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		if err := r.changes.record(tx, userID, models.SyncEntityCalorieEntry, int(id), false); err != nil {
+			return 0, err
+		}
+		existing[imported.ImportKey] = true
+		created++
+	}
+
+	return created, nil
+}
+
+// GetImportKeys returns the import keys of the user's imported entries
+func (r *CalorieEntryRepositoryImpl) GetImportKeys(userID int) (map[string]bool, error) {
+	query, err := r.sqlLoader.Load(QueryGetCalorieEntryImportKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys[key] = true
 	}
 
-	return nil
+	return keys, rows.Err()
 }
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pressly/goose/v3"
+
+	"ypeskov/kkal-tracker/internal/database"
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// newTestDB opens a migrated SQLite database in a temporary directory
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := database.New(filepath.Join(t.TempDir(), "test.db"), testLogger())
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	goose.SetLogger(goose.NopLogger())
+	if err := goose.SetDialect("sqlite3"); err != nil {
+		t.Fatalf("goose dialect: %v", err)
+	}
+	if err := goose.Up(db, filepath.Join("..", "..", "migrations")); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// newTestUser creates a user and returns its ID
+func newTestUser(t *testing.T, db *sql.DB, email string) int {
+	t.Helper()
+	user, err := NewUserRepository(db, testLogger(), DialectSQLite).Create(email, "hash")
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	return user.ID
+}
+
+// newTestCalorieRepo returns a calorie entry repository on a new database with
+// the ID of a user in it
+func newTestCalorieRepo(t *testing.T) (*CalorieEntryRepositoryImpl, int) {
+	t.Helper()
+	db := newTestDB(t)
+	return NewCalorieEntryRepository(db, testLogger(), DialectSQLite), newTestUser(t, db, "user@example.com")
+}
+
+func TestCalorieEntryConditionalWrites(t *testing.T) {
+	repo, userID := newTestCalorieRepo(t)
+	at := time.Date(2026, 3, 5, 8, 0, 0, 0, time.UTC)
+
+	entry, err := repo.Create(userID, "Oatmeal", 185, 50, 370, nil, nil, nil, nil, nil, nil, at)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	created := entry.Version
+
+	updated, err := repo.Update(entry.ID, userID, "Oatmeal", 370, 100, 370, nil, nil, nil, nil, nil, nil, at, created)
+	if err != nil {
+		t.Fatalf("Update with the current version: %v", err)
+	}
+	if updated.Version <= created {
+		t.Fatalf("version %d after update, want more than %d", updated.Version, created)
+	}
+
+	// A second writer still holding the version it read first loses
+	_, err = repo.Update(entry.ID, userID, "Porridge", 200, 54, 370, nil, nil, nil, nil, nil, nil, at, created)
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Update with a stale version: got %v, want %v", err, ErrVersionConflict)
+	}
+	if err := repo.Delete(entry.ID, userID, created); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("Delete with a stale version: got %v, want %v", err, ErrVersionConflict)
+	}
+	if current, err := repo.GetByID(entry.ID); err != nil || current.Food != "Oatmeal" || current.Version != updated.Version {
+		t.Errorf("entry after lost writes = %+v, %v, want it unchanged", current, err)
+	}
+
+	// Writes of another user's entry or of a missing entry find nothing
+	if _, err := repo.Update(entry.ID, userID+1, "Oatmeal", 1, 1, 100, nil, nil, nil, nil, nil, nil, at, updated.Version); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update of another user's entry: got %v, want %v", err, ErrNotFound)
+	}
+	if err := repo.Delete(entry.ID+100, userID, 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete of a missing entry: got %v, want %v", err, ErrNotFound)
+	}
+
+	// Version zero writes unconditionally
+	latest, err := repo.Update(entry.ID, userID, "Oat porridge", 370, 100, 370, nil, nil, nil, nil, nil, nil, at, 0)
+	if err != nil {
+		t.Fatalf("unconditional Update: %v", err)
+	}
+	if err := repo.Delete(entry.ID, userID, latest.Version); err != nil {
+		t.Fatalf("Delete with the current version: %v", err)
+	}
+	if err := repo.Delete(entry.ID, userID, latest.Version); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete of a trashed entry: got %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestGetFoodUsage(t *testing.T) {
+	repo, userID := newTestCalorieRepo(t)
+	day := time.Date(2026, 3, 5, 8, 0, 0, 0, time.UTC)
+
+	for _, meal := range []struct {
+		food string
+		at   time.Time
+	}{
+		{"Oatmeal", day},
+		{"Oatmeal", day.AddDate(0, 0, 2)},
+		{"Oatmeal", day.AddDate(0, 0, 1)},
+		{"Rice", day},
+		{"Rice", day.AddDate(0, 0, -10)}, // Before the range
+	} {
+		if _, err := repo.Create(userID, meal.food, 100, 100, 100, nil, nil, nil, nil, nil, nil, meal.at); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	trashed, err := repo.Create(userID, "Rice", 100, 100, 100, nil, nil, nil, nil, nil, nil, day)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := repo.Delete(trashed.ID, userID, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	usage, err := repo.GetFoodUsage(userID, "2026-03-01", "2026-03-31")
+	if err != nil {
+		t.Fatalf("GetFoodUsage: %v", err)
+	}
+	got := make(map[string]models.FoodUsage)
+	for _, food := range usage {
+		got[food.Food] = *food
+	}
+	want := map[string]models.FoodUsage{
+		"Oatmeal": {Food: "Oatmeal", Count: 3, LastUsedAt: day.AddDate(0, 0, 2)},
+		"Rice":    {Food: "Rice", Count: 1, LastUsedAt: day},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("usage = %+v, want %+v", got, want)
+	}
+	for food, w := range want {
+		if g := got[food]; g.Count != w.Count || !g.LastUsedAt.Equal(w.LastUsedAt) {
+			t.Errorf("usage of %s = %+v, want %+v", food, g, w)
+		}
+	}
+}
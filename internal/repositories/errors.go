@@ -5,4 +5,6 @@ import "errors"
 var (
 	ErrQueryNotFound = errors.New("query not found")
 	ErrNotFound      = errors.New("not found")
+	// ErrVersionConflict is returned by conditional writes when the row has another version than expected
+	ErrVersionConflict = errors.New("version conflict")
 )
@@ -0,0 +1,260 @@
+package repositories
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+type ExportJobRepositoryImpl struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	sqlLoader *SqlLoaderInstance
+}
+
+// NewExportJobRepository creates a new export job repository
+func NewExportJobRepository(db *sql.DB, dialect Dialect, logger *slog.Logger) *ExportJobRepositoryImpl {
+	return &ExportJobRepositoryImpl{
+		db:        db,
+		logger:    logger.With("repository", "export_job"),
+		sqlLoader: NewSqlLoader(dialect),
+	}
+}
+
+// Create stores a new pending job and sets its ID and creation time
+func (r *ExportJobRepositoryImpl) Create(job *models.ExportJob) error {
+	query, err := r.sqlLoader.Load(QueryCreateExportJob)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	job.Status = models.ExportJobPending
+	job.CreatedAt = time.Now().UTC()
+	err = r.db.QueryRow(query, job.UserID, job.Status, job.DateFrom, job.DateTo, job.DataType, job.Format,
+		job.DeliveryType, job.Language, job.Email, job.CreatedAt).Scan(&job.ID)
+	if err != nil {
+		r.logger.Error("Failed to create export job", "user_id", job.UserID, "error", err)
+		return err
+	}
+
+	r.logger.Debug("Export job created", "id", job.ID, "user_id", job.UserID)
+	return nil
+}
+
+// GetByID retrieves a user's job without its file
+func (r *ExportJobRepositoryImpl) GetByID(id, userID int) (*models.ExportJob, error) {
+	query, err := r.sqlLoader.Load(QueryGetExportJobByID)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	job, err := r.scanExportJob(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		r.logger.Error("Failed to get export job", "id", id, "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// GetByUserID returns a user's most recent jobs, newest first
+func (r *ExportJobRepositoryImpl) GetByUserID(userID, limit int) ([]*models.ExportJob, error) {
+	query, err := r.sqlLoader.Load(QueryGetExportJobsByUserID)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, userID, limit)
+	if err != nil {
+		r.logger.Error("Failed to get export jobs", "user_id", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []*models.ExportJob{}
+	for rows.Next() {
+		job, err := r.scanExportJob(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan export job", "error", err)
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+
+	return jobs, rows.Err()
+}
+
+// GetFile returns the file of a completed job, or ErrNotFound if there is none
+func (r *ExportJobRepositoryImpl) GetFile(id, userID int) ([]byte, error) {
+	query, err := r.sqlLoader.Load(QueryGetExportJobFile)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	var data []byte
+	if err := r.db.QueryRow(query, id, userID).Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		r.logger.Error("Failed to get export job file", "id", id, "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// ClaimNext marks the oldest pending job as running and returns it. Jobs left
+// running since before staleBefore are claimed again, as their worker is gone.
+// Returns nil when there is nothing to do.
+func (r *ExportJobRepositoryImpl) ClaimNext(staleBefore time.Time) (*models.ExportJob, error) {
+	query, err := r.sqlLoader.Load(QueryClaimExportJob)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	job, err := r.scanExportJob(r.db.QueryRow(query, time.Now().UTC(), staleBefore.UTC()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to claim export job", "error", err)
+		return nil, err
+	}
+
+	r.logger.Debug("Export job claimed", "id", job.ID, "attempts", job.Attempts)
+	return job, nil
+}
+
+// Complete stores the result of a job. data is nil for jobs delivered by email.
+func (r *ExportJobRepositoryImpl) Complete(id int, fileName, contentType string, data []byte, expiresAt *time.Time) error {
+	query, err := r.sqlLoader.Load(QueryCompleteExportJob)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	var expires any
+	if expiresAt != nil {
+		expires = expiresAt.UTC()
+	}
+	if _, err := r.db.Exec(query, fileName, contentType, len(data), data, time.Now().UTC(), expires, id); err != nil {
+		r.logger.Error("Failed to complete export job", "id", id, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// Fail marks a job as failed with an error message
+func (r *ExportJobRepositoryImpl) Fail(id int, message string) error {
+	query, err := r.sqlLoader.Load(QueryFailExportJob)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	if _, err := r.db.Exec(query, message, time.Now().UTC(), id); err != nil {
+		r.logger.Error("Failed to mark export job as failed", "id", id, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// ExpireFiles deletes the files of completed jobs whose download window ended before now
+func (r *ExportJobRepositoryImpl) ExpireFiles(now time.Time) (int64, error) {
+	query, err := r.sqlLoader.Load(QueryExpireExportJobFiles)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return 0, err
+	}
+
+	result, err := r.db.Exec(query, now.UTC())
+	if err != nil {
+		r.logger.Error("Failed to expire export job files", "error", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// DeleteFinishedBefore removes finished jobs completed before the given time
+// whose files, if any, have expired
+func (r *ExportJobRepositoryImpl) DeleteFinishedBefore(before time.Time) (int64, error) {
+	query, err := r.sqlLoader.Load(QueryDeleteFinishedExportJobs)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return 0, err
+	}
+
+	result, err := r.db.Exec(query, before.UTC(), before.UTC())
+	if err != nil {
+		r.logger.Error("Failed to delete finished export jobs", "error", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// scanExportJob scans a single job from a row
+func (r *ExportJobRepositoryImpl) scanExportJob(row scanner) (*models.ExportJob, error) {
+	var job models.ExportJob
+	var fileName, contentType, errorMessage sql.NullString
+	var startedAt, completedAt, expiresAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Status,
+		&job.DateFrom,
+		&job.DateTo,
+		&job.DataType,
+		&job.Format,
+		&job.DeliveryType,
+		&job.Language,
+		&job.Email,
+		&fileName,
+		&contentType,
+		&job.FileSize,
+		&errorMessage,
+		&job.Attempts,
+		&job.CreatedAt,
+		&startedAt,
+		&completedAt,
+		&expiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if fileName.Valid {
+		job.FileName = &fileName.String
+	}
+	if contentType.Valid {
+		job.ContentType = &contentType.String
+	}
+	if errorMessage.Valid {
+		job.Error = &errorMessage.String
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+	if expiresAt.Valid {
+		job.ExpiresAt = &expiresAt.Time
+	}
+
+	return &job, nil
+}
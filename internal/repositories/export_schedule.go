@@ -0,0 +1,281 @@
+package repositories
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+type ExportScheduleRepositoryImpl struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	sqlLoader *SqlLoaderInstance
+}
+
+// NewExportScheduleRepository creates a new export schedule repository
+func NewExportScheduleRepository(db *sql.DB, dialect Dialect, logger *slog.Logger) *ExportScheduleRepositoryImpl {
+	return &ExportScheduleRepositoryImpl{
+		db:        db,
+		logger:    logger.With("repository", "export_schedule"),
+		sqlLoader: NewSqlLoader(dialect),
+	}
+}
+
+// Create stores a new schedule with a fresh unsubscribe token and sets its ID and timestamps
+func (r *ExportScheduleRepositoryImpl) Create(schedule *models.ExportSchedule) error {
+	query, err := r.sqlLoader.Load(QueryCreateExportSchedule)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		r.logger.Error("Failed to generate unsubscribe token", "error", err)
+		return err
+	}
+
+	now := time.Now().UTC()
+	schedule.UnsubscribeToken = token
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	err = r.db.QueryRow(query, schedule.UserID, schedule.Kind, schedule.Frequency, schedule.DayOfWeek, schedule.DayOfMonth,
+		schedule.Hour, schedule.Timezone, schedule.DataType, schedule.Format, schedule.Enabled, schedule.UnsubscribeToken,
+		schedule.NextRunAt.UTC(), schedule.CreatedAt, schedule.UpdatedAt).Scan(&schedule.ID)
+	if err != nil {
+		r.logger.Error("Failed to create export schedule", "user_id", schedule.UserID, "error", err)
+		return err
+	}
+
+	r.logger.Debug("Export schedule created", "id", schedule.ID, "user_id", schedule.UserID)
+	return nil
+}
+
+// GetByID retrieves one of a user's schedules
+func (r *ExportScheduleRepositoryImpl) GetByID(id, userID int) (*models.ExportSchedule, error) {
+	query, err := r.sqlLoader.Load(QueryGetExportScheduleByID)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	schedule, err := r.scanExportSchedule(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		r.logger.Error("Failed to get export schedule", "id", id, "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// GetByUserID returns all schedules of a user
+func (r *ExportScheduleRepositoryImpl) GetByUserID(userID int) ([]*models.ExportSchedule, error) {
+	query, err := r.sqlLoader.Load(QueryGetExportSchedulesByUserID)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		r.logger.Error("Failed to get export schedules", "user_id", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []*models.ExportSchedule{}
+	for rows.Next() {
+		schedule, err := r.scanExportSchedule(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan export schedule", "error", err)
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+// GetByUnsubscribeToken retrieves the schedule an unsubscribe link points to
+func (r *ExportScheduleRepositoryImpl) GetByUnsubscribeToken(token string) (*models.ExportSchedule, error) {
+	query, err := r.sqlLoader.Load(QueryGetExportScheduleByToken)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	schedule, err := r.scanExportSchedule(r.db.QueryRow(query, token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		r.logger.Error("Failed to get export schedule by token", "error", err)
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// Update saves the user-editable fields and the next run of a schedule
+func (r *ExportScheduleRepositoryImpl) Update(schedule *models.ExportSchedule) error {
+	query, err := r.sqlLoader.Load(QueryUpdateExportSchedule)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	schedule.UpdatedAt = time.Now().UTC()
+	result, err := r.db.Exec(query, schedule.Kind, schedule.Frequency, schedule.DayOfWeek, schedule.DayOfMonth,
+		schedule.Hour, schedule.Timezone, schedule.DataType, schedule.Format, schedule.Enabled,
+		schedule.NextRunAt.UTC(), schedule.UpdatedAt, schedule.ID, schedule.UserID)
+	if err != nil {
+		r.logger.Error("Failed to update export schedule", "id", schedule.ID, "error", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected", "error", err)
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Delete removes one of a user's schedules
+func (r *ExportScheduleRepositoryImpl) Delete(id, userID int) error {
+	query, err := r.sqlLoader.Load(QueryDeleteExportSchedule)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	result, err := r.db.Exec(query, id, userID)
+	if err != nil {
+		r.logger.Error("Failed to delete export schedule", "id", id, "user_id", userID, "error", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected", "error", err)
+		return err
+	}
+	if rowsAffected == 0 {
+		r.logger.Debug("No export schedule deleted (not found)", "id", id, "user_id", userID)
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// Disable turns a schedule off without deleting it
+func (r *ExportScheduleRepositoryImpl) Disable(id int) error {
+	query, err := r.sqlLoader.Load(QueryDisableExportSchedule)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	if _, err := r.db.Exec(query, time.Now().UTC(), id); err != nil {
+		r.logger.Error("Failed to disable export schedule", "id", id, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimDue leases the most overdue enabled schedule to owner until leaseUntil
+// and returns it. Schedules whose lease ran out, because the instance running
+// them stopped, can be claimed again. Returns nil when nothing is due.
+func (r *ExportScheduleRepositoryImpl) ClaimDue(owner string, now, leaseUntil time.Time) (*models.ExportSchedule, error) {
+	query, err := r.sqlLoader.Load(QueryClaimDueExportSchedule)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	schedule, err := r.scanExportSchedule(r.db.QueryRow(query, owner, leaseUntil.UTC(), now.UTC(), now.UTC()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to claim export schedule", "error", err)
+		return nil, err
+	}
+
+	r.logger.Debug("Export schedule claimed", "id", schedule.ID, "owner", owner)
+	return schedule, nil
+}
+
+// FinishRun moves a schedule to its next run and releases the lease. It does
+// nothing if owner no longer holds the lease. lastRunAt is nil when the run was skipped.
+func (r *ExportScheduleRepositoryImpl) FinishRun(id int, owner string, nextRunAt time.Time, lastRunAt *time.Time) error {
+	query, err := r.sqlLoader.Load(QueryFinishExportScheduleRun)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	var lastRun any
+	if lastRunAt != nil {
+		lastRun = lastRunAt.UTC()
+	}
+	if _, err := r.db.Exec(query, nextRunAt.UTC(), lastRun, id, owner); err != nil {
+		r.logger.Error("Failed to finish export schedule run", "id", id, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// scanExportSchedule scans a single schedule from a row
+func (r *ExportScheduleRepositoryImpl) scanExportSchedule(row scanner) (*models.ExportSchedule, error) {
+	var schedule models.ExportSchedule
+	var dayOfWeek, dayOfMonth sql.NullInt64
+	var lastRunAt sql.NullTime
+
+	err := row.Scan(
+		&schedule.ID,
+		&schedule.UserID,
+		&schedule.Kind,
+		&schedule.Frequency,
+		&dayOfWeek,
+		&dayOfMonth,
+		&schedule.Hour,
+		&schedule.Timezone,
+		&schedule.DataType,
+		&schedule.Format,
+		&schedule.Enabled,
+		&schedule.UnsubscribeToken,
+		&schedule.NextRunAt,
+		&lastRunAt,
+		&schedule.CreatedAt,
+		&schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if dayOfWeek.Valid {
+		day := int(dayOfWeek.Int64)
+		schedule.DayOfWeek = &day
+	}
+	if dayOfMonth.Valid {
+		day := int(dayOfMonth.Int64)
+		schedule.DayOfMonth = &day
+	}
+	if lastRunAt.Valid {
+		schedule.LastRunAt = &lastRunAt.Time
+	}
+
+	return &schedule, nil
+}
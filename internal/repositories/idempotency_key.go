@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+type IdempotencyKeyRepositoryImpl struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	sqlLoader *SqlLoaderInstance
+}
+
+// NewIdempotencyKeyRepository creates a new idempotency key repository
+func NewIdempotencyKeyRepository(db *sql.DB, dialect Dialect, logger *slog.Logger) *IdempotencyKeyRepositoryImpl {
+	return &IdempotencyKeyRepositoryImpl{
+		db:        db,
+		logger:    logger.With("repository", "idempotency_key"),
+		sqlLoader: NewSqlLoader(dialect),
+	}
+}
+
+// Claim stores a key for a request that is about to be handled. A key that
+// expired, or whose request was abandoned before staleBefore, is taken over.
+// Returns false if the key is in use.
+func (r *IdempotencyKeyRepositoryImpl) Claim(key *models.IdempotencyKey, staleBefore time.Time) (bool, error) {
+	query, err := r.sqlLoader.Load(QueryClaimIdempotencyKey)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return false, err
+	}
+
+	now := time.Now().UTC()
+	result, err := r.db.Exec(query, key.UserID, key.Key, key.RequestHash, key.CreatedAt.UTC(), key.ExpiresAt.UTC(),
+		now, staleBefore.UTC())
+	if err != nil {
+		r.logger.Error("Failed to claim idempotency key", "user_id", key.UserID, "error", err)
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// Get retrieves a key of the user with its stored response
+func (r *IdempotencyKeyRepositoryImpl) Get(userID int, key string) (*models.IdempotencyKey, error) {
+	query, err := r.sqlLoader.Load(QueryGetIdempotencyKey)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	var stored models.IdempotencyKey
+	var headers sql.NullString
+	err = r.db.QueryRow(query, userID, key).Scan(&stored.UserID, &stored.Key, &stored.RequestHash, &stored.StatusCode,
+		&headers, &stored.ResponseBody, &stored.CreatedAt, &stored.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		r.logger.Error("Failed to get idempotency key", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	if headers.Valid && headers.String != "" {
+		if err := json.Unmarshal([]byte(headers.String), &stored.ResponseHeaders); err != nil {
+			r.logger.Error("Failed to decode stored response headers", "user_id", userID, "error", err)
+			return nil, err
+		}
+	}
+
+	return &stored, nil
+}
+
+// SaveResponse stores the response to the request of a claimed key
+func (r *IdempotencyKeyRepositoryImpl) SaveResponse(key *models.IdempotencyKey) error {
+	query, err := r.sqlLoader.Load(QuerySaveIdempotentResponse)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	headers, err := json.Marshal(key.ResponseHeaders)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.db.Exec(query, key.StatusCode, string(headers), key.ResponseBody, key.UserID, key.Key); err != nil {
+		r.logger.Error("Failed to save idempotent response", "user_id", key.UserID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// Release deletes a claimed key whose request got no response worth keeping,
+// so the request can be retried
+func (r *IdempotencyKeyRepositoryImpl) Release(userID int, key string) error {
+	query, err := r.sqlLoader.Load(QueryDeleteIdempotencyKey)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	if _, err := r.db.Exec(query, userID, key); err != nil {
+		r.logger.Error("Failed to release idempotency key", "user_id", userID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteExpired removes keys that expired before the given time
+func (r *IdempotencyKeyRepositoryImpl) DeleteExpired(before time.Time) (int64, error) {
+	query, err := r.sqlLoader.Load(QueryDeleteExpiredIdempotencyKeys)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return 0, err
+	}
+
+	result, err := r.db.Exec(query, before.UTC())
+	if err != nil {
+		r.logger.Error("Failed to delete expired idempotency keys", "error", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
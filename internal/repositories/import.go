@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"database/sql"
+	"log/slog"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+type ImportRepositoryImpl struct {
+	db          *sql.DB
+	logger      *slog.Logger
+	calories    *CalorieEntryRepositoryImpl
+	weights     *WeightHistoryRepositoryImpl
+	ingredients *IngredientRepositoryImpl
+}
+
+// NewImportRepository creates a new repository for saving imported diaries
+func NewImportRepository(db *sql.DB, dialect Dialect, logger *slog.Logger) *ImportRepositoryImpl {
+	return &ImportRepositoryImpl{
+		db:          db,
+		logger:      logger.With("repository", "import"),
+		calories:    NewCalorieEntryRepository(db, logger, dialect),
+		weights:     NewWeightHistoryRepository(db, logger, dialect),
+		ingredients: NewIngredientRepository(db, logger, dialect),
+	}
+}
+
+// CreateImported inserts the imported calorie entries, weigh-ins and new user
+// ingredients of the user in one transaction, so an import is saved completely
+// or not at all. Returns how many calorie entries were created; entries whose
+// import key the user already has are skipped.
+func (r *ImportRepositoryImpl) CreateImported(userID int, entries []*models.ImportedCalorieEntry,
+	weights []*models.WeightHistory, ingredients []*models.UserIngredient) (int, error) {
+	r.logger.Debug("Creating imported records",
+		slog.Int("user_id", userID),
+		slog.Int("entries", len(entries)),
+		slog.Int("weights", len(weights)),
+		slog.Int("ingredients", len(ingredients)))
+
+	existing, err := r.calories.GetImportKeys(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	for _, weight := range weights {
+		weight.UserID = userID
+		if err := r.weights.insertTx(tx, weight); err != nil {
+			return 0, err
+		}
+	}
+
+	for _, ingredient := range ingredients {
+		ingredient.UserID = userID
+		if err := r.ingredients.insertUserIngredientTx(tx, ingredient); err != nil {
+			return 0, err
+		}
+	}
+
+	created, err := r.calories.createImportedTx(tx, userID, entries, existing)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	r.logger.Debug("Imported records created", slog.Int("user_id", userID), slog.Int("created", created))
+	return created, nil
+}
@@ -12,6 +12,14 @@ type IngredientRepositoryImpl struct {
 	db        *sql.DB
 	logger    *slog.Logger
 	sqlLoader *SqlLoaderInstance
+	changes   *changeRecorder
+	audit     *auditRecorder
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
 }
 
 func NewIngredientRepository(db *sql.DB, logger *slog.Logger, dialect Dialect) *IngredientRepositoryImpl {
@@ -21,6 +29,8 @@ func NewIngredientRepository(db *sql.DB, logger *slog.Logger, dialect Dialect) *
 		db:        db,
 		logger:    logger.With(slog.String("repo", "IngredientRepository")),
 		sqlLoader: sqlLoader,
+		changes:   newChangeRecorder(sqlLoader),
+		audit:     newAuditRecorder(sqlLoader),
 	}
 }
 
@@ -51,6 +61,8 @@ func (r *IngredientRepositoryImpl) GetAllUserIngredients(userID int) ([]*models.
 			&ingredient.Fats,
 			&ingredient.Carbs,
 			&ingredient.Proteins,
+			&ingredient.Nutrients,
+			&ingredient.Barcode,
 			&ingredient.GlobalIngredientID,
 			&ingredient.CreatedAt,
 			&ingredient.UpdatedAt,
@@ -64,6 +76,20 @@ func (r *IngredientRepositoryImpl) GetAllUserIngredients(userID int) ([]*models.
 	return ingredients, nil
 }
 
+// GetSyncVersion returns the user's latest change feed version
+func (r *IngredientRepositoryImpl) GetSyncVersion(userID int) (int64, error) {
+	query, err := r.sqlLoader.Load(QueryGetSyncVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	if err := r.db.QueryRow(query, userID).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
 // GetUserIngredientByName Get user ingredient by name
 func (r *IngredientRepositoryImpl) GetUserIngredientByName(userID int, name string) (*models.UserIngredient, error) {
 	query, err := r.sqlLoader.Load(QueryGetUserIngredientByName)
@@ -80,6 +106,8 @@ func (r *IngredientRepositoryImpl) GetUserIngredientByName(userID int, name stri
 		&ingredient.Fats,
 		&ingredient.Carbs,
 		&ingredient.Proteins,
+		&ingredient.Nutrients,
+		&ingredient.Barcode,
 		&ingredient.GlobalIngredientID,
 		&ingredient.CreatedAt,
 		&ingredient.UpdatedAt,
@@ -93,24 +121,37 @@ func (r *IngredientRepositoryImpl) GetUserIngredientByName(userID int, name stri
 }
 
 // CreateOrUpdateUserIngredient Create or update user ingredient (check first, then insert/update)
-func (r *IngredientRepositoryImpl) CreateOrUpdateUserIngredient(userID int, name string, kcalPer100g float64, fats, carbs, proteins *float64) (*models.UserIngredient, error) {
+func (r *IngredientRepositoryImpl) CreateOrUpdateUserIngredient(userID int, name string, kcalPer100g float64, fats, carbs, proteins *float64, nutrients models.Nutrients) (*models.UserIngredient, error) {
 	r.logger.Debug("Creating or updating user ingredient",
 		slog.Int("user_id", userID),
 		slog.String("name", name),
 		slog.Float64("kcal_per_100g", kcalPer100g))
 
 	// Check if ingredient already exists
-	_, err := r.GetUserIngredientByName(userID, name)
-	if err == nil {
+	existing, lookupErr := r.GetUserIngredientByName(userID, name)
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if lookupErr == nil {
 		// Ingredient exists, update it
 		updateQuery, loadErr := r.sqlLoader.Load(QueryUpdateUserIngredientByName)
 		if loadErr != nil {
 			return nil, loadErr
 		}
-		_, updateErr := r.db.Exec(updateQuery, kcalPer100g, fats, carbs, proteins, userID, name)
+		_, updateErr := tx.Exec(updateQuery, kcalPer100g, fats, carbs, proteins, nutrients, userID, name)
 		if updateErr != nil {
 			return nil, updateErr
 		}
+		if err := r.changes.record(tx, userID, models.SyncEntityIngredient, existing.ID, false); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
 		return r.GetUserIngredientByName(userID, name)
 	}
 
@@ -119,10 +160,20 @@ func (r *IngredientRepositoryImpl) CreateOrUpdateUserIngredient(userID int, name
 	if err != nil {
 		return nil, err
 	}
-	_, err = r.db.Exec(insertQuery, userID, name, kcalPer100g, fats, carbs, proteins)
+	result, err := tx.Exec(insertQuery, userID, name, kcalPer100g, fats, carbs, proteins, nutrients)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
 	if err != nil {
 		return nil, err
 	}
+	if err := r.changes.record(tx, userID, models.SyncEntityIngredient, int(id), false); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
 	// Get the newly created ingredient
 	return r.GetUserIngredientByName(userID, name)
@@ -175,65 +226,321 @@ func (r *IngredientRepositoryImpl) CopyGlobalIngredientsToUser(userID int, langu
 		return err
 	}
 
+	if err := r.changes.recordNewIngredients(tx, userID); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
 // Admin functions for global ingredients
-func (r *IngredientRepositoryImpl) CreateGlobalIngredient(kcalPer100g float64, fats, carbs, proteins *float64, names map[string]string) (*models.GlobalIngredient, error) {
+func (r *IngredientRepositoryImpl) CreateGlobalIngredient(kcalPer100g float64, fats, carbs, proteins *float64, nutrients models.Nutrients, barcode *string, names map[string]string, audit AuditFunc) (*models.GlobalIngredient, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	// Insert global ingredient
+	ingredientID, err := r.insertGlobalIngredient(tx, &models.GlobalIngredient{
+		KcalPer100g: kcalPer100g,
+		Fats:        fats,
+		Carbs:       carbs,
+		Proteins:    proteins,
+		Nutrients:   nutrients,
+		Barcode:     barcode,
+		Source:      models.IngredientSourceManual,
+		Names:       names,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ingredient, err := r.getGlobalIngredient(tx, ingredientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.audit.record(tx, audit, &ingredientID, ingredient); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ingredient, nil
+}
+
+// CreateGlobalIngredients inserts several global ingredients in a single transaction
+// and returns their IDs in input order. Nothing is stored if any insert fails.
+func (r *IngredientRepositoryImpl) CreateGlobalIngredients(ingredients []*models.GlobalIngredient, audit AuditFunc) ([]int, error) {
+	r.logger.Debug("Creating global ingredients", slog.Int("count", len(ingredients)))
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]int, 0, len(ingredients))
+	for _, ingredient := range ingredients {
+		id, err := r.insertGlobalIngredient(tx, ingredient)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := r.audit.record(tx, audit, nil, ids); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// insertGlobalIngredient inserts a global ingredient with its names inside a transaction.
+// An empty source is stored as manual.
+func (r *IngredientRepositoryImpl) insertGlobalIngredient(tx *sql.Tx, ingredient *models.GlobalIngredient) (int, error) {
 	now := time.Now().UTC()
 	insertQuery, err := r.sqlLoader.Load(QueryInsertGlobalIngredient)
+	if err != nil {
+		return 0, err
+	}
+
+	source := ingredient.Source
+	if source == "" {
+		source = models.IngredientSourceManual
+	}
+
+	var ingredientID int
+	err = tx.QueryRow(insertQuery, ingredient.KcalPer100g, ingredient.Fats, ingredient.Carbs, ingredient.Proteins,
+		ingredient.Nutrients, ingredient.Barcode, source, now, now).Scan(&ingredientID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.insertGlobalIngredientNames(tx, ingredientID, ingredient.Names); err != nil {
+		return 0, err
+	}
+
+	return ingredientID, nil
+}
+
+// insertGlobalIngredientNames inserts per-language names of a global ingredient
+func (r *IngredientRepositoryImpl) insertGlobalIngredientNames(tx *sql.Tx, ingredientID int, names map[string]string) error {
+	nameQuery, err := r.sqlLoader.Load(QueryInsertGlobalIngredientName)
+	if err != nil {
+		return err
+	}
+	for langCode, name := range names {
+		if _, err := tx.Exec(nameQuery, ingredientID, langCode, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdateGlobalIngredient updates nutrition values and replaces all names of a global ingredient
+func (r *IngredientRepositoryImpl) UpdateGlobalIngredient(id int, kcalPer100g float64, fats, carbs, proteins *float64, nutrients models.Nutrients, barcode *string, names map[string]string, audit AuditFunc) (*models.GlobalIngredient, error) {
+	r.logger.Debug("Updating global ingredient", slog.Int("id", id))
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	updateQuery, err := r.sqlLoader.Load(QueryUpdateGlobalIngredient)
 	if err != nil {
 		return nil, err
 	}
-	result, err := tx.Exec(insertQuery, kcalPer100g, fats, carbs, proteins, now, now)
+	result, err := tx.Exec(updateQuery, kcalPer100g, fats, carbs, proteins, nutrients, barcode, time.Now().UTC(), id)
 	if err != nil {
 		return nil, err
 	}
 
-	ingredientID, err := result.LastInsertId()
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return nil, err
 	}
+	if rowsAffected == 0 {
+		return nil, sql.ErrNoRows
+	}
 
-	// Insert names
-	nameQuery, err := r.sqlLoader.Load(QueryInsertGlobalIngredientName)
+	deleteNamesQuery, err := r.sqlLoader.Load(QueryDeleteGlobalIngredientNames)
 	if err != nil {
 		return nil, err
 	}
-	for langCode, name := range names {
-		_, err = tx.Exec(nameQuery, ingredientID, langCode, name)
+	if _, err := tx.Exec(deleteNamesQuery, id); err != nil {
+		return nil, err
+	}
+
+	if err := r.insertGlobalIngredientNames(tx, id, names); err != nil {
+		return nil, err
+	}
+
+	ingredient, err := r.getGlobalIngredient(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.audit.record(tx, audit, &id, ingredient); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ingredient, nil
+}
+
+// DeleteGlobalIngredient deletes a global ingredient with its names.
+// User ingredients copied from it are kept and only lose the reference.
+func (r *IngredientRepositoryImpl) DeleteGlobalIngredient(id int, audit AuditFunc) error {
+	r.logger.Debug("Deleting global ingredient", slog.Int("id", id))
+
+	// The copies that lose the reference change for their owners
+	links, err := r.GetLinkedUserIngredientsByGlobalID(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Foreign keys are not enforced on SQLite, so dependent rows are handled explicitly
+	for _, queryName := range []string{QueryUnlinkGlobalIngredient, QueryDeleteGlobalIngredientNames} {
+		query, err := r.sqlLoader.Load(queryName)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(query, id); err != nil {
+			return err
+		}
+	}
+	for _, link := range links {
+		if err := r.changes.record(tx, link.UserID, models.SyncEntityIngredient, link.ID, false); err != nil {
+			return err
+		}
+	}
+
+	deleteQuery, err := r.sqlLoader.Load(QueryDeleteGlobalIngredient)
+	if err != nil {
+		return err
+	}
+	result, err := tx.Exec(deleteQuery, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := r.audit.record(tx, audit, &id, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetAllGlobalIngredients returns every global ingredient with its names
+func (r *IngredientRepositoryImpl) GetAllGlobalIngredients() ([]*models.GlobalIngredient, error) {
+	query, err := r.sqlLoader.Load(QueryGetAllGlobalIngredients)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ingredients []*models.GlobalIngredient
+	byID := make(map[int]*models.GlobalIngredient)
+	for rows.Next() {
+		ingredient := &models.GlobalIngredient{Names: make(map[string]string)}
+		err := rows.Scan(
+			&ingredient.ID,
+			&ingredient.KcalPer100g,
+			&ingredient.Fats,
+			&ingredient.Carbs,
+			&ingredient.Proteins,
+			&ingredient.Nutrients,
+			&ingredient.Barcode,
+			&ingredient.Source,
+			&ingredient.Version,
+			&ingredient.CreatedAt,
+			&ingredient.UpdatedAt,
+		)
 		if err != nil {
 			return nil, err
 		}
+		ingredients = append(ingredients, ingredient)
+		byID[ingredient.ID] = ingredient
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	if err = tx.Commit(); err != nil {
+	namesQuery, err := r.sqlLoader.Load(QueryGetAllGlobalIngredientNames)
+	if err != nil {
+		return nil, err
+	}
+
+	nameRows, err := r.db.Query(namesQuery)
+	if err != nil {
 		return nil, err
 	}
+	defer nameRows.Close()
+
+	for nameRows.Next() {
+		var ingredientID int
+		var langCode, name string
+		if err := nameRows.Scan(&ingredientID, &langCode, &name); err != nil {
+			return nil, err
+		}
+		if ingredient, ok := byID[ingredientID]; ok {
+			ingredient.Names[langCode] = name
+		}
+	}
 
-	return r.GetGlobalIngredientByID(int(ingredientID))
+	return ingredients, nameRows.Err()
 }
 
 func (r *IngredientRepositoryImpl) GetGlobalIngredientByID(id int) (*models.GlobalIngredient, error) {
+	return r.getGlobalIngredient(r.db, id)
+}
+
+// getGlobalIngredient reads a global ingredient with its names, also inside a transaction
+func (r *IngredientRepositoryImpl) getGlobalIngredient(q queryer, id int) (*models.GlobalIngredient, error) {
 	query, err := r.sqlLoader.Load(QueryGetGlobalIngredientByID)
 	if err != nil {
 		return nil, err
 	}
 
 	ingredient := &models.GlobalIngredient{}
-	err = r.db.QueryRow(query, id).Scan(
+	err = q.QueryRow(query, id).Scan(
 		&ingredient.ID,
 		&ingredient.KcalPer100g,
 		&ingredient.Fats,
 		&ingredient.Carbs,
 		&ingredient.Proteins,
+		&ingredient.Nutrients,
+		&ingredient.Barcode,
+		&ingredient.Source,
+		&ingredient.Version,
 		&ingredient.CreatedAt,
 		&ingredient.UpdatedAt,
 	)
@@ -243,7 +550,7 @@ func (r *IngredientRepositoryImpl) GetGlobalIngredientByID(id int) (*models.Glob
 	}
 
 	// Load names for this ingredient
-	names, err := r.getGlobalIngredientNames(ingredient.ID)
+	names, err := r.getGlobalIngredientNames(q, ingredient.ID)
 	if err != nil {
 		return nil, err
 	}
@@ -252,13 +559,13 @@ func (r *IngredientRepositoryImpl) GetGlobalIngredientByID(id int) (*models.Glob
 	return ingredient, nil
 }
 
-func (r *IngredientRepositoryImpl) getGlobalIngredientNames(ingredientID int) (map[string]string, error) {
+func (r *IngredientRepositoryImpl) getGlobalIngredientNames(q queryer, ingredientID int) (map[string]string, error) {
 	query, err := r.sqlLoader.Load(QueryGetGlobalIngredientNames)
 	if err != nil {
 		return nil, err
 	}
 
-	rows, err := r.db.Query(query, ingredientID)
+	rows, err := q.Query(query, ingredientID)
 	if err != nil {
 		return nil, err
 	}
@@ -277,6 +584,283 @@ func (r *IngredientRepositoryImpl) getGlobalIngredientNames(ingredientID int) (m
 	return names, nil
 }
 
+// GetLinkedUserIngredients returns the user's ingredients that were copied from the global catalog
+func (r *IngredientRepositoryImpl) GetLinkedUserIngredients(userID int) ([]*models.LinkedUserIngredient, error) {
+	return r.queryLinkedUserIngredients(QueryGetLinkedUserIngredients, userID)
+}
+
+// GetLinkedUserIngredientsByGlobalID returns all user copies of a global ingredient
+func (r *IngredientRepositoryImpl) GetLinkedUserIngredientsByGlobalID(globalID int) ([]*models.LinkedUserIngredient, error) {
+	return r.queryLinkedUserIngredients(QueryGetLinkedUserIngredientsByGlobal, globalID)
+}
+
+func (r *IngredientRepositoryImpl) queryLinkedUserIngredients(queryName string, arg int) ([]*models.LinkedUserIngredient, error) {
+	query, err := r.sqlLoader.Load(queryName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []*models.LinkedUserIngredient
+	for rows.Next() {
+		link := &models.LinkedUserIngredient{}
+		var syncedName sql.NullString
+		var syncedKcal sql.NullFloat64
+		synced := models.IngredientValues{}
+		err := rows.Scan(
+			&link.ID,
+			&link.UserID,
+			&link.Language,
+			&link.GlobalIngredientID,
+			&link.GlobalVersion,
+			&link.Values.Name,
+			&link.Values.KcalPer100g,
+			&link.Values.Fats,
+			&link.Values.Carbs,
+			&link.Values.Proteins,
+			&link.Values.Nutrients,
+			&syncedName,
+			&syncedKcal,
+			&synced.Fats,
+			&synced.Carbs,
+			&synced.Proteins,
+			&synced.Nutrients,
+			&link.ChangeVersion,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if syncedName.Valid && syncedKcal.Valid {
+			synced.Name = syncedName.String
+			synced.KcalPer100g = syncedKcal.Float64
+			link.Synced = &synced
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// UpdateLinkedUserIngredient stores the values, global version and sync baseline of a user copy.
+// Returns ErrVersionConflict if the copy was changed or removed since it was read.
+func (r *IngredientRepositoryImpl) UpdateLinkedUserIngredient(link *models.LinkedUserIngredient) error {
+	r.logger.Debug("Updating linked user ingredient",
+		slog.Int("user_id", link.UserID),
+		slog.Int("ingredient_id", link.ID),
+		slog.Int("global_version", link.GlobalVersion))
+
+	query, err := r.sqlLoader.Load(QueryUpdateLinkedUserIngredient)
+	if err != nil {
+		return err
+	}
+
+	synced := models.IngredientValues{}
+	var syncedName *string
+	var syncedKcal *float64
+	if link.Synced != nil {
+		synced = *link.Synced
+		syncedName = &synced.Name
+		syncedKcal = &synced.KcalPer100g
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query,
+		link.Values.Name,
+		link.Values.KcalPer100g,
+		link.Values.Fats,
+		link.Values.Carbs,
+		link.Values.Proteins,
+		link.Values.Nutrients,
+		link.GlobalVersion,
+		syncedName,
+		syncedKcal,
+		synced.Fats,
+		synced.Carbs,
+		synced.Proteins,
+		synced.Nutrients,
+		link.ID,
+		link.UserID,
+		link.ChangeVersion,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrVersionConflict
+	}
+
+	if err := r.changes.record(tx, link.UserID, models.SyncEntityIngredient, link.ID, false); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateLinkedUserIngredient copies a global ingredient to the user, using the link values
+// as both the current values and the sync baseline
+func (r *IngredientRepositoryImpl) CreateLinkedUserIngredient(link *models.LinkedUserIngredient) (int, error) {
+	r.logger.Debug("Creating linked user ingredient",
+		slog.Int("user_id", link.UserID),
+		slog.Int("global_ingredient_id", link.GlobalIngredientID),
+		slog.String("name", link.Values.Name))
+
+	query, err := r.sqlLoader.Load(QueryInsertLinkedUserIngredient)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	v := link.Values
+	var id int
+	err = tx.QueryRow(query,
+		link.UserID, v.Name, v.KcalPer100g, v.Fats, v.Carbs, v.Proteins, v.Nutrients, link.GlobalIngredientID,
+		link.GlobalVersion, v.Name, v.KcalPer100g, v.Fats, v.Carbs, v.Proteins, v.Nutrients,
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.changes.record(tx, link.UserID, models.SyncEntityIngredient, id, false); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// MergeUserIngredients deletes the merged ingredients and points everything that referenced
+// them at the kept one: calorie entries logged under a merged name are renamed, meal plan
+// items are relinked and servings move over unless the kept one has one of the same name.
+// Runs in a single transaction and returns the number of renamed calorie entries. Returns sql.ErrNoRows if any of the ingredients is missing.
+func (r *IngredientRepositoryImpl) MergeUserIngredients(userID, keepID int, mergeIDs []int) (int64, error) {
+	r.logger.Debug("Merging user ingredients",
+		slog.Int("user_id", userID),
+		slog.Int("keep_id", keepID),
+		slog.Any("merge_ids", mergeIDs))
+
+	queries := make(map[string]string)
+	for _, name := range []string{QueryGetUserIngredientName, QueryGetCalorieEntryIDsByFood, QueryRenameCalorieEntriesFood,
+		QueryRelinkMealPlanItems, QueryMoveIngredientServings, QueryDeleteIngredientServings, QueryDeleteUserIngredient} {
+		query, err := r.sqlLoader.Load(name)
+		if err != nil {
+			return 0, err
+		}
+		queries[name] = query
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var keepName string
+	if err := tx.QueryRow(queries[QueryGetUserIngredientName], userID, keepID).Scan(&keepName); err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC()
+	var renamed int64
+	for _, id := range mergeIDs {
+		var name string
+		if err := tx.QueryRow(queries[QueryGetUserIngredientName], userID, id).Scan(&name); err != nil {
+			return 0, err
+		}
+
+		if name != keepName {
+			if err := r.recordRenamedEntries(tx, queries[QueryGetCalorieEntryIDsByFood], userID, name); err != nil {
+				return 0, err
+			}
+
+			result, err := tx.Exec(queries[QueryRenameCalorieEntriesFood], keepName, now, userID, name)
+			if err != nil {
+				return 0, err
+			}
+			count, err := result.RowsAffected()
+			if err != nil {
+				return 0, err
+			}
+			renamed += count
+		}
+
+		// Foreign keys are not enforced on SQLite, so dependent rows are handled explicitly
+		if _, err := tx.Exec(queries[QueryRelinkMealPlanItems], keepID, keepName, id, userID); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(queries[QueryMoveIngredientServings], keepID, id, keepID); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(queries[QueryDeleteIngredientServings], id); err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(queries[QueryDeleteUserIngredient], userID, id); err != nil {
+			return 0, err
+		}
+		if err := r.changes.record(tx, userID, models.SyncEntityIngredient, id, true); err != nil {
+			return 0, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return renamed, nil
+}
+
+// recordRenamedEntries records the calorie entries logged under a food name as
+// changed, before they are renamed
+func (r *IngredientRepositoryImpl) recordRenamedEntries(tx *sql.Tx, query string, userID int, food string) error {
+	rows, err := tx.Query(query, userID, food)
+	if err != nil {
+		return err
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := r.changes.record(tx, userID, models.SyncEntityCalorieEntry, id, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetUserIngredientByID Get user ingredient by ID
 func (r *IngredientRepositoryImpl) GetUserIngredientByID(userID int, ingredientID int) (*models.UserIngredient, error) {
 	query, err := r.sqlLoader.Load(QueryGetUserIngredientByID)
@@ -293,6 +877,8 @@ func (r *IngredientRepositoryImpl) GetUserIngredientByID(userID int, ingredientI
 		&ingredient.Fats,
 		&ingredient.Carbs,
 		&ingredient.Proteins,
+		&ingredient.Nutrients,
+		&ingredient.Barcode,
 		&ingredient.GlobalIngredientID,
 		&ingredient.CreatedAt,
 		&ingredient.UpdatedAt,
@@ -306,32 +892,63 @@ func (r *IngredientRepositoryImpl) GetUserIngredientByID(userID int, ingredientI
 }
 
 // CreateUserIngredient Create a new user ingredient
-func (r *IngredientRepositoryImpl) CreateUserIngredient(userID int, name string, kcalPer100g float64, fats, carbs, proteins *float64) (*models.UserIngredient, error) {
+func (r *IngredientRepositoryImpl) CreateUserIngredient(userID int, name string, kcalPer100g float64, fats, carbs, proteins *float64, nutrients models.Nutrients) (*models.UserIngredient, error) {
 	r.logger.Debug("Creating user ingredient",
 		slog.Int("user_id", userID),
 		slog.String("name", name),
 		slog.Float64("kcal_per_100g", kcalPer100g))
 
-	insertQuery, err := r.sqlLoader.Load(QueryInsertUserIngredient)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
 	}
-	result, err := r.db.Exec(insertQuery, userID, name, kcalPer100g, fats, carbs, proteins)
-	if err != nil {
+	defer tx.Rollback()
+
+	ingredient := &models.UserIngredient{
+		UserID:      userID,
+		Name:        name,
+		KcalPer100g: kcalPer100g,
+		Fats:        fats,
+		Carbs:       carbs,
+		Proteins:    proteins,
+		Nutrients:   nutrients,
+	}
+	if err := r.insertUserIngredientTx(tx, ingredient); err != nil {
 		return nil, err
 	}
 
-	id, err := result.LastInsertId()
-	if err != nil {
+	if err = tx.Commit(); err != nil {
 		return nil, err
 	}
 
 	// Get the newly created ingredient
-	return r.GetUserIngredientByID(userID, int(id))
+	return r.GetUserIngredientByID(userID, ingredient.ID)
+}
+
+// insertUserIngredientTx inserts a user ingredient in the transaction and sets its ID
+func (r *IngredientRepositoryImpl) insertUserIngredientTx(tx *sql.Tx, ingredient *models.UserIngredient) error {
+	insertQuery, err := r.sqlLoader.Load(QueryInsertUserIngredient)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(insertQuery, ingredient.UserID, ingredient.Name, ingredient.KcalPer100g,
+		ingredient.Fats, ingredient.Carbs, ingredient.Proteins, ingredient.Nutrients)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	ingredient.ID = int(id)
+
+	return r.changes.record(tx, ingredient.UserID, models.SyncEntityIngredient, ingredient.ID, false)
 }
 
 // UpdateUserIngredient Update an existing user ingredient
-func (r *IngredientRepositoryImpl) UpdateUserIngredient(userID int, ingredientID int, name string, kcalPer100g float64, fats, carbs, proteins *float64) (*models.UserIngredient, error) {
+func (r *IngredientRepositoryImpl) UpdateUserIngredient(userID int, ingredientID int, name string, kcalPer100g float64, fats, carbs, proteins *float64, nutrients models.Nutrients) (*models.UserIngredient, error) {
 	r.logger.Debug("Updating user ingredient",
 		slog.Int("user_id", userID),
 		slog.Int("ingredient_id", ingredientID),
@@ -342,11 +959,30 @@ func (r *IngredientRepositoryImpl) UpdateUserIngredient(userID int, ingredientID
 	if err != nil {
 		return nil, err
 	}
-	_, err = r.db.Exec(updateQuery, name, kcalPer100g, fats, carbs, proteins, userID, ingredientID)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(updateQuery, name, kcalPer100g, fats, carbs, proteins, nutrients, userID, ingredientID)
 	if err != nil {
 		return nil, err
 	}
 
+	// Nothing to record when the ingredient does not exist; the lookup below reports it
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if rowsAffected > 0 {
+		if err := r.changes.record(tx, userID, models.SyncEntityIngredient, ingredientID, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return r.GetUserIngredientByID(userID, ingredientID)
 }
 
@@ -360,7 +996,18 @@ func (r *IngredientRepositoryImpl) DeleteUserIngredient(userID int, ingredientID
 	if err != nil {
 		return err
 	}
-	result, err := r.db.Exec(deleteQuery, userID, ingredientID)
+	deleteServingsQuery, err := r.sqlLoader.Load(QueryDeleteIngredientServings)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(deleteQuery, userID, ingredientID)
 	if err != nil {
 		return err
 	}
@@ -374,5 +1021,194 @@ func (r *IngredientRepositoryImpl) DeleteUserIngredient(userID int, ingredientID
 		return sql.ErrNoRows
 	}
 
-	return nil
+	// Foreign keys are not enforced on SQLite, so servings are deleted explicitly
+	if _, err := tx.Exec(deleteServingsQuery, ingredientID); err != nil {
+		return err
+	}
+
+	if err := r.changes.record(tx, userID, models.SyncEntityIngredient, ingredientID, true); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetIngredientUnits returns the density and named servings of a user ingredient.
+// Returns sql.ErrNoRows if the ingredient does not exist.
+func (r *IngredientRepositoryImpl) GetIngredientUnits(userID, ingredientID int) (*models.IngredientUnits, error) {
+	r.logger.Debug("Getting ingredient units",
+		slog.Int("user_id", userID),
+		slog.Int("ingredient_id", ingredientID))
+
+	densityQuery, err := r.sqlLoader.Load(QueryGetIngredientDensity)
+	if err != nil {
+		return nil, err
+	}
+
+	units := &models.IngredientUnits{IngredientID: ingredientID, Servings: []*models.IngredientServing{}}
+	if err := r.db.QueryRow(densityQuery, userID, ingredientID).Scan(&units.DensityGPerML); err != nil {
+		return nil, err
+	}
+
+	servingsQuery, err := r.sqlLoader.Load(QueryGetIngredientServings)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(servingsQuery, ingredientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		serving := &models.IngredientServing{}
+		if err := rows.Scan(&serving.ID, &serving.IngredientID, &serving.Name, &serving.Grams); err != nil {
+			return nil, err
+		}
+		units.Servings = append(units.Servings, serving)
+	}
+
+	return units, rows.Err()
+}
+
+// SetIngredientUnits replaces the density and named servings of a user ingredient.
+// Returns sql.ErrNoRows if the ingredient does not exist.
+func (r *IngredientRepositoryImpl) SetIngredientUnits(userID int, units *models.IngredientUnits) error {
+	r.logger.Debug("Setting ingredient units",
+		slog.Int("user_id", userID),
+		slog.Int("ingredient_id", units.IngredientID),
+		slog.Int("servings", len(units.Servings)))
+
+	queries := make(map[string]string)
+	for _, name := range []string{QuerySetIngredientDensity, QueryDeleteIngredientServings, QueryInsertIngredientServing} {
+		query, err := r.sqlLoader.Load(name)
+		if err != nil {
+			return err
+		}
+		queries[name] = query
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(queries[QuerySetIngredientDensity], units.DensityGPerML, userID, units.IngredientID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if _, err := tx.Exec(queries[QueryDeleteIngredientServings], units.IngredientID); err != nil {
+		return err
+	}
+	for _, serving := range units.Servings {
+		if _, err := tx.Exec(queries[QueryInsertIngredientServing], units.IngredientID, serving.Name, serving.Grams); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetUserIngredientByBarcode returns the user's ingredient with the given barcode.
+// Returns sql.ErrNoRows if there is none.
+func (r *IngredientRepositoryImpl) GetUserIngredientByBarcode(userID int, barcode string) (*models.UserIngredient, error) {
+	return r.getUserIngredient(QueryGetUserIngredientByBarcode, userID, barcode)
+}
+
+// GetUserIngredientByGlobalID returns the user's copy of a global ingredient.
+// Returns sql.ErrNoRows if the user has no copy.
+func (r *IngredientRepositoryImpl) GetUserIngredientByGlobalID(userID, globalID int) (*models.UserIngredient, error) {
+	return r.getUserIngredient(QueryGetUserIngredientByGlobalID, userID, globalID)
+}
+
+// getUserIngredient runs a query returning at most one user ingredient
+func (r *IngredientRepositoryImpl) getUserIngredient(queryName string, args ...any) (*models.UserIngredient, error) {
+	query, err := r.sqlLoader.Load(queryName)
+	if err != nil {
+		return nil, err
+	}
+
+	ingredient := &models.UserIngredient{}
+	err = r.db.QueryRow(query, args...).Scan(
+		&ingredient.ID,
+		&ingredient.UserID,
+		&ingredient.Name,
+		&ingredient.KcalPer100g,
+		&ingredient.Fats,
+		&ingredient.Carbs,
+		&ingredient.Proteins,
+		&ingredient.Nutrients,
+		&ingredient.Barcode,
+		&ingredient.GlobalIngredientID,
+		&ingredient.CreatedAt,
+		&ingredient.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return ingredient, nil
+}
+
+// SetUserIngredientBarcode sets or, with nil, clears the barcode of a user ingredient
+func (r *IngredientRepositoryImpl) SetUserIngredientBarcode(userID, ingredientID int, barcode *string) error {
+	r.logger.Debug("Setting user ingredient barcode",
+		slog.Int("user_id", userID),
+		slog.Int("ingredient_id", ingredientID))
+
+	query, err := r.sqlLoader.Load(QuerySetUserIngredientBarcode)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, barcode, userID, ingredientID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	if err := r.changes.record(tx, userID, models.SyncEntityIngredient, ingredientID, false); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetGlobalIngredientByBarcode returns the global ingredient with the given barcode.
+// Returns sql.ErrNoRows if there is none.
+func (r *IngredientRepositoryImpl) GetGlobalIngredientByBarcode(barcode string) (*models.GlobalIngredient, error) {
+	query, err := r.sqlLoader.Load(QueryGetGlobalIngredientByBarcode)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int
+	if err := r.db.QueryRow(query, barcode).Scan(&id); err != nil {
+		return nil, err
+	}
+
+	return r.GetGlobalIngredientByID(id)
 }
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// testAudit builds audit entries of an action and records what it was called with
+type testAudit struct {
+	actorID   int
+	entityIDs []*int
+	afters    []any
+}
+
+func (a *testAudit) fn(action string) AuditFunc {
+	return func(entityID *int, after any) (*models.AuditLogEntry, error) {
+		a.entityIDs = append(a.entityIDs, entityID)
+		a.afters = append(a.afters, after)
+		return &models.AuditLogEntry{ActorUserID: &a.actorID, Action: action, EntityType: "global_ingredient", EntityID: entityID}, nil
+	}
+}
+
+func failingAudit(*int, any) (*models.AuditLogEntry, error) {
+	return nil, errors.New("audit log unavailable")
+}
+
+func TestGlobalIngredientWritesAreAudited(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewIngredientRepository(db, testLogger(), DialectSQLite)
+	auditRepo := NewAuditLogRepository(db, DialectSQLite, testLogger())
+	audit := &testAudit{actorID: newTestUser(t, db, "admin@example.com")}
+	names := map[string]string{"en_US": "Oatmeal"}
+
+	created, err := repo.CreateGlobalIngredient(370, nil, nil, nil, nil, nil, names, audit.fn("create"))
+	if err != nil {
+		t.Fatalf("CreateGlobalIngredient: %v", err)
+	}
+	updated, err := repo.UpdateGlobalIngredient(created.ID, 380, nil, nil, nil, nil, nil, names, audit.fn("update"))
+	if err != nil {
+		t.Fatalf("UpdateGlobalIngredient: %v", err)
+	}
+	ids, err := repo.CreateGlobalIngredients([]*models.GlobalIngredient{
+		{KcalPer100g: 52, Names: map[string]string{"en_US": "Apple"}},
+		{KcalPer100g: 89, Names: map[string]string{"en_US": "Banana"}},
+	}, audit.fn("import"))
+	if err != nil {
+		t.Fatalf("CreateGlobalIngredients: %v", err)
+	}
+	if err := repo.DeleteGlobalIngredient(ids[0], audit.fn("delete")); err != nil {
+		t.Fatalf("DeleteGlobalIngredient: %v", err)
+	}
+
+	// The audit function gets what the write stored
+	if after, ok := audit.afters[0].(*models.GlobalIngredient); !ok || after.ID != created.ID || after.Names["en_US"] != "Oatmeal" {
+		t.Errorf("create audited %#v, want the stored ingredient", audit.afters[0])
+	}
+	if after, ok := audit.afters[1].(*models.GlobalIngredient); !ok || after.KcalPer100g != 380 || after.Version != updated.Version {
+		t.Errorf("update audited %#v, want the updated ingredient", audit.afters[1])
+	}
+	if after, ok := audit.afters[2].([]int); !ok || !slices.Equal(after, ids) || audit.entityIDs[2] != nil {
+		t.Errorf("import audited %v for entity %v, want the IDs %v", audit.afters[2], audit.entityIDs[2], ids)
+	}
+	if audit.afters[3] != nil || *audit.entityIDs[3] != ids[0] {
+		t.Errorf("delete audited %v for entity %v", audit.afters[3], audit.entityIDs[3])
+	}
+
+	entries, err := auditRepo.GetEntries("global_ingredient", 0, 10, 0)
+	if err != nil {
+		t.Fatalf("GetEntries: %v", err)
+	}
+	var actions []string
+	for _, entry := range entries {
+		actions = append(actions, entry.Action)
+	}
+	if want := []string{"delete", "import", "update", "create"}; !slices.Equal(actions, want) {
+		t.Errorf("audit log has %v, want %v", actions, want)
+	}
+}
+
+func TestGlobalIngredientWritesFailWithoutAudit(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewIngredientRepository(db, testLogger(), DialectSQLite)
+	names := map[string]string{"en_US": "Oatmeal"}
+
+	existing, err := repo.CreateGlobalIngredient(370, nil, nil, nil, nil, nil, names, nil)
+	if err != nil {
+		t.Fatalf("CreateGlobalIngredient: %v", err)
+	}
+
+	if _, err := repo.CreateGlobalIngredient(52, nil, nil, nil, nil, nil, map[string]string{"en_US": "Apple"}, failingAudit); err == nil {
+		t.Error("create succeeded without its audit entry")
+	}
+	if _, err := repo.CreateGlobalIngredients([]*models.GlobalIngredient{{KcalPer100g: 89, Names: map[string]string{"en_US": "Banana"}}}, failingAudit); err == nil {
+		t.Error("import succeeded without its audit entry")
+	}
+	if _, err := repo.UpdateGlobalIngredient(existing.ID, 400, nil, nil, nil, nil, nil, map[string]string{"en_US": "Oats"}, failingAudit); err == nil {
+		t.Error("update succeeded without its audit entry")
+	}
+	if err := repo.DeleteGlobalIngredient(existing.ID, failingAudit); err == nil {
+		t.Error("delete succeeded without its audit entry")
+	}
+
+	all, err := repo.GetAllGlobalIngredients()
+	if err != nil {
+		t.Fatalf("GetAllGlobalIngredients: %v", err)
+	}
+	if len(all) != 1 || all[0].ID != existing.ID || all[0].KcalPer100g != 370 || all[0].Names["en_US"] != "Oatmeal" {
+		t.Errorf("catalog after failed writes = %+v, want only the unchanged ingredient", all)
+	}
+}
+
+func TestUpdateLinkedUserIngredientIsConditional(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewIngredientRepository(db, testLogger(), DialectSQLite)
+	userID := newTestUser(t, db, "user@example.com")
+
+	global, err := repo.CreateGlobalIngredient(370, nil, nil, nil, nil, nil, map[string]string{"en_US": "Oatmeal"}, nil)
+	if err != nil {
+		t.Fatalf("CreateGlobalIngredient: %v", err)
+	}
+	values := models.IngredientValues{Name: "Oatmeal", KcalPer100g: 370}
+	id, err := repo.CreateLinkedUserIngredient(&models.LinkedUserIngredient{
+		UserID: userID, GlobalIngredientID: global.ID, GlobalVersion: global.Version, Values: values,
+	})
+	if err != nil {
+		t.Fatalf("CreateLinkedUserIngredient: %v", err)
+	}
+
+	links, err := repo.GetLinkedUserIngredientsByGlobalID(global.ID)
+	if err != nil || len(links) != 1 {
+		t.Fatalf("GetLinkedUserIngredientsByGlobalID = %v, %v, want the copy", links, err)
+	}
+	stale := *links[0]
+
+	// The user edits the copy after the sync read it
+	if _, err := repo.UpdateUserIngredient(userID, id, "Oatmeal", 350, nil, nil, nil, nil); err != nil {
+		t.Fatalf("UpdateUserIngredient: %v", err)
+	}
+
+	stale.Values.KcalPer100g = 380
+	if err := repo.UpdateLinkedUserIngredient(&stale); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("update of a stale copy: got %v, want %v", err, ErrVersionConflict)
+	}
+	if current, err := repo.GetUserIngredientByID(userID, id); err != nil || current.KcalPer100g != 350 {
+		t.Fatalf("copy after a stale update = %+v, %v, want the user's value", current, err)
+	}
+
+	// Read again, the update applies
+	links, err = repo.GetLinkedUserIngredients(userID)
+	if err != nil || len(links) != 1 {
+		t.Fatalf("GetLinkedUserIngredients = %v, %v, want the copy", links, err)
+	}
+	fresh := *links[0]
+	fresh.Values.KcalPer100g = 380
+	if err := repo.UpdateLinkedUserIngredient(&fresh); err != nil {
+		t.Fatalf("update of a fresh copy: %v", err)
+	}
+	if current, err := repo.GetUserIngredientByID(userID, id); err != nil || current.KcalPer100g != 380 {
+		t.Errorf("copy after the update = %+v, %v, want 380 kcal", current, err)
+	}
+	if err := repo.UpdateLinkedUserIngredient(&fresh); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("second update with the same read: got %v, want %v", err, ErrVersionConflict)
+	}
+}
@@ -12,24 +12,39 @@ type UserRepository interface {
 	CreateWithLanguage(email, passwordHash, languageCode string, isActive bool) (*models.User, error)
 	GetByID(id int) (*models.User, error)
 	GetByEmail(email string) (*models.User, error)
-	UpdateProfile(userID int, firstName, lastName *string, email string, age *int, height *float64, gender *string, weight *float64, language string, activityLevel *string) error
+	UpdateProfile(userID int, firstName, lastName *string, email string, age *int, height *float64, gender *string, weight *float64, language string, activityLevel *string, version int) error
 	AddWeightEntry(userID int, weight float64) error
 	ActivateUser(userID int) error
 	Delete(userID int) error
 	SetWeightGoal(userID int, targetWeight float64, targetDate *string, initialWeight float64) error
 	ClearWeightGoal(userID int) error
+	SetRole(userID int, role string) error
+
+	// Account deletion
+	ScheduleDeletion(userID int, purgeAt time.Time) error
+	CancelDeletion(userID int) error
+	GetDueForDeletion(now time.Time, limit int) ([]int, error)
 }
 
 // CalorieEntryRepository defines the contract for calorie entry data access
 type CalorieEntryRepository interface {
 	Create(userID int, food string, calories int, weight float64, kcalPer100g float64,
-		fats, carbs, proteins *float64, mealDatetime time.Time) (*models.CalorieEntry, error)
+		fats, carbs, proteins *float64, nutrients models.Nutrients, quantity *float64, unit *string, mealDatetime time.Time) (*models.CalorieEntry, error)
 	GetByID(id int) (*models.CalorieEntry, error)
 	GetByUserID(userID int) ([]*models.CalorieEntry, error)
 	GetByUserIDAndDateRange(userID int, dateFrom, dateTo string) ([]*models.CalorieEntry, error)
+	GetFoodUsage(userID int, dateFrom, dateTo string) ([]*models.FoodUsage, error)
 	Update(id, userID int, food string, calories int, weight float64, kcalPer100g float64,
-		fats, carbs, proteins *float64, mealDatetime time.Time) (*models.CalorieEntry, error)
-	Delete(id, userID int) error
+		fats, carbs, proteins *float64, nutrients models.Nutrients, quantity *float64, unit *string, mealDatetime time.Time, version int) (*models.CalorieEntry, error)
+	Delete(id, userID, version int) error
+
+	// Trash
+	GetTrashed(userID int) ([]*models.CalorieEntry, error)
+	Restore(id, userID int) (*models.CalorieEntry, error)
+	PurgeTrashed(before time.Time) (int64, error)
+
+	// Imports
+	GetImportKeys(userID int) (map[string]bool, error)
 }
 
 // WeightHistoryRepository defines the contract for weight history data access
@@ -37,30 +52,64 @@ type WeightHistoryRepository interface {
 	GetByUserID(userID int) ([]*models.WeightHistory, error)
 	GetByUserIDAndDateRange(userID int, dateFrom, dateTo string) ([]*models.WeightHistory, error)
 	GetLatestByUserID(userID int) (*models.WeightHistory, error)
+	GetByID(id, userID int) (*models.WeightHistory, error)
 	Create(userID int, weight float64, recordedAt *time.Time) (*models.WeightHistory, error)
-	Update(id, userID int, weight float64, recordedAt *time.Time) (*models.WeightHistory, error)
-	Delete(id, userID int) error
+	Update(id, userID int, weight float64, recordedAt *time.Time, version int) (*models.WeightHistory, error)
+	Delete(id, userID, version int) error
+
+	// Trash
+	GetTrashed(userID int) ([]*models.WeightHistory, error)
+	Restore(id, userID int) (*models.WeightHistory, error)
+	PurgeTrashed(before time.Time) (int64, error)
 }
 
 // IngredientRepository defines the contract for ingredient data access
 type IngredientRepository interface {
 	// User ingredients
 	GetAllUserIngredients(userID int) ([]*models.UserIngredient, error)
+	// GetSyncVersion returns the user's latest change feed version, which grows
+	// with every write to their calorie entries, weigh-ins and ingredients
+	GetSyncVersion(userID int) (int64, error)
 	GetUserIngredientByName(userID int, name string) (*models.UserIngredient, error)
 	GetUserIngredientByID(userID int, ingredientID int) (*models.UserIngredient, error)
 	CreateOrUpdateUserIngredient(userID int, name string, kcalPer100g float64,
-		fats, carbs, proteins *float64) (*models.UserIngredient, error)
+		fats, carbs, proteins *float64, nutrients models.Nutrients) (*models.UserIngredient, error)
 	CreateUserIngredient(userID int, name string, kcalPer100g float64,
-		fats, carbs, proteins *float64) (*models.UserIngredient, error)
+		fats, carbs, proteins *float64, nutrients models.Nutrients) (*models.UserIngredient, error)
 	UpdateUserIngredient(userID int, ingredientID int, name string, kcalPer100g float64,
-		fats, carbs, proteins *float64) (*models.UserIngredient, error)
+		fats, carbs, proteins *float64, nutrients models.Nutrients) (*models.UserIngredient, error)
 	DeleteUserIngredient(userID int, ingredientID int) error
 	CopyGlobalIngredientsToUser(userID int, languageCode string) error
 
-	// CreateGlobalIngredient Global ingredients (admin)
-	CreateGlobalIngredient(kcalPer100g float64, fats, carbs, proteins *float64,
-		names map[string]string) (*models.GlobalIngredient, error)
+	// CreateGlobalIngredient Global ingredients (admin). A non-nil audit is
+	// stored in the transaction of the write.
+	CreateGlobalIngredient(kcalPer100g float64, fats, carbs, proteins *float64, nutrients models.Nutrients, barcode *string,
+		names map[string]string, audit AuditFunc) (*models.GlobalIngredient, error)
 	GetGlobalIngredientByID(id int) (*models.GlobalIngredient, error)
+	GetAllGlobalIngredients() ([]*models.GlobalIngredient, error)
+	CreateGlobalIngredients(ingredients []*models.GlobalIngredient, audit AuditFunc) ([]int, error)
+	UpdateGlobalIngredient(id int, kcalPer100g float64, fats, carbs, proteins *float64, nutrients models.Nutrients, barcode *string,
+		names map[string]string, audit AuditFunc) (*models.GlobalIngredient, error)
+	DeleteGlobalIngredient(id int, audit AuditFunc) error
+
+	// Sync between global ingredients and user copies
+	GetLinkedUserIngredients(userID int) ([]*models.LinkedUserIngredient, error)
+	GetLinkedUserIngredientsByGlobalID(globalID int) ([]*models.LinkedUserIngredient, error)
+	UpdateLinkedUserIngredient(link *models.LinkedUserIngredient) error
+	CreateLinkedUserIngredient(link *models.LinkedUserIngredient) (int, error)
+
+	// Barcodes
+	GetUserIngredientByBarcode(userID int, barcode string) (*models.UserIngredient, error)
+	GetUserIngredientByGlobalID(userID, globalID int) (*models.UserIngredient, error)
+	SetUserIngredientBarcode(userID, ingredientID int, barcode *string) error
+	GetGlobalIngredientByBarcode(barcode string) (*models.GlobalIngredient, error)
+
+	// Units and named servings
+	GetIngredientUnits(userID, ingredientID int) (*models.IngredientUnits, error)
+	SetIngredientUnits(userID int, units *models.IngredientUnits) error
+
+	// MergeUserIngredients Merge duplicates into one ingredient
+	MergeUserIngredients(userID, keepID int, mergeIDs []int) (int64, error)
 }
 
 // APIKeyRepository defines the contract for API key data access
@@ -71,3 +120,116 @@ type APIKeyRepository interface {
 	Revoke(id, userID int) error
 	Delete(id, userID int) error
 }
+
+// MealPlanRepository defines the contract for meal plan data access
+type MealPlanRepository interface {
+	Create(plan *models.MealPlan) (*models.MealPlan, error)
+	GetByID(id, userID int) (*models.MealPlan, error)
+	GetByUserID(userID int) ([]*models.MealPlan, error)
+	Delete(id, userID int) error
+}
+
+// AIAnalysisCacheRepository defines the contract for cached AI analysis data access
+type AIAnalysisCacheRepository interface {
+	Get(userID int, inputHash string) (*models.AIAnalysisCache, error)
+	Save(userID int, inputHash, model, response string, tokensUsed int) error
+	DeleteOlderThan(userID int, before time.Time) error
+}
+
+// AuditFunc builds the audit log entry of a write from what it stored: the ID
+// of the entity, if there is a single one, and its state after the write (the
+// IDs for bulk inserts, nil for deletes). It is called inside the transaction
+// of the write; an error rolls the write back.
+type AuditFunc func(entityID *int, after any) (*models.AuditLogEntry, error)
+
+// AuditLogRepository defines the contract for audit trail data access
+type AuditLogRepository interface {
+	Create(entry *models.AuditLogEntry) error
+	GetEntries(entityType string, entityID, limit, offset int) ([]*models.AuditLogEntry, error)
+}
+
+// ExportJobRepository defines the contract for background export job data access
+type ExportJobRepository interface {
+	Create(job *models.ExportJob) error
+	GetByID(id, userID int) (*models.ExportJob, error)
+	GetByUserID(userID, limit int) ([]*models.ExportJob, error)
+	GetFile(id, userID int) ([]byte, error)
+	ClaimNext(staleBefore time.Time) (*models.ExportJob, error)
+	Complete(id int, fileName, contentType string, data []byte, expiresAt *time.Time) error
+	Fail(id int, message string) error
+	ExpireFiles(now time.Time) (int64, error)
+	DeleteFinishedBefore(before time.Time) (int64, error)
+}
+
+// ExportScheduleRepository defines the contract for scheduled export data access
+type ExportScheduleRepository interface {
+	Create(schedule *models.ExportSchedule) error
+	GetByID(id, userID int) (*models.ExportSchedule, error)
+	GetByUserID(userID int) ([]*models.ExportSchedule, error)
+	GetByUnsubscribeToken(token string) (*models.ExportSchedule, error)
+	Update(schedule *models.ExportSchedule) error
+	Delete(id, userID int) error
+	Disable(id int) error
+	ClaimDue(owner string, now, leaseUntil time.Time) (*models.ExportSchedule, error)
+	FinishRun(id int, owner string, nextRunAt time.Time, lastRunAt *time.Time) error
+}
+
+// CalendarFeedRepository defines the contract for calendar feed data access
+type CalendarFeedRepository interface {
+	Save(feed *models.CalendarFeed) error
+	GetByUserID(userID int) (*models.CalendarFeed, error)
+	GetByTokenHash(tokenHash string) (*models.CalendarFeed, error)
+	UpdateSettings(feed *models.CalendarFeed) error
+	Delete(userID int) error
+	MarkAccessed(id int, at time.Time) error
+}
+
+// WebhookRepository defines the contract for webhook and delivery data access
+type WebhookRepository interface {
+	Create(webhook *models.Webhook) error
+	GetByID(id, userID int) (*models.Webhook, error)
+	GetByUserID(userID int) ([]*models.Webhook, error)
+	GetEnabledByUserID(userID int) ([]*models.Webhook, error)
+	Update(webhook *models.Webhook) error
+	UpdateSecret(id, userID int, secret string) error
+	Delete(id, userID int) error
+	RecordSuccess(id int, at time.Time) error
+	RecordFailure(id int, at time.Time, disableAfter int) (bool, error)
+	CreateDelivery(delivery *models.WebhookDelivery) (bool, error)
+	GetDeliveries(webhookID, userID, limit int) ([]*models.WebhookDelivery, error)
+	ClaimDueDelivery(owner string, now, leaseUntil time.Time) (*models.WebhookDelivery, error)
+	FinishDelivery(delivery *models.WebhookDelivery, owner string) error
+	FailPendingDeliveries(webhookID int, message string) error
+	DeleteFinishedDeliveriesBefore(before time.Time) (int64, error)
+	QueueCheck(check *models.WebhookCheck) error
+	TakeCheck(now time.Time) (*models.WebhookCheck, error)
+}
+
+// SyncChangeRepository defines the contract for change feed data access
+type SyncChangeRepository interface {
+	GetChanges(userID int, after int64, limit int) ([]*models.SyncChange, error)
+	GetCalorieEntries(userID int, after, upTo int64) ([]*models.CalorieEntry, error)
+	GetWeightHistory(userID int, after, upTo int64) ([]*models.WeightHistory, error)
+	GetIngredients(userID int, after, upTo int64) ([]*models.UserIngredient, error)
+	DeleteTombstonesBefore(before time.Time) (int64, error)
+}
+
+// BatchRepository defines the contract for applying batches of writes
+type BatchRepository interface {
+	Apply(userID int, ops []*models.BatchOperation, atomic bool) (bool, error)
+}
+
+// ImportRepository defines the contract for saving imported diaries
+type ImportRepository interface {
+	CreateImported(userID int, entries []*models.ImportedCalorieEntry,
+		weights []*models.WeightHistory, ingredients []*models.UserIngredient) (int, error)
+}
+
+// IdempotencyKeyRepository defines the contract for idempotency key data access
+type IdempotencyKeyRepository interface {
+	Claim(key *models.IdempotencyKey, staleBefore time.Time) (bool, error)
+	Get(userID int, key string) (*models.IdempotencyKey, error)
+	SaveResponse(key *models.IdempotencyKey) error
+	Release(userID int, key string) error
+	DeleteExpired(before time.Time) (int64, error)
+}
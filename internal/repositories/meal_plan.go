@@ -0,0 +1,252 @@
+package repositories
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+type MealPlanRepositoryImpl struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	sqlLoader *SqlLoaderInstance
+}
+
+// NewMealPlanRepository creates a new meal plan repository
+func NewMealPlanRepository(db *sql.DB, dialect Dialect, logger *slog.Logger) *MealPlanRepositoryImpl {
+	return &MealPlanRepositoryImpl{
+		db:        db,
+		logger:    logger.With("repository", "meal_plan"),
+		sqlLoader: NewSqlLoader(dialect),
+	}
+}
+
+// Create stores a meal plan together with all of its items in a single transaction
+func (r *MealPlanRepositoryImpl) Create(plan *models.MealPlan) (*models.MealPlan, error) {
+	r.logger.Debug("Creating meal plan", "user_id", plan.UserID, "days", plan.Days, "items", len(plan.Items))
+
+	planQuery, err := r.sqlLoader.Load(QueryCreateMealPlan)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+	itemQuery, err := r.sqlLoader.Load(QueryCreateMealPlanItem)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(planQuery, plan.UserID, plan.Days, plan.TargetCalories, plan.TargetProteins,
+		plan.TargetFats, plan.TargetCarbs, plan.Notes, plan.Model).Scan(&plan.ID)
+	if err != nil {
+		r.logger.Error("Failed to insert meal plan", "error", err)
+		return nil, err
+	}
+
+	for _, item := range plan.Items {
+		item.PlanID = plan.ID
+		err = tx.QueryRow(itemQuery, item.PlanID, item.DayNumber, item.MealType, item.IngredientID, item.Food,
+			item.Weight, item.KcalPer100g, item.Calories, item.Fats, item.Carbs, item.Proteins).Scan(&item.ID)
+		if err != nil {
+			r.logger.Error("Failed to insert meal plan item", "plan_id", plan.ID, "error", err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit meal plan", "error", err)
+		return nil, err
+	}
+
+	plan.CreatedAt = time.Now()
+
+	r.logger.Debug("Meal plan created", "id", plan.ID, "user_id", plan.UserID)
+	return plan, nil
+}
+
+// GetByID retrieves a meal plan with its items
+func (r *MealPlanRepositoryImpl) GetByID(id, userID int) (*models.MealPlan, error) {
+	r.logger.Debug("Getting meal plan", "id", id, "user_id", userID)
+
+	query, err := r.sqlLoader.Load(QueryGetMealPlanByID)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	plan, err := r.scanMealPlan(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			r.logger.Debug("Meal plan not found", "id", id, "user_id", userID)
+			return nil, ErrNotFound
+		}
+		r.logger.Error("Failed to get meal plan", "error", err)
+		return nil, err
+	}
+
+	itemsQuery, err := r.sqlLoader.Load(QueryGetMealPlanItems)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(itemsQuery, plan.ID)
+	if err != nil {
+		r.logger.Error("Failed to query meal plan items", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	plan.Items = []*models.MealPlanItem{}
+	for rows.Next() {
+		var item models.MealPlanItem
+		err := rows.Scan(
+			&item.ID,
+			&item.PlanID,
+			&item.DayNumber,
+			&item.MealType,
+			&item.IngredientID,
+			&item.Food,
+			&item.Weight,
+			&item.KcalPer100g,
+			&item.Calories,
+			&item.Fats,
+			&item.Carbs,
+			&item.Proteins,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan meal plan item row", "error", err)
+			return nil, err
+		}
+		plan.Items = append(plan.Items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating meal plan item rows", "error", err)
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// GetByUserID retrieves all meal plans for a user without their items
+func (r *MealPlanRepositoryImpl) GetByUserID(userID int) ([]*models.MealPlan, error) {
+	r.logger.Debug("Getting meal plans for user", "user_id", userID)
+
+	query, err := r.sqlLoader.Load(QueryGetMealPlansByUserID)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		r.logger.Error("Failed to query meal plans", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []*models.MealPlan{}
+	for rows.Next() {
+		plan, err := r.scanMealPlan(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan meal plan row", "error", err)
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.logger.Error("Error iterating meal plan rows", "error", err)
+		return nil, err
+	}
+
+	r.logger.Debug("Meal plans retrieved", "user_id", userID, "count", len(plans))
+	return plans, nil
+}
+
+// Delete removes a meal plan and its items
+func (r *MealPlanRepositoryImpl) Delete(id, userID int) error {
+	r.logger.Debug("Deleting meal plan", "id", id, "user_id", userID)
+
+	planQuery, err := r.sqlLoader.Load(QueryDeleteMealPlan)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+	itemsQuery, err := r.sqlLoader.Load(QueryDeleteMealPlanItems)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(planQuery, id, userID)
+	if err != nil {
+		r.logger.Error("Failed to delete meal plan", "error", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected", "error", err)
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.logger.Debug("No meal plan deleted (not found)", "id", id, "user_id", userID)
+		return ErrNotFound
+	}
+
+	// Items are removed explicitly since SQLite does not enforce foreign keys by default
+	if _, err := tx.Exec(itemsQuery, id); err != nil {
+		r.logger.Error("Failed to delete meal plan items", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.logger.Error("Failed to commit meal plan deletion", "error", err)
+		return err
+	}
+
+	r.logger.Debug("Meal plan deleted", "id", id, "user_id", userID)
+	return nil
+}
+
+// scanMealPlan scans a single meal plan (without items) from a row
+func (r *MealPlanRepositoryImpl) scanMealPlan(row scanner) (*models.MealPlan, error) {
+	var plan models.MealPlan
+
+	err := row.Scan(
+		&plan.ID,
+		&plan.UserID,
+		&plan.Days,
+		&plan.TargetCalories,
+		&plan.TargetProteins,
+		&plan.TargetFats,
+		&plan.TargetCarbs,
+		&plan.Notes,
+		&plan.Model,
+		&plan.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &plan, nil
+}
@@ -14,14 +14,22 @@ const (
 	QueryDeleteUser            = "deleteUser"
 	QuerySetWeightGoal         = "setWeightGoal"
 	QueryClearWeightGoal       = "clearWeightGoal"
+	QuerySetUserRole           = "setUserRole"
 
 	// Group of CalorieEntries queries
 	QueryInsertCalorieEntry           = "insertCalorieEntry"
 	QueryGetCalorieEntryByID          = "getCalorieEntryByID"
 	QueryGetCalorieEntriesByUserID    = "getCalorieEntriesByUserID"
 	QueryGetCalorieEntriesByDateRange = "getCalorieEntriesByDateRange"
+	QueryGetFoodUsage                 = "getFoodUsage"
 	QueryUpdateCalorieEntry           = "updateCalorieEntry"
 	QueryDeleteCalorieEntry           = "deleteCalorieEntry"
+	QueryGetCalorieEntryVersion       = "getCalorieEntryVersion"
+	QueryGetTrashedCalorieEntries     = "getTrashedCalorieEntries"
+	QueryRestoreCalorieEntry          = "restoreCalorieEntry"
+	QueryPurgeTrashedCalorieEntries   = "purgeTrashedCalorieEntries"
+	QueryInsertImportedCalorieEntry   = "insertImportedCalorieEntry"
+	QueryGetCalorieEntryImportKeys    = "getCalorieEntryImportKeys"
 
 	// Weight History queries
 	QueryGetWeightHistory            = "getWeightHistory"
@@ -30,6 +38,11 @@ const (
 	QueryCreateWeightHistory         = "createWeightHistory"
 	QueryUpdateWeightHistory         = "updateWeightHistory"
 	QueryDeleteWeightHistory         = "deleteWeightHistory"
+	QueryGetWeightHistoryByID        = "getWeightHistoryByID"
+	QueryGetWeightHistoryVersion     = "getWeightHistoryVersion"
+	QueryGetTrashedWeightHistory     = "getTrashedWeightHistory"
+	QueryRestoreWeightHistory        = "restoreWeightHistory"
+	QueryPurgeTrashedWeightHistory   = "purgeTrashedWeightHistory"
 
 	// Ingredient queries
 	QueryGetAllUserIngredients       = "getAllUserIngredients"
@@ -45,6 +58,37 @@ const (
 	QueryInsertGlobalIngredientName  = "insertGlobalIngredientName"
 	QueryGetGlobalIngredientByID     = "getGlobalIngredientByID"
 	QueryGetGlobalIngredientNames    = "getGlobalIngredientNames"
+	QueryGetAllGlobalIngredients     = "getAllGlobalIngredients"
+	QueryGetAllGlobalIngredientNames = "getAllGlobalIngredientNames"
+	QueryUpdateGlobalIngredient      = "updateGlobalIngredient"
+	QueryDeleteGlobalIngredientNames = "deleteGlobalIngredientNames"
+	QueryDeleteGlobalIngredient      = "deleteGlobalIngredient"
+	QueryUnlinkGlobalIngredient      = "unlinkGlobalIngredient"
+
+	// Global ingredient sync queries
+	QueryGetLinkedUserIngredients         = "getLinkedUserIngredients"
+	QueryGetLinkedUserIngredientsByGlobal = "getLinkedUserIngredientsByGlobal"
+	QueryUpdateLinkedUserIngredient       = "updateLinkedUserIngredient"
+	QueryInsertLinkedUserIngredient       = "insertLinkedUserIngredient"
+
+	// Ingredient merge queries
+	QueryGetUserIngredientName    = "getUserIngredientName"
+	QueryRenameCalorieEntriesFood = "renameCalorieEntriesFood"
+	QueryRelinkMealPlanItems      = "relinkMealPlanItems"
+	QueryMoveIngredientServings   = "moveIngredientServings"
+
+	// Ingredient unit queries
+	QueryGetIngredientDensity     = "getIngredientDensity"
+	QuerySetIngredientDensity     = "setIngredientDensity"
+	QueryGetIngredientServings    = "getIngredientServings"
+	QueryInsertIngredientServing  = "insertIngredientServing"
+	QueryDeleteIngredientServings = "deleteIngredientServings"
+
+	// Barcode queries
+	QueryGetUserIngredientByBarcode   = "getUserIngredientByBarcode"
+	QueryGetUserIngredientByGlobalID  = "getUserIngredientByGlobalID"
+	QuerySetUserIngredientBarcode     = "setUserIngredientBarcode"
+	QueryGetGlobalIngredientByBarcode = "getGlobalIngredientByBarcode"
 
 	// Activation Token queries
 	QueryCreateActivationToken         = "createActivationToken"
@@ -58,6 +102,122 @@ const (
 	QueryGetAPIKeysByUserID = "getAPIKeysByUserID"
 	QueryRevokeAPIKey       = "revokeAPIKey"
 	QueryDeleteAPIKey       = "deleteAPIKey"
+
+	// Meal Plan queries
+	QueryCreateMealPlan       = "createMealPlan"
+	QueryCreateMealPlanItem   = "createMealPlanItem"
+	QueryGetMealPlanByID      = "getMealPlanByID"
+	QueryGetMealPlansByUserID = "getMealPlansByUserID"
+	QueryGetMealPlanItems     = "getMealPlanItems"
+	QueryDeleteMealPlanItems  = "deleteMealPlanItems"
+	QueryDeleteMealPlan       = "deleteMealPlan"
+
+	// AI Analysis Cache queries
+	QueryGetAIAnalysisCache             = "getAIAnalysisCache"
+	QueryUpsertAIAnalysisCache          = "upsertAIAnalysisCache"
+	QueryDeleteAIAnalysisCacheOlderThan = "deleteAIAnalysisCacheOlderThan"
+
+	// Audit Log queries
+	QueryCreateAuditLogEntry = "createAuditLogEntry"
+	QueryGetAuditLogEntries  = "getAuditLogEntries"
+
+	// Export job queries
+	QueryCreateExportJob          = "createExportJob"
+	QueryGetExportJobByID         = "getExportJobByID"
+	QueryGetExportJobsByUserID    = "getExportJobsByUserID"
+	QueryGetExportJobFile         = "getExportJobFile"
+	QueryClaimExportJob           = "claimExportJob"
+	QueryCompleteExportJob        = "completeExportJob"
+	QueryFailExportJob            = "failExportJob"
+	QueryExpireExportJobFiles     = "expireExportJobFiles"
+	QueryDeleteFinishedExportJobs = "deleteFinishedExportJobs"
+
+	// Export schedule queries
+	QueryCreateExportSchedule       = "createExportSchedule"
+	QueryGetExportScheduleByID      = "getExportScheduleByID"
+	QueryGetExportSchedulesByUserID = "getExportSchedulesByUserID"
+	QueryGetExportScheduleByToken   = "getExportScheduleByToken"
+	QueryUpdateExportSchedule       = "updateExportSchedule"
+	QueryDeleteExportSchedule       = "deleteExportSchedule"
+	QueryDisableExportSchedule      = "disableExportSchedule"
+	QueryClaimDueExportSchedule     = "claimDueExportSchedule"
+	QueryFinishExportScheduleRun    = "finishExportScheduleRun"
+
+	// Account deletion queries
+	QueryScheduleUserDeletion        = "scheduleUserDeletion"
+	QueryCancelUserDeletion          = "cancelUserDeletion"
+	QueryGetUsersDueForDeletion      = "getUsersDueForDeletion"
+	QueryPurgeUserMealPlanItems      = "purgeUserMealPlanItems"
+	QueryPurgeUserIngredientServings = "purgeUserIngredientServings"
+	QueryPurgeUserMealPlans          = "purgeUserMealPlans"
+	QueryPurgeUserCalorieEntries     = "purgeUserCalorieEntries"
+	QueryPurgeUserWeightHistory      = "purgeUserWeightHistory"
+	QueryPurgeUserIngredients        = "purgeUserIngredients"
+	QueryPurgeUserAPIKeys            = "purgeUserAPIKeys"
+	QueryPurgeUserActivationTokens   = "purgeUserActivationTokens"
+	QueryPurgeUserAICache            = "purgeUserAICache"
+	QueryPurgeUserExportJobs         = "purgeUserExportJobs"
+	QueryPurgeUserExportSchedules    = "purgeUserExportSchedules"
+	QueryPurgeUserAuditLog           = "purgeUserAuditLog" // Keeps the entries without their actor
+	QueryPurgeUserCalendarFeeds      = "purgeUserCalendarFeeds"
+	QueryPurgeUserWebhookDeliveries  = "purgeUserWebhookDeliveries"
+	QueryPurgeUserWebhookChecks      = "purgeUserWebhookChecks"
+	QueryPurgeUserWebhooks           = "purgeUserWebhooks"
+	QueryPurgeUserSyncChanges        = "purgeUserSyncChanges"
+	QueryPurgeUserIdempotencyKeys    = "purgeUserIdempotencyKeys"
+
+	// Calendar feed queries
+	QuerySaveCalendarFeed           = "saveCalendarFeed"
+	QueryGetCalendarFeedByUserID    = "getCalendarFeedByUserID"
+	QueryGetCalendarFeedByTokenHash = "getCalendarFeedByTokenHash"
+	QueryUpdateCalendarFeedSettings = "updateCalendarFeedSettings"
+	QueryDeleteCalendarFeed         = "deleteCalendarFeed"
+	QueryMarkCalendarFeedAccessed   = "markCalendarFeedAccessed"
+
+	// Webhook queries
+	QueryCreateWebhook                   = "createWebhook"
+	QueryGetWebhookByID                  = "getWebhookByID"
+	QueryGetWebhooksByUserID             = "getWebhooksByUserID"
+	QueryGetEnabledWebhooksByUserID      = "getEnabledWebhooksByUserID"
+	QueryUpdateWebhook                   = "updateWebhook"
+	QueryUpdateWebhookSecret             = "updateWebhookSecret"
+	QueryDeleteWebhook                   = "deleteWebhook"
+	QueryRecordWebhookSuccess            = "recordWebhookSuccess"
+	QueryRecordWebhookFailure            = "recordWebhookFailure"
+	QueryCreateWebhookDelivery           = "createWebhookDelivery"
+	QueryGetWebhookDeliveries            = "getWebhookDeliveries"
+	QueryClaimDueWebhookDelivery         = "claimDueWebhookDelivery"
+	QueryFinishWebhookDelivery           = "finishWebhookDelivery"
+	QueryFailPendingWebhookDeliveries    = "failPendingWebhookDeliveries"
+	QueryDeleteWebhookDeliveries         = "deleteWebhookDeliveries"
+	QueryDeleteFinishedWebhookDeliveries = "deleteFinishedWebhookDeliveries"
+	QueryQueueWebhookCheck               = "queueWebhookCheck"
+	QueryTakeWebhookCheck                = "takeWebhookCheck"
+
+	// Change feed queries
+	QueryNextSyncVersion            = "nextSyncVersion"
+	QueryGetSyncVersion             = "getSyncVersion"
+	QueryRecordSyncChange           = "recordSyncChange"
+	QueryCountUntrackedIngredients  = "countUntrackedIngredients"
+	QueryRecordUntrackedIngredients = "recordUntrackedIngredients"
+	QueryGetCalorieEntryIDsByFood   = "getCalorieEntryIDsByFood"
+	QueryGetSyncChanges             = "getSyncChanges"
+	QueryGetSyncedCalorieEntries    = "getSyncedCalorieEntries"
+	QueryGetSyncedWeightHistory     = "getSyncedWeightHistory"
+	QueryGetSyncedIngredients       = "getSyncedIngredients"
+	QueryDeleteSyncTombstones       = "deleteSyncTombstones"
+
+	// Batch write queries
+	QueryBatchSavepoint         = "batchSavepoint"
+	QueryBatchRollbackSavepoint = "batchRollbackSavepoint"
+	QueryBatchReleaseSavepoint  = "batchReleaseSavepoint"
+
+	// Idempotency key queries
+	QueryClaimIdempotencyKey          = "claimIdempotencyKey"
+	QueryGetIdempotencyKey            = "getIdempotencyKey"
+	QuerySaveIdempotentResponse       = "saveIdempotentResponse"
+	QueryDeleteIdempotencyKey         = "deleteIdempotencyKey"
+	QueryDeleteExpiredIdempotencyKeys = "deleteExpiredIdempotencyKeys"
 )
 
 // buildKey creates a query key by combining query name and dialect
@@ -83,63 +243,73 @@ func getQueries() map[string]string {
 	`,
 
 		buildKey(QueryGetUserByEmail, DialectSQLite): `
-		SELECT id, email, password_hash, is_active, first_name, last_name, age, height, gender, language, activity_level, 
-		       target_weight, target_date, goal_set_at, initial_weight_at_goal, created_at, updated_at
+		SELECT id, email, password_hash, is_active, role, first_name, last_name, age, height, gender, language, activity_level, 
+		       target_weight, target_date, goal_set_at, initial_weight_at_goal, deletion_scheduled_at, created_at, updated_at,
+		       profile_version
 		FROM users
 		WHERE email = ?
 	`,
 		buildKey(QueryGetUserByEmail, DialectPostgres): `
-		SELECT id, email, password_hash, is_active, first_name, last_name, age, height, gender, language, activity_level,
-		       target_weight, target_date, goal_set_at, initial_weight_at_goal, created_at, updated_at
+		SELECT id, email, password_hash, is_active, role, first_name, last_name, age, height, gender, language, activity_level,
+		       target_weight, target_date, goal_set_at, initial_weight_at_goal, deletion_scheduled_at, created_at, updated_at,
+		       profile_version
 		FROM users
 		WHERE email = $1
 	`,
 
 		buildKey(QueryGetUserByID, DialectSQLite): `
-		SELECT id, email, password_hash, is_active, first_name, last_name, age, height, gender, language, activity_level,
-		       target_weight, target_date, goal_set_at, initial_weight_at_goal, created_at, updated_at
+		SELECT id, email, password_hash, is_active, role, first_name, last_name, age, height, gender, language, activity_level,
+		       target_weight, target_date, goal_set_at, initial_weight_at_goal, deletion_scheduled_at, created_at, updated_at,
+		       profile_version
 		FROM users
 		WHERE id = ?
 	`,
 		buildKey(QueryGetUserByID, DialectPostgres): `
-		SELECT id, email, password_hash, is_active, first_name, last_name, age, height, gender, language, activity_level,
-		       target_weight, target_date, goal_set_at, initial_weight_at_goal, created_at, updated_at
+		SELECT id, email, password_hash, is_active, role, first_name, last_name, age, height, gender, language, activity_level,
+		       target_weight, target_date, goal_set_at, initial_weight_at_goal, deletion_scheduled_at, created_at, updated_at,
+		       profile_version
 		FROM users
 		WHERE id = $1
 	`,
 
 		buildKey(QueryCopyGlobalIngredients, DialectSQLite): `
-		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins, global_ingredient_id)
-		SELECT ?, gin.name, gi.kcal_per_100g, gi.fats, gi.carbs, gi.proteins, gi.id
+		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, global_ingredient_id,
+		                              global_version, synced_name, synced_kcal_per_100g, synced_fats, synced_carbs, synced_proteins, synced_nutrients)
+		SELECT ?, gin.name, gi.kcal_per_100g, gi.fats, gi.carbs, gi.proteins, gi.nutrients, gi.id,
+		       gi.version, gin.name, gi.kcal_per_100g, gi.fats, gi.carbs, gi.proteins, gi.nutrients
 		FROM global_ingredients gi
 		JOIN global_ingredient_names gin ON gi.id = gin.ingredient_id
-		WHERE gin.language_code = ?
+		WHERE gin.language_code = ? AND gi.source = 'manual'
 		AND gi.id IN (
 			SELECT MIN(gi2.id)
 			FROM global_ingredients gi2
 			JOIN global_ingredient_names gin2 ON gi2.id = gin2.ingredient_id
-			WHERE gin2.language_code = ? AND gin2.name = gin.name
+			WHERE gin2.language_code = ? AND gin2.name = gin.name AND gi2.source = 'manual'
 		)
 	`,
 		buildKey(QueryCopyGlobalIngredients, DialectPostgres): `
-		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins, global_ingredient_id)
-		SELECT DISTINCT ON (gin.name) $1, gin.name, gi.kcal_per_100g, gi.fats, gi.carbs, gi.proteins, gi.id
+		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, global_ingredient_id,
+		                              global_version, synced_name, synced_kcal_per_100g, synced_fats, synced_carbs, synced_proteins, synced_nutrients)
+		SELECT DISTINCT ON (gin.name) $1, gin.name, gi.kcal_per_100g, gi.fats, gi.carbs, gi.proteins, gi.nutrients, gi.id,
+		       gi.version, gin.name, gi.kcal_per_100g, gi.fats, gi.carbs, gi.proteins, gi.nutrients
 		FROM global_ingredients gi
 		JOIN global_ingredient_names gin ON gi.id = gin.ingredient_id
-		WHERE gin.language_code = $2
+		WHERE gin.language_code = $2 AND gi.source = 'manual'
 		ORDER BY gin.name, gi.id
 	`,
 
 		buildKey(QueryUpdateUserProfile, DialectSQLite): `
 		UPDATE users
-		SET first_name = ?, last_name = ?, email = ?, age = ?, height = ?, gender = ?, language = ?, activity_level = ?, updated_at = datetime('now')
-		WHERE id = ?
+		SET first_name = ?, last_name = ?, email = ?, age = ?, height = ?, gender = ?, language = ?, activity_level = ?, updated_at = datetime('now'),
+		    profile_version = profile_version + 1
+		WHERE id = ? AND (? = 0 OR profile_version = ?)
 	`,
 
 		buildKey(QueryUpdateUserProfile, DialectPostgres): `UPDATE users
 		SET first_name = $1, last_name = $2, email = $3, age = $4, height = $5,
-		    gender = $6, language = $7, activity_level = $8, updated_at = NOW()
-		WHERE id = $9
+		    gender = $6, language = $7, activity_level = $8, updated_at = NOW(),
+		    profile_version = profile_version + 1
+		WHERE id = $9 AND ($10 = 0 OR profile_version = $11)
 	`,
 
 		buildKey(QueryAddWeightEntry, DialectSQLite): `
@@ -171,64 +341,79 @@ func getQueries() map[string]string {
 
 		buildKey(QuerySetWeightGoal, DialectSQLite): `
 		UPDATE users
-		SET target_weight = ?, target_date = ?, goal_set_at = datetime('now'), initial_weight_at_goal = ?, updated_at = datetime('now')
+		SET target_weight = ?, target_date = ?, goal_set_at = datetime('now'), initial_weight_at_goal = ?, updated_at = datetime('now'),
+		    profile_version = profile_version + 1
 		WHERE id = ?
 	`,
 		buildKey(QuerySetWeightGoal, DialectPostgres): `
 		UPDATE users
-		SET target_weight = $1, target_date = $2, goal_set_at = NOW(), initial_weight_at_goal = $3, updated_at = NOW()
+		SET target_weight = $1, target_date = $2, goal_set_at = NOW(), initial_weight_at_goal = $3, updated_at = NOW(),
+		    profile_version = profile_version + 1
 		WHERE id = $4
 	`,
 
 		buildKey(QueryClearWeightGoal, DialectSQLite): `
 		UPDATE users
-		SET target_weight = NULL, target_date = NULL, goal_set_at = NULL, initial_weight_at_goal = NULL, updated_at = datetime('now')
+		SET target_weight = NULL, target_date = NULL, goal_set_at = NULL, initial_weight_at_goal = NULL, updated_at = datetime('now'),
+		    profile_version = profile_version + 1
 		WHERE id = ?
 	`,
 		buildKey(QueryClearWeightGoal, DialectPostgres): `
 		UPDATE users
-		SET target_weight = NULL, target_date = NULL, goal_set_at = NULL, initial_weight_at_goal = NULL, updated_at = NOW()
+		SET target_weight = NULL, target_date = NULL, goal_set_at = NULL, initial_weight_at_goal = NULL, updated_at = NOW(),
+		    profile_version = profile_version + 1
 		WHERE id = $1
 	`,
 
+		buildKey(QuerySetUserRole, DialectSQLite): `
+		UPDATE users
+		SET role = ?, updated_at = datetime('now')
+		WHERE id = ?
+	`,
+		buildKey(QuerySetUserRole, DialectPostgres): `
+		UPDATE users
+		SET role = $1, updated_at = NOW()
+		WHERE id = $2
+	`,
+
 		// Weight History queries
 		buildKey(QueryGetWeightHistory, DialectSQLite): `
-		SELECT id, user_id, weight, recorded_at, created_at
+		SELECT id, user_id, weight, recorded_at, created_at, version
 		FROM weight_history
-		WHERE user_id = ?
+		WHERE user_id = ? AND deleted_at IS NULL
 		ORDER BY recorded_at DESC
 	`,
 		buildKey(QueryGetWeightHistory, DialectPostgres): `
-		SELECT id, user_id, weight, recorded_at, created_at
+		SELECT id, user_id, weight, recorded_at, created_at, version
 		FROM weight_history
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL
 		ORDER BY recorded_at DESC
 	`,
 
 		buildKey(QueryGetWeightHistoryByDateRange, DialectSQLite): `
-		SELECT id, user_id, weight, recorded_at, created_at
+		SELECT id, user_id, weight, recorded_at, created_at, version
 		FROM weight_history
-		WHERE user_id = ? AND substr(recorded_at, 1, 10) >= ? AND substr(recorded_at, 1, 10) <= ?
+		WHERE user_id = ? AND deleted_at IS NULL AND substr(recorded_at, 1, 10) >= ? AND substr(recorded_at, 1, 10) <= ?
 		ORDER BY recorded_at ASC
 	`,
 		buildKey(QueryGetWeightHistoryByDateRange, DialectPostgres): `
-		SELECT id, user_id, weight, recorded_at, created_at
+		SELECT id, user_id, weight, recorded_at, created_at, version
 		FROM weight_history
-		WHERE user_id = $1 AND DATE(recorded_at) >= $2 AND DATE(recorded_at) <= $3
+		WHERE user_id = $1 AND deleted_at IS NULL AND DATE(recorded_at) >= $2 AND DATE(recorded_at) <= $3
 		ORDER BY recorded_at ASC
 	`,
 
 		buildKey(QueryGetLatestWeightHistory, DialectSQLite): `
-		SELECT id, user_id, weight, recorded_at, created_at
+		SELECT id, user_id, weight, recorded_at, created_at, version
 		FROM weight_history
-		WHERE user_id = ?
+		WHERE user_id = ? AND deleted_at IS NULL
 		ORDER BY recorded_at DESC
 		LIMIT 1
 	`,
 		buildKey(QueryGetLatestWeightHistory, DialectPostgres): `
-		SELECT id, user_id, weight, recorded_at, created_at
+		SELECT id, user_id, weight, recorded_at, created_at, version
 		FROM weight_history
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL
 		ORDER BY recorded_at DESC
 		LIMIT 1
 	`,
@@ -244,160 +429,300 @@ func getQueries() map[string]string {
 
 		buildKey(QueryUpdateWeightHistory, DialectSQLite): `
 		UPDATE weight_history
-		SET weight = ?, recorded_at = ?
-		WHERE id = ? AND user_id = ?
+		SET weight = ?, recorded_at = ?, version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)
+		RETURNING version, created_at
 	`,
 		buildKey(QueryUpdateWeightHistory, DialectPostgres): `
 		UPDATE weight_history
-		SET weight = $1, recorded_at = $2
-		WHERE id = $3 AND user_id = $4
+		SET weight = $1, recorded_at = $2, version = version + 1
+		WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL AND ($5 = 0 OR version = $6)
+		RETURNING version, created_at
 	`,
 
 		buildKey(QueryDeleteWeightHistory, DialectSQLite): `
-		DELETE FROM weight_history
-		WHERE id = ? AND user_id = ?
+		UPDATE weight_history
+		SET deleted_at = ?
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)
 	`,
 		buildKey(QueryDeleteWeightHistory, DialectPostgres): `
-		DELETE FROM weight_history
-		WHERE id = $1 AND user_id = $2
+		UPDATE weight_history
+		SET deleted_at = $1
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL AND ($4 = 0 OR version = $5)
+	`,
+
+		buildKey(QueryGetWeightHistoryByID, DialectSQLite): `
+		SELECT id, user_id, weight, recorded_at, created_at, version
+		FROM weight_history
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL
+	`,
+		buildKey(QueryGetWeightHistoryByID, DialectPostgres): `
+		SELECT id, user_id, weight, recorded_at, created_at, version
+		FROM weight_history
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`,
+
+		buildKey(QueryGetWeightHistoryVersion, DialectSQLite): `
+		SELECT version FROM weight_history WHERE id = ? AND user_id = ? AND deleted_at IS NULL
+	`,
+		buildKey(QueryGetWeightHistoryVersion, DialectPostgres): `
+		SELECT version FROM weight_history WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`,
+
+		buildKey(QueryGetTrashedWeightHistory, DialectSQLite): `
+		SELECT id, user_id, weight, recorded_at, created_at, version, deleted_at
+		FROM weight_history
+		WHERE user_id = ? AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`,
+		buildKey(QueryGetTrashedWeightHistory, DialectPostgres): `
+		SELECT id, user_id, weight, recorded_at, created_at, version, deleted_at
+		FROM weight_history
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`,
+
+		buildKey(QueryRestoreWeightHistory, DialectSQLite): `
+		UPDATE weight_history
+		SET deleted_at = NULL, version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL
+	`,
+		buildKey(QueryRestoreWeightHistory, DialectPostgres): `
+		UPDATE weight_history
+		SET deleted_at = NULL, version = version + 1
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+	`,
+
+		buildKey(QueryPurgeTrashedWeightHistory, DialectSQLite): `
+		DELETE FROM weight_history WHERE deleted_at < ?
+	`,
+		buildKey(QueryPurgeTrashedWeightHistory, DialectPostgres): `
+		DELETE FROM weight_history WHERE deleted_at < $1
 	`,
 
 		// CalorieEntry queries
 		buildKey(QueryInsertCalorieEntry, DialectSQLite): `
-		INSERT INTO calorie_entries (user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, meal_datetime, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO calorie_entries (user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, nutrients, quantity, unit, meal_datetime, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		buildKey(QueryInsertCalorieEntry, DialectPostgres): `
-		INSERT INTO calorie_entries (user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, meal_datetime, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO calorie_entries (user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, nutrients, quantity, unit, meal_datetime, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`,
 
 		buildKey(QueryGetCalorieEntryByID, DialectSQLite): `
-		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, meal_datetime, updated_at, created_at
+		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, nutrients, quantity, unit, meal_datetime, updated_at, created_at, version
 		FROM calorie_entries
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`,
 		buildKey(QueryGetCalorieEntryByID, DialectPostgres): `
-		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, meal_datetime, updated_at, created_at
+		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, nutrients, quantity, unit, meal_datetime, updated_at, created_at, version
 		FROM calorie_entries
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`,
 
 		buildKey(QueryGetCalorieEntriesByUserID, DialectSQLite): `
-		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, meal_datetime, updated_at, created_at
+		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, nutrients, quantity, unit, meal_datetime, updated_at, created_at, version
 		FROM calorie_entries
-		WHERE user_id = ?
+		WHERE user_id = ? AND deleted_at IS NULL
 		ORDER BY meal_datetime DESC, created_at DESC
 	`,
 		buildKey(QueryGetCalorieEntriesByUserID, DialectPostgres): `
-		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, meal_datetime, updated_at, created_at
+		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, nutrients, quantity, unit, meal_datetime, updated_at, created_at, version
 		FROM calorie_entries
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL
 		ORDER BY meal_datetime DESC, created_at DESC
 	`,
 
 		buildKey(QueryGetCalorieEntriesByDateRange, DialectSQLite): `
-		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, meal_datetime, updated_at, created_at
+		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, nutrients, quantity, unit, meal_datetime, updated_at, created_at, version
 		FROM calorie_entries
-		WHERE user_id = ? AND (
+		WHERE user_id = ? AND deleted_at IS NULL AND (
 			strftime('%Y-%m-%d', meal_datetime) BETWEEN ? AND ?
 			OR strftime('%Y-%m-%d', substr(meal_datetime, 1, 19)) BETWEEN ? AND ?
 		)
 		ORDER BY meal_datetime DESC
 	`,
 		buildKey(QueryGetCalorieEntriesByDateRange, DialectPostgres): `
-		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, meal_datetime, updated_at, created_at
+		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, nutrients, quantity, unit, meal_datetime, updated_at, created_at, version
 		FROM calorie_entries
-		WHERE user_id = $1 AND DATE(meal_datetime) BETWEEN $2 AND $3
+		WHERE user_id = $1 AND deleted_at IS NULL AND DATE(meal_datetime) BETWEEN $2 AND $3
 		ORDER BY meal_datetime DESC
 	`,
 
+		// The latest meal time is read through a window so it keeps the column
+		// type; MAX would return it as text
+		buildKey(QueryGetFoodUsage, DialectSQLite): `
+		SELECT food, uses, meal_datetime
+		FROM (
+			SELECT food, meal_datetime,
+			       COUNT(*) OVER (PARTITION BY food) AS uses,
+			       ROW_NUMBER() OVER (PARTITION BY food ORDER BY meal_datetime DESC) AS position
+			FROM calorie_entries
+			WHERE user_id = ? AND deleted_at IS NULL AND (
+				strftime('%Y-%m-%d', meal_datetime) BETWEEN ? AND ?
+				OR strftime('%Y-%m-%d', substr(meal_datetime, 1, 19)) BETWEEN ? AND ?
+			)
+		)
+		WHERE position = 1
+	`,
+		buildKey(QueryGetFoodUsage, DialectPostgres): `
+		SELECT food, COUNT(*), MAX(meal_datetime)
+		FROM calorie_entries
+		WHERE user_id = $1 AND deleted_at IS NULL AND DATE(meal_datetime) BETWEEN $2 AND $3
+		GROUP BY food
+	`,
+
 		buildKey(QueryUpdateCalorieEntry, DialectSQLite): `
 		UPDATE calorie_entries
-		SET food = ?, calories = ?, weight = ?, kcal_per_100g = ?, fats = ?, carbs = ?, proteins = ?, meal_datetime = ?, updated_at = ?
-		WHERE id = ? AND user_id = ?
+		SET food = ?, calories = ?, weight = ?, kcal_per_100g = ?, fats = ?, carbs = ?, proteins = ?, nutrients = ?, quantity = ?, unit = ?, meal_datetime = ?, updated_at = ?,
+		    version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)
+		RETURNING version
 	`,
 		buildKey(QueryUpdateCalorieEntry, DialectPostgres): `
 		UPDATE calorie_entries
-		SET food = $1, calories = $2, weight = $3, kcal_per_100g = $4, fats = $5, carbs = $6, proteins = $7, meal_datetime = $8, updated_at = $9
-		WHERE id = $10 AND user_id = $11
+		SET food = $1, calories = $2, weight = $3, kcal_per_100g = $4, fats = $5, carbs = $6, proteins = $7, nutrients = $8, quantity = $9, unit = $10, meal_datetime = $11, updated_at = $12,
+		    version = version + 1
+		WHERE id = $13 AND user_id = $14 AND deleted_at IS NULL AND ($15 = 0 OR version = $16)
+		RETURNING version
 	`,
 
 		buildKey(QueryDeleteCalorieEntry, DialectSQLite): `
-		DELETE FROM calorie_entries
-		WHERE id = ? AND user_id = ?
+		UPDATE calorie_entries
+		SET deleted_at = ?
+		WHERE id = ? AND user_id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)
 	`,
 		buildKey(QueryDeleteCalorieEntry, DialectPostgres): `
-		DELETE FROM calorie_entries
-		WHERE id = $1 AND user_id = $2
+		UPDATE calorie_entries
+		SET deleted_at = $1
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL AND ($4 = 0 OR version = $5)
+	`,
+
+		buildKey(QueryGetCalorieEntryVersion, DialectSQLite): `
+		SELECT version FROM calorie_entries WHERE id = ? AND user_id = ? AND deleted_at IS NULL
+	`,
+		buildKey(QueryGetCalorieEntryVersion, DialectPostgres): `
+		SELECT version FROM calorie_entries WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
+	`,
+
+		buildKey(QueryGetTrashedCalorieEntries, DialectSQLite): `
+		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, nutrients, quantity, unit, meal_datetime, updated_at, created_at, version, deleted_at
+		FROM calorie_entries
+		WHERE user_id = ? AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`,
+		buildKey(QueryGetTrashedCalorieEntries, DialectPostgres): `
+		SELECT id, user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, nutrients, quantity, unit, meal_datetime, updated_at, created_at, version, deleted_at
+		FROM calorie_entries
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`,
+
+		buildKey(QueryRestoreCalorieEntry, DialectSQLite): `
+		UPDATE calorie_entries
+		SET deleted_at = NULL, updated_at = ?, version = version + 1
+		WHERE id = ? AND user_id = ? AND deleted_at IS NOT NULL
+	`,
+		buildKey(QueryRestoreCalorieEntry, DialectPostgres): `
+		UPDATE calorie_entries
+		SET deleted_at = NULL, updated_at = $1, version = version + 1
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NOT NULL
+	`,
+
+		buildKey(QueryPurgeTrashedCalorieEntries, DialectSQLite): `
+		DELETE FROM calorie_entries WHERE deleted_at < ?
+	`,
+		buildKey(QueryPurgeTrashedCalorieEntries, DialectPostgres): `
+		DELETE FROM calorie_entries WHERE deleted_at < $1
+	`,
+
+		buildKey(QueryInsertImportedCalorieEntry, DialectSQLite): `
+		INSERT INTO calorie_entries (user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, nutrients, quantity, unit, meal_datetime, updated_at, import_key)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		buildKey(QueryInsertImportedCalorieEntry, DialectPostgres): `
+		INSERT INTO calorie_entries (user_id, food, calories, weight, kcal_per_100g, fats, carbs, proteins, nutrients, quantity, unit, meal_datetime, updated_at, import_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+	`,
+
+		buildKey(QueryGetCalorieEntryImportKeys, DialectSQLite): `
+		SELECT import_key
+		FROM calorie_entries
+		WHERE user_id = ? AND import_key IS NOT NULL
+	`,
+		buildKey(QueryGetCalorieEntryImportKeys, DialectPostgres): `
+		SELECT import_key
+		FROM calorie_entries
+		WHERE user_id = $1 AND import_key IS NOT NULL
 	`,
 
 		// Ingredient queries
 		buildKey(QueryGetAllUserIngredients, DialectSQLite): `
-		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, global_ingredient_id, created_at, updated_at
+		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, global_ingredient_id, created_at, updated_at
 		FROM user_ingredients
 		WHERE user_id = ?
 		ORDER BY name
 	`,
 		//noinspection SqlDialectInspection,SqlResolve
 		buildKey(QueryGetAllUserIngredients, DialectPostgres): `
-		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, global_ingredient_id, created_at, updated_at
+		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, global_ingredient_id, created_at, updated_at
 		FROM user_ingredients
 		WHERE user_id = $1
 		ORDER BY name
 	`,
 
 		buildKey(QueryGetUserIngredientByName, DialectSQLite): `
-		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, global_ingredient_id, created_at, updated_at
+		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, global_ingredient_id, created_at, updated_at
 		FROM user_ingredients
 		WHERE user_id = ? AND name = ?
 	`,
 		buildKey(QueryGetUserIngredientByName, DialectPostgres): `
-		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, global_ingredient_id, created_at, updated_at
+		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, global_ingredient_id, created_at, updated_at
 		FROM user_ingredients
 		WHERE user_id = $1 AND name = $2
 	`,
 
 		buildKey(QueryGetUserIngredientByID, DialectSQLite): `
-		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, global_ingredient_id, created_at, updated_at
+		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, global_ingredient_id, created_at, updated_at
 		FROM user_ingredients
 		WHERE user_id = ? AND id = ?
 	`,
 		buildKey(QueryGetUserIngredientByID, DialectPostgres): `
-		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, global_ingredient_id, created_at, updated_at
+		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, global_ingredient_id, created_at, updated_at
 		FROM user_ingredients
 		WHERE user_id = $1 AND id = $2
 	`,
 
 		buildKey(QueryUpdateUserIngredient, DialectSQLite): `
 		UPDATE user_ingredients
-		SET name = ?, kcal_per_100g = ?, fats = ?, carbs = ?, proteins = ?, updated_at = CURRENT_TIMESTAMP
+		SET name = ?, kcal_per_100g = ?, fats = ?, carbs = ?, proteins = ?, nutrients = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE user_id = ? AND id = ?
 	`,
 		buildKey(QueryUpdateUserIngredient, DialectPostgres): `
 		UPDATE user_ingredients
-		SET name = $1, kcal_per_100g = $2, fats = $3, carbs = $4, proteins = $5, updated_at = CURRENT_TIMESTAMP
-		WHERE user_id = $6 AND id = $7
+		SET name = $1, kcal_per_100g = $2, fats = $3, carbs = $4, proteins = $5, nutrients = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $7 AND id = $8
 	`,
 
 		buildKey(QueryUpdateUserIngredientByName, DialectSQLite): `
 		UPDATE user_ingredients
-		SET kcal_per_100g = ?, fats = ?, carbs = ?, proteins = ?, updated_at = CURRENT_TIMESTAMP
+		SET kcal_per_100g = ?, fats = ?, carbs = ?, proteins = ?, nutrients = ?, updated_at = CURRENT_TIMESTAMP
 		WHERE user_id = ? AND name = ?
 	`,
 		buildKey(QueryUpdateUserIngredientByName, DialectPostgres): `
 		UPDATE user_ingredients
-		SET kcal_per_100g = $1, fats = $2, carbs = $3, proteins = $4, updated_at = CURRENT_TIMESTAMP
-		WHERE user_id = $5 AND name = $6
+		SET kcal_per_100g = $1, fats = $2, carbs = $3, proteins = $4, nutrients = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $6 AND name = $7
 	`,
 
 		buildKey(QueryInsertUserIngredient, DialectSQLite): `
-		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`,
 		buildKey(QueryInsertUserIngredient, DialectPostgres): `
-		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`,
 
 		buildKey(QueryDeleteUserIngredient, DialectSQLite): `
@@ -410,22 +735,26 @@ func getQueries() map[string]string {
 	`,
 
 		buildKey(QueryCopyGlobalIngredientsToUser, DialectSQLite): `
-		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins, global_ingredient_id)
-		SELECT ?, gin.name, gi.kcal_per_100g, gi.fats, gi.carbs, gi.proteins, gi.id
+		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, global_ingredient_id,
+		                              global_version, synced_name, synced_kcal_per_100g, synced_fats, synced_carbs, synced_proteins, synced_nutrients)
+		SELECT ?, gin.name, gi.kcal_per_100g, gi.fats, gi.carbs, gi.proteins, gi.nutrients, gi.id,
+		       gi.version, gin.name, gi.kcal_per_100g, gi.fats, gi.carbs, gi.proteins, gi.nutrients
 		FROM global_ingredients gi
 		INNER JOIN global_ingredient_names gin ON gi.id = gin.ingredient_id
-		WHERE gin.language_code = ?
+		WHERE gin.language_code = ? AND gi.source = 'manual'
 		AND NOT EXISTS (
 			SELECT 1 FROM user_ingredients ui
 			WHERE ui.user_id = ? AND ui.name = gin.name
 		)
 	`,
 		buildKey(QueryCopyGlobalIngredientsToUser, DialectPostgres): `
-		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins, global_ingredient_id)
-		SELECT $1, gin.name, gi.kcal_per_100g, gi.fats, gi.carbs, gi.proteins, gi.id
+		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, global_ingredient_id,
+		                              global_version, synced_name, synced_kcal_per_100g, synced_fats, synced_carbs, synced_proteins, synced_nutrients)
+		SELECT $1, gin.name, gi.kcal_per_100g, gi.fats, gi.carbs, gi.proteins, gi.nutrients, gi.id,
+		       gi.version, gin.name, gi.kcal_per_100g, gi.fats, gi.carbs, gi.proteins, gi.nutrients
 		FROM global_ingredients gi
 		INNER JOIN global_ingredient_names gin ON gi.id = gin.ingredient_id
-		WHERE gin.language_code = $2
+		WHERE gin.language_code = $2 AND gi.source = 'manual'
 		AND NOT EXISTS (
 			SELECT 1 FROM user_ingredients ui
 			WHERE ui.user_id = $1 AND ui.name = gin.name
@@ -440,12 +769,14 @@ func getQueries() map[string]string {
 	`,
 
 		buildKey(QueryInsertGlobalIngredient, DialectSQLite): `
-		INSERT INTO global_ingredients (kcal_per_100g, fats, carbs, proteins, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO global_ingredients (kcal_per_100g, fats, carbs, proteins, nutrients, barcode, source, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
 	`,
 		buildKey(QueryInsertGlobalIngredient, DialectPostgres): `
-		INSERT INTO global_ingredients (kcal_per_100g, fats, carbs, proteins, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO global_ingredients (kcal_per_100g, fats, carbs, proteins, nutrients, barcode, source, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
 	`,
 
 		buildKey(QueryInsertGlobalIngredientName, DialectSQLite): `
@@ -458,12 +789,12 @@ func getQueries() map[string]string {
 	`,
 
 		buildKey(QueryGetGlobalIngredientByID, DialectSQLite): `
-		SELECT id, kcal_per_100g, fats, carbs, proteins, created_at, updated_at
+		SELECT id, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, source, version, created_at, updated_at
 		FROM global_ingredients
 		WHERE id = ?
 	`,
 		buildKey(QueryGetGlobalIngredientByID, DialectPostgres): `
-		SELECT id, kcal_per_100g, fats, carbs, proteins, created_at, updated_at
+		SELECT id, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, source, version, created_at, updated_at
 		FROM global_ingredients
 		WHERE id = $1
 	`,
@@ -479,6 +810,240 @@ func getQueries() map[string]string {
 		WHERE ingredient_id = $1
 	`,
 
+		buildKey(QueryGetAllGlobalIngredients, DialectSQLite): `
+		SELECT id, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, source, version, created_at, updated_at
+		FROM global_ingredients
+		ORDER BY id
+	`,
+		buildKey(QueryGetAllGlobalIngredients, DialectPostgres): `
+		SELECT id, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, source, version, created_at, updated_at
+		FROM global_ingredients
+		ORDER BY id
+	`,
+
+		buildKey(QueryGetAllGlobalIngredientNames, DialectSQLite): `
+		SELECT ingredient_id, language_code, name
+		FROM global_ingredient_names
+	`,
+		buildKey(QueryGetAllGlobalIngredientNames, DialectPostgres): `
+		SELECT ingredient_id, language_code, name
+		FROM global_ingredient_names
+	`,
+
+		buildKey(QueryUpdateGlobalIngredient, DialectSQLite): `
+		UPDATE global_ingredients
+		SET kcal_per_100g = ?, fats = ?, carbs = ?, proteins = ?, nutrients = ?, barcode = ?, version = version + 1, updated_at = ?
+		WHERE id = ?
+	`,
+		buildKey(QueryUpdateGlobalIngredient, DialectPostgres): `
+		UPDATE global_ingredients
+		SET kcal_per_100g = $1, fats = $2, carbs = $3, proteins = $4, nutrients = $5, barcode = $6, version = version + 1, updated_at = $7
+		WHERE id = $8
+	`,
+
+		buildKey(QueryDeleteGlobalIngredientNames, DialectSQLite): `
+		DELETE FROM global_ingredient_names
+		WHERE ingredient_id = ?
+	`,
+		buildKey(QueryDeleteGlobalIngredientNames, DialectPostgres): `
+		DELETE FROM global_ingredient_names
+		WHERE ingredient_id = $1
+	`,
+
+		buildKey(QueryDeleteGlobalIngredient, DialectSQLite): `
+		DELETE FROM global_ingredients
+		WHERE id = ?
+	`,
+		buildKey(QueryDeleteGlobalIngredient, DialectPostgres): `
+		DELETE FROM global_ingredients
+		WHERE id = $1
+	`,
+
+		buildKey(QueryUnlinkGlobalIngredient, DialectSQLite): `
+		UPDATE user_ingredients
+		SET global_ingredient_id = NULL
+		WHERE global_ingredient_id = ?
+	`,
+		buildKey(QueryUnlinkGlobalIngredient, DialectPostgres): `
+		UPDATE user_ingredients
+		SET global_ingredient_id = NULL
+		WHERE global_ingredient_id = $1
+	`,
+
+		// Global ingredient sync queries
+		buildKey(QueryGetLinkedUserIngredients, DialectSQLite): `
+		SELECT ui.id, ui.user_id, COALESCE(u.language, 'en_US'), ui.global_ingredient_id, COALESCE(ui.global_version, 0),
+		       ui.name, ui.kcal_per_100g, ui.fats, ui.carbs, ui.proteins, ui.nutrients,
+		       ui.synced_name, ui.synced_kcal_per_100g, ui.synced_fats, ui.synced_carbs, ui.synced_proteins, ui.synced_nutrients,
+		       COALESCE(c.version, 0)
+		FROM user_ingredients ui
+		JOIN users u ON u.id = ui.user_id
+		LEFT JOIN sync_changes c ON c.user_id = ui.user_id AND c.entity = 'ingredient' AND c.entity_id = ui.id
+		WHERE ui.user_id = ? AND ui.global_ingredient_id IS NOT NULL
+		ORDER BY ui.id
+	`,
+		buildKey(QueryGetLinkedUserIngredients, DialectPostgres): `
+		SELECT ui.id, ui.user_id, COALESCE(u.language, 'en_US'), ui.global_ingredient_id, COALESCE(ui.global_version, 0),
+		       ui.name, ui.kcal_per_100g, ui.fats, ui.carbs, ui.proteins, ui.nutrients,
+		       ui.synced_name, ui.synced_kcal_per_100g, ui.synced_fats, ui.synced_carbs, ui.synced_proteins, ui.synced_nutrients,
+		       COALESCE(c.version, 0)
+		FROM user_ingredients ui
+		JOIN users u ON u.id = ui.user_id
+		LEFT JOIN sync_changes c ON c.user_id = ui.user_id AND c.entity = 'ingredient' AND c.entity_id = ui.id
+		WHERE ui.user_id = $1 AND ui.global_ingredient_id IS NOT NULL
+		ORDER BY ui.id
+	`,
+
+		buildKey(QueryGetLinkedUserIngredientsByGlobal, DialectSQLite): `
+		SELECT ui.id, ui.user_id, COALESCE(u.language, 'en_US'), ui.global_ingredient_id, COALESCE(ui.global_version, 0),
+		       ui.name, ui.kcal_per_100g, ui.fats, ui.carbs, ui.proteins, ui.nutrients,
+		       ui.synced_name, ui.synced_kcal_per_100g, ui.synced_fats, ui.synced_carbs, ui.synced_proteins, ui.synced_nutrients,
+		       COALESCE(c.version, 0)
+		FROM user_ingredients ui
+		JOIN users u ON u.id = ui.user_id
+		LEFT JOIN sync_changes c ON c.user_id = ui.user_id AND c.entity = 'ingredient' AND c.entity_id = ui.id
+		WHERE ui.global_ingredient_id = ?
+		ORDER BY ui.id
+	`,
+		buildKey(QueryGetLinkedUserIngredientsByGlobal, DialectPostgres): `
+		SELECT ui.id, ui.user_id, COALESCE(u.language, 'en_US'), ui.global_ingredient_id, COALESCE(ui.global_version, 0),
+		       ui.name, ui.kcal_per_100g, ui.fats, ui.carbs, ui.proteins, ui.nutrients,
+		       ui.synced_name, ui.synced_kcal_per_100g, ui.synced_fats, ui.synced_carbs, ui.synced_proteins, ui.synced_nutrients,
+		       COALESCE(c.version, 0)
+		FROM user_ingredients ui
+		JOIN users u ON u.id = ui.user_id
+		LEFT JOIN sync_changes c ON c.user_id = ui.user_id AND c.entity = 'ingredient' AND c.entity_id = ui.id
+		WHERE ui.global_ingredient_id = $1
+		ORDER BY ui.id
+	`,
+
+		buildKey(QueryUpdateLinkedUserIngredient, DialectSQLite): `
+		UPDATE user_ingredients
+		SET name = ?, kcal_per_100g = ?, fats = ?, carbs = ?, proteins = ?, nutrients = ?, global_version = ?,
+		    synced_name = ?, synced_kcal_per_100g = ?, synced_fats = ?, synced_carbs = ?, synced_proteins = ?, synced_nutrients = ?,
+		    updated_at = datetime('now')
+		WHERE id = ? AND user_id = ?
+		  AND COALESCE((SELECT c.version FROM sync_changes c
+		                WHERE c.user_id = user_ingredients.user_id AND c.entity = 'ingredient' AND c.entity_id = user_ingredients.id), 0) = ?
+	`,
+		buildKey(QueryUpdateLinkedUserIngredient, DialectPostgres): `
+		UPDATE user_ingredients
+		SET name = $1, kcal_per_100g = $2, fats = $3, carbs = $4, proteins = $5, nutrients = $6, global_version = $7,
+		    synced_name = $8, synced_kcal_per_100g = $9, synced_fats = $10, synced_carbs = $11, synced_proteins = $12, synced_nutrients = $13,
+		    updated_at = NOW()
+		WHERE id = $14 AND user_id = $15
+		  AND COALESCE((SELECT c.version FROM sync_changes c
+		                WHERE c.user_id = user_ingredients.user_id AND c.entity = 'ingredient' AND c.entity_id = user_ingredients.id), 0) = $16
+	`,
+
+		buildKey(QueryInsertLinkedUserIngredient, DialectSQLite): `
+		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, global_ingredient_id,
+		                              global_version, synced_name, synced_kcal_per_100g, synced_fats, synced_carbs, synced_proteins, synced_nutrients)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`,
+		buildKey(QueryInsertLinkedUserIngredient, DialectPostgres): `
+		INSERT INTO user_ingredients (user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, global_ingredient_id,
+		                              global_version, synced_name, synced_kcal_per_100g, synced_fats, synced_carbs, synced_proteins, synced_nutrients)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING id
+	`,
+
+		// Ingredient merge queries
+		buildKey(QueryGetUserIngredientName, DialectSQLite): `
+		SELECT name FROM user_ingredients
+		WHERE user_id = ? AND id = ?
+	`,
+		buildKey(QueryGetUserIngredientName, DialectPostgres): `
+		SELECT name FROM user_ingredients
+		WHERE user_id = $1 AND id = $2
+	`,
+
+		buildKey(QueryRenameCalorieEntriesFood, DialectSQLite): `
+		UPDATE calorie_entries
+		SET food = ?, updated_at = ?, version = version + 1
+		WHERE user_id = ? AND food = ?
+	`,
+		buildKey(QueryRenameCalorieEntriesFood, DialectPostgres): `
+		UPDATE calorie_entries
+		SET food = $1, updated_at = $2, version = version + 1
+		WHERE user_id = $3 AND food = $4
+	`,
+
+		buildKey(QueryRelinkMealPlanItems, DialectSQLite): `
+		UPDATE meal_plan_items
+		SET ingredient_id = ?, food = ?
+		WHERE ingredient_id = ? AND plan_id IN (SELECT id FROM meal_plans WHERE user_id = ?)
+	`,
+		buildKey(QueryRelinkMealPlanItems, DialectPostgres): `
+		UPDATE meal_plan_items
+		SET ingredient_id = $1, food = $2
+		WHERE ingredient_id = $3 AND plan_id IN (SELECT id FROM meal_plans WHERE user_id = $4)
+	`,
+
+		buildKey(QueryMoveIngredientServings, DialectSQLite): `
+		UPDATE ingredient_servings
+		SET ingredient_id = ?
+		WHERE ingredient_id = ? AND name NOT IN (SELECT name FROM ingredient_servings WHERE ingredient_id = ?)
+	`,
+		buildKey(QueryMoveIngredientServings, DialectPostgres): `
+		UPDATE ingredient_servings
+		SET ingredient_id = $1
+		WHERE ingredient_id = $2 AND name NOT IN (SELECT name FROM ingredient_servings WHERE ingredient_id = $3)
+	`,
+
+		// Ingredient unit queries
+		buildKey(QueryGetIngredientDensity, DialectSQLite): `
+		SELECT density_g_per_ml FROM user_ingredients
+		WHERE user_id = ? AND id = ?
+	`,
+		buildKey(QueryGetIngredientDensity, DialectPostgres): `
+		SELECT density_g_per_ml FROM user_ingredients
+		WHERE user_id = $1 AND id = $2
+	`,
+
+		buildKey(QuerySetIngredientDensity, DialectSQLite): `
+		UPDATE user_ingredients
+		SET density_g_per_ml = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND id = ?
+	`,
+		buildKey(QuerySetIngredientDensity, DialectPostgres): `
+		UPDATE user_ingredients
+		SET density_g_per_ml = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2 AND id = $3
+	`,
+
+		buildKey(QueryGetIngredientServings, DialectSQLite): `
+		SELECT id, ingredient_id, name, grams
+		FROM ingredient_servings
+		WHERE ingredient_id = ?
+		ORDER BY grams, name
+	`,
+		buildKey(QueryGetIngredientServings, DialectPostgres): `
+		SELECT id, ingredient_id, name, grams
+		FROM ingredient_servings
+		WHERE ingredient_id = $1
+		ORDER BY grams, name
+	`,
+
+		buildKey(QueryInsertIngredientServing, DialectSQLite): `
+		INSERT INTO ingredient_servings (ingredient_id, name, grams)
+		VALUES (?, ?, ?)
+	`,
+		buildKey(QueryInsertIngredientServing, DialectPostgres): `
+		INSERT INTO ingredient_servings (ingredient_id, name, grams)
+		VALUES ($1, $2, $3)
+	`,
+
+		buildKey(QueryDeleteIngredientServings, DialectSQLite): `
+		DELETE FROM ingredient_servings
+		WHERE ingredient_id = ?
+	`,
+		buildKey(QueryDeleteIngredientServings, DialectPostgres): `
+		DELETE FROM ingredient_servings
+		WHERE ingredient_id = $1
+	`,
+
 		// Activation Token queries
 		buildKey(QueryCreateActivationToken, DialectSQLite): `
 		INSERT INTO activation_tokens (user_id, token, expires_at)
@@ -563,5 +1128,1090 @@ func getQueries() map[string]string {
 		buildKey(QueryDeleteAPIKey, DialectPostgres): `
 		DELETE FROM api_keys WHERE id = $1 AND user_id = $2
 	`,
+
+		// Meal Plan queries
+		buildKey(QueryCreateMealPlan, DialectSQLite): `
+		INSERT INTO meal_plans (user_id, days, target_calories, target_proteins, target_fats, target_carbs, notes, model)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`,
+		buildKey(QueryCreateMealPlan, DialectPostgres): `
+		INSERT INTO meal_plans (user_id, days, target_calories, target_proteins, target_fats, target_carbs, notes, model)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`,
+
+		buildKey(QueryCreateMealPlanItem, DialectSQLite): `
+		INSERT INTO meal_plan_items (plan_id, day_number, meal_type, ingredient_id, food, weight, kcal_per_100g, calories, fats, carbs, proteins)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`,
+		buildKey(QueryCreateMealPlanItem, DialectPostgres): `
+		INSERT INTO meal_plan_items (plan_id, day_number, meal_type, ingredient_id, food, weight, kcal_per_100g, calories, fats, carbs, proteins)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`,
+
+		buildKey(QueryGetMealPlanByID, DialectSQLite): `
+		SELECT id, user_id, days, target_calories, target_proteins, target_fats, target_carbs, notes, model, created_at
+		FROM meal_plans
+		WHERE id = ? AND user_id = ?
+	`,
+		buildKey(QueryGetMealPlanByID, DialectPostgres): `
+		SELECT id, user_id, days, target_calories, target_proteins, target_fats, target_carbs, notes, model, created_at
+		FROM meal_plans
+		WHERE id = $1 AND user_id = $2
+	`,
+
+		buildKey(QueryGetMealPlansByUserID, DialectSQLite): `
+		SELECT id, user_id, days, target_calories, target_proteins, target_fats, target_carbs, notes, model, created_at
+		FROM meal_plans
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+	`,
+		buildKey(QueryGetMealPlansByUserID, DialectPostgres): `
+		SELECT id, user_id, days, target_calories, target_proteins, target_fats, target_carbs, notes, model, created_at
+		FROM meal_plans
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+	`,
+
+		buildKey(QueryGetMealPlanItems, DialectSQLite): `
+		SELECT id, plan_id, day_number, meal_type, ingredient_id, food, weight, kcal_per_100g, calories, fats, carbs, proteins
+		FROM meal_plan_items
+		WHERE plan_id = ?
+		ORDER BY day_number, id
+	`,
+		buildKey(QueryGetMealPlanItems, DialectPostgres): `
+		SELECT id, plan_id, day_number, meal_type, ingredient_id, food, weight, kcal_per_100g, calories, fats, carbs, proteins
+		FROM meal_plan_items
+		WHERE plan_id = $1
+		ORDER BY day_number, id
+	`,
+
+		buildKey(QueryDeleteMealPlanItems, DialectSQLite): `
+		DELETE FROM meal_plan_items WHERE plan_id = ?
+	`,
+		buildKey(QueryDeleteMealPlanItems, DialectPostgres): `
+		DELETE FROM meal_plan_items WHERE plan_id = $1
+	`,
+
+		buildKey(QueryDeleteMealPlan, DialectSQLite): `
+		DELETE FROM meal_plans WHERE id = ? AND user_id = ?
+	`,
+		buildKey(QueryDeleteMealPlan, DialectPostgres): `
+		DELETE FROM meal_plans WHERE id = $1 AND user_id = $2
+	`,
+
+		// AI Analysis Cache queries
+		buildKey(QueryGetAIAnalysisCache, DialectSQLite): `
+		SELECT id, user_id, input_hash, model, response, tokens_used, created_at
+		FROM ai_analysis_cache
+		WHERE user_id = ? AND input_hash = ?
+	`,
+		buildKey(QueryGetAIAnalysisCache, DialectPostgres): `
+		SELECT id, user_id, input_hash, model, response, tokens_used, created_at
+		FROM ai_analysis_cache
+		WHERE user_id = $1 AND input_hash = $2
+	`,
+
+		buildKey(QueryUpsertAIAnalysisCache, DialectSQLite): `
+		INSERT INTO ai_analysis_cache (user_id, input_hash, model, response, tokens_used, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, input_hash) DO UPDATE SET
+			model = excluded.model,
+			response = excluded.response,
+			tokens_used = excluded.tokens_used,
+			created_at = excluded.created_at
+	`,
+		buildKey(QueryUpsertAIAnalysisCache, DialectPostgres): `
+		INSERT INTO ai_analysis_cache (user_id, input_hash, model, response, tokens_used, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, input_hash) DO UPDATE SET
+			model = EXCLUDED.model,
+			response = EXCLUDED.response,
+			tokens_used = EXCLUDED.tokens_used,
+			created_at = EXCLUDED.created_at
+	`,
+
+		buildKey(QueryDeleteAIAnalysisCacheOlderThan, DialectSQLite): `
+		DELETE FROM ai_analysis_cache WHERE user_id = ? AND created_at < ?
+	`,
+		buildKey(QueryDeleteAIAnalysisCacheOlderThan, DialectPostgres): `
+		DELETE FROM ai_analysis_cache WHERE user_id = $1 AND created_at < $2
+	`,
+
+		// Audit Log queries
+		buildKey(QueryCreateAuditLogEntry, DialectSQLite): `
+		INSERT INTO audit_log (actor_user_id, action, entity_type, entity_id, changes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`,
+		buildKey(QueryCreateAuditLogEntry, DialectPostgres): `
+		INSERT INTO audit_log (actor_user_id, action, entity_type, entity_id, changes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`,
+
+		buildKey(QueryGetAuditLogEntries, DialectSQLite): `
+		SELECT id, actor_user_id, action, entity_type, entity_id, changes, created_at
+		FROM audit_log
+		WHERE (? = '' OR entity_type = ?) AND (? = 0 OR entity_id = ?)
+		ORDER BY created_at DESC, id DESC
+		LIMIT ? OFFSET ?
+	`,
+		buildKey(QueryGetAuditLogEntries, DialectPostgres): `
+		SELECT id, actor_user_id, action, entity_type, entity_id, changes, created_at
+		FROM audit_log
+		WHERE ($1 = '' OR entity_type = $1) AND ($2 = 0 OR entity_id = $2)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`,
+
+		// Barcode queries
+		buildKey(QueryGetUserIngredientByBarcode, DialectSQLite): `
+		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, global_ingredient_id, created_at, updated_at
+		FROM user_ingredients
+		WHERE user_id = ? AND barcode = ?
+	`,
+		buildKey(QueryGetUserIngredientByBarcode, DialectPostgres): `
+		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, global_ingredient_id, created_at, updated_at
+		FROM user_ingredients
+		WHERE user_id = $1 AND barcode = $2
+	`,
+
+		buildKey(QueryGetUserIngredientByGlobalID, DialectSQLite): `
+		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, global_ingredient_id, created_at, updated_at
+		FROM user_ingredients
+		WHERE user_id = ? AND global_ingredient_id = ?
+		ORDER BY id
+		LIMIT 1
+	`,
+		buildKey(QueryGetUserIngredientByGlobalID, DialectPostgres): `
+		SELECT id, user_id, name, kcal_per_100g, fats, carbs, proteins, nutrients, barcode, global_ingredient_id, created_at, updated_at
+		FROM user_ingredients
+		WHERE user_id = $1 AND global_ingredient_id = $2
+		ORDER BY id
+		LIMIT 1
+	`,
+
+		buildKey(QuerySetUserIngredientBarcode, DialectSQLite): `
+		UPDATE user_ingredients
+		SET barcode = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = ? AND id = ?
+	`,
+		buildKey(QuerySetUserIngredientBarcode, DialectPostgres): `
+		UPDATE user_ingredients
+		SET barcode = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $2 AND id = $3
+	`,
+
+		buildKey(QueryGetGlobalIngredientByBarcode, DialectSQLite): `
+		SELECT id FROM global_ingredients
+		WHERE barcode = ?
+	`,
+		buildKey(QueryGetGlobalIngredientByBarcode, DialectPostgres): `
+		SELECT id FROM global_ingredients
+		WHERE barcode = $1
+	`,
+
+		// Export job queries
+		buildKey(QueryCreateExportJob, DialectSQLite): `
+		INSERT INTO export_jobs (user_id, status, date_from, date_to, data_type, format, delivery_type, language, email, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`,
+		buildKey(QueryCreateExportJob, DialectPostgres): `
+		INSERT INTO export_jobs (user_id, status, date_from, date_to, data_type, format, delivery_type, language, email, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`,
+
+		buildKey(QueryGetExportJobByID, DialectSQLite): `
+		SELECT id, user_id, status, date_from, date_to, data_type, format, delivery_type, language, email,
+		       file_name, content_type, file_size, error, attempts, created_at, started_at, completed_at, expires_at
+		FROM export_jobs
+		WHERE id = ? AND user_id = ?
+	`,
+		buildKey(QueryGetExportJobByID, DialectPostgres): `
+		SELECT id, user_id, status, date_from, date_to, data_type, format, delivery_type, language, email,
+		       file_name, content_type, file_size, error, attempts, created_at, started_at, completed_at, expires_at
+		FROM export_jobs
+		WHERE id = $1 AND user_id = $2
+	`,
+
+		buildKey(QueryGetExportJobsByUserID, DialectSQLite): `
+		SELECT id, user_id, status, date_from, date_to, data_type, format, delivery_type, language, email,
+		       file_name, content_type, file_size, error, attempts, created_at, started_at, completed_at, expires_at
+		FROM export_jobs
+		WHERE user_id = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`,
+		buildKey(QueryGetExportJobsByUserID, DialectPostgres): `
+		SELECT id, user_id, status, date_from, date_to, data_type, format, delivery_type, language, email,
+		       file_name, content_type, file_size, error, attempts, created_at, started_at, completed_at, expires_at
+		FROM export_jobs
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`,
+
+		buildKey(QueryGetExportJobFile, DialectSQLite): `
+		SELECT file_data
+		FROM export_jobs
+		WHERE id = ? AND user_id = ? AND status = 'completed' AND file_data IS NOT NULL
+	`,
+		buildKey(QueryGetExportJobFile, DialectPostgres): `
+		SELECT file_data
+		FROM export_jobs
+		WHERE id = $1 AND user_id = $2 AND status = 'completed' AND file_data IS NOT NULL
+	`,
+
+		buildKey(QueryClaimExportJob, DialectSQLite): `
+		UPDATE export_jobs
+		SET status = 'running', started_at = ?, attempts = attempts + 1
+		WHERE id = (
+		    SELECT id FROM export_jobs
+		    WHERE status = 'pending' OR (status = 'running' AND started_at < ?)
+		    ORDER BY id
+		    LIMIT 1
+		)
+		RETURNING id, user_id, status, date_from, date_to, data_type, format, delivery_type, language, email,
+		       file_name, content_type, file_size, error, attempts, created_at, started_at, completed_at, expires_at
+	`,
+		buildKey(QueryClaimExportJob, DialectPostgres): `
+		UPDATE export_jobs
+		SET status = 'running', started_at = $1, attempts = attempts + 1
+		WHERE id = (
+		    SELECT id FROM export_jobs
+		    WHERE status = 'pending' OR (status = 'running' AND started_at < $2)
+		    ORDER BY id
+		    LIMIT 1
+		    FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, status, date_from, date_to, data_type, format, delivery_type, language, email,
+		       file_name, content_type, file_size, error, attempts, created_at, started_at, completed_at, expires_at
+	`,
+
+		buildKey(QueryCompleteExportJob, DialectSQLite): `
+		UPDATE export_jobs
+		SET status = 'completed', file_name = ?, content_type = ?, file_size = ?, file_data = ?,
+		    error = NULL, completed_at = ?, expires_at = ?
+		WHERE id = ?
+	`,
+		buildKey(QueryCompleteExportJob, DialectPostgres): `
+		UPDATE export_jobs
+		SET status = 'completed', file_name = $1, content_type = $2, file_size = $3, file_data = $4,
+		    error = NULL, completed_at = $5, expires_at = $6
+		WHERE id = $7
+	`,
+
+		buildKey(QueryFailExportJob, DialectSQLite): `
+		UPDATE export_jobs
+		SET status = 'failed', error = ?, completed_at = ?
+		WHERE id = ?
+	`,
+		buildKey(QueryFailExportJob, DialectPostgres): `
+		UPDATE export_jobs
+		SET status = 'failed', error = $1, completed_at = $2
+		WHERE id = $3
+	`,
+
+		buildKey(QueryExpireExportJobFiles, DialectSQLite): `
+		UPDATE export_jobs
+		SET status = 'expired', file_data = NULL
+		WHERE status = 'completed' AND expires_at IS NOT NULL AND expires_at < ?
+	`,
+		buildKey(QueryExpireExportJobFiles, DialectPostgres): `
+		UPDATE export_jobs
+		SET status = 'expired', file_data = NULL
+		WHERE status = 'completed' AND expires_at IS NOT NULL AND expires_at < $1
+	`,
+
+		buildKey(QueryDeleteFinishedExportJobs, DialectSQLite): `
+		DELETE FROM export_jobs
+		WHERE status IN ('completed', 'failed', 'expired') AND completed_at < ?
+		  AND (expires_at IS NULL OR expires_at < ?)
+	`,
+		buildKey(QueryDeleteFinishedExportJobs, DialectPostgres): `
+		DELETE FROM export_jobs
+		WHERE status IN ('completed', 'failed', 'expired') AND completed_at < $1
+		  AND (expires_at IS NULL OR expires_at < $2)
+	`,
+
+		// Export schedule queries
+		buildKey(QueryCreateExportSchedule, DialectSQLite): `
+		INSERT INTO export_schedules (user_id, kind, frequency, day_of_week, day_of_month, hour, timezone, data_type, format,
+		                              enabled, unsubscribe_token, next_run_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING id
+	`,
+		buildKey(QueryCreateExportSchedule, DialectPostgres): `
+		INSERT INTO export_schedules (user_id, kind, frequency, day_of_week, day_of_month, hour, timezone, data_type, format,
+		                              enabled, unsubscribe_token, next_run_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id
+	`,
+
+		buildKey(QueryGetExportScheduleByID, DialectSQLite): `
+		SELECT id, user_id, kind, frequency, day_of_week, day_of_month, hour, timezone, data_type, format,
+		       enabled, unsubscribe_token, next_run_at, last_run_at, created_at, updated_at
+		FROM export_schedules
+		WHERE id = ? AND user_id = ?
+	`,
+		buildKey(QueryGetExportScheduleByID, DialectPostgres): `
+		SELECT id, user_id, kind, frequency, day_of_week, day_of_month, hour, timezone, data_type, format,
+		       enabled, unsubscribe_token, next_run_at, last_run_at, created_at, updated_at
+		FROM export_schedules
+		WHERE id = $1 AND user_id = $2
+	`,
+
+		buildKey(QueryGetExportSchedulesByUserID, DialectSQLite): `
+		SELECT id, user_id, kind, frequency, day_of_week, day_of_month, hour, timezone, data_type, format,
+		       enabled, unsubscribe_token, next_run_at, last_run_at, created_at, updated_at
+		FROM export_schedules
+		WHERE user_id = ?
+		ORDER BY id
+	`,
+		buildKey(QueryGetExportSchedulesByUserID, DialectPostgres): `
+		SELECT id, user_id, kind, frequency, day_of_week, day_of_month, hour, timezone, data_type, format,
+		       enabled, unsubscribe_token, next_run_at, last_run_at, created_at, updated_at
+		FROM export_schedules
+		WHERE user_id = $1
+		ORDER BY id
+	`,
+
+		buildKey(QueryGetExportScheduleByToken, DialectSQLite): `
+		SELECT id, user_id, kind, frequency, day_of_week, day_of_month, hour, timezone, data_type, format,
+		       enabled, unsubscribe_token, next_run_at, last_run_at, created_at, updated_at
+		FROM export_schedules
+		WHERE unsubscribe_token = ?
+	`,
+		buildKey(QueryGetExportScheduleByToken, DialectPostgres): `
+		SELECT id, user_id, kind, frequency, day_of_week, day_of_month, hour, timezone, data_type, format,
+		       enabled, unsubscribe_token, next_run_at, last_run_at, created_at, updated_at
+		FROM export_schedules
+		WHERE unsubscribe_token = $1
+	`,
+
+		buildKey(QueryUpdateExportSchedule, DialectSQLite): `
+		UPDATE export_schedules
+		SET kind = ?, frequency = ?, day_of_week = ?, day_of_month = ?, hour = ?, timezone = ?, data_type = ?, format = ?,
+		    enabled = ?, next_run_at = ?, updated_at = ?
+		WHERE id = ? AND user_id = ?
+	`,
+		buildKey(QueryUpdateExportSchedule, DialectPostgres): `
+		UPDATE export_schedules
+		SET kind = $1, frequency = $2, day_of_week = $3, day_of_month = $4, hour = $5, timezone = $6, data_type = $7, format = $8,
+		    enabled = $9, next_run_at = $10, updated_at = $11
+		WHERE id = $12 AND user_id = $13
+	`,
+
+		buildKey(QueryDeleteExportSchedule, DialectSQLite): `
+		DELETE FROM export_schedules WHERE id = ? AND user_id = ?
+	`,
+		buildKey(QueryDeleteExportSchedule, DialectPostgres): `
+		DELETE FROM export_schedules WHERE id = $1 AND user_id = $2
+	`,
+
+		buildKey(QueryDisableExportSchedule, DialectSQLite): `
+		UPDATE export_schedules SET enabled = 0, updated_at = ? WHERE id = ?
+	`,
+		buildKey(QueryDisableExportSchedule, DialectPostgres): `
+		UPDATE export_schedules SET enabled = false, updated_at = $1 WHERE id = $2
+	`,
+
+		buildKey(QueryClaimDueExportSchedule, DialectSQLite): `
+		UPDATE export_schedules
+		SET lease_owner = ?, lease_until = ?
+		WHERE id = (
+		    SELECT id FROM export_schedules
+		    WHERE enabled = 1 AND next_run_at <= ? AND (lease_until IS NULL OR lease_until < ?)
+		    ORDER BY next_run_at
+		    LIMIT 1
+		)
+		RETURNING id, user_id, kind, frequency, day_of_week, day_of_month, hour, timezone, data_type, format,
+		       enabled, unsubscribe_token, next_run_at, last_run_at, created_at, updated_at
+	`,
+		buildKey(QueryClaimDueExportSchedule, DialectPostgres): `
+		UPDATE export_schedules
+		SET lease_owner = $1, lease_until = $2
+		WHERE id = (
+		    SELECT id FROM export_schedules
+		    WHERE enabled = true AND next_run_at <= $3 AND (lease_until IS NULL OR lease_until < $4)
+		    ORDER BY next_run_at
+		    LIMIT 1
+		    FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, kind, frequency, day_of_week, day_of_month, hour, timezone, data_type, format,
+		       enabled, unsubscribe_token, next_run_at, last_run_at, created_at, updated_at
+	`,
+
+		buildKey(QueryFinishExportScheduleRun, DialectSQLite): `
+		UPDATE export_schedules
+		SET next_run_at = ?, last_run_at = COALESCE(?, last_run_at), lease_owner = NULL, lease_until = NULL
+		WHERE id = ? AND lease_owner = ?
+	`,
+		buildKey(QueryFinishExportScheduleRun, DialectPostgres): `
+		UPDATE export_schedules
+		SET next_run_at = $1, last_run_at = COALESCE($2, last_run_at), lease_owner = NULL, lease_until = NULL
+		WHERE id = $3 AND lease_owner = $4
+	`,
+
+		// Account deletion queries
+		buildKey(QueryScheduleUserDeletion, DialectSQLite): `
+		UPDATE users SET deletion_scheduled_at = ?, updated_at = datetime('now') WHERE id = ?
+	`,
+		buildKey(QueryScheduleUserDeletion, DialectPostgres): `
+		UPDATE users SET deletion_scheduled_at = $1, updated_at = NOW() WHERE id = $2
+	`,
+
+		buildKey(QueryCancelUserDeletion, DialectSQLite): `
+		UPDATE users SET deletion_scheduled_at = NULL, updated_at = datetime('now') WHERE id = ?
+	`,
+		buildKey(QueryCancelUserDeletion, DialectPostgres): `
+		UPDATE users SET deletion_scheduled_at = NULL, updated_at = NOW() WHERE id = $1
+	`,
+
+		buildKey(QueryGetUsersDueForDeletion, DialectSQLite): `
+		SELECT id FROM users
+		WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?
+		ORDER BY deletion_scheduled_at
+		LIMIT ?
+	`,
+		buildKey(QueryGetUsersDueForDeletion, DialectPostgres): `
+		SELECT id FROM users
+		WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= $1
+		ORDER BY deletion_scheduled_at
+		LIMIT $2
+	`,
+
+		buildKey(QueryPurgeUserMealPlanItems, DialectSQLite): `
+		DELETE FROM meal_plan_items WHERE plan_id IN (SELECT id FROM meal_plans WHERE user_id = ?)
+	`,
+		buildKey(QueryPurgeUserMealPlanItems, DialectPostgres): `
+		DELETE FROM meal_plan_items WHERE plan_id IN (SELECT id FROM meal_plans WHERE user_id = $1)
+	`,
+
+		buildKey(QueryPurgeUserIngredientServings, DialectSQLite): `
+		DELETE FROM ingredient_servings WHERE ingredient_id IN (SELECT id FROM user_ingredients WHERE user_id = ?)
+	`,
+		buildKey(QueryPurgeUserIngredientServings, DialectPostgres): `
+		DELETE FROM ingredient_servings WHERE ingredient_id IN (SELECT id FROM user_ingredients WHERE user_id = $1)
+	`,
+
+		buildKey(QueryPurgeUserMealPlans, DialectSQLite): `
+		DELETE FROM meal_plans WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserMealPlans, DialectPostgres): `
+		DELETE FROM meal_plans WHERE user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserCalorieEntries, DialectSQLite): `
+		DELETE FROM calorie_entries WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserCalorieEntries, DialectPostgres): `
+		DELETE FROM calorie_entries WHERE user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserWeightHistory, DialectSQLite): `
+		DELETE FROM weight_history WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserWeightHistory, DialectPostgres): `
+		DELETE FROM weight_history WHERE user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserIngredients, DialectSQLite): `
+		DELETE FROM user_ingredients WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserIngredients, DialectPostgres): `
+		DELETE FROM user_ingredients WHERE user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserAPIKeys, DialectSQLite): `
+		DELETE FROM api_keys WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserAPIKeys, DialectPostgres): `
+		DELETE FROM api_keys WHERE user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserActivationTokens, DialectSQLite): `
+		DELETE FROM activation_tokens WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserActivationTokens, DialectPostgres): `
+		DELETE FROM activation_tokens WHERE user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserAICache, DialectSQLite): `
+		DELETE FROM ai_analysis_cache WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserAICache, DialectPostgres): `
+		DELETE FROM ai_analysis_cache WHERE user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserExportJobs, DialectSQLite): `
+		DELETE FROM export_jobs WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserExportJobs, DialectPostgres): `
+		DELETE FROM export_jobs WHERE user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserExportSchedules, DialectSQLite): `
+		DELETE FROM export_schedules WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserExportSchedules, DialectPostgres): `
+		DELETE FROM export_schedules WHERE user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserAuditLog, DialectSQLite): `
+		UPDATE audit_log SET actor_user_id = NULL WHERE actor_user_id = ?
+	`,
+		buildKey(QueryPurgeUserAuditLog, DialectPostgres): `
+		UPDATE audit_log SET actor_user_id = NULL WHERE actor_user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserCalendarFeeds, DialectSQLite): `
+		DELETE FROM calendar_feeds WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserCalendarFeeds, DialectPostgres): `
+		DELETE FROM calendar_feeds WHERE user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserWebhookDeliveries, DialectSQLite): `
+		DELETE FROM webhook_deliveries WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserWebhookDeliveries, DialectPostgres): `
+		DELETE FROM webhook_deliveries WHERE user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserWebhookChecks, DialectSQLite): `
+		DELETE FROM webhook_checks WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserWebhookChecks, DialectPostgres): `
+		DELETE FROM webhook_checks WHERE user_id = $1
+	`,
+
+		buildKey(QueryPurgeUserWebhooks, DialectSQLite): `
+		DELETE FROM webhooks WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserWebhooks, DialectPostgres): `
+		DELETE FROM webhooks WHERE user_id = $1
+	`,
+		buildKey(QueryPurgeUserSyncChanges, DialectSQLite): `
+		DELETE FROM sync_changes WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserSyncChanges, DialectPostgres): `
+		DELETE FROM sync_changes WHERE user_id = $1
+	`,
+		buildKey(QueryPurgeUserIdempotencyKeys, DialectSQLite): `
+		DELETE FROM idempotency_keys WHERE user_id = ?
+	`,
+		buildKey(QueryPurgeUserIdempotencyKeys, DialectPostgres): `
+		DELETE FROM idempotency_keys WHERE user_id = $1
+	`,
+
+		// Calendar feed queries
+		buildKey(QuerySaveCalendarFeed, DialectSQLite): `
+		INSERT INTO calendar_feeds (user_id, token_hash, token_prefix, timezone, past_days, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			token_hash = excluded.token_hash,
+			token_prefix = excluded.token_prefix,
+			timezone = excluded.timezone,
+			past_days = excluded.past_days,
+			last_accessed_at = NULL,
+			created_at = excluded.created_at,
+			updated_at = excluded.updated_at
+		RETURNING id
+	`,
+		buildKey(QuerySaveCalendarFeed, DialectPostgres): `
+		INSERT INTO calendar_feeds (user_id, token_hash, token_prefix, timezone, past_days, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE SET
+			token_hash = excluded.token_hash,
+			token_prefix = excluded.token_prefix,
+			timezone = excluded.timezone,
+			past_days = excluded.past_days,
+			last_accessed_at = NULL,
+			created_at = excluded.created_at,
+			updated_at = excluded.updated_at
+		RETURNING id
+	`,
+
+		buildKey(QueryGetCalendarFeedByUserID, DialectSQLite): `
+		SELECT id, user_id, token_hash, token_prefix, timezone, past_days, last_accessed_at, created_at, updated_at
+		FROM calendar_feeds
+		WHERE user_id = ?
+	`,
+		buildKey(QueryGetCalendarFeedByUserID, DialectPostgres): `
+		SELECT id, user_id, token_hash, token_prefix, timezone, past_days, last_accessed_at, created_at, updated_at
+		FROM calendar_feeds
+		WHERE user_id = $1
+	`,
+
+		buildKey(QueryGetCalendarFeedByTokenHash, DialectSQLite): `
+		SELECT id, user_id, token_hash, token_prefix, timezone, past_days, last_accessed_at, created_at, updated_at
+		FROM calendar_feeds
+		WHERE token_hash = ?
+	`,
+		buildKey(QueryGetCalendarFeedByTokenHash, DialectPostgres): `
+		SELECT id, user_id, token_hash, token_prefix, timezone, past_days, last_accessed_at, created_at, updated_at
+		FROM calendar_feeds
+		WHERE token_hash = $1
+	`,
+
+		buildKey(QueryUpdateCalendarFeedSettings, DialectSQLite): `
+		UPDATE calendar_feeds SET timezone = ?, past_days = ?, updated_at = ? WHERE user_id = ?
+	`,
+		buildKey(QueryUpdateCalendarFeedSettings, DialectPostgres): `
+		UPDATE calendar_feeds SET timezone = $1, past_days = $2, updated_at = $3 WHERE user_id = $4
+	`,
+
+		buildKey(QueryDeleteCalendarFeed, DialectSQLite): `
+		DELETE FROM calendar_feeds WHERE user_id = ?
+	`,
+		buildKey(QueryDeleteCalendarFeed, DialectPostgres): `
+		DELETE FROM calendar_feeds WHERE user_id = $1
+	`,
+
+		buildKey(QueryMarkCalendarFeedAccessed, DialectSQLite): `
+		UPDATE calendar_feeds SET last_accessed_at = ? WHERE id = ?
+	`,
+		buildKey(QueryMarkCalendarFeedAccessed, DialectPostgres): `
+		UPDATE calendar_feeds SET last_accessed_at = $1 WHERE id = $2
+	`,
+
+		// Webhook queries
+		buildKey(QueryCreateWebhook, DialectSQLite): `
+		INSERT INTO webhooks (user_id, url, secret, events, enabled, failure_count, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, 0, ?, ?)
+		RETURNING id
+	`,
+		buildKey(QueryCreateWebhook, DialectPostgres): `
+		INSERT INTO webhooks (user_id, url, secret, events, enabled, failure_count, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, 0, $6, $7)
+		RETURNING id
+	`,
+
+		buildKey(QueryGetWebhookByID, DialectSQLite): `
+		SELECT id, user_id, url, secret, events, enabled, failure_count, disabled_at, last_success_at, last_failure_at, created_at, updated_at
+		FROM webhooks
+		WHERE id = ? AND user_id = ?
+	`,
+		buildKey(QueryGetWebhookByID, DialectPostgres): `
+		SELECT id, user_id, url, secret, events, enabled, failure_count, disabled_at, last_success_at, last_failure_at, created_at, updated_at
+		FROM webhooks
+		WHERE id = $1 AND user_id = $2
+	`,
+
+		buildKey(QueryGetWebhooksByUserID, DialectSQLite): `
+		SELECT id, user_id, url, secret, events, enabled, failure_count, disabled_at, last_success_at, last_failure_at, created_at, updated_at
+		FROM webhooks
+		WHERE user_id = ?
+		ORDER BY id
+	`,
+		buildKey(QueryGetWebhooksByUserID, DialectPostgres): `
+		SELECT id, user_id, url, secret, events, enabled, failure_count, disabled_at, last_success_at, last_failure_at, created_at, updated_at
+		FROM webhooks
+		WHERE user_id = $1
+		ORDER BY id
+	`,
+
+		buildKey(QueryGetEnabledWebhooksByUserID, DialectSQLite): `
+		SELECT id, user_id, url, secret, events, enabled, failure_count, disabled_at, last_success_at, last_failure_at, created_at, updated_at
+		FROM webhooks
+		WHERE user_id = ? AND enabled = 1
+		ORDER BY id
+	`,
+		buildKey(QueryGetEnabledWebhooksByUserID, DialectPostgres): `
+		SELECT id, user_id, url, secret, events, enabled, failure_count, disabled_at, last_success_at, last_failure_at, created_at, updated_at
+		FROM webhooks
+		WHERE user_id = $1 AND enabled = true
+		ORDER BY id
+	`,
+
+		buildKey(QueryUpdateWebhook, DialectSQLite): `
+		UPDATE webhooks
+		SET url = ?, events = ?, enabled = ?, failure_count = ?, disabled_at = ?, updated_at = ?
+		WHERE id = ? AND user_id = ?
+	`,
+		buildKey(QueryUpdateWebhook, DialectPostgres): `
+		UPDATE webhooks
+		SET url = $1, events = $2, enabled = $3, failure_count = $4, disabled_at = $5, updated_at = $6
+		WHERE id = $7 AND user_id = $8
+	`,
+
+		buildKey(QueryUpdateWebhookSecret, DialectSQLite): `
+		UPDATE webhooks SET secret = ?, updated_at = ? WHERE id = ? AND user_id = ?
+	`,
+		buildKey(QueryUpdateWebhookSecret, DialectPostgres): `
+		UPDATE webhooks SET secret = $1, updated_at = $2 WHERE id = $3 AND user_id = $4
+	`,
+
+		buildKey(QueryDeleteWebhook, DialectSQLite): `
+		DELETE FROM webhooks WHERE id = ? AND user_id = ?
+	`,
+		buildKey(QueryDeleteWebhook, DialectPostgres): `
+		DELETE FROM webhooks WHERE id = $1 AND user_id = $2
+	`,
+
+		buildKey(QueryRecordWebhookSuccess, DialectSQLite): `
+		UPDATE webhooks SET failure_count = 0, last_success_at = ? WHERE id = ?
+	`,
+		buildKey(QueryRecordWebhookSuccess, DialectPostgres): `
+		UPDATE webhooks SET failure_count = 0, last_success_at = $1 WHERE id = $2
+	`,
+
+		buildKey(QueryRecordWebhookFailure, DialectSQLite): `
+		UPDATE webhooks
+		SET failure_count = failure_count + 1, last_failure_at = ?,
+		    disabled_at = CASE WHEN enabled = 1 AND failure_count + 1 >= ? THEN ? ELSE disabled_at END,
+		    enabled = CASE WHEN failure_count + 1 >= ? THEN 0 ELSE enabled END
+		WHERE id = ?
+		RETURNING enabled
+	`,
+		buildKey(QueryRecordWebhookFailure, DialectPostgres): `
+		UPDATE webhooks
+		SET failure_count = failure_count + 1, last_failure_at = $1,
+		    disabled_at = CASE WHEN enabled = true AND failure_count + 1 >= $2 THEN $3 ELSE disabled_at END,
+		    enabled = CASE WHEN failure_count + 1 >= $4 THEN false ELSE enabled END
+		WHERE id = $5
+		RETURNING enabled
+	`,
+
+		buildKey(QueryCreateWebhookDelivery, DialectSQLite): `
+		INSERT INTO webhook_deliveries (webhook_id, user_id, event, event_id, event_key, payload, status, attempts,
+		                                next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, 'pending', 0, ?, ?)
+		ON CONFLICT (webhook_id, event_key) DO NOTHING
+		RETURNING id
+	`,
+		buildKey(QueryCreateWebhookDelivery, DialectPostgres): `
+		INSERT INTO webhook_deliveries (webhook_id, user_id, event, event_id, event_key, payload, status, attempts,
+		                                next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 'pending', 0, $7, $8)
+		ON CONFLICT (webhook_id, event_key) DO NOTHING
+		RETURNING id
+	`,
+
+		buildKey(QueryGetWebhookDeliveries, DialectSQLite): `
+		SELECT d.id, d.webhook_id, d.user_id, d.event, d.event_id, d.event_key, d.payload, d.status, d.attempts, d.next_attempt_at,
+		       d.response_status, d.error, d.created_at, d.completed_at
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.webhook_id = ? AND w.user_id = ?
+		ORDER BY d.id DESC
+		LIMIT ?
+	`,
+		buildKey(QueryGetWebhookDeliveries, DialectPostgres): `
+		SELECT d.id, d.webhook_id, d.user_id, d.event, d.event_id, d.event_key, d.payload, d.status, d.attempts, d.next_attempt_at,
+		       d.response_status, d.error, d.created_at, d.completed_at
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.webhook_id = $1 AND w.user_id = $2
+		ORDER BY d.id DESC
+		LIMIT $3
+	`,
+
+		buildKey(QueryClaimDueWebhookDelivery, DialectSQLite): `
+		UPDATE webhook_deliveries
+		SET lease_owner = ?, lease_until = ?
+		WHERE id = (
+		    SELECT d.id FROM webhook_deliveries d
+		    JOIN webhooks w ON w.id = d.webhook_id
+		    WHERE d.status = 'pending' AND d.next_attempt_at <= ? AND (d.lease_until IS NULL OR d.lease_until < ?)
+		      AND w.enabled = 1
+		    ORDER BY d.next_attempt_at
+		    LIMIT 1
+		)
+		RETURNING id, webhook_id, user_id, event, event_id, event_key, payload, status, attempts, next_attempt_at, response_status, error,
+		       created_at, completed_at
+	`,
+		buildKey(QueryClaimDueWebhookDelivery, DialectPostgres): `
+		UPDATE webhook_deliveries
+		SET lease_owner = $1, lease_until = $2
+		WHERE id = (
+		    SELECT d.id FROM webhook_deliveries d
+		    JOIN webhooks w ON w.id = d.webhook_id
+		    WHERE d.status = 'pending' AND d.next_attempt_at <= $3 AND (d.lease_until IS NULL OR d.lease_until < $4)
+		      AND w.enabled = true
+		    ORDER BY d.next_attempt_at
+		    LIMIT 1
+		    FOR UPDATE OF d SKIP LOCKED
+		)
+		RETURNING id, webhook_id, user_id, event, event_id, event_key, payload, status, attempts, next_attempt_at, response_status, error,
+		       created_at, completed_at
+	`,
+
+		buildKey(QueryFinishWebhookDelivery, DialectSQLite): `
+		UPDATE webhook_deliveries
+		SET status = ?, attempts = ?, next_attempt_at = ?, response_status = ?, error = ?, completed_at = ?,
+		    lease_owner = NULL, lease_until = NULL
+		WHERE id = ? AND lease_owner = ?
+	`,
+		buildKey(QueryFinishWebhookDelivery, DialectPostgres): `
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, response_status = $4, error = $5, completed_at = $6,
+		    lease_owner = NULL, lease_until = NULL
+		WHERE id = $7 AND lease_owner = $8
+	`,
+
+		buildKey(QueryFailPendingWebhookDeliveries, DialectSQLite): `
+		UPDATE webhook_deliveries
+		SET status = 'failed', error = ?, completed_at = ?, lease_owner = NULL, lease_until = NULL
+		WHERE webhook_id = ? AND status = 'pending'
+	`,
+		buildKey(QueryFailPendingWebhookDeliveries, DialectPostgres): `
+		UPDATE webhook_deliveries
+		SET status = 'failed', error = $1, completed_at = $2, lease_owner = NULL, lease_until = NULL
+		WHERE webhook_id = $3 AND status = 'pending'
+	`,
+
+		buildKey(QueryDeleteWebhookDeliveries, DialectSQLite): `
+		DELETE FROM webhook_deliveries WHERE webhook_id = ?
+	`,
+		buildKey(QueryDeleteWebhookDeliveries, DialectPostgres): `
+		DELETE FROM webhook_deliveries WHERE webhook_id = $1
+	`,
+
+		buildKey(QueryDeleteFinishedWebhookDeliveries, DialectSQLite): `
+		DELETE FROM webhook_deliveries WHERE status <> 'pending' AND completed_at < ?
+	`,
+		buildKey(QueryDeleteFinishedWebhookDeliveries, DialectPostgres): `
+		DELETE FROM webhook_deliveries WHERE status <> 'pending' AND completed_at < $1
+	`,
+
+		buildKey(QueryQueueWebhookCheck, DialectSQLite): `
+		INSERT INTO webhook_checks (user_id, event, subject, attempts, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, event, subject) DO NOTHING
+	`,
+		buildKey(QueryQueueWebhookCheck, DialectPostgres): `
+		INSERT INTO webhook_checks (user_id, event, subject, attempts, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, event, subject) DO NOTHING
+	`,
+
+		buildKey(QueryTakeWebhookCheck, DialectSQLite): `
+		DELETE FROM webhook_checks
+		WHERE id = (SELECT id FROM webhook_checks WHERE next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT 1)
+		RETURNING id, user_id, event, subject, attempts, next_attempt_at, created_at
+	`,
+		buildKey(QueryTakeWebhookCheck, DialectPostgres): `
+		DELETE FROM webhook_checks
+		WHERE id = (SELECT id FROM webhook_checks WHERE next_attempt_at <= $1 ORDER BY next_attempt_at, id LIMIT 1 FOR UPDATE SKIP LOCKED)
+		RETURNING id, user_id, event, subject, attempts, next_attempt_at, created_at
+	`,
+
+		// Change feed queries
+		buildKey(QueryGetSyncVersion, DialectSQLite): `
+		SELECT sync_version FROM users WHERE id = ?
+	`,
+		buildKey(QueryGetSyncVersion, DialectPostgres): `
+		SELECT sync_version FROM users WHERE id = $1
+	`,
+
+		buildKey(QueryNextSyncVersion, DialectSQLite): `
+		UPDATE users SET sync_version = sync_version + ? WHERE id = ?
+		RETURNING sync_version
+	`,
+		buildKey(QueryNextSyncVersion, DialectPostgres): `
+		UPDATE users SET sync_version = sync_version + $1 WHERE id = $2
+		RETURNING sync_version
+	`,
+
+		buildKey(QueryRecordSyncChange, DialectSQLite): `
+		INSERT INTO sync_changes (user_id, entity, entity_id, version, deleted, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, entity, entity_id) DO UPDATE SET
+			version = excluded.version, deleted = excluded.deleted, changed_at = excluded.changed_at
+	`,
+		buildKey(QueryRecordSyncChange, DialectPostgres): `
+		INSERT INTO sync_changes (user_id, entity, entity_id, version, deleted, changed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, entity, entity_id) DO UPDATE SET
+			version = excluded.version, deleted = excluded.deleted, changed_at = excluded.changed_at
+	`,
+
+		buildKey(QueryCountUntrackedIngredients, DialectSQLite): `
+		SELECT COUNT(*)
+		FROM user_ingredients ui
+		WHERE ui.user_id = ? AND NOT EXISTS (
+			SELECT 1 FROM sync_changes c
+			WHERE c.user_id = ui.user_id AND c.entity = 'ingredient' AND c.entity_id = ui.id
+		)
+	`,
+		buildKey(QueryCountUntrackedIngredients, DialectPostgres): `
+		SELECT COUNT(*)
+		FROM user_ingredients ui
+		WHERE ui.user_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM sync_changes c
+			WHERE c.user_id = ui.user_id AND c.entity = 'ingredient' AND c.entity_id = ui.id
+		)
+	`,
+
+		buildKey(QueryRecordUntrackedIngredients, DialectSQLite): `
+		INSERT INTO sync_changes (user_id, entity, entity_id, version, deleted, changed_at)
+		SELECT ui.user_id, 'ingredient', ui.id, ? + ROW_NUMBER() OVER (ORDER BY ui.id), 0, ?
+		FROM user_ingredients ui
+		WHERE ui.user_id = ? AND NOT EXISTS (
+			SELECT 1 FROM sync_changes c
+			WHERE c.user_id = ui.user_id AND c.entity = 'ingredient' AND c.entity_id = ui.id
+		)
+	`,
+		buildKey(QueryRecordUntrackedIngredients, DialectPostgres): `
+		INSERT INTO sync_changes (user_id, entity, entity_id, version, deleted, changed_at)
+		SELECT ui.user_id, 'ingredient', ui.id, $1 + ROW_NUMBER() OVER (ORDER BY ui.id), false, $2
+		FROM user_ingredients ui
+		WHERE ui.user_id = $3 AND NOT EXISTS (
+			SELECT 1 FROM sync_changes c
+			WHERE c.user_id = ui.user_id AND c.entity = 'ingredient' AND c.entity_id = ui.id
+		)
+	`,
+
+		buildKey(QueryGetCalorieEntryIDsByFood, DialectSQLite): `
+		SELECT id FROM calorie_entries WHERE user_id = ? AND food = ? AND deleted_at IS NULL
+	`,
+		buildKey(QueryGetCalorieEntryIDsByFood, DialectPostgres): `
+		SELECT id FROM calorie_entries WHERE user_id = $1 AND food = $2 AND deleted_at IS NULL
+	`,
+
+		buildKey(QueryGetSyncChanges, DialectSQLite): `
+		SELECT user_id, entity, entity_id, version, deleted, changed_at
+		FROM sync_changes
+		WHERE user_id = ? AND version > ?
+		ORDER BY version
+		LIMIT ?
+	`,
+		buildKey(QueryGetSyncChanges, DialectPostgres): `
+		SELECT user_id, entity, entity_id, version, deleted, changed_at
+		FROM sync_changes
+		WHERE user_id = $1 AND version > $2
+		ORDER BY version
+		LIMIT $3
+	`,
+
+		buildKey(QueryGetSyncedCalorieEntries, DialectSQLite): `
+		SELECT e.id, e.user_id, e.food, e.calories, e.weight, e.kcal_per_100g, e.fats, e.carbs, e.proteins, e.nutrients,
+		       e.quantity, e.unit, e.meal_datetime, e.updated_at, e.created_at, e.version
+		FROM sync_changes c
+		JOIN calorie_entries e ON e.id = c.entity_id AND e.user_id = c.user_id AND e.deleted_at IS NULL
+		WHERE c.user_id = ? AND c.entity = 'calorie_entry' AND c.version > ? AND c.version <= ?
+	`,
+		buildKey(QueryGetSyncedCalorieEntries, DialectPostgres): `
+		SELECT e.id, e.user_id, e.food, e.calories, e.weight, e.kcal_per_100g, e.fats, e.carbs, e.proteins, e.nutrients,
+		       e.quantity, e.unit, e.meal_datetime, e.updated_at, e.created_at, e.version
+		FROM sync_changes c
+		JOIN calorie_entries e ON e.id = c.entity_id AND e.user_id = c.user_id AND e.deleted_at IS NULL
+		WHERE c.user_id = $1 AND c.entity = 'calorie_entry' AND c.version > $2 AND c.version <= $3
+	`,
+
+		buildKey(QueryGetSyncedWeightHistory, DialectSQLite): `
+		SELECT w.id, w.user_id, w.weight, w.recorded_at, w.created_at, w.version
+		FROM sync_changes c
+		JOIN weight_history w ON w.id = c.entity_id AND w.user_id = c.user_id AND w.deleted_at IS NULL
+		WHERE c.user_id = ? AND c.entity = 'weight' AND c.version > ? AND c.version <= ?
+	`,
+		buildKey(QueryGetSyncedWeightHistory, DialectPostgres): `
+		SELECT w.id, w.user_id, w.weight, w.recorded_at, w.created_at, w.version
+		FROM sync_changes c
+		JOIN weight_history w ON w.id = c.entity_id AND w.user_id = c.user_id AND w.deleted_at IS NULL
+		WHERE c.user_id = $1 AND c.entity = 'weight' AND c.version > $2 AND c.version <= $3
+	`,
+
+		buildKey(QueryGetSyncedIngredients, DialectSQLite): `
+		SELECT ui.id, ui.user_id, ui.name, ui.kcal_per_100g, ui.fats, ui.carbs, ui.proteins, ui.nutrients, ui.barcode,
+		       ui.global_ingredient_id, ui.created_at, ui.updated_at
+		FROM sync_changes c
+		JOIN user_ingredients ui ON ui.id = c.entity_id AND ui.user_id = c.user_id
+		WHERE c.user_id = ? AND c.entity = 'ingredient' AND c.version > ? AND c.version <= ?
+	`,
+		buildKey(QueryGetSyncedIngredients, DialectPostgres): `
+		SELECT ui.id, ui.user_id, ui.name, ui.kcal_per_100g, ui.fats, ui.carbs, ui.proteins, ui.nutrients, ui.barcode,
+		       ui.global_ingredient_id, ui.created_at, ui.updated_at
+		FROM sync_changes c
+		JOIN user_ingredients ui ON ui.id = c.entity_id AND ui.user_id = c.user_id
+		WHERE c.user_id = $1 AND c.entity = 'ingredient' AND c.version > $2 AND c.version <= $3
+	`,
+
+		buildKey(QueryDeleteSyncTombstones, DialectSQLite): `
+		DELETE FROM sync_changes WHERE deleted = 1 AND changed_at < ?
+	`,
+		buildKey(QueryDeleteSyncTombstones, DialectPostgres): `
+		DELETE FROM sync_changes WHERE deleted = true AND changed_at < $1
+	`,
+
+		// Batch write queries
+		buildKey(QueryBatchSavepoint, DialectSQLite): `
+		SAVEPOINT batch_operation
+	`,
+		buildKey(QueryBatchSavepoint, DialectPostgres): `
+		SAVEPOINT batch_operation
+	`,
+		buildKey(QueryBatchRollbackSavepoint, DialectSQLite): `
+		ROLLBACK TO SAVEPOINT batch_operation
+	`,
+		buildKey(QueryBatchRollbackSavepoint, DialectPostgres): `
+		ROLLBACK TO SAVEPOINT batch_operation
+	`,
+		buildKey(QueryBatchReleaseSavepoint, DialectSQLite): `
+		RELEASE SAVEPOINT batch_operation
+	`,
+		buildKey(QueryBatchReleaseSavepoint, DialectPostgres): `
+		RELEASE SAVEPOINT batch_operation
+	`,
+
+		// Idempotency key queries
+		buildKey(QueryClaimIdempotencyKey, DialectSQLite): `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, status_code, created_at, expires_at)
+		VALUES (?, ?, ?, 0, ?, ?)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE SET
+			request_hash = excluded.request_hash, status_code = 0, response_headers = NULL, response_body = NULL,
+			created_at = excluded.created_at, expires_at = excluded.expires_at
+		WHERE idempotency_keys.expires_at <= ? OR (idempotency_keys.status_code = 0 AND idempotency_keys.created_at <= ?)
+	`,
+		buildKey(QueryClaimIdempotencyKey, DialectPostgres): `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, status_code, created_at, expires_at)
+		VALUES ($1, $2, $3, 0, $4, $5)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE SET
+			request_hash = excluded.request_hash, status_code = 0, response_headers = NULL, response_body = NULL,
+			created_at = excluded.created_at, expires_at = excluded.expires_at
+		WHERE idempotency_keys.expires_at <= $6 OR (idempotency_keys.status_code = 0 AND idempotency_keys.created_at <= $7)
+	`,
+
+		buildKey(QueryGetIdempotencyKey, DialectSQLite): `
+		SELECT user_id, idempotency_key, request_hash, status_code, response_headers, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = ? AND idempotency_key = ?
+	`,
+		buildKey(QueryGetIdempotencyKey, DialectPostgres): `
+		SELECT user_id, idempotency_key, request_hash, status_code, response_headers, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`,
+
+		buildKey(QuerySaveIdempotentResponse, DialectSQLite): `
+		UPDATE idempotency_keys
+		SET status_code = ?, response_headers = ?, response_body = ?
+		WHERE user_id = ? AND idempotency_key = ? AND status_code = 0
+	`,
+		buildKey(QuerySaveIdempotentResponse, DialectPostgres): `
+		UPDATE idempotency_keys
+		SET status_code = $1, response_headers = $2, response_body = $3
+		WHERE user_id = $4 AND idempotency_key = $5 AND status_code = 0
+	`,
+
+		buildKey(QueryDeleteIdempotencyKey, DialectSQLite): `
+		DELETE FROM idempotency_keys WHERE user_id = ? AND idempotency_key = ? AND status_code = 0
+	`,
+		buildKey(QueryDeleteIdempotencyKey, DialectPostgres): `
+		DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2 AND status_code = 0
+	`,
+
+		buildKey(QueryDeleteExpiredIdempotencyKeys, DialectSQLite): `
+		DELETE FROM idempotency_keys WHERE expires_at < ?
+	`,
+		buildKey(QueryDeleteExpiredIdempotencyKeys, DialectPostgres): `
+		DELETE FROM idempotency_keys WHERE expires_at < $1
+	`,
 	}
 }
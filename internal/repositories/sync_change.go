@@ -0,0 +1,255 @@
+package repositories
+
+import (
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// changeRecorder adds writes to calorie entries, weigh-ins and ingredients to
+// the change feed. It runs in the transaction of the write, so the feed never
+// misses a committed change nor shows one that was rolled back.
+type changeRecorder struct {
+	sqlLoader *SqlLoaderInstance
+}
+
+func newChangeRecorder(sqlLoader *SqlLoaderInstance) *changeRecorder {
+	return &changeRecorder{sqlLoader: sqlLoader}
+}
+
+// record gives an entity the user's next version; a deleted entity becomes a tombstone
+func (c *changeRecorder) record(tx *sql.Tx, userID int, entity string, entityID int, deleted bool) error {
+	version, err := c.nextVersion(tx, userID, 1)
+	if err != nil {
+		return err
+	}
+
+	query, err := c.sqlLoader.Load(QueryRecordSyncChange)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(query, userID, entity, entityID, version, deleted, time.Now().UTC())
+	return err
+}
+
+// recordNewIngredients records the user's ingredients that are not in the feed
+// yet, after ingredients were inserted in bulk
+func (c *changeRecorder) recordNewIngredients(tx *sql.Tx, userID int) error {
+	countQuery, err := c.sqlLoader.Load(QueryCountUntrackedIngredients)
+	if err != nil {
+		return err
+	}
+	insertQuery, err := c.sqlLoader.Load(QueryRecordUntrackedIngredients)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if err := tx.QueryRow(countQuery, userID).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		return nil
+	}
+
+	last, err := c.nextVersion(tx, userID, count)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(insertQuery, last-int64(count), time.Now().UTC(), userID)
+	return err
+}
+
+// nextVersion reserves count versions for the user and returns the last of
+// them. Updating the user row also makes concurrent writes of the same user
+// wait for each other, so versions are committed in the order they are given.
+func (c *changeRecorder) nextVersion(tx *sql.Tx, userID int, count int) (int64, error) {
+	query, err := c.sqlLoader.Load(QueryNextSyncVersion)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	if err := tx.QueryRow(query, count, userID).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+type SyncChangeRepositoryImpl struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	sqlLoader *SqlLoaderInstance
+}
+
+// NewSyncChangeRepository creates a new change feed repository
+func NewSyncChangeRepository(db *sql.DB, dialect Dialect, logger *slog.Logger) *SyncChangeRepositoryImpl {
+	return &SyncChangeRepositoryImpl{
+		db:        db,
+		logger:    logger.With("repository", "sync_change"),
+		sqlLoader: NewSqlLoader(dialect),
+	}
+}
+
+// GetChanges returns up to limit of the user's changes with a version above the given one, oldest first
+func (r *SyncChangeRepositoryImpl) GetChanges(userID int, after int64, limit int) ([]*models.SyncChange, error) {
+	query, err := r.sqlLoader.Load(QueryGetSyncChanges)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, userID, after, limit)
+	if err != nil {
+		r.logger.Error("Failed to get changes", "user_id", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []*models.SyncChange{}
+	for rows.Next() {
+		var change models.SyncChange
+		if err := rows.Scan(&change.UserID, &change.Entity, &change.EntityID, &change.Version, &change.Deleted, &change.ChangedAt); err != nil {
+			r.logger.Error("Failed to scan change", "error", err)
+			return nil, err
+		}
+		changes = append(changes, &change)
+	}
+
+	return changes, rows.Err()
+}
+
+// GetCalorieEntries returns the user's existing calorie entries whose latest
+// version is in the range (after, upTo]
+func (r *SyncChangeRepositoryImpl) GetCalorieEntries(userID int, after, upTo int64) ([]*models.CalorieEntry, error) {
+	rows, err := r.query(QueryGetSyncedCalorieEntries, userID, after, upTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.CalorieEntry
+	for rows.Next() {
+		entry := &models.CalorieEntry{}
+		err := rows.Scan(
+			&entry.ID,
+			&entry.UserID,
+			&entry.Food,
+			&entry.Calories,
+			&entry.Weight,
+			&entry.KcalPer100g,
+			&entry.Fats,
+			&entry.Carbs,
+			&entry.Proteins,
+			&entry.Nutrients,
+			&entry.Quantity,
+			&entry.Unit,
+			&entry.MealDatetime,
+			&entry.UpdatedAt,
+			&entry.CreatedAt,
+			&entry.Version,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan calorie entry", "error", err)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetWeightHistory returns the user's existing weigh-ins whose latest version
+// is in the range (after, upTo]
+func (r *SyncChangeRepositoryImpl) GetWeightHistory(userID int, after, upTo int64) ([]*models.WeightHistory, error) {
+	rows, err := r.query(QueryGetSyncedWeightHistory, userID, after, upTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.WeightHistory
+	for rows.Next() {
+		entry := &models.WeightHistory{}
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Weight, &entry.RecordedAt, &entry.CreatedAt, &entry.Version); err != nil {
+			r.logger.Error("Failed to scan weight entry", "error", err)
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// GetIngredients returns the user's existing ingredients whose latest version
+// is in the range (after, upTo]
+func (r *SyncChangeRepositoryImpl) GetIngredients(userID int, after, upTo int64) ([]*models.UserIngredient, error) {
+	rows, err := r.query(QueryGetSyncedIngredients, userID, after, upTo)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ingredients []*models.UserIngredient
+	for rows.Next() {
+		ingredient := &models.UserIngredient{}
+		err := rows.Scan(
+			&ingredient.ID,
+			&ingredient.UserID,
+			&ingredient.Name,
+			&ingredient.KcalPer100g,
+			&ingredient.Fats,
+			&ingredient.Carbs,
+			&ingredient.Proteins,
+			&ingredient.Nutrients,
+			&ingredient.Barcode,
+			&ingredient.GlobalIngredientID,
+			&ingredient.CreatedAt,
+			&ingredient.UpdatedAt,
+		)
+		if err != nil {
+			r.logger.Error("Failed to scan ingredient", "error", err)
+			return nil, err
+		}
+		ingredients = append(ingredients, ingredient)
+	}
+
+	return ingredients, rows.Err()
+}
+
+// DeleteTombstonesBefore removes tombstones of entities deleted before the given time
+func (r *SyncChangeRepositoryImpl) DeleteTombstonesBefore(before time.Time) (int64, error) {
+	query, err := r.sqlLoader.Load(QueryDeleteSyncTombstones)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return 0, err
+	}
+
+	result, err := r.db.Exec(query, before.UTC())
+	if err != nil {
+		r.logger.Error("Failed to delete tombstones", "error", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// query runs a query for the changed entities of one type
+func (r *SyncChangeRepositoryImpl) query(queryName string, userID int, after, upTo int64) (*sql.Rows, error) {
+	query, err := r.sqlLoader.Load(queryName)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, userID, after, upTo)
+	if err != nil {
+		r.logger.Error("Failed to get changed entities", "query", queryName, "user_id", userID, "error", err)
+		return nil, err
+	}
+	return rows, nil
+}
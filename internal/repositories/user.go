@@ -3,6 +3,7 @@ package repositories
 import (
 	"database/sql"
 	"log/slog"
+	"time"
 
 	"ypeskov/kkal-tracker/internal/models"
 )
@@ -11,6 +12,7 @@ type UserRepositoryImpl struct {
 	db        *sql.DB
 	logger    *slog.Logger
 	sqlLoader *SqlLoaderInstance
+	changes   *changeRecorder
 }
 
 func NewUserRepository(db *sql.DB, logger *slog.Logger, dialect Dialect) *UserRepositoryImpl {
@@ -20,6 +22,7 @@ func NewUserRepository(db *sql.DB, logger *slog.Logger, dialect Dialect) *UserRe
 		db:        db,
 		logger:    logger,
 		sqlLoader: sqlLoader,
+		changes:   newChangeRecorder(sqlLoader),
 	}
 }
 
@@ -79,6 +82,10 @@ func (r *UserRepositoryImpl) CreateWithLanguage(email, passwordHash, languageCod
 		slog.Int64("user_id", id),
 		slog.Int64("count", rowsInserted))
 
+	if err := r.changes.recordNewIngredients(tx, int(id)); err != nil {
+		return nil, err
+	}
+
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
 		return nil, err
@@ -103,11 +110,13 @@ func (r *UserRepositoryImpl) GetByID(id int) (*models.User, error) {
 	var activityLevel sql.NullString
 	var targetDate sql.NullTime
 	var goalSetAt sql.NullTime
+	var deletionScheduledAt sql.NullTime
 	err = r.db.QueryRow(query, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
 		&user.IsActive,
+		&user.Role,
 		&user.FirstName,
 		&user.LastName,
 		&user.Age,
@@ -119,8 +128,10 @@ func (r *UserRepositoryImpl) GetByID(id int) (*models.User, error) {
 		&targetDate,
 		&goalSetAt,
 		&user.InitialWeightAtGoal,
+		&deletionScheduledAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.ProfileVersion,
 	)
 	if err == nil && language.Valid {
 		user.Language = &language.String
@@ -137,6 +148,9 @@ func (r *UserRepositoryImpl) GetByID(id int) (*models.User, error) {
 	if err == nil && goalSetAt.Valid {
 		user.GoalSetAt = &goalSetAt.Time
 	}
+	if err == nil && deletionScheduledAt.Valid {
+		user.DeletionScheduledAt = &deletionScheduledAt.Time
+	}
 
 	if err != nil {
 		return nil, err
@@ -159,11 +173,13 @@ func (r *UserRepositoryImpl) GetByEmail(email string) (*models.User, error) {
 	var activityLevel sql.NullString
 	var targetDate sql.NullTime
 	var goalSetAt sql.NullTime
+	var deletionScheduledAt sql.NullTime
 	err = r.db.QueryRow(query, email).Scan(
 		&user.ID,
 		&user.Email,
 		&user.PasswordHash,
 		&user.IsActive,
+		&user.Role,
 		&user.FirstName,
 		&user.LastName,
 		&user.Age,
@@ -175,8 +191,10 @@ func (r *UserRepositoryImpl) GetByEmail(email string) (*models.User, error) {
 		&targetDate,
 		&goalSetAt,
 		&user.InitialWeightAtGoal,
+		&deletionScheduledAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.ProfileVersion,
 	)
 	if err == nil && language.Valid {
 		user.Language = &language.String
@@ -193,6 +211,9 @@ func (r *UserRepositoryImpl) GetByEmail(email string) (*models.User, error) {
 	if err == nil && goalSetAt.Valid {
 		user.GoalSetAt = &goalSetAt.Time
 	}
+	if err == nil && deletionScheduledAt.Valid {
+		user.DeletionScheduledAt = &deletionScheduledAt.Time
+	}
 
 	if err != nil {
 		return nil, err
@@ -201,8 +222,9 @@ func (r *UserRepositoryImpl) GetByEmail(email string) (*models.User, error) {
 	return user, nil
 }
 
-// UpdateProfile updates user profile information
-func (r *UserRepositoryImpl) UpdateProfile(userID int, firstName, lastName *string, email string, age *int, height *float64, gender *string, _ *float64, language string, activityLevel *string) error {
+// UpdateProfile updates user profile information. A non-zero version makes the
+// update conditional: ErrVersionConflict is returned if the profile has another version.
+func (r *UserRepositoryImpl) UpdateProfile(userID int, firstName, lastName *string, email string, age *int, height *float64, gender *string, _ *float64, language string, activityLevel *string, version int) error {
 	r.logger.Debug("Updating user profile", slog.Int("user_id", userID))
 
 	query, err := r.sqlLoader.Load(QueryUpdateUserProfile)
@@ -210,7 +232,7 @@ func (r *UserRepositoryImpl) UpdateProfile(userID int, firstName, lastName *stri
 		return err
 	}
 
-	_, err = r.db.Exec(query,
+	result, err := r.db.Exec(query,
 		firstName,
 		lastName,
 		email,
@@ -220,9 +242,22 @@ func (r *UserRepositoryImpl) UpdateProfile(userID int, firstName, lastName *stri
 		language,
 		activityLevel,
 		userID,
+		version,
+		version,
 	)
+	if err != nil {
+		return err
+	}
 
-	return err
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 && version != 0 {
+		return ErrVersionConflict
+	}
+
+	return nil
 }
 
 // AddWeightEntry adds a new weight entry to the history
@@ -234,8 +269,26 @@ func (r *UserRepositoryImpl) AddWeightEntry(userID int, weight float64) error {
 		return err
 	}
 
-	_, err = r.db.Exec(query, userID, weight)
-	return err
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, userID, weight)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if err := r.changes.record(tx, userID, models.SyncEntityWeight, int(id), false); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // ActivateUser sets the user's is_active status to true
@@ -267,7 +320,30 @@ func (r *UserRepositoryImpl) ActivateUser(userID int) error {
 	return nil
 }
 
-// Delete removes a user from the database
+// userDataQueries delete everything a user owns, children before their parents.
+// Foreign key cascades are not relied on, as SQLite does not enforce them here.
+var userDataQueries = []string{
+	QueryPurgeUserMealPlanItems,
+	QueryPurgeUserIngredientServings,
+	QueryPurgeUserMealPlans,
+	QueryPurgeUserCalorieEntries,
+	QueryPurgeUserWeightHistory,
+	QueryPurgeUserIngredients,
+	QueryPurgeUserAPIKeys,
+	QueryPurgeUserActivationTokens,
+	QueryPurgeUserAICache,
+	QueryPurgeUserExportJobs,
+	QueryPurgeUserExportSchedules,
+	QueryPurgeUserCalendarFeeds,
+	QueryPurgeUserWebhookDeliveries,
+	QueryPurgeUserWebhookChecks,
+	QueryPurgeUserWebhooks,
+	QueryPurgeUserSyncChanges,
+	QueryPurgeUserIdempotencyKeys,
+	QueryPurgeUserAuditLog,
+}
+
+// Delete removes a user and all of their data from the database in one transaction
 func (r *UserRepositoryImpl) Delete(userID int) error {
 	r.logger.Debug("Deleting user", slog.Int("user_id", userID))
 
@@ -276,7 +352,24 @@ func (r *UserRepositoryImpl) Delete(userID int) error {
 		return err
 	}
 
-	result, err := r.db.Exec(query, userID)
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, name := range userDataQueries {
+		dataQuery, err := r.sqlLoader.Load(name)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(dataQuery, userID); err != nil {
+			r.logger.Error("Failed to delete user data", "error", err, "user_id", userID, "query", name)
+			return err
+		}
+	}
+
+	result, err := tx.Exec(query, userID)
 	if err != nil {
 		r.logger.Error("Failed to delete user", "error", err, "user_id", userID)
 		return err
@@ -292,6 +385,10 @@ func (r *UserRepositoryImpl) Delete(userID int) error {
 		return ErrNotFound
 	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
 	r.logger.Info("User deleted successfully", "user_id", userID)
 	return nil
 }
@@ -356,3 +453,114 @@ func (r *UserRepositoryImpl) ClearWeightGoal(userID int) error {
 	r.logger.Info("Weight goal cleared successfully", "user_id", userID)
 	return nil
 }
+
+// SetRole changes the user's role
+func (r *UserRepositoryImpl) SetRole(userID int, role string) error {
+	r.logger.Debug("Setting user role", slog.Int("user_id", userID), slog.String("role", role))
+
+	query, err := r.sqlLoader.Load(QuerySetUserRole)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(query, role, userID)
+	if err != nil {
+		r.logger.Error("Failed to set user role", "error", err, "user_id", userID)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.logger.Warn("No user updated (user not found)", "user_id", userID)
+		return ErrNotFound
+	}
+
+	r.logger.Info("User role set successfully", "user_id", userID, "role", role)
+	return nil
+}
+
+// ScheduleDeletion marks the user's account to be purged at the given time
+func (r *UserRepositoryImpl) ScheduleDeletion(userID int, purgeAt time.Time) error {
+	r.logger.Debug("Scheduling user deletion", slog.Int("user_id", userID), slog.Time("purge_at", purgeAt))
+
+	query, err := r.sqlLoader.Load(QueryScheduleUserDeletion)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(query, purgeAt.UTC(), userID)
+	if err != nil {
+		r.logger.Error("Failed to schedule user deletion", "error", err, "user_id", userID)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.logger.Warn("No user updated (user not found)", "user_id", userID)
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// CancelDeletion keeps an account that was scheduled for deletion
+func (r *UserRepositoryImpl) CancelDeletion(userID int) error {
+	r.logger.Debug("Cancelling user deletion", slog.Int("user_id", userID))
+
+	query, err := r.sqlLoader.Load(QueryCancelUserDeletion)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.Exec(query, userID)
+	if err != nil {
+		r.logger.Error("Failed to cancel user deletion", "error", err, "user_id", userID)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		r.logger.Warn("No user updated (user not found)", "user_id", userID)
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// GetDueForDeletion returns the IDs of up to limit users whose deletion time has passed
+func (r *UserRepositoryImpl) GetDueForDeletion(now time.Time, limit int) ([]int, error) {
+	query, err := r.sqlLoader.Load(QueryGetUsersDueForDeletion)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, now.UTC(), limit)
+	if err != nil {
+		r.logger.Error("Failed to get users due for deletion", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
@@ -0,0 +1,495 @@
+package repositories
+
+import (
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+type WebhookRepositoryImpl struct {
+	db        *sql.DB
+	logger    *slog.Logger
+	sqlLoader *SqlLoaderInstance
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *sql.DB, dialect Dialect, logger *slog.Logger) *WebhookRepositoryImpl {
+	return &WebhookRepositoryImpl{
+		db:        db,
+		logger:    logger.With("repository", "webhook"),
+		sqlLoader: NewSqlLoader(dialect),
+	}
+}
+
+// Create stores a new webhook and sets its ID and timestamps
+func (r *WebhookRepositoryImpl) Create(webhook *models.Webhook) error {
+	query, err := r.sqlLoader.Load(QueryCreateWebhook)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	now := time.Now().UTC()
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	err = r.db.QueryRow(query, webhook.UserID, webhook.URL, webhook.Secret, strings.Join(webhook.Events, ","),
+		webhook.Enabled, webhook.CreatedAt, webhook.UpdatedAt).Scan(&webhook.ID)
+	if err != nil {
+		r.logger.Error("Failed to create webhook", "user_id", webhook.UserID, "error", err)
+		return err
+	}
+
+	r.logger.Debug("Webhook created", "id", webhook.ID, "user_id", webhook.UserID)
+	return nil
+}
+
+// GetByID retrieves one of a user's webhooks
+func (r *WebhookRepositoryImpl) GetByID(id, userID int) (*models.Webhook, error) {
+	query, err := r.sqlLoader.Load(QueryGetWebhookByID)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	webhook, err := r.scanWebhook(r.db.QueryRow(query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		r.logger.Error("Failed to get webhook", "id", id, "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+// GetByUserID retrieves all of a user's webhooks
+func (r *WebhookRepositoryImpl) GetByUserID(userID int) ([]*models.Webhook, error) {
+	return r.list(QueryGetWebhooksByUserID, userID)
+}
+
+// GetEnabledByUserID retrieves the user's webhooks that receive events
+func (r *WebhookRepositoryImpl) GetEnabledByUserID(userID int) ([]*models.Webhook, error) {
+	return r.list(QueryGetEnabledWebhooksByUserID, userID)
+}
+
+// list runs a query returning a user's webhooks
+func (r *WebhookRepositoryImpl) list(name string, userID int) ([]*models.Webhook, error) {
+	query, err := r.sqlLoader.Load(name)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		r.logger.Error("Failed to get webhooks", "user_id", userID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []*models.Webhook{}
+	for rows.Next() {
+		webhook, err := r.scanWebhook(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan webhook", "error", err)
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+// Update stores the URL, events and state of a webhook
+func (r *WebhookRepositoryImpl) Update(webhook *models.Webhook) error {
+	query, err := r.sqlLoader.Load(QueryUpdateWebhook)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	var disabledAt any
+	if webhook.DisabledAt != nil {
+		disabledAt = webhook.DisabledAt.UTC()
+	}
+	webhook.UpdatedAt = time.Now().UTC()
+	result, err := r.db.Exec(query, webhook.URL, strings.Join(webhook.Events, ","), webhook.Enabled, webhook.FailureCount,
+		disabledAt, webhook.UpdatedAt, webhook.ID, webhook.UserID)
+	if err != nil {
+		r.logger.Error("Failed to update webhook", "id", webhook.ID, "error", err)
+		return err
+	}
+
+	return r.requireRow(result)
+}
+
+// UpdateSecret replaces the signing secret of one of a user's webhooks
+func (r *WebhookRepositoryImpl) UpdateSecret(id, userID int, secret string) error {
+	query, err := r.sqlLoader.Load(QueryUpdateWebhookSecret)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	result, err := r.db.Exec(query, secret, time.Now().UTC(), id, userID)
+	if err != nil {
+		r.logger.Error("Failed to update webhook secret", "id", id, "error", err)
+		return err
+	}
+
+	return r.requireRow(result)
+}
+
+// Delete removes one of a user's webhooks together with its delivery log
+func (r *WebhookRepositoryImpl) Delete(id, userID int) error {
+	query, err := r.sqlLoader.Load(QueryDeleteWebhook)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+	deliveriesQuery, err := r.sqlLoader.Load(QueryDeleteWebhookDeliveries)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		r.logger.Error("Failed to begin transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, id, userID)
+	if err != nil {
+		r.logger.Error("Failed to delete webhook", "id", id, "user_id", userID, "error", err)
+		return err
+	}
+	if err := r.requireRow(result); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(deliveriesQuery, id); err != nil {
+		r.logger.Error("Failed to delete webhook deliveries", "id", id, "error", err)
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RecordSuccess resets the failure count of a webhook after a successful delivery
+func (r *WebhookRepositoryImpl) RecordSuccess(id int, at time.Time) error {
+	query, err := r.sqlLoader.Load(QueryRecordWebhookSuccess)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	if _, err := r.db.Exec(query, at.UTC(), id); err != nil {
+		r.logger.Error("Failed to record webhook success", "id", id, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// RecordFailure counts a failed delivery attempt and disables the webhook once
+// disableAfter attempts in a row have failed. Reports whether it is still enabled.
+func (r *WebhookRepositoryImpl) RecordFailure(id int, at time.Time, disableAfter int) (bool, error) {
+	query, err := r.sqlLoader.Load(QueryRecordWebhookFailure)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return false, err
+	}
+
+	var enabled bool
+	if err := r.db.QueryRow(query, at.UTC(), disableAfter, at.UTC(), disableAfter, id).Scan(&enabled); err != nil {
+		if err == sql.ErrNoRows {
+			return false, ErrNotFound
+		}
+		r.logger.Error("Failed to record webhook failure", "id", id, "error", err)
+		return false, err
+	}
+
+	return enabled, nil
+}
+
+// CreateDelivery queues a delivery and sets its ID. A delivery with an event
+// key the webhook already received is not stored; false is returned then.
+func (r *WebhookRepositoryImpl) CreateDelivery(delivery *models.WebhookDelivery) (bool, error) {
+	query, err := r.sqlLoader.Load(QueryCreateWebhookDelivery)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return false, err
+	}
+
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.CreatedAt = time.Now().UTC()
+	err = r.db.QueryRow(query, delivery.WebhookID, delivery.UserID, delivery.Event, delivery.EventID, delivery.EventKey,
+		delivery.Payload, delivery.NextAttemptAt.UTC(), delivery.CreatedAt).Scan(&delivery.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		r.logger.Error("Failed to create webhook delivery", "webhook_id", delivery.WebhookID, "error", err)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetDeliveries retrieves the latest deliveries of one of a user's webhooks, newest first
+func (r *WebhookRepositoryImpl) GetDeliveries(webhookID, userID, limit int) ([]*models.WebhookDelivery, error) {
+	query, err := r.sqlLoader.Load(QueryGetWebhookDeliveries)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, webhookID, userID, limit)
+	if err != nil {
+		r.logger.Error("Failed to get webhook deliveries", "webhook_id", webhookID, "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := r.scanDelivery(rows)
+		if err != nil {
+			r.logger.Error("Failed to scan webhook delivery", "error", err)
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// ClaimDueDelivery leases the most overdue pending delivery of an enabled
+// webhook to owner until leaseUntil and returns it. Deliveries whose lease ran
+// out, because the instance sending them stopped, can be claimed again.
+// Returns nil when nothing is due.
+func (r *WebhookRepositoryImpl) ClaimDueDelivery(owner string, now, leaseUntil time.Time) (*models.WebhookDelivery, error) {
+	query, err := r.sqlLoader.Load(QueryClaimDueWebhookDelivery)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	delivery, err := r.scanDelivery(r.db.QueryRow(query, owner, leaseUntil.UTC(), now.UTC(), now.UTC()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to claim webhook delivery", "error", err)
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// FinishDelivery stores the outcome of an attempt and releases the lease. It
+// does nothing if owner no longer holds the lease.
+func (r *WebhookRepositoryImpl) FinishDelivery(delivery *models.WebhookDelivery, owner string) error {
+	query, err := r.sqlLoader.Load(QueryFinishWebhookDelivery)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	var completedAt any
+	if delivery.CompletedAt != nil {
+		completedAt = delivery.CompletedAt.UTC()
+	}
+	_, err = r.db.Exec(query, delivery.Status, delivery.Attempts, delivery.NextAttemptAt.UTC(), delivery.ResponseStatus,
+		delivery.Error, completedAt, delivery.ID, owner)
+	if err != nil {
+		r.logger.Error("Failed to finish webhook delivery", "id", delivery.ID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// FailPendingDeliveries gives up on the queued deliveries of a webhook
+func (r *WebhookRepositoryImpl) FailPendingDeliveries(webhookID int, message string) error {
+	query, err := r.sqlLoader.Load(QueryFailPendingWebhookDeliveries)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	if _, err := r.db.Exec(query, message, time.Now().UTC(), webhookID); err != nil {
+		r.logger.Error("Failed to fail pending webhook deliveries", "webhook_id", webhookID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteFinishedDeliveriesBefore removes delivered and failed deliveries completed before the given time
+func (r *WebhookRepositoryImpl) DeleteFinishedDeliveriesBefore(before time.Time) (int64, error) {
+	query, err := r.sqlLoader.Load(QueryDeleteFinishedWebhookDeliveries)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return 0, err
+	}
+
+	result, err := r.db.Exec(query, before.UTC())
+	if err != nil {
+		r.logger.Error("Failed to delete finished webhook deliveries", "error", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// QueueCheck queues a check for the webhook worker. A check of the same
+// event and subject that is still queued is kept instead. A new check is due
+// right away; a retried one keeps its attempts and is due at NextAttemptAt.
+func (r *WebhookRepositoryImpl) QueueCheck(check *models.WebhookCheck) error {
+	query, err := r.sqlLoader.Load(QueryQueueWebhookCheck)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return err
+	}
+
+	if check.CreatedAt.IsZero() {
+		check.CreatedAt = time.Now().UTC()
+	}
+	if check.NextAttemptAt.IsZero() {
+		check.NextAttemptAt = check.CreatedAt
+	}
+	_, err = r.db.Exec(query, check.UserID, check.Event, check.Subject, check.Attempts, check.NextAttemptAt.UTC(), check.CreatedAt.UTC())
+	if err != nil {
+		r.logger.Error("Failed to queue webhook check", "user_id", check.UserID, "event", check.Event, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// TakeCheck removes the check that has been due the longest at now and returns
+// it, or nil when there is none. A check taken by one worker is not seen by the others.
+func (r *WebhookRepositoryImpl) TakeCheck(now time.Time) (*models.WebhookCheck, error) {
+	query, err := r.sqlLoader.Load(QueryTakeWebhookCheck)
+	if err != nil {
+		r.logger.Error("Failed to load query", "error", err)
+		return nil, err
+	}
+
+	var check models.WebhookCheck
+	err = r.db.QueryRow(query, now.UTC()).Scan(&check.ID, &check.UserID, &check.Event, &check.Subject,
+		&check.Attempts, &check.NextAttemptAt, &check.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		r.logger.Error("Failed to take webhook check", "error", err)
+		return nil, err
+	}
+
+	return &check, nil
+}
+
+// requireRow returns ErrNotFound when a statement changed no row
+func (r *WebhookRepositoryImpl) requireRow(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		r.logger.Error("Failed to get rows affected", "error", err)
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// scanWebhook scans a single webhook from a row
+func (r *WebhookRepositoryImpl) scanWebhook(row scanner) (*models.Webhook, error) {
+	var webhook models.Webhook
+	var events string
+	var disabledAt, lastSuccessAt, lastFailureAt sql.NullTime
+
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.UserID,
+		&webhook.URL,
+		&webhook.Secret,
+		&events,
+		&webhook.Enabled,
+		&webhook.FailureCount,
+		&disabledAt,
+		&lastSuccessAt,
+		&lastFailureAt,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Events = []string{}
+	if events != "" {
+		webhook.Events = strings.Split(events, ",")
+	}
+	if disabledAt.Valid {
+		webhook.DisabledAt = &disabledAt.Time
+	}
+	if lastSuccessAt.Valid {
+		webhook.LastSuccessAt = &lastSuccessAt.Time
+	}
+	if lastFailureAt.Valid {
+		webhook.LastFailureAt = &lastFailureAt.Time
+	}
+
+	return &webhook, nil
+}
+
+// scanDelivery scans a single delivery from a row
+func (r *WebhookRepositoryImpl) scanDelivery(row scanner) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var eventKey, errorMessage sql.NullString
+	var responseStatus sql.NullInt64
+	var completedAt sql.NullTime
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.UserID,
+		&delivery.Event,
+		&delivery.EventID,
+		&eventKey,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&responseStatus,
+		&errorMessage,
+		&delivery.CreatedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if eventKey.Valid {
+		delivery.EventKey = &eventKey.String
+	}
+	if responseStatus.Valid {
+		status := int(responseStatus.Int64)
+		delivery.ResponseStatus = &status
+	}
+	if errorMessage.Valid {
+		delivery.Error = &errorMessage.String
+	}
+	if completedAt.Valid {
+		delivery.CompletedAt = &completedAt.Time
+	}
+
+	return &delivery, nil
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"testing"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+func TestWebhookChecksAreTakenWhenDue(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewWebhookRepository(db, DialectSQLite, testLogger())
+	userID := newTestUser(t, db, "user@example.com")
+
+	retried := &models.WebhookCheck{UserID: userID, Event: models.WebhookDailyTargetExceeded, Subject: "2026-10-17",
+		Attempts: 3, NextAttemptAt: time.Now().Add(time.Minute)}
+	fresh := &models.WebhookCheck{UserID: userID, Event: models.WebhookDailyTargetExceeded, Subject: "2026-10-18"}
+	for _, check := range []*models.WebhookCheck{retried, fresh} {
+		if err := repo.QueueCheck(check); err != nil {
+			t.Fatalf("QueueCheck: %v", err)
+		}
+	}
+	now := time.Now()
+
+	// The retried check waits for its backoff, the new one is due right away
+	check, err := repo.TakeCheck(now)
+	if err != nil || check == nil || check.Subject != fresh.Subject || check.Attempts != 0 {
+		t.Fatalf("TakeCheck(now) = %+v, %v, want the new check", check, err)
+	}
+	if check, err := repo.TakeCheck(now); err != nil || check != nil {
+		t.Fatalf("TakeCheck(now) = %+v, %v, want nothing due", check, err)
+	}
+
+	check, err = repo.TakeCheck(now.Add(2 * time.Minute))
+	if err != nil || check == nil || check.Subject != retried.Subject || check.Attempts != 3 {
+		t.Fatalf("TakeCheck after the backoff = %+v, %v, want the retried check", check, err)
+	}
+	if check, err := repo.TakeCheck(now.Add(time.Hour)); err != nil || check != nil {
+		t.Errorf("TakeCheck = %+v, %v, want an empty queue", check, err)
+	}
+}
@@ -12,6 +12,7 @@ type WeightHistoryRepositoryImpl struct {
 	db        *sql.DB
 	logger    *slog.Logger
 	sqlLoader *SqlLoaderInstance
+	changes   *changeRecorder
 }
 
 func NewWeightHistoryRepository(db *sql.DB, logger *slog.Logger, dialect Dialect) *WeightHistoryRepositoryImpl {
@@ -21,6 +22,7 @@ func NewWeightHistoryRepository(db *sql.DB, logger *slog.Logger, dialect Dialect
 		db:        db,
 		logger:    logger,
 		sqlLoader: sqlLoader,
+		changes:   newChangeRecorder(sqlLoader),
 	}
 }
 
@@ -41,7 +43,7 @@ func (r *WeightHistoryRepositoryImpl) GetByUserID(userID int) ([]*models.WeightH
 	var history []*models.WeightHistory
 	for rows.Next() {
 		var entry models.WeightHistory
-		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Weight, &entry.RecordedAt, &entry.CreatedAt); err != nil {
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Weight, &entry.RecordedAt, &entry.CreatedAt, &entry.Version); err != nil {
 			return nil, err
 		}
 		history = append(history, &entry)
@@ -70,7 +72,7 @@ func (r *WeightHistoryRepositoryImpl) GetByUserIDAndDateRange(userID int, dateFr
 	var history []*models.WeightHistory
 	for rows.Next() {
 		var entry models.WeightHistory
-		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Weight, &entry.RecordedAt, &entry.CreatedAt); err != nil {
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Weight, &entry.RecordedAt, &entry.CreatedAt, &entry.Version); err != nil {
 			return nil, err
 		}
 		history = append(history, &entry)
@@ -88,7 +90,7 @@ func (r *WeightHistoryRepositoryImpl) GetLatestByUserID(userID int) (*models.Wei
 	}
 
 	var entry models.WeightHistory
-	err = r.db.QueryRow(query, userID).Scan(&entry.ID, &entry.UserID, &entry.Weight, &entry.RecordedAt, &entry.CreatedAt)
+	err = r.db.QueryRow(query, userID).Scan(&entry.ID, &entry.UserID, &entry.Weight, &entry.RecordedAt, &entry.CreatedAt, &entry.Version)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // No weight history yet
@@ -99,91 +101,164 @@ func (r *WeightHistoryRepositoryImpl) GetLatestByUserID(userID int) (*models.Wei
 	return &entry, nil
 }
 
+// GetByID retrieves a weigh-in of the user. Returns ErrNotFound if there is no such entry.
+func (r *WeightHistoryRepositoryImpl) GetByID(id, userID int) (*models.WeightHistory, error) {
+	r.logger.Debug("Getting weight history entry", slog.Int("id", id), slog.Int("user_id", userID))
+
+	query, err := r.sqlLoader.Load(QueryGetWeightHistoryByID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry models.WeightHistory
+	err = r.db.QueryRow(query, id, userID).Scan(&entry.ID, &entry.UserID, &entry.Weight, &entry.RecordedAt, &entry.CreatedAt, &entry.Version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
 func (r *WeightHistoryRepositoryImpl) Create(userID int, weight float64, recordedAt *time.Time) (*models.WeightHistory, error) {
 	r.logger.Debug("Creating weight history entry",
 		slog.Int("user_id", userID),
 		slog.Float64("weight", weight))
 
-	query, err := r.sqlLoader.Load(QueryCreateWeightHistory)
+	entry := &models.WeightHistory{
+		UserID:     userID,
+		Weight:     weight,
+		RecordedAt: time.Now(),
+		CreatedAt:  time.Now(),
+	}
+	if recordedAt != nil {
+		entry.RecordedAt = *recordedAt
+	}
+
+	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	var result sql.Result
-	if recordedAt != nil {
-		result, err = r.db.Exec(query, userID, weight, recordedAt)
-	} else {
-		result, err = r.db.Exec(query, userID, weight, time.Now())
+	if err := r.insertTx(tx, entry); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
 	}
 
+	return entry, nil
+}
+
+// insertTx inserts a weigh-in in the transaction and sets its ID
+func (r *WeightHistoryRepositoryImpl) insertTx(tx *sql.Tx, entry *models.WeightHistory) error {
+	query, err := r.sqlLoader.Load(QueryCreateWeightHistory)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	result, err := tx.Exec(query, entry.UserID, entry.Weight, entry.RecordedAt)
+	if err != nil {
+		return err
 	}
 
 	id, err := result.LastInsertId()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	entry.ID = int(id)
+	entry.Version = 1 // New rows start at the column default
 
-	return &models.WeightHistory{
-		ID:         int(id),
-		UserID:     userID,
-		Weight:     weight,
-		RecordedAt: time.Now(),
-		CreatedAt:  time.Now(),
-	}, nil
+	return r.changes.record(tx, entry.UserID, models.SyncEntityWeight, entry.ID, false)
 }
 
-func (r *WeightHistoryRepositoryImpl) Update(id, userID int, weight float64, recordedAt *time.Time) (*models.WeightHistory, error) {
+// Update overwrites a weigh-in of the user. A non-zero version makes the update
+// conditional: ErrVersionConflict is returned if the entry has another version.
+func (r *WeightHistoryRepositoryImpl) Update(id, userID int, weight float64, recordedAt *time.Time, version int) (*models.WeightHistory, error) {
 	r.logger.Debug("Updating weight history entry",
 		slog.Int("id", id),
 		slog.Int("user_id", userID),
 		slog.Float64("weight", weight))
 
-	query, err := r.sqlLoader.Load(QueryUpdateWeightHistory)
-	if err != nil {
-		return nil, err
+	entry := &models.WeightHistory{
+		ID:         id,
+		UserID:     userID,
+		Weight:     weight,
+		RecordedAt: time.Now(),
+		Version:    version,
 	}
-
-	var result sql.Result
 	if recordedAt != nil {
-		result, err = r.db.Exec(query, weight, recordedAt, id, userID)
-	} else {
-		result, err = r.db.Exec(query, weight, time.Now(), id, userID)
+		entry.RecordedAt = *recordedAt
 	}
 
+	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
+	if err := r.updateTx(tx, entry); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
 		return nil, err
 	}
 
-	if rowsAffected == 0 {
-		return nil, ErrNotFound
+	return entry, nil
+}
+
+// updateTx updates a weigh-in of the user in the transaction and sets its new
+// version. A non-zero entry.Version is the version the entry must have. Returns
+// ErrNotFound if there is no such entry and ErrVersionConflict if it has another version.
+func (r *WeightHistoryRepositoryImpl) updateTx(tx *sql.Tx, entry *models.WeightHistory) error {
+	query, err := r.sqlLoader.Load(QueryUpdateWeightHistory)
+	if err != nil {
+		return err
 	}
 
-	return &models.WeightHistory{
-		ID:         id,
-		UserID:     userID,
-		Weight:     weight,
-		RecordedAt: *recordedAt,
-	}, nil
+	err = tx.QueryRow(query, entry.Weight, entry.RecordedAt, entry.ID, entry.UserID, entry.Version, entry.Version).Scan(&entry.Version, &entry.CreatedAt)
+	if err == sql.ErrNoRows {
+		return r.missedWriteTx(tx, entry.ID, entry.UserID, entry.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	return r.changes.record(tx, entry.UserID, models.SyncEntityWeight, entry.ID, false)
 }
 
-func (r *WeightHistoryRepositoryImpl) Delete(id, userID int) error {
+// Delete moves a weigh-in of the user to the trash. A non-zero version makes the
+// delete conditional: ErrVersionConflict is returned if the entry has another version.
+func (r *WeightHistoryRepositoryImpl) Delete(id, userID, version int) error {
 	r.logger.Debug("Deleting weight history entry",
 		slog.Int("id", id),
 		slog.Int("user_id", userID))
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := r.deleteTx(tx, id, userID, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// deleteTx moves a weigh-in of the user to the trash in the transaction. A
+// non-zero version is the version the entry must have. Returns ErrNotFound if there is no such
+// entry and ErrVersionConflict if it has another version.
+func (r *WeightHistoryRepositoryImpl) deleteTx(tx *sql.Tx, id, userID, version int) error {
 	query, err := r.sqlLoader.Load(QueryDeleteWeightHistory)
 	if err != nil {
 		return err
 	}
 
-	result, err := r.db.Exec(query, id, userID)
+	result, err := tx.Exec(query, time.Now().UTC(), id, userID, version, version)
 	if err != nil {
 		return err
 	}
@@ -194,8 +269,114 @@ func (r *WeightHistoryRepositoryImpl) Delete(id, userID int) error {
 	}
 
 	if rowsAffected == 0 {
+		return r.missedWriteTx(tx, id, userID, version)
+	}
+
+	return r.changes.record(tx, userID, models.SyncEntityWeight, id, true)
+}
+
+// GetTrashed returns the user's weigh-ins in the trash, most recently deleted first
+func (r *WeightHistoryRepositoryImpl) GetTrashed(userID int) ([]*models.WeightHistory, error) {
+	r.logger.Debug("Getting trashed weight history", slog.Int("user_id", userID))
+
+	query, err := r.sqlLoader.Load(QueryGetTrashedWeightHistory)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*models.WeightHistory
+	for rows.Next() {
+		var entry models.WeightHistory
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Weight, &entry.RecordedAt, &entry.CreatedAt, &entry.Version, &entry.DeletedAt); err != nil {
+			return nil, err
+		}
+		history = append(history, &entry)
+	}
+
+	return history, rows.Err()
+}
+
+// Restore takes a weigh-in of the user out of the trash and gives it a new
+// version. Returns ErrNotFound if the entry is not in the trash.
+func (r *WeightHistoryRepositoryImpl) Restore(id, userID int) (*models.WeightHistory, error) {
+	r.logger.Debug("Restoring weight history entry",
+		slog.Int("id", id),
+		slog.Int("user_id", userID))
+
+	query, err := r.sqlLoader.Load(QueryRestoreWeightHistory)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+
+	if err := r.changes.record(tx, userID, models.SyncEntityWeight, id, false); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return r.GetByID(id, userID)
+}
+
+// PurgeTrashed permanently deletes the weigh-ins of all users that were moved to
+// the trash before the given time. Their tombstones were recorded when they were deleted.
+func (r *WeightHistoryRepositoryImpl) PurgeTrashed(before time.Time) (int64, error) {
+	query, err := r.sqlLoader.Load(QueryPurgeTrashedWeightHistory)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.Exec(query, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// missedWriteTx tells why a write of a weigh-in matched no row: ErrVersionConflict
+// if it was conditional and the entry exists, ErrNotFound otherwise
+func (r *WeightHistoryRepositoryImpl) missedWriteTx(tx *sql.Tx, id, userID, version int) error {
+	if version == 0 {
 		return ErrNotFound
 	}
 
-	return nil
+	query, err := r.sqlLoader.Load(QueryGetWeightHistoryVersion)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	err = tx.QueryRow(query, id, userID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return ErrVersionConflict
 }
\ No newline at end of file
@@ -1,39 +1,61 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"embed"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"ypeskov/kkal-tracker/internal/auth"
 	"ypeskov/kkal-tracker/internal/config"
+	accounthandler "ypeskov/kkal-tracker/internal/handlers/account"
+	adminhandler "ypeskov/kkal-tracker/internal/handlers/admin"
 	aihandler "ypeskov/kkal-tracker/internal/handlers/ai"
 	apidatahandler "ypeskov/kkal-tracker/internal/handlers/apidata"
 	apikeyhandler "ypeskov/kkal-tracker/internal/handlers/apikey"
 	authhandler "ypeskov/kkal-tracker/internal/handlers/auth"
+	batchhandler "ypeskov/kkal-tracker/internal/handlers/batch"
+	calendarhandler "ypeskov/kkal-tracker/internal/handlers/calendar"
 	"ypeskov/kkal-tracker/internal/handlers/calories"
+	changefeedhandler "ypeskov/kkal-tracker/internal/handlers/changefeed"
+	"ypeskov/kkal-tracker/internal/handlers/etag"
 	exporthandler "ypeskov/kkal-tracker/internal/handlers/export"
+	importhandler "ypeskov/kkal-tracker/internal/handlers/importer"
 	"ypeskov/kkal-tracker/internal/handlers/ingredients"
 	languageshandler "ypeskov/kkal-tracker/internal/handlers/languages"
 	metricshandler "ypeskov/kkal-tracker/internal/handlers/metrics"
 	"ypeskov/kkal-tracker/internal/handlers/profile"
 	reportshandler "ypeskov/kkal-tracker/internal/handlers/reports"
+	schedulehandler "ypeskov/kkal-tracker/internal/handlers/schedule"
 	"ypeskov/kkal-tracker/internal/handlers/static"
+	trashhandler "ypeskov/kkal-tracker/internal/handlers/trash"
 	weighthandler "ypeskov/kkal-tracker/internal/handlers/weight"
+	webhookhandler "ypeskov/kkal-tracker/internal/handlers/webhook"
 	"ypeskov/kkal-tracker/internal/middleware"
 	"ypeskov/kkal-tracker/internal/repositories"
+	accountservice "ypeskov/kkal-tracker/internal/services/account"
+	adminservice "ypeskov/kkal-tracker/internal/services/admin"
 	aiservice "ypeskov/kkal-tracker/internal/services/ai"
 	apikeyservice "ypeskov/kkal-tracker/internal/services/apikey"
 	authservice "ypeskov/kkal-tracker/internal/services/auth"
+	batchservice "ypeskov/kkal-tracker/internal/services/batch"
+	calendarservice "ypeskov/kkal-tracker/internal/services/calendar"
 	calorieservice "ypeskov/kkal-tracker/internal/services/calorie"
+	changefeedservice "ypeskov/kkal-tracker/internal/services/changefeed"
 	emailservice "ypeskov/kkal-tracker/internal/services/email"
 	exportservice "ypeskov/kkal-tracker/internal/services/export"
+	idempotencyservice "ypeskov/kkal-tracker/internal/services/idempotency"
+	importservice "ypeskov/kkal-tracker/internal/services/importer"
 	ingredientservice "ypeskov/kkal-tracker/internal/services/ingredient"
 	metricsservice "ypeskov/kkal-tracker/internal/services/metrics"
 	profileservice "ypeskov/kkal-tracker/internal/services/profile"
 	reportsservice "ypeskov/kkal-tracker/internal/services/reports"
+	scheduleservice "ypeskov/kkal-tracker/internal/services/schedule"
+	trashservice "ypeskov/kkal-tracker/internal/services/trash"
+	webhookservice "ypeskov/kkal-tracker/internal/services/webhook"
 	weightservice "ypeskov/kkal-tracker/internal/services/weight"
 
 	"github.com/labstack/echo/v4"
@@ -52,6 +74,18 @@ type Server struct {
 	ingredientRepo repositories.IngredientRepository
 	weightRepo     repositories.WeightHistoryRepository
 	apiKeyRepo     repositories.APIKeyRepository
+	mealPlanRepo   repositories.MealPlanRepository
+	aiCacheRepo    repositories.AIAnalysisCacheRepository
+	auditRepo      repositories.AuditLogRepository
+	exportJobRepo  repositories.ExportJobRepository
+	scheduleRepo   repositories.ExportScheduleRepository
+	calendarRepo   repositories.CalendarFeedRepository
+	webhookRepo    repositories.WebhookRepository
+	syncRepo       repositories.SyncChangeRepository
+	batchRepo      repositories.BatchRepository
+	importRepo     repositories.ImportRepository
+	idemKeyRepo    repositories.IdempotencyKeyRepository
+	aiPrompts      *aiservice.PromptSet
 }
 
 // setupRepositories configures repositories based on the database type
@@ -64,6 +98,17 @@ func (s *Server) setupRepositories() error {
 		s.ingredientRepo = repositories.NewIngredientRepository(s.db, s.logger, repositories.DialectSQLite)
 		s.weightRepo = repositories.NewWeightHistoryRepository(s.db, s.logger, repositories.DialectSQLite)
 		s.apiKeyRepo = repositories.NewAPIKeyRepository(s.db, repositories.DialectSQLite, s.logger)
+		s.mealPlanRepo = repositories.NewMealPlanRepository(s.db, repositories.DialectSQLite, s.logger)
+		s.aiCacheRepo = repositories.NewAIAnalysisCacheRepository(s.db, repositories.DialectSQLite, s.logger)
+		s.auditRepo = repositories.NewAuditLogRepository(s.db, repositories.DialectSQLite, s.logger)
+		s.exportJobRepo = repositories.NewExportJobRepository(s.db, repositories.DialectSQLite, s.logger)
+		s.scheduleRepo = repositories.NewExportScheduleRepository(s.db, repositories.DialectSQLite, s.logger)
+		s.calendarRepo = repositories.NewCalendarFeedRepository(s.db, repositories.DialectSQLite, s.logger)
+		s.webhookRepo = repositories.NewWebhookRepository(s.db, repositories.DialectSQLite, s.logger)
+		s.syncRepo = repositories.NewSyncChangeRepository(s.db, repositories.DialectSQLite, s.logger)
+		s.batchRepo = repositories.NewBatchRepository(s.db, repositories.DialectSQLite, s.logger)
+		s.importRepo = repositories.NewImportRepository(s.db, repositories.DialectSQLite, s.logger)
+		s.idemKeyRepo = repositories.NewIdempotencyKeyRepository(s.db, repositories.DialectSQLite, s.logger)
 		s.logger.Debug("Configured SQLite repositories")
 	case "postgres":
 		s.userRepo = repositories.NewUserRepository(s.db, s.logger, repositories.DialectPostgres)
@@ -72,6 +117,17 @@ func (s *Server) setupRepositories() error {
 		s.ingredientRepo = repositories.NewIngredientRepository(s.db, s.logger, repositories.DialectPostgres)
 		s.weightRepo = repositories.NewWeightHistoryRepository(s.db, s.logger, repositories.DialectPostgres)
 		s.apiKeyRepo = repositories.NewAPIKeyRepository(s.db, repositories.DialectPostgres, s.logger)
+		s.mealPlanRepo = repositories.NewMealPlanRepository(s.db, repositories.DialectPostgres, s.logger)
+		s.aiCacheRepo = repositories.NewAIAnalysisCacheRepository(s.db, repositories.DialectPostgres, s.logger)
+		s.auditRepo = repositories.NewAuditLogRepository(s.db, repositories.DialectPostgres, s.logger)
+		s.exportJobRepo = repositories.NewExportJobRepository(s.db, repositories.DialectPostgres, s.logger)
+		s.scheduleRepo = repositories.NewExportScheduleRepository(s.db, repositories.DialectPostgres, s.logger)
+		s.calendarRepo = repositories.NewCalendarFeedRepository(s.db, repositories.DialectPostgres, s.logger)
+		s.webhookRepo = repositories.NewWebhookRepository(s.db, repositories.DialectPostgres, s.logger)
+		s.syncRepo = repositories.NewSyncChangeRepository(s.db, repositories.DialectPostgres, s.logger)
+		s.batchRepo = repositories.NewBatchRepository(s.db, repositories.DialectPostgres, s.logger)
+		s.importRepo = repositories.NewImportRepository(s.db, repositories.DialectPostgres, s.logger)
+		s.idemKeyRepo = repositories.NewIdempotencyKeyRepository(s.db, repositories.DialectPostgres, s.logger)
 		s.logger.Debug("Configured PostgreSQL repositories")
 	default:
 		return fmt.Errorf("unsupported database type: %s", s.config.DatabaseType)
@@ -94,6 +150,13 @@ func New(cfg *config.Config, logger *slog.Logger, db *sql.DB, staticFiles embed.
 		return nil, fmt.Errorf("failed to setup repositories: %w", err)
 	}
 
+	// Load and validate AI prompt templates up front so a broken override fails the startup
+	prompts, err := aiservice.LoadPrompts(cfg.AI.PromptsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AI prompts: %w", err)
+	}
+	server.aiPrompts = prompts
+
 	return server, nil
 }
 
@@ -108,9 +171,10 @@ func (s *Server) Start() *http.Server {
 	//e.Use(middleware.Logger(s.logger))
 	e.Use(echomiddleware.Recover())
 	e.Use(echomiddleware.CORSWithConfig(echomiddleware.CORSConfig{
-		AllowOrigins: []string{s.config.AppURL},
-		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAuthorization, "X-API-Key"},
+		AllowOrigins:  []string{s.config.AppURL},
+		AllowMethods:  []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodOptions},
+		AllowHeaders:  []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAuthorization, "X-API-Key", middleware.IdempotencyKeyHeader, etag.HeaderIfMatch},
+		ExposeHeaders: []string{etag.HeaderETag},
 	}))
 	e.Use(echomiddleware.SecureWithConfig(echomiddleware.SecureConfig{
 		XSSProtection:      "1; mode=block",
@@ -127,15 +191,31 @@ func (s *Server) Start() *http.Server {
 
 	// Initialize auth service with all dependencies
 	authService := authservice.New(s.userRepo, s.tokenRepo, jwtService, emailService, s.logger)
-	calorieService := calorieservice.New(s.calorieRepo, s.ingredientRepo, s.logger)
-	ingredientService := ingredientservice.New(s.ingredientRepo, s.logger)
-	profileService := profileservice.New(s.db, s.userRepo, s.weightRepo, s.logger)
-	weightService := weightservice.New(s.weightRepo, s.logger)
 	metricsService := metricsservice.New(s.userRepo, s.weightRepo, s.logger)
+	webhookSvc := webhookservice.New(s.webhookRepo, s.userRepo, s.weightRepo, s.calorieRepo, metricsService, s.config.WebhookAllowPrivateNetworks, s.logger)
+	calorieService := calorieservice.New(s.calorieRepo, s.ingredientRepo, webhookSvc, s.logger)
+	ingredientService := ingredientservice.New(s.ingredientRepo, s.calorieRepo, s.logger)
+	profileService := profileservice.New(s.db, s.userRepo, s.weightRepo, ingredientService, s.logger)
+	weightService := weightservice.New(s.weightRepo, webhookSvc, s.logger)
 	reportsService := reportsservice.New(calorieService, weightService, s.logger)
-	aiSvc := aiservice.New(s.config, s.logger)
-	exportSvc := exportservice.New(calorieService, weightService, emailService, s.logger)
+	batchSvc := batchservice.New(s.batchRepo, calorieService, webhookSvc, s.logger)
+	aiSvc := aiservice.New(s.config, s.aiPrompts, s.mealPlanRepo, s.aiCacheRepo, s.ingredientRepo, calorieService, batchSvc, metricsService, s.logger)
+	exportJobTTL := time.Duration(s.config.ExportJobTTLHours) * time.Hour
+	exportSvc := exportservice.New(calorieService, weightService, profileService, metricsService, s.exportJobRepo, emailService, exportJobTTL, s.logger)
+	scheduleSvc := scheduleservice.New(s.scheduleRepo, s.userRepo, exportSvc, calorieService, metricsService, emailService, s.config.AppURL, s.logger)
+	calendarSvc := calendarservice.New(s.calendarRepo, s.userRepo, calorieService, weightService, s.config.AppURL, s.logger)
+	accountGracePeriod := time.Duration(s.config.AccountDeletionGraceDays) * 24 * time.Hour
+	accountSvc := accountservice.New(s.userRepo, s.weightRepo, s.calorieRepo, s.ingredientRepo, s.apiKeyRepo, s.mealPlanRepo, s.scheduleRepo, accountGracePeriod, s.logger)
+	tombstoneRetention := time.Duration(s.config.SyncTombstoneRetentionDays) * 24 * time.Hour
+	changefeedSvc := changefeedservice.New(s.syncRepo, tombstoneRetention, s.logger)
+	idempotencyKeyTTL := time.Duration(s.config.IdempotencyKeyTTLHours) * time.Hour
+	idempotencySvc := idempotencyservice.New(s.idemKeyRepo, idempotencyKeyTTL, s.logger)
+	trashRetention := time.Duration(s.config.TrashRetentionDays) * 24 * time.Hour
+	undoWindow := time.Duration(s.config.UndoWindowSeconds) * time.Second
+	trashSvc := trashservice.New(s.calorieRepo, s.weightRepo, trashRetention, undoWindow, s.logger)
+	importSvc := importservice.New(s.calorieRepo, s.importRepo, s.ingredientRepo, s.weightRepo, s.logger)
 	apiKeySvc := apikeyservice.New(s.apiKeyRepo, s.logger)
+	adminSvc := adminservice.New(s.userRepo, s.ingredientRepo, s.auditRepo, ingredientService, s.logger)
 
 	authHandler := authhandler.NewHandler(authService, s.logger)
 	calorieHandler := calories.New(calorieService, s.logger)
@@ -146,9 +226,20 @@ func (s *Server) Start() *http.Server {
 	reportsHandler := reportshandler.New(reportsService, s.logger)
 	aiHandler := aihandler.New(aiSvc, calorieService, weightService, s.userRepo, s.logger)
 	exportHandler := exporthandler.New(exportSvc, s.userRepo, s.logger)
+	scheduleHandler := schedulehandler.New(scheduleSvc, s.userRepo, s.logger)
+	accountHandler := accounthandler.New(accountSvc, s.logger)
+	calendarHandler := calendarhandler.New(calendarSvc, s.logger)
+	webhookHandler := webhookhandler.New(webhookSvc, s.logger)
+	changefeedHandler := changefeedhandler.New(changefeedSvc, s.logger)
+	batchHandler := batchhandler.New(batchSvc, s.logger)
+	trashHandler := trashhandler.New(trashSvc, s.logger)
+	importHandler := importhandler.New(importSvc, s.logger)
 	apiKeyHandler := apikeyhandler.New(apiKeySvc, s.logger)
 	apiKeyMiddleware := middleware.NewAPIKeyMiddleware(apiKeySvc, s.logger)
 	apiDataHandler := apidatahandler.New(calorieService, weightService, s.logger)
+	adminHandler := adminhandler.New(adminSvc, s.logger)
+	adminMiddleware := middleware.NewAdminMiddleware(adminSvc, s.logger)
+	idempotencyMiddleware := middleware.NewIdempotencyMiddleware(idempotencySvc, s.logger)
 
 	apiGroup := e.Group("/api")
 
@@ -164,46 +255,101 @@ func (s *Server) Start() *http.Server {
 	authGroup := apiGroup.Group("/auth", authRateLimiter)
 	authHandler.RegisterRoutes(authGroup, authMiddleware)
 
-	caloriesGroup := apiGroup.Group("/calories", authMiddleware.RequireAuth)
+	caloriesGroup := apiGroup.Group("/calories", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
 	calorieHandler.RegisterRoutes(caloriesGroup)
 
-	ingredientsGroup := apiGroup.Group("/ingredients", authMiddleware.RequireAuth)
+	ingredientsGroup := apiGroup.Group("/ingredients", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
 	ingredientHandler.RegisterRoutes(ingredientsGroup)
 
 	// Profile routes require authentication
-	profileGroup := apiGroup.Group("", authMiddleware.RequireAuth)
+	profileGroup := apiGroup.Group("", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
 	profileHandler.RegisterRoutes(profileGroup)
 
 	// Weight history routes require authentication
-	weightGroup := apiGroup.Group("/weight", authMiddleware.RequireAuth)
+	weightGroup := apiGroup.Group("/weight", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
 	weightHandler.RegisterRoutes(weightGroup)
 
+	// Trash routes require authentication
+	trashGroup := apiGroup.Group("/trash", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
+	trashHandler.RegisterRoutes(trashGroup)
+
 	// Health metrics routes require authentication
-	metricsGroup := apiGroup.Group("", authMiddleware.RequireAuth)
+	metricsGroup := apiGroup.Group("", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
 	metricsHandler.RegisterRoutes(metricsGroup)
 
 	// Reports routes require authentication
-	reportsGroup := apiGroup.Group("/reports", authMiddleware.RequireAuth)
+	reportsGroup := apiGroup.Group("/reports", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
 	reportsHandler.RegisterRoutes(reportsGroup)
 
 	// AI routes require authentication and strict rate limiting
 	// Rate: 2 requests per minute (1 request every 30 seconds) to control AI costs
 	aiRateLimiter := echomiddleware.RateLimiter(echomiddleware.NewRateLimiterMemoryStore(2.0/60.0))
-	aiGroup := apiGroup.Group("/ai", authMiddleware.RequireAuth, aiRateLimiter)
+	aiGroup := apiGroup.Group("/ai", authMiddleware.RequireAuth, idempotencyMiddleware.Handle, aiRateLimiter)
 	aiHandler.RegisterRoutes(aiGroup)
 
+	// Saved meal plans are not rate limited, only their generation is
+	mealPlansGroup := apiGroup.Group("/meal-plans", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
+	aiHandler.RegisterMealPlanRoutes(mealPlansGroup)
+
 	// Export routes require authentication
-	exportGroup := apiGroup.Group("/export", authMiddleware.RequireAuth)
+	exportGroup := apiGroup.Group("/export", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
 	exportHandler.RegisterRoutes(exportGroup)
 
+	// Export schedule routes require authentication
+	schedulesGroup := apiGroup.Group("/schedules", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
+	scheduleHandler.RegisterRoutes(schedulesGroup)
+
+	// Unsubscribe links in scheduled emails work without logging in; the token identifies the schedule
+	unsubscribeGroup := apiGroup.Group("/unsubscribe", authRateLimiter)
+	scheduleHandler.RegisterUnsubscribeRoutes(unsubscribeGroup)
+
+	// Calendar feed management routes require authentication
+	calendarGroup := apiGroup.Group("/calendar", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
+	calendarHandler.RegisterRoutes(calendarGroup)
+
+	// Calendar apps fetch the feed without logging in; the token in the URL identifies it
+	icalGroup := apiGroup.Group("/ical", authRateLimiter)
+	calendarHandler.RegisterFeedRoutes(icalGroup)
+
+	// Webhook routes require authentication
+	webhooksGroup := apiGroup.Group("/webhooks", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
+	webhookHandler.RegisterRoutes(webhooksGroup)
+
+	// Change feed and batch writes for offline clients require authentication
+	syncGroup := apiGroup.Group("/sync", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
+	changefeedHandler.RegisterRoutes(syncGroup)
+	batchHandler.RegisterRoutes(syncGroup)
+
+	// Account archive and deletion routes require authentication
+	accountGroup := apiGroup.Group("/account", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
+	accountHandler.RegisterRoutes(accountGroup)
+
+	// Import routes require authentication
+	importGroup := apiGroup.Group("/import", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
+	importHandler.RegisterRoutes(importGroup)
+
 	// API key management routes (JWT auth - user manages their keys)
-	apiKeysGroup := apiGroup.Group("/api-keys", authMiddleware.RequireAuth)
+	apiKeysGroup := apiGroup.Group("/api-keys", authMiddleware.RequireAuth, idempotencyMiddleware.Handle)
 	apiKeyHandler.RegisterRoutes(apiKeysGroup)
 
 	// External data API (API key auth, rate limited: 60 req/min)
 	v1RateLimiter := echomiddleware.RateLimiter(echomiddleware.NewRateLimiterMemoryStore(1))
 	v1Group := apiGroup.Group("/v1", apiKeyMiddleware.RequireAPIKey, v1RateLimiter)
 	apiDataHandler.RegisterRoutes(v1Group)
+	changefeedHandler.RegisterRoutes(v1Group)
+
+	// Admin routes require authentication and the admin role
+	adminGroup := apiGroup.Group("/admin", authMiddleware.RequireAuth, adminMiddleware.RequireAdmin, idempotencyMiddleware.Handle)
+	adminHandler.RegisterRoutes(adminGroup)
+
+	// Queued exports, email delivery, scheduled emails, account and trash purges, webhook deliveries, tombstone and idempotency key cleanup run in the background for the lifetime of the process
+	go exportSvc.RunWorker(context.Background())
+	go scheduleSvc.Run(context.Background())
+	go accountSvc.RunPurge(context.Background())
+	go webhookSvc.RunWorker(context.Background())
+	go changefeedSvc.RunCleanup(context.Background())
+	go idempotencySvc.RunCleanup(context.Background())
+	go trashSvc.RunPurge(context.Background())
 
 	staticHandler := static.New(s.staticFiles, s.logger)
 	staticHandler.RegisterRoutes(e)
@@ -0,0 +1,8 @@
+package account
+
+import "errors"
+
+var (
+	ErrInvalidPassword      = errors.New("invalid password")
+	ErrDeletionNotRequested = errors.New("account deletion has not been requested")
+)
@@ -0,0 +1,11 @@
+package account
+
+import "time"
+
+// Servicer defines the account service contract used by handlers.
+type Servicer interface {
+	Archive(userID int) (*Archive, error)
+	DeletionScheduledAt(userID int) (*time.Time, error)
+	RequestDeletion(userID int, password string) (time.Time, error)
+	CancelDeletion(userID int) error
+}
@@ -0,0 +1,265 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// Purge job settings
+const (
+	purgeInterval  = time.Hour
+	purgeBatchSize = 50
+)
+
+// Service handles the account archive and account deletion
+type Service struct {
+	userRepo       repositories.UserRepository
+	weightRepo     repositories.WeightHistoryRepository
+	calorieRepo    repositories.CalorieEntryRepository
+	ingredientRepo repositories.IngredientRepository
+	apiKeyRepo     repositories.APIKeyRepository
+	mealPlanRepo   repositories.MealPlanRepository
+	scheduleRepo   repositories.ExportScheduleRepository
+	gracePeriod    time.Duration // Time between a deletion request and the purge
+	logger         *slog.Logger
+}
+
+// New creates a new account service
+func New(
+	userRepo repositories.UserRepository,
+	weightRepo repositories.WeightHistoryRepository,
+	calorieRepo repositories.CalorieEntryRepository,
+	ingredientRepo repositories.IngredientRepository,
+	apiKeyRepo repositories.APIKeyRepository,
+	mealPlanRepo repositories.MealPlanRepository,
+	scheduleRepo repositories.ExportScheduleRepository,
+	gracePeriod time.Duration,
+	logger *slog.Logger,
+) *Service {
+	return &Service{
+		userRepo:       userRepo,
+		weightRepo:     weightRepo,
+		calorieRepo:    calorieRepo,
+		ingredientRepo: ingredientRepo,
+		apiKeyRepo:     apiKeyRepo,
+		mealPlanRepo:   mealPlanRepo,
+		scheduleRepo:   scheduleRepo,
+		gracePeriod:    gracePeriod,
+		logger:         logger.With("service", "account"),
+	}
+}
+
+// Archive collects all of the user's data into a ZIP file with one JSON file per kind of data
+func (s *Service) Archive(userID int) (*Archive, error) {
+	s.logger.Debug("Archive called", "user_id", userID)
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	var goal *archiveGoal
+	if user.TargetWeight != nil {
+		goal = &archiveGoal{
+			TargetWeight:        *user.TargetWeight,
+			TargetDate:          user.TargetDate,
+			GoalSetAt:           user.GoalSetAt,
+			InitialWeightAtGoal: user.InitialWeightAtGoal,
+		}
+	}
+
+	weights, err := s.weightRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weight history: %w", err)
+	}
+	entries, err := s.calorieRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calorie entries: %w", err)
+	}
+	ingredients, err := s.ingredientRepo.GetAllUserIngredients(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ingredients: %w", err)
+	}
+	apiKeys, err := s.apiKeyRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API keys: %w", err)
+	}
+	schedules, err := s.scheduleRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedules: %w", err)
+	}
+
+	// The list of plans leaves out the items, so each plan is loaded in full
+	plans, err := s.mealPlanRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meal plans: %w", err)
+	}
+	for i, plan := range plans {
+		full, err := s.mealPlanRepo.GetByID(plan.ID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get meal plan: %w", err)
+		}
+		plans[i] = full
+	}
+
+	files := []archiveFile{
+		{name: "profile.json", records: 1, data: newProfileData(user)},
+		{name: "goal.json", records: boolToInt(goal != nil), data: goal},
+		newArchiveFile("weight_history.json", weights),
+		newArchiveFile("calorie_entries.json", entries),
+		newArchiveFile("ingredients.json", ingredients),
+		newArchiveFile("api_keys.json", apiKeys),
+		newArchiveFile("meal_plans.json", plans),
+		newArchiveFile("export_schedules.json", schedules),
+	}
+
+	now := time.Now().UTC()
+	manifest := archiveManifest{CreatedAt: now, UserID: user.ID, Email: user.Email, Files: make(map[string]int)}
+	for _, file := range files {
+		manifest.Files[file.name] = file.records
+	}
+
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+	for _, file := range append([]archiveFile{{name: "manifest.json", data: manifest}}, files...) {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s to archive: %w", file.name, err)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	s.logger.Info("Account archive created", "user_id", userID, "size_bytes", buffer.Len())
+	return &Archive{
+		FileBytes: buffer.Bytes(),
+		FileName:  fmt.Sprintf("kkal-account-%s.zip", now.Format("2006-01-02")),
+	}, nil
+}
+
+// DeletionScheduledAt returns when the user's account will be purged, or nil if
+// deletion has not been requested
+func (s *Service) DeletionScheduledAt(userID int) (*time.Time, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user.DeletionScheduledAt, nil
+}
+
+// RequestDeletion schedules the user's account to be purged after the grace
+// period. The password is checked again, as the session alone should not be
+// enough to delete an account. Asking again keeps the original date.
+func (s *Service) RequestDeletion(userID int, password string) (time.Time, error) {
+	s.logger.Debug("RequestDeletion called", "user_id", userID)
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.CheckPassword(password) {
+		return time.Time{}, ErrInvalidPassword
+	}
+	if user.DeletionScheduledAt != nil {
+		return *user.DeletionScheduledAt, nil
+	}
+
+	purgeAt := time.Now().Add(s.gracePeriod).UTC()
+	if err := s.userRepo.ScheduleDeletion(userID, purgeAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to schedule deletion: %w", err)
+	}
+
+	s.logger.Info("Account deletion requested", "user_id", userID, "purge_at", purgeAt)
+	return purgeAt, nil
+}
+
+// CancelDeletion keeps an account whose deletion was requested
+func (s *Service) CancelDeletion(userID int) error {
+	s.logger.Debug("CancelDeletion called", "user_id", userID)
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.DeletionScheduledAt == nil {
+		return ErrDeletionNotRequested
+	}
+
+	if err := s.userRepo.CancelDeletion(userID); err != nil {
+		return fmt.Errorf("failed to cancel deletion: %w", err)
+	}
+
+	s.logger.Info("Account deletion cancelled", "user_id", userID)
+	return nil
+}
+
+// RunPurge deletes accounts whose grace period has ended until ctx is
+// cancelled. Running it on several instances is safe: an account that another
+// instance already deleted is skipped.
+func (s *Service) RunPurge(ctx context.Context) {
+	s.logger.Info("Account purge job started")
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		s.purgeDue()
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Account purge job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDue deletes all accounts that are due
+func (s *Service) purgeDue() {
+	for {
+		ids, err := s.userRepo.GetDueForDeletion(time.Now(), purgeBatchSize)
+		if err != nil {
+			s.logger.Error("Failed to get accounts due for deletion", "error", err)
+			return
+		}
+
+		purged := 0
+		for _, id := range ids {
+			if err := s.userRepo.Delete(id); err != nil {
+				if !errors.Is(err, repositories.ErrNotFound) {
+					s.logger.Error("Failed to purge account", "user_id", id, "error", err)
+				}
+				continue
+			}
+			purged++
+			s.logger.Info("Account purged", "user_id", id)
+		}
+
+		// A short or failing batch means there is nothing more to do for now
+		if len(ids) < purgeBatchSize || purged == 0 {
+			return
+		}
+	}
+}
+
+// boolToInt returns 1 for true and 0 for false
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
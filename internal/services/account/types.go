@@ -0,0 +1,78 @@
+package account
+
+import (
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// Archive is a ZIP file with all of a user's data
+type Archive struct {
+	FileBytes []byte
+	FileName  string
+}
+
+// archiveGoal is the weight goal as written to goal.json
+type archiveGoal struct {
+	TargetWeight        float64    `json:"target_weight"`
+	TargetDate          *time.Time `json:"target_date,omitempty"`
+	GoalSetAt           *time.Time `json:"goal_set_at,omitempty"`
+	InitialWeightAtGoal *float64   `json:"initial_weight_at_goal,omitempty"`
+}
+
+// archiveManifest describes the archive in manifest.json
+type archiveManifest struct {
+	CreatedAt time.Time      `json:"created_at"`
+	UserID    int            `json:"user_id"`
+	Email     string         `json:"email"`
+	Files     map[string]int `json:"files"` // Number of records per file
+}
+
+// archiveFile is one JSON file of the archive
+type archiveFile struct {
+	name    string
+	records int
+	data    any
+}
+
+// newArchiveFile creates an archive file for a list of records
+func newArchiveFile[T any](name string, records []T) archiveFile {
+	if records == nil {
+		records = []T{}
+	}
+	return archiveFile{name: name, records: len(records), data: records}
+}
+
+// profileData is the user profile as written to profile.json, without the goal
+type profileData struct {
+	ID            int       `json:"id"`
+	Email         string    `json:"email"`
+	Role          string    `json:"role"`
+	FirstName     *string   `json:"first_name,omitempty"`
+	LastName      *string   `json:"last_name,omitempty"`
+	Age           *int      `json:"age,omitempty"`
+	Height        *float64  `json:"height,omitempty"`
+	Gender        *string   `json:"gender,omitempty"`
+	Language      *string   `json:"language,omitempty"`
+	ActivityLevel *string   `json:"activity_level,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// newProfileData copies the profile fields of a user
+func newProfileData(user *models.User) profileData {
+	return profileData{
+		ID:            user.ID,
+		Email:         user.Email,
+		Role:          user.Role,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Age:           user.Age,
+		Height:        user.Height,
+		Gender:        user.Gender,
+		Language:      user.Language,
+		ActivityLevel: user.ActivityLevel,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
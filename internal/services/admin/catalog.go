@@ -0,0 +1,361 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"ypeskov/kkal-tracker/internal/config"
+	"ypeskov/kkal-tracker/internal/models"
+	ingredientservice "ypeskov/kkal-tracker/internal/services/ingredient"
+)
+
+// ListGlobalIngredients returns a page of the global catalog. With a query, names are
+// matched the same way as user ingredient search and prefix matches come first.
+func (s *Service) ListGlobalIngredients(filter GlobalIngredientFilter) (*GlobalIngredientPage, error) {
+	s.logger.Debug("ListGlobalIngredients called", "query", filter.Query, "language", filter.LanguageCode, "limit", filter.Limit, "offset", filter.Offset)
+
+	if filter.LanguageCode != "" {
+		if _, ok := config.SupportedLanguages[filter.LanguageCode]; !ok {
+			return nil, fmt.Errorf("%w: unsupported language %q", ErrInvalidIngredient, filter.LanguageCode)
+		}
+	}
+
+	ingredients, err := s.ingredientRepo.GetAllGlobalIngredients()
+	if err != nil {
+		s.logger.Error("Failed to get global ingredients", "error", err)
+		return nil, err
+	}
+
+	query := ingredientservice.NormalizeName(filter.Query)
+	if query != "" {
+		ingredients = filterByName(ingredients, query, filter.LanguageCode)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+
+	start := min(max(filter.Offset, 0), len(ingredients))
+	end := min(start+limit, len(ingredients))
+
+	page := &GlobalIngredientPage{
+		Items: ingredients[start:end],
+		Total: len(ingredients),
+	}
+	if page.Items == nil {
+		page.Items = []*models.GlobalIngredient{}
+	}
+
+	s.logger.Debug("ListGlobalIngredients completed successfully", "total", page.Total, "returned", len(page.Items))
+	return page, nil
+}
+
+// filterByName keeps ingredients whose name contains the normalized query,
+// ordering prefix matches before other matches and keeping ID order otherwise
+func filterByName(ingredients []*models.GlobalIngredient, query, languageCode string) []*models.GlobalIngredient {
+	type match struct {
+		ingredient *models.GlobalIngredient
+		prefix     bool
+	}
+
+	var matches []match
+	for _, ingredient := range ingredients {
+		found, prefix := false, false
+		for lang, name := range ingredient.Names {
+			if languageCode != "" && lang != languageCode {
+				continue
+			}
+			normalized := ingredientservice.NormalizeName(name)
+			if strings.HasPrefix(normalized, query) {
+				found, prefix = true, true
+				break
+			}
+			if strings.Contains(normalized, query) {
+				found = true
+			}
+		}
+		if found {
+			matches = append(matches, match{ingredient: ingredient, prefix: prefix})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].prefix && !matches[j].prefix
+	})
+
+	result := make([]*models.GlobalIngredient, 0, len(matches))
+	for _, m := range matches {
+		result = append(result, m.ingredient)
+	}
+	return result
+}
+
+// GetGlobalIngredient returns a single global ingredient with its names
+func (s *Service) GetGlobalIngredient(id int) (*models.GlobalIngredient, error) {
+	s.logger.Debug("GetGlobalIngredient called", "ingredient_id", id)
+
+	ingredient, err := s.ingredientRepo.GetGlobalIngredientByID(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrIngredientNotFound
+		}
+		s.logger.Error("Failed to get global ingredient", "error", err, "ingredient_id", id)
+		return nil, err
+	}
+
+	return ingredient, nil
+}
+
+// CreateGlobalIngredient adds an ingredient to the global catalog
+func (s *Service) CreateGlobalIngredient(actorID int, input *GlobalIngredientInput) (*models.GlobalIngredient, error) {
+	s.logger.Debug("CreateGlobalIngredient called", "actor_user_id", actorID)
+
+	if err := validateInput(input); err != nil {
+		s.logger.Debug("CreateGlobalIngredient failed - validation error", "actor_user_id", actorID, "error", err)
+		return nil, err
+	}
+	if err := s.checkBarcode(0, input.Barcode); err != nil {
+		return nil, err
+	}
+
+	ingredient, err := s.ingredientRepo.CreateGlobalIngredient(input.KcalPer100g, input.Fats, input.Carbs, input.Proteins, input.Nutrients, nonEmpty(input.Barcode), input.Names,
+		s.audit(actorID, AuditActionCreate, EntityGlobalIngredient, nil))
+	if err != nil {
+		s.logger.Error("Failed to create global ingredient", "error", err, "actor_user_id", actorID)
+		return nil, err
+	}
+
+	s.logger.Info("Global ingredient created", "actor_user_id", actorID, "ingredient_id", ingredient.ID)
+	return ingredient, nil
+}
+
+// UpdateGlobalIngredient replaces nutrition values and names of a global ingredient
+func (s *Service) UpdateGlobalIngredient(actorID, id int, input *GlobalIngredientInput) (*models.GlobalIngredient, error) {
+	s.logger.Debug("UpdateGlobalIngredient called", "actor_user_id", actorID, "ingredient_id", id)
+
+	if err := validateInput(input); err != nil {
+		s.logger.Debug("UpdateGlobalIngredient failed - validation error", "actor_user_id", actorID, "ingredient_id", id, "error", err)
+		return nil, err
+	}
+
+	before, err := s.GetGlobalIngredient(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a barcode in the input the stored one is kept
+	barcode := before.Barcode
+	if input.Barcode != nil {
+		if err := s.checkBarcode(id, input.Barcode); err != nil {
+			return nil, err
+		}
+		barcode = nonEmpty(input.Barcode)
+	}
+
+	ingredient, err := s.ingredientRepo.UpdateGlobalIngredient(id, input.KcalPer100g, input.Fats, input.Carbs, input.Proteins, input.Nutrients, barcode, input.Names,
+		s.audit(actorID, AuditActionUpdate, EntityGlobalIngredient, before))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrIngredientNotFound
+		}
+		s.logger.Error("Failed to update global ingredient", "error", err, "ingredient_id", id)
+		return nil, err
+	}
+
+	// User copies are updated best effort; users can still sync or review the change later
+	if _, err := s.ingredientService.PropagateGlobalIngredient(id); err != nil {
+		s.logger.Error("Failed to propagate global ingredient update", "error", err, "ingredient_id", id)
+	}
+
+	s.logger.Info("Global ingredient updated", "actor_user_id", actorID, "ingredient_id", id)
+	return ingredient, nil
+}
+
+// DeleteGlobalIngredient removes an ingredient from the global catalog.
+// Copies already in users' personal lists are kept.
+func (s *Service) DeleteGlobalIngredient(actorID, id int) error {
+	s.logger.Debug("DeleteGlobalIngredient called", "actor_user_id", actorID, "ingredient_id", id)
+
+	before, err := s.GetGlobalIngredient(id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ingredientRepo.DeleteGlobalIngredient(id, s.audit(actorID, AuditActionDelete, EntityGlobalIngredient, before)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrIngredientNotFound
+		}
+		s.logger.Error("Failed to delete global ingredient", "error", err, "ingredient_id", id)
+		return err
+	}
+
+	s.logger.Info("Global ingredient deleted", "actor_user_id", actorID, "ingredient_id", id)
+	return nil
+}
+
+// ImportGlobalIngredients adds many ingredients at once. All items are validated
+// first and nothing is stored unless every item is valid.
+func (s *Service) ImportGlobalIngredients(actorID int, inputs []*GlobalIngredientInput) (*ImportResult, error) {
+	s.logger.Debug("ImportGlobalIngredients called", "actor_user_id", actorID, "count", len(inputs))
+
+	if len(inputs) == 0 {
+		return nil, ErrEmptyImport
+	}
+	if len(inputs) > MaxImportSize {
+		return nil, ErrImportTooLarge
+	}
+
+	var importErrors []ImportError
+	ingredients := make([]*models.GlobalIngredient, 0, len(inputs))
+	barcodes := make(map[string]int)
+	for i, input := range inputs {
+		if err := validateInput(input); err != nil {
+			importErrors = append(importErrors, ImportError{Index: i, Error: err.Error()})
+			continue
+		}
+		if input.Barcode != nil && *input.Barcode != "" {
+			if first, ok := barcodes[*input.Barcode]; ok {
+				importErrors = append(importErrors, ImportError{Index: i, Error: fmt.Sprintf("%v: barcode repeats item %d", ErrInvalidIngredient, first)})
+				continue
+			}
+			barcodes[*input.Barcode] = i
+		}
+		if err := s.checkBarcode(0, input.Barcode); err != nil {
+			importErrors = append(importErrors, ImportError{Index: i, Error: err.Error()})
+			continue
+		}
+		ingredients = append(ingredients, &models.GlobalIngredient{
+			KcalPer100g: input.KcalPer100g,
+			Fats:        input.Fats,
+			Carbs:       input.Carbs,
+			Proteins:    input.Proteins,
+			Nutrients:   input.Nutrients,
+			Barcode:     nonEmpty(input.Barcode),
+			Names:       input.Names,
+		})
+	}
+	if len(importErrors) > 0 {
+		s.logger.Debug("ImportGlobalIngredients failed - validation errors", "actor_user_id", actorID, "invalid", len(importErrors))
+		return nil, &ImportValidationError{Errors: importErrors}
+	}
+
+	audit := s.audit(actorID, AuditActionImport, EntityGlobalIngredient, nil)
+	ids, err := s.ingredientRepo.CreateGlobalIngredients(ingredients, func(_ *int, after any) (*models.AuditLogEntry, error) {
+		ids := after.([]int)
+		return audit(nil, map[string]any{
+			"count": len(ids),
+			"ids":   ids,
+		})
+	})
+	if err != nil {
+		s.logger.Error("Failed to import global ingredients", "error", err, "actor_user_id", actorID)
+		return nil, err
+	}
+
+	s.logger.Info("Global ingredients imported", "actor_user_id", actorID, "count", len(ids))
+	return &ImportResult{Created: len(ids), IDs: ids}, nil
+}
+
+// validateInput checks nutrition values and names, trimming names and completing
+// extended nutrients in place
+func validateInput(input *GlobalIngredientInput) error {
+	if input == nil {
+		return fmt.Errorf("%w: ingredient is required", ErrInvalidIngredient)
+	}
+
+	if math.IsNaN(input.KcalPer100g) || input.KcalPer100g < 0 || input.KcalPer100g > MaxKcalPer100g {
+		return fmt.Errorf("%w: kcalPer100g must be between 0 and %g", ErrInvalidIngredient, MaxKcalPer100g)
+	}
+
+	macros := []struct {
+		field string
+		value *float64
+	}{
+		{"fats", input.Fats},
+		{"carbs", input.Carbs},
+		{"proteins", input.Proteins},
+	}
+
+	var macroTotal float64
+	for _, macro := range macros {
+		if macro.value == nil {
+			continue
+		}
+		if math.IsNaN(*macro.value) || *macro.value < 0 || *macro.value > MaxMacroPer100g {
+			return fmt.Errorf("%w: %s must be between 0 and %g", ErrInvalidIngredient, macro.field, MaxMacroPer100g)
+		}
+		macroTotal += *macro.value
+	}
+	if macroTotal > MaxMacroPer100g {
+		return fmt.Errorf("%w: fats, carbs and proteins cannot exceed %g g per 100 g in total", ErrInvalidIngredient, MaxMacroPer100g)
+	}
+
+	if err := input.Nutrients.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidIngredient, err)
+	}
+	input.Nutrients = input.Nutrients.Complete()
+
+	if len(input.Names) == 0 {
+		return fmt.Errorf("%w: at least one name is required", ErrInvalidIngredient)
+	}
+
+	names := make(map[string]string, len(input.Names))
+	for lang, name := range input.Names {
+		if _, ok := config.SupportedLanguages[lang]; !ok {
+			return fmt.Errorf("%w: unsupported language %q", ErrInvalidIngredient, lang)
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return fmt.Errorf("%w: name for %s cannot be empty", ErrInvalidIngredient, lang)
+		}
+		if len([]rune(name)) > MaxNameLength {
+			return fmt.Errorf("%w: name for %s cannot be longer than %d characters", ErrInvalidIngredient, lang, MaxNameLength)
+		}
+		names[lang] = name
+	}
+	input.Names = names
+
+	if input.Barcode != nil && *input.Barcode != "" {
+		barcode, err := ingredientservice.NormalizeBarcode(*input.Barcode)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidIngredient, err)
+		}
+		input.Barcode = &barcode
+	}
+
+	return nil
+}
+
+// checkBarcode fails when another global ingredient already has the barcode
+func (s *Service) checkBarcode(ingredientID int, barcode *string) error {
+	if barcode == nil || *barcode == "" {
+		return nil
+	}
+
+	existing, err := s.ingredientRepo.GetGlobalIngredientByBarcode(*barcode)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		s.logger.Error("Failed to look up global ingredient by barcode", "error", err)
+		return err
+	}
+	if existing.ID != ingredientID {
+		return fmt.Errorf("%w: barcode is already used by ingredient %d", ErrInvalidIngredient, existing.ID)
+	}
+	return nil
+}
+
+// nonEmpty returns nil for a missing or empty string
+func nonEmpty(value *string) *string {
+	if value == nil || *value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,37 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrIngredientNotFound = errors.New("global ingredient not found")
+	ErrInvalidIngredient  = errors.New("invalid global ingredient")
+	ErrEmptyImport        = errors.New("import contains no ingredients")
+	ErrImportTooLarge     = fmt.Errorf("import cannot contain more than %d ingredients", MaxImportSize)
+)
+
+// ImportError describes why a single item of a bulk import was rejected
+type ImportError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// ImportValidationError is returned when one or more items of a bulk import are invalid
+type ImportValidationError struct {
+	Errors []ImportError
+}
+
+func (e *ImportValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, item := range e.Errors {
+		messages = append(messages, fmt.Sprintf("item %d: %s", item.Index, item.Error))
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ImportValidationError) Unwrap() error {
+	return ErrInvalidIngredient
+}
@@ -0,0 +1,17 @@
+package admin
+
+import "ypeskov/kkal-tracker/internal/models"
+
+// Servicer defines the admin service contract used by handlers and middleware
+type Servicer interface {
+	IsAdmin(userID int) (bool, error)
+
+	ListGlobalIngredients(filter GlobalIngredientFilter) (*GlobalIngredientPage, error)
+	GetGlobalIngredient(id int) (*models.GlobalIngredient, error)
+	CreateGlobalIngredient(actorID int, input *GlobalIngredientInput) (*models.GlobalIngredient, error)
+	UpdateGlobalIngredient(actorID, id int, input *GlobalIngredientInput) (*models.GlobalIngredient, error)
+	DeleteGlobalIngredient(actorID, id int) error
+	ImportGlobalIngredients(actorID int, inputs []*GlobalIngredientInput) (*ImportResult, error)
+
+	GetAuditLog(filter AuditFilter) ([]*models.AuditLogEntry, error)
+}
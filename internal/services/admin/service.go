@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+	ingredientservice "ypeskov/kkal-tracker/internal/services/ingredient"
+)
+
+type Service struct {
+	userRepo          repositories.UserRepository
+	ingredientRepo    repositories.IngredientRepository
+	auditRepo         repositories.AuditLogRepository
+	ingredientService ingredientservice.Servicer
+	logger            *slog.Logger
+}
+
+func New(userRepo repositories.UserRepository, ingredientRepo repositories.IngredientRepository,
+	auditRepo repositories.AuditLogRepository, ingredientService ingredientservice.Servicer, logger *slog.Logger) *Service {
+	return &Service{
+		userRepo:          userRepo,
+		ingredientRepo:    ingredientRepo,
+		auditRepo:         auditRepo,
+		ingredientService: ingredientService,
+		logger:            logger.With("service", "admin"),
+	}
+}
+
+// IsAdmin reports whether the user currently has the admin role.
+// The role is read on every call so revoking it takes effect immediately.
+func (s *Service) IsAdmin(userID int) (bool, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		s.logger.Error("Failed to get user", "error", err, "user_id", userID)
+		return false, err
+	}
+	return user.IsAdmin(), nil
+}
+
+// GetAuditLog returns audit log entries, newest first
+func (s *Service) GetAuditLog(filter AuditFilter) ([]*models.AuditLogEntry, error) {
+	s.logger.Debug("GetAuditLog called", "entity_type", filter.EntityType, "entity_id", filter.EntityID)
+
+	limit := filter.Limit
+	if limit <= 0 || limit > MaxAuditPageSize {
+		limit = MaxAuditPageSize
+	}
+
+	entries, err := s.auditRepo.GetEntries(filter.EntityType, filter.EntityID, limit, max(filter.Offset, 0))
+	if err != nil {
+		s.logger.Error("Failed to get audit log", "error", err)
+		return nil, err
+	}
+
+	if entries == nil {
+		entries = []*models.AuditLogEntry{}
+	}
+
+	s.logger.Debug("GetAuditLog completed successfully", "count", len(entries))
+	return entries, nil
+}
+
+// audit returns the function the repository uses to build the audit log entry
+// of a write. The entry is stored in the transaction of the write, so a change
+// that cannot be audited is not made.
+func (s *Service) audit(actorID int, action, entityType string, before any) repositories.AuditFunc {
+	return func(entityID *int, after any) (*models.AuditLogEntry, error) {
+		entry := &models.AuditLogEntry{
+			ActorUserID: &actorID,
+			Action:      action,
+			EntityType:  entityType,
+			EntityID:    entityID,
+		}
+
+		if before != nil || after != nil {
+			data, err := json.Marshal(auditChanges{Before: before, After: after})
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode audit changes: %w", err)
+			}
+			changes := string(data)
+			entry.Changes = &changes
+		}
+
+		return entry, nil
+	}
+}
@@ -0,0 +1,69 @@
+package admin
+
+import "ypeskov/kkal-tracker/internal/models"
+
+// Catalog limits
+const (
+	DefaultPageSize  = 50
+	MaxPageSize      = 500
+	MaxImportSize    = 1000
+	MaxNameLength    = 255
+	MaxKcalPer100g   = 900.0 // Pure fat, the most energy-dense food
+	MaxMacroPer100g  = 100.0
+	MaxAuditPageSize = 200
+)
+
+// Audit trail values
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	AuditActionImport = "import"
+
+	EntityGlobalIngredient = "global_ingredient"
+)
+
+// GlobalIngredientInput holds nutrition values and per-language names of a global ingredient
+type GlobalIngredientInput struct {
+	KcalPer100g float64
+	Fats        *float64
+	Carbs       *float64
+	Proteins    *float64
+	Nutrients   models.Nutrients
+	Barcode     *string           // On update, nil keeps the stored barcode and empty clears it
+	Names       map[string]string // language_code -> name
+}
+
+// GlobalIngredientFilter narrows down the global catalog listing
+type GlobalIngredientFilter struct {
+	Query        string // Matches names in any language unless LanguageCode is set
+	LanguageCode string
+	Limit        int
+	Offset       int
+}
+
+// GlobalIngredientPage is a page of the global catalog
+type GlobalIngredientPage struct {
+	Items []*models.GlobalIngredient `json:"items"`
+	Total int                        `json:"total"`
+}
+
+// ImportResult reports the outcome of a bulk import
+type ImportResult struct {
+	Created int   `json:"created"`
+	IDs     []int `json:"ids"`
+}
+
+// AuditFilter narrows down the audit log listing
+type AuditFilter struct {
+	EntityType string
+	EntityID   int
+	Limit      int
+	Offset     int
+}
+
+// auditChanges is the JSON stored in an audit log entry
+type auditChanges struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
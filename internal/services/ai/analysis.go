@@ -0,0 +1,186 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// Limits applied to the structured analysis returned by the model
+const (
+	maxFindings        = 10
+	maxRecommendations = 10
+	maxRiskFlags       = 10
+)
+
+// analysisInputHash identifies an analysis request by its data, the model and the
+// prompt templates, so that changing any of them invalidates cached results
+func (s *Service) analysisInputHash(req AnalysisRequest) (string, error) {
+	payload, err := json.Marshal(struct {
+		Model   string          `json:"model"`
+		Prompts string          `json:"prompts"`
+		Request AnalysisRequest `json:"request"`
+	}{
+		Model:   s.config.AI.Model,
+		Prompts: s.prompts.Fingerprint(),
+		Request: req,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode analysis input: %w", err)
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// getCachedAnalysis returns a fresh cached analysis or nil. Cache failures are
+// logged and treated as a miss so they never block a real analysis.
+func (s *Service) getCachedAnalysis(userID int, inputHash string) *AnalysisResponse {
+	entry, err := s.cacheRepo.Get(userID, inputHash)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrNotFound) {
+			s.logger.Warn("Failed to read analysis cache", slog.String("error", err.Error()))
+		}
+		return nil
+	}
+
+	if time.Since(entry.CreatedAt) > s.cacheTTL() {
+		return nil
+	}
+
+	var response AnalysisResponse
+	if err := json.Unmarshal([]byte(entry.Response), &response); err != nil {
+		s.logger.Warn("Failed to decode cached analysis", slog.String("error", err.Error()))
+		return nil
+	}
+
+	response.Cached = true
+	response.DurationMs = 0
+	return &response
+}
+
+// cacheAnalysis stores a validated analysis and drops the user's expired entries
+func (s *Service) cacheAnalysis(userID int, inputHash string, response *AnalysisResponse) {
+	payload, err := json.Marshal(response)
+	if err != nil {
+		s.logger.Warn("Failed to encode analysis for cache", slog.String("error", err.Error()))
+		return
+	}
+
+	if err := s.cacheRepo.Save(userID, inputHash, response.Model, string(payload), response.TokensUsed); err != nil {
+		s.logger.Warn("Failed to cache analysis", slog.String("error", err.Error()))
+		return
+	}
+
+	if err := s.cacheRepo.DeleteOlderThan(userID, time.Now().Add(-s.cacheTTL())); err != nil {
+		s.logger.Warn("Failed to purge expired analyses", slog.String("error", err.Error()))
+	}
+}
+
+// cacheTTL returns how long cached analyses stay valid
+func (s *Service) cacheTTL() time.Duration {
+	return time.Duration(s.config.AI.CacheTTLHours) * time.Hour
+}
+
+// validateAnalysis checks the structured response against the input data.
+// Dates and foods that do not appear in the input are dropped, priorities and
+// risk codes are normalized, and empty entries are removed.
+func validateAnalysis(resp *AnalysisResponse, req AnalysisRequest) error {
+	resp.Summary = strings.TrimSpace(resp.Summary)
+	if resp.Summary == "" {
+		return fmt.Errorf("%w: summary is empty", ErrAnalysisFailed)
+	}
+
+	knownDates := make(map[string]bool)
+	knownFoods := make(map[string]string)
+	for _, day := range req.NutritionData {
+		knownDates[day.Date] = true
+		for _, item := range day.FoodItems {
+			knownFoods[strings.ToLower(strings.TrimSpace(item.Name))] = item.Name
+		}
+	}
+	for _, w := range req.WeightData {
+		knownDates[w.Date] = true
+	}
+
+	findings := make([]Finding, 0, len(resp.Findings))
+	for _, f := range resp.Findings {
+		f.Title = strings.TrimSpace(f.Title)
+		f.Detail = strings.TrimSpace(f.Detail)
+		if f.Detail == "" {
+			continue
+		}
+
+		dates := make([]string, 0, len(f.Dates))
+		for _, d := range f.Dates {
+			if knownDates[strings.TrimSpace(d)] {
+				dates = append(dates, strings.TrimSpace(d))
+			}
+		}
+		f.Dates = dates
+
+		foods := make([]string, 0, len(f.Foods))
+		for _, food := range f.Foods {
+			if name, ok := knownFoods[strings.ToLower(strings.TrimSpace(food))]; ok {
+				foods = append(foods, name)
+			}
+		}
+		f.Foods = foods
+
+		findings = append(findings, f)
+		if len(findings) == maxFindings {
+			break
+		}
+	}
+	resp.Findings = findings
+
+	recommendations := make([]Recommendation, 0, len(resp.Recommendations))
+	for _, r := range resp.Recommendations {
+		r.Action = strings.TrimSpace(r.Action)
+		r.Rationale = strings.TrimSpace(r.Rationale)
+		if r.Action == "" {
+			continue
+		}
+
+		switch p := strings.ToLower(strings.TrimSpace(r.Priority)); p {
+		case PriorityHigh, PriorityMedium, PriorityLow:
+			r.Priority = p
+		default:
+			r.Priority = PriorityMedium
+		}
+
+		recommendations = append(recommendations, r)
+		if len(recommendations) == maxRecommendations {
+			break
+		}
+	}
+	resp.Recommendations = recommendations
+
+	riskFlags := make([]RiskFlag, 0, len(resp.RiskFlags))
+	for _, flag := range resp.RiskFlags {
+		flag.Description = strings.TrimSpace(flag.Description)
+		if flag.Description == "" {
+			continue
+		}
+
+		flag.Code = strings.ToLower(strings.TrimSpace(flag.Code))
+		if !RiskFlagCodes[flag.Code] {
+			flag.Code = RiskOther
+		}
+
+		riskFlags = append(riskFlags, flag)
+		if len(riskFlags) == maxRiskFlags {
+			break
+		}
+	}
+	resp.RiskFlags = riskFlags
+
+	return nil
+}
@@ -1,10 +1,21 @@
 package ai
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
 
 // Servicer defines the AI service contract used by handlers.
 type Servicer interface {
 	Analyze(ctx context.Context, req AnalysisRequest) (*AnalysisResponse, error)
 	IsAvailable() bool
 	GetModel() string
+
+	GenerateMealPlan(ctx context.Context, req GenerateMealPlanRequest) (*MealPlanResult, error)
+	GetMealPlans(userID int) ([]*models.MealPlan, error)
+	GetMealPlan(userID, planID int) (*MealPlanResult, error)
+	DeleteMealPlan(userID, planID int) error
+	ApplyMealPlanDay(userID, planID, day int, date time.Time) ([]*models.CalorieEntry, error)
 }
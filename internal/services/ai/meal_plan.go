@@ -0,0 +1,408 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+	"ypeskov/kkal-tracker/internal/services/batch"
+)
+
+// Meal plan generation limits
+const (
+	MaxMealPlanDays     = 7
+	DefaultMealsPerDay  = 4
+	maxCatalogItems     = 150    // Catalog entries sent to the model
+	catalogUsageDays    = 60     // Window used to rank the catalog by recent usage
+	maxItemGrams        = 1000.0 // Largest realistic single portion
+	dayCalorieTolerance = 10.0   // Allowed daily deviation from the calorie target, percent
+	maxNotesLength      = 1000
+)
+
+// mealHours maps meal types to the hour entries are logged at when a plan is applied
+var mealHours = map[string]int{
+	"breakfast": 8,
+	"lunch":     13,
+	"snack":     16,
+	"dinner":    19,
+}
+
+// GenerateMealPlan drafts a meal plan with the AI provider, validates it against
+// the user's catalog and targets, and stores it
+func (s *Service) GenerateMealPlan(ctx context.Context, req GenerateMealPlanRequest) (*MealPlanResult, error) {
+	s.logger.Debug("GenerateMealPlan called", "user_id", req.UserID, "days", req.Days)
+
+	if s.provider == nil {
+		return nil, ErrProviderNotAvailable
+	}
+
+	if req.MealsPerDay == 0 {
+		req.MealsPerDay = DefaultMealsPerDay
+	}
+
+	s.enrichUserContext(req.UserID, &req.UserContext)
+	if req.UserContext.Targets == nil {
+		return nil, ErrTargetsUnavailable
+	}
+	targets := req.UserContext.Targets
+
+	ingredients, err := s.ingredientRepo.GetAllUserIngredients(req.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get user ingredients", "user_id", req.UserID, "error", err)
+		return nil, err
+	}
+
+	catalog, err := s.buildCatalog(req.UserID, ingredients)
+	if err != nil {
+		return nil, err
+	}
+
+	draft, err := s.provider.GenerateMealPlan(ctx, s.config.AI.Model, MealPlanRequest{
+		UserContext: req.UserContext,
+		Targets:     *targets,
+		Catalog:     catalog,
+		Days:        req.Days,
+		MealsPerDay: req.MealsPerDay,
+		Preferences: req.Preferences,
+	})
+	if err != nil {
+		s.logger.Error("Meal plan generation failed", "user_id", req.UserID, "error", err)
+		return nil, err
+	}
+
+	plan, warnings, err := buildMealPlan(draft, req, targets, ingredients)
+	if err != nil {
+		s.logger.Warn("Meal plan draft rejected", "user_id", req.UserID, "error", err)
+		return nil, err
+	}
+
+	plan, err = s.mealPlanRepo.Create(plan)
+	if err != nil {
+		s.logger.Error("Failed to save meal plan", "user_id", req.UserID, "error", err)
+		return nil, err
+	}
+
+	days := summarizeMealPlan(plan)
+	for _, day := range days {
+		if !day.WithinTolerance {
+			warnings = append(warnings, fmt.Sprintf("Day %d has %d kcal, %.0f%% off the target", day.Day, day.Calories, day.DeviationPercent))
+		}
+	}
+
+	s.logger.Info("Meal plan generated",
+		slog.Int("user_id", req.UserID),
+		slog.Int("plan_id", plan.ID),
+		slog.Int("items", len(plan.Items)),
+		slog.Int("tokens", draft.TokensUsed))
+
+	return &MealPlanResult{
+		Plan:       plan,
+		Days:       days,
+		Warnings:   warnings,
+		TokensUsed: draft.TokensUsed,
+		DurationMs: draft.DurationMs,
+	}, nil
+}
+
+// GetMealPlans returns all saved meal plans of a user without their items
+func (s *Service) GetMealPlans(userID int) ([]*models.MealPlan, error) {
+	s.logger.Debug("GetMealPlans called", "user_id", userID)
+	return s.mealPlanRepo.GetByUserID(userID)
+}
+
+// GetMealPlan returns a saved meal plan with its items and per-day totals
+func (s *Service) GetMealPlan(userID, planID int) (*MealPlanResult, error) {
+	s.logger.Debug("GetMealPlan called", "user_id", userID, "plan_id", planID)
+
+	plan, err := s.mealPlanRepo.GetByID(planID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrMealPlanNotFound
+		}
+		return nil, err
+	}
+
+	return &MealPlanResult{
+		Plan: plan,
+		Days: summarizeMealPlan(plan),
+	}, nil
+}
+
+// DeleteMealPlan removes a saved meal plan
+func (s *Service) DeleteMealPlan(userID, planID int) error {
+	s.logger.Debug("DeleteMealPlan called", "user_id", userID, "plan_id", planID)
+
+	err := s.mealPlanRepo.Delete(planID, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrMealPlanNotFound
+	}
+	return err
+}
+
+// ApplyMealPlanDay logs all items of one plan day as calorie entries on the
+// given date. The entries are created in one transaction: either all of them
+// are logged or none.
+func (s *Service) ApplyMealPlanDay(userID, planID, day int, date time.Time) ([]*models.CalorieEntry, error) {
+	s.logger.Debug("ApplyMealPlanDay called", "user_id", userID, "plan_id", planID, "day", day)
+
+	plan, err := s.mealPlanRepo.GetByID(planID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrMealPlanNotFound
+		}
+		return nil, err
+	}
+
+	if day < 1 || day > plan.Days {
+		return nil, ErrInvalidPlanDay
+	}
+
+	req := &batch.BatchRequest{Atomic: true}
+	for _, item := range plan.Items {
+		if item.DayNumber != day {
+			continue
+		}
+
+		hour, ok := mealHours[item.MealType]
+		if !ok {
+			hour = mealHours["snack"]
+		}
+		mealDatetime := time.Date(date.Year(), date.Month(), date.Day(), hour, 0, 0, 0, date.Location())
+
+		req.Operations = append(req.Operations, &batch.Operation{
+			Action: models.BatchActionCreate,
+			Type:   models.SyncEntityCalorieEntry,
+			Data: &batch.OperationData{
+				Food:        item.Food,
+				Calories:    item.Calories,
+				Weight:      item.Weight,
+				KcalPer100g: item.KcalPer100g,
+				Fats:        item.Fats,
+				Carbs:       item.Carbs,
+				Proteins:    item.Proteins,
+				MealTime:    mealDatetime,
+			},
+		})
+	}
+
+	entries := []*models.CalorieEntry{}
+	if len(req.Operations) > 0 {
+		result, err := s.batchService.Apply(userID, req)
+		if err != nil {
+			s.logger.Error("Failed to create entries from meal plan", "user_id", userID, "plan_id", planID, "error", err)
+			return nil, err
+		}
+		for _, item := range result.Results {
+			// The other items of the failed one only report that they were not applied
+			if item.Error != "" && item.Status != http.StatusFailedDependency {
+				s.logger.Error("Meal plan item rejected", "user_id", userID, "plan_id", planID, "index", item.Index, "error", item.Error)
+				return nil, fmt.Errorf("failed to create entry for meal plan item %d: %s", item.Index+1, item.Error)
+			}
+			if entry, ok := item.Data.(*models.CalorieEntry); ok {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	s.logger.Info("Meal plan day applied", "user_id", userID, "plan_id", planID, "day", day, "entries", len(entries))
+	return entries, nil
+}
+
+// buildCatalog ranks the user's ingredients by how often they were eaten recently
+// and returns the top of the list for the prompt
+func (s *Service) buildCatalog(userID int, ingredients []*models.UserIngredient) ([]CatalogItem, error) {
+	dateTo := time.Now().Format("2006-01-02")
+	dateFrom := time.Now().AddDate(0, 0, -catalogUsageDays).Format("2006-01-02")
+
+	entries, err := s.calorieService.GetEntriesByDateRange(userID, dateFrom, dateTo)
+	if err != nil {
+		s.logger.Error("Failed to get recent entries for catalog", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	usage := make(map[string]int)
+	for _, entry := range entries {
+		usage[strings.ToLower(strings.TrimSpace(entry.Food))]++
+	}
+
+	ranked := make([]*models.UserIngredient, len(ingredients))
+	copy(ranked, ingredients)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		ui := usage[strings.ToLower(ranked[i].Name)]
+		uj := usage[strings.ToLower(ranked[j].Name)]
+		if ui != uj {
+			return ui > uj
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+
+	if len(ranked) > maxCatalogItems {
+		ranked = ranked[:maxCatalogItems]
+	}
+
+	catalog := make([]CatalogItem, 0, len(ranked))
+	for _, ing := range ranked {
+		catalog = append(catalog, CatalogItem{
+			ID:          ing.ID,
+			Name:        ing.Name,
+			KcalPer100g: ing.KcalPer100g,
+			Fats:        valueOrZero(ing.Fats),
+			Carbs:       valueOrZero(ing.Carbs),
+			Proteins:    valueOrZero(ing.Proteins),
+		})
+	}
+
+	return catalog, nil
+}
+
+// buildMealPlan validates a model draft and converts it into a meal plan.
+// Nutrition always comes from the user's ingredients, never from the model, so
+// foods that match no ingredient are dropped.
+func buildMealPlan(draft *MealPlanDraft, req GenerateMealPlanRequest, targets *NutritionTargets, ingredients []*models.UserIngredient) (*models.MealPlan, []string, error) {
+	byID := make(map[int]*models.UserIngredient, len(ingredients))
+	byName := make(map[string]*models.UserIngredient, len(ingredients))
+	for _, ing := range ingredients {
+		byID[ing.ID] = ing
+		byName[strings.ToLower(strings.TrimSpace(ing.Name))] = ing
+	}
+
+	plan := &models.MealPlan{
+		UserID:         req.UserID,
+		Days:           req.Days,
+		TargetCalories: targets.Calories,
+		TargetProteins: &targets.Proteins,
+		TargetFats:     &targets.Fats,
+		TargetCarbs:    &targets.Carbs,
+		Model:          draft.Model,
+	}
+	if notes := strings.TrimSpace(draft.Notes); notes != "" {
+		if len([]rune(notes)) > maxNotesLength {
+			notes = string([]rune(notes)[:maxNotesLength])
+		}
+		plan.Notes = &notes
+	}
+
+	var warnings []string
+	seenDays := make(map[int]bool)
+	itemsPerDay := make(map[int]int)
+	offCatalog := 0
+
+	for i, draftDay := range draft.Days {
+		dayNumber := draftDay.Day
+		if dayNumber < 1 || dayNumber > req.Days {
+			dayNumber = i + 1
+		}
+		if dayNumber > req.Days || seenDays[dayNumber] {
+			warnings = append(warnings, fmt.Sprintf("Ignored unexpected day %d in the generated plan", draftDay.Day))
+			continue
+		}
+		seenDays[dayNumber] = true
+
+		for _, meal := range draftDay.Meals {
+			mealType := strings.ToLower(strings.TrimSpace(meal.MealType))
+			if _, ok := mealHours[mealType]; !ok {
+				mealType = "snack"
+			}
+
+			for _, draftItem := range meal.Items {
+				grams := math.Round(draftItem.Grams)
+				if grams <= 0 || grams > maxItemGrams {
+					warnings = append(warnings, fmt.Sprintf("Skipped %q: unrealistic portion of %.0f g", draftItem.Name, draftItem.Grams))
+					continue
+				}
+
+				item := &models.MealPlanItem{
+					DayNumber: dayNumber,
+					MealType:  mealType,
+					Weight:    grams,
+				}
+
+				var ing *models.UserIngredient
+				if draftItem.IngredientID != nil {
+					ing = byID[*draftItem.IngredientID]
+				}
+				if ing == nil {
+					ing = byName[strings.ToLower(strings.TrimSpace(draftItem.Name))]
+				}
+
+				if ing == nil {
+					offCatalog++
+					continue
+				}
+
+				id := ing.ID
+				item.IngredientID = &id
+				item.Food = ing.Name
+				item.KcalPer100g = ing.KcalPer100g
+				item.Fats = ing.Fats
+				item.Carbs = ing.Carbs
+				item.Proteins = ing.Proteins
+
+				item.Calories = int(math.Max(1, math.Round(item.KcalPer100g*grams/100)))
+				plan.Items = append(plan.Items, item)
+				itemsPerDay[dayNumber]++
+			}
+		}
+	}
+
+	for day := 1; day <= req.Days; day++ {
+		if itemsPerDay[day] == 0 {
+			return nil, nil, fmt.Errorf("%w: day %d has no valid items", ErrInvalidMealPlan, day)
+		}
+	}
+
+	if offCatalog > 0 {
+		warnings = append(warnings, fmt.Sprintf("Skipped %d item(s) that are not in your ingredient catalog", offCatalog))
+	}
+
+	return plan, warnings, nil
+}
+
+// summarizeMealPlan calculates per-day totals of a meal plan and compares them to its target
+func summarizeMealPlan(plan *models.MealPlan) []MealPlanDaySummary {
+	days := make([]MealPlanDaySummary, plan.Days)
+	for i := range days {
+		days[i].Day = i + 1
+	}
+
+	for _, item := range plan.Items {
+		if item.DayNumber < 1 || item.DayNumber > plan.Days {
+			continue
+		}
+		day := &days[item.DayNumber-1]
+		day.Calories += item.Calories
+		day.Fats += valueOrZero(item.Fats) * item.Weight / 100
+		day.Carbs += valueOrZero(item.Carbs) * item.Weight / 100
+		day.Proteins += valueOrZero(item.Proteins) * item.Weight / 100
+	}
+
+	for i := range days {
+		day := &days[i]
+		day.Fats = math.Round(day.Fats*10) / 10
+		day.Carbs = math.Round(day.Carbs*10) / 10
+		day.Proteins = math.Round(day.Proteins*10) / 10
+		if plan.TargetCalories > 0 {
+			deviation := (float64(day.Calories) - plan.TargetCalories) / plan.TargetCalories * 100
+			day.DeviationPercent = math.Round(deviation*10) / 10
+		}
+		day.WithinTolerance = math.Abs(day.DeviationPercent) <= dayCalorieTolerance
+	}
+
+	return days
+}
+
+// valueOrZero dereferences an optional nutrition value
+func valueOrZero(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
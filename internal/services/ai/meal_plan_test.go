@@ -0,0 +1,165 @@
+package ai
+
+import (
+	"errors"
+	"testing"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+func mealPlanCatalog() []*models.UserIngredient {
+	protein := 31.0
+	return []*models.UserIngredient{
+		{ID: 1, Name: "Oatmeal", KcalPer100g: 370},
+		{ID: 2, Name: "Chicken breast", KcalPer100g: 165, Proteins: &protein},
+	}
+}
+
+func draftDay(day int, items ...DraftItem) DraftDay {
+	return DraftDay{Day: day, Meals: []DraftMeal{{MealType: "Lunch", Items: items}}}
+}
+
+func TestBuildMealPlan(t *testing.T) {
+	oatmeal := 1
+	unknown := 99
+	tests := []struct {
+		name      string
+		days      int
+		draft     []DraftDay
+		wantItems []models.MealPlanItem // Day, food, weight and calories of each item
+		wantWarns int
+		wantErr   bool
+	}{
+		{
+			name: "nutrition comes from the catalog",
+			days: 1,
+			draft: []DraftDay{draftDay(1,
+				DraftItem{IngredientID: &oatmeal, Name: "Porridge", Grams: 50},
+				DraftItem{Name: " chicken BREAST ", Grams: 150.4},
+			)},
+			wantItems: []models.MealPlanItem{
+				{DayNumber: 1, Food: "Oatmeal", Weight: 50, Calories: 185},
+				{DayNumber: 1, Food: "Chicken breast", Weight: 150, Calories: 248},
+			},
+		},
+		{
+			name: "foods outside the catalog are dropped",
+			days: 1,
+			draft: []DraftDay{draftDay(1,
+				DraftItem{Name: "Oatmeal", Grams: 100},
+				DraftItem{IngredientID: &unknown, Name: "Dragon fruit", Grams: 200},
+			)},
+			wantItems: []models.MealPlanItem{{DayNumber: 1, Food: "Oatmeal", Weight: 100, Calories: 370}},
+			wantWarns: 1,
+		},
+		{
+			name: "a repeated day is ignored wherever it comes",
+			days: 2,
+			draft: []DraftDay{
+				draftDay(2, DraftItem{Name: "Chicken breast", Grams: 100}),
+				draftDay(1, DraftItem{Name: "Oatmeal", Grams: 100}),
+				draftDay(2, DraftItem{Name: "Oatmeal", Grams: 300}),
+			},
+			wantItems: []models.MealPlanItem{
+				{DayNumber: 2, Food: "Chicken breast", Weight: 100, Calories: 165},
+				{DayNumber: 1, Food: "Oatmeal", Weight: 100, Calories: 370},
+			},
+			wantWarns: 1,
+		},
+		{
+			name: "days out of range take their position",
+			days: 2,
+			draft: []DraftDay{
+				draftDay(0, DraftItem{Name: "Oatmeal", Grams: 100}),
+				draftDay(7, DraftItem{Name: "Chicken breast", Grams: 100}),
+			},
+			wantItems: []models.MealPlanItem{
+				{DayNumber: 1, Food: "Oatmeal", Weight: 100, Calories: 370},
+				{DayNumber: 2, Food: "Chicken breast", Weight: 100, Calories: 165},
+			},
+		},
+		{
+			name: "unrealistic portions are skipped",
+			days: 1,
+			draft: []DraftDay{draftDay(1,
+				DraftItem{Name: "Oatmeal", Grams: 2000},
+				DraftItem{Name: "Chicken breast", Grams: 100},
+			)},
+			wantItems: []models.MealPlanItem{{DayNumber: 1, Food: "Chicken breast", Weight: 100, Calories: 165}},
+			wantWarns: 1,
+		},
+		{
+			name:    "a day without catalog foods fails",
+			days:    2,
+			draft:   []DraftDay{draftDay(1, DraftItem{Name: "Oatmeal", Grams: 100}), draftDay(2, DraftItem{Name: "Pizza", Grams: 300})},
+			wantErr: true,
+		},
+	}
+
+	catalogIDs := make(map[string]int)
+	for _, ing := range mealPlanCatalog() {
+		catalogIDs[ing.Name] = ing.ID
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := GenerateMealPlanRequest{UserID: 7, Days: tt.days}
+			targets := &NutritionTargets{Calories: 2000}
+			plan, warnings, err := buildMealPlan(&MealPlanDraft{Days: tt.draft}, req, targets, mealPlanCatalog())
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMealPlan) {
+					t.Fatalf("got error %v, want %v", err, ErrInvalidMealPlan)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(warnings) != tt.wantWarns {
+				t.Errorf("got warnings %q, want %d", warnings, tt.wantWarns)
+			}
+			if len(plan.Items) != len(tt.wantItems) {
+				t.Fatalf("got %d items, want %d", len(plan.Items), len(tt.wantItems))
+			}
+			for i, want := range tt.wantItems {
+				got := plan.Items[i]
+				if got.DayNumber != want.DayNumber || got.Food != want.Food || got.Weight != want.Weight || got.Calories != want.Calories {
+					t.Errorf("item %d = day %d %q %g g %d kcal, want day %d %q %g g %d kcal", i,
+						got.DayNumber, got.Food, got.Weight, got.Calories, want.DayNumber, want.Food, want.Weight, want.Calories)
+				}
+				if got.IngredientID == nil || *got.IngredientID != catalogIDs[got.Food] {
+					t.Errorf("item %d is not linked to its catalog ingredient", i)
+				}
+				if got.MealType != "lunch" {
+					t.Errorf("item %d has meal type %q, want lunch", i, got.MealType)
+				}
+			}
+		})
+	}
+}
+
+func TestSummarizeMealPlan(t *testing.T) {
+	protein := 20.0
+	plan := &models.MealPlan{
+		Days:           2,
+		TargetCalories: 2000,
+		Items: []*models.MealPlanItem{
+			{DayNumber: 1, Weight: 200, Calories: 1500, Proteins: &protein},
+			{DayNumber: 1, Weight: 100, Calories: 500},
+			{DayNumber: 2, Weight: 100, Calories: 1500},
+			{DayNumber: 3, Weight: 100, Calories: 900}, // Outside the plan
+		},
+	}
+
+	days := summarizeMealPlan(plan)
+	if len(days) != 2 {
+		t.Fatalf("got %d days, want 2", len(days))
+	}
+	if days[0].Calories != 2000 || days[0].Proteins != 40 || days[0].DeviationPercent != 0 || !days[0].WithinTolerance {
+		t.Errorf("day 1 = %+v", days[0])
+	}
+	if days[1].Calories != 1500 || days[1].DeviationPercent != -25 || days[1].WithinTolerance {
+		t.Errorf("day 2 = %+v", days[1])
+	}
+}
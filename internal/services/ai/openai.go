@@ -1,46 +1,28 @@
 package ai
 
 import (
-	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
-	"text/template"
+	"strings"
 	"time"
 
-	"ypeskov/kkal-tracker/internal/config"
 
 	"github.com/sashabaranov/go-openai"
 )
 
-var (
-	systemPromptTmpl *template.Template
-	userPromptTmpl   *template.Template
-)
-
-func init() {
-	var err error
-	systemPromptTmpl, err = template.New("system").Parse(SystemPromptTemplate)
-	if err != nil {
-		panic(fmt.Sprintf("failed to parse system prompt template: %v", err))
-	}
-
-	userPromptTmpl, err = template.New("user").Parse(UserPromptTemplate)
-	if err != nil {
-		panic(fmt.Sprintf("failed to parse user prompt template: %v", err))
-	}
-}
-
 // OpenAIProvider implements the Provider interface for OpenAI
 type OpenAIProvider struct {
 	client       *openai.Client
+	prompts      *PromptSet
 	logger       *slog.Logger
 	useMaxTokens bool
 	maxTokens    int
 }
 
 // NewOpenAIProvider creates a new OpenAI provider instance
-func NewOpenAIProvider(apiKey string, baseURL string, useMaxTokens bool, maxTokens int, logger *slog.Logger) *OpenAIProvider {
+func NewOpenAIProvider(apiKey string, baseURL string, useMaxTokens bool, maxTokens int, prompts *PromptSet, logger *slog.Logger) *OpenAIProvider {
 	cfg := openai.DefaultConfig(apiKey)
 	if baseURL != "" {
 		cfg.BaseURL = baseURL
@@ -48,6 +30,7 @@ func NewOpenAIProvider(apiKey string, baseURL string, useMaxTokens bool, maxToke
 
 	return &OpenAIProvider{
 		client:       openai.NewClientWithConfig(cfg),
+		prompts:      prompts,
 		logger:       logger.With("provider", "openai"),
 		useMaxTokens: useMaxTokens,
 		maxTokens:    maxTokens,
@@ -68,8 +51,22 @@ func (p *OpenAIProvider) IsAvailable() bool {
 func (p *OpenAIProvider) Analyze(ctx context.Context, model string, req AnalysisRequest) (*AnalysisResponse, error) {
 	startTime := time.Now()
 
-	systemPrompt := p.buildSystemPrompt(req.UserContext)
-	userPrompt := p.buildUserPrompt(req)
+	data := newPromptData(req.UserContext)
+	data.PeriodDays = req.PeriodDays
+	data.NutritionData = req.NutritionData
+	data.WeightData = req.WeightData
+	data.Query = req.Query
+
+	systemPrompt, err := p.prompts.Render(PromptSystem, data.LanguageCode, data)
+	if err != nil {
+		p.logger.Error("Failed to build system prompt", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%w: %v", ErrAnalysisFailed, err)
+	}
+	userPrompt, err := p.prompts.Render(PromptUser, data.LanguageCode, data)
+	if err != nil {
+		p.logger.Error("Failed to build user prompt", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%w: %v", ErrAnalysisFailed, err)
+	}
 
 	p.logger.Debug("Sending analysis request to OpenAI",
 		slog.String("model", model),
@@ -87,11 +84,14 @@ func (p *OpenAIProvider) Analyze(ctx context.Context, model string, req Analysis
 			},
 			{
 				Role:    openai.ChatMessageRoleUser,
-			Content: userPrompt,
+				Content: userPrompt,
+			},
 		},
-	},
-	Temperature: 0.4, // Lower temperature for more consistent and reliable health advice
-}
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+		Temperature: 0.4, // Lower temperature for more consistent and reliable health advice
+	}
 
 	// Use MaxCompletionTokens for newer models (GPT-4.5+, GPT-5.x)
 	if p.useMaxTokens && p.maxTokens > 0 {
@@ -108,67 +108,102 @@ func (p *OpenAIProvider) Analyze(ctx context.Context, model string, req Analysis
 		return nil, ErrAnalysisFailed
 	}
 
-	duration := time.Since(startTime).Milliseconds()
+	var analysis AnalysisResponse
+	content := stripCodeFence(resp.Choices[0].Message.Content)
+	if err := json.Unmarshal([]byte(content), &analysis); err != nil {
+		p.logger.Error("Failed to parse analysis JSON", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%w: invalid JSON response: %v", ErrAnalysisFailed, err)
+	}
 
-	return &AnalysisResponse{
-		Analysis:   resp.Choices[0].Message.Content,
-		Model:      model,
-		TokensUsed: resp.Usage.TotalTokens,
-		DurationMs: duration,
-	}, nil
-}
+	analysis.Model = model
+	analysis.TokensUsed = resp.Usage.TotalTokens
+	analysis.DurationMs = time.Since(startTime).Milliseconds()
 
-// systemPromptData holds data for system prompt template
-type systemPromptData struct {
-	Language string
-	Age      *int
-	Height   *float64
+	return &analysis, nil
 }
 
-// buildSystemPrompt creates the system prompt for AI analysis
-func (p *OpenAIProvider) buildSystemPrompt(userCtx UserContext) string {
-	lang := userCtx.Language
-	if lang == "" {
-		lang = config.DefaultLanguageCode
+// GenerateMealPlan asks OpenAI for a meal plan draft in JSON format
+func (p *OpenAIProvider) GenerateMealPlan(ctx context.Context, model string, req MealPlanRequest) (*MealPlanDraft, error) {
+	startTime := time.Now()
+
+	data := newPromptData(req.UserContext)
+	data.Targets = req.Targets
+	data.Days = req.Days
+	data.MealsPerDay = req.MealsPerDay
+	data.Catalog = req.Catalog
+	data.Preferences = req.Preferences
+
+	systemPrompt, err := p.prompts.Render(PromptMealPlanSystem, data.LanguageCode, data)
+	if err != nil {
+		p.logger.Error("Failed to build meal plan system prompt", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%w: %v", ErrAnalysisFailed, err)
+	}
+	userPrompt, err := p.prompts.Render(PromptMealPlanUser, data.LanguageCode, data)
+	if err != nil {
+		p.logger.Error("Failed to build meal plan user prompt", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%w: %v", ErrAnalysisFailed, err)
 	}
 
-	data := systemPromptData{
-		Language: config.GetLanguageName(lang),
-		Age:      userCtx.Age,
-		Height:   userCtx.Height,
+	p.logger.Debug("Sending meal plan request to OpenAI",
+		slog.String("model", model),
+		slog.Int("days", req.Days),
+		slog.Int("catalog_size", len(req.Catalog)))
+
+	chatReq := openai.ChatCompletionRequest{
+		Model: model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleSystem,
+				Content: systemPrompt,
+			},
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: userPrompt,
+			},
+		},
+		ResponseFormat: &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONObject,
+		},
+		Temperature: 0.4,
 	}
 
-	var buf bytes.Buffer
-	if err := systemPromptTmpl.Execute(&buf, data); err != nil {
-		p.logger.Error("Failed to execute system prompt template", slog.String("error", err.Error()))
-		return ""
+	if p.useMaxTokens && p.maxTokens > 0 {
+		chatReq.MaxCompletionTokens = p.maxTokens
 	}
 
-	return buf.String()
-}
+	resp, err := p.client.CreateChatCompletion(ctx, chatReq)
+	if err != nil {
+		p.logger.Error("OpenAI API error", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%w: %v", ErrAnalysisFailed, err)
+	}
 
-// userPromptData holds data for user prompt template
-type userPromptData struct {
-	PeriodDays    int
-	NutritionData []NutritionDataPoint
-	WeightData    []WeightDataPoint
-	Query         string
-}
+	if len(resp.Choices) == 0 {
+		return nil, ErrAnalysisFailed
+	}
 
-// buildUserPrompt creates the user prompt with nutrition and weight data
-func (p *OpenAIProvider) buildUserPrompt(req AnalysisRequest) string {
-	data := userPromptData{
-		PeriodDays:    req.PeriodDays,
-		NutritionData: req.NutritionData,
-		WeightData:    req.WeightData,
-		Query:         req.Query,
+	var draft MealPlanDraft
+	content := stripCodeFence(resp.Choices[0].Message.Content)
+	if err := json.Unmarshal([]byte(content), &draft); err != nil {
+		p.logger.Error("Failed to parse meal plan JSON", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMealPlan, err)
 	}
 
-	var buf bytes.Buffer
-	if err := userPromptTmpl.Execute(&buf, data); err != nil {
-		p.logger.Error("Failed to execute user prompt template", slog.String("error", err.Error()))
-		return ""
+	draft.Model = model
+	draft.TokensUsed = resp.Usage.TotalTokens
+	draft.DurationMs = time.Since(startTime).Milliseconds()
+
+	return &draft, nil
+}
+
+// stripCodeFence removes a markdown code fence some models wrap JSON output in
+func stripCodeFence(content string) string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "```") {
+		return content
 	}
 
-	return buf.String()
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+	return strings.TrimSpace(content)
 }
@@ -1,11 +1,283 @@
 package ai
 
 import (
-	_ "embed"
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"ypeskov/kkal-tracker/internal/config"
+)
+
+//go:embed prompts/*.txt
+var defaultPromptsFS embed.FS
+
+// Prompt template kinds. Each kind is stored as <kind>.txt, with optional
+// per-language variants named <kind>.<language code>.txt (e.g. system.uk_UA.txt).
+const (
+	PromptSystem         = "system"
+	PromptUser           = "user"
+	PromptMealPlanSystem = "meal_plan_system"
+	PromptMealPlanUser   = "meal_plan_user"
 )
 
-//go:embed prompts/system.txt
-var SystemPromptTemplate string
+var promptKinds = []string{PromptSystem, PromptUser, PromptMealPlanSystem, PromptMealPlanUser}
+
+// PromptData is the data available to every prompt template
+type PromptData struct {
+	// Profile
+	Language      string // Full language name, e.g. "Ukrainian"
+	LanguageCode  string
+	Age           int
+	Height        float64
+	Gender        string
+	ActivityLevel string
+	BMI           float64
+	BMICategory   string
+
+	// Weight goal
+	CurrentWeight float64
+	TargetWeight  float64
+	TargetDate    string
+	GoalProgress  float64
+
+	// Daily targets, zero when the profile is incomplete
+	Targets NutritionTargets
+
+	// Analysis
+	PeriodDays    int
+	NutritionData []NutritionDataPoint
+	WeightData    []WeightDataPoint
+	Query         string
+
+	// Meal plans
+	Days        int
+	MealsPerDay int
+	Catalog     []CatalogItem
+	Preferences string
+}
+
+// PromptSet holds parsed prompt templates: the embedded defaults, optionally
+// overridden or extended with per-language variants from a directory
+type PromptSet struct {
+	templates   map[string]*template.Template // "<kind>" or "<kind>.<language code>"
+	fingerprint string
+}
+
+// LoadPrompts parses the embedded prompt templates and applies overrides from dir
+// (if not empty). Every template is executed against sample data, so a broken
+// template fails at startup instead of producing an empty prompt at runtime.
+func LoadPrompts(dir string) (*PromptSet, error) {
+	sources := make(map[string]string)
+
+	err := fs.WalkDir(defaultPromptsFS, "prompts", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := defaultPromptsFS.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		sources[strings.TrimSuffix(d.Name(), ".txt")] = string(content)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded prompts: %w", err)
+	}
+
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompts directory %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".txt") {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read prompt %s: %w", entry.Name(), err)
+			}
+			sources[strings.TrimSuffix(entry.Name(), ".txt")] = string(content)
+		}
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	set := &PromptSet{templates: make(map[string]*template.Template, len(sources))}
+	hash := sha256.New()
+
+	for _, name := range names {
+		kind, lang, _ := strings.Cut(name, ".")
+		if !isPromptKind(kind) {
+			return nil, fmt.Errorf("unknown prompt template %q", name+".txt")
+		}
+		if _, ok := config.SupportedLanguages[lang]; lang != "" && !ok {
+			return nil, fmt.Errorf("prompt template %q: unsupported language %q", name+".txt", lang)
+		}
+
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(sources[name])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse prompt template %q: %w", name+".txt", err)
+		}
+		if err := validatePromptTemplate(tmpl); err != nil {
+			return nil, fmt.Errorf("invalid prompt template %q: %w", name+".txt", err)
+		}
+
+		set.templates[name] = tmpl
+		fmt.Fprintf(hash, "%s\x00%s\x00", name, sources[name])
+	}
+
+	for _, kind := range promptKinds {
+		if _, ok := set.templates[kind]; !ok {
+			return nil, fmt.Errorf("missing prompt template %q", kind+".txt")
+		}
+	}
+
+	set.fingerprint = hex.EncodeToString(hash.Sum(nil))
+	return set, nil
+}
+
+// Render executes the template of the given kind, preferring the variant for the language
+func (p *PromptSet) Render(kind, languageCode string, data PromptData) (string, error) {
+	tmpl, ok := p.templates[kind+"."+languageCode]
+	if !ok {
+		tmpl, ok = p.templates[kind]
+	}
+	if !ok {
+		return "", fmt.Errorf("missing prompt template %q", kind)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute prompt template %q: %w", tmpl.Name(), err)
+	}
+
+	prompt := strings.TrimSpace(buf.String())
+	if prompt == "" {
+		return "", fmt.Errorf("prompt template %q produced an empty prompt", tmpl.Name())
+	}
+	return prompt, nil
+}
+
+// Fingerprint identifies the loaded templates; it changes whenever any template changes
+func (p *PromptSet) Fingerprint() string {
+	return p.fingerprint
+}
+
+// validatePromptTemplate executes a template against empty and fully populated sample data
+func validatePromptTemplate(tmpl *template.Template) error {
+	samples := []PromptData{
+		{Language: config.GetLanguageName(config.DefaultLanguageCode), LanguageCode: config.DefaultLanguageCode},
+		samplePromptData(),
+	}
+
+	for _, data := range samples {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return err
+		}
+		if strings.TrimSpace(buf.String()) == "" {
+			return fmt.Errorf("template produces an empty prompt")
+		}
+	}
+
+	return nil
+}
+
+// samplePromptData returns data with every field set, used to validate templates
+func samplePromptData() PromptData {
+	return PromptData{
+		Language:      config.GetLanguageName(config.DefaultLanguageCode),
+		LanguageCode:  config.DefaultLanguageCode,
+		Age:           35,
+		Height:        175,
+		Gender:        "female",
+		ActivityLevel: "moderate",
+		BMI:           24.5,
+		BMICategory:   "normal",
+		CurrentWeight: 75,
+		TargetWeight:  70,
+		TargetDate:    "2030-01-01",
+		GoalProgress:  40,
+		Targets:       NutritionTargets{Calories: 1900, Proteins: 135, Fats: 53, Carbs: 216},
+		PeriodDays:    7,
+		NutritionData: []NutritionDataPoint{{
+			Date: "2030-01-01", Calories: 1800, Fats: 60, Carbs: 200, Proteins: 100,
+			FoodItems: []FoodItem{{Name: "Oatmeal", Weight: 80, Calories: 300}},
+		}},
+		WeightData:  []WeightDataPoint{{Date: "2030-01-01", Weight: 75}},
+		Query:       "Sample question",
+		Days:        3,
+		MealsPerDay: 4,
+		Catalog:     []CatalogItem{{ID: 1, Name: "Oatmeal", KcalPer100g: 370, Fats: 7, Carbs: 60, Proteins: 13}},
+		Preferences: "No fish",
+	}
+}
+
+// isPromptKind reports whether kind is a known prompt template kind
+func isPromptKind(kind string) bool {
+	for _, k := range promptKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// newPromptData fills the profile and goal part of PromptData from the user context
+func newPromptData(userCtx UserContext) PromptData {
+	lang := userCtx.Language
+	if lang == "" {
+		lang = config.DefaultLanguageCode
+	}
+
+	data := PromptData{
+		Language:     config.GetLanguageName(lang),
+		LanguageCode: lang,
+		BMICategory:  userCtx.BMICategory,
+	}
+	if userCtx.Age != nil {
+		data.Age = *userCtx.Age
+	}
+	if userCtx.Height != nil {
+		data.Height = *userCtx.Height
+	}
+	if userCtx.Gender != nil {
+		data.Gender = *userCtx.Gender
+	}
+	if userCtx.ActivityLevel != nil {
+		data.ActivityLevel = *userCtx.ActivityLevel
+	}
+	if userCtx.BMI != nil {
+		data.BMI = *userCtx.BMI
+	}
+	if userCtx.CurrentWeight != nil {
+		data.CurrentWeight = *userCtx.CurrentWeight
+	}
+	if userCtx.TargetWeight != nil {
+		data.TargetWeight = *userCtx.TargetWeight
+	}
+	if userCtx.TargetDate != nil {
+		data.TargetDate = *userCtx.TargetDate
+	}
+	if userCtx.GoalProgress != nil {
+		data.GoalProgress = *userCtx.GoalProgress
+	}
+	if userCtx.Targets != nil {
+		data.Targets = *userCtx.Targets
+	}
 
-//go:embed prompts/user.txt
-var UserPromptTemplate string
+	return data
+}
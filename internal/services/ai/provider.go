@@ -7,6 +7,9 @@ type Provider interface {
 	// Analyze performs nutrition and weight analysis
 	Analyze(ctx context.Context, model string, req AnalysisRequest) (*AnalysisResponse, error)
 
+	// GenerateMealPlan drafts a meal plan from the user's targets and catalog
+	GenerateMealPlan(ctx context.Context, model string, req MealPlanRequest) (*MealPlanDraft, error)
+
 	// GetProviderName returns the provider identifier
 	GetProviderName() string
 
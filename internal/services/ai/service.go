@@ -2,23 +2,53 @@ package ai
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"time"
 
 	"ypeskov/kkal-tracker/internal/config"
+	"ypeskov/kkal-tracker/internal/repositories"
+	"ypeskov/kkal-tracker/internal/services/batch"
+	"ypeskov/kkal-tracker/internal/services/calorie"
+	"ypeskov/kkal-tracker/internal/services/metrics"
 )
 
 // Service handles AI-related business logic
 type Service struct {
-	config   *config.Config
-	provider Provider
-	logger   *slog.Logger
+	config         *config.Config
+	prompts        *PromptSet
+	provider       Provider
+	mealPlanRepo   repositories.MealPlanRepository
+	cacheRepo      repositories.AIAnalysisCacheRepository
+	ingredientRepo repositories.IngredientRepository
+	calorieService calorie.Servicer
+	batchService   batch.Servicer
+	metricsService metrics.Servicer
+	logger         *slog.Logger
 }
 
 // New creates a new AI service instance
-func New(cfg *config.Config, logger *slog.Logger) *Service {
+func New(
+	cfg *config.Config,
+	prompts *PromptSet,
+	mealPlanRepo repositories.MealPlanRepository,
+	cacheRepo repositories.AIAnalysisCacheRepository,
+	ingredientRepo repositories.IngredientRepository,
+	calorieService calorie.Servicer,
+	batchService batch.Servicer,
+	metricsService metrics.Servicer,
+	logger *slog.Logger,
+) *Service {
 	svc := &Service{
-		config: cfg,
-		logger: logger.With("service", "ai"),
+		config:         cfg,
+		prompts:        prompts,
+		mealPlanRepo:   mealPlanRepo,
+		cacheRepo:      cacheRepo,
+		ingredientRepo: ingredientRepo,
+		calorieService: calorieService,
+		batchService:   batchService,
+		metricsService: metricsService,
+		logger:         logger.With("service", "ai"),
 	}
 	svc.initProvider()
 	return svc
@@ -32,6 +62,7 @@ func (s *Service) initProvider() {
 			s.config.AI.BaseURL,
 			s.config.AI.UseMaxTokens,
 			s.config.AI.MaxTokens,
+			s.prompts,
 			s.logger,
 		)
 		s.logger.Info("OpenAI provider initialized",
@@ -41,7 +72,9 @@ func (s *Service) initProvider() {
 	}
 }
 
-// Analyze performs AI analysis using the configured provider
+// Analyze performs AI analysis using the configured provider.
+// Results are cached by a hash of the input data, so repeating a request for
+// an unchanged period returns the stored analysis without calling the provider.
 func (s *Service) Analyze(ctx context.Context, req AnalysisRequest) (*AnalysisResponse, error) {
 	s.logger.Debug("Starting AI analysis",
 		slog.Int("period_days", req.PeriodDays))
@@ -50,6 +83,18 @@ func (s *Service) Analyze(ctx context.Context, req AnalysisRequest) (*AnalysisRe
 		return nil, ErrProviderNotAvailable
 	}
 
+	s.enrichUserContext(req.UserID, &req.UserContext)
+
+	inputHash, err := s.analysisInputHash(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached := s.getCachedAnalysis(req.UserID, inputHash); cached != nil {
+		s.logger.Info("AI analysis served from cache", slog.Int("user_id", req.UserID))
+		return cached, nil
+	}
+
 	// Perform analysis using the model from config
 	response, err := s.provider.Analyze(ctx, s.config.AI.Model, req)
 	if err != nil {
@@ -59,6 +104,16 @@ func (s *Service) Analyze(ctx context.Context, req AnalysisRequest) (*AnalysisRe
 		return nil, err
 	}
 
+	if err := validateAnalysis(response, req); err != nil {
+		s.logger.Error("Analysis rejected",
+			slog.String("model", s.config.AI.Model),
+			slog.String("error", err.Error()))
+		return nil, err
+	}
+	response.GeneratedAt = time.Now().UTC()
+
+	s.cacheAnalysis(req.UserID, inputHash, response)
+
 	s.logger.Info("AI analysis completed",
 		slog.String("model", s.config.AI.Model),
 		slog.Int("tokens", response.TokensUsed),
@@ -76,3 +131,30 @@ func (s *Service) IsAvailable() bool {
 func (s *Service) GetModel() string {
 	return s.config.AI.Model
 }
+
+// enrichUserContext adds calculated health metrics and daily targets to the user context.
+// Missing profile data is not an error: the prompts simply omit these values.
+func (s *Service) enrichUserContext(userID int, userCtx *UserContext) {
+	healthMetrics, err := s.metricsService.GetHealthMetrics(userID)
+	if err != nil {
+		s.logger.Warn("Failed to get health metrics for AI context", slog.String("error", err.Error()))
+	} else {
+		userCtx.BMI = healthMetrics.BMI
+		userCtx.BMICategory = healthMetrics.BMICategory
+	}
+
+	targets, err := s.metricsService.GetDailyTargets(userID)
+	if err != nil {
+		if !errors.Is(err, metrics.ErrInsufficientData) {
+			s.logger.Warn("Failed to get daily targets for AI context", slog.String("error", err.Error()))
+		}
+		return
+	}
+
+	userCtx.Targets = &NutritionTargets{
+		Calories: targets.Calories,
+		Proteins: targets.Proteins,
+		Fats:     targets.Fats,
+		Carbs:    targets.Carbs,
+	}
+}
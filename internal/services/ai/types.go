@@ -1,11 +1,20 @@
 package ai
 
-import "errors"
+import (
+	"errors"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
 
 // Service errors
 var (
 	ErrProviderNotAvailable = errors.New("AI provider is not available")
 	ErrAnalysisFailed       = errors.New("AI analysis failed")
+	ErrTargetsUnavailable   = errors.New("daily targets are not available")
+	ErrInvalidMealPlan      = errors.New("AI returned an unusable meal plan")
+	ErrMealPlanNotFound     = errors.New("meal plan not found")
+	ErrInvalidPlanDay       = errors.New("meal plan day out of range")
 )
 
 // FoodItem represents a single food entry for AI analysis
@@ -33,17 +42,23 @@ type WeightDataPoint struct {
 
 // UserContext provides user profile information for personalized analysis
 type UserContext struct {
-	Age           *int     `json:"age,omitempty"`
-	Height        *float64 `json:"height,omitempty"`
-	Language      string   `json:"language"`
-	TargetWeight  *float64 `json:"target_weight,omitempty"`
-	TargetDate    *string  `json:"target_date,omitempty"`
-	CurrentWeight *float64 `json:"current_weight,omitempty"`
-	GoalProgress  *float64 `json:"goal_progress,omitempty"` // Percentage 0-100
+	Age           *int              `json:"age,omitempty"`
+	Height        *float64          `json:"height,omitempty"`
+	Gender        *string           `json:"gender,omitempty"`
+	ActivityLevel *string           `json:"activity_level,omitempty"`
+	BMI           *float64          `json:"bmi,omitempty"`
+	BMICategory   string            `json:"bmi_category,omitempty"`
+	Targets       *NutritionTargets `json:"targets,omitempty"`
+	Language      string            `json:"language"`
+	TargetWeight  *float64          `json:"target_weight,omitempty"`
+	TargetDate    *string           `json:"target_date,omitempty"`
+	CurrentWeight *float64          `json:"current_weight,omitempty"`
+	GoalProgress  *float64          `json:"goal_progress,omitempty"` // Percentage 0-100
 }
 
 // AnalysisRequest represents a request for AI analysis
 type AnalysisRequest struct {
+	UserID        int                  `json:"-"`
 	UserContext   UserContext          `json:"user_context"`
 	NutritionData []NutritionDataPoint `json:"nutrition_data"`
 	WeightData    []WeightDataPoint    `json:"weight_data"`
@@ -51,10 +66,147 @@ type AnalysisRequest struct {
 	PeriodDays    int                  `json:"period_days"`
 }
 
-// AnalysisResponse represents the AI analysis result
+// Finding is an observation about the analyzed period tied to specific days and foods
+type Finding struct {
+	Title  string   `json:"title"`
+	Detail string   `json:"detail"`
+	Dates  []string `json:"dates,omitempty"` // YYYY-MM-DD, only dates present in the input data
+	Foods  []string `json:"foods,omitempty"` // Only foods present in the input data
+}
+
+// Recommendation is an actionable suggestion for the user
+type Recommendation struct {
+	Action    string `json:"action"`
+	Rationale string `json:"rationale,omitempty"`
+	Priority  string `json:"priority"` // high, medium or low
+}
+
+// RiskFlag marks a potentially unhealthy pattern in the data
+type RiskFlag struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// Recommendation priorities
+const (
+	PriorityHigh   = "high"
+	PriorityMedium = "medium"
+	PriorityLow    = "low"
+)
+
+// RiskFlagCodes lists risk flag codes the model may return; anything else becomes RiskOther
+var RiskFlagCodes = map[string]bool{
+	"very_low_intake":   true,
+	"excessive_deficit": true,
+	"excessive_surplus": true,
+	"rapid_weight_loss": true,
+	"rapid_weight_gain": true,
+	"low_protein":       true,
+	"high_fat":          true,
+	"high_sugar":        true,
+	"irregular_eating":  true,
+	"unrealistic_goal":  true,
+}
+
+// RiskOther is used for risk flags with an unknown code
+const RiskOther = "other"
+
+// AnalysisResponse represents the validated, structured AI analysis result
 type AnalysisResponse struct {
-	Analysis   string `json:"analysis"`
-	Model      string `json:"model"`
-	TokensUsed int    `json:"tokens_used,omitempty"`
-	DurationMs int64  `json:"duration_ms"`
+	Summary         string           `json:"summary"`
+	Findings        []Finding        `json:"findings"`
+	Recommendations []Recommendation `json:"recommendations"`
+	RiskFlags       []RiskFlag       `json:"risk_flags"`
+	Model           string           `json:"model"`
+	TokensUsed      int              `json:"tokens_used,omitempty"`
+	DurationMs      int64            `json:"duration_ms"`
+	Cached          bool             `json:"cached"`
+	GeneratedAt     time.Time        `json:"generated_at"`
+}
+
+// NutritionTargets are recommended daily calorie and macronutrient targets
+type NutritionTargets struct {
+	Calories float64 `json:"calories"`
+	Proteins float64 `json:"proteins"`
+	Fats     float64 `json:"fats"`
+	Carbs    float64 `json:"carbs"`
+}
+
+// CatalogItem is an ingredient from the user's catalog offered to the model.
+// Missing macronutrients are sent as zero.
+type CatalogItem struct {
+	ID          int     `json:"id"`
+	Name        string  `json:"name"`
+	KcalPer100g float64 `json:"kcal_per_100g"`
+	Fats        float64 `json:"fats"`
+	Carbs       float64 `json:"carbs"`
+	Proteins    float64 `json:"proteins"`
+}
+
+// MealPlanRequest represents a request to the provider for a meal plan draft
+type MealPlanRequest struct {
+	UserContext UserContext      `json:"user_context"`
+	Targets     NutritionTargets `json:"targets"`
+	Catalog     []CatalogItem    `json:"catalog"`
+	Days        int              `json:"days"`
+	MealsPerDay int              `json:"meals_per_day"`
+	Preferences string           `json:"preferences,omitempty"`
+}
+
+// MealPlanDraft is the raw plan returned by the model before validation
+type MealPlanDraft struct {
+	Days       []DraftDay `json:"days"`
+	Notes      string     `json:"notes"`
+	Model      string     `json:"-"`
+	TokensUsed int        `json:"-"`
+	DurationMs int64      `json:"-"`
+}
+
+// DraftDay is a single day of a meal plan draft
+type DraftDay struct {
+	Day   int         `json:"day"`
+	Meals []DraftMeal `json:"meals"`
+}
+
+// DraftMeal is a single meal of a meal plan draft
+type DraftMeal struct {
+	MealType string      `json:"meal_type"`
+	Items    []DraftItem `json:"items"`
+}
+
+// DraftItem is a food portion suggested by the model. Its nutrition always
+// comes from the matching catalog ingredient.
+type DraftItem struct {
+	IngredientID *int    `json:"ingredient_id"`
+	Name         string  `json:"name"`
+	Grams        float64 `json:"grams"`
+}
+
+// GenerateMealPlanRequest represents a user's request to generate a meal plan
+type GenerateMealPlanRequest struct {
+	UserID      int
+	UserContext UserContext
+	Days        int
+	MealsPerDay int
+	Preferences string
+}
+
+// MealPlanDaySummary contains validated totals for one day of a meal plan
+type MealPlanDaySummary struct {
+	Day              int     `json:"day"`
+	Calories         int     `json:"calories"`
+	Proteins         float64 `json:"proteins"`
+	Fats             float64 `json:"fats"`
+	Carbs            float64 `json:"carbs"`
+	DeviationPercent float64 `json:"deviation_percent"` // Calorie deviation from the target
+	WithinTolerance  bool    `json:"within_tolerance"`
+}
+
+// MealPlanResult is a stored meal plan together with its per-day totals
+type MealPlanResult struct {
+	Plan       *models.MealPlan     `json:"plan"`
+	Days       []MealPlanDaySummary `json:"days"`
+	Warnings   []string             `json:"warnings,omitempty"`
+	TokensUsed int                  `json:"tokens_used,omitempty"`
+	DurationMs int64                `json:"duration_ms,omitempty"`
 }
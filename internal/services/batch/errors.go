@@ -0,0 +1,14 @@
+package batch
+
+import "errors"
+
+var (
+	ErrInvalidAction     = errors.New("action must be create, update or delete")
+	ErrInvalidType       = errors.New("type must be calorie_entry or weight")
+	ErrMissingID         = errors.New("id is required for update and delete")
+	ErrMissingData       = errors.New("data is required for create and update")
+	ErrInvalidWeight     = errors.New("weight must be between 1 and 500")
+	ErrInvalidMealTime   = errors.New("invalid meal_datetime format, use ISO 8601")
+	ErrInvalidRecordedAt = errors.New("invalid recorded_at format, expected YYYY-MM-DD")
+	ErrNotApplied        = errors.New("not applied because another operation of the atomic batch failed")
+)
@@ -0,0 +1,6 @@
+package batch
+
+// Servicer defines the batch write service contract used by handlers.
+type Servicer interface {
+	Apply(userID int, req *BatchRequest) (*BatchResult, error)
+}
@@ -0,0 +1,226 @@
+package batch
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+	calorieservice "ypeskov/kkal-tracker/internal/services/calorie"
+	webhookservice "ypeskov/kkal-tracker/internal/services/webhook"
+)
+
+// Weigh-in limits, the same as for POST /api/weight
+const (
+	minWeight = 1
+	maxWeight = 500
+)
+
+// Service applies batches of calorie entry and weigh-in writes
+type Service struct {
+	batchRepo      repositories.BatchRepository
+	calorieService calorieservice.Servicer
+	events         webhookservice.Publisher
+	logger         *slog.Logger
+}
+
+// New creates a new batch service
+func New(batchRepo repositories.BatchRepository, calorieService calorieservice.Servicer, events webhookservice.Publisher, logger *slog.Logger) *Service {
+	return &Service{
+		batchRepo:      batchRepo,
+		calorieService: calorieService,
+		events:         events,
+		logger:         logger.With("service", "batch"),
+	}
+}
+
+// Apply validates the operations and applies the valid ones in one
+// transaction. Invalid operations and operations on missing entities are
+// reported in their results; an atomic batch is then not applied at all.
+func (s *Service) Apply(userID int, req *BatchRequest) (*BatchResult, error) {
+	s.logger.Debug("Apply called", "user_id", userID, "operations", len(req.Operations), "atomic", req.Atomic)
+
+	ops := make([]*models.BatchOperation, len(req.Operations))
+	invalid := false
+	for i, op := range req.Operations {
+		ops[i] = s.prepare(userID, op)
+		if ops[i].Err != nil {
+			invalid = true
+		}
+	}
+
+	committed := false
+	if !req.Atomic || !invalid {
+		var err error
+		committed, err = s.batchRepo.Apply(userID, ops, req.Atomic)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply batch: %w", err)
+		}
+	}
+
+	result := &BatchResult{Committed: committed, Results: make([]*OperationResult, 0, len(ops))}
+	applied := 0
+	for i, op := range ops {
+		item := operationResult(i, op, committed)
+		if item.Error == "" {
+			applied++
+		}
+		result.Results = append(result.Results, item)
+	}
+
+	if committed {
+		s.publish(ops)
+	}
+
+	s.logger.Info("Batch applied", "user_id", userID, "operations", len(ops), "applied", applied, "committed", committed)
+	return result, nil
+}
+
+// prepare turns a requested operation into one the repository can apply, or
+// one with the validation error
+func (s *Service) prepare(userID int, op *Operation) *models.BatchOperation {
+	prepared := &models.BatchOperation{Action: op.Action, Entity: op.Type, ID: op.ID, Version: op.Version}
+
+	switch op.Action {
+	case models.BatchActionCreate:
+		prepared.ID = 0
+		prepared.Version = 0
+	case models.BatchActionUpdate, models.BatchActionDelete:
+		if op.ID <= 0 {
+			prepared.Err = ErrMissingID
+			return prepared
+		}
+	default:
+		prepared.Err = ErrInvalidAction
+		return prepared
+	}
+
+	if op.Type != models.SyncEntityCalorieEntry && op.Type != models.SyncEntityWeight {
+		prepared.Err = ErrInvalidType
+		return prepared
+	}
+	if op.Action == models.BatchActionDelete {
+		return prepared
+	}
+	if op.Data == nil {
+		prepared.Err = ErrMissingData
+		return prepared
+	}
+
+	if op.Type == models.SyncEntityCalorieEntry {
+		prepared.CalorieEntry, prepared.Err = s.prepareCalorieEntry(userID, prepared.ID, op.Data)
+	} else {
+		prepared.Weight, prepared.Err = prepareWeight(op.Data)
+	}
+	return prepared
+}
+
+// prepareCalorieEntry validates the values of a calorie entry like POST /api/calories does
+func (s *Service) prepareCalorieEntry(userID, entryID int, data *OperationData) (*models.CalorieEntry, error) {
+	mealDatetime := data.MealTime
+	if mealDatetime.IsZero() {
+		var err error
+		mealDatetime, err = time.Parse(time.RFC3339, data.MealDatetime)
+		if err != nil {
+			return nil, ErrInvalidMealTime
+		}
+	}
+
+	return s.calorieService.PrepareEntry(&calorieservice.UpdateEntryRequest{
+		EntryID:      entryID,
+		UserID:       userID,
+		Food:         data.Food,
+		Calories:     data.Calories,
+		Weight:       data.Weight,
+		KcalPer100g:  data.KcalPer100g,
+		Fats:         data.Fats,
+		Carbs:        data.Carbs,
+		Proteins:     data.Proteins,
+		Nutrients:    data.Nutrients,
+		MealDatetime: mealDatetime,
+		Quantity:     data.Quantity,
+		Unit:         data.Unit,
+	})
+}
+
+// prepareWeight validates the values of a weigh-in like POST /api/weight does
+func prepareWeight(data *OperationData) (*models.WeightHistory, error) {
+	if data.Weight < minWeight || data.Weight > maxWeight {
+		return nil, ErrInvalidWeight
+	}
+
+	now := time.Now()
+	entry := &models.WeightHistory{Weight: data.Weight, RecordedAt: now, CreatedAt: now}
+	if data.RecordedAt != "" {
+		recordedAt, err := time.Parse("2006-01-02", data.RecordedAt)
+		if err != nil {
+			return nil, ErrInvalidRecordedAt
+		}
+		entry.RecordedAt = recordedAt
+	}
+	return entry, nil
+}
+
+// operationResult reports the outcome of an operation
+func operationResult(index int, op *models.BatchOperation, committed bool) *OperationResult {
+	result := &OperationResult{Index: index, ID: op.ID}
+
+	switch {
+	case errors.Is(op.Err, repositories.ErrNotFound):
+		result.Status = http.StatusNotFound
+		result.Error = fmt.Sprintf("%s not found", op.Entity)
+	case errors.Is(op.Err, repositories.ErrVersionConflict):
+		result.Status = http.StatusPreconditionFailed
+		result.Error = fmt.Sprintf("%s was changed since version %d", op.Entity, op.Version)
+	case op.Err != nil:
+		result.Status = http.StatusBadRequest
+		result.Error = op.Err.Error()
+	case !committed:
+		result.Status = http.StatusFailedDependency
+		result.Error = ErrNotApplied.Error()
+	case op.Action == models.BatchActionCreate:
+		result.Status = http.StatusCreated
+		result.Data = operationData(op)
+	case op.Action == models.BatchActionUpdate:
+		result.Status = http.StatusOK
+		result.Data = operationData(op)
+	default:
+		result.Status = http.StatusNoContent
+	}
+
+	// A create that was rolled back has no ID
+	if op.Action == models.BatchActionCreate && result.Error != "" {
+		result.ID = 0
+	}
+	return result
+}
+
+// operationData returns the saved entity of an operation
+func operationData(op *models.BatchOperation) any {
+	if op.Entity == models.SyncEntityWeight {
+		return op.Weight
+	}
+	return op.CalorieEntry
+}
+
+// publish runs what follows a saved write: new foods are added to the user's
+// ingredients and webhooks are told about the changes
+func (s *Service) publish(ops []*models.BatchOperation) {
+	for _, op := range ops {
+		if op.Err != nil {
+			continue
+		}
+		switch {
+		case op.Entity == models.SyncEntityCalorieEntry && op.Action == models.BatchActionCreate:
+			s.calorieService.AddIngredient(op.CalorieEntry)
+			s.events.CalorieEntryCreated(op.CalorieEntry)
+		case op.Entity == models.SyncEntityCalorieEntry && op.Action == models.BatchActionUpdate:
+			s.events.CalorieEntryUpdated(op.CalorieEntry)
+		case op.Entity == models.SyncEntityWeight && op.Action == models.BatchActionCreate:
+			s.events.WeightCreated(op.Weight)
+		}
+	}
+}
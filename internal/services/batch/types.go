@@ -0,0 +1,60 @@
+package batch
+
+import (
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// BatchRequest is a list of writes applied in order in one transaction. With
+// Atomic, a failed operation leaves every other one unapplied as well.
+type BatchRequest struct {
+	Operations []*Operation
+	Atomic     bool
+}
+
+// Operation creates, updates or deletes a calorie entry or weigh-in
+type Operation struct {
+	Action  string // Values of the models.BatchAction constants
+	Type    string // models.SyncEntityCalorieEntry or models.SyncEntityWeight
+	ID      int    // For updates and deletes
+	Version int    // Version an updated or deleted entity must have; 0 skips the check
+	Data    *OperationData
+}
+
+// OperationData holds the values of a created or updated entity. Calorie
+// entries use the fields of POST /api/calories, with Weight in grams; weigh-ins
+// use Weight in kg and RecordedAt.
+type OperationData struct {
+	Food         string
+	Calories     int
+	Weight       float64
+	KcalPer100g  float64
+	Fats         *float64
+	Carbs        *float64
+	Proteins     *float64
+	Nutrients    models.Nutrients
+	MealDatetime string    // RFC 3339
+	MealTime     time.Time // Used instead of MealDatetime when set, keeping its location
+	Quantity     *float64
+	Unit         string
+	RecordedAt   string // YYYY-MM-DD; today when empty
+}
+
+// BatchResult has one result per operation, in the order of the request
+type BatchResult struct {
+	Committed bool               `json:"committed"`
+	Results   []*OperationResult `json:"results"`
+}
+
+// OperationResult uses the HTTP status the operation would have had as a
+// request of its own: 201, 200 or 204 when it was applied, 400, 404 or 412
+// when it failed, and 424 when an atomic batch was not applied because of
+// another operation.
+type OperationResult struct {
+	Index  int    `json:"index"`
+	Status int    `json:"status"`
+	ID     int    `json:"id,omitempty"`
+	Data   any    `json:"data,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,10 @@
+package calendar
+
+import "errors"
+
+var (
+	ErrFeedNotFound    = errors.New("calendar feed not found")
+	ErrInvalidToken    = errors.New("invalid calendar feed token")
+	ErrInvalidTimezone = errors.New("unknown time zone")
+	ErrInvalidPastDays = errors.New("past_days must be between 1 and 365")
+)
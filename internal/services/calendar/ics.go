@@ -0,0 +1,243 @@
+package calendar
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"ypeskov/kkal-tracker/internal/i18n"
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// iCalendar (RFC 5545) settings
+const (
+	icsMaxLineOctets = 75 // Longer content lines are folded
+	icsMealDuration  = "PT30M"
+	icsRefresh       = "PT1H" // How often clients are asked to poll the feed
+	icsDateTimeUTC   = "20060102T150405Z"
+	icsDateTimeLocal = "20060102T150405"
+	icsDate          = "20060102"
+)
+
+// icsCalendar writes the content lines of an iCalendar document
+type icsCalendar struct {
+	t     *i18n.Translator
+	lang  string
+	loc   *time.Location
+	host  string
+	stamp time.Time
+	b     strings.Builder
+}
+
+// newICSCalendar creates a calendar for the given language and time zone
+func newICSCalendar(lang string, loc *time.Location, host string, stamp time.Time) *icsCalendar {
+	return &icsCalendar{
+		t:     i18n.GetTranslator(),
+		lang:  lang,
+		loc:   loc,
+		host:  host,
+		stamp: stamp.UTC(),
+	}
+}
+
+// tr is a shortcut for translator.Get
+func (c *icsCalendar) tr(key string) string {
+	return c.t.Get(c.lang, key)
+}
+
+// render writes the meals and weigh-ins of the window [start, end) as a VCALENDAR
+func (c *icsCalendar) render(start, end time.Time, meals []*models.CalorieEntry, weights []*models.WeightHistory) []byte {
+	c.line("BEGIN", "VCALENDAR")
+	c.line("VERSION", "2.0")
+	c.line("PRODID", "-//kkal-tracker//Calendar Feed//EN")
+	c.line("CALSCALE", "GREGORIAN")
+	c.line("METHOD", "PUBLISH")
+	c.text("X-WR-CALNAME", c.tr("calendar.name"))
+	c.line("X-WR-TIMEZONE", c.loc.String())
+	c.line("REFRESH-INTERVAL;VALUE=DURATION", icsRefresh)
+	c.line("X-PUBLISHED-TTL", icsRefresh)
+	if !c.utc() {
+		c.timezone(start, end)
+	}
+
+	sort.Slice(meals, func(i, j int) bool {
+		return meals[i].MealDatetime.Before(meals[j].MealDatetime)
+	})
+	for _, meal := range meals {
+		c.meal(meal)
+	}
+	sort.Slice(weights, func(i, j int) bool {
+		return weights[i].RecordedAt.Before(weights[j].RecordedAt)
+	})
+	for _, weight := range weights {
+		c.weighIn(weight)
+	}
+
+	c.line("END", "VCALENDAR")
+	return []byte(c.b.String())
+}
+
+// meal writes a meal as a half-hour event at the time it was eaten
+func (c *icsCalendar) meal(entry *models.CalorieEntry) {
+	kcal, g := c.tr("calendar.units.kcal"), c.tr("calendar.units.g")
+
+	description := []string{
+		fmt.Sprintf("%s: %d %s", c.tr("calendar.calories"), entry.Calories, kcal),
+		fmt.Sprintf("%s: %.0f %s", c.tr("calendar.portion"), entry.Weight, g),
+	}
+	macros := []struct {
+		key   string
+		value *float64
+	}{
+		{"calendar.proteins", entry.Proteins},
+		{"calendar.fats", entry.Fats},
+		{"calendar.carbs", entry.Carbs},
+	}
+	for _, macro := range macros {
+		if macro.value != nil {
+			description = append(description,
+				fmt.Sprintf("%s: %.1f %s", c.tr(macro.key), *macro.value*entry.Weight/100, g))
+		}
+	}
+
+	c.line("BEGIN", "VEVENT")
+	c.line("UID", fmt.Sprintf("meal-%d@%s", entry.ID, c.host))
+	c.line("DTSTAMP", c.stamp.Format(icsDateTimeUTC))
+	c.line("CREATED", entry.CreatedAt.UTC().Format(icsDateTimeUTC))
+	c.line("LAST-MODIFIED", entry.UpdatedAt.UTC().Format(icsDateTimeUTC))
+	c.dateTime("DTSTART", entry.MealDatetime)
+	c.line("DURATION", icsMealDuration)
+	c.text("SUMMARY", fmt.Sprintf("%s · %d %s", entry.Food, entry.Calories, kcal))
+	c.text("DESCRIPTION", strings.Join(description, "\n"))
+	c.line("TRANSP", "TRANSPARENT")
+	c.line("END", "VEVENT")
+}
+
+// weighIn writes a weigh-in as an all-day event on the day it was recorded
+func (c *icsCalendar) weighIn(entry *models.WeightHistory) {
+	day := entry.RecordedAt.UTC()
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+
+	c.line("BEGIN", "VEVENT")
+	c.line("UID", fmt.Sprintf("weight-%d@%s", entry.ID, c.host))
+	c.line("DTSTAMP", c.stamp.Format(icsDateTimeUTC))
+	c.line("CREATED", entry.CreatedAt.UTC().Format(icsDateTimeUTC))
+	c.line("DTSTART;VALUE=DATE", day.Format(icsDate))
+	c.line("DTEND;VALUE=DATE", day.AddDate(0, 0, 1).Format(icsDate))
+	c.text("SUMMARY", fmt.Sprintf("%s: %.1f %s", c.tr("calendar.weigh_in"), entry.Weight, c.tr("calendar.units.kg")))
+	c.line("TRANSP", "TRANSPARENT")
+	c.line("END", "VEVENT")
+}
+
+// timezone writes a VTIMEZONE with the offsets that apply between start and end
+func (c *icsCalendar) timezone(start, end time.Time) {
+	c.line("BEGIN", "VTIMEZONE")
+	c.line("TZID", c.loc.String())
+
+	at := start.In(c.loc)
+	for {
+		onset, next := at.ZoneBounds()
+		name, offset := at.Zone()
+		from := offset
+		if onset.IsZero() {
+			// The zone has always had this offset
+			onset = time.Date(1970, 1, 1, 0, 0, 0, 0, c.loc)
+		} else {
+			_, from = onset.Add(-time.Second).Zone()
+		}
+
+		kind := "STANDARD"
+		if at.IsDST() {
+			kind = "DAYLIGHT"
+		}
+		c.line("BEGIN", kind)
+		// The onset is given in the local time that was in effect before it
+		c.line("DTSTART", onset.In(time.FixedZone("", from)).Format(icsDateTimeLocal))
+		c.line("TZOFFSETFROM", formatOffset(from))
+		c.line("TZOFFSETTO", formatOffset(offset))
+		c.text("TZNAME", name)
+		c.line("END", kind)
+
+		if next.IsZero() || !next.Before(end) {
+			break
+		}
+		at = next
+	}
+
+	c.line("END", "VTIMEZONE")
+}
+
+// dateTime writes a date-time property in the feed's time zone, or in UTC
+func (c *icsCalendar) dateTime(name string, t time.Time) {
+	if c.utc() {
+		c.line(name, t.UTC().Format(icsDateTimeUTC))
+		return
+	}
+	c.line(name+";TZID="+c.loc.String(), t.In(c.loc).Format(icsDateTimeLocal))
+}
+
+// utc reports whether the feed uses UTC and needs no VTIMEZONE
+func (c *icsCalendar) utc() bool {
+	return c.loc == time.UTC || c.loc.String() == "UTC"
+}
+
+// text writes a property whose value is free text
+func (c *icsCalendar) text(name, value string) {
+	c.line(name, escapeText(value))
+}
+
+// line writes a content line, folded to the maximum line length
+func (c *icsCalendar) line(name, value string) {
+	c.b.WriteString(foldLine(name + ":" + value))
+	c.b.WriteString("\r\n")
+}
+
+// foldLine splits a content line into lines of at most icsMaxLineOctets
+// octets, continued with a leading space, without splitting UTF-8 sequences
+func foldLine(line string) string {
+	if len(line) <= icsMaxLineOctets {
+		return line
+	}
+
+	var b strings.Builder
+	limit := icsMaxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = icsMaxLineOctets - 1 // The leading space counts towards the limit
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+// escapeText escapes a TEXT value as required by RFC 5545 section 3.3.11
+func escapeText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(value)
+}
+
+// formatOffset formats a UTC offset in seconds as +hhmm, adding seconds only when needed
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	result := fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset/60%60)
+	if offset%60 != 0 {
+		result += fmt.Sprintf("%02d", offset%60)
+	}
+	return result
+}
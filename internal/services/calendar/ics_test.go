@@ -0,0 +1,152 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+func TestFoldLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"short", "SUMMARY:Oatmeal"},
+		{"exactly the limit", "SUMMARY:" + strings.Repeat("a", icsMaxLineOctets-len("SUMMARY:"))},
+		{"one over the limit", "SUMMARY:" + strings.Repeat("a", icsMaxLineOctets-len("SUMMARY:")+1)},
+		{"several lines", "DESCRIPTION:" + strings.Repeat("0123456789", 20)},
+		{"multibyte", "SUMMARY:" + strings.Repeat("Гречка варена · ", 10)},
+		{"four-byte runes", "SUMMARY:" + strings.Repeat("🥣", 40)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			folded := foldLine(tt.line)
+			lines := strings.Split(folded, "\r\n")
+			if len(tt.line) <= icsMaxLineOctets && len(lines) != 1 {
+				t.Errorf("folded a line of %d octets", len(tt.line))
+			}
+
+			var unfolded strings.Builder
+			for i, line := range lines {
+				if len(line) > icsMaxLineOctets {
+					t.Errorf("line %d has %d octets", i, len(line))
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a UTF-8 sequence: %q", i, line)
+				}
+				if i > 0 {
+					if !strings.HasPrefix(line, " ") {
+						t.Fatalf("continuation line %d does not start with a space: %q", i, line)
+					}
+					line = line[1:]
+				}
+				unfolded.WriteString(line)
+			}
+			if unfolded.String() != tt.line {
+				t.Errorf("unfolded line = %q, want %q", unfolded.String(), tt.line)
+			}
+		})
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := []struct{ value, want string }{
+		{"Oatmeal", "Oatmeal"},
+		{"Rice, boiled; salted", `Rice\, boiled\; salted`},
+		{`C:\food`, `C:\\food`},
+		{"Calories: 350\nPortion: 100 g", `Calories: 350\nPortion: 100 g`},
+		{"a\r\nb\rc", `a\nbc`},
+		{`\,`, `\\\,`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeText(tt.value); got != tt.want {
+			t.Errorf("escapeText(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFormatOffset(t *testing.T) {
+	tests := []struct {
+		offset int
+		want   string
+	}{
+		{0, "+0000"},
+		{2 * 3600, "+0200"},
+		{5*3600 + 30*60, "+0530"},
+		{-(3*3600 + 30*60), "-0330"},
+		{-10 * 3600, "-1000"},
+		{1*3600 + 39*60 + 49, "+013949"}, // Local mean time of old zones
+	}
+
+	for _, tt := range tests {
+		if got := formatOffset(tt.offset); got != tt.want {
+			t.Errorf("formatOffset(%d) = %q, want %q", tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	stamp := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	meals := []*models.CalorieEntry{
+		{ID: 2, Food: "Soup, hot", Calories: 120, Weight: 300, MealDatetime: time.Date(2026, 4, 2, 10, 0, 0, 0, time.UTC)},
+		{ID: 1, Food: "Oatmeal", Calories: 370, Weight: 100, MealDatetime: time.Date(2026, 3, 2, 6, 30, 0, 0, time.UTC)},
+	}
+	weights := []*models.WeightHistory{{ID: 3, Weight: 72.5, RecordedAt: time.Date(2026, 3, 3, 23, 0, 0, 0, time.UTC)}}
+
+	t.Run("UTC", func(t *testing.T) {
+		ics := string(newICSCalendar("en_US", time.UTC, "example.com", stamp).render(start, end, meals, weights))
+
+		if !strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(ics, "END:VCALENDAR\r\n") {
+			t.Errorf("not a VCALENDAR:\n%s", ics)
+		}
+		if strings.Contains(ics, "VTIMEZONE") {
+			t.Error("a UTC feed has a VTIMEZONE")
+		}
+		for _, want := range []string{
+			"UID:meal-1@example.com\r\n",
+			"DTSTART:20260302T063000Z\r\n",
+			"DTSTAMP:20260310T090000Z\r\n",
+			`SUMMARY:Soup\, hot · 120`,
+			"UID:weight-3@example.com\r\n",
+			"DTSTART;VALUE=DATE:20260303\r\n",
+			"DTEND;VALUE=DATE:20260304\r\n",
+		} {
+			if !strings.Contains(ics, want) {
+				t.Errorf("missing %q in:\n%s", want, ics)
+			}
+		}
+		if strings.Index(ics, "UID:meal-1@") > strings.Index(ics, "UID:meal-2@") {
+			t.Error("meals are not in chronological order")
+		}
+	})
+
+	t.Run("time zone with daylight saving", func(t *testing.T) {
+		loc, err := time.LoadLocation("Europe/Kyiv")
+		if err != nil {
+			t.Skipf("time zone data not available: %v", err)
+		}
+		ics := string(newICSCalendar("en_US", loc, "example.com", stamp).render(start, end, meals, nil))
+
+		for _, want := range []string{
+			"BEGIN:VTIMEZONE\r\nTZID:Europe/Kyiv\r\n",
+			"BEGIN:STANDARD\r\nDTSTART:20251026T040000\r\nTZOFFSETFROM:+0300\r\nTZOFFSETTO:+0200\r\n",
+			"BEGIN:DAYLIGHT\r\nDTSTART:20260329T030000\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0300\r\n",
+			"DTSTART;TZID=Europe/Kyiv:20260302T083000\r\n",
+			"DTSTART;TZID=Europe/Kyiv:20260402T130000\r\n",
+		} {
+			if !strings.Contains(ics, want) {
+				t.Errorf("missing %q in:\n%s", want, ics)
+			}
+		}
+		if strings.Count(ics, "BEGIN:STANDARD") != 1 || strings.Count(ics, "BEGIN:DAYLIGHT") != 1 {
+			t.Errorf("want one STANDARD and one DAYLIGHT rule for the window:\n%s", ics)
+		}
+	})
+}
@@ -0,0 +1,12 @@
+package calendar
+
+import "ypeskov/kkal-tracker/internal/models"
+
+// Servicer defines the calendar feed service contract used by handlers.
+type Servicer interface {
+	GetFeed(userID int) (*models.CalendarFeed, error)
+	CreateFeed(userID int, req *FeedRequest) (*CreatedFeed, error)
+	UpdateFeed(userID int, req *FeedRequest) (*models.CalendarFeed, error)
+	DeleteFeed(userID int) error
+	Render(token string) ([]byte, error)
+}
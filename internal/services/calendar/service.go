@@ -0,0 +1,227 @@
+package calendar
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+	calorieservice "ypeskov/kkal-tracker/internal/services/calorie"
+	weightservice "ypeskov/kkal-tracker/internal/services/weight"
+)
+
+// Service manages calendar feeds and renders them as iCalendar documents
+type Service struct {
+	feedRepo       repositories.CalendarFeedRepository
+	userRepo       repositories.UserRepository
+	calorieService calorieservice.Servicer
+	weightService  weightservice.Servicer
+	appURL         string
+	logger         *slog.Logger
+}
+
+// New creates a new calendar feed service
+func New(
+	feedRepo repositories.CalendarFeedRepository,
+	userRepo repositories.UserRepository,
+	calorieService calorieservice.Servicer,
+	weightService weightservice.Servicer,
+	appURL string,
+	logger *slog.Logger,
+) *Service {
+	return &Service{
+		feedRepo:       feedRepo,
+		userRepo:       userRepo,
+		calorieService: calorieService,
+		weightService:  weightService,
+		appURL:         appURL,
+		logger:         logger.With("service", "calendar"),
+	}
+}
+
+// GetFeed returns the user's feed settings
+func (s *Service) GetFeed(userID int) (*models.CalendarFeed, error) {
+	s.logger.Debug("GetFeed called", "user_id", userID)
+
+	feed, err := s.feedRepo.GetByUserID(userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrFeedNotFound
+	}
+	return feed, err
+}
+
+// CreateFeed creates the user's feed with a new token. An existing feed is
+// replaced, so the old URL stops working.
+func (s *Service) CreateFeed(userID int, req *FeedRequest) (*CreatedFeed, error) {
+	s.logger.Debug("CreateFeed called", "user_id", userID)
+
+	feed := &models.CalendarFeed{UserID: userID}
+	if err := applyRequest(feed, req); err != nil {
+		return nil, err
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate feed token: %w", err)
+	}
+	feed.TokenHash = hashToken(token)
+	feed.TokenPrefix = token[:8]
+
+	if err := s.feedRepo.Save(feed); err != nil {
+		return nil, fmt.Errorf("failed to save calendar feed: %w", err)
+	}
+
+	s.logger.Info("Calendar feed created", "user_id", userID, "prefix", feed.TokenPrefix)
+	return &CreatedFeed{
+		CalendarFeed: feed,
+		URL:          fmt.Sprintf("%s/api/ical/%s.ics", s.appURL, token),
+	}, nil
+}
+
+// UpdateFeed changes the settings of the user's feed and keeps its token
+func (s *Service) UpdateFeed(userID int, req *FeedRequest) (*models.CalendarFeed, error) {
+	s.logger.Debug("UpdateFeed called", "user_id", userID)
+
+	feed, err := s.GetFeed(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyRequest(feed, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.feedRepo.UpdateSettings(feed); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrFeedNotFound
+		}
+		return nil, fmt.Errorf("failed to update calendar feed: %w", err)
+	}
+	return feed, nil
+}
+
+// DeleteFeed revokes the user's feed
+func (s *Service) DeleteFeed(userID int) error {
+	s.logger.Debug("DeleteFeed called", "user_id", userID)
+
+	if err := s.feedRepo.Delete(userID); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrFeedNotFound
+		}
+		return fmt.Errorf("failed to delete calendar feed: %w", err)
+	}
+
+	s.logger.Info("Calendar feed revoked", "user_id", userID)
+	return nil
+}
+
+// Render builds the iCalendar document of the feed the token belongs to
+func (s *Service) Render(token string) ([]byte, error) {
+	feed, err := s.feedRepo.GetByTokenHash(hashToken(token))
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+
+	user, err := s.userRepo.GetByID(feed.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.IsActive || user.DeletionScheduledAt != nil {
+		return nil, ErrInvalidToken
+	}
+
+	language := "en_US"
+	if user.Language != nil {
+		language = *user.Language
+	}
+
+	loc, err := time.LoadLocation(feed.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	now := time.Now()
+	local := now.In(loc)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	start, end := today.AddDate(0, 0, -feed.PastDays), today.AddDate(0, 0, 1)
+
+	// Entries are stored in UTC, so the UTC dates of the window bounds cover it
+	entries, err := s.calorieService.GetEntriesByDateRange(feed.UserID,
+		start.UTC().Format("2006-01-02"), end.UTC().Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calorie entries: %w", err)
+	}
+	meals := make([]*models.CalorieEntry, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.MealDatetime.Before(start) && entry.MealDatetime.Before(end) {
+			meals = append(meals, entry)
+		}
+	}
+
+	// Weigh-ins are dates, so they are compared with the local calendar days
+	weights, err := s.weightService.GetWeightHistoryByDateRange(feed.UserID,
+		start.Format("2006-01-02"), today.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weight history: %w", err)
+	}
+
+	if err := s.feedRepo.MarkAccessed(feed.ID, now); err != nil {
+		s.logger.Warn("Failed to record calendar feed access", "feed_id", feed.ID, "error", err)
+	}
+
+	return newICSCalendar(language, loc, s.host(), now).render(start, end, meals, weights), nil
+}
+
+// host returns the domain used in event UIDs
+func (s *Service) host() string {
+	if u, err := url.Parse(s.appURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return "kkal-tracker"
+}
+
+// applyRequest validates the request and copies it onto the feed
+func applyRequest(feed *models.CalendarFeed, req *FeedRequest) error {
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	// "Local" would depend on the server's settings and is no valid TZID
+	if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		return ErrInvalidTimezone
+	}
+
+	pastDays := req.PastDays
+	if pastDays == 0 {
+		pastDays = DefaultPastDays
+	}
+	if pastDays < 1 || pastDays > MaxPastDays {
+		return ErrInvalidPastDays
+	}
+
+	feed.Timezone = timezone
+	feed.PastDays = pastDays
+	return nil
+}
+
+// generateToken creates a random feed token
+func generateToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// hashToken computes the SHA-256 hash of a raw token
+func hashToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,22 @@
+package calendar
+
+import "ypeskov/kkal-tracker/internal/models"
+
+// Feed window limits, in days before today
+const (
+	DefaultPastDays = 30
+	MaxPastDays     = 365
+)
+
+// FeedRequest contains the settings a user picks for the feed
+type FeedRequest struct {
+	Timezone string // IANA name, defaults to UTC
+	PastDays int    // Days before today the feed covers, defaults to DefaultPastDays
+}
+
+// CreatedFeed is returned once when a feed is created; the URL carries the
+// raw token and cannot be retrieved again
+type CreatedFeed struct {
+	*models.CalendarFeed
+	URL string `json:"url"`
+}
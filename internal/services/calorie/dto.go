@@ -15,7 +15,11 @@ type CreateEntryRequest struct {
 	Fats         *float64
 	Carbs        *float64
 	Proteins     *float64
+	Nutrients    models.Nutrients // Per 100 g; when nil, the ingredient's values are snapshotted
 	MealDatetime time.Time
+	// Quantity in Unit, e.g. 2 "egg"; when Unit is set, Weight and Calories are computed from it
+	Quantity *float64
+	Unit     string
 }
 
 type UpdateEntryRequest struct {
@@ -28,7 +32,11 @@ type UpdateEntryRequest struct {
 	Fats         *float64
 	Carbs        *float64
 	Proteins     *float64
+	Nutrients    models.Nutrients
 	MealDatetime time.Time
+	Quantity     *float64
+	Unit         string
+	Version      int // Version the entry must have, from If-Match; 0 skips the check
 }
 
 type CreateEntryResult struct {
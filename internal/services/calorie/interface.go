@@ -6,7 +6,10 @@ import "ypeskov/kkal-tracker/internal/models"
 type Servicer interface {
 	CreateEntry(req *CreateEntryRequest) (*CreateEntryResult, error)
 	UpdateEntry(req *UpdateEntryRequest) (*models.CalorieEntry, error)
-	DeleteEntry(entryID, userID int) error
+	GetEntry(entryID, userID int) (*models.CalorieEntry, error)
+	DeleteEntry(entryID, userID, version int) error
+	PrepareEntry(req *UpdateEntryRequest) (*models.CalorieEntry, error)
+	AddIngredient(entry *models.CalorieEntry) bool
 	GetEntriesByDateRange(userID int, dateFrom, dateTo string) ([]*models.CalorieEntry, error)
 	GetTotalCaloriesForDate(userID int, date string) (int, error)
 	GetWeeklyStats(userID int, startDate string) (map[string]int, error)
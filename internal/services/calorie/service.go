@@ -1,26 +1,35 @@
 package calorie
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
 	"log/slog"
+	"math"
+	"strings"
 	"time"
 
 	"ypeskov/kkal-tracker/internal/models"
 	"ypeskov/kkal-tracker/internal/repositories"
+	ingredientservice "ypeskov/kkal-tracker/internal/services/ingredient"
+	webhookservice "ypeskov/kkal-tracker/internal/services/webhook"
 )
 
 type Service struct {
 	calorieRepo    repositories.CalorieEntryRepository
 	ingredientRepo repositories.IngredientRepository
+	events         webhookservice.Publisher
 	logger         *slog.Logger
 }
 
 func New(calorieRepo repositories.CalorieEntryRepository,
 	ingredientRepo repositories.IngredientRepository,
+	events webhookservice.Publisher,
 	logger *slog.Logger) *Service {
 	return &Service{
 		calorieRepo:    calorieRepo,
 		ingredientRepo: ingredientRepo,
+		events:         events,
 		logger:         logger.With("service", "calorie"),
 	}
 }
@@ -28,49 +37,35 @@ func New(calorieRepo repositories.CalorieEntryRepository,
 func (s *Service) CreateEntry(req *CreateEntryRequest) (*CreateEntryResult, error) {
 	s.logger.Debug("CreateEntry called", "user_id", req.UserID, "food", req.Food, "calories", req.Calories, "weight", req.Weight)
 
-	// Validate calories
-	if req.Calories <= 0 {
-		return nil, errors.New("calories must be greater than 0")
-	}
-
-	// Validate weight
-	if req.Weight <= 0 {
-		return nil, errors.New("weight must be greater than 0")
-	}
-
-	// Validate kcal per 100g
-	if req.KcalPer100g <= 0 {
-		return nil, errors.New("kcal per 100g must be greater than 0")
-	}
-
-	// Validate food name
-	if req.Food == "" {
-		return nil, errors.New("food name is required")
-	}
-
-	newIngredientCreated := false
-
-	// Check if ingredient exists, if not create it
-	_, err := s.ingredientRepo.GetUserIngredientByName(req.UserID, req.Food)
+	prepared, err := s.PrepareEntry(&UpdateEntryRequest{
+		UserID:       req.UserID,
+		Food:         req.Food,
+		Calories:     req.Calories,
+		Weight:       req.Weight,
+		KcalPer100g:  req.KcalPer100g,
+		Fats:         req.Fats,
+		Carbs:        req.Carbs,
+		Proteins:     req.Proteins,
+		Nutrients:    req.Nutrients,
+		MealDatetime: req.MealDatetime,
+		Quantity:     req.Quantity,
+		Unit:         req.Unit,
+	})
 	if err != nil {
-		// Ingredient doesn't exist, create it
-		_, createErr := s.ingredientRepo.CreateOrUpdateUserIngredient(req.UserID, req.Food, req.KcalPer100g, req.Fats, req.Carbs, req.Proteins)
-		if createErr != nil {
-			s.logger.Error("Failed to create user ingredient", "error", createErr, "user_id", req.UserID, "food", req.Food)
-			// Don't fail the calorie entry creation if ingredient creation fails
-		} else {
-			s.logger.Info("New user ingredient created", "user_id", req.UserID, "food", req.Food, "kcalPer100g", req.KcalPer100g)
-			newIngredientCreated = true
-		}
+		return nil, err
 	}
 
-	entry, err := s.calorieRepo.Create(req.UserID, req.Food, req.Calories, req.Weight, req.KcalPer100g, req.Fats, req.Carbs, req.Proteins, req.MealDatetime)
+	newIngredientCreated := s.AddIngredient(prepared)
+
+	entry, err := s.calorieRepo.Create(prepared.UserID, prepared.Food, prepared.Calories, prepared.Weight, prepared.KcalPer100g,
+		prepared.Fats, prepared.Carbs, prepared.Proteins, prepared.Nutrients, prepared.Quantity, prepared.Unit, prepared.MealDatetime)
 	if err != nil {
 		s.logger.Error("Failed to create calorie entry", "error", err, "user_id", req.UserID)
 		return nil, err
 	}
 
-	s.logger.Info("Calorie entry created", "user_id", req.UserID, "food", req.Food, "calories", req.Calories, "weight", req.Weight, "kcalPer100g", req.KcalPer100g, "meal_datetime", req.MealDatetime)
+	s.logger.Info("Calorie entry created", "user_id", req.UserID, "food", entry.Food, "calories", entry.Calories, "weight", entry.Weight, "kcalPer100g", entry.KcalPer100g, "meal_datetime", entry.MealDatetime)
+	s.events.CalorieEntryCreated(entry)
 
 	result := &CreateEntryResult{
 		Entry:                entry,
@@ -81,10 +76,49 @@ func (s *Service) CreateEntry(req *CreateEntryRequest) (*CreateEntryResult, erro
 	return result, nil
 }
 
-func (s *Service) DeleteEntry(entryID, userID int) error {
+// AddIngredient adds the food of an entry to the user's ingredients unless it is
+// there already. A failure is logged and does not affect the entry. Returns
+// whether an ingredient was created.
+func (s *Service) AddIngredient(entry *models.CalorieEntry) bool {
+	if _, err := s.ingredientRepo.GetUserIngredientByName(entry.UserID, entry.Food); err == nil {
+		return false
+	}
+
+	_, err := s.ingredientRepo.CreateOrUpdateUserIngredient(entry.UserID, entry.Food, entry.KcalPer100g, entry.Fats, entry.Carbs, entry.Proteins, entry.Nutrients)
+	if err != nil {
+		s.logger.Error("Failed to create user ingredient", "error", err, "user_id", entry.UserID, "food", entry.Food)
+		return false
+	}
+
+	s.logger.Info("New user ingredient created", "user_id", entry.UserID, "food", entry.Food, "kcalPer100g", entry.KcalPer100g)
+	return true
+}
+
+// GetEntry returns an entry of the user. Returns ErrEntryNotFound if the user has no such entry.
+func (s *Service) GetEntry(entryID, userID int) (*models.CalorieEntry, error) {
+	s.logger.Debug("GetEntry called", "entry_id", entryID, "user_id", userID)
+
+	entry, err := s.calorieRepo.GetByID(entryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEntryNotFound
+		}
+		s.logger.Error("Failed to get calorie entry", "error", err, "entry_id", entryID, "user_id", userID)
+		return nil, err
+	}
+	if entry.UserID != userID {
+		return nil, ErrEntryNotFound
+	}
+
+	return entry, nil
+}
+
+// DeleteEntry deletes an entry of the user. A non-zero version is the version
+// the entry must have, otherwise repositories.ErrVersionConflict is returned.
+func (s *Service) DeleteEntry(entryID, userID, version int) error {
 	s.logger.Debug("DeleteEntry called", "entry_id", entryID, "user_id", userID)
 
-	err := s.calorieRepo.Delete(entryID, userID)
+	err := s.calorieRepo.Delete(entryID, userID, version)
 	if err != nil {
 		s.logger.Error("Failed to delete calorie entry", "error", err, "entry_id", entryID, "user_id", userID)
 		return err
@@ -160,6 +194,34 @@ func (s *Service) GetEntriesByDateRange(userID int, dateFrom, dateTo string) ([]
 func (s *Service) UpdateEntry(req *UpdateEntryRequest) (*models.CalorieEntry, error) {
 	s.logger.Debug("UpdateEntry called", "entry_id", req.EntryID, "user_id", req.UserID, "food", req.Food, "calories", req.Calories, "weight", req.Weight)
 
+	prepared, err := s.PrepareEntry(req)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := s.calorieRepo.Update(req.EntryID, req.UserID, prepared.Food, prepared.Calories, prepared.Weight, prepared.KcalPer100g,
+		prepared.Fats, prepared.Carbs, prepared.Proteins, prepared.Nutrients, prepared.Quantity, prepared.Unit, prepared.MealDatetime, req.Version)
+	if err != nil {
+		s.logger.Error("Failed to update calorie entry", "error", err, "entry_id", req.EntryID, "user_id", req.UserID)
+		return nil, err
+	}
+
+	s.logger.Info("Calorie entry updated", "entry_id", req.EntryID, "user_id", req.UserID, "food", entry.Food, "calories", entry.Calories, "weight", entry.Weight, "kcalPer100g", entry.KcalPer100g, "meal_datetime", entry.MealDatetime)
+	s.events.CalorieEntryUpdated(entry)
+	s.logger.Debug("UpdateEntry completed successfully", "entry_id", req.EntryID, "user_id", req.UserID)
+	return entry, nil
+}
+
+// PrepareEntry validates the values of a new entry (EntryID 0) or of an update
+// and returns the entry to save, without saving it: a quantity in a unit is
+// converted to grams and the nutrients snapshot is resolved.
+func (s *Service) PrepareEntry(req *UpdateEntryRequest) (*models.CalorieEntry, error) {
+	// Convert a quantity in servings or other units to grams
+	quantity, unit, err := s.resolvePortion(req.UserID, req.Food, req.KcalPer100g, req.Quantity, req.Unit, &req.Weight, &req.Calories)
+	if err != nil {
+		return nil, err
+	}
+
 	// Validate calories
 	if req.Calories <= 0 {
 		return nil, errors.New("calories must be greater than 0")
@@ -180,13 +242,98 @@ func (s *Service) UpdateEntry(req *UpdateEntryRequest) (*models.CalorieEntry, er
 		return nil, errors.New("food name is required")
 	}
 
-	entry, err := s.calorieRepo.Update(req.EntryID, req.UserID, req.Food, req.Calories, req.Weight, req.KcalPer100g, req.Fats, req.Carbs, req.Proteins, req.MealDatetime)
+	// Keep the stored snapshot when the food did not change and no nutrients were sent
+	var current *models.CalorieEntry
+	if req.EntryID != 0 && req.Nutrients == nil {
+		current, err = s.calorieRepo.GetByID(req.EntryID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			s.logger.Error("Failed to get calorie entry", "error", err, "entry_id", req.EntryID, "user_id", req.UserID)
+			return nil, err
+		}
+		if current != nil && (current.UserID != req.UserID || current.Food != req.Food) {
+			current = nil
+		}
+	}
+
+	nutrients, err := s.resolveNutrients(req.UserID, req.Food, req.Nutrients, current)
 	if err != nil {
-		s.logger.Error("Failed to update calorie entry", "error", err, "entry_id", req.EntryID, "user_id", req.UserID)
 		return nil, err
 	}
 
-	s.logger.Info("Calorie entry updated", "entry_id", req.EntryID, "user_id", req.UserID, "food", req.Food, "calories", req.Calories, "weight", req.Weight, "kcalPer100g", req.KcalPer100g, "meal_datetime", req.MealDatetime)
-	s.logger.Debug("UpdateEntry completed successfully", "entry_id", req.EntryID, "user_id", req.UserID)
-	return entry, nil
+	return &models.CalorieEntry{
+		ID:           req.EntryID,
+		UserID:       req.UserID,
+		Food:         req.Food,
+		Calories:     req.Calories,
+		Weight:       req.Weight,
+		KcalPer100g:  req.KcalPer100g,
+		Fats:         req.Fats,
+		Carbs:        req.Carbs,
+		Proteins:     req.Proteins,
+		Nutrients:    nutrients,
+		Quantity:     quantity,
+		Unit:         unit,
+		MealDatetime: req.MealDatetime,
+	}, nil
+}
+
+// resolvePortion converts a quantity in a unit to grams using the food's servings and
+// density, and recomputes weight and calories from it. Returns the quantity and unit to
+// store with the entry; both are nil when the entry was given in grams.
+func (s *Service) resolvePortion(userID int, food string, kcalPer100g float64, quantity *float64, unit string, weight *float64, calories *int) (*float64, *string, error) {
+	unit = strings.TrimSpace(unit)
+	if unit == "" {
+		return nil, nil, nil
+	}
+	if quantity == nil {
+		return nil, nil, ingredientservice.ErrInvalidQuantity
+	}
+
+	var units *models.IngredientUnits
+	ingredient, err := s.ingredientRepo.GetUserIngredientByName(userID, food)
+	switch {
+	case err == nil:
+		units, err = s.ingredientRepo.GetIngredientUnits(userID, ingredient.ID)
+		if err != nil {
+			s.logger.Error("Failed to get ingredient units", "error", err, "user_id", userID, "food", food)
+			return nil, nil, err
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		s.logger.Error("Failed to get user ingredient", "error", err, "user_id", userID, "food", food)
+		return nil, nil, err
+	}
+
+	grams, unit, err := ingredientservice.ToGrams(units, *quantity, unit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	*weight = math.Round(grams*10) / 10
+	*calories = int(math.Round(grams * kcalPer100g / 100))
+	return quantity, &unit, nil
+}
+
+// resolveNutrients returns the extended nutrients to snapshot with an entry. Values sent
+// with the request are validated and used as is; otherwise the current entry's snapshot
+// is kept, falling back to the values of the food's ingredient. Unknown values stay unset.
+func (s *Service) resolveNutrients(userID int, food string, nutrients models.Nutrients, current *models.CalorieEntry) (models.Nutrients, error) {
+	if nutrients != nil {
+		if err := nutrients.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ingredientservice.ErrInvalidNutrients, err)
+		}
+		return nutrients.Complete(), nil
+	}
+	if current != nil {
+		return current.Nutrients, nil
+	}
+
+	ingredient, err := s.ingredientRepo.GetUserIngredientByName(userID, food)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		s.logger.Error("Failed to get user ingredient", "error", err, "user_id", userID, "food", food)
+		return nil, err
+	}
+	return ingredient.Nutrients, nil
 }
@@ -0,0 +1,8 @@
+package changefeed
+
+import "errors"
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrCursorExpired = errors.New("cursor is older than the tombstone retention, sync again without a cursor")
+)
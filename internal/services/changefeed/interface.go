@@ -0,0 +1,6 @@
+package changefeed
+
+// Servicer defines the change feed service contract used by handlers.
+type Servicer interface {
+	Changes(userID int, cursor string, limit int) (*ChangesPage, error)
+}
@@ -0,0 +1,209 @@
+package changefeed
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// Page and cleanup settings
+const (
+	defaultLimit    = 500
+	maxLimit        = 1000
+	cleanupInterval = time.Hour
+	cursorPrefix    = "v1"
+)
+
+// Service serves the change feed that offline clients sync from
+type Service struct {
+	syncRepo  repositories.SyncChangeRepository
+	retention time.Duration // How long tombstones of deleted entities are kept
+	logger    *slog.Logger
+}
+
+// New creates a new change feed service
+func New(syncRepo repositories.SyncChangeRepository, retention time.Duration, logger *slog.Logger) *Service {
+	return &Service{
+		syncRepo:  syncRepo,
+		retention: retention,
+		logger:    logger.With("service", "changefeed"),
+	}
+}
+
+// Changes returns the user's entities that changed after the cursor, oldest
+// first. An empty cursor starts from the beginning and returns every entity
+// the user has. Limit is clamped to maxLimit; zero means defaultLimit.
+func (s *Service) Changes(userID int, cursor string, limit int) (*ChangesPage, error) {
+	s.logger.Debug("Changes called", "user_id", userID, "limit", limit)
+
+	now := time.Now().UTC()
+	after, since := int64(0), now
+	if cursor != "" {
+		var err error
+		after, since, err = decodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		// Tombstones deleted since the cursor was issued may already be gone
+		if since.Before(now.Add(-s.retention)) {
+			return nil, ErrCursorExpired
+		}
+	}
+
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	limit = min(limit, maxLimit)
+
+	changes, err := s.syncRepo.GetChanges(userID, after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changes: %w", err)
+	}
+
+	hasMore := len(changes) > limit
+	if hasMore {
+		changes = changes[:limit]
+	}
+
+	upTo := after
+	if len(changes) > 0 {
+		upTo = changes[len(changes)-1].Version
+	}
+
+	data, err := s.loadEntities(userID, after, upTo)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ChangesPage{Changes: make([]*Change, 0, len(changes)), HasMore: hasMore}
+	for _, change := range changes {
+		item := &Change{
+			Type:      change.Entity,
+			ID:        change.EntityID,
+			Version:   change.Version,
+			Deleted:   change.Deleted,
+			ChangedAt: change.ChangedAt,
+		}
+		if !change.Deleted {
+			entity, ok := data[entityKey{change.Entity, change.EntityID}]
+			if !ok {
+				// Changed again after the page was read; it comes with a later version
+				continue
+			}
+			item.Data = entity
+		}
+		page.Changes = append(page.Changes, item)
+	}
+
+	// The client only has everything up to now once it reached the end of the
+	// feed; until then the cursor keeps the time the sync started
+	if !hasMore {
+		since = now
+	}
+	page.Cursor = encodeCursor(upTo, since)
+
+	return page, nil
+}
+
+// entityKey identifies an entity in the feed
+type entityKey struct {
+	entity string
+	id     int
+}
+
+// loadEntities reads the current state of the entities whose latest version is in (after, upTo]
+func (s *Service) loadEntities(userID int, after, upTo int64) (map[entityKey]any, error) {
+	data := make(map[entityKey]any)
+	if upTo <= after {
+		return data, nil
+	}
+
+	entries, err := s.syncRepo.GetCalorieEntries(userID, after, upTo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed calorie entries: %w", err)
+	}
+	for _, entry := range entries {
+		data[entityKey{models.SyncEntityCalorieEntry, entry.ID}] = entry
+	}
+
+	weights, err := s.syncRepo.GetWeightHistory(userID, after, upTo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed weight entries: %w", err)
+	}
+	for _, weight := range weights {
+		data[entityKey{models.SyncEntityWeight, weight.ID}] = weight
+	}
+
+	ingredients, err := s.syncRepo.GetIngredients(userID, after, upTo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get changed ingredients: %w", err)
+	}
+	for _, ingredient := range ingredients {
+		data[entityKey{models.SyncEntityIngredient, ingredient.ID}] = ingredient
+	}
+
+	return data, nil
+}
+
+// RunCleanup removes tombstones older than the retention until ctx is
+// cancelled. Running it on several instances is harmless.
+func (s *Service) RunCleanup(ctx context.Context) {
+	s.logger.Info("Change feed cleanup job started")
+
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.syncRepo.DeleteTombstonesBefore(time.Now().Add(-s.retention))
+		if err != nil {
+			s.logger.Error("Failed to delete old tombstones", "error", err)
+		} else if deleted > 0 {
+			s.logger.Info("Old tombstones deleted", "count", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Change feed cleanup job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// encodeCursor returns the cursor for the changes after a version, for a
+// client whose copy is complete as of since
+func encodeCursor(version int64, since time.Time) string {
+	raw := fmt.Sprintf("%s.%d.%d", cursorPrefix, version, since.Unix())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor returns the version and time encoded in a cursor
+func decodeCursor(cursor string) (int64, time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, time.Time{}, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), ".")
+	if len(parts) != 3 || parts[0] != cursorPrefix {
+		return 0, time.Time{}, ErrInvalidCursor
+	}
+
+	version, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || version < 0 {
+		return 0, time.Time{}, ErrInvalidCursor
+	}
+	since, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, ErrInvalidCursor
+	}
+
+	return version, time.Unix(since, 0).UTC(), nil
+}
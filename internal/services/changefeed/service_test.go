@@ -0,0 +1,244 @@
+package changefeed
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// fakeSyncRepo serves a fixed change log. Entities are returned by the loaders
+// when their current version is in the requested range.
+type fakeSyncRepo struct {
+	repositories.SyncChangeRepository
+	changes     []*models.SyncChange
+	versions    map[entityKey]int64 // Current version of each live entity
+	entries     []*models.CalorieEntry
+	weights     []*models.WeightHistory
+	ingredients []*models.UserIngredient
+	lastLimit   int
+}
+
+func (r *fakeSyncRepo) GetChanges(userID int, after int64, limit int) ([]*models.SyncChange, error) {
+	r.lastLimit = limit
+	var changes []*models.SyncChange
+	for _, change := range r.changes {
+		if change.Version > after && len(changes) < limit {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+func (r *fakeSyncRepo) inRange(entity string, id int, after, upTo int64) bool {
+	version := r.versions[entityKey{entity, id}]
+	return version > after && version <= upTo
+}
+
+func (r *fakeSyncRepo) GetCalorieEntries(userID int, after, upTo int64) ([]*models.CalorieEntry, error) {
+	var entries []*models.CalorieEntry
+	for _, entry := range r.entries {
+		if r.inRange(models.SyncEntityCalorieEntry, entry.ID, after, upTo) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func (r *fakeSyncRepo) GetWeightHistory(userID int, after, upTo int64) ([]*models.WeightHistory, error) {
+	var weights []*models.WeightHistory
+	for _, weight := range r.weights {
+		if r.inRange(models.SyncEntityWeight, weight.ID, after, upTo) {
+			weights = append(weights, weight)
+		}
+	}
+	return weights, nil
+}
+
+func (r *fakeSyncRepo) GetIngredients(userID int, after, upTo int64) ([]*models.UserIngredient, error) {
+	var ingredients []*models.UserIngredient
+	for _, ingredient := range r.ingredients {
+		if r.inRange(models.SyncEntityIngredient, ingredient.ID, after, upTo) {
+			ingredients = append(ingredients, ingredient)
+		}
+	}
+	return ingredients, nil
+}
+
+func newFakeSyncRepo() *fakeSyncRepo {
+	return &fakeSyncRepo{
+		changes: []*models.SyncChange{
+			{Entity: models.SyncEntityCalorieEntry, EntityID: 1, Version: 1},
+			{Entity: models.SyncEntityWeight, EntityID: 1, Version: 2},
+			{Entity: models.SyncEntityCalorieEntry, EntityID: 2, Version: 3, Deleted: true},
+			{Entity: models.SyncEntityIngredient, EntityID: 1, Version: 4},
+			{Entity: models.SyncEntityCalorieEntry, EntityID: 3, Version: 5}, // Changed again after the log was read
+		},
+		versions: map[entityKey]int64{
+			{models.SyncEntityCalorieEntry, 1}: 1,
+			{models.SyncEntityWeight, 1}:       2,
+			{models.SyncEntityIngredient, 1}:   4,
+			{models.SyncEntityCalorieEntry, 3}: 6,
+		},
+		entries:     []*models.CalorieEntry{{ID: 1, Food: "Oatmeal"}, {ID: 3, Food: "Rice"}},
+		weights:     []*models.WeightHistory{{ID: 1, Weight: 72.5}},
+		ingredients: []*models.UserIngredient{{ID: 1, Name: "Oatmeal"}},
+	}
+}
+
+func newTestService(repo *fakeSyncRepo) *Service {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(repo, 24*time.Hour, logger)
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	since := time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)
+	for _, version := range []int64{0, 1, 1 << 40} {
+		gotVersion, gotSince, err := decodeCursor(encodeCursor(version, since))
+		if err != nil {
+			t.Fatalf("decodeCursor: %v", err)
+		}
+		if gotVersion != version || !gotSince.Equal(since) {
+			t.Errorf("got %d at %v, want %d at %v", gotVersion, gotSince, version, since)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "%%%"},
+		{"empty", ""},
+		{"unknown version", encode("v2.5.1760000000")},
+		{"missing time", encode("v1.5")},
+		{"extra part", encode("v1.5.1760000000.1")},
+		{"negative version", encode("v1.-5.1760000000")},
+		{"version not a number", encode("v1.five.1760000000")},
+		{"time not a number", encode("v1.5.today")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("decodeCursor(%q) error = %v, want %v", tt.cursor, err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestChanges(t *testing.T) {
+	repo := newFakeSyncRepo()
+	svc := newTestService(repo)
+
+	first, err := svc.Changes(1, "", 2)
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if !first.HasMore || len(first.Changes) != 2 {
+		t.Fatalf("first page has %d changes, more %v, want 2 and more", len(first.Changes), first.HasMore)
+	}
+	if entry, ok := first.Changes[0].Data.(*models.CalorieEntry); !ok || entry.Food != "Oatmeal" {
+		t.Errorf("first change carries %#v, want the calorie entry", first.Changes[0].Data)
+	}
+	if version, _, _ := decodeCursor(first.Cursor); version != 2 {
+		t.Errorf("first cursor at version %d, want 2", version)
+	}
+
+	second, err := svc.Changes(1, first.Cursor, 10)
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	if second.HasMore {
+		t.Error("second page has more")
+	}
+	var versions []int64
+	for _, change := range second.Changes {
+		versions = append(versions, change.Version)
+	}
+	// Version 5 is skipped: the entry comes again with its later version
+	if !slices.Equal(versions, []int64{3, 4}) {
+		t.Fatalf("second page versions %v, want [3 4]", versions)
+	}
+	if tombstone := second.Changes[0]; !tombstone.Deleted || tombstone.Data != nil {
+		t.Errorf("tombstone = %+v, want deleted without data", tombstone)
+	}
+	if version, _, _ := decodeCursor(second.Cursor); version != 5 {
+		t.Errorf("second cursor at version %d, want 5", version)
+	}
+
+	// Nothing changed since the end of the feed
+	last, err := svc.Changes(1, second.Cursor, 10)
+	if err != nil {
+		t.Fatalf("last page: %v", err)
+	}
+	if len(last.Changes) != 0 || last.HasMore {
+		t.Errorf("got %+v, want an empty page", last)
+	}
+	if version, _, _ := decodeCursor(last.Cursor); version != 5 {
+		t.Errorf("cursor moved to version %d, want 5", version)
+	}
+}
+
+func TestChangesCursorTime(t *testing.T) {
+	svc := newTestService(newFakeSyncRepo())
+	started := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	// Until the end of the feed is reached the cursor keeps the time the sync started
+	page, err := svc.Changes(1, encodeCursor(0, started), 1)
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+	if _, since, _ := decodeCursor(page.Cursor); !since.Equal(started) {
+		t.Errorf("cursor time %v, want %v", since, started)
+	}
+
+	page, err = svc.Changes(1, encodeCursor(0, started), 10)
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+	if _, since, _ := decodeCursor(page.Cursor); since.Before(time.Now().Add(-time.Minute)) {
+		t.Errorf("cursor time %v, want now", since)
+	}
+}
+
+func TestChangesErrors(t *testing.T) {
+	svc := newTestService(newFakeSyncRepo())
+
+	if _, err := svc.Changes(1, "%%%", 10); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("invalid cursor: got %v, want %v", err, ErrInvalidCursor)
+	}
+	expired := encodeCursor(2, time.Now().Add(-25*time.Hour))
+	if _, err := svc.Changes(1, expired, 10); !errors.Is(err, ErrCursorExpired) {
+		t.Errorf("expired cursor: got %v, want %v", err, ErrCursorExpired)
+	}
+}
+
+func TestChangesLimit(t *testing.T) {
+	tests := []struct{ limit, want int }{
+		{0, defaultLimit + 1},
+		{-1, defaultLimit + 1},
+		{10, 11},
+		{maxLimit * 5, maxLimit + 1},
+	}
+
+	for _, tt := range tests {
+		repo := newFakeSyncRepo()
+		if _, err := newTestService(repo).Changes(1, "", tt.limit); err != nil {
+			t.Fatalf("Changes: %v", err)
+		}
+		if repo.lastLimit != tt.want {
+			t.Errorf("limit %d read %d changes, want %d", tt.limit, repo.lastLimit, tt.want)
+		}
+	}
+}
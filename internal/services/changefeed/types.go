@@ -0,0 +1,23 @@
+package changefeed
+
+import "time"
+
+// Change is an entity that was created, updated or deleted since the cursor.
+// Data holds the entity as the regular endpoints return it and is omitted for
+// deleted entities.
+type Change struct {
+	Type      string    `json:"type"` // Values of the models.SyncEntity constants
+	ID        int       `json:"id"`
+	Version   int64     `json:"version"`
+	Deleted   bool      `json:"deleted"`
+	ChangedAt time.Time `json:"changed_at"`
+	Data      any       `json:"data,omitempty"`
+}
+
+// ChangesPage is one page of the feed. Cursor is passed back to get the next
+// page, or the changes made since, once HasMore is false.
+type ChangesPage struct {
+	Changes []*Change `json:"changes"`
+	Cursor  string    `json:"cursor"`
+	HasMore bool      `json:"has_more"`
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"html/template"
 	"log/slog"
+	"math"
 	"net/smtp"
 
 	"ypeskov/kkal-tracker/internal/config"
@@ -92,17 +93,26 @@ func (s *Service) SendActivationEmail(toEmail, token, language string) error {
 
 // ExportEmailData contains data for export email template
 type ExportEmailData struct {
-	FileName string
-	AppName  string
+	FileName        string
+	AppName         string
+	UnsubscribeURL  string // Set for scheduled exports
+	UnsubscribeText string
+	UnsubscribeLink string
 }
 
-// SendEmailWithAttachment sends an email with a file attachment
-func (s *Service) SendEmailWithAttachment(toEmail, language string, attachment []byte, attachmentName string) error {
+// SendEmailWithAttachment sends an email with a file attachment. Emails sent
+// by a schedule pass its unsubscribeURL, which is linked in the footer and
+// offered to mail clients as a one-click unsubscribe; it is empty otherwise.
+func (s *Service) SendEmailWithAttachment(toEmail, language string, attachment []byte, attachmentName, contentType, unsubscribeURL string) error {
 	s.logger.Debug("Sending email with attachment", "to", toEmail, "attachment", attachmentName, "language", language)
 
+	t := i18n.GetTranslator()
 	data := ExportEmailData{
-		FileName: attachmentName,
-		AppName:  i18n.GetTranslator().Get(language, "app.name"),
+		FileName:        attachmentName,
+		AppName:         t.Get(language, "app.name"),
+		UnsubscribeURL:  unsubscribeURL,
+		UnsubscribeText: t.Get(language, "email.unsubscribe.footer"),
+		UnsubscribeLink: t.Get(language, "email.unsubscribe.link"),
 	}
 
 	// Select template based on language
@@ -141,6 +151,7 @@ func (s *Service) SendEmailWithAttachment(toEmail, language string, attachment [
 	message.WriteString(fmt.Sprintf("From: %s\r\n", s.config.SMTPFrom))
 	message.WriteString(fmt.Sprintf("To: %s\r\n", toEmail))
 	message.WriteString(fmt.Sprintf("Subject: %s\r\n", subject))
+	message.WriteString(unsubscribeHeaders(unsubscribeURL))
 	message.WriteString("MIME-Version: 1.0\r\n")
 	message.WriteString(fmt.Sprintf("Content-Type: multipart/mixed; boundary=\"%s\"\r\n", boundary))
 	message.WriteString("\r\n")
@@ -155,7 +166,7 @@ func (s *Service) SendEmailWithAttachment(toEmail, language string, attachment [
 
 	// Attachment part
 	message.WriteString(fmt.Sprintf("--%s\r\n", boundary))
-	message.WriteString("Content-Type: application/vnd.openxmlformats-officedocument.spreadsheetml.sheet\r\n")
+	message.WriteString(fmt.Sprintf("Content-Type: %s\r\n", contentType))
 	message.WriteString(fmt.Sprintf("Content-Disposition: attachment; filename=\"%s\"\r\n", attachmentName))
 	message.WriteString("Content-Transfer-Encoding: base64\r\n")
 	message.WriteString("\r\n")
@@ -187,3 +198,126 @@ func (s *Service) SendEmailWithAttachment(toEmail, language string, attachment [
 	s.logger.Info("Export email sent", "to", toEmail, "attachment", attachmentName)
 	return nil
 }
+
+// DigestDay is the calorie total of one day in a digest email
+type DigestDay struct {
+	Date     string
+	Calories float64
+}
+
+// DigestTargets are the daily targets a digest is compared against
+type DigestTargets struct {
+	Calories float64
+	Proteins float64
+	Fats     float64
+	Carbs    float64
+}
+
+// Digest is the content of a digest email. Intake values are the total of the
+// day for a one-day digest and daily averages over logged days otherwise.
+type Digest struct {
+	DateFrom      string // YYYY-MM-DD
+	DateTo        string
+	Days          int
+	DaysLogged    int
+	Calories      float64
+	Proteins      float64
+	Fats          float64
+	Carbs         float64
+	Targets       *DigestTargets // nil when the profile is incomplete
+	DailyCalories []DigestDay
+}
+
+// digestRow is one line of the digest table
+type digestRow struct {
+	Label   string
+	Unit    string
+	Actual  float64
+	Target  float64
+	Percent int
+}
+
+// DigestEmailData contains data for the digest email template
+type DigestEmailData struct {
+	*Digest
+	Period         string
+	Rows           []digestRow
+	HasTargets     bool
+	UnsubscribeURL string
+}
+
+// SendDigestEmail sends a summary of the user's intake against their daily targets
+func (s *Service) SendDigestEmail(toEmail, language string, digest *Digest, unsubscribeURL string) error {
+	s.logger.Debug("Sending digest email", "to", toEmail, "language", language, "date_from", digest.DateFrom, "date_to", digest.DateTo)
+
+	t := i18n.GetTranslator()
+	data := DigestEmailData{
+		Digest:         digest,
+		Period:         digest.DateFrom,
+		HasTargets:     digest.Targets != nil,
+		UnsubscribeURL: unsubscribeURL,
+	}
+	if digest.DateTo != digest.DateFrom {
+		data.Period = digest.DateFrom + " – " + digest.DateTo
+	}
+
+	kcal, g := t.Get(language, "email.digest.kcal"), t.Get(language, "email.digest.g")
+	rows := []digestRow{
+		{Label: t.Get(language, "email.digest.calories"), Unit: kcal, Actual: digest.Calories},
+		{Label: t.Get(language, "email.digest.proteins"), Unit: g, Actual: digest.Proteins},
+		{Label: t.Get(language, "email.digest.fats"), Unit: g, Actual: digest.Fats},
+		{Label: t.Get(language, "email.digest.carbs"), Unit: g, Actual: digest.Carbs},
+	}
+	if digest.Targets != nil {
+		targets := []float64{digest.Targets.Calories, digest.Targets.Proteins, digest.Targets.Fats, digest.Targets.Carbs}
+		for i := range rows {
+			rows[i].Target = targets[i]
+			if targets[i] > 0 {
+				rows[i].Percent = int(math.Round(rows[i].Actual / targets[i] * 100))
+			}
+		}
+	}
+	data.Rows = rows
+
+	tmpl, err := template.New("digest").Funcs(template.FuncMap{
+		"t": func(key string) string { return t.Get(language, key) },
+	}).Parse(digestTemplate)
+	if err != nil {
+		s.logger.Error("Failed to parse digest email template", "error", err)
+		return err
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		s.logger.Error("Failed to execute digest email template", "error", err)
+		return err
+	}
+
+	subject := s.emailSubject(language, "email.digestSubject")
+	headers := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n%sMIME-Version: 1.0\r\nContent-Type: text/html; charset=UTF-8\r\n\r\n",
+		s.config.SMTPFrom, toEmail, subject, unsubscribeHeaders(unsubscribeURL))
+
+	message := []byte(headers + body.String())
+
+	// Send email via SMTP
+	auth := smtp.PlainAuth("", s.config.SMTPUser, s.config.SMTPPassword, s.config.SMTPHost)
+	smtpAddr := fmt.Sprintf("%s:%d", s.config.SMTPHost, s.config.SMTPPort)
+
+	err = smtp.SendMail(smtpAddr, auth, s.config.SMTPFrom, []string{toEmail}, message)
+	if err != nil {
+		s.logger.Error("Failed to send digest email", "error", err, "to", toEmail)
+		return err
+	}
+
+	s.logger.Info("Digest email sent", "to", toEmail)
+	return nil
+}
+
+// unsubscribeHeaders returns the List-Unsubscribe headers (RFC 2369, RFC 8058)
+// that let mail clients offer a one-click unsubscribe, or nothing without a URL
+func unsubscribeHeaders(unsubscribeURL string) string {
+	if unsubscribeURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("List-Unsubscribe: <%s>\r\nList-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n", unsubscribeURL)
+}
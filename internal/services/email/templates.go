@@ -130,6 +130,11 @@ const exportTemplateEN = `
         <p style="color: #6b7280; font-size: 14px; margin-top: 30px;">
             This is an automated message. Please do not reply to this email.
         </p>
+        {{if .UnsubscribeURL}}
+        <p style="color: #9ca3af; font-size: 12px;">
+            {{.UnsubscribeText}} <a href="{{.UnsubscribeURL}}" style="color: #6b7280;">{{.UnsubscribeLink}}</a>
+        </p>
+        {{end}}
     </div>
 </body>
 </html>
@@ -153,6 +158,11 @@ const exportTemplateUK = `
         <p style="color: #6b7280; font-size: 14px; margin-top: 30px;">
             Це автоматичне повідомлення. Будь ласка, не відповідайте на цей лист.
         </p>
+        {{if .UnsubscribeURL}}
+        <p style="color: #9ca3af; font-size: 12px;">
+            {{.UnsubscribeText}} <a href="{{.UnsubscribeURL}}" style="color: #6b7280;">{{.UnsubscribeLink}}</a>
+        </p>
+        {{end}}
     </div>
 </body>
 </html>
@@ -176,6 +186,11 @@ const exportTemplateRU = `
         <p style="color: #6b7280; font-size: 14px; margin-top: 30px;">
             Это автоматическое сообщение. Пожалуйста, не отвечайте на это письмо.
         </p>
+        {{if .UnsubscribeURL}}
+        <p style="color: #9ca3af; font-size: 12px;">
+            {{.UnsubscribeText}} <a href="{{.UnsubscribeURL}}" style="color: #6b7280;">{{.UnsubscribeLink}}</a>
+        </p>
+        {{end}}
     </div>
 </body>
 </html>
@@ -199,6 +214,80 @@ const exportTemplateBG = `
         <p style="color: #6b7280; font-size: 14px; margin-top: 30px;">
             Това е автоматично съобщение. Моля, не отговаряйте на този имейл.
         </p>
+        {{if .UnsubscribeURL}}
+        <p style="color: #9ca3af; font-size: 12px;">
+            {{.UnsubscribeText}} <a href="{{.UnsubscribeURL}}" style="color: #6b7280;">{{.UnsubscribeLink}}</a>
+        </p>
+        {{end}}
+    </div>
+</body>
+</html>
+`
+
+// Digest email template. Labels come from the translations, so one template serves all languages.
+const digestTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>{{t "email.digest.title"}}</title>
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333; max-width: 600px; margin: 0 auto; padding: 20px;">
+    <div style="background-color: #f8f9fa; padding: 20px; border-radius: 8px;">
+        <h1 style="color: #3b82f6; margin-bottom: 10px;">{{t "email.digest.title"}}</h1>
+        <p style="color: #6b7280; margin-top: 0;">{{.Period}}</p>
+
+        {{if eq .DaysLogged 0}}
+        <p>{{t "email.digest.no_entries"}}</p>
+        {{else}}
+        {{if gt .Days 1}}
+        <p>{{t "email.digest.averages"}} ({{t "email.digest.days_logged"}}: {{.DaysLogged}} / {{.Days}})</p>
+        {{end}}
+        <table style="width: 100%; border-collapse: collapse; background-color: #ffffff;">
+            <tr style="background-color: #e5e7eb;">
+                <th style="text-align: left; padding: 8px;"></th>
+                <th style="text-align: right; padding: 8px;">{{t "email.digest.eaten"}}</th>
+                {{if .HasTargets}}
+                <th style="text-align: right; padding: 8px;">{{t "email.digest.target"}}</th>
+                <th style="text-align: right; padding: 8px;">%</th>
+                {{end}}
+            </tr>
+            {{range .Rows}}
+            <tr style="border-top: 1px solid #e5e7eb;">
+                <td style="padding: 8px;">{{.Label}}</td>
+                <td style="text-align: right; padding: 8px;">{{printf "%.0f" .Actual}} {{.Unit}}</td>
+                {{if $.HasTargets}}
+                <td style="text-align: right; padding: 8px;">{{printf "%.0f" .Target}} {{.Unit}}</td>
+                <td style="text-align: right; padding: 8px; color: {{if gt .Percent 110}}#dc2626{{else}}#059669{{end}};">{{.Percent}}%</td>
+                {{end}}
+            </tr>
+            {{end}}
+        </table>
+        {{if not .HasTargets}}
+        <p style="color: #6b7280; font-size: 14px;">{{t "email.digest.no_targets"}}</p>
+        {{end}}
+
+        {{if gt (len .DailyCalories) 1}}
+        <h2 style="font-size: 18px; margin-top: 30px;">{{t "email.digest.by_day"}}</h2>
+        <table style="width: 100%; border-collapse: collapse; background-color: #ffffff;">
+            {{range .DailyCalories}}
+            <tr style="border-top: 1px solid #e5e7eb;">
+                <td style="padding: 6px 8px;">{{.Date}}</td>
+                <td style="text-align: right; padding: 6px 8px;">{{printf "%.0f" .Calories}} {{t "email.digest.kcal"}}</td>
+            </tr>
+            {{end}}
+        </table>
+        {{end}}
+        {{end}}
+
+        <p style="color: #6b7280; font-size: 14px; margin-top: 30px;">
+            {{t "email.digest.automated"}}
+        </p>
+        {{if .UnsubscribeURL}}
+        <p style="color: #9ca3af; font-size: 12px;">
+            {{t "email.unsubscribe.footer"}} <a href="{{.UnsubscribeURL}}" style="color: #6b7280;">{{t "email.unsubscribe.link"}}</a>
+        </p>
+        {{end}}
     </div>
 </body>
 </html>
@@ -0,0 +1,256 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// Chart images are rendered large and scaled down in the PDF so they stay sharp when printed
+const (
+	chartWidth    = 1600
+	chartHeight   = 640
+	chartFontSize = 24
+	chartTicks    = 5
+)
+
+var (
+	chartBackground = color.RGBA{255, 255, 255, 255}
+	chartGrid       = color.RGBA{225, 228, 232, 255}
+	chartText       = color.RGBA{90, 96, 104, 255}
+	chartLine       = color.RGBA{37, 99, 235, 255}
+	chartBar        = color.RGBA{16, 185, 129, 255}
+	chartReference  = color.RGBA{220, 38, 38, 255}
+)
+
+// chartPoint is one value of a chart; X is a position on the x axis in any unit
+type chartPoint struct {
+	X     float64
+	Value float64
+}
+
+// chartLabel is a label on the x axis
+type chartLabel struct {
+	X    float64
+	Text string
+}
+
+// chart is a canvas with a plot area and value axis
+type chart struct {
+	img        *image.RGBA
+	face       font.Face
+	plot       image.Rectangle
+	minX, maxX float64
+	minY, maxY float64
+}
+
+// newChart creates a blank chart whose axes cover the given ranges
+func newChart(minX, maxX, minY, maxY float64) (*chart, error) {
+	parsed, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse chart font: %w", err)
+	}
+	face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: chartFontSize, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chart font: %w", err)
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, chartWidth, chartHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(chartBackground), image.Point{}, draw.Src)
+
+	if maxX <= minX {
+		maxX = minX + 1
+	}
+	return &chart{
+		img:  img,
+		face: face,
+		plot: image.Rect(110, 30, chartWidth-40, chartHeight-60),
+		minX: minX,
+		maxX: maxX,
+		minY: minY,
+		maxY: maxY,
+	}, nil
+}
+
+// px converts an x axis value to a pixel column
+func (c *chart) px(x float64) float64 {
+	return float64(c.plot.Min.X) + (x-c.minX)/(c.maxX-c.minX)*float64(c.plot.Dx())
+}
+
+// py converts a value to a pixel row
+func (c *chart) py(value float64) float64 {
+	return float64(c.plot.Max.Y) - (value-c.minY)/(c.maxY-c.minY)*float64(c.plot.Dy())
+}
+
+// drawAxes draws horizontal grid lines with value labels and the x axis labels
+func (c *chart) drawAxes(step float64, labels []chartLabel) {
+	decimals := 0
+	if step < 1 {
+		decimals = 1
+	}
+	for value := c.minY; value <= c.maxY+step/2; value += step {
+		y := c.py(value)
+		c.line([][2]float64{{float64(c.plot.Min.X), y}, {float64(c.plot.Max.X), y}}, 1, chartGrid)
+		c.text(float64(c.plot.Min.X)-12, y+chartFontSize/3, strconv.FormatFloat(value, 'f', decimals, 64), 1)
+	}
+	for _, label := range labels {
+		c.text(c.px(label.X), float64(c.plot.Max.Y)+chartFontSize+14, label.Text, 0.5)
+	}
+}
+
+// text draws s with its anchor at x; align is 0 for left, 0.5 for centered and 1 for right.
+// Text is kept inside the image, so labels at the ends of an axis are not cut off.
+func (c *chart) text(x, y float64, s string, align float64) {
+	d := &font.Drawer{Dst: c.img, Src: image.NewUniform(chartText), Face: c.face}
+	width := float64(d.MeasureString(s)) / 64
+	left := math.Max(4, math.Min(x-width*align, chartWidth-width-4))
+	d.Dot = fixed.P(int(left), int(y))
+	d.DrawString(s)
+}
+
+// line strokes a polyline given in pixel coordinates
+func (c *chart) line(points [][2]float64, width float64, col color.Color) {
+	for i := 1; i < len(points); i++ {
+		x0, y0, x1, y1 := points[i-1][0], points[i-1][1], points[i][0], points[i][1]
+		length := math.Hypot(x1-x0, y1-y0)
+		if length == 0 {
+			continue
+		}
+		nx, ny := -(y1-y0)/length*width/2, (x1-x0)/length*width/2
+		c.fill(col, [][2]float64{{x0 + nx, y0 + ny}, {x1 + nx, y1 + ny}, {x1 - nx, y1 - ny}, {x0 - nx, y0 - ny}})
+	}
+}
+
+// dashed strokes a horizontal dashed line at a value
+func (c *chart) dashed(value float64, col color.Color) {
+	y := c.py(value)
+	for x := float64(c.plot.Min.X); x < float64(c.plot.Max.X); x += 24 {
+		c.line([][2]float64{{x, y}, {math.Min(x+14, float64(c.plot.Max.X)), y}}, 3, col)
+	}
+}
+
+// dot fills a circle around a pixel position
+func (c *chart) dot(x, y, radius float64, col color.Color) {
+	const segments = 16
+	polygon := make([][2]float64, segments)
+	for i := range polygon {
+		angle := 2 * math.Pi * float64(i) / segments
+		polygon[i] = [2]float64{x + radius*math.Cos(angle), y + radius*math.Sin(angle)}
+	}
+	c.fill(col, polygon)
+}
+
+// fill paints an anti-aliased polygon
+func (c *chart) fill(col color.Color, polygon [][2]float64) {
+	r := vector.NewRasterizer(chartWidth, chartHeight)
+	r.DrawOp = draw.Over
+	r.MoveTo(float32(polygon[0][0]), float32(polygon[0][1]))
+	for _, p := range polygon[1:] {
+		r.LineTo(float32(p[0]), float32(p[1]))
+	}
+	r.ClosePath()
+	r.Draw(c.img, c.img.Bounds(), image.NewUniform(col), image.Point{})
+}
+
+// png encodes the chart; the image is opaque so it is written without an alpha channel
+func (c *chart) png() ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := png.Encode(&buffer, c.img); err != nil {
+		return nil, fmt.Errorf("failed to encode chart: %w", err)
+	}
+	return buffer.Bytes(), nil
+}
+
+// axisRange widens [low, high] to round tick values and returns the tick step
+func axisRange(low, high float64, zeroBased bool) (float64, float64, float64) {
+	if zeroBased {
+		low = math.Min(low, 0)
+	}
+	if high-low < 1 {
+		low, high = low-0.5, high+0.5
+	}
+
+	// Pick a step of 1, 2 or 5 times a power of ten
+	raw := (high - low) / (chartTicks - 1)
+	magnitude := math.Pow(10, math.Floor(math.Log10(raw)))
+	step := 10 * magnitude
+	for _, factor := range []float64{1, 2, 5} {
+		if factor*magnitude >= raw {
+			step = factor * magnitude
+			break
+		}
+	}
+	return math.Floor(low/step) * step, math.Ceil(high/step) * step, step
+}
+
+// renderLineChart draws values as a line with markers and an optional dashed reference value
+func renderLineChart(points []chartPoint, labels []chartLabel, minX, maxX float64, reference *float64) ([]byte, error) {
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		low, high = math.Min(low, p.Value), math.Max(high, p.Value)
+	}
+	if reference != nil {
+		low, high = math.Min(low, *reference), math.Max(high, *reference)
+	}
+	low, high, step := axisRange(low, high, false)
+
+	c, err := newChart(minX, maxX, low, high)
+	if err != nil {
+		return nil, err
+	}
+	c.drawAxes(step, labels)
+	if reference != nil {
+		c.dashed(*reference, chartReference)
+	}
+
+	pixels := make([][2]float64, len(points))
+	for i, p := range points {
+		pixels[i] = [2]float64{c.px(p.X), c.py(p.Value)}
+	}
+	c.line(pixels, 4, chartLine)
+	for _, p := range pixels {
+		c.dot(p[0], p[1], 6, chartLine)
+	}
+	return c.png()
+}
+
+// renderBarChart draws one bar per point, where X is the index of a slot of
+// slots, with an optional dashed reference value
+func renderBarChart(points []chartPoint, labels []chartLabel, slots int, reference *float64) ([]byte, error) {
+	high := 0.0
+	for _, p := range points {
+		high = math.Max(high, p.Value)
+	}
+	if reference != nil {
+		high = math.Max(high, *reference)
+	}
+	low, high, step := axisRange(0, high, true)
+
+	// Bars are centered on X, so the axis runs half a slot past either end
+	c, err := newChart(-0.5, float64(slots)-0.5, low, high)
+	if err != nil {
+		return nil, err
+	}
+	c.drawAxes(step, labels)
+
+	half := math.Max(1, float64(c.plot.Dx())/float64(slots)*0.35)
+	for _, p := range points {
+		x, top, bottom := c.px(p.X), c.py(p.Value), c.py(0)
+		c.fill(chartBar, [][2]float64{{x - half, top}, {x + half, top}, {x + half, bottom}, {x - half, bottom}})
+	}
+	if reference != nil {
+		c.dashed(*reference, chartReference)
+	}
+	return c.png()
+}
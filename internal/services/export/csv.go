@@ -0,0 +1,133 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// Column names of CSV exports. They are not translated so that scripts reading
+// the files work for every user; they match the keys of the Excel columns.
+var (
+	csvWeightColumns = []string{"date", "weight_kg"}
+	csvFoodColumns   = []string{"date", "time", "food", "weight_g", "calories", "kcal_per100", "fats", "carbs", "proteins"}
+)
+
+// CSVExporter writes RFC 4180 CSV files with CRLF line endings
+type CSVExporter struct{}
+
+// NewCSVExporter creates a new CSV exporter
+func NewCSVExporter() *CSVExporter {
+	return &CSVExporter{}
+}
+
+// ContentType returns text/csv, or application/zip when both data types are exported
+func (e *CSVExporter) ContentType(dataType ExportDataType) string {
+	if dataType == ExportBoth {
+		return "application/zip"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FileExtension returns csv, or zip when both data types are exported
+func (e *CSVExporter) FileExtension(dataType ExportDataType) string {
+	if dataType == ExportBoth {
+		return "zip"
+	}
+	return "csv"
+}
+
+// Write streams one CSV file, or a ZIP archive with weight.csv and food.csv
+func (e *CSVExporter) Write(w io.Writer, data *ExportData) error {
+	switch data.DataType {
+	case ExportWeight:
+		return writeWeightCSV(w, data.Weight)
+	case ExportFood:
+		return writeFoodCSV(w, data.Food)
+	}
+
+	archive := zip.NewWriter(w)
+	weightFile, err := archive.Create("weight.csv")
+	if err != nil {
+		return err
+	}
+	if err := writeWeightCSV(weightFile, data.Weight); err != nil {
+		return err
+	}
+	foodFile, err := archive.Create("food.csv")
+	if err != nil {
+		return err
+	}
+	if err := writeFoodCSV(foodFile, data.Food); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func newCSVWriter(w io.Writer) *csv.Writer {
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = true
+	return writer
+}
+
+func writeWeightCSV(w io.Writer, data []*models.WeightHistory) error {
+	writer := newCSVWriter(w)
+	if err := writer.Write(csvWeightColumns); err != nil {
+		return err
+	}
+	for _, entry := range data {
+		if err := writer.Write([]string{entry.RecordedAt.Format("2006-01-02"), formatNumber(entry.Weight)}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeFoodCSV(w io.Writer, data []*models.CalorieEntry) error {
+	writer := newCSVWriter(w)
+	nutrients := usedNutrients(data)
+	if err := writer.Write(append(append([]string{}, csvFoodColumns...), nutrients...)); err != nil {
+		return err
+	}
+
+	record := make([]string, 0, len(csvFoodColumns)+len(nutrients))
+	for _, entry := range data {
+		record = record[:0]
+		record = append(record,
+			entry.MealDatetime.Format("2006-01-02"),
+			entry.MealDatetime.Format("15:04"),
+			// Spreadsheet apps evaluate formulas in CSV files too
+			sanitizeForExcel(entry.Food),
+			formatNumber(entry.Weight),
+			strconv.Itoa(entry.Calories),
+			formatNumber(entry.KcalPer100g),
+			formatOptional(entry.Fats),
+			formatOptional(entry.Carbs),
+			formatOptional(entry.Proteins),
+		)
+		// Unknown nutrients are left blank rather than written as zero
+		for _, code := range nutrients {
+			record = append(record, formatOptional(entry.Nutrients.Get(code)))
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func formatNumber(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatOptional(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return formatNumber(*value)
+}
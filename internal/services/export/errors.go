@@ -0,0 +1,13 @@
+package export
+
+import "errors"
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported export format")
+	ErrEmailRequired     = errors.New("email address is required for email delivery")
+	ErrJobNotFound       = errors.New("export job not found")
+	ErrJobNotReady       = errors.New("export job is not finished yet")
+	ErrJobFailed         = errors.New("export job failed")
+	ErrJobExpired        = errors.New("export file has expired")
+	ErrJobNoFile         = errors.New("export was delivered by email")
+)
@@ -1,7 +1,9 @@
 package export
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/xuri/excelize/v2"
@@ -41,6 +43,30 @@ func (g *ExcelGenerator) tr(lang, key string) string {
 	return g.t.Get(lang, key)
 }
 
+// ContentType returns the MIME type of Excel workbooks
+func (g *ExcelGenerator) ContentType(ExportDataType) string {
+	return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+}
+
+// FileExtension returns xlsx
+func (g *ExcelGenerator) FileExtension(ExportDataType) string {
+	return "xlsx"
+}
+
+// Write creates an Excel file with the requested data and writes it to w
+func (g *ExcelGenerator) Write(w io.Writer, data *ExportData) error {
+	f, err := g.build(data)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write Excel file: %w", err)
+	}
+	return nil
+}
+
 // Generate creates an Excel file with the requested data
 func (g *ExcelGenerator) Generate(
 	weightData []*models.WeightHistory,
@@ -48,76 +74,99 @@ func (g *ExcelGenerator) Generate(
 	dataType ExportDataType,
 	language string,
 ) ([]byte, error) {
+	var buffer bytes.Buffer
+	err := g.Write(&buffer, &ExportData{Weight: weightData, Food: foodData, DataType: dataType, Language: language})
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// build lays out the sheets of the workbook: a summary first, then the raw
+// records, and for food the totals of each day
+func (g *ExcelGenerator) build(data *ExportData) (*excelize.File, error) {
 	f := excelize.NewFile()
-	defer f.Close()
+	lang := data.Language
 
-	// Track if we've created any sheets
-	sheetsCreated := 0
+	styles, err := newExcelStyles(f, g.units(lang))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	wb := &excelWorkbook{
+		File:    f,
+		styles:  styles,
+		summary: g.tr(lang, "export.sheets.summary"),
+	}
+	f.SetSheetName("Sheet1", wb.summary)
 
 	// Create Weight History sheet if requested
-	if dataType == ExportWeight || dataType == ExportBoth {
-		sheetName := g.tr(language, "export.sheets.weight")
-		if sheetsCreated == 0 {
-			f.SetSheetName("Sheet1", sheetName)
-		} else {
-			f.NewSheet(sheetName)
-		}
-		sheetsCreated++
+	if data.DataType == ExportWeight || data.DataType == ExportBoth {
+		wb.weight = g.tr(lang, "export.sheets.weight")
+		f.NewSheet(wb.weight)
 
-		if err := g.writeWeightSheet(f, sheetName, weightData, language); err != nil {
+		if err := g.writeWeightSheet(wb, data.Weight, lang); err != nil {
+			f.Close()
 			return nil, fmt.Errorf("failed to write weight sheet: %w", err)
 		}
 	}
 
-	// Create Food Entries sheet if requested
-	if dataType == ExportFood || dataType == ExportBoth {
-		sheetName := g.tr(language, "export.sheets.food")
-		if sheetsCreated == 0 {
-			f.SetSheetName("Sheet1", sheetName)
-		} else {
-			f.NewSheet(sheetName)
-		}
-		sheetsCreated++
+	// Create Food Entries and Daily Totals sheets if requested
+	if data.DataType == ExportFood || data.DataType == ExportBoth {
+		wb.food = g.tr(lang, "export.sheets.food")
+		f.NewSheet(wb.food)
 
-		if err := g.writeFoodSheet(f, sheetName, foodData, language); err != nil {
+		if err := g.writeFoodSheet(wb, data.Food, lang); err != nil {
+			f.Close()
 			return nil, fmt.Errorf("failed to write food sheet: %w", err)
 		}
-	}
 
-	// Delete default Sheet1 if it still exists and we created other sheets
-	if sheetsCreated > 0 {
-		sheetIndex, _ := f.GetSheetIndex("Sheet1")
-		if sheetIndex >= 0 {
-			f.DeleteSheet("Sheet1")
+		wb.daily = g.tr(lang, "export.sheets.daily")
+		f.NewSheet(wb.daily)
+
+		if err := g.writeDailySheet(wb, data.Food, lang); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to write daily totals sheet: %w", err)
 		}
 	}
 
-	// Write to buffer
-	buffer, err := f.WriteToBuffer()
-	if err != nil {
-		return nil, fmt.Errorf("failed to write Excel to buffer: %w", err)
+	if err := g.writeSummarySheet(wb, data); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write summary sheet: %w", err)
 	}
 
-	return buffer.Bytes(), nil
+	// Daily totals are formulas without stored results, so spreadsheet apps calculate them on opening
+	fullCalc := true
+	if err := f.SetCalcProps(&excelize.CalcPropsOptions{FullCalcOnLoad: &fullCalc}); err != nil {
+		f.Close()
+		return nil, err
+	}
+	f.SetActiveSheet(0)
+
+	return f, nil
 }
 
-func (g *ExcelGenerator) writeWeightSheet(f *excelize.File, sheetName string, data []*models.WeightHistory, lang string) error {
+func (g *ExcelGenerator) writeWeightSheet(wb *excelWorkbook, data []*models.WeightHistory, lang string) error {
+	sheetName := wb.weight
+
+	// Column formats apply to the cells written below
+	f := wb.File
+	f.SetColStyle(sheetName, "A", wb.styles.date)
+	f.SetColStyle(sheetName, "B", wb.styles.decimal)
+
 	// Write headers
 	f.SetCellValue(sheetName, "A1", g.tr(lang, "export.columns.date"))
 	f.SetCellValue(sheetName, "B1", g.tr(lang, "export.columns.weight_kg"))
-
-	// Style headers (bold)
-	headerStyle, _ := f.NewStyle(&excelize.Style{
-		Font: &excelize.Font{Bold: true},
-	})
-	f.SetCellStyle(sheetName, "A1", "B1", headerStyle)
+	f.SetCellStyle(sheetName, "A1", "B1", wb.styles.header)
 
 	// Write data
 	for i, entry := range data {
 		row := i + 2
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), entry.RecordedAt.Format("2006-01-02"))
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), excelDay(entry.RecordedAt))
 		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), entry.Weight)
 	}
+	wb.weightRows = len(data)
 
 	// Set column widths
 	f.SetColWidth(sheetName, "A", "A", 15)
@@ -126,40 +175,54 @@ func (g *ExcelGenerator) writeWeightSheet(f *excelize.File, sheetName string, da
 	return nil
 }
 
-func (g *ExcelGenerator) writeFoodSheet(f *excelize.File, sheetName string, data []*models.CalorieEntry, lang string) error {
+func (g *ExcelGenerator) writeFoodSheet(wb *excelWorkbook, data []*models.CalorieEntry, lang string) error {
+	sheetName := wb.food
+	f := wb.File
+
 	// Write headers
-	columns := []struct {
+	type column struct {
 		col   string
 		key   string
 		width float64
-	}{
-		{"A", "export.columns.date", 12},
-		{"B", "export.columns.time", 10},
-		{"C", "export.columns.food", 30},
-		{"D", "export.columns.weight_g", 12},
-		{"E", "export.columns.calories", 12},
-		{"F", "export.columns.kcal_per100", 12},
-		{"G", "export.columns.fats", 12},
-		{"H", "export.columns.carbs", 12},
-		{"I", "export.columns.proteins", 12},
+		style int
+	}
+	columns := []column{
+		{"A", "export.columns.date", 12, wb.styles.date},
+		{"B", "export.columns.time", 10, wb.styles.clock},
+		{"C", "export.columns.food", 30, 0},
+		{"D", "export.columns.weight_g", 12, wb.styles.decimal},
+		{"E", "export.columns.calories", 12, wb.styles.integer},
+		{"F", "export.columns.kcal_per100", 12, wb.styles.decimal},
+		{"G", "export.columns.fats", 12, wb.styles.decimal},
+		{"H", "export.columns.carbs", 12, wb.styles.decimal},
+		{"I", "export.columns.proteins", 12, wb.styles.decimal},
+	}
+
+	// Extended nutrients follow the macros; only nutrients known for some entry get a column
+	nutrients := usedNutrients(data)
+	firstNutrient := len(columns)
+	for i, code := range nutrients {
+		col, err := excelize.ColumnNumberToName(firstNutrient + 1 + i)
+		if err != nil {
+			return err
+		}
+		columns = append(columns, column{col, "export.nutrients." + code, 16, wb.styles.decimal})
 	}
 
 	for _, col := range columns {
+		if col.style != 0 {
+			f.SetColStyle(sheetName, col.col, col.style)
+		}
 		f.SetCellValue(sheetName, col.col+"1", g.tr(lang, col.key))
 		f.SetColWidth(sheetName, col.col, col.col, col.width)
 	}
-
-	// Style headers (bold)
-	headerStyle, _ := f.NewStyle(&excelize.Style{
-		Font: &excelize.Font{Bold: true},
-	})
-	f.SetCellStyle(sheetName, "A1", "I1", headerStyle)
+	f.SetCellStyle(sheetName, "A1", columns[len(columns)-1].col+"1", wb.styles.header)
 
 	// Write data
 	for i, entry := range data {
 		row := i + 2
-		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), entry.MealDatetime.Format("2006-01-02"))
-		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), entry.MealDatetime.Format("15:04"))
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), excelDay(entry.MealDatetime))
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), excelClock(entry.MealDatetime))
 		f.SetCellValue(sheetName, fmt.Sprintf("C%d", row), sanitizeForExcel(entry.Food))
 		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), entry.Weight)
 		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), entry.Calories)
@@ -175,7 +238,29 @@ func (g *ExcelGenerator) writeFoodSheet(f *excelize.File, sheetName string, data
 		if entry.Proteins != nil {
 			f.SetCellValue(sheetName, fmt.Sprintf("I%d", row), *entry.Proteins)
 		}
+
+		// Unknown nutrients are left blank rather than written as zero
+		for j, code := range nutrients {
+			if value := entry.Nutrients.Get(code); value != nil {
+				f.SetCellValue(sheetName, fmt.Sprintf("%s%d", columns[firstNutrient+j].col, row), *value)
+			}
+		}
 	}
+	wb.foodRows = len(data)
 
 	return nil
 }
+
+// usedNutrients returns the codes of extended nutrients known for at least one entry, in catalog order
+func usedNutrients(data []*models.CalorieEntry) []string {
+	var codes []string
+	for _, info := range models.NutrientCatalog {
+		for _, entry := range data {
+			if entry.Nutrients.Get(info.Code) != nil {
+				codes = append(codes, info.Code)
+				break
+			}
+		}
+	}
+	return codes
+}
@@ -0,0 +1,362 @@
+package export
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// Size of the charts on the summary sheet in pixels
+const (
+	excelChartWidth  = 640
+	excelChartHeight = 300
+)
+
+// excelWorkbook is a workbook being built with the names and sizes of its sheets;
+// names are empty for sheets the export does not include
+type excelWorkbook struct {
+	*excelize.File
+	styles     *excelStyles
+	summary    string
+	weight     string
+	food       string
+	daily      string
+	weightRows int
+	foodRows   int
+	dailyRows  int
+}
+
+// excelUnits are the localized units shown in the summary number formats
+type excelUnits struct {
+	kg   string
+	kcal string
+	g    string
+}
+
+// units returns the units of a language
+func (g *ExcelGenerator) units(lang string) excelUnits {
+	return excelUnits{
+		kg:   g.tr(lang, "export.summary.units.kg"),
+		kcal: g.tr(lang, "export.summary.units.kcal"),
+		g:    g.tr(lang, "export.summary.units.g"),
+	}
+}
+
+// excelStyles are the cell styles of a workbook
+type excelStyles struct {
+	header   int
+	title    int
+	date     int
+	clock    int
+	integer  int
+	decimal  int
+	kg       int
+	kgChange int
+	kcal     int
+	grams    int
+	percent  int
+}
+
+// newExcelStyles registers the cell styles in the workbook
+func newExcelStyles(f *excelize.File, units excelUnits) (*excelStyles, error) {
+	var styles excelStyles
+	definitions := []struct {
+		id    *int
+		style *excelize.Style
+	}{
+		{&styles.header, &excelize.Style{Font: &excelize.Font{Bold: true}}},
+		{&styles.title, &excelize.Style{Font: &excelize.Font{Bold: true, Size: 14}}},
+		{&styles.date, &excelize.Style{CustomNumFmt: strPtr("yyyy-mm-dd")}},
+		{&styles.clock, &excelize.Style{CustomNumFmt: strPtr("hh:mm")}},
+		{&styles.integer, &excelize.Style{NumFmt: 1}},
+		{&styles.decimal, &excelize.Style{CustomNumFmt: strPtr("0.0#")}},
+		{&styles.kg, &excelize.Style{CustomNumFmt: strPtr(withUnit("0.0", units.kg))}},
+		{&styles.kgChange, &excelize.Style{CustomNumFmt: strPtr(withUnit("+0.0", units.kg) + ";" + withUnit("-0.0", units.kg) + ";" + withUnit("0.0", units.kg))}},
+		{&styles.kcal, &excelize.Style{CustomNumFmt: strPtr(withUnit("0", units.kcal))}},
+		{&styles.grams, &excelize.Style{CustomNumFmt: strPtr(withUnit("0.0", units.g))}},
+		{&styles.percent, &excelize.Style{CustomNumFmt: strPtr("0.0%")}},
+	}
+	for _, definition := range definitions {
+		id, err := f.NewStyle(definition.style)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create cell style: %w", err)
+		}
+		*definition.id = id
+	}
+	return &styles, nil
+}
+
+// withUnit appends a unit to a number format as literal text
+func withUnit(format, unit string) string {
+	return fmt.Sprintf(`%s" %s"`, format, strings.ReplaceAll(unit, `"`, ""))
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// excelDay returns the calendar day of t, which Excel stores as a whole date serial
+func excelDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// excelClock returns the time of day of t as the fraction of a day Excel uses for times
+func excelClock(t time.Time) float64 {
+	return float64(t.Hour()*60+t.Minute()) / (24 * 60)
+}
+
+// sheetRange returns an absolute reference to rows of a column on another sheet
+func sheetRange(sheet, col string, firstRow, lastRow int) string {
+	return fmt.Sprintf("'%s'!$%s$%d:$%s$%d", strings.ReplaceAll(sheet, "'", "''"), col, firstRow, col, lastRow)
+}
+
+// writeDailySheet writes one row per day with entries. The totals are formulas
+// over the food sheet, so they follow edits made to the entries.
+func (g *ExcelGenerator) writeDailySheet(wb *excelWorkbook, data []*models.CalorieEntry, lang string) error {
+	sheetName := wb.daily
+	f := wb.File
+
+	columns := []struct {
+		col   string
+		key   string
+		style int
+	}{
+		{"A", "export.columns.date", wb.styles.date},
+		{"B", "export.columns.entries", wb.styles.integer},
+		{"C", "export.columns.calories", wb.styles.integer},
+		{"D", "export.columns.fats", wb.styles.decimal},
+		{"E", "export.columns.carbs", wb.styles.decimal},
+		{"F", "export.columns.proteins", wb.styles.decimal},
+	}
+	for _, col := range columns {
+		f.SetColStyle(sheetName, col.col, col.style)
+		f.SetCellValue(sheetName, col.col+"1", g.tr(lang, col.key))
+	}
+	f.SetCellStyle(sheetName, "A1", "F1", wb.styles.header)
+	f.SetColWidth(sheetName, "A", "F", 14)
+
+	lastRow := wb.foodRows + 1
+	dates := sheetRange(wb.food, "A", 2, lastRow)
+	weights := sheetRange(wb.food, "D", 2, lastRow)
+	calories := sheetRange(wb.food, "E", 2, lastRow)
+
+	// Macros are stored per 100 g, so each is scaled by the entry's weight
+	macroFormula := func(col string, row int) string {
+		return fmt.Sprintf("SUMPRODUCT((%s=$A%d)*%s*%s)/100", dates, row, sheetRange(wb.food, col, 2, lastRow), weights)
+	}
+
+	days := dailyIntakes(data)
+	for i, day := range days {
+		row := i + 2
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), day.Date)
+		formulas := map[string]string{
+			"B": fmt.Sprintf("COUNTIF(%s,$A%d)", dates, row),
+			"C": fmt.Sprintf("SUMIF(%s,$A%d,%s)", dates, row, calories),
+			"D": macroFormula("G", row),
+			"E": macroFormula("H", row),
+			"F": macroFormula("I", row),
+		}
+		for col, formula := range formulas {
+			if err := f.SetCellFormula(sheetName, fmt.Sprintf("%s%d", col, row), formula); err != nil {
+				return err
+			}
+		}
+	}
+	wb.dailyRows = len(days)
+
+	return nil
+}
+
+// excelSummary writes label and value rows to the summary sheet
+type excelSummary struct {
+	wb   *excelWorkbook
+	g    *ExcelGenerator
+	lang string
+	row  int
+}
+
+// tr is a shortcut for translating keys of the summary sheet
+func (s *excelSummary) tr(key string) string {
+	return s.g.tr(s.lang, "export.summary."+key)
+}
+
+// section starts a group of rows with a bold title, after a blank row
+func (s *excelSummary) section(key string) {
+	s.row++
+	cell := fmt.Sprintf("A%d", s.row)
+	s.wb.SetCellValue(s.wb.summary, cell, s.tr(key))
+	s.wb.SetCellStyle(s.wb.summary, cell, cell, s.wb.styles.header)
+	s.row++
+}
+
+// value writes a labeled value in the given style
+func (s *excelSummary) value(key string, value any, style int) {
+	s.wb.SetCellValue(s.wb.summary, fmt.Sprintf("A%d", s.row), s.tr(key))
+	cell := fmt.Sprintf("B%d", s.row)
+	s.wb.SetCellValue(s.wb.summary, cell, value)
+	s.wb.SetCellStyle(s.wb.summary, cell, cell, style)
+	s.row++
+}
+
+// valueOn writes a labeled value with the date it belongs to
+func (s *excelSummary) valueOn(key string, value any, style int, date time.Time) {
+	cell := fmt.Sprintf("C%d", s.row)
+	s.wb.SetCellValue(s.wb.summary, cell, excelDay(date))
+	s.wb.SetCellStyle(s.wb.summary, cell, cell, s.wb.styles.date)
+	s.value(key, value, style)
+}
+
+// writeSummarySheet writes the period totals and averages, the goal progress
+// and charts of the weight and daily calories
+func (g *ExcelGenerator) writeSummarySheet(wb *excelWorkbook, data *ExportData) error {
+	s := &excelSummary{wb: wb, g: g, lang: data.Language, row: 3}
+	styles := wb.styles
+	from, to := reportPeriod(data)
+
+	wb.SetCellValue(wb.summary, "A1", s.tr("title"))
+	wb.SetCellStyle(wb.summary, "A1", "A1", styles.title)
+	wb.SetColWidth(wb.summary, "A", "A", 32)
+	wb.SetColWidth(wb.summary, "B", "C", 14)
+	wb.SetCellValue(wb.summary, "C3", excelDay(to))
+	wb.SetCellStyle(wb.summary, "C3", "C3", styles.date)
+	s.value("period", excelDay(from), styles.date)
+
+	days := dailyIntakes(data.Food)
+	weights := sortedWeights(data.Weight)
+
+	if wb.food != "" {
+		s.section("food")
+		s.value("days_logged", len(days), styles.integer)
+		s.value("period_days", int(to.Sub(from).Hours()/24)+1, styles.integer)
+
+		if len(days) > 0 {
+			var calories int
+			var fats, carbs, proteins float64
+			highest, lowest := days[0], days[0]
+			for _, day := range days {
+				calories += day.Calories
+				fats += day.Fats
+				carbs += day.Carbs
+				proteins += day.Proteins
+				if day.Calories > highest.Calories {
+					highest = day
+				}
+				if day.Calories < lowest.Calories {
+					lowest = day
+				}
+			}
+			n := float64(len(days))
+			s.value("avg_calories", math.Round(float64(calories)/n), styles.kcal)
+			s.value("avg_fats", fats/n, styles.grams)
+			s.value("avg_carbs", carbs/n, styles.grams)
+			s.value("avg_proteins", proteins/n, styles.grams)
+			s.value("total_calories", calories, styles.kcal)
+			s.value("total_fats", fats, styles.grams)
+			s.value("total_carbs", carbs, styles.grams)
+			s.value("total_proteins", proteins, styles.grams)
+			s.valueOn("highest_day", highest.Calories, styles.kcal, highest.Date)
+			s.valueOn("lowest_day", lowest.Calories, styles.kcal, lowest.Date)
+		}
+	}
+
+	if wb.weight != "" && len(weights) > 0 {
+		first, last := weights[0], weights[len(weights)-1]
+		lightest, heaviest := first, first
+		var total float64
+		for _, entry := range weights {
+			total += entry.Weight
+			if entry.Weight < lightest.Weight {
+				lightest = entry
+			}
+			if entry.Weight > heaviest.Weight {
+				heaviest = entry
+			}
+		}
+
+		s.section("weight")
+		s.valueOn("weight_start", first.Weight, styles.kg, first.RecordedAt)
+		s.valueOn("weight_end", last.Weight, styles.kg, last.RecordedAt)
+		s.value("weight_change", last.Weight-first.Weight, styles.kgChange)
+		s.valueOn("weight_min", lightest.Weight, styles.kg, lightest.RecordedAt)
+		s.valueOn("weight_max", heaviest.Weight, styles.kg, heaviest.RecordedAt)
+		s.value("weight_avg", total/float64(len(weights)), styles.kg)
+	}
+
+	if (wb.food == "" || len(days) == 0) && (wb.weight == "" || len(weights) == 0) {
+		s.row++
+		wb.SetCellValue(wb.summary, fmt.Sprintf("A%d", s.row), s.tr("no_data"))
+		s.row++
+	}
+
+	if goal := data.Goal; goal != nil {
+		s.section("goal")
+		s.value("target_weight", goal.TargetWeight, styles.kg)
+		s.valueOn("initial_weight", goal.InitialWeightAtGoal, styles.kg, goal.GoalSetAt)
+		s.value("current_weight", goal.CurrentWeight, styles.kg)
+		s.value("weight_to_go", math.Abs(goal.WeightToGo), styles.kg)
+		if goal.TargetDate != nil {
+			s.value("target_date", excelDay(*goal.TargetDate), styles.date)
+		}
+		if goal.EstimatedCompletion != nil {
+			s.value("estimated_completion", excelDay(*goal.EstimatedCompletion), styles.date)
+		}
+		if goal.DailyDeficitNeeded != nil {
+			key := "daily_deficit"
+			if goal.IsGaining {
+				key = "daily_surplus"
+			}
+			s.value(key, math.Round(*goal.DailyDeficitNeeded), styles.kcal)
+		}
+		s.value("progress", goal.ProgressPercent/100, styles.percent)
+	}
+
+	return g.addSummaryCharts(s)
+}
+
+// addSummaryCharts places line charts of the weight records and the daily
+// calories next to the summary. Sheets without rows get no chart.
+func (g *ExcelGenerator) addSummaryCharts(s *excelSummary) error {
+	wb := s.wb
+	anchor := 2
+
+	type series struct {
+		sheet, valueCol string
+		rows            int
+		title           string
+	}
+	charts := []series{
+		{wb.weight, "B", wb.weightRows, s.tr("weight_chart")},
+		{wb.daily, "C", wb.dailyRows, s.tr("calorie_chart")},
+	}
+	for _, chart := range charts {
+		if chart.sheet == "" || chart.rows == 0 {
+			continue
+		}
+		err := wb.AddChart(wb.summary, fmt.Sprintf("E%d", anchor), &excelize.Chart{
+			Type: excelize.Line,
+			Series: []excelize.ChartSeries{{
+				Name:       sheetRange(chart.sheet, chart.valueCol, 1, 1),
+				Categories: sheetRange(chart.sheet, "A", 2, chart.rows+1),
+				Values:     sheetRange(chart.sheet, chart.valueCol, 2, chart.rows+1),
+				Marker:     excelize.ChartMarker{Symbol: "circle", Size: 5},
+			}},
+			Title:        []excelize.RichTextRun{{Text: chart.title}},
+			Legend:       excelize.ChartLegend{Position: "none"},
+			Dimension:    excelize.ChartDimension{Width: excelChartWidth, Height: excelChartHeight},
+			XAxis:        excelize.ChartAxis{NumFmt: excelize.ChartNumFmt{CustomNumFmt: "yyyy-mm-dd"}},
+			YAxis:        excelize.ChartAxis{MajorGridLines: true},
+			ShowBlanksAs: "gap",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add chart: %w", err)
+		}
+		// Rows are 20 pixels high by default
+		anchor += excelChartHeight/20 + 2
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"ypeskov/kkal-tracker/internal/models"
+	metricsservice "ypeskov/kkal-tracker/internal/services/metrics"
+	profileservice "ypeskov/kkal-tracker/internal/services/profile"
+)
+
+// Format is the file format of an export
+type Format string
+
+const (
+	FormatExcel  Format = "xlsx"
+	FormatCSV    Format = "csv"    // One file per data type, zipped when both are exported
+	FormatNDJSON Format = "ndjson" // One JSON object per line
+	FormatPDF    Format = "pdf"    // Printable progress report
+)
+
+// ExportData is the data written by an exporter
+type ExportData struct {
+	Weight   []*models.WeightHistory
+	Food     []*models.CalorieEntry
+	DataType ExportDataType
+	Language string
+	DateFrom string // YYYY-MM-DD
+	DateTo   string // YYYY-MM-DD
+
+	// Loaded only for the PDF report and, for the goal, the Excel summary
+	Goal   *profileservice.WeightGoalResponse // nil when no weight goal is set
+	Health *metricsservice.HealthMetrics
+}
+
+// Exporter writes export data in one file format
+type Exporter interface {
+	// ContentType returns the MIME type of the file written for a data type
+	ContentType(dataType ExportDataType) string
+	// FileExtension returns the file name extension, without the dot
+	FileExtension(dataType ExportDataType) string
+	// Write streams the file to w
+	Write(w io.Writer, data *ExportData) error
+}
+
+// NewExporter returns the exporter for a format; an empty format means Excel
+func NewExporter(format Format) (Exporter, error) {
+	switch format {
+	case FormatExcel, "":
+		return NewExcelGenerator(), nil
+	case FormatCSV:
+		return NewCSVExporter(), nil
+	case FormatNDJSON:
+		return NewNDJSONExporter(), nil
+	case FormatPDF:
+		return NewPDFGenerator(), nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, format)
+}
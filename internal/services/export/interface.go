@@ -1,6 +1,13 @@
 package export
 
+import "ypeskov/kkal-tracker/internal/models"
+
 // Servicer defines the export service contract used by handlers.
 type Servicer interface {
 	Export(req *ExportRequest) (*ExportResult, error)
+	Stream(req *ExportRequest) (*ExportStream, error)
+	EnqueueJob(req *ExportRequest) (*models.ExportJob, error)
+	GetJob(userID, jobID int) (*models.ExportJob, error)
+	ListJobs(userID int) ([]*models.ExportJob, error)
+	GetJobFile(userID, jobID int) (*ExportResult, error)
 }
@@ -0,0 +1,216 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// Background job settings
+const (
+	jobPollInterval    = 5 * time.Second
+	jobCleanupInterval = time.Hour
+	jobStaleAfter      = 15 * time.Minute    // Running jobs not finished by then are picked up again
+	jobMaxAttempts     = 3                   // Tries before a job that keeps stalling is failed
+	jobHistoryLimit    = 20                  // Jobs returned by ListJobs
+	jobRetention       = 30 * 24 * time.Hour // Finished jobs stay listed this long
+)
+
+// EnqueueJob queues an export for the background worker
+func (s *Service) EnqueueJob(req *ExportRequest) (*models.ExportJob, error) {
+	s.logger.Debug("EnqueueJob called",
+		"user_id", req.UserID,
+		"date_from", req.DateFrom,
+		"date_to", req.DateTo,
+		"data_type", req.DataType,
+		"format", req.Format,
+		"delivery_type", req.DeliveryType,
+	)
+
+	if _, err := NewExporter(req.Format); err != nil {
+		return nil, err
+	}
+	if req.DeliveryType == DeliveryEmail && req.UserEmail == "" {
+		return nil, ErrEmailRequired
+	}
+
+	format := req.Format
+	if format == "" {
+		format = FormatExcel
+	}
+	job := &models.ExportJob{
+		UserID:       req.UserID,
+		DateFrom:     req.DateFrom,
+		DateTo:       req.DateTo,
+		DataType:     string(req.DataType),
+		Format:       string(format),
+		DeliveryType: string(req.DeliveryType),
+		Language:     req.Language,
+		Email:        req.UserEmail,
+	}
+	if err := s.jobRepo.Create(job); err != nil {
+		return nil, fmt.Errorf("failed to queue export: %w", err)
+	}
+
+	s.logger.Info("Export job queued", "user_id", req.UserID, "job_id", job.ID)
+	return job, nil
+}
+
+// GetJob returns one of the user's export jobs
+func (s *Service) GetJob(userID, jobID int) (*models.ExportJob, error) {
+	s.logger.Debug("GetJob called", "user_id", userID, "job_id", jobID)
+
+	job, err := s.jobRepo.GetByID(jobID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// ListJobs returns the user's recent export jobs, newest first
+func (s *Service) ListJobs(userID int) ([]*models.ExportJob, error) {
+	s.logger.Debug("ListJobs called", "user_id", userID)
+	return s.jobRepo.GetByUserID(userID, jobHistoryLimit)
+}
+
+// GetJobFile returns the file of a finished download job
+func (s *Service) GetJobFile(userID, jobID int) (*ExportResult, error) {
+	s.logger.Debug("GetJobFile called", "user_id", userID, "job_id", jobID)
+
+	job, err := s.GetJob(userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch job.Status {
+	case models.ExportJobPending, models.ExportJobRunning:
+		return nil, ErrJobNotReady
+	case models.ExportJobFailed:
+		return nil, ErrJobFailed
+	case models.ExportJobExpired:
+		return nil, ErrJobExpired
+	}
+	if job.DeliveryType == string(DeliveryEmail) || job.FileName == nil || job.ContentType == nil {
+		return nil, ErrJobNoFile
+	}
+
+	data, err := s.jobRepo.GetFile(jobID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			// The file expired between reading the job and loading it
+			return nil, ErrJobExpired
+		}
+		return nil, err
+	}
+
+	return &ExportResult{
+		FileBytes:   data,
+		FileName:    *job.FileName,
+		ContentType: *job.ContentType,
+	}, nil
+}
+
+// RunWorker processes queued export jobs until ctx is cancelled. Several
+// workers, also on different instances, can run at the same time: each job
+// is claimed by exactly one of them. Expired files are cleaned up as well.
+func (s *Service) RunWorker(ctx context.Context) {
+	s.logger.Info("Export worker started")
+
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+	var lastCleanup time.Time
+
+	for {
+		// Work through the queue before waiting again
+		for ctx.Err() == nil && s.processNextJob() {
+		}
+
+		if time.Since(lastCleanup) >= jobCleanupInterval {
+			s.cleanupJobs()
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Export worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processNextJob runs one queued job and reports whether there was one
+func (s *Service) processNextJob() bool {
+	job, err := s.jobRepo.ClaimNext(time.Now().Add(-jobStaleAfter))
+	if err != nil {
+		s.logger.Error("Failed to claim export job", "error", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	logger := s.logger.With("job_id", job.ID, "user_id", job.UserID)
+	if job.Attempts > jobMaxAttempts {
+		logger.Error("Export job abandoned after repeated stalls", "attempts", job.Attempts)
+		if err := s.jobRepo.Fail(job.ID, "export did not finish"); err != nil {
+			logger.Error("Failed to mark export job as failed", "error", err)
+		}
+		return true
+	}
+
+	started := time.Now()
+	result, err := s.Export(&ExportRequest{
+		UserID:       job.UserID,
+		DateFrom:     job.DateFrom,
+		DateTo:       job.DateTo,
+		DataType:     ExportDataType(job.DataType),
+		Format:       Format(job.Format),
+		DeliveryType: DeliveryType(job.DeliveryType),
+		Language:     job.Language,
+		UserEmail:    job.Email,
+	})
+	if err != nil {
+		// The details stay in the log; users only see that the export failed
+		logger.Error("Export job failed", "error", err)
+		if err := s.jobRepo.Fail(job.ID, "export failed"); err != nil {
+			logger.Error("Failed to mark export job as failed", "error", err)
+		}
+		return true
+	}
+
+	var expiresAt *time.Time
+	if result.FileBytes != nil {
+		expires := time.Now().Add(s.jobFileTTL)
+		expiresAt = &expires
+	}
+	if err := s.jobRepo.Complete(job.ID, result.FileName, result.ContentType, result.FileBytes, expiresAt); err != nil {
+		logger.Error("Failed to store export job result", "error", err)
+		return true
+	}
+
+	logger.Info("Export job completed", "duration", time.Since(started), "size_bytes", len(result.FileBytes))
+	return true
+}
+
+// cleanupJobs deletes expired files and old finished jobs
+func (s *Service) cleanupJobs() {
+	expired, err := s.jobRepo.ExpireFiles(time.Now())
+	if err != nil {
+		s.logger.Error("Failed to expire export files", "error", err)
+	}
+	deleted, err := s.jobRepo.DeleteFinishedBefore(time.Now().Add(-jobRetention))
+	if err != nil {
+		s.logger.Error("Failed to delete old export jobs", "error", err)
+	}
+	if expired > 0 || deleted > 0 {
+		s.logger.Info("Export jobs cleaned up", "expired_files", expired, "deleted_jobs", deleted)
+	}
+}
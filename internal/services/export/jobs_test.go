@@ -0,0 +1,217 @@
+package export
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// fakeJobRepo keeps export jobs in memory and records failed ones
+type fakeJobRepo struct {
+	repositories.ExportJobRepository
+	jobs     map[int]*models.ExportJob
+	files    map[int][]byte
+	next     *models.ExportJob // Returned by ClaimNext
+	claimErr error
+	created  []*models.ExportJob
+	failed   map[int]string
+	claimed  time.Time // staleBefore of the last claim
+}
+
+func newFakeJobRepo(jobs ...*models.ExportJob) *fakeJobRepo {
+	r := &fakeJobRepo{jobs: make(map[int]*models.ExportJob), files: make(map[int][]byte), failed: make(map[int]string)}
+	for _, job := range jobs {
+		r.jobs[job.ID] = job
+	}
+	return r
+}
+
+func (r *fakeJobRepo) Create(job *models.ExportJob) error {
+	job.ID = len(r.created) + 1
+	job.Status = models.ExportJobPending
+	r.created = append(r.created, job)
+	return nil
+}
+
+func (r *fakeJobRepo) GetByID(id, userID int) (*models.ExportJob, error) {
+	job, ok := r.jobs[id]
+	if !ok || job.UserID != userID {
+		return nil, repositories.ErrNotFound
+	}
+	return job, nil
+}
+
+func (r *fakeJobRepo) GetFile(id, userID int) ([]byte, error) {
+	data, ok := r.files[id]
+	if !ok {
+		return nil, repositories.ErrNotFound
+	}
+	return data, nil
+}
+
+func (r *fakeJobRepo) ClaimNext(staleBefore time.Time) (*models.ExportJob, error) {
+	r.claimed = staleBefore
+	job := r.next
+	r.next = nil
+	return job, r.claimErr
+}
+
+func (r *fakeJobRepo) Fail(id int, message string) error {
+	r.failed[id] = message
+	return nil
+}
+
+func newTestService(repo *fakeJobRepo) *Service {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(nil, nil, nil, nil, repo, nil, time.Hour, logger)
+}
+
+func TestEnqueueJob(t *testing.T) {
+	tests := []struct {
+		name       string
+		req        ExportRequest
+		wantFormat string
+		wantErr    error
+	}{
+		{
+			name:       "format defaults to Excel",
+			req:        ExportRequest{UserID: 1, DateFrom: "2026-01-01", DateTo: "2026-01-31", DataType: ExportBoth, DeliveryType: DeliveryDownload},
+			wantFormat: string(FormatExcel),
+		},
+		{
+			name:       "email delivery",
+			req:        ExportRequest{UserID: 1, DataType: ExportFood, Format: FormatCSV, DeliveryType: DeliveryEmail, UserEmail: "user@example.com"},
+			wantFormat: string(FormatCSV),
+		},
+		{
+			name:    "email delivery without an address",
+			req:     ExportRequest{UserID: 1, DataType: ExportFood, DeliveryType: DeliveryEmail},
+			wantErr: ErrEmailRequired,
+		},
+		{
+			name:    "unsupported format",
+			req:     ExportRequest{UserID: 1, DataType: ExportFood, Format: "docx", DeliveryType: DeliveryDownload},
+			wantErr: ErrUnsupportedFormat,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeJobRepo()
+			job, err := newTestService(repo).EnqueueJob(&tt.req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("got error %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.created) != 0 {
+					t.Error("an invalid export was queued")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("EnqueueJob: %v", err)
+			}
+
+			if len(repo.created) != 1 || job != repo.created[0] {
+				t.Fatalf("got %d queued jobs, want the returned one", len(repo.created))
+			}
+			if job.UserID != tt.req.UserID || job.Format != tt.wantFormat || job.DataType != string(tt.req.DataType) ||
+				job.DeliveryType != string(tt.req.DeliveryType) || job.Email != tt.req.UserEmail ||
+				job.DateFrom != tt.req.DateFrom || job.DateTo != tt.req.DateTo {
+				t.Errorf("queued %+v for request %+v", job, tt.req)
+			}
+		})
+	}
+}
+
+func TestGetJobFile(t *testing.T) {
+	fileName, contentType := "export.csv", "text/csv"
+	job := func(id int, status string, delivery DeliveryType, withFile bool) *models.ExportJob {
+		job := &models.ExportJob{ID: id, UserID: 1, Status: status, DeliveryType: string(delivery)}
+		if withFile {
+			job.FileName, job.ContentType = &fileName, &contentType
+		}
+		return job
+	}
+	repo := newFakeJobRepo(
+		job(1, models.ExportJobPending, DeliveryDownload, false),
+		job(2, models.ExportJobRunning, DeliveryDownload, false),
+		job(3, models.ExportJobFailed, DeliveryDownload, false),
+		job(4, models.ExportJobExpired, DeliveryDownload, true),
+		job(5, models.ExportJobCompleted, DeliveryEmail, true),
+		job(6, models.ExportJobCompleted, DeliveryDownload, false),
+		job(7, models.ExportJobCompleted, DeliveryDownload, true), // File deleted after the job was read
+		job(8, models.ExportJobCompleted, DeliveryDownload, true),
+	)
+	repo.files[8] = []byte("date,food\n")
+	svc := newTestService(repo)
+
+	tests := []struct {
+		name    string
+		userID  int
+		jobID   int
+		wantErr error
+	}{
+		{"pending", 1, 1, ErrJobNotReady},
+		{"running", 1, 2, ErrJobNotReady},
+		{"failed", 1, 3, ErrJobFailed},
+		{"expired", 1, 4, ErrJobExpired},
+		{"sent by email", 1, 5, ErrJobNoFile},
+		{"completed without a file", 1, 6, ErrJobNoFile},
+		{"file expired meanwhile", 1, 7, ErrJobExpired},
+		{"unknown job", 1, 99, ErrJobNotFound},
+		{"job of another user", 2, 8, ErrJobNotFound},
+		{"ready", 1, 8, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := svc.GetJobFile(tt.userID, tt.jobID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if result.FileName != fileName || result.ContentType != contentType || string(result.FileBytes) != "date,food\n" {
+				t.Errorf("got %+v", result)
+			}
+		})
+	}
+}
+
+func TestProcessNextJob(t *testing.T) {
+	t.Run("empty queue", func(t *testing.T) {
+		repo := newFakeJobRepo()
+		if newTestService(repo).processNextJob() {
+			t.Error("reported a job with an empty queue")
+		}
+		if since := time.Since(repo.claimed); since < jobStaleAfter || since > jobStaleAfter+time.Minute {
+			t.Errorf("claimed jobs stale since %v ago, want %v", since, jobStaleAfter)
+		}
+	})
+
+	t.Run("claim error", func(t *testing.T) {
+		repo := newFakeJobRepo()
+		repo.claimErr = errors.New("database is locked")
+		if newTestService(repo).processNextJob() {
+			t.Error("reported a job after a failed claim")
+		}
+	})
+
+	t.Run("job that keeps stalling is failed", func(t *testing.T) {
+		repo := newFakeJobRepo()
+		repo.next = &models.ExportJob{ID: 5, UserID: 1, Status: models.ExportJobRunning, Attempts: jobMaxAttempts + 1}
+		if !newTestService(repo).processNextJob() {
+			t.Error("did not report the claimed job")
+		}
+		if message, ok := repo.failed[5]; !ok || message != "export did not finish" {
+			t.Errorf("job failed with %q (%v), want %q", message, ok, "export did not finish")
+		}
+	})
+}
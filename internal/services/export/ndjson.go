@@ -0,0 +1,96 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// ndjsonWeight is a weight record line
+type ndjsonWeight struct {
+	Type       string    `json:"type"` // Always "weight"
+	ID         int       `json:"id"`
+	Weight     float64   `json:"weight_kg"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// ndjsonFood is a calorie entry line; nutrition values are per 100 g
+type ndjsonFood struct {
+	Type         string           `json:"type"` // Always "food"
+	ID           int              `json:"id"`
+	MealDatetime time.Time        `json:"meal_datetime"`
+	Food         string           `json:"food"`
+	Weight       float64          `json:"weight_g"`
+	Quantity     *float64         `json:"quantity,omitempty"`
+	Unit         *string          `json:"unit,omitempty"`
+	Calories     int              `json:"calories"`
+	KcalPer100g  float64          `json:"kcal_per_100g"`
+	Fats         *float64         `json:"fats,omitempty"`
+	Carbs        *float64         `json:"carbs,omitempty"`
+	Proteins     *float64         `json:"proteins,omitempty"`
+	Nutrients    models.Nutrients `json:"nutrients,omitempty"`
+}
+
+// NDJSONExporter writes newline-delimited JSON, one record per line with a
+// type field telling weight and food records apart
+type NDJSONExporter struct{}
+
+// NewNDJSONExporter creates a new NDJSON exporter
+func NewNDJSONExporter() *NDJSONExporter {
+	return &NDJSONExporter{}
+}
+
+// ContentType returns the NDJSON MIME type
+func (e *NDJSONExporter) ContentType(ExportDataType) string {
+	return "application/x-ndjson"
+}
+
+// FileExtension returns ndjson
+func (e *NDJSONExporter) FileExtension(ExportDataType) string {
+	return "ndjson"
+}
+
+// Write streams weight records first, then calorie entries
+func (e *NDJSONExporter) Write(w io.Writer, data *ExportData) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	if data.DataType == ExportWeight || data.DataType == ExportBoth {
+		for _, entry := range data.Weight {
+			if err := encoder.Encode(&ndjsonWeight{
+				Type:       "weight",
+				ID:         entry.ID,
+				Weight:     entry.Weight,
+				RecordedAt: entry.RecordedAt,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if data.DataType == ExportFood || data.DataType == ExportBoth {
+		for _, entry := range data.Food {
+			if err := encoder.Encode(&ndjsonFood{
+				Type:         "food",
+				ID:           entry.ID,
+				MealDatetime: entry.MealDatetime,
+				Food:         entry.Food,
+				Weight:       entry.Weight,
+				Quantity:     entry.Quantity,
+				Unit:         entry.Unit,
+				Calories:     entry.Calories,
+				KcalPer100g:  entry.KcalPer100g,
+				Fats:         entry.Fats,
+				Carbs:        entry.Carbs,
+				Proteins:     entry.Proteins,
+				Nutrients:    entry.Nutrients,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
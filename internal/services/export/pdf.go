@@ -0,0 +1,533 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+
+	"ypeskov/kkal-tracker/internal/i18n"
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// Page layout in millimeters
+const (
+	pdfMargin      = 15.0
+	pdfFooter      = 15.0
+	pdfLineHeight  = 6.0
+	pdfLabelWidth  = 75.0
+	pdfChartHeight = 72.0 // 180 mm wide at the chart's aspect ratio
+	pdfFont        = "go"
+	pdfMaxLabels   = 6 // Dates shown under a chart
+)
+
+// dailyIntake is the total of one day's calorie entries; macros are in grams
+type dailyIntake struct {
+	Date     time.Time
+	Calories int
+	Fats     float64
+	Carbs    float64
+	Proteins float64
+	Entries  int
+}
+
+// PDFGenerator renders a printable progress report for a period
+type PDFGenerator struct {
+	t *i18n.Translator
+}
+
+// NewPDFGenerator creates a new PDF report generator
+func NewPDFGenerator() *PDFGenerator {
+	return &PDFGenerator{
+		t: i18n.GetTranslator(),
+	}
+}
+
+// ContentType returns the MIME type of PDF documents
+func (g *PDFGenerator) ContentType(ExportDataType) string {
+	return "application/pdf"
+}
+
+// FileExtension returns pdf
+func (g *PDFGenerator) FileExtension(ExportDataType) string {
+	return "pdf"
+}
+
+// pdfReport holds the state of one report being rendered
+type pdfReport struct {
+	*fpdf.Fpdf
+	g    *PDFGenerator
+	lang string
+	data *ExportData
+	from time.Time
+	to   time.Time
+}
+
+// tr is a shortcut for translating keys of the PDF report
+func (r *pdfReport) tr(key string) string {
+	return r.g.t.Get(r.lang, "export.pdf."+key)
+}
+
+// Write renders the report and writes it to w. Sections follow the data type:
+// weight adds the weight summary and trend chart, food adds the intake summary,
+// calorie chart and daily table. Goal progress and health metrics are always included.
+func (g *PDFGenerator) Write(w io.Writer, data *ExportData) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(pdfFont, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", gobold.TTF)
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfFooter+5)
+
+	r := &pdfReport{Fpdf: pdf, g: g, lang: data.Language, data: data}
+	r.from, r.to = reportPeriod(data)
+
+	pdf.SetTitle(r.tr("title"), true)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfFooter)
+		pdf.SetFont(pdfFont, "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 10, fmt.Sprintf("%s %d / {nb}", r.tr("page"), pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	withWeight := data.DataType == ExportWeight || data.DataType == ExportBoth
+	withFood := data.DataType == ExportFood || data.DataType == ExportBoth
+	days := dailyIntakes(data.Food)
+	weights := sortedWeights(data.Weight)
+
+	r.header()
+	r.summary(withWeight, withFood, days, weights)
+	r.goal()
+	r.health()
+	if withWeight {
+		if err := r.weightChart(weights); err != nil {
+			return err
+		}
+	}
+	if withFood {
+		if err := r.calorieChart(days); err != nil {
+			return err
+		}
+		r.dailyTable(days)
+	}
+
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("failed to write PDF report: %w", err)
+	}
+	return nil
+}
+
+func (r *pdfReport) header() {
+	r.SetFont(pdfFont, "B", 18)
+	r.SetTextColor(0, 0, 0)
+	r.CellFormat(0, 10, r.tr("title"), "", 1, "L", false, 0, "")
+
+	r.SetFont(pdfFont, "", 10)
+	r.SetTextColor(90, 90, 90)
+	r.CellFormat(0, pdfLineHeight, fmt.Sprintf("%s: %s — %s", r.tr("period"),
+		r.from.Format("2006-01-02"), r.to.Format("2006-01-02")), "", 1, "L", false, 0, "")
+	r.CellFormat(0, pdfLineHeight, fmt.Sprintf("%s: %s", r.tr("generated"),
+		time.Now().Format("2006-01-02")), "", 1, "L", false, 0, "")
+	r.Ln(4)
+}
+
+// section starts a titled section, moving to a new page if the title and
+// the first height millimeters of its content would not fit
+func (r *pdfReport) section(title string, height float64) {
+	r.ensureSpace(10 + height)
+	r.SetFont(pdfFont, "B", 13)
+	r.SetTextColor(0, 0, 0)
+	r.CellFormat(0, 10, title, "B", 1, "L", false, 0, "")
+	r.Ln(2)
+}
+
+// ensureSpace starts a new page if height millimeters do not fit on the current one
+func (r *pdfReport) ensureSpace(height float64) bool {
+	_, pageHeight := r.GetPageSize()
+	if r.GetY()+height > pageHeight-pdfFooter-5 {
+		r.AddPage()
+		return true
+	}
+	return false
+}
+
+// row writes a label and value pair
+func (r *pdfReport) row(label, value string) {
+	r.SetFont(pdfFont, "", 10)
+	r.SetTextColor(90, 90, 90)
+	r.CellFormat(pdfLabelWidth, pdfLineHeight, label, "", 0, "L", false, 0, "")
+	r.SetTextColor(0, 0, 0)
+	r.CellFormat(0, pdfLineHeight, value, "", 1, "L", false, 0, "")
+}
+
+func (r *pdfReport) summary(withWeight, withFood bool, days []dailyIntake, weights []*models.WeightHistory) {
+	r.section(r.tr("summary"), 4*pdfLineHeight)
+
+	if withFood {
+		periodDays := int(r.to.Sub(r.from).Hours()/24) + 1
+		r.row(r.tr("days_logged"), fmt.Sprintf("%d / %d", len(days), periodDays))
+
+		if len(days) > 0 {
+			var calories int
+			var fats, carbs, proteins float64
+			highest, lowest := days[0], days[0]
+			for _, day := range days {
+				calories += day.Calories
+				fats += day.Fats
+				carbs += day.Carbs
+				proteins += day.Proteins
+				if day.Calories > highest.Calories {
+					highest = day
+				}
+				if day.Calories < lowest.Calories {
+					lowest = day
+				}
+			}
+			n := float64(len(days))
+			r.row(r.tr("avg_calories"), r.kcal(float64(calories)/n))
+			r.row(r.tr("avg_fats"), r.grams(fats/n))
+			r.row(r.tr("avg_carbs"), r.grams(carbs/n))
+			r.row(r.tr("avg_proteins"), r.grams(proteins/n))
+			r.row(r.tr("highest_day"), fmt.Sprintf("%s (%s)", r.kcal(float64(highest.Calories)), highest.Date.Format("2006-01-02")))
+			r.row(r.tr("lowest_day"), fmt.Sprintf("%s (%s)", r.kcal(float64(lowest.Calories)), lowest.Date.Format("2006-01-02")))
+		}
+	}
+
+	if withWeight && len(weights) > 0 {
+		first, last := weights[0], weights[len(weights)-1]
+		r.row(r.tr("weight_start"), fmt.Sprintf("%s (%s)", r.kg(first.Weight), first.RecordedAt.Format("2006-01-02")))
+		r.row(r.tr("weight_end"), fmt.Sprintf("%s (%s)", r.kg(last.Weight), last.RecordedAt.Format("2006-01-02")))
+		r.row(r.tr("weight_change"), fmt.Sprintf("%+.1f %s", last.Weight-first.Weight, r.tr("units.kg")))
+	}
+
+	if (!withFood || len(days) == 0) && (!withWeight || len(weights) == 0) {
+		r.row(r.tr("no_data"), "")
+	}
+	r.Ln(4)
+}
+
+func (r *pdfReport) goal() {
+	goal := r.data.Goal
+	if goal == nil {
+		return
+	}
+
+	r.section(r.tr("goal"), 8*pdfLineHeight)
+	r.row(r.tr("target_weight"), r.kg(goal.TargetWeight))
+	r.row(r.tr("initial_weight"), fmt.Sprintf("%s (%s)", r.kg(goal.InitialWeightAtGoal), goal.GoalSetAt.Format("2006-01-02")))
+	r.row(r.tr("current_weight"), r.kg(goal.CurrentWeight))
+	r.row(r.tr("weight_to_go"), r.kg(math.Abs(goal.WeightToGo)))
+	if goal.TargetDate != nil {
+		r.row(r.tr("target_date"), goal.TargetDate.Format("2006-01-02"))
+	}
+	if goal.EstimatedCompletion != nil {
+		r.row(r.tr("estimated_completion"), goal.EstimatedCompletion.Format("2006-01-02"))
+	}
+	if goal.DailyDeficitNeeded != nil {
+		label := r.tr("daily_deficit")
+		if goal.IsGaining {
+			label = r.tr("daily_surplus")
+		}
+		r.row(label, r.kcal(*goal.DailyDeficitNeeded))
+	}
+
+	// Progress bar next to the percentage
+	r.SetFont(pdfFont, "", 10)
+	r.SetTextColor(90, 90, 90)
+	r.CellFormat(pdfLabelWidth, pdfLineHeight, r.tr("progress"), "", 0, "L", false, 0, "")
+	x, y := r.GetXY()
+	const barWidth = 70.0
+	r.SetFillColor(229, 231, 235)
+	r.Rect(x, y+1.5, barWidth, 3, "F")
+	r.SetFillColor(16, 185, 129)
+	r.Rect(x, y+1.5, barWidth*goal.ProgressPercent/100, 3, "F")
+	r.SetX(x + barWidth + 4)
+	r.SetTextColor(0, 0, 0)
+	r.CellFormat(0, pdfLineHeight, fmt.Sprintf("%.1f%%", goal.ProgressPercent), "", 1, "L", false, 0, "")
+	r.Ln(4)
+}
+
+func (r *pdfReport) health() {
+	health := r.data.Health
+	if health == nil {
+		return
+	}
+
+	r.section(r.tr("health"), 3*pdfLineHeight)
+	if health.BMI == nil && health.BMR == nil {
+		r.row(r.tr("health_incomplete"), "")
+	}
+	if health.BMI != nil {
+		r.row(r.tr("bmi"), fmt.Sprintf("%.1f (%s)", *health.BMI, r.tr("bmi_categories."+health.BMICategory)))
+	}
+	if health.BMR != nil {
+		r.row(r.tr("bmr"), r.kcal(*health.BMR))
+	}
+	if health.TDEE != nil {
+		r.row(r.tr("tdee"), fmt.Sprintf("%s (%s)", r.kcal(*health.TDEE), r.tr("activity_levels."+health.ActivityLevel)))
+	}
+	r.Ln(4)
+}
+
+func (r *pdfReport) weightChart(weights []*models.WeightHistory) error {
+	if len(weights) == 0 {
+		return nil
+	}
+
+	points := make([]chartPoint, len(weights))
+	for i, entry := range weights {
+		points[i] = chartPoint{X: entry.RecordedAt.Sub(r.from).Hours() / 24, Value: entry.Weight}
+	}
+	var target *float64
+	if r.data.Goal != nil {
+		target = &r.data.Goal.TargetWeight
+	}
+
+	// The axis runs to the end of the last day, as weights are recorded at any time of day
+	periodDays := r.to.Sub(r.from).Hours() / 24
+	img, err := renderLineChart(points, r.dateLabels(periodDays), 0, periodDays+1, target)
+	if err != nil {
+		return err
+	}
+
+	legend := ""
+	if target != nil {
+		legend = fmt.Sprintf("- - - %s: %s", r.tr("legend_target"), r.kg(*target))
+	}
+	return r.chart("weight_chart", r.tr("weight_chart"), img, legend)
+}
+
+func (r *pdfReport) calorieChart(days []dailyIntake) error {
+	if len(days) == 0 {
+		return nil
+	}
+
+	points := make([]chartPoint, len(days))
+	for i, day := range days {
+		points[i] = chartPoint{X: math.Round(day.Date.Sub(r.from).Hours() / 24), Value: float64(day.Calories)}
+	}
+	var tdee *float64
+	if r.data.Health != nil && r.data.Health.TDEE != nil {
+		rounded := math.Round(*r.data.Health.TDEE)
+		tdee = &rounded
+	}
+
+	slots := int(math.Round(r.to.Sub(r.from).Hours()/24)) + 1
+	img, err := renderBarChart(points, r.dateLabels(float64(slots-1)), slots, tdee)
+	if err != nil {
+		return err
+	}
+
+	legend := ""
+	if tdee != nil {
+		legend = fmt.Sprintf("- - - %s: %s", r.tr("legend_tdee"), r.kcal(*tdee))
+	}
+	return r.chart("calorie_chart", r.tr("calorie_chart"), img, legend)
+}
+
+// chart places a rendered chart image under a section title
+func (r *pdfReport) chart(name, title string, img []byte, legend string) error {
+	r.section(title, pdfChartHeight+pdfLineHeight)
+
+	r.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(img))
+	pageWidth, _ := r.GetPageSize()
+	r.ImageOptions(name, pdfMargin, r.GetY(), pageWidth-2*pdfMargin, 0, true, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+	if err := r.Error(); err != nil {
+		return fmt.Errorf("failed to add chart to PDF report: %w", err)
+	}
+
+	if legend != "" {
+		r.SetFont(pdfFont, "", 9)
+		r.SetTextColor(220, 38, 38)
+		r.CellFormat(0, pdfLineHeight, legend, "", 1, "L", false, 0, "")
+	}
+	r.Ln(4)
+	return nil
+}
+
+// dateLabels spreads up to pdfMaxLabels dates over the days of the period,
+// where last is the number of days from the first to the last one
+func (r *pdfReport) dateLabels(last float64) []chartLabel {
+	count := int(math.Min(pdfMaxLabels, math.Floor(last)+1))
+	if count < 2 {
+		return []chartLabel{{X: 0, Text: r.from.Format("2006-01-02")}}
+	}
+
+	labels := make([]chartLabel, count)
+	for i := range labels {
+		x := math.Round(last * float64(i) / float64(count-1))
+		labels[i] = chartLabel{X: x, Text: r.from.AddDate(0, 0, int(x)).Format("2006-01-02")}
+	}
+	return labels
+}
+
+func (r *pdfReport) dailyTable(days []dailyIntake) {
+	if len(days) == 0 {
+		return
+	}
+
+	widths := []float64{40, 30, 30, 30, 30, 20}
+	header := func() {
+		r.SetFont(pdfFont, "B", 9)
+		r.SetTextColor(0, 0, 0)
+		r.SetFillColor(243, 244, 246)
+		titles := []string{
+			r.g.t.Get(r.lang, "export.columns.date"),
+			r.g.t.Get(r.lang, "export.columns.calories"),
+			r.g.t.Get(r.lang, "export.columns.fats"),
+			r.g.t.Get(r.lang, "export.columns.carbs"),
+			r.g.t.Get(r.lang, "export.columns.proteins"),
+			r.tr("entries"),
+		}
+		for i, title := range titles {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			r.CellFormat(widths[i], 7, title, "B", 0, align, true, 0, "")
+		}
+		r.Ln(-1)
+		r.SetFont(pdfFont, "", 9)
+	}
+	line := func(cells []string, style string) {
+		if r.ensureSpace(pdfLineHeight) {
+			header()
+		}
+		r.SetFont(pdfFont, style, 9)
+		for i, cell := range cells {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			r.CellFormat(widths[i], pdfLineHeight, cell, "", 0, align, false, 0, "")
+		}
+		r.Ln(-1)
+	}
+
+	r.section(r.tr("daily"), 7+2*pdfLineHeight)
+	header()
+
+	var calories, entries int
+	var fats, carbs, proteins float64
+	for _, day := range days {
+		line([]string{
+			day.Date.Format("2006-01-02"),
+			strconv.Itoa(day.Calories),
+			formatGrams(day.Fats),
+			formatGrams(day.Carbs),
+			formatGrams(day.Proteins),
+			strconv.Itoa(day.Entries),
+		}, "")
+		calories += day.Calories
+		entries += day.Entries
+		fats += day.Fats
+		carbs += day.Carbs
+		proteins += day.Proteins
+	}
+
+	n := float64(len(days))
+	line([]string{
+		r.tr("average"),
+		strconv.Itoa(int(math.Round(float64(calories) / n))),
+		formatGrams(fats / n),
+		formatGrams(carbs / n),
+		formatGrams(proteins / n),
+		strconv.FormatFloat(math.Round(float64(entries)/n*10)/10, 'f', -1, 64),
+	}, "B")
+}
+
+func (r *pdfReport) kcal(value float64) string {
+	return fmt.Sprintf("%.0f %s", value, r.tr("units.kcal"))
+}
+
+func (r *pdfReport) kg(value float64) string {
+	return fmt.Sprintf("%.1f %s", value, r.tr("units.kg"))
+}
+
+func (r *pdfReport) grams(value float64) string {
+	return fmt.Sprintf("%s %s", formatGrams(value), r.tr("units.g"))
+}
+
+func formatGrams(value float64) string {
+	return strconv.FormatFloat(value, 'f', 1, 64)
+}
+
+// reportPeriod returns the first and last day of the report. The requested
+// dates are used when set, otherwise the span of the data.
+func reportPeriod(data *ExportData) (time.Time, time.Time) {
+	from, errFrom := time.Parse("2006-01-02", data.DateFrom)
+	to, errTo := time.Parse("2006-01-02", data.DateTo)
+	if errFrom == nil && errTo == nil && !to.Before(from) {
+		return from, to
+	}
+
+	var first, last time.Time
+	extend := func(t time.Time) {
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		if first.IsZero() || day.Before(first) {
+			first = day
+		}
+		if day.After(last) {
+			last = day
+		}
+	}
+	for _, entry := range data.Weight {
+		extend(entry.RecordedAt)
+	}
+	for _, entry := range data.Food {
+		extend(entry.MealDatetime)
+	}
+	if first.IsZero() {
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		return today, today
+	}
+	return first, last
+}
+
+// dailyIntakes sums calorie entries per day, oldest first. Macros are stored
+// per 100 g, so they are scaled by each entry's weight; unknown macros count as zero.
+func dailyIntakes(entries []*models.CalorieEntry) []dailyIntake {
+	byDay := make(map[string]*dailyIntake)
+	for _, entry := range entries {
+		key := entry.MealDatetime.Format("2006-01-02")
+		day, ok := byDay[key]
+		if !ok {
+			date, _ := time.Parse("2006-01-02", key)
+			day = &dailyIntake{Date: date}
+			byDay[key] = day
+		}
+		day.Calories += entry.Calories
+		day.Entries++
+		if entry.Fats != nil {
+			day.Fats += *entry.Fats * entry.Weight / 100
+		}
+		if entry.Carbs != nil {
+			day.Carbs += *entry.Carbs * entry.Weight / 100
+		}
+		if entry.Proteins != nil {
+			day.Proteins += *entry.Proteins * entry.Weight / 100
+		}
+	}
+
+	days := make([]dailyIntake, 0, len(byDay))
+	for _, day := range byDay {
+		days = append(days, *day)
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date.Before(days[j].Date) })
+	return days
+}
+
+// sortedWeights returns weight records oldest first without reordering the input
+func sortedWeights(data []*models.WeightHistory) []*models.WeightHistory {
+	weights := append([]*models.WeightHistory(nil), data...)
+	sort.Slice(weights, func(i, j int) bool { return weights[i].RecordedAt.Before(weights[j].RecordedAt) })
+	return weights
+}
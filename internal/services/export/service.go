@@ -1,12 +1,18 @@
 package export
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"time"
 
-	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
 	calorieservice "ypeskov/kkal-tracker/internal/services/calorie"
 	emailservice "ypeskov/kkal-tracker/internal/services/email"
+	metricsservice "ypeskov/kkal-tracker/internal/services/metrics"
+	profileservice "ypeskov/kkal-tracker/internal/services/profile"
 	weightservice "ypeskov/kkal-tracker/internal/services/weight"
 )
 
@@ -14,8 +20,12 @@ import (
 type Service struct {
 	calorieService calorieservice.Servicer
 	weightService  weightservice.Servicer
+	profileService profileservice.Servicer
+	metricsService metricsservice.Servicer
+	jobRepo        repositories.ExportJobRepository
 	emailService   *emailservice.Service
 	excelGenerator *ExcelGenerator
+	jobFileTTL     time.Duration // How long files of finished jobs can be downloaded
 	logger         *slog.Logger
 }
 
@@ -23,14 +33,22 @@ type Service struct {
 func New(
 	calorieService calorieservice.Servicer,
 	weightService weightservice.Servicer,
+	profileService profileservice.Servicer,
+	metricsService metricsservice.Servicer,
+	jobRepo repositories.ExportJobRepository,
 	emailService *emailservice.Service,
+	jobFileTTL time.Duration,
 	logger *slog.Logger,
 ) *Service {
 	return &Service{
 		calorieService: calorieService,
 		weightService:  weightService,
+		profileService: profileService,
+		metricsService: metricsService,
+		jobRepo:        jobRepo,
 		emailService:   emailService,
 		excelGenerator: NewExcelGenerator(),
+		jobFileTTL:     jobFileTTL,
 		logger:         logger.With("service", "export"),
 	}
 }
@@ -42,47 +60,35 @@ func (s *Service) Export(req *ExportRequest) (*ExportResult, error) {
 		"date_from", req.DateFrom,
 		"date_to", req.DateTo,
 		"data_type", req.DataType,
+		"format", req.Format,
 		"delivery_type", req.DeliveryType,
 	)
 
-	// Fetch weight data if needed
-	var weightData []*models.WeightHistory
-	if req.DataType == ExportWeight || req.DataType == ExportBoth {
-		data, err := s.weightService.GetWeightHistoryByDateRange(req.UserID, req.DateFrom, req.DateTo)
-		if err != nil {
-			s.logger.Error("Failed to fetch weight data", "error", err)
-			return nil, fmt.Errorf("failed to fetch weight data: %w", err)
-		}
-		weightData = data
-		s.logger.Debug("Fetched weight data", "count", len(weightData))
+	exporter, err := NewExporter(req.Format)
+	if err != nil {
+		return nil, err
 	}
 
-	// Fetch calorie data if needed
-	var calorieData []*models.CalorieEntry
-	if req.DataType == ExportFood || req.DataType == ExportBoth {
-		data, err := s.calorieService.GetEntriesByDateRange(req.UserID, req.DateFrom, req.DateTo)
-		if err != nil {
-			s.logger.Error("Failed to fetch calorie data", "error", err)
-			return nil, fmt.Errorf("failed to fetch calorie data: %w", err)
-		}
-		calorieData = data
-		s.logger.Debug("Fetched calorie data", "count", len(calorieData))
+	data, err := s.loadData(req)
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate Excel file
-	fileBytes, err := s.excelGenerator.Generate(weightData, calorieData, req.DataType, req.Language)
-	if err != nil {
-		s.logger.Error("Failed to generate Excel file", "error", err)
-		return nil, fmt.Errorf("failed to generate Excel file: %w", err)
+	var buffer bytes.Buffer
+	if err := exporter.Write(&buffer, data); err != nil {
+		s.logger.Error("Failed to generate export file", "error", err, "format", req.Format)
+		return nil, fmt.Errorf("failed to generate export file: %w", err)
 	}
+	fileBytes := buffer.Bytes()
 
 	// Create filename
-	fileName := fmt.Sprintf("kkal-export-%s-to-%s.xlsx", req.DateFrom, req.DateTo)
+	fileName := exportFileName(req, exporter)
+	contentType := exporter.ContentType(req.DataType)
 
 	// Handle delivery
 	if req.DeliveryType == DeliveryEmail {
 		if req.UserEmail == "" {
-			return nil, fmt.Errorf("email address is required for email delivery")
+			return nil, ErrEmailRequired
 		}
 
 		err = s.emailService.SendEmailWithAttachment(
@@ -90,6 +96,8 @@ func (s *Service) Export(req *ExportRequest) (*ExportResult, error) {
 			req.Language,
 			fileBytes,
 			fileName,
+			contentType,
+			req.UnsubscribeURL,
 		)
 		if err != nil {
 			s.logger.Error("Failed to send export email", "error", err)
@@ -104,7 +112,8 @@ func (s *Service) Export(req *ExportRequest) (*ExportResult, error) {
 
 		// Return empty result for email delivery (file was sent)
 		return &ExportResult{
-			FileName: fileName,
+			FileName:    fileName,
+			ContentType: contentType,
 		}, nil
 	}
 
@@ -116,7 +125,100 @@ func (s *Service) Export(req *ExportRequest) (*ExportResult, error) {
 	)
 
 	return &ExportResult{
-		FileBytes: fileBytes,
-		FileName:  fileName,
+		FileBytes:   fileBytes,
+		FileName:    fileName,
+		ContentType: contentType,
+	}, nil
+}
+
+// Stream prepares a download that is written straight to the response instead
+// of being built in memory first. Data is loaded before returning, so errors
+// can still be reported before any of the file is sent.
+func (s *Service) Stream(req *ExportRequest) (*ExportStream, error) {
+	s.logger.Debug("Stream called",
+		"user_id", req.UserID,
+		"date_from", req.DateFrom,
+		"date_to", req.DateTo,
+		"data_type", req.DataType,
+		"format", req.Format,
+	)
+
+	exporter, err := NewExporter(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.loadData(req)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := exportFileName(req, exporter)
+	s.logger.Info("Export streamed for download", "user_id", req.UserID, "file_name", fileName)
+
+	return &ExportStream{
+		FileName:    fileName,
+		ContentType: exporter.ContentType(req.DataType),
+		write: func(w io.Writer) error {
+			return exporter.Write(w, data)
+		},
 	}, nil
 }
+
+// loadData fetches the records covered by an export request
+func (s *Service) loadData(req *ExportRequest) (*ExportData, error) {
+	data := &ExportData{
+		DataType: req.DataType,
+		Language: req.Language,
+		DateFrom: req.DateFrom,
+		DateTo:   req.DateTo,
+	}
+
+	// Fetch weight data if needed
+	if req.DataType == ExportWeight || req.DataType == ExportBoth {
+		weightData, err := s.weightService.GetWeightHistoryByDateRange(req.UserID, req.DateFrom, req.DateTo)
+		if err != nil {
+			s.logger.Error("Failed to fetch weight data", "error", err)
+			return nil, fmt.Errorf("failed to fetch weight data: %w", err)
+		}
+		data.Weight = weightData
+		s.logger.Debug("Fetched weight data", "count", len(weightData))
+	}
+
+	// Fetch calorie data if needed
+	if req.DataType == ExportFood || req.DataType == ExportBoth {
+		calorieData, err := s.calorieService.GetEntriesByDateRange(req.UserID, req.DateFrom, req.DateTo)
+		if err != nil {
+			s.logger.Error("Failed to fetch calorie data", "error", err)
+			return nil, fmt.Errorf("failed to fetch calorie data: %w", err)
+		}
+		data.Food = calorieData
+		s.logger.Debug("Fetched calorie data", "count", len(calorieData))
+	}
+
+	// The PDF report and the Excel summary also show the current goal progress
+	if req.Format == FormatPDF || req.Format == FormatExcel || req.Format == "" {
+		goal, err := s.profileService.GetWeightGoalProgress(req.UserID)
+		if err != nil && !errors.Is(err, profileservice.ErrGoalNotSet) && !errors.Is(err, profileservice.ErrNoWeightData) {
+			s.logger.Error("Failed to fetch weight goal progress", "error", err)
+			return nil, fmt.Errorf("failed to fetch weight goal progress: %w", err)
+		}
+		data.Goal = goal
+	}
+
+	// Health metrics are only part of the PDF report
+	if req.Format == FormatPDF {
+		health, err := s.metricsService.GetHealthMetrics(req.UserID)
+		if err != nil {
+			s.logger.Error("Failed to fetch health metrics", "error", err)
+			return nil, fmt.Errorf("failed to fetch health metrics: %w", err)
+		}
+		data.Health = health
+	}
+
+	return data, nil
+}
+
+func exportFileName(req *ExportRequest, exporter Exporter) string {
+	return fmt.Sprintf("kkal-export-%s-to-%s.%s", req.DateFrom, req.DateTo, exporter.FileExtension(req.DataType))
+}
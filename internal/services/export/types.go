@@ -1,5 +1,7 @@
 package export
 
+import "io"
+
 // ExportDataType defines what data to export
 type ExportDataType string
 
@@ -19,17 +21,32 @@ const (
 
 // ExportRequest contains all parameters for export
 type ExportRequest struct {
-	UserID       int
-	DateFrom     string // YYYY-MM-DD
-	DateTo       string // YYYY-MM-DD
-	DataType     ExportDataType
-	DeliveryType DeliveryType
-	Language     string
-	UserEmail    string // for email delivery
+	UserID         int
+	DateFrom       string // YYYY-MM-DD
+	DateTo         string // YYYY-MM-DD
+	DataType       ExportDataType
+	Format         Format // Defaults to Excel
+	DeliveryType   DeliveryType
+	Language       string
+	UserEmail      string // for email delivery
+	UnsubscribeURL string // for emails sent by a schedule
 }
 
 // ExportResult contains the generated file
 type ExportResult struct {
-	FileBytes []byte
-	FileName  string
+	FileBytes   []byte
+	FileName    string
+	ContentType string
+}
+
+// ExportStream is a download written directly to the client
+type ExportStream struct {
+	FileName    string
+	ContentType string
+	write       func(w io.Writer) error
+}
+
+// Write streams the file to w
+func (s *ExportStream) Write(w io.Writer) error {
+	return s.write(w)
 }
@@ -0,0 +1,10 @@
+package idempotency
+
+import "ypeskov/kkal-tracker/internal/models"
+
+// Servicer defines the idempotency key service contract used by middleware.
+type Servicer interface {
+	Begin(userID int, key, requestHash string) (*models.IdempotencyKey, error)
+	Complete(userID int, key string, statusCode int, headers map[string]string, body []byte) error
+	Release(userID int, key string) error
+}
@@ -0,0 +1,117 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// Key handling settings
+const (
+	abandonAfter    = 5 * time.Minute // A request still unanswered after this is taken to have died with its instance
+	cleanupInterval = time.Hour
+)
+
+// Service stores the responses to requests sent with an Idempotency-Key
+// header, so retries of a request are answered without applying it again
+type Service struct {
+	keyRepo repositories.IdempotencyKeyRepository
+	ttl     time.Duration // How long a response is replayed
+	logger  *slog.Logger
+}
+
+// New creates a new idempotency key service
+func New(keyRepo repositories.IdempotencyKeyRepository, ttl time.Duration, logger *slog.Logger) *Service {
+	return &Service{
+		keyRepo: keyRepo,
+		ttl:     ttl,
+		logger:  logger.With("service", "idempotency"),
+	}
+}
+
+// Begin claims a key for a request about to be handled and returns nil. If the
+// key was used before, the stored response is returned instead, unless it was
+// used for a different request or that request is still being handled.
+func (s *Service) Begin(userID int, key, requestHash string) (*models.IdempotencyKey, error) {
+	s.logger.Debug("Begin called", "user_id", userID)
+
+	now := time.Now()
+	claimed, err := s.keyRepo.Claim(&models.IdempotencyKey{
+		UserID:      userID,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}, now.Add(-abandonAfter))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	stored, err := s.keyRepo.Get(userID, key)
+	if err != nil {
+		// Released by the request that held it; the client can simply retry
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrRequestInProgress
+		}
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if stored.RequestHash != requestHash {
+		return nil, ErrKeyReused
+	}
+	if stored.StatusCode == 0 {
+		return nil, ErrRequestInProgress
+	}
+
+	s.logger.Debug("Replaying stored response", "user_id", userID, "status", stored.StatusCode)
+	return stored, nil
+}
+
+// Complete stores the response to the request of a claimed key
+func (s *Service) Complete(userID int, key string, statusCode int, headers map[string]string, body []byte) error {
+	return s.keyRepo.SaveResponse(&models.IdempotencyKey{
+		UserID:          userID,
+		Key:             key,
+		StatusCode:      statusCode,
+		ResponseHeaders: headers,
+		ResponseBody:    body,
+	})
+}
+
+// Release gives up a claimed key whose response is not kept, so the request can be retried
+func (s *Service) Release(userID int, key string) error {
+	return s.keyRepo.Release(userID, key)
+}
+
+// RunCleanup removes expired keys until ctx is cancelled. Running it on
+// several instances is harmless.
+func (s *Service) RunCleanup(ctx context.Context) {
+	s.logger.Info("Idempotency key cleanup job started")
+
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.keyRepo.DeleteExpired(time.Now())
+		if err != nil {
+			s.logger.Error("Failed to delete expired idempotency keys", "error", err)
+		} else if deleted > 0 {
+			s.logger.Info("Expired idempotency keys deleted", "count", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Idempotency key cleanup job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
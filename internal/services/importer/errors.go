@@ -0,0 +1,10 @@
+package importer
+
+import "errors"
+
+var (
+	ErrEmptyFile     = errors.New("the file is empty")
+	ErrInvalidFile   = errors.New("the file is not a valid CSV or Excel export")
+	ErrUnknownFormat = errors.New("unrecognized export format, expected MyFitnessPal, Cronometer, Lose It! or our Excel export")
+	ErrTooManyRows   = errors.New("the file has too many rows")
+)
@@ -0,0 +1,491 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"ypeskov/kkal-tracker/internal/config"
+	"ypeskov/kkal-tracker/internal/i18n"
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// Keys of the columns written by export.ExcelGenerator
+const (
+	columnDate       = "export.columns.date"
+	columnTime       = "export.columns.time"
+	columnFood       = "export.columns.food"
+	columnWeightG    = "export.columns.weight_g"
+	columnWeightKg   = "export.columns.weight_kg"
+	columnCalories   = "export.columns.calories"
+	columnKcalPer100 = "export.columns.kcal_per100"
+	columnFats       = "export.columns.fats"
+	columnCarbs      = "export.columns.carbs"
+	columnProteins   = "export.columns.proteins"
+	nutrientPrefix   = "export.nutrients."
+)
+
+const maxWeightKg = 500.0
+
+// isExcel reports whether the data looks like an .xlsx file (a zip archive)
+func isExcel(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+// excelHeaders maps the column titles of the export in every supported language
+// to their i18n keys, so files exported in any language can be read back
+func excelHeaders() map[string]string {
+	keys := []string{columnDate, columnTime, columnFood, columnWeightG, columnWeightKg, columnCalories,
+		columnKcalPer100, columnFats, columnCarbs, columnProteins}
+	for _, info := range models.NutrientCatalog {
+		keys = append(keys, nutrientPrefix+info.Code)
+	}
+
+	t := i18n.GetTranslator()
+	headers := make(map[string]string)
+	for _, lang := range config.GetSupportedLanguageCodes() {
+		for _, key := range keys {
+			headers[normalizeHeader(t.Get(lang, key))] = key
+		}
+	}
+	return headers
+}
+
+func normalizeHeader(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// excelSheet is a sheet of the export with its columns resolved to i18n keys
+type excelSheet struct {
+	name    string
+	columns map[string]int // i18n key -> index
+	rows    [][]string
+}
+
+// cell returns the trimmed value of a column in a row
+func (s *excelSheet) cell(row []string, key string) string {
+	i, ok := s.columns[key]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// number parses an optional numeric column; blank cells give nil
+func (s *excelSheet) number(row []string, key string) (*float64, error) {
+	value := s.cell(row, key)
+	if value == "" {
+		return nil, nil
+	}
+	number, err := parseNumber(value)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a number", value)
+	}
+	return &number, nil
+}
+
+func (s *excelSheet) has(keys ...string) bool {
+	for _, key := range keys {
+		if _, ok := s.columns[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// readExcel opens the workbook and resolves the header row of every sheet
+func readExcel(data []byte) ([]*excelSheet, error) {
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	defer f.Close()
+
+	headers := excelHeaders()
+	var sheets []*excelSheet
+	for _, name := range f.GetSheetList() {
+		// Raw values keep numbers and dates independent of the cell formats
+		rows, err := f.GetRows(name, excelize.Options{RawCellValue: true})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		sheet := &excelSheet{name: name, columns: make(map[string]int)}
+		if len(rows) > 0 {
+			for i, title := range rows[0] {
+				if key, ok := headers[normalizeHeader(title)]; ok {
+					if _, seen := sheet.columns[key]; !seen {
+						sheet.columns[key] = i
+					}
+				}
+			}
+			sheet.rows = rows[1:]
+		}
+		if len(sheet.rows) > MaxRows {
+			return nil, ErrTooManyRows
+		}
+		sheets = append(sheets, sheet)
+	}
+	return sheets, nil
+}
+
+// excelDate reads a date written as text or stored as an Excel date serial
+func excelDate(value string) (string, error) {
+	if serial, err := parseNumber(value); err == nil {
+		t, err := excelize.ExcelDateToTime(serial, false)
+		if err != nil {
+			return "", fmt.Errorf("%q is not a date", value)
+		}
+		return t.Format("2006-01-02"), nil
+	}
+	return value, nil
+}
+
+// excelClock reads a time of day written as text or stored as a fraction of a day
+func excelClock(value string) string {
+	if fraction, err := parseNumber(value); err == nil && fraction >= 0 && fraction < 1 {
+		minutes := int(math.Round(fraction * 24 * 60))
+		return fmt.Sprintf("%02d:%02d", minutes/60%24, minutes%60)
+	}
+	return value
+}
+
+// unsanitize removes the quote export.sanitizeForExcel puts before values that
+// would otherwise be read as formulas
+func unsanitize(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@\t\r", rune(value[1])) || strings.HasPrefix(value, "'0x") {
+		return value[1:]
+	}
+	return value
+}
+
+// excelFoodRow is a food row with the entry it restores
+type excelFoodRow struct {
+	line  int
+	entry *models.CalorieEntry
+	key   string // Date, time and food, as written by the export
+}
+
+// importExcel restores weight history and calorie entries from our own Excel
+// export. Sheets and columns are recognized by their titles in any supported
+// language. Rows matching an existing record are duplicates; rows for the same
+// food and time (or, for weight, the same day) with different values are
+// conflicts. Neither is imported.
+func (s *Service) importExcel(req *ImportRequest, loc *time.Location) (*ImportResult, error) {
+	sheets, err := readExcel(req.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newResult(FormatExcel, req.DryRun)
+	var foodSheet, weightSheet *excelSheet
+	for _, sheet := range sheets {
+		switch {
+		case foodSheet == nil && sheet.has(columnDate, columnFood, columnWeightG, columnCalories):
+			foodSheet = sheet
+		case weightSheet == nil && sheet.has(columnDate, columnWeightKg):
+			weightSheet = sheet
+		default:
+			result.SkippedSheets = append(result.SkippedSheets, sheet.name)
+		}
+	}
+	if foodSheet == nil && weightSheet == nil {
+		return nil, ErrUnknownFormat
+	}
+
+	var entries []*models.ImportedCalorieEntry
+	var newIngredients []*models.CalorieEntry
+	if foodSheet != nil {
+		if entries, newIngredients, err = s.matchFood(req.UserID, foodSheet, loc, result); err != nil {
+			return nil, err
+		}
+	}
+
+	var weights []*models.WeightHistory
+	if weightSheet != nil {
+		if weights, err = s.matchWeights(req.UserID, weightSheet, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.save(req, result, entries, weights, newIngredients); err != nil {
+		return nil, err
+	}
+	if !req.DryRun && result.Weights != nil {
+		s.logger.Info("Weight history imported", "user_id", req.UserID, "created", result.Weights.Created,
+			"duplicates", result.Weights.Duplicates, "conflicts", result.Weights.Conflicts)
+	}
+
+	s.logger.Debug("Import completed successfully", "user_id", req.UserID, "format", FormatExcel, "dry_run", req.DryRun)
+	return result, nil
+}
+
+// matchFood parses the food sheet and sorts its rows into new entries,
+// duplicates and conflicts with the user's existing entries
+func (s *Service) matchFood(userID int, sheet *excelSheet, loc *time.Location, result *ImportResult) ([]*models.ImportedCalorieEntry, []*models.CalorieEntry, error) {
+	var rows []*excelFoodRow
+	dateFrom, dateTo := "", ""
+	for i, values := range sheet.rows {
+		if isBlank(values) {
+			continue
+		}
+		result.Rows++
+		line := i + 2
+
+		entry, err := parseFoodRow(sheet, values, loc)
+		if err != nil {
+			result.addError(sheet.name, line, err.Error())
+			continue
+		}
+		if entry == nil {
+			result.Skipped++
+			continue
+		}
+
+		date := entry.MealDatetime.Format("2006-01-02")
+		if dateFrom == "" || date < dateFrom {
+			dateFrom = date
+		}
+		if date > dateTo {
+			dateTo = date
+		}
+		rows = append(rows, &excelFoodRow{line: line, entry: entry, key: entryKey(entry)})
+	}
+	if len(rows) == 0 {
+		return nil, nil, nil
+	}
+
+	existing, err := s.calorieRepo.GetByUserIDAndDateRange(userID, dateFrom, dateTo)
+	if err != nil {
+		s.logger.Error("Failed to get calorie entries", "error", err, "user_id", userID)
+		return nil, nil, err
+	}
+	byKey := make(map[string][]*models.CalorieEntry)
+	for _, entry := range existing {
+		key := entryKey(entry)
+		byKey[key] = append(byKey[key], entry)
+	}
+	existingKeys, err := s.calorieRepo.GetImportKeys(userID)
+	if err != nil {
+		s.logger.Error("Failed to get import keys", "error", err, "user_id", userID)
+		return nil, nil, err
+	}
+	knownFoods, err := s.knownFoods(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	occurrences := make(map[string]int)
+	var entries []*models.ImportedCalorieEntry
+	var newIngredients []*models.CalorieEntry
+	for _, row := range rows {
+		entry := row.entry
+		source := []string{row.key, fmt.Sprint(entry.Weight), fmt.Sprint(entry.Calories)}
+		sourceKey := strings.Join(source, "\x1f")
+		occurrences[sourceKey]++
+		importKey := importKey(FormatExcel, source, occurrences[sourceKey])
+
+		// Each existing entry matches at most one row, so repeated rows are compared one to one
+		status := RowNew
+		var conflictWith *int
+		if existingKeys[importKey] {
+			status = RowDuplicate
+		} else if candidates := byKey[row.key]; len(candidates) > 0 {
+			status = RowConflict
+			conflictWith = &candidates[0].ID
+			for i, candidate := range candidates {
+				if candidate.Calories == entry.Calories && math.Abs(candidate.Weight-entry.Weight) < 0.01 {
+					status, conflictWith = RowDuplicate, nil
+					byKey[row.key] = append(candidates[:i:i], candidates[i+1:]...)
+					break
+				}
+			}
+		}
+
+		switch status {
+		case RowDuplicate:
+			result.Duplicates++
+		case RowConflict:
+			result.Conflicts++
+		default:
+			result.Created++
+			entries = append(entries, &models.ImportedCalorieEntry{Entry: entry, ImportKey: importKey})
+			if !knownFoods[entry.Food] {
+				knownFoods[entry.Food] = true
+				newIngredients = append(newIngredients, entry)
+			}
+		}
+		if len(result.Entries) < PreviewLimit {
+			preview := newPreview(row.line, status, entry)
+			preview.ConflictWith = conflictWith
+			result.Entries = append(result.Entries, preview)
+		}
+	}
+
+	return entries, newIngredients, nil
+}
+
+// parseFoodRow reads a row of the food sheet; rows without calories give nil
+func parseFoodRow(sheet *excelSheet, values []string, loc *time.Location) (*models.CalorieEntry, error) {
+	food := cleanText(unsanitize(sheet.cell(values, columnFood)))
+	if food == "" {
+		return nil, errors.New("food is missing")
+	}
+
+	date, err := excelDate(sheet.cell(values, columnDate))
+	if err != nil {
+		return nil, err
+	}
+	mealDatetime, err := parseDateTime(date, excelClock(sheet.cell(values, columnTime)), "", loc)
+	if err != nil {
+		return nil, err
+	}
+
+	weight, err := sheet.number(values, columnWeightG)
+	if err != nil {
+		return nil, err
+	}
+	if weight == nil || *weight <= 0 {
+		return nil, errors.New("weight must be greater than 0")
+	}
+	calories, err := sheet.number(values, columnCalories)
+	if err != nil {
+		return nil, err
+	}
+	if calories == nil {
+		return nil, errors.New("calories are missing")
+	}
+	if math.Round(*calories) <= 0 {
+		return nil, nil
+	}
+
+	entry := &models.CalorieEntry{
+		Food:         food,
+		Calories:     int(math.Round(*calories)),
+		Weight:       *weight,
+		MealDatetime: mealDatetime,
+	}
+
+	kcalPer100g, err := sheet.number(values, columnKcalPer100)
+	if err != nil {
+		return nil, err
+	}
+	if kcalPer100g == nil || *kcalPer100g <= 0 {
+		kcalPer100g = round(*calories * 100 / *weight)
+	}
+	entry.KcalPer100g = *kcalPer100g
+
+	macros := []struct {
+		key    string
+		target **float64
+	}{{columnFats, &entry.Fats}, {columnCarbs, &entry.Carbs}, {columnProteins, &entry.Proteins}}
+	for _, macro := range macros {
+		value, err := sheet.number(values, macro.key)
+		if err != nil {
+			return nil, err
+		}
+		if value != nil && *value < 0 {
+			return nil, errors.New("nutrition values cannot be negative")
+		}
+		*macro.target = value
+	}
+
+	// Values the app cannot hold are dropped one by one instead of failing the row
+	for _, info := range models.NutrientCatalog {
+		value, err := sheet.number(values, nutrientPrefix+info.Code)
+		if err != nil {
+			return nil, err
+		}
+		if (models.Nutrients{}).With(info.Code, value).Validate() == nil {
+			entry.Nutrients = entry.Nutrients.With(info.Code, value)
+		}
+	}
+
+	return entry, nil
+}
+
+// entryKey identifies an entry the way the export shows it
+func entryKey(entry *models.CalorieEntry) string {
+	return entry.MealDatetime.Format("2006-01-02 15:04") + "\x1f" + entry.Food
+}
+
+// matchWeights parses the weight sheet and sorts its rows into new records,
+// duplicates and conflicts with the user's weight history. Weights are recorded
+// per day, like entries made in the app.
+func (s *Service) matchWeights(userID int, sheet *excelSheet, result *ImportResult) ([]*models.WeightHistory, error) {
+	result.Weights = &WeightResult{Entries: []*WeightPreview{}}
+	history, err := s.weightRepo.GetByUserID(userID)
+	if err != nil {
+		s.logger.Error("Failed to get weight history", "error", err, "user_id", userID)
+		return nil, err
+	}
+	byDate := make(map[string]*models.WeightHistory)
+	for _, record := range history {
+		date := record.RecordedAt.Format("2006-01-02")
+		if byDate[date] == nil {
+			byDate[date] = record
+		}
+	}
+
+	var weights []*models.WeightHistory
+	for i, values := range sheet.rows {
+		if isBlank(values) {
+			continue
+		}
+		result.Weights.Rows++
+		line := i + 2
+
+		date, err := excelDate(sheet.cell(values, columnDate))
+		if err == nil {
+			_, err = time.Parse("2006-01-02", date)
+		}
+		if err != nil {
+			result.addError(sheet.name, line, fmt.Sprintf("%q is not a date", sheet.cell(values, columnDate)))
+			continue
+		}
+		recordedAt, _ := time.Parse("2006-01-02", date)
+		weight, err := sheet.number(values, columnWeightKg)
+		if err != nil {
+			result.addError(sheet.name, line, err.Error())
+			continue
+		}
+		if weight == nil || *weight <= 0 || *weight > maxWeightKg {
+			result.addError(sheet.name, line, fmt.Sprintf("weight must be between 0 and %g kg", maxWeightKg))
+			continue
+		}
+
+		preview := &WeightPreview{Line: line, Status: RowNew, Weight: *weight, RecordedAt: recordedAt}
+		if record := byDate[date]; record != nil {
+			if math.Abs(record.Weight-*weight) < 0.001 {
+				preview.Status = RowDuplicate
+			} else {
+				preview.Status = RowConflict
+				if record.ID != 0 {
+					preview.ConflictWith = &record.ID
+				}
+			}
+		}
+
+		switch preview.Status {
+		case RowDuplicate:
+			result.Weights.Duplicates++
+		case RowConflict:
+			result.Weights.Conflicts++
+		default:
+			result.Weights.Created++
+			record := &models.WeightHistory{Weight: *weight, RecordedAt: recordedAt}
+			weights = append(weights, record)
+			// A second row for the same day is compared with this one
+			byDate[date] = record
+		}
+		if len(result.Weights.Entries) < PreviewLimit {
+			result.Weights.Entries = append(result.Weights.Entries, preview)
+		}
+	}
+
+	return weights, nil
+}
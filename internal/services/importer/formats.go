@@ -0,0 +1,217 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parser detects and reads the diary export of one app
+type parser interface {
+	format() Format
+	detect(columns map[string]int) bool
+	// parse returns nil without an error for rows that are not food, like exercise
+	parse(r record, loc *time.Location) (*diaryRow, error)
+}
+
+var parsers = []parser{myFitnessPal{}, cronometer{}, loseIt{}}
+
+// findParser returns the parser for a format, or detects it from the header
+func findParser(format Format, columns map[string]int) (parser, error) {
+	for _, p := range parsers {
+		if format != "" && p.format() != format {
+			continue
+		}
+		if p.detect(columns) {
+			return p, nil
+		}
+	}
+	return nil, ErrUnknownFormat
+}
+
+// nutrientColumn maps an export column to a nutrient; Scale converts the
+// column's unit to the catalog unit
+type nutrientColumn struct {
+	Column string
+	Code   string
+	Scale  float64
+}
+
+// readFood reads the calories, macros and nutrients shared by all formats
+func readFood(r record, row *diaryRow, caloriesColumn string, macros [3]string, nutrients []nutrientColumn) error {
+	calories, err := r.number(caloriesColumn)
+	if err != nil {
+		return err
+	}
+	if calories == nil {
+		return errors.New("calories are missing")
+	}
+	if *calories < 0 {
+		return errors.New("calories cannot be negative")
+	}
+	row.Calories = *calories
+
+	targets := []**float64{&row.Fats, &row.Carbs, &row.Proteins}
+	for i, column := range macros {
+		value, err := r.number(column)
+		if err != nil {
+			return err
+		}
+		if value != nil && *value < 0 {
+			return fmt.Errorf("%s cannot be negative", column)
+		}
+		*targets[i] = value
+	}
+
+	row.Nutrients = make(map[string]float64)
+	for _, column := range nutrients {
+		value, err := r.number(column.Column)
+		if err != nil {
+			return err
+		}
+		if value != nil {
+			row.Nutrients[column.Code] = *value * column.Scale
+		}
+	}
+	return nil
+}
+
+// myFitnessPal reads the "Nutrition" export, which has one row per meal and
+// day. Food names are only present in exports made by third-party tools.
+type myFitnessPal struct{}
+
+var myFitnessPalNutrients = []nutrientColumn{
+	{Column: "saturated fat", Code: "saturated_fat", Scale: 1},
+	{Column: "trans fat", Code: "trans_fat", Scale: 1},
+	{Column: "cholesterol", Code: "cholesterol", Scale: 1},
+	{Column: "sodium (mg)", Code: "sodium", Scale: 1},
+	{Column: "potassium", Code: "potassium", Scale: 1},
+	{Column: "fiber", Code: "fiber", Scale: 1},
+	{Column: "sugar", Code: "sugars", Scale: 1},
+}
+
+func (myFitnessPal) format() Format { return FormatMyFitnessPal }
+
+func (myFitnessPal) detect(columns map[string]int) bool {
+	return hasColumns(columns, "date", "meal", "calories", "fat (g)", "carbohydrates (g)", "protein (g)")
+}
+
+func (myFitnessPal) parse(r record, loc *time.Location) (*diaryRow, error) {
+	row := &diaryRow{Line: r.line, Meal: r.get("meal")}
+	row.Food = cleanText(r.get("food", "food name", "name"))
+	if row.Food == "" {
+		row.Food = cleanText(row.Meal)
+		row.MealTotal = true
+	}
+	if row.Food == "" {
+		return nil, errors.New("meal is missing")
+	}
+
+	var err error
+	if row.Date, err = parseDateTime(r.get("date"), r.get("time"), row.Meal, loc); err != nil {
+		return nil, err
+	}
+	if err := readFood(r, row, "calories", [3]string{"fat (g)", "carbohydrates (g)", "protein (g)"}, myFitnessPalNutrients); err != nil {
+		return nil, err
+	}
+
+	row.Source = []string{r.get("date"), r.get("time"), row.Meal, row.Food, r.get("calories")}
+	return row, nil
+}
+
+// cronometer reads the "Servings" export with one row per logged food
+type cronometer struct{}
+
+var cronometerNutrients = []nutrientColumn{
+	{Column: "fiber (g)", Code: "fiber", Scale: 1},
+	{Column: "sugars (g)", Code: "sugars", Scale: 1},
+	{Column: "saturated (g)", Code: "saturated_fat", Scale: 1},
+	{Column: "trans-fats (g)", Code: "trans_fat", Scale: 1},
+	{Column: "cholesterol (mg)", Code: "cholesterol", Scale: 1},
+	{Column: "sodium (mg)", Code: "sodium", Scale: 1},
+	{Column: "potassium (mg)", Code: "potassium", Scale: 1},
+	{Column: "calcium (mg)", Code: "calcium", Scale: 1},
+	{Column: "iron (mg)", Code: "iron", Scale: 1},
+	{Column: "magnesium (mg)", Code: "magnesium", Scale: 1},
+	{Column: "vitamin a (µg)", Code: "vitamin_a", Scale: 1},
+	{Column: "vitamin c (mg)", Code: "vitamin_c", Scale: 1},
+	{Column: "vitamin d (iu)", Code: "vitamin_d", Scale: 0.025},
+	{Column: "b12 (cobalamin) (µg)", Code: "vitamin_b12", Scale: 1},
+}
+
+func (cronometer) format() Format { return FormatCronometer }
+
+func (cronometer) detect(columns map[string]int) bool {
+	return hasColumns(columns, "day", "food name", "amount", "energy (kcal)")
+}
+
+func (cronometer) parse(r record, loc *time.Location) (*diaryRow, error) {
+	row := &diaryRow{Line: r.line, Meal: r.get("group"), Food: cleanText(r.get("food name"))}
+	if row.Food == "" {
+		return nil, errors.New("food name is missing")
+	}
+
+	var err error
+	if row.Date, err = parseDateTime(r.get("day"), r.get("time"), row.Meal, loc); err != nil {
+		return nil, err
+	}
+	row.Quantity, row.Unit, row.Grams = parseAmount(r.get("amount"))
+	if err := readFood(r, row, "energy (kcal)", [3]string{"fat (g)", "carbs (g)", "protein (g)"}, cronometerNutrients); err != nil {
+		return nil, err
+	}
+
+	row.Source = []string{r.get("day"), r.get("time"), row.Meal, row.Food, r.get("amount"), r.get("energy (kcal)")}
+	return row, nil
+}
+
+// loseIt reads the food log export; it also lists exercise and deleted items
+type loseIt struct{}
+
+var loseItNutrients = []nutrientColumn{
+	{Column: "saturated fat (g)", Code: "saturated_fat", Scale: 1},
+	{Column: "sugars (g)", Code: "sugars", Scale: 1},
+	{Column: "fiber (g)", Code: "fiber", Scale: 1},
+	{Column: "cholesterol (mg)", Code: "cholesterol", Scale: 1},
+	{Column: "sodium (mg)", Code: "sodium", Scale: 1},
+}
+
+func (loseIt) format() Format { return FormatLoseIt }
+
+func (loseIt) detect(columns map[string]int) bool {
+	return hasColumns(columns, "date", "name", "type", "quantity", "units", "calories")
+}
+
+func (loseIt) parse(r record, loc *time.Location) (*diaryRow, error) {
+	meal := r.get("type")
+	if strings.EqualFold(meal, "exercise") {
+		return nil, nil
+	}
+	switch strings.ToLower(r.get("deleted")) {
+	case "true", "yes", "1":
+		return nil, nil
+	}
+
+	row := &diaryRow{Line: r.line, Meal: meal, Food: cleanText(r.get("name"))}
+	if row.Food == "" {
+		return nil, errors.New("name is missing")
+	}
+
+	var err error
+	if row.Date, err = parseDateTime(r.get("date"), "", row.Meal, loc); err != nil {
+		return nil, err
+	}
+	if row.Quantity, err = r.number("quantity"); err != nil {
+		return nil, err
+	}
+	row.Unit = r.get("units")
+	if row.Quantity != nil {
+		row.Grams = toGrams(*row.Quantity, row.Unit)
+	}
+	if err := readFood(r, row, "calories", [3]string{"fat (g)", "carbohydrates (g)", "protein (g)"}, loseItNutrients); err != nil {
+		return nil, err
+	}
+
+	row.Source = []string{r.get("date"), row.Meal, row.Food, r.get("quantity"), row.Unit, r.get("calories")}
+	return row, nil
+}
@@ -0,0 +1,543 @@
+package importer
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+		ok    bool
+	}{
+		{"150", 150, true},
+		{" 12.5 ", 12.5, true},
+		{"12,5", 12.5, true},
+		{"1,234", 1234, true},
+		{"1,234.5", 1234.5, true},
+		{"1 234", 1234, true},
+		{"1,234,567", 1234567, true},
+		{"-3", -3, true},
+		{"", 0, false},
+		{"abc", 0, false},
+		{"NaN", 0, false},
+		{"Inf", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseNumber(tt.value)
+			if (err == nil) != tt.ok {
+				t.Fatalf("parseNumber(%q) error = %v, want ok %v", tt.value, err, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("parseNumber(%q) = %g, want %g", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		amount   string
+		quantity float64 // 0 when the amount has no quantity
+		unit     string
+		grams    float64 // 0 when the amount cannot be converted
+	}{
+		{"150.00 g", 150, "g", 150},
+		{"2 oz", 2, "oz", 56.69904625},
+		{"0,5 kg", 0.5, "kg", 500},
+		{"1 lbs.", 1, "lbs.", 453.59237},
+		{"1 cup - 240 g", 1, "cup - 240 g", 240},
+		{"2 slices (56 grams)", 2, "slices (56 grams)", 56},
+		{"1.00 serving", 1, "serving", 0},
+		{"a pinch", 0, "a pinch", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			quantity, unit, grams := parseAmount(tt.amount)
+			if gotQuantity := valueOf(quantity); gotQuantity != tt.quantity || unit != tt.unit {
+				t.Errorf("parseAmount(%q) = %g %q, want %g %q", tt.amount, gotQuantity, unit, tt.quantity, tt.unit)
+			}
+			if gotGrams := valueOf(grams); math.Abs(gotGrams-tt.grams) > 1e-9 {
+				t.Errorf("parseAmount(%q) grams = %g, want %g", tt.amount, gotGrams, tt.grams)
+			}
+		})
+	}
+}
+
+func TestParseDateTime(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	tests := []struct {
+		date, clock, meal string
+		want              string
+		ok                bool
+	}{
+		{"2026-03-05", "", "", "2026-03-05 12:00:00", true},
+		{"03/05/2026", "", "Breakfast", "2026-03-05 08:00:00", true},
+		{"3/5/26", "", " dinner ", "2026-03-05 19:00:00", true},
+		{"Mar 5, 2026", "", "Snacks", "2026-03-05 16:00:00", true},
+		{"2026/03/05", "7:30 pm", "Breakfast", "2026-03-05 19:30:00", true},
+		{"2026-03-05", "06:15:20", "", "2026-03-05 06:15:20", true},
+		{"05.03.2026", "", "", "", false},
+		{"2026-03-05", "noon", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date+" "+tt.clock, func(t *testing.T) {
+			got, err := parseDateTime(tt.date, tt.clock, tt.meal, loc)
+			if (err == nil) != tt.ok {
+				t.Fatalf("got error %v, want ok %v", err, tt.ok)
+			}
+			if !tt.ok {
+				return
+			}
+			if got.Location() != loc || got.Format("2006-01-02 15:04:05") != tt.want {
+				t.Errorf("got %v, want %s in %s", got, tt.want, loc)
+			}
+		})
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	data := "\xef\xbb\xbfDay;Food Name;Amount\n2026-03-05;Oatmeal;\"50,0 g\"\n2026-03-06;Tea;1 cup\n"
+	columns, records, err := readCSV([]byte(data))
+	if err != nil {
+		t.Fatalf("readCSV: %v", err)
+	}
+	if !hasColumns(columns, "day", "food name", "amount") {
+		t.Errorf("got columns %v", columns)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if got := records[0].get("amount"); got != "50,0 g" {
+		t.Errorf("amount = %q, want %q", got, "50,0 g")
+	}
+	if records[1].line != 3 {
+		t.Errorf("second record on line %d, want 3", records[1].line)
+	}
+	if got := records[1].get("missing", "food name"); got != "Tea" {
+		t.Errorf("get falls back to %q, want %q", got, "Tea")
+	}
+
+	for _, empty := range []string{"", " \n ", "\xef\xbb\xbf"} {
+		if _, _, err := readCSV([]byte(empty)); !errors.Is(err, ErrEmptyFile) {
+			t.Errorf("readCSV(%q) error = %v, want %v", empty, err, ErrEmptyFile)
+		}
+	}
+}
+
+func TestFindParser(t *testing.T) {
+	columnsOf := func(names ...string) map[string]int {
+		columns := make(map[string]int, len(names))
+		for i, name := range names {
+			columns[name] = i
+		}
+		return columns
+	}
+	myFitnessPalColumns := columnsOf("date", "meal", "calories", "fat (g)", "carbohydrates (g)", "protein (g)")
+	cronometerColumns := columnsOf("day", "group", "food name", "amount", "energy (kcal)")
+	loseItColumns := columnsOf("date", "name", "type", "quantity", "units", "calories")
+
+	tests := []struct {
+		name    string
+		format  Format
+		columns map[string]int
+		want    Format
+	}{
+		{"detects MyFitnessPal", "", myFitnessPalColumns, FormatMyFitnessPal},
+		{"detects Cronometer", "", cronometerColumns, FormatCronometer},
+		{"detects Lose It!", "", loseItColumns, FormatLoseIt},
+		{"requested format", FormatCronometer, cronometerColumns, FormatCronometer},
+		{"requested format does not match", FormatLoseIt, cronometerColumns, ""},
+		{"unknown header", "", columnsOf("date", "food"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := findParser(tt.format, tt.columns)
+			if tt.want == "" {
+				if !errors.Is(err, ErrUnknownFormat) {
+					t.Errorf("got %v, want %v", err, ErrUnknownFormat)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("findParser: %v", err)
+			}
+			if p.format() != tt.want {
+				t.Errorf("got %s, want %s", p.format(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParsers(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		want     *diaryRow // nil when the row is skipped
+		wantErr  bool
+		wantDate string
+	}{
+		{
+			name: "MyFitnessPal meal total",
+			csv:  "Date,Meal,Calories,Fat (g),Carbohydrates (g),Protein (g),Sodium (mg)\n2026-03-05,Breakfast,450,12,60,20,300\n",
+			want: &diaryRow{Meal: "Breakfast", Food: "Breakfast", MealTotal: true, Calories: 450,
+				Fats: ptr(12), Carbs: ptr(60), Proteins: ptr(20), Nutrients: map[string]float64{"sodium": 300}},
+			wantDate: "2026-03-05 08:00",
+		},
+		{
+			name: "MyFitnessPal food",
+			csv:  "Date,Time,Meal,Food Name,Calories,Fat (g),Carbohydrates (g),Protein (g)\n2026-03-05,7:45 AM,Breakfast,  Greek   yogurt ,100,,,10\n",
+			want: &diaryRow{Meal: "Breakfast", Food: "Greek yogurt", Calories: 100,
+				Proteins: ptr(10), Nutrients: map[string]float64{}},
+			wantDate: "2026-03-05 07:45",
+		},
+		{
+			name: "Cronometer serving",
+			csv:  "Day,Group,Food Name,Amount,Energy (kcal),Fat (g),Carbs (g),Protein (g),Vitamin D (IU)\n2026-03-05,Lunch,Rice,150.00 g,195,0.4,42,4,40\n",
+			want: &diaryRow{Meal: "Lunch", Food: "Rice", Quantity: ptr(150), Unit: "g", Grams: ptr(150), Calories: 195,
+				Fats: ptr(0.4), Carbs: ptr(42), Proteins: ptr(4), Nutrients: map[string]float64{"vitamin_d": 1}},
+			wantDate: "2026-03-05 13:00",
+		},
+		{
+			name:    "Cronometer negative calories",
+			csv:     "Day,Food Name,Amount,Energy (kcal)\n2026-03-05,Rice,150 g,-5\n",
+			wantErr: true,
+		},
+		{
+			name: "Lose It! food",
+			csv:  "Date,Name,Type,Quantity,Units,Calories,Fat (g),Carbohydrates (g),Protein (g)\n03/05/2026,Almonds,Snacks,1,Ounce,164,14,6,6\n",
+			want: &diaryRow{Meal: "Snacks", Food: "Almonds", Quantity: ptr(1), Unit: "Ounce", Grams: ptr(28.349523125), Calories: 164,
+				Fats: ptr(14), Carbs: ptr(6), Proteins: ptr(6), Nutrients: map[string]float64{}},
+			wantDate: "2026-03-05 16:00",
+		},
+		{
+			name: "Lose It! exercise is skipped",
+			csv:  "Date,Name,Type,Quantity,Units,Calories\n03/05/2026,Running,Exercise,30,Minutes,-300\n",
+		},
+		{
+			name: "Lose It! deleted item is skipped",
+			csv:  "Date,Name,Type,Quantity,Units,Calories,Deleted\n03/05/2026,Almonds,Snacks,1,Ounce,164,true\n",
+		},
+		{
+			name:    "Lose It! invalid quantity",
+			csv:     "Date,Name,Type,Quantity,Units,Calories\n03/05/2026,Almonds,Snacks,lots,Ounce,164\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, records, err := readCSV([]byte(tt.csv))
+			if err != nil {
+				t.Fatalf("readCSV: %v", err)
+			}
+			p, err := findParser("", columns)
+			if err != nil {
+				t.Fatalf("findParser: %v", err)
+			}
+
+			row, err := p.parse(records[0], time.UTC)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("parse succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if tt.want == nil {
+				if row != nil {
+					t.Errorf("got %+v, want the row skipped", row)
+				}
+				return
+			}
+			if row == nil {
+				t.Fatal("row was skipped")
+			}
+
+			if row.Line != 2 || row.Meal != tt.want.Meal || row.Food != tt.want.Food || row.MealTotal != tt.want.MealTotal ||
+				row.Unit != tt.want.Unit || row.Calories != tt.want.Calories {
+				t.Errorf("got %+v, want %+v", row, tt.want)
+			}
+			if got := row.Date.Format("2006-01-02 15:04"); got != tt.wantDate {
+				t.Errorf("date = %s, want %s", got, tt.wantDate)
+			}
+			for name, pair := range map[string][2]*float64{
+				"quantity": {row.Quantity, tt.want.Quantity},
+				"grams":    {row.Grams, tt.want.Grams},
+				"fats":     {row.Fats, tt.want.Fats},
+				"carbs":    {row.Carbs, tt.want.Carbs},
+				"proteins": {row.Proteins, tt.want.Proteins},
+			} {
+				if (pair[0] == nil) != (pair[1] == nil) || pair[0] != nil && math.Abs(*pair[0]-*pair[1]) > 1e-9 {
+					t.Errorf("%s = %v, want %v", name, formatOptional(pair[0]), formatOptional(pair[1]))
+				}
+			}
+			if len(row.Nutrients) != len(tt.want.Nutrients) {
+				t.Errorf("nutrients = %v, want %v", row.Nutrients, tt.want.Nutrients)
+			}
+			for code, want := range tt.want.Nutrients {
+				if math.Abs(row.Nutrients[code]-want) > 1e-9 {
+					t.Errorf("nutrient %s = %g, want %g", code, row.Nutrients[code], want)
+				}
+			}
+			if len(row.Source) == 0 {
+				t.Error("row has no source fields for its import key")
+			}
+		})
+	}
+}
+
+func TestImportKey(t *testing.T) {
+	source := []string{"2026-03-05", "Breakfast", "Oatmeal", "370"}
+	key := importKey(FormatCronometer, source, 0)
+
+	if importKey(FormatCronometer, source, 0) != key {
+		t.Error("the same row gave different keys")
+	}
+	if importKey(FormatCronometer, source, 1) == key {
+		t.Error("a repeated row has the key of the first one")
+	}
+	if importKey(FormatLoseIt, source, 0) == key {
+		t.Error("rows of different formats share a key")
+	}
+	if importKey(FormatCronometer, []string{"2026-03-05", "Breakfast", "Oatmeal", "371"}, 0) == key {
+		t.Error("different rows share a key")
+	}
+}
+
+func TestExcelDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+		ok    bool
+	}{
+		{"46086", "2026-03-05", true},
+		{"46086.75", "2026-03-05", true},
+		{"2026-03-05", "2026-03-05", true},
+		{"03/05/2026", "03/05/2026", true}, // Text is left to parseDateTime
+		{"-1", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := excelDate(tt.value)
+			if (err == nil) != tt.ok {
+				t.Fatalf("excelDate(%q) error = %v, want ok %v", tt.value, err, tt.ok)
+			}
+			if got != tt.want {
+				t.Errorf("excelDate(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcelClock(t *testing.T) {
+	tests := []struct{ value, want string }{
+		{"0", "00:00"},
+		{"0.5", "12:00"},
+		{"0.3125", "07:30"},
+		{"0.9999999", "00:00"}, // Rounds up to midnight
+		{"08:15", "08:15"},
+		{"1.5", "1.5"}, // Not a time of day, left to parseDateTime to reject
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := excelClock(tt.value); got != tt.want {
+			t.Errorf("excelClock(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestUnsanitize(t *testing.T) {
+	tests := []struct{ value, want string }{
+		{"'=SUM(A1)", "=SUM(A1)"},
+		{"'+380", "+380"},
+		{"'-fried", "-fried"},
+		{"'@home", "@home"},
+		{"'0x1F", "0x1F"},
+		{"'quoted'", "'quoted'"},
+		{"'", "'"},
+		{"Oatmeal", "Oatmeal"},
+	}
+
+	for _, tt := range tests {
+		if got := unsanitize(tt.value); got != tt.want {
+			t.Errorf("unsanitize(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseFoodRow(t *testing.T) {
+	sheet := &excelSheet{columns: map[string]int{
+		columnDate:                   0,
+		columnTime:                   1,
+		columnFood:                   2,
+		columnWeightG:                3,
+		columnCalories:               4,
+		columnKcalPer100:             5,
+		columnFats:                   6,
+		columnCarbs:                  7,
+		columnProteins:               8,
+		nutrientPrefix + "fiber":     9,
+		nutrientPrefix + "potassium": 10,
+	}}
+
+	tests := []struct {
+		name    string
+		values  []string
+		want    *models.CalorieEntry // nil when the row is skipped
+		wantErr bool
+	}{
+		{
+			name:   "text date and time",
+			values: []string{"2026-03-05", "08:30", "Oatmeal", "50", "185", "370", "3.5", "30", "6.5", "5"},
+			want: &models.CalorieEntry{Food: "Oatmeal", Weight: 50, Calories: 185, KcalPer100g: 370,
+				Fats: ptr(3.5), Carbs: ptr(30), Proteins: ptr(6.5), Nutrients: models.Nutrients{"fiber": 5},
+				MealDatetime: time.Date(2026, 3, 5, 8, 30, 0, 0, time.UTC)},
+		},
+		{
+			name:   "date serial, time fraction and a sanitized name",
+			values: []string{"46086", "0.75", "'-fried eggs", "120", "180.6"},
+			want: &models.CalorieEntry{Food: "-fried eggs", Weight: 120, Calories: 181, KcalPer100g: 150.5,
+				MealDatetime: time.Date(2026, 3, 5, 18, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:   "nutrients out of range are dropped",
+			values: []string{"2026-03-05", "12:00", "Bran", "100", "200", "", "", "", "", "1000", "350"},
+			want: &models.CalorieEntry{Food: "Bran", Weight: 100, Calories: 200, KcalPer100g: 200,
+				Nutrients: models.Nutrients{"potassium": 350}, MealDatetime: time.Date(2026, 3, 5, 12, 0, 0, 0, time.UTC)},
+		},
+		{name: "zero calories", values: []string{"2026-03-05", "12:00", "Water", "250", "0"}},
+		{name: "missing food", values: []string{"2026-03-05", "12:00", "", "100", "200"}, wantErr: true},
+		{name: "missing weight", values: []string{"2026-03-05", "12:00", "Rice", "", "200"}, wantErr: true},
+		{name: "missing calories", values: []string{"2026-03-05", "12:00", "Rice", "100"}, wantErr: true},
+		{name: "invalid date", values: []string{"yesterday", "12:00", "Rice", "100", "130"}, wantErr: true},
+		{name: "invalid number", values: []string{"2026-03-05", "12:00", "Rice", "100", "lots"}, wantErr: true},
+		{name: "negative macro", values: []string{"2026-03-05", "12:00", "Rice", "100", "130", "", "-1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, err := parseFoodRow(sheet, tt.values, time.UTC)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseFoodRow succeeded with %+v, want an error", entry)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFoodRow: %v", err)
+			}
+			if tt.want == nil {
+				if entry != nil {
+					t.Errorf("got %+v, want the row skipped", entry)
+				}
+				return
+			}
+			if entry == nil {
+				t.Fatal("row was skipped")
+			}
+
+			if entry.Food != tt.want.Food || entry.Weight != tt.want.Weight || entry.Calories != tt.want.Calories ||
+				entry.KcalPer100g != tt.want.KcalPer100g || !entry.MealDatetime.Equal(tt.want.MealDatetime) {
+				t.Errorf("got %s %g g %d kcal %g kcal/100g at %v, want %s %g g %d kcal %g kcal/100g at %v",
+					entry.Food, entry.Weight, entry.Calories, entry.KcalPer100g, entry.MealDatetime,
+					tt.want.Food, tt.want.Weight, tt.want.Calories, tt.want.KcalPer100g, tt.want.MealDatetime)
+			}
+			for name, pair := range map[string][2]*float64{
+				"fats":     {entry.Fats, tt.want.Fats},
+				"carbs":    {entry.Carbs, tt.want.Carbs},
+				"proteins": {entry.Proteins, tt.want.Proteins},
+			} {
+				if formatOptional(pair[0]) != formatOptional(pair[1]) {
+					t.Errorf("%s = %v, want %v", name, formatOptional(pair[0]), formatOptional(pair[1]))
+				}
+			}
+			if len(entry.Nutrients) != len(tt.want.Nutrients) {
+				t.Errorf("nutrients = %v, want %v", entry.Nutrients, tt.want.Nutrients)
+			}
+			for code, want := range tt.want.Nutrients {
+				if got := entry.Nutrients.Get(code); got == nil || *got != want {
+					t.Errorf("nutrient %s = %v, want %g", code, formatOptional(got), want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadExcel(t *testing.T) {
+	f := excelize.NewFile()
+	rows := [][]any{
+		{"  DATE ", "Time", "Food", "Weight (g)", "Calories", "Notes", "Fiber (g)"},
+		{"2026-03-05", "08:30", "Oatmeal", 50, 185, "ignored", 5},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow("Sheet1", cell, &row); err != nil {
+			t.Fatalf("SetSheetRow: %v", err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !isExcel(buf.Bytes()) {
+		t.Fatal("the workbook is not recognized as Excel")
+	}
+
+	sheets, err := readExcel(buf.Bytes())
+	if err != nil {
+		t.Fatalf("readExcel: %v", err)
+	}
+	if len(sheets) != 1 || len(sheets[0].rows) != 1 {
+		t.Fatalf("got %d sheets, want 1 with 1 row", len(sheets))
+	}
+	sheet := sheets[0]
+	if !sheet.has(columnDate, columnTime, columnFood, columnWeightG, columnCalories, nutrientPrefix+"fiber") {
+		t.Errorf("columns not resolved: %v", sheet.columns)
+	}
+	if len(sheet.columns) != 6 {
+		t.Errorf("got %d columns, want the unknown one ignored: %v", len(sheet.columns), sheet.columns)
+	}
+	if got := sheet.cell(sheet.rows[0], columnFood); got != "Oatmeal" {
+		t.Errorf("food = %q, want Oatmeal", got)
+	}
+
+	if _, err := readExcel([]byte("PK\x03\x04 not a workbook")); !errors.Is(err, ErrInvalidFile) {
+		t.Errorf("got %v, want %v", err, ErrInvalidFile)
+	}
+}
+
+func ptr(value float64) *float64 {
+	return &value
+}
+
+func valueOf(value *float64) float64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+func formatOptional(value *float64) any {
+	if value == nil {
+		return nil
+	}
+	return *value
+}
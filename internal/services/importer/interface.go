@@ -0,0 +1,6 @@
+package importer
+
+// Servicer defines the import service contract used by handlers.
+type Servicer interface {
+	Import(req *ImportRequest) (*ImportResult, error)
+}
@@ -0,0 +1,268 @@
+package importer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	ingredientservice "ypeskov/kkal-tracker/internal/services/ingredient"
+)
+
+const maxFoodLength = 255
+
+// diaryRow is a food row of an export with the totals for the logged amount
+type diaryRow struct {
+	Line      int
+	Date      time.Time
+	Meal      string
+	Food      string
+	MealTotal bool // The row sums a whole meal, so it names no food
+	Quantity  *float64
+	Unit      string
+	Grams     *float64 // nil when the amount cannot be converted to grams
+	Calories  float64
+	Fats      *float64
+	Carbs     *float64
+	Proteins  *float64
+	Nutrients map[string]float64 // Totals in the units of models.NutrientCatalog
+	Source    []string           // Raw fields that identify the row for re-imports
+}
+
+// record is a CSV row addressed by header names
+type record struct {
+	line    int
+	columns map[string]int // Lower-cased header -> index
+	values  []string
+}
+
+// get returns the first non-empty value of the named columns
+func (r record) get(names ...string) string {
+	for _, name := range names {
+		if i, ok := r.columns[name]; ok && i < len(r.values) {
+			if value := strings.TrimSpace(r.values[i]); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// number parses an optional numeric column; blank values give nil
+func (r record) number(names ...string) (*float64, error) {
+	value := r.get(names...)
+	if value == "" {
+		return nil, nil
+	}
+	number, err := parseNumber(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %q is not a number", names[0], value)
+	}
+	return &number, nil
+}
+
+// readCSV reads the header and rows of an export. The delimiter is detected from
+// the header line so exports saved by spreadsheet apps in other locales work too.
+func readCSV(data []byte) (map[string]int, []record, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil, ErrEmptyFile
+	}
+
+	headerLine, _, _ := bytes.Cut(data, []byte("\n"))
+	comma := ','
+	for _, candidate := range []rune{';', '\t'} {
+		if bytes.Count(headerLine, []byte(string(candidate))) > bytes.Count(headerLine, []byte(string(comma))) {
+			comma = candidate
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	var records []record
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if len(records) == MaxRows {
+			return nil, nil, ErrTooManyRows
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record{line: line, columns: columns, values: values})
+	}
+
+	return columns, records, nil
+}
+
+// hasColumns reports whether the header contains all the given columns
+func hasColumns(columns map[string]int, names ...string) bool {
+	for _, name := range names {
+		if _, ok := columns[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// parseNumber accepts thousands separators and a decimal comma
+func parseNumber(value string) (float64, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "")
+	if strings.Contains(value, ".") {
+		value = strings.ReplaceAll(value, ",", "")
+	} else if i := strings.LastIndex(value, ","); i >= 0 {
+		if strings.Count(value, ",") == 1 && len(value)-i-1 != 3 {
+			value = strings.Replace(value, ",", ".", 1)
+		} else {
+			value = strings.ReplaceAll(value, ",", "")
+		}
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return 0, errors.New("invalid number")
+	}
+	return number, nil
+}
+
+var dateLayouts = []string{"2006-01-02", "01/02/2006", "1/2/2006", "01/02/06", "1/2/06", "2006/01/02", "Jan 2, 2006", "January 2, 2006"}
+
+var clockLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04PM", "3:04:05 PM", "03:04 PM"}
+
+// mealTimes places rows without a time of day at a typical time for their meal
+var mealTimes = map[string]int{
+	"breakfast": 8,
+	"lunch":     13,
+	"dinner":    19,
+	"snack":     16,
+	"snacks":    16,
+}
+
+// parseDateTime combines a date, an optional time of day and the meal into a
+// time in the given location
+func parseDateTime(date, clock, meal string, loc *time.Location) (time.Time, error) {
+	var day time.Time
+	var err error
+	for _, layout := range dateLayouts {
+		if day, err = time.ParseInLocation(layout, date, loc); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a date", date)
+	}
+
+	hour, minute, second := 12, 0, 0
+	if h, ok := mealTimes[strings.ToLower(strings.TrimSpace(meal))]; ok {
+		hour = h
+	}
+	if clock != "" {
+		var t time.Time
+		for _, layout := range clockLayouts {
+			if t, err = time.Parse(layout, strings.ToUpper(clock)); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%q is not a time of day", clock)
+		}
+		hour, minute, second = t.Clock()
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, loc), nil
+}
+
+// unitAliases maps unit spellings used by exports to mass units ToGrams knows
+var unitAliases = map[string]string{
+	"g": "g", "gram": "g", "grams": "g", "gr": "g",
+	"kg": "kg", "kilogram": "kg", "kilograms": "kg",
+	"oz": "oz", "ounce": "oz", "ounces": "oz",
+	"lb": "lb", "lbs": "lb", "pound": "lb", "pounds": "lb",
+}
+
+// massUnit returns the standard name of a mass unit, or "" for other units
+func massUnit(unit string) string {
+	return unitAliases[strings.ToLower(strings.TrimSpace(strings.TrimSuffix(unit, ".")))]
+}
+
+// toGrams converts an amount in a mass unit to grams
+func toGrams(quantity float64, unit string) *float64 {
+	name := massUnit(unit)
+	if name == "" {
+		return nil
+	}
+	grams, _, err := ingredientservice.ToGrams(nil, quantity, name)
+	if err != nil {
+		return nil
+	}
+	return &grams
+}
+
+// amountPattern splits "1.5 cup" into quantity and unit; gramsPattern finds the
+// gram weight some exports append to other units, e.g. "1 cup - 240 g"
+var (
+	amountPattern = regexp.MustCompile(`^([0-9]+(?:[.,][0-9]+)?)\s*(.*)$`)
+	gramsPattern  = regexp.MustCompile(`([0-9]+(?:[.,][0-9]+)?)\s*(?:g|grams?)\)?$`)
+)
+
+// parseAmount reads an amount column such as "150.00 g" or "1.00 serving"
+func parseAmount(amount string) (*float64, string, *float64) {
+	match := amountPattern.FindStringSubmatch(strings.TrimSpace(amount))
+	if match == nil {
+		return nil, strings.TrimSpace(amount), nil
+	}
+	quantity, err := parseNumber(match[1])
+	if err != nil {
+		return nil, strings.TrimSpace(amount), nil
+	}
+	unit := strings.TrimSpace(match[2])
+
+	if grams := toGrams(quantity, unit); grams != nil {
+		return &quantity, unit, grams
+	}
+	if weight := gramsPattern.FindStringSubmatch(unit); weight != nil {
+		if grams, err := parseNumber(weight[1]); err == nil && grams > 0 {
+			return &quantity, unit, &grams
+		}
+	}
+	return &quantity, unit, nil
+}
+
+// cleanText collapses whitespace and truncates to the food name size
+func cleanText(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if utf8.RuneCountInString(value) > maxFoodLength {
+		value = strings.TrimSpace(string([]rune(value)[:maxFoodLength]))
+	}
+	return value
+}
+
+// importKey fingerprints a row. Identical rows in one file, like two equal
+// snacks on the same day, are told apart by their occurrence number.
+func importKey(format Format, source []string, occurrence int) string {
+	sum := sha256.Sum256([]byte(strings.Join(append([]string{string(format)}, source...), "\x1f")))
+	return fmt.Sprintf("%s:%s:%d", format, hex.EncodeToString(sum[:12]), occurrence)
+}
@@ -0,0 +1,280 @@
+package importer
+
+import (
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// Service imports food diaries exported by other apps
+type Service struct {
+	calorieRepo    repositories.CalorieEntryRepository
+	importRepo     repositories.ImportRepository
+	ingredientRepo repositories.IngredientRepository
+	weightRepo     repositories.WeightHistoryRepository
+	logger         *slog.Logger
+}
+
+// New creates a new import service
+func New(calorieRepo repositories.CalorieEntryRepository,
+	importRepo repositories.ImportRepository,
+	ingredientRepo repositories.IngredientRepository,
+	weightRepo repositories.WeightHistoryRepository,
+	logger *slog.Logger) *Service {
+	return &Service{
+		calorieRepo:    calorieRepo,
+		importRepo:     importRepo,
+		ingredientRepo: ingredientRepo,
+		weightRepo:     weightRepo,
+		logger:         logger.With("service", "import"),
+	}
+}
+
+// Import parses a diary export and creates calorie entries for its rows, along
+// with user ingredients for foods logged by weight that the user does not have
+// yet. Rows imported before are recognized by their import key and skipped, so
+// importing an overlapping export again only adds the new rows. In a dry run
+// nothing is saved and the result shows what would happen. Excel files made by
+// our own export are restored by importExcel.
+func (s *Service) Import(req *ImportRequest) (*ImportResult, error) {
+	s.logger.Debug("Import called", "user_id", req.UserID, "format", req.Format, "dry_run", req.DryRun, "size", len(req.Data))
+
+	loc := req.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	if req.Format == FormatExcel || (req.Format == "" && isExcel(req.Data)) {
+		return s.importExcel(req, loc)
+	}
+
+	columns, records, err := readCSV(req.Data)
+	if err != nil {
+		return nil, err
+	}
+	p, err := findParser(req.Format, columns)
+	if err != nil {
+		return nil, err
+	}
+
+	existingKeys, err := s.calorieRepo.GetImportKeys(req.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get import keys", "error", err, "user_id", req.UserID)
+		return nil, err
+	}
+	knownFoods, err := s.knownFoods(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newResult(p.format(), req.DryRun)
+	occurrences := make(map[string]int)
+	var entries []*models.ImportedCalorieEntry
+	var newIngredients []*models.CalorieEntry
+
+	for _, r := range records {
+		if isBlank(r.values) {
+			continue
+		}
+		result.Rows++
+
+		row, err := p.parse(r, loc)
+		if err != nil {
+			result.addError("", r.line, err.Error())
+			continue
+		}
+		if row == nil {
+			result.Skipped++
+			continue
+		}
+		entry := toEntry(row)
+		if entry == nil {
+			result.Skipped++
+			continue
+		}
+
+		source := strings.Join(row.Source, "\x1f")
+		occurrences[source]++
+		key := importKey(p.format(), row.Source, occurrences[source])
+
+		status := RowNew
+		if existingKeys[key] {
+			status = RowDuplicate
+			result.Duplicates++
+		} else {
+			result.Created++
+			entries = append(entries, &models.ImportedCalorieEntry{Entry: entry, ImportKey: key})
+			if row.Grams != nil && !row.MealTotal && !knownFoods[entry.Food] {
+				knownFoods[entry.Food] = true
+				newIngredients = append(newIngredients, entry)
+			}
+		}
+		if len(result.Entries) < PreviewLimit {
+			result.Entries = append(result.Entries, newPreview(row.Line, status, entry))
+		}
+	}
+
+	if err := s.save(req, result, entries, nil, newIngredients); err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug("Import completed successfully", "user_id", req.UserID, "dry_run", req.DryRun, "rows", result.Rows)
+	return result, nil
+}
+
+// save creates the new entries, weigh-ins and ingredients of an import in one
+// transaction, so a failed import leaves nothing behind; a dry run only lists
+// the ingredients
+func (s *Service) save(req *ImportRequest, result *ImportResult, entries []*models.ImportedCalorieEntry,
+	weights []*models.WeightHistory, newIngredients []*models.CalorieEntry) error {
+	if req.DryRun {
+		for _, entry := range newIngredients {
+			result.IngredientsCreated = append(result.IngredientsCreated, entry.Food)
+		}
+		return nil
+	}
+
+	ingredients := make([]*models.UserIngredient, 0, len(newIngredients))
+	for _, entry := range newIngredients {
+		ingredients = append(ingredients, &models.UserIngredient{
+			Name:        entry.Food,
+			KcalPer100g: entry.KcalPer100g,
+			Fats:        entry.Fats,
+			Carbs:       entry.Carbs,
+			Proteins:    entry.Proteins,
+			Nutrients:   entry.Nutrients,
+		})
+	}
+
+	if len(entries) > 0 || len(weights) > 0 {
+		created, err := s.importRepo.CreateImported(req.UserID, entries, weights, ingredients)
+		if err != nil {
+			s.logger.Error("Failed to save import", "error", err, "user_id", req.UserID)
+			return err
+		}
+		result.Created = created
+	}
+	for _, ingredient := range ingredients {
+		result.IngredientsCreated = append(result.IngredientsCreated, ingredient.Name)
+	}
+
+	s.logger.Info("Diary imported", "user_id", req.UserID, "format", result.Format, "rows", result.Rows, "created", result.Created,
+		"duplicates", result.Duplicates, "conflicts", result.Conflicts, "skipped", result.Skipped, "failed", result.Failed,
+		"ingredients", len(result.IngredientsCreated))
+	return nil
+}
+
+// knownFoods returns the names of the user's ingredients
+func (s *Service) knownFoods(userID int) (map[string]bool, error) {
+	ingredients, err := s.ingredientRepo.GetAllUserIngredients(userID)
+	if err != nil {
+		s.logger.Error("Failed to get user ingredients", "error", err, "user_id", userID)
+		return nil, err
+	}
+	names := make(map[string]bool, len(ingredients))
+	for _, ingredient := range ingredients {
+		names[ingredient.Name] = true
+	}
+	return names, nil
+}
+
+func newResult(format Format, dryRun bool) *ImportResult {
+	return &ImportResult{
+		Format:             format,
+		DryRun:             dryRun,
+		IngredientsCreated: []string{},
+		Entries:            []*EntryPreview{},
+		Errors:             []*RowError{},
+	}
+}
+
+func (r *ImportResult) addError(sheet string, line int, message string) {
+	r.Failed++
+	if len(r.Errors) < MaxRowErrors {
+		r.Errors = append(r.Errors, &RowError{Sheet: sheet, Line: line, Message: message})
+	}
+}
+
+// toEntry converts a row to a calorie entry with values per 100 g. Rows without
+// calories are not worth a diary entry and give nil. When the amount is not a
+// weight, the entry is stored as a 100 g portion holding the row's totals and
+// the amount is kept in the food name.
+func toEntry(row *diaryRow) *models.CalorieEntry {
+	calories := int(math.Round(row.Calories))
+	if calories <= 0 {
+		return nil
+	}
+
+	entry := &models.CalorieEntry{
+		Food:         row.Food,
+		Calories:     calories,
+		Weight:       100,
+		MealDatetime: row.Date,
+	}
+	if row.Grams != nil && *row.Grams > 0 {
+		entry.Weight = *round(*row.Grams)
+		if unit := massUnit(row.Unit); unit != "" && unit != "g" {
+			entry.Quantity = row.Quantity
+			entry.Unit = &unit
+		}
+	} else if !row.MealTotal && row.Quantity != nil {
+		entry.Food = cleanText(row.Food + " (" + strconv.FormatFloat(*row.Quantity, 'f', -1, 64) + " " + row.Unit + ")")
+	}
+
+	per100g := func(total *float64) *float64 {
+		if total == nil {
+			return nil
+		}
+		return round(*total * 100 / entry.Weight)
+	}
+	entry.KcalPer100g = *per100g(&row.Calories)
+	entry.Fats = per100g(row.Fats)
+	entry.Carbs = per100g(row.Carbs)
+	entry.Proteins = per100g(row.Proteins)
+
+	// Values the app cannot hold are dropped one by one instead of failing the row
+	for code, total := range row.Nutrients {
+		value := per100g(&total)
+		if (models.Nutrients{}).With(code, value).Validate() == nil {
+			entry.Nutrients = entry.Nutrients.With(code, value)
+		}
+	}
+	entry.Nutrients = entry.Nutrients.Complete()
+
+	return entry
+}
+
+func newPreview(line int, status string, entry *models.CalorieEntry) *EntryPreview {
+	return &EntryPreview{
+		Line:         line,
+		Status:       status,
+		Food:         entry.Food,
+		Calories:     entry.Calories,
+		Weight:       entry.Weight,
+		Quantity:     entry.Quantity,
+		Unit:         entry.Unit,
+		Fats:         entry.Fats,
+		Carbs:        entry.Carbs,
+		Proteins:     entry.Proteins,
+		MealDatetime: entry.MealDatetime,
+	}
+}
+
+func isBlank(values []string) bool {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func round(value float64) *float64 {
+	rounded := math.Round(value*100) / 100
+	return &rounded
+}
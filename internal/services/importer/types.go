@@ -0,0 +1,94 @@
+package importer
+
+import "time"
+
+// Format identifies the app a diary export comes from
+type Format string
+
+const (
+	FormatMyFitnessPal Format = "myfitnesspal"
+	FormatCronometer   Format = "cronometer"
+	FormatLoseIt       Format = "loseit"
+	FormatExcel        Format = "excel" // Our own Excel export
+)
+
+// Import limits
+const (
+	MaxRows      = 50000
+	PreviewLimit = 200 // Entries listed in a result
+	MaxRowErrors = 500 // Row errors listed in a result; Failed counts all of them
+)
+
+// Row statuses in a preview
+const (
+	RowNew       = "new"
+	RowDuplicate = "duplicate"
+	RowConflict  = "conflict" // An existing record on the same date differs
+)
+
+// ImportRequest contains an uploaded diary export
+type ImportRequest struct {
+	UserID   int
+	Data     []byte
+	Format   Format         // Empty to detect it from the header
+	Location *time.Location // Time zone of the dates in the file
+	DryRun   bool           // Parse and report without saving anything
+}
+
+// ImportResult reports what an import did, or would do in a dry run
+type ImportResult struct {
+	Format             Format          `json:"format"`
+	DryRun             bool            `json:"dry_run"`
+	Rows               int             `json:"rows"`
+	Created            int             `json:"created"`    // Entries created, or that would be created
+	Duplicates         int             `json:"duplicates"` // Rows imported before
+	Conflicts          int             `json:"conflicts"`  // Rows not imported because they differ from an existing record
+	Skipped            int             `json:"skipped"`    // Exercise, deleted and empty rows
+	Failed             int             `json:"failed"`
+	IngredientsCreated []string        `json:"ingredients_created"`
+	Entries            []*EntryPreview `json:"entries"`
+	Weights            *WeightResult   `json:"weights,omitempty"`        // Excel exports only
+	SkippedSheets      []string        `json:"skipped_sheets,omitempty"` // Excel sheets that are not ours
+	Errors             []*RowError     `json:"errors"`
+}
+
+// WeightResult reports the weight history rows of an import
+type WeightResult struct {
+	Rows       int              `json:"rows"`
+	Created    int              `json:"created"`
+	Duplicates int              `json:"duplicates"`
+	Conflicts  int              `json:"conflicts"`
+	Entries    []*WeightPreview `json:"entries"`
+}
+
+// WeightPreview is a weight record parsed from a row
+type WeightPreview struct {
+	Line         int       `json:"line"`
+	Status       string    `json:"status"` // new, duplicate or conflict
+	Weight       float64   `json:"weight"`
+	RecordedAt   time.Time `json:"recorded_at"`
+	ConflictWith *int      `json:"conflict_with,omitempty"` // ID of the differing record
+}
+
+// EntryPreview is a calorie entry parsed from a row
+type EntryPreview struct {
+	Line         int       `json:"line"`
+	Status       string    `json:"status"` // new or duplicate
+	Food         string    `json:"food"`
+	Calories     int       `json:"calories"`
+	Weight       float64   `json:"weight"`
+	Quantity     *float64  `json:"quantity,omitempty"`
+	Unit         *string   `json:"unit,omitempty"`
+	Fats         *float64  `json:"fats,omitempty"` // Per 100 g like on calorie entries
+	Carbs        *float64  `json:"carbs,omitempty"`
+	Proteins     *float64  `json:"proteins,omitempty"`
+	MealDatetime time.Time `json:"meal_datetime"`
+	ConflictWith *int      `json:"conflict_with,omitempty"` // ID of the differing entry
+}
+
+// RowError explains why a row was not imported
+type RowError struct {
+	Sheet   string `json:"sheet,omitempty"` // Excel exports only
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,182 @@
+package ingredient
+
+import (
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+
+	"ypeskov/kkal-tracker/internal/config"
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// Sources of a barcode lookup result
+const (
+	BarcodeSourceUser   = "user"
+	BarcodeSourceGlobal = "global"
+)
+
+// NormalizeBarcode removes spaces and dashes from a GTIN barcode (EAN-8, UPC-A, EAN-13
+// or GTIN-14) and checks its check digit. UPC-A codes are padded to EAN-13 so both
+// spellings of the same product match.
+func NormalizeBarcode(code string) (string, error) {
+	code = strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code))
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidBarcode
+		}
+	}
+
+	switch len(code) {
+	case 8, 13, 14:
+	case 12:
+		code = "0" + code
+	default:
+		return "", ErrInvalidBarcode
+	}
+
+	// GS1 check digit: weights 3 and 1 alternate from the rightmost data digit
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	if (10-sum%10)%10 != int(code[len(code)-1]-'0') {
+		return "", ErrInvalidBarcode
+	}
+
+	return code, nil
+}
+
+// LookupBarcode finds a food by barcode without any network calls. The user's own
+// ingredient wins: one with that barcode, or else the user's copy of the catalog item.
+// The catalog item is returned as well when there is one, named in the given language.
+func (s *Service) LookupBarcode(userID int, code, language string) (*BarcodeLookupResult, error) {
+	s.logger.Debug("LookupBarcode called", "user_id", userID, "barcode", code)
+
+	barcode, err := NormalizeBarcode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BarcodeLookupResult{Barcode: barcode}
+
+	ingredient, err := s.ingredientRepo.GetUserIngredientByBarcode(userID, barcode)
+	switch {
+	case err == nil:
+		result.Source = BarcodeSourceUser
+		result.Ingredient = ingredient
+	case !errors.Is(err, sql.ErrNoRows):
+		s.logger.Error("Failed to look up user ingredient by barcode", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	global, err := s.ingredientRepo.GetGlobalIngredientByBarcode(barcode)
+	switch {
+	case err == nil:
+		result.GlobalIngredient = global
+		result.Name = localizedName(global.Names, language)
+		if result.Ingredient == nil {
+			result.Source = BarcodeSourceGlobal
+			copied, err := s.ingredientRepo.GetUserIngredientByGlobalID(userID, global.ID)
+			switch {
+			case err == nil:
+				result.Source = BarcodeSourceUser
+				result.Ingredient = copied
+			case !errors.Is(err, sql.ErrNoRows):
+				s.logger.Error("Failed to get user copy of global ingredient", "error", err, "user_id", userID, "global_ingredient_id", global.ID)
+				return nil, err
+			}
+		}
+	case !errors.Is(err, sql.ErrNoRows):
+		s.logger.Error("Failed to look up global ingredient by barcode", "error", err)
+		return nil, err
+	}
+
+	if result.Ingredient == nil && result.GlobalIngredient == nil {
+		return nil, ErrBarcodeNotFound
+	}
+	if result.Ingredient != nil {
+		result.Name = result.Ingredient.Name
+	}
+
+	s.logger.Debug("LookupBarcode completed successfully", "user_id", userID, "barcode", barcode, "source", result.Source)
+	return result, nil
+}
+
+// localizedName picks the name in the requested language, then the default language,
+// then the first one by language code
+func localizedName(names map[string]string, language string) string {
+	if name := names[language]; name != "" {
+		return name
+	}
+	if name := names[config.DefaultLanguageCode]; name != "" {
+		return name
+	}
+	languages := make([]string, 0, len(names))
+	for lang := range names {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	for _, lang := range languages {
+		if names[lang] != "" {
+			return names[lang]
+		}
+	}
+	return ""
+}
+
+// normalizeBarcodeInput validates an optional barcode from a request in place.
+// An empty barcode means it should be cleared.
+func normalizeBarcodeInput(barcode *string) error {
+	if barcode == nil {
+		return nil
+	}
+	if strings.TrimSpace(*barcode) == "" {
+		*barcode = ""
+		return nil
+	}
+	normalized, err := NormalizeBarcode(*barcode)
+	if err != nil {
+		return err
+	}
+	*barcode = normalized
+	return nil
+}
+
+// barcodeTaken reports whether another ingredient of the user already has the barcode
+func (s *Service) barcodeTaken(userID, ingredientID int, barcode *string) (bool, error) {
+	if barcode == nil || *barcode == "" {
+		return false, nil
+	}
+
+	existing, err := s.ingredientRepo.GetUserIngredientByBarcode(userID, *barcode)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return existing.ID != ingredientID, nil
+}
+
+// setBarcode stores a barcode sent with a create or update request on the ingredient.
+// Nil leaves the barcode unchanged and an empty one clears it.
+func (s *Service) setBarcode(ingredient *models.UserIngredient, barcode *string) error {
+	if barcode == nil {
+		return nil
+	}
+
+	var value *string
+	if *barcode != "" {
+		value = barcode
+	}
+	if err := s.ingredientRepo.SetUserIngredientBarcode(ingredient.UserID, ingredient.ID, value); err != nil {
+		return err
+	}
+	ingredient.Barcode = value
+	return nil
+}
@@ -0,0 +1,218 @@
+package ingredient
+
+import (
+	"sort"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// Duplicate detection tuning
+const (
+	MaxMergeIngredients    = 50
+	duplicateKcalTolerance = 5.0  // Absolute kcal per 100g difference always accepted
+	duplicateKcalRatio     = 0.05 // Relative kcal difference accepted for energy-dense foods
+	duplicateMacroMaxDiff  = 1.5  // Grams per 100g
+)
+
+// FindDuplicates groups the user's ingredients that likely describe the same food:
+// names equal after normalization, or names a typo apart with close nutrition values.
+// Each group suggests the ingredient to keep: a catalog copy first, then the most used.
+func (s *Service) FindDuplicates(userID int) ([]*DuplicateGroup, error) {
+	s.logger.Debug("FindDuplicates called", "user_id", userID)
+
+	ingredients, err := s.ingredientRepo.GetAllUserIngredients(userID)
+	if err != nil {
+		s.logger.Error("Failed to get user ingredients", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	useCounts, err := s.getUseCounts(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([][]rune, len(ingredients))
+	for i, ing := range ingredients {
+		names[i] = []rune(NormalizeName(ing.Name))
+	}
+
+	// Union-find over ingredient indexes
+	parent := make([]int, len(ingredients))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range ingredients {
+		for j := i + 1; j < len(ingredients); j++ {
+			if isDuplicate(ingredients[i], ingredients[j], names[i], names[j]) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range ingredients {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+
+	groups := []*DuplicateGroup{}
+	for _, indexes := range members {
+		if len(indexes) < 2 {
+			continue
+		}
+
+		group := &DuplicateGroup{Reason: DuplicateSameName}
+		for _, i := range indexes {
+			if string(names[i]) != string(names[indexes[0]]) {
+				group.Reason = DuplicateSimilar
+			}
+			group.Ingredients = append(group.Ingredients, &DuplicateCandidate{
+				UserIngredient: ingredients[i],
+				UseCount:       useCounts[ingredients[i].Name],
+			})
+		}
+		sort.Slice(group.Ingredients, func(a, b int) bool {
+			return keepBefore(group.Ingredients[a], group.Ingredients[b])
+		})
+		group.SuggestedKeepID = group.Ingredients[0].ID
+		groups = append(groups, group)
+	}
+
+	sort.Slice(groups, func(a, b int) bool {
+		return groups[a].SuggestedKeepID < groups[b].SuggestedKeepID
+	})
+
+	s.logger.Debug("FindDuplicates completed successfully", "user_id", userID, "groups", len(groups))
+	return groups, nil
+}
+
+// MergeIngredients keeps one ingredient and merges the others into it. Calorie entries
+// and meal plan items that referenced the merged ingredients are rewritten to the kept
+// one in the same transaction that deletes the merged ingredients.
+func (s *Service) MergeIngredients(req *MergeRequest) (*MergeResult, error) {
+	s.logger.Debug("MergeIngredients called", "user_id", req.UserID, "keep_id", req.KeepID, "merge_ids", req.MergeIDs)
+
+	if err := validateMergeRequest(req); err != nil {
+		return nil, err
+	}
+
+	renamed, err := s.ingredientRepo.MergeUserIngredients(req.UserID, req.KeepID, req.MergeIDs)
+	if err != nil {
+		s.logger.Error("Failed to merge user ingredients", "error", err, "user_id", req.UserID, "keep_id", req.KeepID)
+		return nil, err
+	}
+
+	ingredient, err := s.ingredientRepo.GetUserIngredientByID(req.UserID, req.KeepID)
+	if err != nil {
+		s.logger.Error("Failed to get merged ingredient", "error", err, "user_id", req.UserID, "ingredient_id", req.KeepID)
+		return nil, err
+	}
+
+	s.logger.Info("Ingredients merged", "user_id", req.UserID, "keep_id", req.KeepID,
+		"merged", len(req.MergeIDs), "entries_renamed", renamed)
+	return &MergeResult{
+		Ingredient:     ingredient,
+		MergedIDs:      req.MergeIDs,
+		EntriesRenamed: renamed,
+	}, nil
+}
+
+// getUseCounts counts the user's recent calorie entries by exact food name, which tells
+// apart duplicates that normalize to the same name
+func (s *Service) getUseCounts(userID int) (map[string]int, error) {
+	now := time.Now()
+	dateTo := now.AddDate(0, 0, 1).Format("2006-01-02")
+	dateFrom := now.AddDate(0, 0, -usageWindowDays).Format("2006-01-02")
+
+	foods, err := s.calorieRepo.GetFoodUsage(userID, dateFrom, dateTo)
+	if err != nil {
+		s.logger.Error("Failed to get food usage for use counts", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(foods))
+	for _, food := range foods {
+		counts[food.Food] = food.Count
+	}
+	return counts, nil
+}
+
+func validateMergeRequest(req *MergeRequest) error {
+	if req.KeepID <= 0 || len(req.MergeIDs) == 0 || len(req.MergeIDs) > MaxMergeIngredients {
+		return ErrInvalidMerge
+	}
+
+	seen := map[int]bool{req.KeepID: true}
+	for _, id := range req.MergeIDs {
+		if id <= 0 || seen[id] {
+			return ErrInvalidMerge
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// isDuplicate reports whether two ingredients likely describe the same food
+func isDuplicate(a, b *models.UserIngredient, nameA, nameB []rune) bool {
+	if string(nameA) == string(nameB) {
+		return true
+	}
+	if len(nameA) < minFuzzyQueryLength || len(nameB) < minFuzzyQueryLength {
+		return false
+	}
+
+	maxEdits := shortTokenMaxEdits
+	if min(len(nameA), len(nameB)) > 2*shortTokenLengthEdge {
+		maxEdits = longTokenMaxEdits
+	}
+	if levenshtein(nameA, nameB, maxEdits) > maxEdits {
+		return false
+	}
+
+	return similarNutrition(a, b)
+}
+
+// similarNutrition reports whether nutrition values are close enough to be the same food.
+// A macro missing on one side does not count against similarity.
+func similarNutrition(a, b *models.UserIngredient) bool {
+	kcalDiff := a.KcalPer100g - b.KcalPer100g
+	if kcalDiff < 0 {
+		kcalDiff = -kcalDiff
+	}
+	if kcalDiff > max(duplicateKcalTolerance, duplicateKcalRatio*max(a.KcalPer100g, b.KcalPer100g)) {
+		return false
+	}
+
+	for _, pair := range [][2]*float64{{a.Fats, b.Fats}, {a.Carbs, b.Carbs}, {a.Proteins, b.Proteins}} {
+		if pair[0] == nil || pair[1] == nil {
+			continue
+		}
+		diff := *pair[0] - *pair[1]
+		if diff > duplicateMacroMaxDiff || diff < -duplicateMacroMaxDiff {
+			return false
+		}
+	}
+	return true
+}
+
+// keepBefore orders merge candidates by preference: catalog copies, then the most
+// used, then the oldest
+func keepBefore(a, b *DuplicateCandidate) bool {
+	aLinked, bLinked := a.GlobalIngredientID != nil, b.GlobalIngredientID != nil
+	if aLinked != bLinked {
+		return aLinked
+	}
+	if a.UseCount != b.UseCount {
+		return a.UseCount > b.UseCount
+	}
+	return a.ID < b.ID
+}
@@ -1,5 +1,11 @@
 package ingredient
 
+import (
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
 type CreateIngredientRequest struct {
 	UserID      int
 	Name        string
@@ -7,6 +13,8 @@ type CreateIngredientRequest struct {
 	Fats        *float64
 	Carbs       *float64
 	Proteins    *float64
+	Nutrients   models.Nutrients
+	Barcode     *string // Nil leaves it unset, empty clears it
 }
 
 type UpdateIngredientRequest struct {
@@ -17,4 +25,158 @@ type UpdateIngredientRequest struct {
 	Fats         *float64
 	Carbs        *float64
 	Proteins     *float64
-}
\ No newline at end of file
+	Nutrients    models.Nutrients
+	Barcode      *string // Nil leaves it unchanged, empty clears it
+}
+
+type SearchRequest struct {
+	UserID int
+	Query  string
+	Limit  int
+	Cursor string
+}
+
+// SearchResult is an ingredient together with its usage statistics and match quality
+type SearchResult struct {
+	*models.UserIngredient
+	UseCount   int        `json:"use_count"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Match      MatchType  `json:"match,omitempty"`
+}
+
+// SearchPage is one page of search results
+type SearchPage struct {
+	Items      []*SearchResult `json:"items"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// Reasons for grouping ingredients as duplicates
+const (
+	DuplicateSameName = "same_name" // Names differ only in case, accents or punctuation
+	DuplicateSimilar  = "similar"   // Names are a typo apart and nutrition values are close
+)
+
+// DuplicateCandidate is an ingredient in a duplicate group with its usage count
+type DuplicateCandidate struct {
+	*models.UserIngredient
+	UseCount int `json:"use_count"`
+}
+
+// DuplicateGroup is a set of ingredients that likely describe the same food
+type DuplicateGroup struct {
+	Reason          string                `json:"reason"`
+	SuggestedKeepID int                   `json:"suggested_keep_id"`
+	Ingredients     []*DuplicateCandidate `json:"ingredients"`
+}
+
+type MergeRequest struct {
+	UserID   int
+	KeepID   int
+	MergeIDs []int
+}
+
+// MergeResult describes a completed merge
+type MergeResult struct {
+	Ingredient     *models.UserIngredient `json:"ingredient"`
+	MergedIDs      []int                  `json:"merged_ids"`
+	EntriesRenamed int64                  `json:"entries_renamed"`
+}
+
+type ServingInput struct {
+	Name  string
+	Grams float64
+}
+
+type SetUnitsRequest struct {
+	UserID        int
+	IngredientID  int
+	DensityGPerML *float64
+	Servings      []ServingInput
+}
+
+// UnitsResponse is an ingredient's servings and density with the units a quantity can be logged in
+type UnitsResponse struct {
+	*models.IngredientUnits
+	Units []string `json:"units"`
+}
+
+// BarcodeLookupResult is the food found for a barcode. Source tells which one to use:
+// the user's own ingredient when there is one, otherwise the catalog item.
+type BarcodeLookupResult struct {
+	Barcode          string                   `json:"barcode"`
+	Source           string                   `json:"source"`
+	Name             string                   `json:"name"`
+	Ingredient       *models.UserIngredient   `json:"ingredient,omitempty"`
+	GlobalIngredient *models.GlobalIngredient `json:"global_ingredient,omitempty"`
+}
+
+type RelocalizeRequest struct {
+	UserID   int
+	Language string
+	// PreviousLanguage limits added ingredients to those the catalog had no name for in
+	// that language, so ingredients the user deleted earlier are not brought back.
+	// When empty, every catalog ingredient missing from the user's list is added.
+	PreviousLanguage string
+}
+
+// Reasons for keeping an ingredient name during re-localization
+const (
+	KeepReasonCustomized    = "customized"
+	KeepReasonNameTaken     = "name_taken"
+	KeepReasonNoTranslation = "no_translation"
+)
+
+// RenamedIngredient is an ingredient renamed to the new language
+type RenamedIngredient struct {
+	IngredientID int    `json:"ingredient_id"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+}
+
+// KeptIngredient is a catalog copy whose name was left unchanged
+type KeptIngredient struct {
+	IngredientID int    `json:"ingredient_id"`
+	Name         string `json:"name"`
+	Reason       string `json:"reason"`
+}
+
+// AddedIngredient is a catalog ingredient newly copied to the user
+type AddedIngredient struct {
+	IngredientID       int    `json:"ingredient_id"`
+	GlobalIngredientID int    `json:"global_ingredient_id"`
+	Name               string `json:"name"`
+}
+
+// RelocalizeReport describes what re-localization changed
+type RelocalizeReport struct {
+	Language string              `json:"language"`
+	Renamed  []RenamedIngredient `json:"renamed"`
+	Kept     []KeptIngredient    `json:"kept"`
+	Added    []AddedIngredient   `json:"added"`
+}
+
+// FieldChange is a difference between a user ingredient and its global source
+type FieldChange struct {
+	Field   string `json:"field"`
+	Current any    `json:"current"`
+	Global  any    `json:"global"`
+}
+
+// PendingUpdate is a global catalog change that was not applied automatically
+// because the user customized the affected fields
+type PendingUpdate struct {
+	IngredientID       int           `json:"ingredient_id"`
+	GlobalIngredientID int           `json:"global_ingredient_id"`
+	GlobalVersion      int           `json:"global_version"`
+	Name               string        `json:"name"`
+	Changes            []FieldChange `json:"changes"`
+}
+
+// SyncReport summarizes a sync of user copies with the global catalog
+type SyncReport struct {
+	Checked   int `json:"checked"`
+	Updated   int `json:"updated"`   // Nutrition values updated automatically
+	Renamed   int `json:"renamed"`   // Names updated automatically
+	Pending   int `json:"pending"`   // Customized copies with an update awaiting review
+	Conflicts int `json:"conflicts"` // Renames skipped because the name is already taken
+}
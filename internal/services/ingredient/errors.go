@@ -7,5 +7,20 @@ var (
 	ErrInvalidName           = errors.New("ingredient name is required")
 	ErrInvalidKcalPer100g    = errors.New("kcal per 100g must be greater than or equal to 0")
 	ErrInvalidNutritionValue = errors.New("nutrition values must be greater than or equal to 0")
+	ErrInvalidNutrients      = errors.New("invalid nutrient values")
 	ErrInvalidIngredientID   = errors.New("ingredient ID must be greater than 0")
+	ErrInvalidCursor         = errors.New("invalid pagination cursor")
+	ErrNoPendingUpdate       = errors.New("no pending catalog update for this ingredient")
+	ErrNameConflict          = errors.New("an ingredient with this name already exists")
+	ErrIngredientChanged     = errors.New("ingredient was changed, reload it and try again")
+	ErrUnsupportedLanguage   = errors.New("unsupported language")
+	ErrInvalidMerge          = errors.New("merge needs a kept ingredient and up to 50 other ingredients to merge into it")
+	ErrInvalidServing        = errors.New("invalid serving")
+	ErrInvalidDensity        = errors.New("density must be greater than 0 and at most 5 g/ml")
+	ErrInvalidQuantity       = errors.New("quantity must be greater than 0")
+	ErrUnknownUnit           = errors.New("unknown unit for this ingredient")
+	ErrDensityRequired       = errors.New("ingredient density is required for volume units")
+	ErrInvalidBarcode        = errors.New("barcode must be a valid EAN-8, UPC-A, EAN-13 or GTIN-14 code")
+	ErrBarcodeTaken          = errors.New("another ingredient already has this barcode")
+	ErrBarcodeNotFound       = errors.New("no ingredient found for this barcode")
 )
\ No newline at end of file
@@ -0,0 +1,391 @@
+package ingredient
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// fakeIngredientRepo serves a fixed list of user ingredients at a sync version
+type fakeIngredientRepo struct {
+	repositories.IngredientRepository
+	ingredients []*models.UserIngredient
+	version     int64
+	loads       int // Calls of GetAllUserIngredients
+}
+
+func (r *fakeIngredientRepo) GetAllUserIngredients(int) ([]*models.UserIngredient, error) {
+	r.loads++
+	return r.ingredients, nil
+}
+
+func (r *fakeIngredientRepo) GetSyncVersion(int) (int64, error) {
+	return r.version, nil
+}
+
+// fakeCalorieRepo serves a fixed list of calorie entries for any date range
+type fakeCalorieRepo struct {
+	repositories.CalorieEntryRepository
+	entries []*models.CalorieEntry
+}
+
+func (r *fakeCalorieRepo) GetByUserIDAndDateRange(int, string, string) ([]*models.CalorieEntry, error) {
+	return r.entries, nil
+}
+
+func (r *fakeCalorieRepo) GetFoodUsage(int, string, string) ([]*models.FoodUsage, error) {
+	byFood := make(map[string]*models.FoodUsage)
+	var usage []*models.FoodUsage
+	for _, entry := range r.entries {
+		food, ok := byFood[entry.Food]
+		if !ok {
+			food = &models.FoodUsage{Food: entry.Food}
+			byFood[entry.Food] = food
+			usage = append(usage, food)
+		}
+		food.Count++
+		if entry.MealDatetime.After(food.LastUsedAt) {
+			food.LastUsedAt = entry.MealDatetime
+		}
+	}
+	return usage, nil
+}
+
+func newTestService(ingredients []*models.UserIngredient, entries []*models.CalorieEntry) *Service {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return New(&fakeIngredientRepo{ingredients: ingredients}, &fakeCalorieRepo{entries: entries}, logger)
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Chicken Breast", "chicken breast"},
+		{"  Chicken--breast, grilled!  ", "chicken breast grilled"},
+		{"Crème Brûlée", "creme brulee"},
+		{"Jalapeño", "jalapeno"},
+		{"Йогурт", "иогурт"},
+		{"Сыр", "сир"},
+		{"Сір", "сир"},
+		{"Гречка (варена)", "гречка варена"},
+		{"Объём", "обьем"},
+		{"7-Up", "7 up"},
+		{"", ""},
+		{"!!!", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeName(tt.name); got != tt.want {
+				t.Errorf("NormalizeName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchName(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		fuzzy bool
+		want  MatchType
+		ok    bool
+	}{
+		{"apple", "apple", false, MatchExact, true},
+		{"apple pie", "apple", false, MatchPrefix, true},
+		{"green apple", "app", false, MatchWordPrefix, true},
+		{"chicken breast grilled", "grill chick", false, MatchWordPrefix, true},
+		{"pineapple", "apple", false, MatchSubstring, true},
+		{"chicken", "chiken", false, "", false},
+		{"chicken", "chiken", true, MatchFuzzy, true},
+		{"chicken breast", "chikn", true, MatchFuzzy, true},
+		{"milk", "mlkk", true, "", false},
+		{"rice", "bread", true, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query+" in "+tt.name, func(t *testing.T) {
+			got, ok := matchName(tt.name, tt.query, strings.Fields(tt.query), tt.fuzzy)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("matchName(%q, %q, fuzzy=%v) = %q, %v, want %q, %v", tt.name, tt.query, tt.fuzzy, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCursorLess(t *testing.T) {
+	keys := []searchCursor{
+		{Rank: 0, Score: 1, Name: "b", ID: 9},
+		{Rank: 0, Score: 1, Name: "b", ID: 10},
+		{Rank: 0, Score: 0.5, Name: "a", ID: 1},
+		{Rank: 1, Score: 5, Name: "a", ID: 2},
+		{Rank: 1, Score: 5, Name: "c", ID: 3},
+		{Rank: 4, Score: 10, Name: "a", ID: 4},
+	}
+
+	for i := range keys {
+		for j := range keys {
+			if got, want := cursorLess(keys[i], keys[j]), i < j; got != want {
+				t.Errorf("cursorLess(%+v, %+v) = %v, want %v", keys[i], keys[j], got, want)
+			}
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := searchCursor{Rank: 2, Score: 1.75, Name: "гречка варена", ID: 42, RankedAt: 1760000000}
+
+	decoded, err := decodeCursor(encodeCursor(cursor))
+	if err != nil {
+		t.Fatalf("decodeCursor: %v", err)
+	}
+	if *decoded != cursor {
+		t.Errorf("got %+v, want %+v", *decoded, cursor)
+	}
+
+	for _, invalid := range []string{"not base64!", "bm90IGpzb24"} {
+		if _, err := decodeCursor(invalid); err == nil {
+			t.Errorf("decodeCursor(%q) succeeded", invalid)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	var ranked []rankedResult
+	for id := 1; id <= 7; id++ {
+		ranked = append(ranked, rankedResult{
+			result: &SearchResult{UserIngredient: &models.UserIngredient{ID: id}},
+			key:    searchCursor{Rank: id / 3, Score: float64(10 - id), Name: "food", ID: id},
+		})
+	}
+	sort.Slice(ranked, func(i, j int) bool { return cursorLess(ranked[i].key, ranked[j].key) })
+	rankedAt := time.Unix(1760000000, 0)
+
+	var ids []int
+	var after *searchCursor
+	for pages := 0; ; pages++ {
+		if pages > len(ranked) {
+			t.Fatal("pagination does not end")
+		}
+		page := paginate(ranked, after, 3, rankedAt)
+		for _, item := range page.Items {
+			ids = append(ids, item.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor, err := decodeCursor(page.NextCursor)
+		if err != nil {
+			t.Fatalf("decodeCursor: %v", err)
+		}
+		if cursor.RankedAt != rankedAt.Unix() {
+			t.Errorf("cursor ranked at %d, want %d", cursor.RankedAt, rankedAt.Unix())
+		}
+		after = cursor
+	}
+
+	if want := []int{1, 2, 3, 4, 5, 6, 7}; !slices.Equal(ids, want) {
+		t.Errorf("got ids %v, want %v", ids, want)
+	}
+
+	if page := paginate(ranked, nil, 7, rankedAt); page.NextCursor != "" {
+		t.Error("a page holding every result has a next cursor")
+	}
+	if page := paginate(nil, nil, 3, rankedAt); len(page.Items) != 0 || page.NextCursor != "" {
+		t.Errorf("empty results gave %+v", page)
+	}
+}
+
+func TestNormalizeLimit(t *testing.T) {
+	tests := []struct{ limit, want int }{
+		{0, DefaultSearchLimit},
+		{-5, DefaultSearchLimit},
+		{10, 10},
+		{MaxSearchLimit + 1, MaxSearchLimit},
+	}
+	for _, tt := range tests {
+		if got := normalizeLimit(tt.limit); got != tt.want {
+			t.Errorf("normalizeLimit(%d) = %d, want %d", tt.limit, got, tt.want)
+		}
+	}
+}
+
+// Later pages are ranked at the time of the first page, so usage scores that
+// decayed in between neither repeat nor skip results
+func TestSearchIngredientsPagesAreStable(t *testing.T) {
+	now := time.Now()
+	firstPageAt := now.Add(-15 * 24 * time.Hour)
+	ingredients := []*models.UserIngredient{
+		{ID: 1, Name: "Apple"},
+		{ID: 2, Name: "Apricot"},
+	}
+	var entries []*models.CalorieEntry
+	// Apple was used more, but long ago; apricot less often, but today
+	for range 4 {
+		entries = append(entries, &models.CalorieEntry{Food: "Apple", MealDatetime: firstPageAt.Add(time.Hour)})
+	}
+	for range 3 {
+		entries = append(entries, &models.CalorieEntry{Food: "Apricot", MealDatetime: now})
+	}
+	svc := newTestService(ingredients, entries)
+
+	// Ranked now, apricot comes first
+	page, err := svc.SearchIngredients(&SearchRequest{UserID: 1, Query: "ap"})
+	if err != nil {
+		t.Fatalf("SearchIngredients: %v", err)
+	}
+	if len(page.Items) != 2 || page.Items[0].ID != 2 {
+		t.Fatalf("ranked now: got %v, want apricot first", resultIDs(page.Items))
+	}
+
+	// A cursor from a first page ranked two weeks ago, when apple came first
+	cursor := encodeCursor(searchCursor{Rank: matchRank[MatchPrefix], Score: 4, Name: "apple", ID: 1, RankedAt: firstPageAt.Unix()})
+	page, err = svc.SearchIngredients(&SearchRequest{UserID: 1, Query: "ap", Cursor: cursor})
+	if err != nil {
+		t.Fatalf("SearchIngredients: %v", err)
+	}
+	if ids := resultIDs(page.Items); !slices.Equal(ids, []int{2}) {
+		t.Errorf("second page: got %v, want [2]", ids)
+	}
+}
+
+func TestSearchIndexIsReusedUntilDataChanges(t *testing.T) {
+	ingredients := &fakeIngredientRepo{ingredients: []*models.UserIngredient{{ID: 1, Name: "Apple"}}, version: 5}
+	calories := &fakeCalorieRepo{entries: []*models.CalorieEntry{
+		{Food: "apple", MealDatetime: time.Now()},
+		{Food: "Äpple", MealDatetime: time.Now().Add(-time.Hour)},
+	}}
+	svc := New(ingredients, calories, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for _, query := range []string{"a", "ap", "app"} {
+		page, err := svc.SearchIngredients(&SearchRequest{UserID: 1, Query: query})
+		if err != nil {
+			t.Fatalf("SearchIngredients(%q): %v", query, err)
+		}
+		// Usage of names that normalize to the same food is added up
+		if len(page.Items) != 1 || page.Items[0].UseCount != 2 {
+			t.Fatalf("SearchIngredients(%q) = %+v, want apple used twice", query, page.Items)
+		}
+	}
+	if _, err := svc.GetRecentIngredients(1, 10); err != nil {
+		t.Fatalf("GetRecentIngredients: %v", err)
+	}
+	if ingredients.loads != 1 {
+		t.Errorf("ingredients loaded %d times at the same version, want once", ingredients.loads)
+	}
+
+	// A write raises the version and the next search sees it
+	ingredients.ingredients = append(ingredients.ingredients, &models.UserIngredient{ID: 2, Name: "Apricot"})
+	ingredients.version++
+	page, err := svc.SearchIngredients(&SearchRequest{UserID: 1, Query: "ap"})
+	if err != nil {
+		t.Fatalf("SearchIngredients: %v", err)
+	}
+	if ids := resultIDs(page.Items); !slices.Equal(ids, []int{1, 2}) || ingredients.loads != 2 {
+		t.Errorf("after a write got %v with %d loads, want [1 2] with 2", ids, ingredients.loads)
+	}
+}
+
+func TestSearchIndexCacheDropsLeastRecentlyUsed(t *testing.T) {
+	cache := newSearchIndexCache()
+	now := time.Now()
+	for userID := range maxSearchIndexes {
+		cache.put(userID, &searchIndex{version: 1, builtAt: now, usedAt: now.Add(time.Duration(userID) * time.Millisecond)})
+	}
+	// User 0 is used again, so user 1 is the least recently used one
+	cache.get(0, 1, now.Add(time.Minute))
+	cache.put(maxSearchIndexes, &searchIndex{version: 1, builtAt: now, usedAt: now})
+
+	if len(cache.indexes) != maxSearchIndexes {
+		t.Errorf("cache holds %d indexes, want %d", len(cache.indexes), maxSearchIndexes)
+	}
+	if cache.get(1, 1, now) != nil {
+		t.Error("least recently used index was kept")
+	}
+	if cache.get(0, 1, now) == nil || cache.get(maxSearchIndexes, 1, now) == nil {
+		t.Error("recently used index was dropped")
+	}
+	if cache.get(0, 2, now) != nil {
+		t.Error("index returned for another version")
+	}
+	if cache.get(0, 1, now.Add(searchIndexTTL)) != nil {
+		t.Error("index returned after its TTL")
+	}
+}
+
+func TestSearchIngredientsErrors(t *testing.T) {
+	svc := newTestService(nil, nil)
+
+	if _, err := svc.SearchIngredients(&SearchRequest{UserID: 1, Query: " -- "}); !errors.Is(err, ErrEmptyQuery) {
+		t.Errorf("punctuation-only query: got %v, want %v", err, ErrEmptyQuery)
+	}
+	if _, err := svc.SearchIngredients(&SearchRequest{UserID: 1, Query: "apple", Cursor: "%%%"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("invalid cursor: got %v, want %v", err, ErrInvalidCursor)
+	}
+}
+
+func resultIDs(results []*SearchResult) []int {
+	ids := make([]int, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+	return ids
+}
+
+func TestToGrams(t *testing.T) {
+	density := 1.03
+	milk := &models.IngredientUnits{
+		DensityGPerML: &density,
+		Servings: []*models.IngredientServing{
+			{Name: "Glass", Grams: 250},
+			{Name: "cup", Grams: 200}, // Overrides the standard cup
+		},
+	}
+	noDensity := &models.IngredientUnits{}
+
+	tests := []struct {
+		name      string
+		units     *models.IngredientUnits
+		quantity  float64
+		unit      string
+		wantGrams float64
+		wantUnit  string
+		wantErr   error
+	}{
+		{"grams", nil, 150, "g", 150, "g", nil},
+		{"kilograms", nil, 1.5, " KG ", 1500, "kg", nil},
+		{"ounces", nil, 2, "oz", 56.69904625, "oz", nil},
+		{"pounds", nil, 1, "lb", 453.59237, "lb", nil},
+		{"serving", milk, 2, "glass", 500, "Glass", nil},
+		{"serving overrides the standard unit", milk, 1, "cup", 200, "cup", nil},
+		{"volume with density", milk, 100, "ml", 103, "ml", nil},
+		{"liters with density", milk, 0.5, "l", 515, "l", nil},
+		{"volume without density", noDensity, 1, "tbsp", 0, "", ErrDensityRequired},
+		{"volume without ingredient", nil, 1, "cup", 0, "", ErrDensityRequired},
+		{"unknown unit", milk, 1, "handful", 0, "", ErrUnknownUnit},
+		{"zero quantity", nil, 0, "g", 0, "", ErrInvalidQuantity},
+		{"negative quantity", nil, -1, "g", 0, "", ErrInvalidQuantity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grams, unit, err := ToGrams(tt.units, tt.quantity, tt.unit)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if math.Abs(grams-tt.wantGrams) > 1e-9 || unit != tt.wantUnit {
+				t.Errorf("ToGrams(%g %q) = %g %q, want %g %q", tt.quantity, tt.unit, grams, unit, tt.wantGrams, tt.wantUnit)
+			}
+		})
+	}
+}
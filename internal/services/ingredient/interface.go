@@ -9,4 +9,25 @@ type Servicer interface {
 	CreateIngredient(req *CreateIngredientRequest) (*models.UserIngredient, error)
 	UpdateIngredient(req *UpdateIngredientRequest) (*models.UserIngredient, error)
 	DeleteIngredient(userID, ingredientID int) error
+	SearchIngredients(req *SearchRequest) (*SearchPage, error)
+	GetRecentIngredients(userID, limit int) ([]*SearchResult, error)
+
+	// Sync with the global catalog
+	SyncWithCatalog(userID int) (*SyncReport, error)
+	PropagateGlobalIngredient(globalID int) (*SyncReport, error)
+	GetPendingUpdates(userID int) ([]*PendingUpdate, error)
+	ApplyPendingUpdate(userID, ingredientID int) (*models.UserIngredient, error)
+	DismissPendingUpdate(userID, ingredientID int) error
+	Relocalize(req *RelocalizeRequest) (*RelocalizeReport, error)
+
+	// Units and named servings
+	GetIngredientUnits(userID, ingredientID int) (*UnitsResponse, error)
+	SetIngredientUnits(req *SetUnitsRequest) (*UnitsResponse, error)
+
+	// Barcodes
+	LookupBarcode(userID int, code, language string) (*BarcodeLookupResult, error)
+
+	// Duplicates
+	FindDuplicates(userID int) ([]*DuplicateGroup, error)
+	MergeIngredients(req *MergeRequest) (*MergeResult, error)
 }
@@ -0,0 +1,174 @@
+package ingredient
+
+import (
+	"errors"
+	"sort"
+
+	"ypeskov/kkal-tracker/internal/config"
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// Relocalize switches the user's catalog copies to another language. Copies that still
+// carry the name they were synced with are renamed; renamed or customized ones keep
+// their names. Catalog ingredients available in the new language are added.
+func (s *Service) Relocalize(req *RelocalizeRequest) (*RelocalizeReport, error) {
+	s.logger.Debug("Relocalize called", "user_id", req.UserID, "language", req.Language, "previous_language", req.PreviousLanguage)
+
+	if _, ok := config.SupportedLanguages[req.Language]; !ok {
+		return nil, ErrUnsupportedLanguage
+	}
+
+	links, err := s.ingredientRepo.GetLinkedUserIngredients(req.UserID)
+	if err != nil {
+		s.logger.Error("Failed to get linked user ingredients", "error", err, "user_id", req.UserID)
+		return nil, err
+	}
+
+	globals, err := s.getGlobalIngredients()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RelocalizeReport{
+		Language: req.Language,
+		Renamed:  []RenamedIngredient{},
+		Kept:     []KeptIngredient{},
+		Added:    []AddedIngredient{},
+	}
+
+	linked := make(map[int]bool, len(links))
+	for _, link := range links {
+		linked[link.GlobalIngredientID] = true
+
+		global, ok := globals[link.GlobalIngredientID]
+		if !ok {
+			continue
+		}
+
+		if err := s.relocalizeLink(link, global, req.Language, report); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only the curated catalog is copied; imported products are found by barcode
+	for _, global := range sortedGlobals(globals) {
+		if linked[global.ID] || global.Source != models.IngredientSourceManual ||
+			!isNewInLanguage(global, req.Language, req.PreviousLanguage) {
+			continue
+		}
+
+		if err := s.addFromCatalog(req.UserID, global, req.Language, report); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.Info("Ingredients relocalized", "user_id", req.UserID, "language", req.Language,
+		"renamed", len(report.Renamed), "kept", len(report.Kept), "added", len(report.Added))
+	return report, nil
+}
+
+// relocalizeLink renames a single copy when its name was never changed by the user
+func (s *Service) relocalizeLink(link *models.LinkedUserIngredient, global *models.GlobalIngredient, language string, report *RelocalizeReport) error {
+	name, ok := global.Names[language]
+	if !ok || name == "" {
+		report.Kept = append(report.Kept, KeptIngredient{IngredientID: link.ID, Name: link.Values.Name, Reason: KeepReasonNoTranslation})
+		return nil
+	}
+	if name == link.Values.Name {
+		return nil
+	}
+	if link.Synced == nil || link.Synced.Name != link.Values.Name {
+		report.Kept = append(report.Kept, KeptIngredient{IngredientID: link.ID, Name: link.Values.Name, Reason: KeepReasonCustomized})
+		return nil
+	}
+
+	taken, err := s.nameTaken(link.UserID, name, link.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		report.Kept = append(report.Kept, KeptIngredient{IngredientID: link.ID, Name: link.Values.Name, Reason: KeepReasonNameTaken})
+		return nil
+	}
+
+	updated := *link
+	synced := *link.Synced
+	updated.Values.Name = name
+	synced.Name = name
+	updated.Synced = &synced
+	err = s.ingredientRepo.UpdateLinkedUserIngredient(&updated)
+	if errors.Is(err, repositories.ErrVersionConflict) {
+		// Renamed or edited by the user since it was read
+		report.Kept = append(report.Kept, KeptIngredient{IngredientID: link.ID, Name: link.Values.Name, Reason: KeepReasonCustomized})
+		return nil
+	}
+	if err != nil {
+		s.logger.Error("Failed to rename user ingredient", "error", err, "user_id", link.UserID, "ingredient_id", link.ID)
+		return err
+	}
+
+	report.Renamed = append(report.Renamed, RenamedIngredient{IngredientID: link.ID, From: link.Values.Name, To: name})
+	return nil
+}
+
+// addFromCatalog copies a global ingredient to the user unless its name is taken
+func (s *Service) addFromCatalog(userID int, global *models.GlobalIngredient, language string, report *RelocalizeReport) error {
+	name := global.Names[language]
+
+	taken, err := s.nameTaken(userID, name, 0)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return nil
+	}
+
+	link := &models.LinkedUserIngredient{
+		UserID:             userID,
+		GlobalIngredientID: global.ID,
+		GlobalVersion:      global.Version,
+		Values: models.IngredientValues{
+			Name:        name,
+			KcalPer100g: global.KcalPer100g,
+			Fats:        global.Fats,
+			Carbs:       global.Carbs,
+			Proteins:    global.Proteins,
+			Nutrients:   global.Nutrients,
+		},
+	}
+
+	id, err := s.ingredientRepo.CreateLinkedUserIngredient(link)
+	if err != nil {
+		s.logger.Error("Failed to copy global ingredient", "error", err, "user_id", userID, "global_ingredient_id", global.ID)
+		return err
+	}
+
+	report.Added = append(report.Added, AddedIngredient{IngredientID: id, GlobalIngredientID: global.ID, Name: name})
+	return nil
+}
+
+// isNewInLanguage reports whether a global ingredient has a name in the language and,
+// when a previous language is given, had none in it
+func isNewInLanguage(global *models.GlobalIngredient, language, previousLanguage string) bool {
+	if name := global.Names[language]; name == "" {
+		return false
+	}
+	if previousLanguage == "" {
+		return true
+	}
+	return global.Names[previousLanguage] == ""
+}
+
+// sortedGlobals returns the catalog ordered by ID so lower IDs win name clashes,
+// like the registration copy does
+func sortedGlobals(globals map[int]*models.GlobalIngredient) []*models.GlobalIngredient {
+	sorted := make([]*models.GlobalIngredient, 0, len(globals))
+	for _, global := range globals {
+		sorted = append(sorted, global)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
@@ -0,0 +1,84 @@
+package ingredient
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// cyrillicFold maps Cyrillic letters that are commonly swapped between Ukrainian,
+// Russian and Bulgarian spellings (or typed without diacritics) to one base letter.
+// Letters with diacritics (й, ї, ё) are already reduced to their base by NFD.
+var cyrillicFold = map[rune]rune{
+	'і': 'и',
+	'ы': 'и',
+	'є': 'е',
+	'э': 'е',
+	'ґ': 'г',
+	'ъ': 'ь',
+}
+
+// NormalizeName converts an ingredient name to a form used for matching:
+// lower case, without accents, with folded Cyrillic variants, and with any
+// punctuation collapsed into single spaces.
+func NormalizeName(name string) string {
+	decomposed := norm.NFD.String(strings.ToLower(name))
+
+	var b strings.Builder
+	b.Grow(len(decomposed))
+	pendingSpace := false
+
+	for _, r := range decomposed {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if folded, ok := cyrillicFold[r]; ok {
+			r = folded
+		}
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			pendingSpace = true
+			continue
+		}
+		if pendingSpace && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		pendingSpace = false
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// levenshtein returns the edit distance between two strings, giving up early
+// and returning limit+1 once the distance is known to exceed limit
+func levenshtein(a, b []rune, limit int) int {
+	if diff := len(a) - len(b); diff > limit || -diff > limit {
+		return limit + 1
+	}
+
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
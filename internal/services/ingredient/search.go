@@ -0,0 +1,447 @@
+package ingredient
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// MatchType describes how an ingredient name matched the search query
+type MatchType string
+
+const (
+	MatchExact      MatchType = "exact"
+	MatchPrefix     MatchType = "prefix"
+	MatchWordPrefix MatchType = "word_prefix"
+	MatchSubstring  MatchType = "substring"
+	MatchFuzzy      MatchType = "fuzzy"
+)
+
+// matchRank orders match types from best to worst
+var matchRank = map[MatchType]int{
+	MatchExact:      0,
+	MatchPrefix:     1,
+	MatchWordPrefix: 2,
+	MatchSubstring:  3,
+	MatchFuzzy:      4,
+}
+
+// Search tuning
+const (
+	DefaultSearchLimit   = 20
+	MaxSearchLimit       = 100
+	usageWindowDays      = 180 // Calorie entries considered for usage ranking
+	recencyHalfLifeDays  = 14.0
+	minFuzzyQueryLength  = 3
+	shortTokenMaxEdits   = 1 // Allowed typos for query tokens up to 4 letters
+	longTokenMaxEdits    = 2
+	shortTokenLengthEdge = 4
+	searchIndexTTL       = 10 * time.Minute // Usage is reloaded this often even without writes
+	maxSearchIndexes     = 1000             // Users whose search index is kept in memory
+)
+
+// usageStats describes how often and how recently a food was logged
+type usageStats struct {
+	count    int
+	lastUsed time.Time
+}
+
+// score combines frequency and recency: every use counts, recent ones count more
+func (u usageStats) score(now time.Time) float64 {
+	if u.count == 0 {
+		return 0
+	}
+	days := now.Sub(u.lastUsed).Hours() / 24
+	if days < 0 {
+		days = 0
+	}
+	return float64(u.count) / (1 + days/recencyHalfLifeDays)
+}
+
+// indexedIngredient is an ingredient with its normalized name and usage
+type indexedIngredient struct {
+	ingredient *models.UserIngredient
+	name       string
+	stats      usageStats
+}
+
+// searchIndex is what the searches of a user run on. It is built at a sync
+// version of the user, which every write to their calorie entries and
+// ingredients raises, and is reused until the version changes, so typing a
+// query or paging through the results does not load the data again.
+type searchIndex struct {
+	version     int64
+	builtAt     time.Time
+	usedAt      time.Time
+	ingredients []indexedIngredient
+}
+
+// searchIndexCache keeps the search indexes of recently active users
+type searchIndexCache struct {
+	mu      sync.Mutex
+	indexes map[int]*searchIndex
+}
+
+func newSearchIndexCache() *searchIndexCache {
+	return &searchIndexCache{indexes: make(map[int]*searchIndex)}
+}
+
+// get returns the user's index if it was built at the version and is not too old
+func (c *searchIndexCache) get(userID int, version int64, now time.Time) *searchIndex {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index, ok := c.indexes[userID]
+	if !ok || index.version != version || now.Sub(index.builtAt) >= searchIndexTTL {
+		return nil
+	}
+	index.usedAt = now
+	return index
+}
+
+// put stores the user's index, making room by dropping the least recently used one
+func (c *searchIndexCache) put(userID int, index *searchIndex) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.indexes[userID]; !ok && len(c.indexes) >= maxSearchIndexes {
+		oldestID := 0
+		var oldest *searchIndex
+		for id, candidate := range c.indexes {
+			if oldest == nil || candidate.usedAt.Before(oldest.usedAt) {
+				oldestID, oldest = id, candidate
+			}
+		}
+		delete(c.indexes, oldestID)
+	}
+	c.indexes[userID] = index
+}
+
+// searchCursor is the sort key of the last item on a page. Usage scores decay
+// with time, so the cursor also keeps the time the first page was ranked at,
+// and later pages are ranked at that same time.
+type searchCursor struct {
+	Rank     int     `json:"r"`
+	Score    float64 `json:"s"`
+	Name     string  `json:"n"`
+	ID       int     `json:"i"`
+	RankedAt int64   `json:"t,omitempty"` // Unix seconds; not part of the sort order
+}
+
+// rankedResult is a search result with its sort key
+type rankedResult struct {
+	result *SearchResult
+	key    searchCursor
+}
+
+// SearchIngredients finds the user's ingredients matching a query. Matching is
+// case-, accent- and Cyrillic-variant-insensitive and falls back to typo-tolerant
+// matching. Results are ordered by match quality, then by usage.
+func (s *Service) SearchIngredients(req *SearchRequest) (*SearchPage, error) {
+	s.logger.Debug("SearchIngredients called", "user_id", req.UserID, "query", req.Query, "cursor", req.Cursor)
+
+	query := NormalizeName(req.Query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	var after *searchCursor
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		after = cursor
+	}
+
+	index, err := s.getSearchIndex(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Unix(time.Now().Unix(), 0)
+	if after != nil && after.RankedAt != 0 {
+		now = time.Unix(after.RankedAt, 0)
+	}
+	queryTokens := strings.Fields(query)
+
+	// Typo-tolerant matching is only a fallback when nothing matches directly
+	ranked := rankMatches(index.ingredients, query, queryTokens, false, now)
+	if len(ranked) == 0 && len([]rune(query)) >= minFuzzyQueryLength {
+		ranked = rankMatches(index.ingredients, query, queryTokens, true, now)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		return cursorLess(ranked[i].key, ranked[j].key)
+	})
+
+	page := paginate(ranked, after, normalizeLimit(req.Limit), now)
+
+	s.logger.Debug("SearchIngredients completed successfully", "user_id", req.UserID, "matches", len(ranked), "returned", len(page.Items))
+	return page, nil
+}
+
+// GetRecentIngredients returns the user's most frequently and recently used ingredients
+func (s *Service) GetRecentIngredients(userID, limit int) ([]*SearchResult, error) {
+	s.logger.Debug("GetRecentIngredients called", "user_id", userID, "limit", limit)
+
+	index, err := s.getSearchIndex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var ranked []rankedResult
+	for _, ing := range index.ingredients {
+		if ing.stats.count == 0 {
+			continue
+		}
+		ranked = append(ranked, rankedResult{
+			result: newSearchResult(ing.ingredient, ing.stats, ""),
+			key:    searchCursor{Score: ing.stats.score(now), Name: ing.name, ID: ing.ingredient.ID},
+		})
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		return cursorLess(ranked[i].key, ranked[j].key)
+	})
+
+	page := paginate(ranked, nil, normalizeLimit(limit), now)
+
+	s.logger.Debug("GetRecentIngredients completed successfully", "user_id", userID, "count", len(page.Items))
+	return page.Items, nil
+}
+
+// getSearchIndex returns the user's search index, building it again when the
+// user's data changed since it was built
+func (s *Service) getSearchIndex(userID int) (*searchIndex, error) {
+	// Read first: a write after it makes the next search build the index again
+	version, err := s.ingredientRepo.GetSyncVersion(userID)
+	if err != nil {
+		s.logger.Error("Failed to get sync version", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	now := time.Now()
+	if index := s.searchIndexes.get(userID, version, now); index != nil {
+		return index, nil
+	}
+
+	ingredients, err := s.ingredientRepo.GetAllUserIngredients(userID)
+	if err != nil {
+		s.logger.Error("Failed to get user ingredients", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	usage, err := s.getUsageStats(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &searchIndex{version: version, builtAt: now, usedAt: now, ingredients: make([]indexedIngredient, 0, len(ingredients))}
+	for _, ing := range ingredients {
+		name := NormalizeName(ing.Name)
+		index.ingredients = append(index.ingredients, indexedIngredient{ingredient: ing, name: name, stats: usage[name]})
+	}
+	s.searchIndexes.put(userID, index)
+
+	s.logger.Debug("Search index built", "user_id", userID, "version", version, "ingredients", len(ingredients))
+	return index, nil
+}
+
+// getUsageStats aggregates the user's recent calorie entries by normalized food name
+func (s *Service) getUsageStats(userID int) (map[string]usageStats, error) {
+	// Include tomorrow so entries logged in timezones ahead of the server are counted
+	now := time.Now()
+	dateTo := now.AddDate(0, 0, 1).Format("2006-01-02")
+	dateFrom := now.AddDate(0, 0, -usageWindowDays).Format("2006-01-02")
+
+	foods, err := s.calorieRepo.GetFoodUsage(userID, dateFrom, dateTo)
+	if err != nil {
+		s.logger.Error("Failed to get food usage", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	// Names that differ only in case or accents are the same food
+	usage := make(map[string]usageStats)
+	for _, food := range foods {
+		name := NormalizeName(food.Food)
+		stats := usage[name]
+		stats.count += food.Count
+		if food.LastUsedAt.After(stats.lastUsed) {
+			stats.lastUsed = food.LastUsedAt
+		}
+		usage[name] = stats
+	}
+
+	return usage, nil
+}
+
+// rankMatches collects the ingredients matching the query together with their sort keys
+func rankMatches(ingredients []indexedIngredient, query string, queryTokens []string, fuzzy bool, now time.Time) []rankedResult {
+	var ranked []rankedResult
+	for _, ing := range ingredients {
+		match, ok := matchName(ing.name, query, queryTokens, fuzzy)
+		if !ok {
+			continue
+		}
+
+		ranked = append(ranked, rankedResult{
+			result: newSearchResult(ing.ingredient, ing.stats, match),
+			key: searchCursor{
+				Rank:  matchRank[match],
+				Score: ing.stats.score(now),
+				Name:  ing.name,
+				ID:    ing.ingredient.ID,
+			},
+		})
+	}
+	return ranked
+}
+
+// matchName reports whether a normalized ingredient name matches the normalized query
+func matchName(name, query string, queryTokens []string, fuzzy bool) (MatchType, bool) {
+	switch {
+	case name == query:
+		return MatchExact, true
+	case strings.HasPrefix(name, query):
+		return MatchPrefix, true
+	}
+
+	nameTokens := strings.Fields(name)
+	if allTokensMatch(queryTokens, nameTokens, func(q, w string) bool { return strings.HasPrefix(w, q) }) {
+		return MatchWordPrefix, true
+	}
+
+	if strings.Contains(name, query) {
+		return MatchSubstring, true
+	}
+
+	if fuzzy && allTokensMatch(queryTokens, nameTokens, fuzzyTokenMatch) {
+		return MatchFuzzy, true
+	}
+
+	return "", false
+}
+
+// allTokensMatch reports whether every query token matches at least one name token
+func allTokensMatch(queryTokens, nameTokens []string, match func(q, w string) bool) bool {
+	for _, q := range queryTokens {
+		found := false
+		for _, w := range nameTokens {
+			if match(q, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return len(queryTokens) > 0
+}
+
+// fuzzyTokenMatch compares a query token with a word and with the word's prefix of
+// the same length, so partially typed words with typos still match
+func fuzzyTokenMatch(q, w string) bool {
+	qr, wr := []rune(q), []rune(w)
+
+	maxEdits := longTokenMaxEdits
+	if len(qr) <= shortTokenLengthEdge {
+		maxEdits = shortTokenMaxEdits
+	}
+
+	if levenshtein(qr, wr, maxEdits) <= maxEdits {
+		return true
+	}
+	if len(wr) > len(qr) {
+		return levenshtein(qr, wr[:len(qr)], maxEdits) <= maxEdits
+	}
+	return false
+}
+
+// cursorLess orders results: better match first, then higher usage score, then name and ID
+func cursorLess(a, b searchCursor) bool {
+	if a.Rank != b.Rank {
+		return a.Rank < b.Rank
+	}
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.ID < b.ID
+}
+
+// paginate returns up to limit results that sort after the cursor. The next
+// cursor remembers rankedAt, the time the results were scored at.
+func paginate(ranked []rankedResult, after *searchCursor, limit int, rankedAt time.Time) *SearchPage {
+	start := 0
+	if after != nil {
+		start = sort.Search(len(ranked), func(i int) bool {
+			return cursorLess(*after, ranked[i].key)
+		})
+	}
+
+	end := min(start+limit, len(ranked))
+	page := &SearchPage{Items: make([]*SearchResult, 0, end-start)}
+	for _, r := range ranked[start:end] {
+		page.Items = append(page.Items, r.result)
+	}
+
+	if end < len(ranked) {
+		next := ranked[end-1].key
+		next.RankedAt = rankedAt.Unix()
+		page.NextCursor = encodeCursor(next)
+	}
+
+	return page
+}
+
+// normalizeLimit applies the default and maximum page size
+func normalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultSearchLimit
+	}
+	return min(limit, MaxSearchLimit)
+}
+
+// newSearchResult wraps an ingredient with its usage statistics
+func newSearchResult(ing *models.UserIngredient, stats usageStats, match MatchType) *SearchResult {
+	result := &SearchResult{
+		UserIngredient: ing,
+		UseCount:       stats.count,
+		Match:          match,
+	}
+	if stats.count > 0 {
+		lastUsed := stats.lastUsed
+		result.LastUsedAt = &lastUsed
+	}
+	return result
+}
+
+// encodeCursor serializes a sort key into an opaque cursor string
+func encodeCursor(c searchCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor
+func decodeCursor(cursor string) (*searchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var c searchCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
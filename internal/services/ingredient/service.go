@@ -1,6 +1,7 @@
 package ingredient
 
 import (
+	"fmt"
 	"log/slog"
 
 	"ypeskov/kkal-tracker/internal/models"
@@ -9,12 +10,16 @@ import (
 
 type Service struct {
 	ingredientRepo repositories.IngredientRepository
+	calorieRepo    repositories.CalorieEntryRepository
+	searchIndexes  *searchIndexCache
 	logger         *slog.Logger
 }
 
-func New(ingredientRepo repositories.IngredientRepository, logger *slog.Logger) *Service {
+func New(ingredientRepo repositories.IngredientRepository, calorieRepo repositories.CalorieEntryRepository, logger *slog.Logger) *Service {
 	return &Service{
 		ingredientRepo: ingredientRepo,
+		calorieRepo:    calorieRepo,
+		searchIndexes:  newSearchIndexCache(),
 		logger:         logger.With("service", "ingredient"),
 	}
 }
@@ -53,14 +58,28 @@ func (s *Service) CreateIngredient(req *CreateIngredientRequest) (*models.UserIn
 		return nil, err
 	}
 
+	taken, err := s.barcodeTaken(req.UserID, 0, req.Barcode)
+	if err != nil {
+		s.logger.Error("Failed to check barcode", "error", err, "user_id", req.UserID)
+		return nil, err
+	}
+	if taken {
+		return nil, ErrBarcodeTaken
+	}
+
 	ingredient, err := s.ingredientRepo.CreateUserIngredient(
-		req.UserID, req.Name, req.KcalPer100g, req.Fats, req.Carbs, req.Proteins,
+		req.UserID, req.Name, req.KcalPer100g, req.Fats, req.Carbs, req.Proteins, req.Nutrients.Complete(),
 	)
 	if err != nil {
 		s.logger.Error("Failed to create user ingredient", "error", err, "user_id", req.UserID, "name", req.Name)
 		return nil, err
 	}
 
+	if err := s.setBarcode(ingredient, req.Barcode); err != nil {
+		s.logger.Error("Failed to set ingredient barcode", "error", err, "user_id", req.UserID, "ingredient_id", ingredient.ID)
+		return nil, err
+	}
+
 	s.logger.Debug("CreateIngredient completed successfully", "user_id", req.UserID, "ingredient_id", ingredient.ID, "name", req.Name)
 	return ingredient, nil
 }
@@ -73,14 +92,39 @@ func (s *Service) UpdateIngredient(req *UpdateIngredientRequest) (*models.UserIn
 		return nil, err
 	}
 
+	taken, err := s.barcodeTaken(req.UserID, req.IngredientID, req.Barcode)
+	if err != nil {
+		s.logger.Error("Failed to check barcode", "error", err, "user_id", req.UserID)
+		return nil, err
+	}
+	if taken {
+		return nil, ErrBarcodeTaken
+	}
+
+	// Clients that do not send extended nutrients keep the stored ones
+	nutrients := req.Nutrients
+	if nutrients == nil {
+		current, err := s.ingredientRepo.GetUserIngredientByID(req.UserID, req.IngredientID)
+		if err != nil {
+			s.logger.Debug("UpdateIngredient failed - ingredient not found", "user_id", req.UserID, "ingredient_id", req.IngredientID, "error", err)
+			return nil, err
+		}
+		nutrients = current.Nutrients
+	}
+
 	ingredient, err := s.ingredientRepo.UpdateUserIngredient(
-		req.UserID, req.IngredientID, req.Name, req.KcalPer100g, req.Fats, req.Carbs, req.Proteins,
+		req.UserID, req.IngredientID, req.Name, req.KcalPer100g, req.Fats, req.Carbs, req.Proteins, nutrients.Complete(),
 	)
 	if err != nil {
 		s.logger.Error("Failed to update user ingredient", "error", err, "user_id", req.UserID, "ingredient_id", req.IngredientID)
 		return nil, err
 	}
 
+	if err := s.setBarcode(ingredient, req.Barcode); err != nil {
+		s.logger.Error("Failed to set ingredient barcode", "error", err, "user_id", req.UserID, "ingredient_id", req.IngredientID)
+		return nil, err
+	}
+
 	s.logger.Debug("UpdateIngredient completed successfully", "user_id", req.UserID, "ingredient_id", req.IngredientID, "name", req.Name)
 	return ingredient, nil
 }
@@ -114,7 +158,10 @@ func (s *Service) validateCreateRequest(req *CreateIngredientRequest) error {
 	if req.Proteins != nil && *req.Proteins < 0 {
 		return ErrInvalidNutritionValue
 	}
-	return nil
+	if err := normalizeBarcodeInput(req.Barcode); err != nil {
+		return err
+	}
+	return validateNutrients(req.Nutrients)
 }
 
 func (s *Service) validateUpdateRequest(req *UpdateIngredientRequest) error {
@@ -136,5 +183,16 @@ func (s *Service) validateUpdateRequest(req *UpdateIngredientRequest) error {
 	if req.Proteins != nil && *req.Proteins < 0 {
 		return ErrInvalidNutritionValue
 	}
+	if err := normalizeBarcodeInput(req.Barcode); err != nil {
+		return err
+	}
+	return validateNutrients(req.Nutrients)
+}
+
+// validateNutrients checks extended nutrient values, wrapping the failure in ErrInvalidNutrients
+func validateNutrients(nutrients models.Nutrients) error {
+	if err := nutrients.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidNutrients, err)
+	}
 	return nil
 }
@@ -0,0 +1,428 @@
+package ingredient
+
+import (
+	"database/sql"
+	"errors"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// syncPlan is the outcome of reconciling a user copy with its global ingredient
+type syncPlan struct {
+	link     models.LinkedUserIngredient // Copy state after the automatic changes
+	updated  bool
+	renamed  bool
+	conflict bool
+	pending  []FieldChange // Differences kept because the user customized them
+}
+
+// SyncWithCatalog brings the user's copies of global ingredients up to date. Fields the
+// user never changed follow the catalog; customized fields are left as pending updates.
+// Names follow the user's current language when the catalog has a translation.
+func (s *Service) SyncWithCatalog(userID int) (*SyncReport, error) {
+	s.logger.Debug("SyncWithCatalog called", "user_id", userID)
+
+	links, err := s.ingredientRepo.GetLinkedUserIngredients(userID)
+	if err != nil {
+		s.logger.Error("Failed to get linked user ingredients", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	globals, err := s.getGlobalIngredients()
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.syncLinks(links, globals)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug("SyncWithCatalog completed successfully", "user_id", userID, "updated", report.Updated, "renamed", report.Renamed, "pending", report.Pending)
+	return report, nil
+}
+
+// PropagateGlobalIngredient pushes the current state of a global ingredient to all user copies
+func (s *Service) PropagateGlobalIngredient(globalID int) (*SyncReport, error) {
+	s.logger.Debug("PropagateGlobalIngredient called", "global_ingredient_id", globalID)
+
+	global, err := s.ingredientRepo.GetGlobalIngredientByID(globalID)
+	if err != nil {
+		s.logger.Error("Failed to get global ingredient", "error", err, "global_ingredient_id", globalID)
+		return nil, err
+	}
+
+	links, err := s.ingredientRepo.GetLinkedUserIngredientsByGlobalID(globalID)
+	if err != nil {
+		s.logger.Error("Failed to get linked user ingredients", "error", err, "global_ingredient_id", globalID)
+		return nil, err
+	}
+
+	report, err := s.syncLinks(links, map[int]*models.GlobalIngredient{globalID: global})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Global ingredient propagated", "global_ingredient_id", globalID, "copies", report.Checked, "updated", report.Updated, "renamed", report.Renamed, "pending", report.Pending)
+	return report, nil
+}
+
+// GetPendingUpdates lists catalog updates the user has not reviewed yet
+func (s *Service) GetPendingUpdates(userID int) ([]*PendingUpdate, error) {
+	s.logger.Debug("GetPendingUpdates called", "user_id", userID)
+
+	links, err := s.ingredientRepo.GetLinkedUserIngredients(userID)
+	if err != nil {
+		s.logger.Error("Failed to get linked user ingredients", "error", err, "user_id", userID)
+		return nil, err
+	}
+
+	globals, err := s.getGlobalIngredients()
+	if err != nil {
+		return nil, err
+	}
+
+	updates := []*PendingUpdate{}
+	for _, link := range links {
+		global, ok := globals[link.GlobalIngredientID]
+		if !ok || link.GlobalVersion >= global.Version {
+			continue
+		}
+		changes := diffValues(link.Values, targetValues(link, global))
+		if len(changes) == 0 {
+			continue
+		}
+		updates = append(updates, &PendingUpdate{
+			IngredientID:       link.ID,
+			GlobalIngredientID: global.ID,
+			GlobalVersion:      global.Version,
+			Name:               link.Values.Name,
+			Changes:            changes,
+		})
+	}
+
+	s.logger.Debug("GetPendingUpdates completed successfully", "user_id", userID, "count", len(updates))
+	return updates, nil
+}
+
+// ApplyPendingUpdate overwrites a customized ingredient with the current catalog values
+func (s *Service) ApplyPendingUpdate(userID, ingredientID int) (*models.UserIngredient, error) {
+	s.logger.Debug("ApplyPendingUpdate called", "user_id", userID, "ingredient_id", ingredientID)
+
+	link, global, err := s.getPendingLink(userID, ingredientID)
+	if err != nil {
+		return nil, err
+	}
+
+	target := targetValues(link, global)
+	if target.Name != link.Values.Name {
+		taken, err := s.nameTaken(userID, target.Name, link.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrNameConflict
+		}
+	}
+
+	link.Values = target
+	link.Synced = &target
+	link.GlobalVersion = global.Version
+	if err := s.ingredientRepo.UpdateLinkedUserIngredient(link); err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			return nil, ErrIngredientChanged
+		}
+		s.logger.Error("Failed to apply catalog update", "error", err, "user_id", userID, "ingredient_id", ingredientID)
+		return nil, err
+	}
+
+	s.logger.Debug("ApplyPendingUpdate completed successfully", "user_id", userID, "ingredient_id", ingredientID, "global_version", global.Version)
+	return s.ingredientRepo.GetUserIngredientByID(userID, ingredientID)
+}
+
+// DismissPendingUpdate keeps the user's customized values and hides the update
+// until the global ingredient changes again
+func (s *Service) DismissPendingUpdate(userID, ingredientID int) error {
+	s.logger.Debug("DismissPendingUpdate called", "user_id", userID, "ingredient_id", ingredientID)
+
+	link, global, err := s.getPendingLink(userID, ingredientID)
+	if err != nil {
+		return err
+	}
+
+	link.GlobalVersion = global.Version
+	if err := s.ingredientRepo.UpdateLinkedUserIngredient(link); err != nil {
+		if errors.Is(err, repositories.ErrVersionConflict) {
+			return ErrIngredientChanged
+		}
+		s.logger.Error("Failed to dismiss catalog update", "error", err, "user_id", userID, "ingredient_id", ingredientID)
+		return err
+	}
+
+	s.logger.Debug("DismissPendingUpdate completed successfully", "user_id", userID, "ingredient_id", ingredientID, "global_version", global.Version)
+	return nil
+}
+
+// syncLinks reconciles user copies with their global ingredients and stores the changes
+func (s *Service) syncLinks(links []*models.LinkedUserIngredient, globals map[int]*models.GlobalIngredient) (*SyncReport, error) {
+	report := &SyncReport{}
+
+	for _, link := range links {
+		global, ok := globals[link.GlobalIngredientID]
+		if !ok {
+			continue
+		}
+		report.Checked++
+
+		plan := planSync(link, global)
+		if plan.renamed {
+			taken, err := s.nameTaken(link.UserID, plan.link.Values.Name, link.ID)
+			if err != nil {
+				return nil, err
+			}
+			if taken {
+				plan.link.Values.Name = link.Values.Name
+				plan.link.Synced.Name = link.Values.Name
+				plan.renamed = false
+				plan.conflict = true
+			}
+		}
+
+		if linkChanged(link, &plan.link) {
+			err := s.ingredientRepo.UpdateLinkedUserIngredient(&plan.link)
+			if errors.Is(err, repositories.ErrVersionConflict) {
+				// The user changed the copy after it was read; the next sync plans it again
+				s.logger.Info("User ingredient changed during sync, skipped", "user_id", link.UserID, "ingredient_id", link.ID)
+				continue
+			}
+			if err != nil {
+				s.logger.Error("Failed to sync user ingredient", "error", err, "user_id", link.UserID, "ingredient_id", link.ID)
+				return nil, err
+			}
+		}
+
+		if plan.updated {
+			report.Updated++
+		}
+		if plan.renamed {
+			report.Renamed++
+		}
+		if plan.conflict {
+			report.Conflicts++
+		}
+		if len(plan.pending) > 0 && plan.link.GlobalVersion < global.Version {
+			report.Pending++
+		}
+	}
+
+	return report, nil
+}
+
+// planSync decides which fields of a user copy follow the catalog. A field is updated
+// only while it still equals the value it was last synced to.
+func planSync(link *models.LinkedUserIngredient, global *models.GlobalIngredient) syncPlan {
+	target := targetValues(link, global)
+	plan := syncPlan{link: *link}
+
+	// Without a baseline every field is treated as customized
+	hasBaseline := link.Synced != nil
+	synced := link.Values
+	if hasBaseline {
+		synced = *link.Synced
+	}
+
+	for _, field := range nutrientFields {
+		current, want := field.get(link.Values), field.get(target)
+		switch {
+		case sameValue(current, want):
+			field.set(&synced, want)
+		case hasBaseline && sameValue(current, field.get(synced)):
+			field.set(&plan.link.Values, want)
+			field.set(&synced, want)
+			plan.updated = true
+		default:
+			plan.pending = append(plan.pending, FieldChange{Field: field.name, Current: current, Global: want})
+		}
+	}
+
+	switch {
+	case link.Values.Name == target.Name:
+		synced.Name = target.Name
+	case hasBaseline && link.Values.Name == synced.Name:
+		plan.link.Values.Name = target.Name
+		synced.Name = target.Name
+		plan.renamed = true
+	default:
+		plan.pending = append(plan.pending, FieldChange{Field: "name", Current: link.Values.Name, Global: target.Name})
+	}
+
+	plan.link.Synced = &synced
+	if len(plan.pending) == 0 {
+		plan.link.GlobalVersion = global.Version
+	}
+
+	return plan
+}
+
+// linkChanged reports whether a sync modified anything that needs to be stored
+func linkChanged(before, after *models.LinkedUserIngredient) bool {
+	if before.GlobalVersion != after.GlobalVersion || !sameValues(before.Values, after.Values) {
+		return true
+	}
+	if before.Synced == nil || after.Synced == nil {
+		return before.Synced != after.Synced
+	}
+	return !sameValues(*before.Synced, *after.Synced)
+}
+
+// targetValues returns the catalog values for a user copy, named in the user's language.
+// Without a translation the copy keeps its current name.
+func targetValues(link *models.LinkedUserIngredient, global *models.GlobalIngredient) models.IngredientValues {
+	name, ok := global.Names[link.Language]
+	if !ok || name == "" {
+		name = link.Values.Name
+	}
+	return models.IngredientValues{
+		Name:        name,
+		KcalPer100g: global.KcalPer100g,
+		Fats:        global.Fats,
+		Carbs:       global.Carbs,
+		Proteins:    global.Proteins,
+		Nutrients:   global.Nutrients,
+	}
+}
+
+// diffValues lists the fields where a user copy differs from the catalog
+func diffValues(current, target models.IngredientValues) []FieldChange {
+	var changes []FieldChange
+	if current.Name != target.Name {
+		changes = append(changes, FieldChange{Field: "name", Current: current.Name, Global: target.Name})
+	}
+	return append(changes, diffNutrition(current, target)...)
+}
+
+func diffNutrition(current, target models.IngredientValues) []FieldChange {
+	var changes []FieldChange
+	for _, field := range nutrientFields {
+		if !sameValue(field.get(current), field.get(target)) {
+			changes = append(changes, FieldChange{Field: field.name, Current: field.get(current), Global: field.get(target)})
+		}
+	}
+	return changes
+}
+
+func sameValues(a, b models.IngredientValues) bool {
+	return a.Name == b.Name && len(diffNutrition(a, b)) == 0
+}
+
+// nutrientField gives uniform access to a nutrition value kept in sync
+type nutrientField struct {
+	name string
+	get  func(v models.IngredientValues) *float64
+	set  func(v *models.IngredientValues, value *float64)
+}
+
+// nutrientFields lists the macros followed by every nutrient in the catalog
+var nutrientFields = append([]nutrientField{
+	{
+		name: "kcalPer100g",
+		get:  func(v models.IngredientValues) *float64 { return &v.KcalPer100g },
+		set:  func(v *models.IngredientValues, value *float64) { v.KcalPer100g = *value },
+	},
+	{
+		name: "fats",
+		get:  func(v models.IngredientValues) *float64 { return v.Fats },
+		set:  func(v *models.IngredientValues, value *float64) { v.Fats = value },
+	},
+	{
+		name: "carbs",
+		get:  func(v models.IngredientValues) *float64 { return v.Carbs },
+		set:  func(v *models.IngredientValues, value *float64) { v.Carbs = value },
+	},
+	{
+		name: "proteins",
+		get:  func(v models.IngredientValues) *float64 { return v.Proteins },
+		set:  func(v *models.IngredientValues, value *float64) { v.Proteins = value },
+	},
+}, extendedNutrientFields()...)
+
+// extendedNutrientFields builds accessors for the extended nutrients. Setters copy the
+// map so values and the sync baseline never share it.
+func extendedNutrientFields() []nutrientField {
+	fields := make([]nutrientField, 0, len(models.NutrientCatalog))
+	for _, info := range models.NutrientCatalog {
+		code := info.Code
+		fields = append(fields, nutrientField{
+			name: code,
+			get:  func(v models.IngredientValues) *float64 { return v.Nutrients.Get(code) },
+			set:  func(v *models.IngredientValues, value *float64) { v.Nutrients = v.Nutrients.With(code, value) },
+		})
+	}
+	return fields
+}
+
+func sameValue(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// getPendingLink finds a user copy with an unreviewed catalog update
+func (s *Service) getPendingLink(userID, ingredientID int) (*models.LinkedUserIngredient, *models.GlobalIngredient, error) {
+	links, err := s.ingredientRepo.GetLinkedUserIngredients(userID)
+	if err != nil {
+		s.logger.Error("Failed to get linked user ingredients", "error", err, "user_id", userID)
+		return nil, nil, err
+	}
+
+	for _, link := range links {
+		if link.ID != ingredientID {
+			continue
+		}
+
+		global, err := s.ingredientRepo.GetGlobalIngredientByID(link.GlobalIngredientID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, nil, ErrNoPendingUpdate
+			}
+			return nil, nil, err
+		}
+
+		if link.GlobalVersion >= global.Version || len(diffValues(link.Values, targetValues(link, global))) == 0 {
+			return nil, nil, ErrNoPendingUpdate
+		}
+		return link, global, nil
+	}
+
+	return nil, nil, ErrNoPendingUpdate
+}
+
+// getGlobalIngredients returns the global catalog keyed by ID
+func (s *Service) getGlobalIngredients() (map[int]*models.GlobalIngredient, error) {
+	ingredients, err := s.ingredientRepo.GetAllGlobalIngredients()
+	if err != nil {
+		s.logger.Error("Failed to get global ingredients", "error", err)
+		return nil, err
+	}
+
+	globals := make(map[int]*models.GlobalIngredient, len(ingredients))
+	for _, ingredient := range ingredients {
+		globals[ingredient.ID] = ingredient
+	}
+	return globals, nil
+}
+
+// nameTaken reports whether another ingredient of the user already has the name
+func (s *Service) nameTaken(userID int, name string, exceptID int) (bool, error) {
+	existing, err := s.ingredientRepo.GetUserIngredientByName(userID, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		s.logger.Error("Failed to check ingredient name", "error", err, "user_id", userID)
+		return false, err
+	}
+	return existing.ID != exceptID, nil
+}
@@ -0,0 +1,181 @@
+package ingredient
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// Serving limits
+const (
+	MaxServings          = 20
+	MaxServingNameLength = 50
+	MaxServingGrams      = 10000.0
+	MaxDensityGPerML     = 5.0
+)
+
+// unit is a standard unit with its size in grams or milliliters
+type unit struct {
+	name string
+	size float64
+}
+
+// massUnits convert to grams directly, in the order they are offered
+var massUnits = []unit{
+	{"g", 1},
+	{"kg", 1000},
+	{"oz", 28.349523125},
+	{"lb", 453.59237},
+}
+
+// volumeUnits convert to milliliters and need the ingredient density to get grams
+var volumeUnits = []unit{
+	{"ml", 1},
+	{"l", 1000},
+	{"tsp", 5},
+	{"tbsp", 15},
+	{"cup", 240},
+	{"fl_oz", 29.5735295625},
+}
+
+// ToGrams converts a quantity to grams and returns it with the canonical unit name,
+// i.e. the serving name as the user defined it or the standard unit. The unit is matched case-insensitively against
+// the ingredient's named servings first, so a serving called "cup" overrides the
+// standard cup, then against mass units, then volume units using the density.
+// units may be nil for foods without an ingredient; only mass units work then.
+func ToGrams(units *models.IngredientUnits, quantity float64, unitName string) (float64, string, error) {
+	if quantity <= 0 || math.IsNaN(quantity) || math.IsInf(quantity, 0) {
+		return 0, "", ErrInvalidQuantity
+	}
+	name := strings.ToLower(strings.TrimSpace(unitName))
+
+	if units != nil {
+		for _, serving := range units.Servings {
+			if strings.EqualFold(serving.Name, name) {
+				return quantity * serving.Grams, serving.Name, nil
+			}
+		}
+	}
+
+	if size, ok := findUnit(massUnits, name); ok {
+		return quantity * size, name, nil
+	}
+
+	if size, ok := findUnit(volumeUnits, name); ok {
+		if units == nil || units.DensityGPerML == nil {
+			return 0, "", ErrDensityRequired
+		}
+		return quantity * size * *units.DensityGPerML, name, nil
+	}
+
+	return 0, "", ErrUnknownUnit
+}
+
+// GetIngredientUnits returns the servings and density of an ingredient
+func (s *Service) GetIngredientUnits(userID, ingredientID int) (*UnitsResponse, error) {
+	s.logger.Debug("GetIngredientUnits called", "user_id", userID, "ingredient_id", ingredientID)
+
+	units, err := s.ingredientRepo.GetIngredientUnits(userID, ingredientID)
+	if err != nil {
+		s.logger.Error("Failed to get ingredient units", "error", err, "user_id", userID, "ingredient_id", ingredientID)
+		return nil, err
+	}
+
+	return newUnitsResponse(units), nil
+}
+
+// SetIngredientUnits replaces the servings and density of an ingredient
+func (s *Service) SetIngredientUnits(req *SetUnitsRequest) (*UnitsResponse, error) {
+	s.logger.Debug("SetIngredientUnits called", "user_id", req.UserID, "ingredient_id", req.IngredientID, "servings", len(req.Servings))
+
+	units, err := validateUnits(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ingredientRepo.SetIngredientUnits(req.UserID, units); err != nil {
+		s.logger.Error("Failed to set ingredient units", "error", err, "user_id", req.UserID, "ingredient_id", req.IngredientID)
+		return nil, err
+	}
+
+	s.logger.Debug("SetIngredientUnits completed successfully", "user_id", req.UserID, "ingredient_id", req.IngredientID)
+	return s.GetIngredientUnits(req.UserID, req.IngredientID)
+}
+
+func validateUnits(req *SetUnitsRequest) (*models.IngredientUnits, error) {
+	if req.IngredientID <= 0 {
+		return nil, ErrInvalidIngredientID
+	}
+	if req.DensityGPerML != nil && (*req.DensityGPerML <= 0 || *req.DensityGPerML > MaxDensityGPerML) {
+		return nil, ErrInvalidDensity
+	}
+	if len(req.Servings) > MaxServings {
+		return nil, fmt.Errorf("%w: at most %d servings are allowed", ErrInvalidServing, MaxServings)
+	}
+
+	units := &models.IngredientUnits{
+		IngredientID:  req.IngredientID,
+		DensityGPerML: req.DensityGPerML,
+		Servings:      make([]*models.IngredientServing, 0, len(req.Servings)),
+	}
+	seen := make(map[string]bool, len(req.Servings))
+	for _, input := range req.Servings {
+		name := strings.TrimSpace(input.Name)
+		key := strings.ToLower(name)
+
+		switch {
+		case name == "" || len([]rune(name)) > MaxServingNameLength:
+			return nil, fmt.Errorf("%w: name must be 1-%d characters", ErrInvalidServing, MaxServingNameLength)
+		case input.Grams <= 0 || input.Grams > MaxServingGrams:
+			return nil, fmt.Errorf("%w: %q must weigh more than 0 and at most %g g", ErrInvalidServing, name, MaxServingGrams)
+		case seen[key]:
+			return nil, fmt.Errorf("%w: %q is listed twice", ErrInvalidServing, name)
+		}
+		if _, ok := findUnit(massUnits, key); ok {
+			return nil, fmt.Errorf("%w: %q is a standard mass unit", ErrInvalidServing, name)
+		}
+
+		seen[key] = true
+		units.Servings = append(units.Servings, &models.IngredientServing{
+			IngredientID: req.IngredientID,
+			Name:         name,
+			Grams:        input.Grams,
+		})
+	}
+
+	return units, nil
+}
+
+// newUnitsResponse lists the units a quantity of the ingredient can be logged in:
+// named servings, mass units, and volume units when the density is known
+func newUnitsResponse(units *models.IngredientUnits) *UnitsResponse {
+	names := make([]string, 0, len(units.Servings)+len(massUnits)+len(volumeUnits))
+	taken := make(map[string]bool)
+	for _, serving := range units.Servings {
+		names = append(names, serving.Name)
+		taken[strings.ToLower(serving.Name)] = true
+	}
+	for _, u := range massUnits {
+		names = append(names, u.name)
+	}
+	if units.DensityGPerML != nil {
+		for _, u := range volumeUnits {
+			if !taken[u.name] {
+				names = append(names, u.name)
+			}
+		}
+	}
+
+	return &UnitsResponse{IngredientUnits: units, Units: names}
+}
+
+func findUnit(units []unit, name string) (float64, bool) {
+	for _, u := range units {
+		if u.name == name {
+			return u.size, true
+		}
+	}
+	return 0, false
+}
@@ -4,4 +4,5 @@ package metrics
 type Servicer interface {
 	GetHealthMetrics(userID int) (*HealthMetrics, error)
 	CalculateTDEE(userID int, activityLevel ActivityLevel) (*float64, error)
+	GetDailyTargets(userID int) (*DailyTargets, error)
 }
@@ -1,11 +1,25 @@
 package metrics
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
+	"time"
 
 	"ypeskov/kkal-tracker/internal/repositories"
+	"ypeskov/kkal-tracker/internal/services/profile"
+)
+
+// ErrInsufficientData is returned when the profile lacks the data needed for a calculation
+var ErrInsufficientData = errors.New("insufficient profile data")
+
+// Macronutrient split used for daily targets
+const (
+	proteinPerKgDeficit     = 1.8  // g/kg body weight while in a calorie deficit
+	proteinPerKgMaintenance = 1.6  // g/kg body weight at maintenance or surplus
+	fatCaloriesShare        = 0.25 // Share of daily calories coming from fat
+	goalToleranceKg         = 0.5  // Difference from target weight treated as "reached"
 )
 
 type Service struct {
@@ -162,3 +176,87 @@ func (s *Service) generateHealthStatus(metrics *HealthMetrics) string {
 		return "significantly_above_healthy_weight"
 	}
 }
+
+// GetDailyTargets calculates recommended daily calories and macronutrients.
+// Calories are TDEE adjusted towards the user's weight goal, never going below
+// the safe minimum for the user's gender.
+func (s *Service) GetDailyTargets(userID int) (*DailyTargets, error) {
+	s.logger.Debug("GetDailyTargets called", "user_id", userID)
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		s.logger.Error("Failed to get user", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	weights, err := s.weightRepo.GetByUserID(userID)
+	if err != nil {
+		s.logger.Error("Failed to get weight history", "user_id", userID, "error", err)
+		return nil, fmt.Errorf("failed to get weight history: %w", err)
+	}
+
+	if len(weights) == 0 || user.Age == nil || user.Height == nil || user.Gender == nil {
+		return nil, ErrInsufficientData
+	}
+	currentWeight := weights[0].Weight
+
+	bmr := s.calculateBMR(currentWeight, *user.Height, *user.Age, *user.Gender)
+	activityLevel := ActivitySedentary
+	if user.ActivityLevel != nil {
+		activityLevel = ActivityLevel(*user.ActivityLevel)
+	}
+	multiplier, ok := ActivityMultipliers[activityLevel]
+	if !ok {
+		multiplier = ActivityMultipliers[ActivitySedentary]
+	}
+	tdee := math.Round(bmr * multiplier)
+
+	// Daily adjustment towards the weight goal
+	var adjustment float64
+	if user.TargetWeight != nil {
+		weightToGo := *user.TargetWeight - currentWeight
+		if math.Abs(weightToGo) >= goalToleranceKg {
+			safeDaily := profile.SafeWeeklyLossKg * profile.KcalPerKg / 7
+			adjustment = math.Copysign(safeDaily, weightToGo)
+
+			if user.TargetDate != nil {
+				daysRemaining := math.Ceil(time.Until(*user.TargetDate).Hours() / 24)
+				if daysRemaining > 0 {
+					adjustment = weightToGo * profile.KcalPerKg / daysRemaining
+				}
+			}
+
+			adjustment = math.Max(-profile.MaxDailyDeficit, math.Min(profile.MaxDailyDeficit, adjustment))
+		}
+	}
+
+	minCalories := profile.MinDailyCaloriesFemale
+	if *user.Gender == "male" {
+		minCalories = profile.MinDailyCaloriesMale
+	}
+	calories := math.Round(tdee + adjustment)
+	if calories < minCalories {
+		calories = minCalories
+	}
+	adjustment = calories - tdee
+
+	proteinPerKg := proteinPerKgMaintenance
+	if adjustment < 0 {
+		proteinPerKg = proteinPerKgDeficit
+	}
+	proteins := math.Round(currentWeight * proteinPerKg)
+	fats := math.Round(calories * fatCaloriesShare / 9)
+	carbs := math.Max(0, math.Round((calories-proteins*4-fats*9)/4))
+
+	targets := &DailyTargets{
+		Calories:   calories,
+		Proteins:   proteins,
+		Fats:       fats,
+		Carbs:      carbs,
+		TDEE:       tdee,
+		Adjustment: adjustment,
+	}
+
+	s.logger.Debug("GetDailyTargets completed successfully", "user_id", userID, "calories", calories)
+	return targets, nil
+}
@@ -29,3 +29,14 @@ var ActivityMultipliers = map[ActivityLevel]float64{
 	ActivityVeryActive:    1.725,
 	ActivityExtraActive:   1.9,
 }
+
+// DailyTargets represents recommended daily calorie and macronutrient intake
+// derived from TDEE and the user's weight goal
+type DailyTargets struct {
+	Calories   float64 `json:"calories"`   // Recommended daily intake (kcal)
+	Proteins   float64 `json:"proteins"`   // Grams per day
+	Fats       float64 `json:"fats"`       // Grams per day
+	Carbs      float64 `json:"carbs"`      // Grams per day
+	TDEE       float64 `json:"tdee"`       // Maintenance calories the targets are based on
+	Adjustment float64 `json:"adjustment"` // Daily kcal adjustment for the weight goal (negative = deficit)
+}
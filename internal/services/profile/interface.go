@@ -1,9 +1,11 @@
 package profile
 
+import ingredientservice "ypeskov/kkal-tracker/internal/services/ingredient"
+
 // Servicer defines the profile service contract used by handlers.
 type Servicer interface {
 	GetProfile(userID int) (*ProfileResponse, error)
-	UpdateProfile(userID int, req *ProfileUpdateRequest) error
+	UpdateProfile(userID int, req *ProfileUpdateRequest) (*ingredientservice.RelocalizeReport, error)
 	SetWeightGoal(userID int, req *WeightGoalRequest) error
 	ClearWeightGoal(userID int) error
 	GetWeightGoalProgress(userID int) (*WeightGoalResponse, error)
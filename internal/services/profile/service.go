@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"ypeskov/kkal-tracker/internal/repositories"
+	ingredientservice "ypeskov/kkal-tracker/internal/services/ingredient"
 )
 
 // Constants for weight goal calculations
@@ -27,18 +28,20 @@ var (
 )
 
 type Service struct {
-	db             *sql.DB
-	userRepo       repositories.UserRepository
-	weightHistRepo repositories.WeightHistoryRepository
-	logger         *slog.Logger
+	db                *sql.DB
+	userRepo          repositories.UserRepository
+	weightHistRepo    repositories.WeightHistoryRepository
+	ingredientService ingredientservice.Servicer
+	logger            *slog.Logger
 }
 
-func New(db *sql.DB, userRepo repositories.UserRepository, weightHistRepo repositories.WeightHistoryRepository, logger *slog.Logger) *Service {
+func New(db *sql.DB, userRepo repositories.UserRepository, weightHistRepo repositories.WeightHistoryRepository, ingredientService ingredientservice.Servicer, logger *slog.Logger) *Service {
 	return &Service{
-		db:             db,
-		userRepo:       userRepo,
-		weightHistRepo: weightHistRepo,
-		logger:         logger.With("service", "profile"),
+		db:                db,
+		userRepo:          userRepo,
+		weightHistRepo:    weightHistRepo,
+		ingredientService: ingredientService,
+		logger:            logger.With("service", "profile"),
 	}
 }
 
@@ -79,6 +82,7 @@ func (s *Service) GetProfile(userID int) (*ProfileResponse, error) {
 		Gender:              user.Gender,
 		Language:            language,
 		ActivityLevel:       user.ActivityLevel,
+		Version:             user.ProfileVersion,
 		TargetWeight:        user.TargetWeight,
 		TargetDate:          user.TargetDate,
 		GoalSetAt:           user.GoalSetAt,
@@ -89,22 +93,35 @@ func (s *Service) GetProfile(userID int) (*ProfileResponse, error) {
 	return response, nil
 }
 
-// UpdateProfile updates the user profile, passing DTO directly to repository
-func (s *Service) UpdateProfile(userID int, req *ProfileUpdateRequest) error {
+// UpdateProfile updates the user profile, passing DTO directly to repository.
+// When the language changes, the user's catalog ingredients are re-localized and the
+// report is returned; otherwise the report is nil.
+func (s *Service) UpdateProfile(userID int, req *ProfileUpdateRequest) (*ingredientservice.RelocalizeReport, error) {
 	s.logger.Debug("UpdateProfile called", "user_id", userID, "email", req.Email, "first_name", req.FirstName, "last_name", req.LastName)
 
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		s.logger.Error("Failed to get user", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	previousLanguage := "en_US"
+	if user.Language != nil {
+		previousLanguage = *user.Language
+	}
+
 	// Start transaction for atomic updates
 	tx, err := s.db.Begin()
 	if err != nil {
 		s.logger.Error("Failed to begin transaction", "user_id", userID, "error", err)
-		return err
+		return nil, err
 	}
 	defer tx.Rollback()
 
 	// Update user profile (without weight - now managed only in weight history)
-	if err := s.userRepo.UpdateProfile(userID, req.FirstName, req.LastName, req.Email, req.Age, req.Height, req.Gender, nil, req.Language, req.ActivityLevel); err != nil {
+	if err := s.userRepo.UpdateProfile(userID, req.FirstName, req.LastName, req.Email, req.Age, req.Height, req.Gender, nil, req.Language, req.ActivityLevel, req.Version); err != nil {
 		s.logger.Error("Failed to update profile", "user_id", userID, "error", err)
-		return err
+		return nil, err
 	}
 
 	// Weight is now managed only through weight history, not profile updates
@@ -112,11 +129,27 @@ func (s *Service) UpdateProfile(userID int, req *ProfileUpdateRequest) error {
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		s.logger.Error("Failed to commit transaction", "user_id", userID, "error", err)
-		return err
+		return nil, err
 	}
 
-	s.logger.Debug("UpdateProfile completed successfully", "user_id", userID)
-	return nil
+	if req.Language == previousLanguage {
+		s.logger.Debug("UpdateProfile completed successfully", "user_id", userID)
+		return nil, nil
+	}
+
+	// The profile is already saved; a failed re-localization can be retried from the ingredients page
+	report, err := s.ingredientService.Relocalize(&ingredientservice.RelocalizeRequest{
+		UserID:           userID,
+		Language:         req.Language,
+		PreviousLanguage: previousLanguage,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to relocalize ingredients", "user_id", userID, "language", req.Language, "error", err)
+		return nil, nil
+	}
+
+	s.logger.Debug("UpdateProfile completed successfully", "user_id", userID, "language", req.Language)
+	return report, nil
 }
 
 // SetWeightGoal sets a weight goal for the user
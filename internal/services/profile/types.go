@@ -1,6 +1,10 @@
 package profile
 
-import "time"
+import (
+	"time"
+
+	ingredientservice "ypeskov/kkal-tracker/internal/services/ingredient"
+)
 
 // ProfileUpdateRequest represents the request to update user profile
 type ProfileUpdateRequest struct {
@@ -12,6 +16,9 @@ type ProfileUpdateRequest struct {
 	Gender        *string  `json:"gender" validate:"omitempty,oneof=male female"`
 	Language      string   `json:"language" validate:"required,oneof=en_US uk_UA ru_UA bg_BG"`
 	ActivityLevel *string  `json:"activity_level" validate:"omitempty,oneof=sedentary lightly_active moderate very_active extra_active"`
+
+	// Version the profile must have, from If-Match; 0 skips the check
+	Version int `json:"-"`
 }
 
 // ProfileResponse represents the user profile data returned to the client
@@ -25,12 +32,16 @@ type ProfileResponse struct {
 	Gender        *string  `json:"gender"`
 	Language      string   `json:"language"`
 	ActivityLevel *string  `json:"activity_level"`
+	Version       int      `json:"version"` // Incremented by every change to the profile or weight goal; the profile's ETag
 
 	// Weight goal fields
 	TargetWeight        *float64   `json:"target_weight,omitempty"`
 	TargetDate          *time.Time `json:"target_date,omitempty"`
 	GoalSetAt           *time.Time `json:"goal_set_at,omitempty"`
 	InitialWeightAtGoal *float64   `json:"initial_weight_at_goal,omitempty"`
+
+	// Set only in the update response when the language change re-localized ingredients
+	IngredientRelocalization *ingredientservice.RelocalizeReport `json:"ingredient_relocalization,omitempty"`
 }
 
 // WeightGoalRequest represents the request to set a weight goal
@@ -2,6 +2,7 @@ package reports
 
 import (
 	"log/slog"
+	"math"
 	"time"
 
 	"ypeskov/kkal-tracker/internal/models"
@@ -83,22 +84,38 @@ func (s *Service) aggregateWeightData(weightHistory []*models.WeightHistory) []W
 	return weightPoints
 }
 
-// aggregateCalorieData processes calorie entries and calculates daily totals
+// aggregateCalorieData processes calorie entries and calculates daily totals.
+// Extended nutrients are summed only from entries where they are known.
 func (s *Service) aggregateCalorieData(calorieEntries []*models.CalorieEntry) []CalorieDataPoint {
-	// Sum calories by day
-	caloriesByDay := make(map[string]int)
+	// Sum calories and nutrients by day
+	pointsByDay := make(map[string]*CalorieDataPoint)
 	for _, entry := range calorieEntries {
 		day := entry.MealDatetime.Format("2006-01-02")
-		caloriesByDay[day] += entry.Calories
+		point, ok := pointsByDay[day]
+		if !ok {
+			point = &CalorieDataPoint{Date: day}
+			pointsByDay[day] = point
+		}
+		point.Calories += entry.Calories
+		point.Entries++
+
+		for code, value := range entry.Nutrients {
+			if point.Nutrients == nil {
+				point.Nutrients = make(models.Nutrients)
+				point.NutrientEntries = make(map[string]int)
+			}
+			point.Nutrients[code] += value * entry.Weight / 100
+			point.NutrientEntries[code]++
+		}
 	}
 
 	// Convert to slice
-	caloriePoints := make([]CalorieDataPoint, 0, len(caloriesByDay))
-	for date, calories := range caloriesByDay {
-		caloriePoints = append(caloriePoints, CalorieDataPoint{
-			Date:     date,
-			Calories: calories,
-		})
+	caloriePoints := make([]CalorieDataPoint, 0, len(pointsByDay))
+	for _, point := range pointsByDay {
+		for code, total := range point.Nutrients {
+			point.Nutrients[code] = math.Round(total*100) / 100
+		}
+		caloriePoints = append(caloriePoints, *point)
 	}
 
 	return caloriePoints
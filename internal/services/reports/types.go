@@ -1,5 +1,7 @@
 package reports
 
+import "ypeskov/kkal-tracker/internal/models"
+
 // WeightDataPoint represents daily weight data
 type WeightDataPoint struct {
 	Date   string  `json:"date"`
@@ -10,6 +12,11 @@ type WeightDataPoint struct {
 type CalorieDataPoint struct {
 	Date     string `json:"date"`
 	Calories int    `json:"calories"`
+	Entries  int    `json:"entries"`
+	// Nutrients are the day's totals (in each nutrient's unit, not per 100 g) summed over
+	// the entries where the value is known; NutrientEntries tells how many entries that is
+	Nutrients       models.Nutrients `json:"nutrients,omitempty"`
+	NutrientEntries map[string]int   `json:"nutrient_entries,omitempty"`
 }
 
 // ReportDataResponse contains aggregated metrics
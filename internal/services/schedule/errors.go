@@ -0,0 +1,11 @@
+package schedule
+
+import "errors"
+
+var (
+	ErrScheduleNotFound   = errors.New("schedule not found")
+	ErrInvalidToken       = errors.New("invalid unsubscribe link")
+	ErrInvalidTimezone    = errors.New("unknown time zone")
+	ErrDayOfWeekRequired  = errors.New("day_of_week is required for weekly schedules")
+	ErrDayOfMonthRequired = errors.New("day_of_month is required for monthly schedules")
+)
@@ -0,0 +1,13 @@
+package schedule
+
+import "ypeskov/kkal-tracker/internal/models"
+
+// Servicer defines the schedule service contract used by handlers.
+type Servicer interface {
+	List(userID int) ([]*models.ExportSchedule, error)
+	Create(userID int, req *ScheduleRequest) (*models.ExportSchedule, error)
+	Update(userID, scheduleID int, req *ScheduleRequest) (*models.ExportSchedule, error)
+	Delete(userID, scheduleID int) error
+	GetByUnsubscribeToken(token string) (*models.ExportSchedule, error)
+	Unsubscribe(token string) error
+}
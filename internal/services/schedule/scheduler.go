@@ -0,0 +1,242 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	emailservice "ypeskov/kkal-tracker/internal/services/email"
+	exportservice "ypeskov/kkal-tracker/internal/services/export"
+	metricsservice "ypeskov/kkal-tracker/internal/services/metrics"
+)
+
+// Scheduler settings
+const (
+	schedulerInterval   = time.Minute
+	scheduleLease       = 10 * time.Minute // Failed runs are tried again once the lease runs out
+	scheduleRetryWindow = 6 * time.Hour    // Runs still failing this late are skipped until the next occurrence
+)
+
+// errSkipRun is returned by send when the user should get no email this time; retrying would not help
+var errSkipRun = errors.New("user does not receive scheduled emails")
+
+// Run sends due scheduled emails until ctx is cancelled. Several instances can
+// run at the same time: a schedule is leased to one of them while it is sent.
+func (s *Service) Run(ctx context.Context) {
+	s.logger.Info("Scheduler started", "owner", s.owner)
+
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil && s.runNext() {
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext sends one due schedule and reports whether there was one
+func (s *Service) runNext() bool {
+	now := time.Now()
+	schedule, err := s.scheduleRepo.ClaimDue(s.owner, now, now.Add(scheduleLease))
+	if err != nil {
+		s.logger.Error("Failed to claim schedule", "error", err)
+		return false
+	}
+	if schedule == nil {
+		return false
+	}
+
+	logger := s.logger.With("schedule_id", schedule.ID, "user_id", schedule.UserID, "kind", schedule.Kind)
+	var lastRun *time.Time
+	if err := s.send(schedule); errors.Is(err, errSkipRun) {
+		logger.Info("Scheduled email skipped", "reason", err)
+	} else if err != nil {
+		if now.Sub(schedule.NextRunAt) < scheduleRetryWindow {
+			// Keep the lease; the run is picked up again when it expires
+			logger.Error("Scheduled email failed, will retry", "error", err)
+			return true
+		}
+		logger.Error("Scheduled email failed, skipping this run", "error", err)
+	} else {
+		lastRun = &now
+		logger.Info("Scheduled email sent")
+	}
+
+	// Runs missed while the server was down are not caught up on
+	if err := s.scheduleRepo.FinishRun(schedule.ID, s.owner, nextRun(schedule, now), lastRun); err != nil {
+		logger.Error("Failed to finish schedule run", "error", err)
+	}
+	return true
+}
+
+// send emails the export or digest of a schedule
+func (s *Service) send(schedule *models.ExportSchedule) error {
+	user, err := s.userRepo.GetByID(schedule.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if !user.IsActive || user.DeletionScheduledAt != nil {
+		return errSkipRun
+	}
+
+	language := "en_US"
+	if user.Language != nil {
+		language = *user.Language
+	}
+	dateFrom, dateTo := coveredPeriod(schedule)
+	unsubscribeURL := fmt.Sprintf("%s/api/unsubscribe/%s", s.appURL, schedule.UnsubscribeToken)
+
+	if schedule.Kind == models.ScheduleKindDigest {
+		digest, err := s.buildDigest(schedule.UserID, dateFrom, dateTo)
+		if err != nil {
+			return err
+		}
+		return s.emailService.SendDigestEmail(user.Email, language, digest, unsubscribeURL)
+	}
+
+	_, err = s.exportService.Export(&exportservice.ExportRequest{
+		UserID:         schedule.UserID,
+		DateFrom:       dateFrom.Format("2006-01-02"),
+		DateTo:         dateTo.Format("2006-01-02"),
+		DataType:       exportservice.ExportDataType(schedule.DataType),
+		Format:         exportservice.Format(schedule.Format),
+		DeliveryType:   exportservice.DeliveryEmail,
+		Language:       language,
+		UserEmail:      user.Email,
+		UnsubscribeURL: unsubscribeURL,
+	})
+	return err
+}
+
+// buildDigest sums the user's entries per day and compares them with the daily targets
+func (s *Service) buildDigest(userID int, dateFrom, dateTo time.Time) (*emailservice.Digest, error) {
+	from, to := dateFrom.Format("2006-01-02"), dateTo.Format("2006-01-02")
+	entries, err := s.calorieService.GetEntriesByDateRange(userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calorie entries: %w", err)
+	}
+
+	digest := &emailservice.Digest{
+		DateFrom: from,
+		DateTo:   to,
+		Days:     int(dateTo.Sub(dateFrom).Hours()/24) + 1,
+	}
+
+	byDay := make(map[string]float64)
+	for _, entry := range entries {
+		byDay[entry.MealDatetime.Format("2006-01-02")] += float64(entry.Calories)
+		digest.Calories += float64(entry.Calories)
+		if entry.Proteins != nil {
+			digest.Proteins += *entry.Proteins * entry.Weight / 100
+		}
+		if entry.Fats != nil {
+			digest.Fats += *entry.Fats * entry.Weight / 100
+		}
+		if entry.Carbs != nil {
+			digest.Carbs += *entry.Carbs * entry.Weight / 100
+		}
+	}
+	for date, calories := range byDay {
+		digest.DailyCalories = append(digest.DailyCalories, emailservice.DigestDay{Date: date, Calories: calories})
+	}
+	sort.Slice(digest.DailyCalories, func(i, j int) bool {
+		return digest.DailyCalories[i].Date < digest.DailyCalories[j].Date
+	})
+
+	// Longer periods show daily averages over the days with entries
+	digest.DaysLogged = len(byDay)
+	if digest.DaysLogged > 1 {
+		days := float64(digest.DaysLogged)
+		digest.Calories /= days
+		digest.Proteins /= days
+		digest.Fats /= days
+		digest.Carbs /= days
+	}
+
+	targets, err := s.metricsService.GetDailyTargets(userID)
+	if err != nil && !errors.Is(err, metricsservice.ErrInsufficientData) {
+		return nil, fmt.Errorf("failed to get daily targets: %w", err)
+	}
+	if targets != nil {
+		digest.Targets = &emailservice.DigestTargets{
+			Calories: targets.Calories,
+			Proteins: targets.Proteins,
+			Fats:     targets.Fats,
+			Carbs:    targets.Carbs,
+		}
+	}
+
+	return digest, nil
+}
+
+// location returns the time zone of a schedule, falling back to UTC
+func location(schedule *models.ExportSchedule) *time.Location {
+	loc, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// nextRun returns the first occurrence of a schedule after the given time
+func nextRun(schedule *models.ExportSchedule, after time.Time) time.Time {
+	loc := location(schedule)
+	local := after.In(loc)
+
+	switch schedule.Frequency {
+	case models.ScheduleWeekly:
+		run := time.Date(local.Year(), local.Month(), local.Day(), schedule.Hour, 0, 0, 0, loc)
+		run = run.AddDate(0, 0, (*schedule.DayOfWeek-int(run.Weekday())+7)%7)
+		if !run.After(after) {
+			run = run.AddDate(0, 0, 7)
+		}
+		return run
+	case models.ScheduleMonthly:
+		run := monthDay(local.Year(), local.Month(), *schedule.DayOfMonth, schedule.Hour, loc)
+		if !run.After(after) {
+			run = monthDay(local.Year(), local.Month()+1, *schedule.DayOfMonth, schedule.Hour, loc)
+		}
+		return run
+	default:
+		run := time.Date(local.Year(), local.Month(), local.Day(), schedule.Hour, 0, 0, 0, loc)
+		if !run.After(after) {
+			run = run.AddDate(0, 0, 1)
+		}
+		return run
+	}
+}
+
+// monthDay returns the given day of a month, or the month's last day if it is shorter
+func monthDay(year int, month time.Month, day, hour int, loc *time.Location) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	return time.Date(year, month, min(day, last), hour, 0, 0, 0, loc)
+}
+
+// coveredPeriod returns the first and last day a run reports on: the day
+// before for daily schedules, the seven days before for weekly ones and the
+// previous calendar month for monthly ones
+func coveredPeriod(schedule *models.ExportSchedule) (time.Time, time.Time) {
+	run := schedule.NextRunAt.In(location(schedule))
+	today := time.Date(run.Year(), run.Month(), run.Day(), 0, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+
+	switch schedule.Frequency {
+	case models.ScheduleWeekly:
+		return today.AddDate(0, 0, -7), yesterday
+	case models.ScheduleMonthly:
+		first := time.Date(run.Year(), run.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return first.AddDate(0, -1, 0), first.AddDate(0, 0, -1)
+	default:
+		return yesterday, yesterday
+	}
+}
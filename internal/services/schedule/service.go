@@ -0,0 +1,201 @@
+package schedule
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+	calorieservice "ypeskov/kkal-tracker/internal/services/calorie"
+	emailservice "ypeskov/kkal-tracker/internal/services/email"
+	exportservice "ypeskov/kkal-tracker/internal/services/export"
+	metricsservice "ypeskov/kkal-tracker/internal/services/metrics"
+)
+
+// Service manages export schedules and sends the scheduled emails
+type Service struct {
+	scheduleRepo   repositories.ExportScheduleRepository
+	userRepo       repositories.UserRepository
+	exportService  exportservice.Servicer
+	calorieService calorieservice.Servicer
+	metricsService metricsservice.Servicer
+	emailService   *emailservice.Service
+	appURL         string
+	owner          string // Identifies this instance in schedule leases
+	logger         *slog.Logger
+}
+
+// New creates a new schedule service
+func New(
+	scheduleRepo repositories.ExportScheduleRepository,
+	userRepo repositories.UserRepository,
+	exportService exportservice.Servicer,
+	calorieService calorieservice.Servicer,
+	metricsService metricsservice.Servicer,
+	emailService *emailservice.Service,
+	appURL string,
+	logger *slog.Logger,
+) *Service {
+	return &Service{
+		scheduleRepo:   scheduleRepo,
+		userRepo:       userRepo,
+		exportService:  exportService,
+		calorieService: calorieService,
+		metricsService: metricsService,
+		emailService:   emailService,
+		appURL:         appURL,
+		owner:          leaseOwner(),
+		logger:         logger.With("service", "schedule"),
+	}
+}
+
+// List returns the user's schedules
+func (s *Service) List(userID int) ([]*models.ExportSchedule, error) {
+	s.logger.Debug("List called", "user_id", userID)
+	return s.scheduleRepo.GetByUserID(userID)
+}
+
+// Create adds a schedule for the user
+func (s *Service) Create(userID int, req *ScheduleRequest) (*models.ExportSchedule, error) {
+	s.logger.Debug("Create called", "user_id", userID, "kind", req.Kind, "frequency", req.Frequency)
+
+	schedule := &models.ExportSchedule{UserID: userID}
+	if err := applyRequest(schedule, req); err != nil {
+		return nil, err
+	}
+	schedule.NextRunAt = nextRun(schedule, time.Now())
+
+	if err := s.scheduleRepo.Create(schedule); err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	s.logger.Info("Schedule created", "user_id", userID, "schedule_id", schedule.ID, "next_run_at", schedule.NextRunAt)
+	return schedule, nil
+}
+
+// Update changes one of the user's schedules. The next run is computed again
+// from the new settings.
+func (s *Service) Update(userID, scheduleID int, req *ScheduleRequest) (*models.ExportSchedule, error) {
+	s.logger.Debug("Update called", "user_id", userID, "schedule_id", scheduleID)
+
+	schedule, err := s.scheduleRepo.GetByID(scheduleID, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+
+	if err := applyRequest(schedule, req); err != nil {
+		return nil, err
+	}
+	schedule.NextRunAt = nextRun(schedule, time.Now())
+
+	if err := s.scheduleRepo.Update(schedule); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, fmt.Errorf("failed to update schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+// Delete removes one of the user's schedules
+func (s *Service) Delete(userID, scheduleID int) error {
+	s.logger.Debug("Delete called", "user_id", userID, "schedule_id", scheduleID)
+
+	if err := s.scheduleRepo.Delete(scheduleID, userID); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrScheduleNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// GetByUnsubscribeToken returns the schedule an unsubscribe link belongs to
+func (s *Service) GetByUnsubscribeToken(token string) (*models.ExportSchedule, error) {
+	schedule, err := s.scheduleRepo.GetByUnsubscribeToken(token)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Unsubscribe disables the schedule an unsubscribe link belongs to. The
+// schedule is kept, so the user can turn it on again in the app.
+func (s *Service) Unsubscribe(token string) error {
+	schedule, err := s.GetByUnsubscribeToken(token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.scheduleRepo.Disable(schedule.ID); err != nil {
+		return err
+	}
+
+	s.logger.Info("Schedule unsubscribed", "user_id", schedule.UserID, "schedule_id", schedule.ID)
+	return nil
+}
+
+// applyRequest validates a request and copies it onto a schedule
+func applyRequest(schedule *models.ExportSchedule, req *ScheduleRequest) error {
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+
+	schedule.Kind = req.Kind
+	schedule.Frequency = req.Frequency
+	schedule.Hour = req.Hour
+	schedule.Timezone = timezone
+	schedule.Enabled = req.Enabled
+	schedule.DayOfWeek = nil
+	schedule.DayOfMonth = nil
+
+	switch req.Frequency {
+	case models.ScheduleWeekly:
+		if req.DayOfWeek == nil {
+			return ErrDayOfWeekRequired
+		}
+		schedule.DayOfWeek = req.DayOfWeek
+	case models.ScheduleMonthly:
+		if req.DayOfMonth == nil {
+			return ErrDayOfMonthRequired
+		}
+		schedule.DayOfMonth = req.DayOfMonth
+	}
+
+	schedule.DataType = req.DataType
+	if schedule.DataType == "" {
+		schedule.DataType = string(exportservice.ExportBoth)
+	}
+	schedule.Format = req.Format
+	if schedule.Format == "" {
+		schedule.Format = string(exportservice.FormatExcel)
+	}
+	return nil
+}
+
+// leaseOwner returns a name for this instance that is unique across replicas
+func leaseOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "server"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,14 @@
+package schedule
+
+// ScheduleRequest contains the fields a user sets on a schedule
+type ScheduleRequest struct {
+	Kind       string // models.ScheduleKindExport or models.ScheduleKindDigest
+	Frequency  string // models.ScheduleDaily, ScheduleWeekly or ScheduleMonthly
+	DayOfWeek  *int   // 0 = Sunday, weekly only
+	DayOfMonth *int   // 1-31, monthly only
+	Hour       int    // Local hour of the day the email is sent
+	Timezone   string // IANA name, defaults to UTC
+	DataType   string // Exports only, defaults to both
+	Format     string // Exports only, defaults to xlsx
+	Enabled    bool
+}
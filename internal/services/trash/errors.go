@@ -0,0 +1,5 @@
+package trash
+
+import "errors"
+
+var ErrNothingToUndo = errors.New("nothing was deleted recently enough to undo")
@@ -0,0 +1,11 @@
+package trash
+
+import "ypeskov/kkal-tracker/internal/models"
+
+// Servicer defines the trash service contract used by handlers.
+type Servicer interface {
+	List(userID int) (*Trash, error)
+	RestoreCalorieEntry(entryID, userID int) (*models.CalorieEntry, error)
+	RestoreWeightEntry(entryID, userID int) (*models.WeightHistory, error)
+	Undo(userID int) (*Restored, error)
+}
@@ -0,0 +1,179 @@
+package trash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// Purge job settings
+const purgeInterval = time.Hour
+
+// Service keeps deleted calorie entries and weigh-ins in the trash, where
+// they can be restored until they are purged
+type Service struct {
+	calorieRepo repositories.CalorieEntryRepository
+	weightRepo  repositories.WeightHistoryRepository
+	retention   time.Duration // How long deleted entries stay in the trash
+	undoWindow  time.Duration // How long after a delete Undo restores it
+	logger      *slog.Logger
+}
+
+// New creates a new trash service
+func New(
+	calorieRepo repositories.CalorieEntryRepository,
+	weightRepo repositories.WeightHistoryRepository,
+	retention time.Duration,
+	undoWindow time.Duration,
+	logger *slog.Logger,
+) *Service {
+	return &Service{
+		calorieRepo: calorieRepo,
+		weightRepo:  weightRepo,
+		retention:   retention,
+		undoWindow:  undoWindow,
+		logger:      logger.With("service", "trash"),
+	}
+}
+
+// List returns the user's calorie entries and weigh-ins in the trash, most
+// recently deleted first
+func (s *Service) List(userID int) (*Trash, error) {
+	s.logger.Debug("List called", "user_id", userID)
+
+	entries, err := s.calorieRepo.GetTrashed(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trashed calorie entries: %w", err)
+	}
+	weights, err := s.weightRepo.GetTrashed(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trashed weight entries: %w", err)
+	}
+
+	if entries == nil {
+		entries = []*models.CalorieEntry{}
+	}
+	if weights == nil {
+		weights = []*models.WeightHistory{}
+	}
+
+	return &Trash{
+		CalorieEntries: entries,
+		WeightEntries:  weights,
+		RetentionDays:  int(s.retention / (24 * time.Hour)),
+	}, nil
+}
+
+// RestoreCalorieEntry takes a calorie entry of the user out of the trash.
+// Returns repositories.ErrNotFound if it is not in the trash.
+func (s *Service) RestoreCalorieEntry(entryID, userID int) (*models.CalorieEntry, error) {
+	s.logger.Debug("RestoreCalorieEntry called", "entry_id", entryID, "user_id", userID)
+
+	entry, err := s.calorieRepo.Restore(entryID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Calorie entry restored", "entry_id", entryID, "user_id", userID)
+	return entry, nil
+}
+
+// RestoreWeightEntry takes a weigh-in of the user out of the trash.
+// Returns repositories.ErrNotFound if it is not in the trash.
+func (s *Service) RestoreWeightEntry(entryID, userID int) (*models.WeightHistory, error) {
+	s.logger.Debug("RestoreWeightEntry called", "id", entryID, "user_id", userID)
+
+	entry, err := s.weightRepo.Restore(entryID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info("Weight entry restored", "id", entryID, "user_id", userID)
+	return entry, nil
+}
+
+// Undo restores the calorie entry or weigh-in the user deleted last, if that
+// was within the undo window. Calling it again undoes the delete before that,
+// as long as it is in the window too.
+func (s *Service) Undo(userID int) (*Restored, error) {
+	s.logger.Debug("Undo called", "user_id", userID)
+
+	trash, err := s.List(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastEntry *models.CalorieEntry
+	if len(trash.CalorieEntries) > 0 {
+		lastEntry = trash.CalorieEntries[0]
+	}
+	var lastWeight *models.WeightHistory
+	if len(trash.WeightEntries) > 0 {
+		lastWeight = trash.WeightEntries[0]
+	}
+
+	since := time.Now().Add(-s.undoWindow)
+	switch {
+	case lastWeight != nil && lastWeight.DeletedAt.After(since) &&
+		(lastEntry == nil || lastWeight.DeletedAt.After(*lastEntry.DeletedAt)):
+		entry, err := s.RestoreWeightEntry(lastWeight.ID, userID)
+		if err != nil {
+			return nil, undoError(err)
+		}
+		return &Restored{Type: models.SyncEntityWeight, ID: entry.ID, Version: entry.Version, Data: entry}, nil
+
+	case lastEntry != nil && lastEntry.DeletedAt.After(since):
+		entry, err := s.RestoreCalorieEntry(lastEntry.ID, userID)
+		if err != nil {
+			return nil, undoError(err)
+		}
+		return &Restored{Type: models.SyncEntityCalorieEntry, ID: entry.ID, Version: entry.Version, Data: entry}, nil
+	}
+
+	return nil, ErrNothingToUndo
+}
+
+// undoError reports an entry that was restored or purged while Undo was
+// looking at it as nothing to undo
+func undoError(err error) error {
+	if errors.Is(err, repositories.ErrNotFound) {
+		return ErrNothingToUndo
+	}
+	return err
+}
+
+// RunPurge permanently deletes entries that have been in the trash for longer
+// than the retention until ctx is cancelled. Running it on several instances
+// is harmless.
+func (s *Service) RunPurge(ctx context.Context) {
+	s.logger.Info("Trash purge job started")
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		before := time.Now().Add(-s.retention)
+		if purged, err := s.calorieRepo.PurgeTrashed(before); err != nil {
+			s.logger.Error("Failed to purge trashed calorie entries", "error", err)
+		} else if purged > 0 {
+			s.logger.Info("Trashed calorie entries purged", "count", purged)
+		}
+		if purged, err := s.weightRepo.PurgeTrashed(before); err != nil {
+			s.logger.Error("Failed to purge trashed weight entries", "error", err)
+		} else if purged > 0 {
+			s.logger.Info("Trashed weight entries purged", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Trash purge job stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
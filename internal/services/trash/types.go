@@ -0,0 +1,18 @@
+package trash
+
+import "ypeskov/kkal-tracker/internal/models"
+
+// Trash holds the user's deleted entries that can still be restored
+type Trash struct {
+	CalorieEntries []*models.CalorieEntry  `json:"calorie_entries"`
+	WeightEntries  []*models.WeightHistory `json:"weight_entries"`
+	RetentionDays  int                     `json:"retention_days"` // Entries are purged this many days after they were deleted
+}
+
+// Restored is an entry taken out of the trash by an undo
+type Restored struct {
+	Type    string `json:"type"` // calorie_entry or weight, as in the change feed
+	ID      int    `json:"id"`
+	Version int    `json:"version"`
+	Data    any    `json:"data"`
+}
@@ -0,0 +1,307 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// Delivery settings
+const (
+	deliveryPollInterval    = 5 * time.Second
+	deliveryCleanupInterval = time.Hour
+	deliveryLease           = 2 * time.Minute // Deliveries of a stopped instance are picked up again after this
+	deliveryTimeout         = 10 * time.Second
+	deliveryMaxAttempts     = 10               // Attempts before a delivery is given up on, about 4 hours in
+	deliveryBaseBackoff     = 30 * time.Second // Doubled after every failed attempt
+	deliveryMaxBackoff      = 2 * time.Hour
+	disableAfterFailures    = 20                  // Failed attempts in a row that disable a webhook
+	deliveryRetention       = 30 * 24 * time.Hour // Finished deliveries stay in the log this long
+	maxErrorLength          = 300
+)
+
+// Request headers sent with every delivery
+const (
+	HeaderEvent     = "X-Kkal-Event"
+	HeaderDelivery  = "X-Kkal-Delivery"
+	HeaderSignature = "X-Kkal-Signature" // t=<unix time>,v1=<hex HMAC-SHA256 of "<t>.<body>">
+)
+
+// errPrivateAddress is returned when an endpoint resolves to a private network address
+var errPrivateAddress = errors.New("endpoint resolves to a private network address")
+
+// RunWorker works out queued checks and delivers queued events until ctx is
+// cancelled. Several workers, also on different instances, can run at the same
+// time: a check is taken by one of them, and a delivery is leased to one of
+// them while it is sent. Old deliveries are cleaned up as well.
+func (s *Service) RunWorker(ctx context.Context) {
+	s.logger.Info("Webhook worker started", "owner", s.owner)
+
+	ticker := time.NewTicker(deliveryPollInterval)
+	defer ticker.Stop()
+	var lastCleanup time.Time
+
+	for {
+		for ctx.Err() == nil && s.checkNext() {
+		}
+		for ctx.Err() == nil && s.deliverNext(ctx) {
+		}
+
+		if time.Since(lastCleanup) >= deliveryCleanupInterval {
+			s.cleanupDeliveries()
+			lastCleanup = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Webhook worker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverNext sends one due delivery and reports whether there was one
+func (s *Service) deliverNext(ctx context.Context) bool {
+	now := time.Now()
+	delivery, err := s.webhookRepo.ClaimDueDelivery(s.owner, now, now.Add(deliveryLease))
+	if err != nil {
+		s.logger.Error("Failed to claim webhook delivery", "error", err)
+		return false
+	}
+	if delivery == nil {
+		return false
+	}
+
+	logger := s.logger.With("delivery_id", delivery.ID, "webhook_id", delivery.WebhookID, "event", delivery.Event)
+	webhook, err := s.webhookRepo.GetByID(delivery.WebhookID, delivery.UserID)
+	if err != nil {
+		if !errors.Is(err, repositories.ErrNotFound) {
+			// The lease runs out and the delivery is tried again
+			logger.Error("Failed to get webhook", "error", err)
+			return true
+		}
+		s.finish(delivery, models.WebhookDeliveryFailed, nil, "webhook deleted", logger)
+		return true
+	}
+
+	delivery.Attempts++
+	status, err := s.send(ctx, webhook, delivery)
+	finished := time.Now()
+	if err == nil {
+		s.finish(delivery, models.WebhookDeliveryDelivered, &status, "", logger)
+		if err := s.webhookRepo.RecordSuccess(webhook.ID, finished); err != nil {
+			logger.Error("Failed to record webhook success", "error", err)
+		}
+		logger.Info("Webhook delivered", "status", status, "attempts", delivery.Attempts)
+		return true
+	}
+
+	var responseStatus *int
+	if status != 0 {
+		responseStatus = &status
+	}
+	if delivery.Attempts >= deliveryMaxAttempts {
+		logger.Warn("Webhook delivery failed, giving up", "error", err, "attempts", delivery.Attempts)
+		s.finish(delivery, models.WebhookDeliveryFailed, responseStatus, err.Error(), logger)
+	} else {
+		delivery.NextAttemptAt = finished.Add(backoff(delivery.Attempts))
+		logger.Info("Webhook delivery failed, will retry", "error", err, "attempts", delivery.Attempts,
+			"next_attempt_at", delivery.NextAttemptAt)
+		s.finish(delivery, models.WebhookDeliveryPending, responseStatus, err.Error(), logger)
+	}
+
+	enabled, err := s.webhookRepo.RecordFailure(webhook.ID, finished, disableAfterFailures)
+	if err != nil {
+		logger.Error("Failed to record webhook failure", "error", err)
+		return true
+	}
+	if !enabled {
+		logger.Warn("Webhook disabled after repeated failures", "failures", disableAfterFailures)
+		if err := s.webhookRepo.FailPendingDeliveries(webhook.ID, "webhook disabled after repeated failures"); err != nil {
+			logger.Error("Failed to drop queued deliveries", "error", err)
+		}
+	}
+	return true
+}
+
+// finish stores the outcome of an attempt and releases the delivery
+func (s *Service) finish(delivery *models.WebhookDelivery, status string, responseStatus *int, message string, logger *slog.Logger) {
+	delivery.Status = status
+	delivery.ResponseStatus = responseStatus
+	delivery.Error = nil
+	if message != "" {
+		if len(message) > maxErrorLength {
+			message = message[:maxErrorLength]
+		}
+		delivery.Error = &message
+	}
+	delivery.CompletedAt = nil
+	if status != models.WebhookDeliveryPending {
+		now := time.Now()
+		delivery.CompletedAt = &now
+	}
+
+	if err := s.webhookRepo.FinishDelivery(delivery, s.owner); err != nil {
+		logger.Error("Failed to store webhook delivery result", "error", err)
+	}
+}
+
+// send POSTs the signed payload to the webhook and returns the response status.
+// Only 2xx responses count as delivered; redirects are not followed.
+func (s *Service) send(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("invalid request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "kkal-tracker-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, delivery.EventID)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, time.Now(), body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header of a payload sent at the given time.
+// Receivers recompute the HMAC with their secret and should reject old timestamps.
+func Sign(secret string, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff returns the wait after the given number of failed attempts
+func backoff(attempts int) time.Duration {
+	wait := deliveryBaseBackoff << (attempts - 1)
+	if wait <= 0 || wait > deliveryMaxBackoff {
+		return deliveryMaxBackoff
+	}
+	return wait
+}
+
+// cleanupDeliveries deletes old finished deliveries from the log
+func (s *Service) cleanupDeliveries() {
+	deleted, err := s.webhookRepo.DeleteFinishedDeliveriesBefore(time.Now().Add(-deliveryRetention))
+	if err != nil {
+		s.logger.Error("Failed to delete old webhook deliveries", "error", err)
+		return
+	}
+	if deleted > 0 {
+		s.logger.Info("Webhook deliveries cleaned up", "deleted", deleted)
+	}
+}
+
+// newClient creates the HTTP client for deliveries. Unless private networks
+// are allowed, connections to loopback, private, link-local and other
+// non-public addresses are refused after DNS resolution, so endpoints cannot
+// reach internal services.
+func (s *Service) newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: deliveryTimeout}
+	if !s.allowPrivate {
+		dialer.Control = refusePrivate
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   deliveryTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// refusePrivate is the dialer control that refuses connections to addresses
+// isPrivateIP reports as private
+func refusePrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || isPrivateIP(ip) {
+		return errPrivateAddress
+	}
+	return nil
+}
+
+// privateNetworks are the ranges not reachable on the public internet that the
+// net.IP methods do not cover
+var privateNetworks = parseNetworks(
+	"0.0.0.0/8",      // This network
+	"100.64.0.0/10",  // Carrier-grade NAT
+	"192.0.0.0/24",   // IETF protocol assignments
+	"64:ff9b:1::/48", // Local-use NAT64
+)
+
+// nat64Network embeds IPv4 addresses in its last 32 bits (RFC 6052)
+var nat64Network = parseNetworks("64:ff9b::/96")[0]
+
+// isPrivateIP reports whether an address is not reachable on the public
+// internet. IPv4 addresses mapped to IPv6 or translated by NAT64 are judged by
+// the IPv4 address.
+func isPrivateIP(ip net.IP) bool {
+	if nat64Network.Contains(ip) {
+		ip = ip.To16()[12:]
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
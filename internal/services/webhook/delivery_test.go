@@ -0,0 +1,156 @@
+package webhook
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIsPrivateIP(t *testing.T) {
+	tests := []struct {
+		ip      string
+		private bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"100.64.0.1", true},
+		{"100.127.255.254", true},
+		{"192.0.0.8", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"::", true},
+		{"fc00::1", true},
+		{"fe80::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"::ffff:100.64.0.1", true},
+		{"64:ff9b::7f00:1", true},    // 127.0.0.1 through NAT64
+		{"64:ff9b::a9fe:a9fe", true}, // 169.254.169.254 through NAT64
+		{"64:ff9b:1::1", true},
+		{"8.8.8.8", false},
+		{"100.63.255.255", false},
+		{"100.128.0.1", false},
+		{"192.0.1.1", false},
+		{"::ffff:8.8.8.8", false},
+		{"64:ff9b::808:808", false}, // 8.8.8.8 through NAT64
+		{"2001:4860:4860::8888", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			ip := net.ParseIP(tt.ip)
+			if ip == nil {
+				t.Fatalf("invalid test address %q", tt.ip)
+			}
+			if got := isPrivateIP(ip); got != tt.private {
+				t.Errorf("isPrivateIP(%s) = %v, want %v", tt.ip, got, tt.private)
+			}
+		})
+	}
+}
+
+func TestRefusePrivate(t *testing.T) {
+	tests := []struct {
+		address string
+		refused bool
+	}{
+		{"93.184.216.34:443", false},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", false},
+		{"127.0.0.1:80", true},
+		{"100.64.1.1:443", true},
+		{"[::ffff:192.168.0.1]:443", true},
+		{"[64:ff9b::a00:1]:443", true},
+		{"example.com:443", true}, // Not resolved: only addresses are dialed
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := refusePrivate("tcp", tt.address, nil)
+			if tt.refused && !errors.Is(err, errPrivateAddress) {
+				t.Errorf("refusePrivate(%q) = %v, want %v", tt.address, err, errPrivateAddress)
+			}
+			if !tt.refused && err != nil {
+				t.Errorf("refusePrivate(%q) = %v, want nil", tt.address, err)
+			}
+		})
+	}
+
+	if err := refusePrivate("tcp", "127.0.0.1", nil); err == nil {
+		t.Error("refusePrivate accepted an address without a port")
+	}
+}
+
+func TestClientRefusesPrivateEndpoints(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	strict := (&Service{}).newClient()
+	if _, err := strict.Post(srv.URL, "application/json", nil); !errors.Is(err, errPrivateAddress) {
+		t.Errorf("request to %s: got %v, want %v", srv.URL, err, errPrivateAddress)
+	}
+
+	allowing := (&Service{allowPrivate: true}).newClient()
+	resp, err := allowing.Post(srv.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("request to %s with private networks allowed: %v", srv.URL, err)
+	}
+	resp.Body.Close()
+}
+
+func TestSign(t *testing.T) {
+	at := time.Unix(1760000000, 0)
+	body := []byte(`{"event":"ping"}`)
+
+	want := "t=1760000000,v1=7b3dfb12b416238df73d3eb92d1702951e3ebe69a71967d039b03ccc93c8d291"
+	if got := Sign("whsec_test", at, body); got != want {
+		t.Errorf("Sign = %q, want %q", got, want)
+	}
+
+	for name, other := range map[string]string{
+		"secret":    Sign("whsec_other", at, body),
+		"timestamp": Sign("whsec_test", at.Add(time.Second), body),
+		"body":      Sign("whsec_test", at, []byte(`{"event":"pong"}`)),
+	} {
+		if other == want {
+			t.Errorf("a different %s gives the same signature", name)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{8, 64 * time.Minute},
+		{9, deliveryMaxBackoff},
+		{40, deliveryMaxBackoff},
+		{64, deliveryMaxBackoff},
+		{100, deliveryMaxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+
+	for attempts := 2; attempts < deliveryMaxAttempts; attempts++ {
+		if backoff(attempts) < backoff(attempts-1) {
+			t.Errorf("backoff(%d) is shorter than backoff(%d)", attempts, attempts-1)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package webhook
+
+import "errors"
+
+var (
+	ErrWebhookNotFound = errors.New("webhook not found")
+	ErrInvalidURL      = errors.New("webhook URL must be an absolute http or https URL")
+	ErrPrivateURL      = errors.New("webhook URL must not point to a private network address")
+	ErrInvalidEvent    = errors.New("unknown webhook event")
+	ErrNoEvents        = errors.New("at least one event is required")
+	ErrTooManyWebhooks = errors.New("webhook limit reached")
+)
@@ -0,0 +1,286 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	metricsservice "ypeskov/kkal-tracker/internal/services/metrics"
+)
+
+// goalToleranceKg is the difference from the target weight treated as reached,
+// the same the metrics service uses for daily targets
+const goalToleranceKg = 0.5
+
+// WeightCreated queues weight.created and a check whether the weigh-in reaches the goal
+func (s *Service) WeightCreated(entry *models.WeightHistory) {
+	webhooks := s.subscribers(entry.UserID)
+	if len(webhooks) == 0 {
+		return
+	}
+
+	s.enqueue(webhooks, entry.UserID, models.WebhookWeightCreated, "", entry)
+
+	if subscribed(webhooks, models.WebhookGoalReached) {
+		s.queueCheck(entry.UserID, models.WebhookGoalReached, strconv.Itoa(entry.ID))
+	}
+}
+
+// CalorieEntryCreated queues calorie_entry.created and a check whether the day went over the target
+func (s *Service) CalorieEntryCreated(entry *models.CalorieEntry) {
+	s.calorieEntryChanged(models.WebhookCalorieEntryCreated, entry)
+}
+
+// CalorieEntryUpdated queues calorie_entry.updated and a check whether the day went over the target
+func (s *Service) CalorieEntryUpdated(entry *models.CalorieEntry) {
+	s.calorieEntryChanged(models.WebhookCalorieEntryUpdated, entry)
+}
+
+// calorieEntryChanged queues the events of a created or updated entry
+func (s *Service) calorieEntryChanged(event string, entry *models.CalorieEntry) {
+	webhooks := s.subscribers(entry.UserID)
+	if len(webhooks) == 0 {
+		return
+	}
+
+	s.enqueue(webhooks, entry.UserID, event, "", entry)
+
+	if subscribed(webhooks, models.WebhookDailyTargetExceeded) {
+		// The same day GetByUserIDAndDateRange puts the entry in: its own local date
+		s.queueCheck(entry.UserID, models.WebhookDailyTargetExceeded, entry.MealDatetime.Format("2006-01-02"))
+	}
+}
+
+// queueCheck leaves an event that depends on totals to the worker, so saving
+// a change does not wait for them to be computed
+func (s *Service) queueCheck(userID int, event, subject string) {
+	check := &models.WebhookCheck{UserID: userID, Event: event, Subject: subject}
+	if err := s.webhookRepo.QueueCheck(check); err != nil {
+		s.logger.Error("Failed to queue webhook check", "user_id", userID, "event", event, "error", err)
+	}
+}
+
+// checkNext works out one due check and queues its event if it happened.
+// Reports whether there was a check that did not fail.
+func (s *Service) checkNext() bool {
+	check, err := s.webhookRepo.TakeCheck(time.Now())
+	if err != nil {
+		s.logger.Error("Failed to take webhook check", "error", err)
+		return false
+	}
+	if check == nil {
+		return false
+	}
+
+	var data any
+	var key string
+	switch check.Event {
+	case models.WebhookGoalReached:
+		entryID, _ := strconv.Atoi(check.Subject)
+		var goal *GoalReachedData
+		goal, key, err = s.goalReached(check.UserID, entryID)
+		if goal != nil {
+			data = goal
+		}
+	case models.WebhookDailyTargetExceeded:
+		var exceeded *DailyTargetExceededData
+		exceeded, key, err = s.dailyTargetExceeded(check.UserID, check.Subject)
+		if exceeded != nil {
+			data = exceeded
+		}
+	}
+	if err != nil {
+		s.retryCheck(check, err)
+		return false
+	}
+
+	if data != nil {
+		if webhooks := s.subscribers(check.UserID); len(webhooks) > 0 {
+			s.enqueue(webhooks, check.UserID, check.Event, key, data)
+		}
+	}
+	return true
+}
+
+// retryCheck queues a failed check again with the backoff of deliveries, or
+// drops it once it failed as many times as a delivery is attempted
+func (s *Service) retryCheck(check *models.WebhookCheck, err error) {
+	logger := s.logger.With("user_id", check.UserID, "event", check.Event, "subject", check.Subject)
+
+	check.Attempts++
+	if check.Attempts >= deliveryMaxAttempts {
+		logger.Error("Webhook check failed, giving up", "error", err, "attempts", check.Attempts)
+		return
+	}
+
+	check.NextAttemptAt = time.Now().Add(backoff(check.Attempts))
+	logger.Warn("Webhook check failed, will retry", "error", err, "attempts", check.Attempts,
+		"next_attempt_at", check.NextAttemptAt)
+	if err := s.webhookRepo.QueueCheck(check); err != nil {
+		logger.Error("Failed to queue webhook check again", "error", err)
+	}
+}
+
+// goalReached returns the goal.reached data when the weigh-in is the latest one
+// and the first to reach the target weight. The event key makes sure a goal is
+// only announced once, even if the weight goes back and forth around it.
+func (s *Service) goalReached(userID, entryID int) (*GoalReachedData, string, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.TargetWeight == nil {
+		return nil, "", nil
+	}
+
+	weights, err := s.weightRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get weight history: %w", err)
+	}
+	// Weigh-ins recorded for an earlier day do not change the current weight
+	if len(weights) == 0 || weights[0].ID != entryID {
+		return nil, "", nil
+	}
+	entry := weights[0]
+	if !goalMet(user, entry.Weight) || (len(weights) > 1 && goalMet(user, weights[1].Weight)) {
+		return nil, "", nil
+	}
+
+	var goalSet int64
+	if user.GoalSetAt != nil {
+		goalSet = user.GoalSetAt.Unix()
+	}
+	data := &GoalReachedData{
+		TargetWeight:  *user.TargetWeight,
+		Weight:        entry.Weight,
+		InitialWeight: user.InitialWeightAtGoal,
+		GoalSetAt:     user.GoalSetAt,
+		RecordedAt:    entry.RecordedAt,
+	}
+	return data, fmt.Sprintf("%s:%d", models.WebhookGoalReached, goalSet), nil
+}
+
+// goalMet reports whether a weight reaches the user's target. Past the
+// target counts as well, in the direction the goal was set in.
+func goalMet(user *models.User, weight float64) bool {
+	target := *user.TargetWeight
+	if user.InitialWeightAtGoal != nil {
+		switch {
+		case *user.InitialWeightAtGoal > target:
+			return weight <= target+goalToleranceKg
+		case *user.InitialWeightAtGoal < target:
+			return weight >= target-goalToleranceKg
+		}
+	}
+	return math.Abs(weight-target) < goalToleranceKg
+}
+
+// dailyTargetExceeded returns the daily_target.exceeded data when the calories
+// of the date are over the user's daily target. Each day is announced once,
+// keyed by its date.
+func (s *Service) dailyTargetExceeded(userID int, date string) (*DailyTargetExceededData, string, error) {
+	targets, err := s.metricsService.GetDailyTargets(userID)
+	if err != nil {
+		if errors.Is(err, metricsservice.ErrInsufficientData) {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("failed to get daily targets: %w", err)
+	}
+
+	entries, err := s.calorieRepo.GetByUserIDAndDateRange(userID, date, date)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get calorie entries: %w", err)
+	}
+	total := 0
+	for _, e := range entries {
+		total += e.Calories
+	}
+	if float64(total) <= targets.Calories {
+		return nil, "", nil
+	}
+
+	data := &DailyTargetExceededData{
+		Date:           date,
+		Calories:       total,
+		TargetCalories: targets.Calories,
+	}
+	return data, fmt.Sprintf("%s:%s", models.WebhookDailyTargetExceeded, date), nil
+}
+
+// subscribers returns the user's enabled webhooks, logging failures
+func (s *Service) subscribers(userID int) []*models.Webhook {
+	webhooks, err := s.webhookRepo.GetEnabledByUserID(userID)
+	if err != nil {
+		s.logger.Error("Failed to get webhooks", "user_id", userID, "error", err)
+		return nil
+	}
+	return webhooks
+}
+
+// subscribed reports whether any of the webhooks receives the event
+func subscribed(webhooks []*models.Webhook, event string) bool {
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueue queues an event for every webhook subscribed to it. key, if set,
+// keeps a webhook from receiving the same event twice.
+func (s *Service) enqueue(webhooks []*models.Webhook, userID int, event, key string, data any) {
+	now := time.Now().UTC()
+	eventID, err := generateEventID()
+	if err != nil {
+		s.logger.Error("Failed to generate event ID", "error", err)
+		return
+	}
+	payload, err := json.Marshal(Event{ID: eventID, Type: event, CreatedAt: now, Data: data})
+	if err != nil {
+		s.logger.Error("Failed to encode webhook event", "event", event, "error", err)
+		return
+	}
+
+	var eventKey *string
+	if key != "" {
+		eventKey = &key
+	}
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event) {
+			continue
+		}
+		delivery := &models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			UserID:        userID,
+			Event:         event,
+			EventID:       eventID,
+			EventKey:      eventKey,
+			Payload:       string(payload),
+			NextAttemptAt: now,
+		}
+		created, err := s.webhookRepo.CreateDelivery(delivery)
+		if err != nil {
+			s.logger.Error("Failed to queue webhook delivery", "webhook_id", webhook.ID, "event", event, "error", err)
+			continue
+		}
+		if created {
+			s.logger.Debug("Webhook delivery queued", "webhook_id", webhook.ID, "event", event, "delivery_id", delivery.ID)
+		}
+	}
+}
+
+// generateEventID creates a random event ID
+func generateEventID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "evt_" + hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+)
+
+// fakeWebhookRepo hands out one check and keeps the checks queued again
+type fakeWebhookRepo struct {
+	repositories.WebhookRepository
+	check  *models.WebhookCheck
+	queued []*models.WebhookCheck
+}
+
+func (r *fakeWebhookRepo) TakeCheck(now time.Time) (*models.WebhookCheck, error) {
+	check := r.check
+	r.check = nil
+	return check, nil
+}
+
+func (r *fakeWebhookRepo) QueueCheck(check *models.WebhookCheck) error {
+	copied := *check
+	r.queued = append(r.queued, &copied)
+	return nil
+}
+
+// failingUserRepo cannot load users, so every goal check fails
+type failingUserRepo struct {
+	repositories.UserRepository
+}
+
+func (failingUserRepo) GetByID(int) (*models.User, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestFailedChecksAreRetriedWithBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int // Failed attempts before this one
+		retried  bool
+	}{
+		{"first failure", 0, true},
+		{"later failure", 4, true},
+		{"last attempt", deliveryMaxAttempts - 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeWebhookRepo{check: &models.WebhookCheck{
+				ID: 7, UserID: 1, Event: models.WebhookGoalReached, Subject: "42", Attempts: tt.attempts,
+			}}
+			svc := New(repo, failingUserRepo{}, nil, nil, nil, false, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			started := time.Now()
+			if svc.checkNext() {
+				t.Error("checkNext reported a failed check as done")
+			}
+
+			if !tt.retried {
+				if len(repo.queued) != 0 {
+					t.Errorf("check queued again after %d attempts: %+v", tt.attempts+1, repo.queued[0])
+				}
+				return
+			}
+			if len(repo.queued) != 1 {
+				t.Fatalf("check queued %d times, want once", len(repo.queued))
+			}
+			retry := repo.queued[0]
+			if retry.Attempts != tt.attempts+1 || retry.Subject != "42" {
+				t.Errorf("retry = %+v, want attempt %d of the same subject", retry, tt.attempts+1)
+			}
+			if wait := retry.NextAttemptAt.Sub(started); wait < backoff(retry.Attempts) {
+				t.Errorf("retried after %v, want at least %v", wait, backoff(retry.Attempts))
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package webhook
+
+import "ypeskov/kkal-tracker/internal/models"
+
+// Servicer defines the webhook service contract used by handlers.
+type Servicer interface {
+	List(userID int) ([]*models.Webhook, error)
+	Get(userID, webhookID int) (*models.Webhook, error)
+	Create(userID int, req *WebhookRequest) (*CreatedWebhook, error)
+	Update(userID, webhookID int, req *WebhookRequest) (*models.Webhook, error)
+	Delete(userID, webhookID int) error
+	RotateSecret(userID, webhookID int) (string, error)
+	Deliveries(userID, webhookID int) ([]*models.WebhookDelivery, error)
+}
+
+// Publisher is told about changes to a user's data and queues the webhook
+// events they cause. It never fails the change itself; problems are logged.
+type Publisher interface {
+	WeightCreated(entry *models.WeightHistory)
+	CalorieEntryCreated(entry *models.CalorieEntry)
+	CalorieEntryUpdated(entry *models.CalorieEntry)
+}
@@ -0,0 +1,244 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+	"ypeskov/kkal-tracker/internal/repositories"
+	metricsservice "ypeskov/kkal-tracker/internal/services/metrics"
+)
+
+// Webhook limits
+const (
+	maxWebhooksPerUser = 10
+	deliveryLogLimit   = 50 // Deliveries returned by Deliveries
+)
+
+// Service manages webhooks, queues events for them and delivers them
+type Service struct {
+	webhookRepo    repositories.WebhookRepository
+	userRepo       repositories.UserRepository
+	weightRepo     repositories.WeightHistoryRepository
+	calorieRepo    repositories.CalorieEntryRepository
+	metricsService metricsservice.Servicer
+	allowPrivate   bool // Whether endpoints may be on loopback or private networks
+	client         *http.Client
+	owner          string // Identifies this instance in delivery leases
+	logger         *slog.Logger
+}
+
+// New creates a new webhook service
+func New(
+	webhookRepo repositories.WebhookRepository,
+	userRepo repositories.UserRepository,
+	weightRepo repositories.WeightHistoryRepository,
+	calorieRepo repositories.CalorieEntryRepository,
+	metricsService metricsservice.Servicer,
+	allowPrivate bool,
+	logger *slog.Logger,
+) *Service {
+	s := &Service{
+		webhookRepo:    webhookRepo,
+		userRepo:       userRepo,
+		weightRepo:     weightRepo,
+		calorieRepo:    calorieRepo,
+		metricsService: metricsService,
+		allowPrivate:   allowPrivate,
+		owner:          leaseOwner(),
+		logger:         logger.With("service", "webhook"),
+	}
+	s.client = s.newClient()
+	return s
+}
+
+// List returns the user's webhooks
+func (s *Service) List(userID int) ([]*models.Webhook, error) {
+	s.logger.Debug("List called", "user_id", userID)
+	return s.webhookRepo.GetByUserID(userID)
+}
+
+// Get returns one of the user's webhooks
+func (s *Service) Get(userID, webhookID int) (*models.Webhook, error) {
+	s.logger.Debug("Get called", "user_id", userID, "webhook_id", webhookID)
+
+	webhook, err := s.webhookRepo.GetByID(webhookID, userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, err
+}
+
+// Create adds a webhook for the user with a new signing secret
+func (s *Service) Create(userID int, req *WebhookRequest) (*CreatedWebhook, error) {
+	s.logger.Debug("Create called", "user_id", userID, "events", req.Events)
+
+	existing, err := s.webhookRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count webhooks: %w", err)
+	}
+	if len(existing) >= maxWebhooksPerUser {
+		return nil, ErrTooManyWebhooks
+	}
+
+	webhook := &models.Webhook{UserID: userID}
+	if err := s.applyRequest(webhook, req); err != nil {
+		return nil, err
+	}
+	if webhook.Secret, err = generateSecret(); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	if err := s.webhookRepo.Create(webhook); err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	s.logger.Info("Webhook created", "user_id", userID, "webhook_id", webhook.ID)
+	return &CreatedWebhook{Webhook: webhook, Secret: webhook.Secret}, nil
+}
+
+// Update changes one of the user's webhooks
+func (s *Service) Update(userID, webhookID int, req *WebhookRequest) (*models.Webhook, error) {
+	s.logger.Debug("Update called", "user_id", userID, "webhook_id", webhookID)
+
+	webhook, err := s.Get(userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	wasEnabled := webhook.Enabled
+	if err := s.applyRequest(webhook, req); err != nil {
+		return nil, err
+	}
+
+	// Enabling a webhook gives it a fresh start; disabling it drops the queue
+	switch {
+	case webhook.Enabled && !wasEnabled:
+		webhook.FailureCount = 0
+		webhook.DisabledAt = nil
+	case !webhook.Enabled && wasEnabled:
+		now := time.Now()
+		webhook.DisabledAt = &now
+	}
+
+	if err := s.webhookRepo.Update(webhook); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	if !webhook.Enabled && wasEnabled {
+		if err := s.webhookRepo.FailPendingDeliveries(webhook.ID, "webhook disabled"); err != nil {
+			s.logger.Error("Failed to drop queued deliveries", "webhook_id", webhook.ID, "error", err)
+		}
+	}
+
+	return webhook, nil
+}
+
+// Delete removes one of the user's webhooks and its delivery log
+func (s *Service) Delete(userID, webhookID int) error {
+	s.logger.Debug("Delete called", "user_id", userID, "webhook_id", webhookID)
+
+	if err := s.webhookRepo.Delete(webhookID, userID); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return ErrWebhookNotFound
+		}
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	s.logger.Info("Webhook deleted", "user_id", userID, "webhook_id", webhookID)
+	return nil
+}
+
+// RotateSecret gives one of the user's webhooks a new signing secret and returns it
+func (s *Service) RotateSecret(userID, webhookID int) (string, error) {
+	s.logger.Debug("RotateSecret called", "user_id", userID, "webhook_id", webhookID)
+
+	secret, err := generateSecret()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	if err := s.webhookRepo.UpdateSecret(webhookID, userID, secret); err != nil {
+		if errors.Is(err, repositories.ErrNotFound) {
+			return "", ErrWebhookNotFound
+		}
+		return "", fmt.Errorf("failed to update webhook secret: %w", err)
+	}
+
+	s.logger.Info("Webhook secret rotated", "user_id", userID, "webhook_id", webhookID)
+	return secret, nil
+}
+
+// Deliveries returns the latest deliveries of one of the user's webhooks
+func (s *Service) Deliveries(userID, webhookID int) ([]*models.WebhookDelivery, error) {
+	s.logger.Debug("Deliveries called", "user_id", userID, "webhook_id", webhookID)
+
+	if _, err := s.Get(userID, webhookID); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.GetDeliveries(webhookID, userID, deliveryLogLimit)
+}
+
+// applyRequest validates the request and copies it onto the webhook
+func (s *Service) applyRequest(webhook *models.Webhook, req *WebhookRequest) error {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Hostname() == "" {
+		return ErrInvalidURL
+	}
+	if !s.allowPrivate {
+		host := target.Hostname()
+		if host == "localhost" {
+			return ErrPrivateURL
+		}
+		if ip := net.ParseIP(host); ip != nil && isPrivateIP(ip) {
+			return ErrPrivateURL
+		}
+	}
+
+	if len(req.Events) == 0 {
+		return ErrNoEvents
+	}
+	events := make([]string, 0, len(req.Events))
+	for _, event := range req.Events {
+		if !slices.Contains(models.WebhookEvents, event) {
+			return fmt.Errorf("%w: %s", ErrInvalidEvent, event)
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+
+	webhook.URL = target.String()
+	webhook.Events = events
+	webhook.Enabled = req.Enabled
+	return nil
+}
+
+// generateSecret creates a random signing secret
+func generateSecret() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(bytes), nil
+}
+
+// leaseOwner returns an identifier for this instance, unique per process
+func leaseOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "server"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return host + "-" + hex.EncodeToString(suffix)
+}
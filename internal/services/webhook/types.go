@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"time"
+
+	"ypeskov/kkal-tracker/internal/models"
+)
+
+// WebhookRequest contains the fields a user sets on a webhook
+type WebhookRequest struct {
+	URL     string
+	Events  []string // Values of models.WebhookEvents
+	Enabled bool     // Enabling a webhook also resets its failure count
+}
+
+// CreatedWebhook is returned when a webhook is created; the secret is only
+// shown then and when it is rotated
+type CreatedWebhook struct {
+	*models.Webhook
+	Secret string `json:"secret"`
+}
+
+// Event is the JSON body POSTed to webhook endpoints
+type Event struct {
+	ID        string    `json:"id"` // The same for every endpoint receiving the event
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// GoalReachedData is the data of goal.reached events
+type GoalReachedData struct {
+	TargetWeight  float64    `json:"target_weight"`
+	Weight        float64    `json:"weight"`
+	InitialWeight *float64   `json:"initial_weight,omitempty"`
+	GoalSetAt     *time.Time `json:"goal_set_at,omitempty"`
+	RecordedAt    time.Time  `json:"recorded_at"`
+}
+
+// DailyTargetExceededData is the data of daily_target.exceeded events
+type DailyTargetExceededData struct {
+	Date           string  `json:"date"`
+	Calories       int     `json:"calories"`        // Calories logged for the day
+	TargetCalories float64 `json:"target_calories"` // Daily target from the user's profile
+}
@@ -11,6 +11,7 @@ type Servicer interface {
 	GetWeightHistory(userID int) ([]*models.WeightHistory, error)
 	GetWeightHistoryByDateRange(userID int, dateFrom, dateTo string) ([]*models.WeightHistory, error)
 	CreateWeightEntry(userID int, weight float64, recordedAt *time.Time) (*models.WeightHistory, error)
-	UpdateWeightEntry(id, userID int, weight float64, recordedAt *time.Time) (*models.WeightHistory, error)
-	DeleteWeightEntry(id, userID int) error
+	GetWeightEntry(id, userID int) (*models.WeightHistory, error)
+	UpdateWeightEntry(id, userID int, weight float64, recordedAt *time.Time, version int) (*models.WeightHistory, error)
+	DeleteWeightEntry(id, userID, version int) error
 }
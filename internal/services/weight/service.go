@@ -6,16 +6,19 @@ import (
 
 	"ypeskov/kkal-tracker/internal/models"
 	"ypeskov/kkal-tracker/internal/repositories"
+	webhookservice "ypeskov/kkal-tracker/internal/services/webhook"
 )
 
 type Service struct {
 	weightRepo repositories.WeightHistoryRepository
+	events     webhookservice.Publisher
 	logger     *slog.Logger
 }
 
-func New(weightRepo repositories.WeightHistoryRepository, logger *slog.Logger) *Service {
+func New(weightRepo repositories.WeightHistoryRepository, events webhookservice.Publisher, logger *slog.Logger) *Service {
 	return &Service{
 		weightRepo: weightRepo,
+		events:     events,
 		logger:     logger.With("service", "weight"),
 	}
 }
@@ -56,24 +59,41 @@ func (s *Service) CreateWeightEntry(userID int, weight float64, recordedAt *time
 		"user_id", userID,
 		"weight", weight)
 
-	return s.weightRepo.Create(userID, weight, recordedAt)
+	entry, err := s.weightRepo.Create(userID, weight, recordedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	s.events.WeightCreated(entry)
+	return entry, nil
+}
+
+// GetWeightEntry returns a weight entry of the user
+func (s *Service) GetWeightEntry(id, userID int) (*models.WeightHistory, error) {
+	s.logger.Debug("GetWeightEntry called",
+		"id", id,
+		"user_id", userID)
+
+	return s.weightRepo.GetByID(id, userID)
 }
 
-// UpdateWeightEntry updates an existing weight entry
-func (s *Service) UpdateWeightEntry(id, userID int, weight float64, recordedAt *time.Time) (*models.WeightHistory, error) {
+// UpdateWeightEntry updates an existing weight entry. A non-zero version is the
+// version the entry must have, otherwise repositories.ErrVersionConflict is returned.
+func (s *Service) UpdateWeightEntry(id, userID int, weight float64, recordedAt *time.Time, version int) (*models.WeightHistory, error) {
 	s.logger.Debug("UpdateWeightEntry called",
 		"id", id,
 		"user_id", userID,
 		"weight", weight)
 
-	return s.weightRepo.Update(id, userID, weight, recordedAt)
+	return s.weightRepo.Update(id, userID, weight, recordedAt, version)
 }
 
-// DeleteWeightEntry deletes a weight entry
-func (s *Service) DeleteWeightEntry(id, userID int) error {
+// DeleteWeightEntry deletes a weight entry. A non-zero version is the version
+// the entry must have, otherwise repositories.ErrVersionConflict is returned.
+func (s *Service) DeleteWeightEntry(id, userID, version int) error {
 	s.logger.Debug("DeleteWeightEntry called",
 		"id", id,
 		"user_id", userID)
 
-	return s.weightRepo.Delete(id, userID)
+	return s.weightRepo.Delete(id, userID, version)
 }
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE meal_plans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    days INTEGER NOT NULL,
+    target_calories REAL NOT NULL,
+    target_proteins REAL,
+    target_fats REAL,
+    target_carbs REAL,
+    notes TEXT,
+    model TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_meal_plans_user_id ON meal_plans(user_id);
+
+CREATE TABLE meal_plan_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    plan_id INTEGER NOT NULL,
+    day_number INTEGER NOT NULL,
+    meal_type TEXT NOT NULL,
+    ingredient_id INTEGER,
+    food TEXT NOT NULL,
+    weight REAL NOT NULL,
+    kcal_per_100g REAL NOT NULL,
+    calories INTEGER NOT NULL,
+    fats REAL,
+    carbs REAL,
+    proteins REAL,
+    FOREIGN KEY (plan_id) REFERENCES meal_plans(id) ON DELETE CASCADE,
+    FOREIGN KEY (ingredient_id) REFERENCES user_ingredients(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_meal_plan_items_plan_id ON meal_plan_items(plan_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_meal_plan_items_plan_id;
+DROP TABLE IF EXISTS meal_plan_items;
+DROP INDEX IF EXISTS idx_meal_plans_user_id;
+DROP TABLE IF EXISTS meal_plans;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Add role field to users table
+ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK(role IN ('user', 'admin'));
+
+-- Audit trail of admin changes to shared data
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_user_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER,
+    changes TEXT,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (actor_user_id) REFERENCES users(id)
+);
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_audit_log_created_at;
+DROP INDEX IF EXISTS idx_audit_log_entity;
+DROP TABLE IF EXISTS audit_log;
+ALTER TABLE users DROP COLUMN role;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Global ingredients get a version that is bumped on every admin update
+ALTER TABLE global_ingredients ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- User copies remember the global version and values they were last synced to,
+-- so unmodified copies can be told apart from ones the user customized
+ALTER TABLE user_ingredients ADD COLUMN global_version INTEGER;
+ALTER TABLE user_ingredients ADD COLUMN synced_name TEXT;
+ALTER TABLE user_ingredients ADD COLUMN synced_kcal_per_100g REAL;
+ALTER TABLE user_ingredients ADD COLUMN synced_fats REAL;
+ALTER TABLE user_ingredients ADD COLUMN synced_carbs REAL;
+ALTER TABLE user_ingredients ADD COLUMN synced_proteins REAL;
+
+-- Existing copies are assumed to have been synced to the current global values
+-- under the name they were copied with
+UPDATE user_ingredients
+SET global_version = 1,
+    synced_name = name,
+    synced_kcal_per_100g = (SELECT gi.kcal_per_100g FROM global_ingredients gi WHERE gi.id = user_ingredients.global_ingredient_id),
+    synced_fats = (SELECT gi.fats FROM global_ingredients gi WHERE gi.id = user_ingredients.global_ingredient_id),
+    synced_carbs = (SELECT gi.carbs FROM global_ingredients gi WHERE gi.id = user_ingredients.global_ingredient_id),
+    synced_proteins = (SELECT gi.proteins FROM global_ingredients gi WHERE gi.id = user_ingredients.global_ingredient_id)
+WHERE global_ingredient_id IS NOT NULL;
+
+CREATE INDEX idx_user_ingredients_global ON user_ingredients(global_ingredient_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_user_ingredients_global;
+ALTER TABLE user_ingredients DROP COLUMN synced_proteins;
+ALTER TABLE user_ingredients DROP COLUMN synced_carbs;
+ALTER TABLE user_ingredients DROP COLUMN synced_fats;
+ALTER TABLE user_ingredients DROP COLUMN synced_kcal_per_100g;
+ALTER TABLE user_ingredients DROP COLUMN synced_name;
+ALTER TABLE user_ingredients DROP COLUMN global_version;
+ALTER TABLE global_ingredients DROP COLUMN version;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Named servings of an ingredient, e.g. "egg" = 50 g
+CREATE TABLE ingredient_servings (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    ingredient_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    grams REAL NOT NULL CHECK (grams > 0),
+    created_at DATETIME DEFAULT (datetime('now')),
+    FOREIGN KEY (ingredient_id) REFERENCES user_ingredients(id) ON DELETE CASCADE,
+    UNIQUE (ingredient_id, name)
+);
+CREATE INDEX idx_ingredient_servings_ingredient_id ON ingredient_servings(ingredient_id);
+
+-- Density converts milliliter-based units of liquids to grams
+ALTER TABLE user_ingredients ADD COLUMN density_g_per_ml REAL;
+
+-- Calorie entries keep the quantity and unit they were logged with; weight stays in grams
+ALTER TABLE calorie_entries ADD COLUMN quantity REAL;
+ALTER TABLE calorie_entries ADD COLUMN unit TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE calorie_entries DROP COLUMN unit;
+ALTER TABLE calorie_entries DROP COLUMN quantity;
+ALTER TABLE user_ingredients DROP COLUMN density_g_per_ml;
+DROP INDEX IF EXISTS idx_ingredient_servings_ingredient_id;
+DROP TABLE IF EXISTS ingredient_servings;
+-- +goose StatementEnd
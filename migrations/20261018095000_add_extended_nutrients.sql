@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Extended nutrients (fiber, sugars, sodium, vitamins, ...) per 100 g as a JSON object
+-- keyed by nutrient code; NULL or a missing key means the value is unknown.
+-- TEXT keeps the column portable between SQLite and PostgreSQL.
+ALTER TABLE global_ingredients ADD COLUMN nutrients TEXT;
+ALTER TABLE user_ingredients ADD COLUMN nutrients TEXT;
+ALTER TABLE user_ingredients ADD COLUMN synced_nutrients TEXT;
+
+-- Calorie entries snapshot the nutrients at the time of logging
+ALTER TABLE calorie_entries ADD COLUMN nutrients TEXT;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE calorie_entries DROP COLUMN nutrients;
+ALTER TABLE user_ingredients DROP COLUMN synced_nutrients;
+ALTER TABLE user_ingredients DROP COLUMN nutrients;
+ALTER TABLE global_ingredients DROP COLUMN nutrients;
+-- +goose StatementEnd
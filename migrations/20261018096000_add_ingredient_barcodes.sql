@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Barcodes (EAN-13/EAN-8/GTIN-14, normalized to digits) for looking up packaged foods.
+-- Products imported from Open Food Facts are catalog-only: they are found by barcode
+-- but not copied to every user like the curated catalog.
+ALTER TABLE global_ingredients ADD COLUMN barcode TEXT;
+ALTER TABLE global_ingredients ADD COLUMN source TEXT NOT NULL DEFAULT 'manual';
+ALTER TABLE user_ingredients ADD COLUMN barcode TEXT;
+
+CREATE UNIQUE INDEX idx_global_ingredients_barcode ON global_ingredients (barcode) WHERE barcode IS NOT NULL;
+CREATE UNIQUE INDEX idx_user_ingredients_barcode ON user_ingredients (user_id, barcode) WHERE barcode IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_user_ingredients_barcode;
+DROP INDEX IF EXISTS idx_global_ingredients_barcode;
+ALTER TABLE user_ingredients DROP COLUMN barcode;
+ALTER TABLE global_ingredients DROP COLUMN source;
+ALTER TABLE global_ingredients DROP COLUMN barcode;
+-- +goose StatementEnd
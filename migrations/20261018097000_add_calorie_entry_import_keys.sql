@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Entries imported from other apps remember a fingerprint of their source row,
+-- so importing the same file again skips rows that are already in the diary.
+ALTER TABLE calorie_entries ADD COLUMN import_key TEXT;
+
+CREATE UNIQUE INDEX idx_calorie_entries_import_key ON calorie_entries (user_id, import_key) WHERE import_key IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_calorie_entries_import_key;
+ALTER TABLE calorie_entries DROP COLUMN import_key;
+-- +goose StatementEnd
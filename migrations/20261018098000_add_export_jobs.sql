@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Exports queued for a background worker. Finished files are kept in the row,
+-- so any instance can serve the download, until they expire.
+CREATE TABLE export_jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    date_from TEXT NOT NULL,
+    date_to TEXT NOT NULL,
+    data_type TEXT NOT NULL,
+    format TEXT NOT NULL,
+    delivery_type TEXT NOT NULL,
+    language TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    file_name TEXT,
+    content_type TEXT,
+    file_size INTEGER NOT NULL DEFAULT 0,
+    file_data BLOB,
+    error TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    started_at DATETIME,
+    completed_at DATETIME,
+    expires_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_export_jobs_user ON export_jobs(user_id, created_at);
+CREATE INDEX idx_export_jobs_status ON export_jobs(status, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_export_jobs_status;
+DROP INDEX IF EXISTS idx_export_jobs_user;
+DROP TABLE IF EXISTS export_jobs;
+-- +goose StatementEnd
@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Recurring exports and digest emails. A server instance takes a lease on a
+-- due schedule before running it, so each occurrence is sent once even with
+-- several replicas. The unsubscribe token lets users turn the email off from
+-- a link without logging in.
+CREATE TABLE export_schedules (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    frequency TEXT NOT NULL,
+    day_of_week INTEGER,
+    day_of_month INTEGER,
+    hour INTEGER NOT NULL DEFAULT 8,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    data_type TEXT NOT NULL DEFAULT 'both',
+    format TEXT NOT NULL DEFAULT 'xlsx',
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    unsubscribe_token TEXT NOT NULL,
+    next_run_at DATETIME NOT NULL,
+    last_run_at DATETIME,
+    lease_owner TEXT,
+    lease_until DATETIME,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_export_schedules_user ON export_schedules(user_id);
+CREATE INDEX idx_export_schedules_due ON export_schedules(enabled, next_run_at);
+CREATE UNIQUE INDEX idx_export_schedules_unsubscribe ON export_schedules(unsubscribe_token);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_export_schedules_unsubscribe;
+DROP INDEX IF EXISTS idx_export_schedules_due;
+DROP INDEX IF EXISTS idx_export_schedules_user;
+DROP TABLE IF EXISTS export_schedules;
+-- +goose StatementEnd
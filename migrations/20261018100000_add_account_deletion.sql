@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Accounts are purged once this time has passed; users can cancel until then
+ALTER TABLE users ADD COLUMN deletion_scheduled_at DATETIME;
+CREATE INDEX idx_users_deletion_scheduled_at ON users(deletion_scheduled_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_users_deletion_scheduled_at;
+ALTER TABLE users DROP COLUMN deletion_scheduled_at;
+-- +goose StatementEnd
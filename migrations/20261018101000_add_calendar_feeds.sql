@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- One iCalendar feed per user. Calendar apps cannot log in, so the feed URL
+-- carries a token; only its hash is stored, and replacing or deleting the row
+-- revokes the old URL.
+CREATE TABLE calendar_feeds (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    token_hash TEXT NOT NULL,
+    token_prefix TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    past_days INTEGER NOT NULL DEFAULT 30,
+    last_accessed_at DATETIME,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_calendar_feeds_user ON calendar_feeds(user_id);
+CREATE UNIQUE INDEX idx_calendar_feeds_token ON calendar_feeds(token_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_calendar_feeds_token;
+DROP INDEX IF EXISTS idx_calendar_feeds_user;
+DROP TABLE IF EXISTS calendar_feeds;
+-- +goose StatementEnd
@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Endpoints users register to be told about changes to their data. The secret
+-- signs the payloads, so it is kept as is. Consecutive failed deliveries are
+-- counted, and the endpoint is disabled once there are too many of them.
+CREATE TABLE webhooks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT 1,
+    failure_count INTEGER NOT NULL DEFAULT 0,
+    disabled_at DATETIME,
+    last_success_at DATETIME,
+    last_failure_at DATETIME,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_webhooks_user ON webhooks(user_id);
+
+-- Queue and log of deliveries, one row per event and endpoint. A server
+-- instance leases a due delivery while sending it; failed attempts are
+-- retried with exponential backoff. event_key marks events that must reach an
+-- endpoint only once, such as a goal being reached.
+CREATE TABLE webhook_deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    event_key TEXT,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at DATETIME NOT NULL,
+    lease_owner TEXT,
+    lease_until DATETIME,
+    response_status INTEGER,
+    error TEXT,
+    created_at DATETIME NOT NULL,
+    completed_at DATETIME,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries(webhook_id, created_at);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(status, next_attempt_at);
+CREATE UNIQUE INDEX idx_webhook_deliveries_event_key ON webhook_deliveries(webhook_id, event_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_webhook_deliveries_event_key;
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP INDEX IF EXISTS idx_webhooks_user;
+DROP TABLE IF EXISTS webhooks;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every write to a user's calorie entries, weigh-ins or ingredients takes the
+-- next value of this counter, so versions only grow within an account
+ALTER TABLE users ADD COLUMN sync_version INTEGER NOT NULL DEFAULT 0;
+
+-- Change feed for offline clients: the latest version of each entity the user
+-- has, or had. A deleted entity keeps its row as a tombstone until it is
+-- cleaned up; the entity itself is read from its own table.
+CREATE TABLE sync_changes (
+    user_id INTEGER NOT NULL,
+    entity TEXT NOT NULL,
+    entity_id INTEGER NOT NULL,
+    version INTEGER NOT NULL,
+    deleted BOOLEAN NOT NULL DEFAULT 0,
+    changed_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, entity, entity_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_sync_changes_user_version ON sync_changes(user_id, version);
+CREATE INDEX idx_sync_changes_tombstones ON sync_changes(deleted, changed_at);
+
+-- Existing entities get versions in the order they were created
+INSERT INTO sync_changes (user_id, entity, entity_id, version, deleted, changed_at)
+SELECT user_id, entity, entity_id, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY created_at, entity, entity_id), 0, CURRENT_TIMESTAMP
+FROM (
+    SELECT user_id, 'calorie_entry' AS entity, id AS entity_id, created_at FROM calorie_entries
+    UNION ALL
+    SELECT user_id, 'weight', id, created_at FROM weight_history
+    UNION ALL
+    SELECT user_id, 'ingredient', id, created_at FROM user_ingredients
+);
+
+UPDATE users
+SET sync_version = (SELECT COALESCE(MAX(version), 0) FROM sync_changes WHERE sync_changes.user_id = users.id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_sync_changes_tombstones;
+DROP INDEX IF EXISTS idx_sync_changes_user_version;
+DROP TABLE IF EXISTS sync_changes;
+ALTER TABLE users DROP COLUMN sync_version;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Responses to mutating requests sent with an Idempotency-Key header, so a
+-- retried request gets the first response instead of being applied again.
+-- request_hash identifies the request the key was first used with, and
+-- status_code stays 0 while that request is still being processed.
+CREATE TABLE idempotency_keys (
+    user_id INTEGER NOT NULL,
+    idempotency_key TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    response_headers TEXT,
+    response_body BLOB,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_idempotency_keys_expires ON idempotency_keys(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_idempotency_keys_expires;
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
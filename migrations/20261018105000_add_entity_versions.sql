@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Row versions for optimistic concurrency: every update increments them, and
+-- clients send the version they edited in If-Match so a concurrent change is
+-- detected instead of being overwritten
+ALTER TABLE calorie_entries ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE weight_history ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- Covers the fields of the profile and the weight goal
+ALTER TABLE users ADD COLUMN profile_version INTEGER NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN profile_version;
+ALTER TABLE weight_history DROP COLUMN version;
+ALTER TABLE calorie_entries DROP COLUMN version;
+-- +goose StatementEnd
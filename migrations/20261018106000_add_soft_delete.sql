@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Deleted calorie entries and weigh-ins go to the trash first: they keep their
+-- row with the time they were deleted, can be restored, and are purged later
+ALTER TABLE calorie_entries ADD COLUMN deleted_at DATETIME;
+ALTER TABLE weight_history ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX idx_calorie_entries_deleted_at ON calorie_entries(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_weight_history_deleted_at ON weight_history(deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_weight_history_deleted_at;
+DROP INDEX IF EXISTS idx_calorie_entries_deleted_at;
+ALTER TABLE weight_history DROP COLUMN deleted_at;
+ALTER TABLE calorie_entries DROP COLUMN deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Events that depend on totals, such as a day going over the calorie target,
+-- are not worked out while the change is saved. The change queues a check
+-- here, and the webhook worker computes it and queues the deliveries. Repeated
+-- checks of the same subject (a date, or a weigh-in ID) are merged.
+CREATE TABLE webhook_checks (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_webhook_checks_subject ON webhook_checks(user_id, event, subject);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_webhook_checks_subject;
+DROP TABLE IF EXISTS webhook_checks;
+-- +goose StatementEnd
@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The audit trail outlives the admins in it: when an account is deleted, its
+-- entries are kept with actor_user_id set to NULL. SQLite cannot drop NOT NULL
+-- from a column, so the table is recreated.
+CREATE TABLE audit_log_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_user_id INTEGER,
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER,
+    changes TEXT,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (actor_user_id) REFERENCES users(id)
+);
+
+INSERT INTO audit_log_new (id, actor_user_id, action, entity_type, entity_id, changes, created_at)
+SELECT id, actor_user_id, action, entity_type, entity_id, changes, created_at FROM audit_log;
+
+DROP INDEX IF EXISTS idx_audit_log_created_at;
+DROP INDEX IF EXISTS idx_audit_log_entity;
+DROP TABLE audit_log;
+
+ALTER TABLE audit_log_new RENAME TO audit_log;
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Entries of deleted admins cannot be kept without an actor
+CREATE TABLE audit_log_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_user_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id INTEGER,
+    changes TEXT,
+    created_at DATETIME NOT NULL,
+    FOREIGN KEY (actor_user_id) REFERENCES users(id)
+);
+
+INSERT INTO audit_log_old (id, actor_user_id, action, entity_type, entity_id, changes, created_at)
+SELECT id, actor_user_id, action, entity_type, entity_id, changes, created_at FROM audit_log
+WHERE actor_user_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_audit_log_created_at;
+DROP INDEX IF EXISTS idx_audit_log_entity;
+DROP TABLE audit_log;
+
+ALTER TABLE audit_log_old RENAME TO audit_log;
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A check that fails is retried with backoff, like a delivery, and dropped
+-- after as many attempts. Checks already queued are due right away.
+ALTER TABLE webhook_checks ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE webhook_checks ADD COLUMN next_attempt_at DATETIME;
+UPDATE webhook_checks SET next_attempt_at = created_at;
+CREATE INDEX idx_webhook_checks_due ON webhook_checks(next_attempt_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_webhook_checks_due;
+ALTER TABLE webhook_checks DROP COLUMN next_attempt_at;
+ALTER TABLE webhook_checks DROP COLUMN attempts;
+-- +goose StatementEnd
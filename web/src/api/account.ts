@@ -0,0 +1,68 @@
+export interface AccountDeletion {
+  deletion_scheduled_at: string | null;
+}
+
+class AccountService {
+  private getHeaders() {
+    const token = sessionStorage.getItem('token');
+    return {
+      'Content-Type': 'application/json',
+      ...(token && { Authorization: `Bearer ${token}` }),
+    };
+  }
+
+  downloadArchive = async (): Promise<Blob> => {
+    const response = await fetch('/api/account/archive', {
+      headers: this.getHeaders(),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to create account archive' }));
+      throw new Error(error.message || 'Failed to create account archive');
+    }
+
+    return response.blob();
+  };
+
+  getDeletion = async (): Promise<AccountDeletion> => {
+    const response = await fetch('/api/account/deletion', {
+      headers: this.getHeaders(),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to get account deletion' }));
+      throw new Error(error.message || 'Failed to get account deletion');
+    }
+
+    return response.json();
+  };
+
+  requestDeletion = async (password: string): Promise<AccountDeletion> => {
+    const response = await fetch('/api/account/deletion', {
+      method: 'POST',
+      headers: this.getHeaders(),
+      body: JSON.stringify({ password }),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to request account deletion' }));
+      throw new Error(error.message || 'Failed to request account deletion');
+    }
+
+    return response.json();
+  };
+
+  cancelDeletion = async (): Promise<void> => {
+    const response = await fetch('/api/account/deletion', {
+      method: 'DELETE',
+      headers: this.getHeaders(),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to cancel account deletion' }));
+      throw new Error(error.message || 'Failed to cancel account deletion');
+    }
+  };
+}
+
+export const accountService = new AccountService();
@@ -11,11 +11,36 @@ export interface AnalyzeRequest {
   period_days: number;
 }
 
+export interface AnalysisFinding {
+  title: string;
+  detail: string;
+  dates?: string[];
+  foods?: string[];
+}
+
+export type RecommendationPriority = 'high' | 'medium' | 'low';
+
+export interface AnalysisRecommendation {
+  action: string;
+  rationale?: string;
+  priority: RecommendationPriority;
+}
+
+export interface AnalysisRiskFlag {
+  code: string;
+  description: string;
+}
+
 export interface AnalysisResult {
-  analysis: string;
+  summary: string;
+  findings: AnalysisFinding[];
+  recommendations: AnalysisRecommendation[];
+  risk_flags: AnalysisRiskFlag[];
   model: string;
   tokens_used?: number;
   duration_ms: number;
+  cached: boolean;
+  generated_at: string;
 }
 
 class AIService {
@@ -0,0 +1,89 @@
+export interface CalendarFeed {
+  id: number;
+  token_prefix: string;
+  timezone: string;
+  past_days: number;
+  last_accessed_at?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface CreatedCalendarFeed extends CalendarFeed {
+  url: string;
+}
+
+export interface CalendarFeedRequest {
+  timezone: string;
+  past_days: number;
+}
+
+class CalendarService {
+  private getHeaders() {
+    const token = sessionStorage.getItem('token');
+    return {
+      'Content-Type': 'application/json',
+      ...(token && { Authorization: `Bearer ${token}` }),
+    };
+  }
+
+  // Returns null when the user has no feed
+  getFeed = async (): Promise<CalendarFeed | null> => {
+    const response = await fetch('/api/calendar/feed', {
+      headers: this.getHeaders(),
+    });
+
+    if (response.status === 404) {
+      return null;
+    }
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to get calendar feed' }));
+      throw new Error(error.message || 'Failed to get calendar feed');
+    }
+
+    return response.json();
+  };
+
+  createFeed = async (data: CalendarFeedRequest): Promise<CreatedCalendarFeed> => {
+    const response = await fetch('/api/calendar/feed', {
+      method: 'POST',
+      headers: this.getHeaders(),
+      body: JSON.stringify(data),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to create calendar feed' }));
+      throw new Error(error.message || 'Failed to create calendar feed');
+    }
+
+    return response.json();
+  };
+
+  updateFeed = async (data: CalendarFeedRequest): Promise<CalendarFeed> => {
+    const response = await fetch('/api/calendar/feed', {
+      method: 'PUT',
+      headers: this.getHeaders(),
+      body: JSON.stringify(data),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to update calendar feed' }));
+      throw new Error(error.message || 'Failed to update calendar feed');
+    }
+
+    return response.json();
+  };
+
+  deleteFeed = async (): Promise<void> => {
+    const response = await fetch('/api/calendar/feed', {
+      method: 'DELETE',
+      headers: this.getHeaders(),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to revoke calendar feed' }));
+      throw new Error(error.message || 'Failed to revoke calendar feed');
+    }
+  };
+}
+
+export const calendarService = new CalendarService();
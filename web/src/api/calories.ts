@@ -1,4 +1,4 @@
-import { ingredientService } from './ingredients'
+import { ingredientService, type Nutrients } from './ingredients'
 
 interface CalorieEntry {
   id?: number
@@ -8,8 +8,13 @@ interface CalorieEntry {
   fats?: number
   carbs?: number
   proteins?: number
+  // Snapshot of extended nutrients per 100 g; the ingredient's values are used when omitted
+  nutrients?: Nutrients
   calories: number
   meal_datetime: string
+  // Quantity in a serving or unit (e.g. 2 "egg"); weight and calories are then computed by the server
+  quantity?: number
+  unit?: string
 }
 
 interface CreateEntryResult {
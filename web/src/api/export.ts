@@ -1,17 +1,44 @@
 export type ExportDataType = 'weight' | 'food' | 'both';
 export type DeliveryType = 'download' | 'email';
+export type ExportFormat = 'xlsx' | 'csv' | 'ndjson' | 'pdf';
 
 export interface ExportRequest {
   date_from: string;
   date_to: string;
   data_type: ExportDataType;
+  format?: ExportFormat; // Defaults to xlsx
   delivery_type: DeliveryType;
 }
 
+export type ExportJobStatus = 'pending' | 'running' | 'completed' | 'failed' | 'expired';
+
+export interface ExportJob {
+  id: number;
+  status: ExportJobStatus;
+  date_from: string;
+  date_to: string;
+  data_type: ExportDataType;
+  format: ExportFormat;
+  delivery_type: DeliveryType;
+  file_name?: string;
+  content_type?: string;
+  file_size: number;
+  error?: string;
+  attempts: number;
+  created_at: string;
+  started_at?: string;
+  completed_at?: string;
+  expires_at?: string; // Download window of finished files
+}
+
 export interface ExportEmailResponse {
   message: string;
+  job: ExportJob; // Email is sent by a background job
 }
 
+// How often a queued export is checked while waiting for it
+const JOB_POLL_INTERVAL_MS = 2000;
+
 class ExportService {
   private getHeaders() {
     const token = sessionStorage.getItem('token');
@@ -35,12 +62,68 @@ class ExportService {
 
     // Check if response is file (download) or JSON (email)
     const contentType = response.headers.get('Content-Type');
-    if (contentType?.includes('application/vnd.openxmlformats-officedocument.spreadsheetml.sheet')) {
-      return response.blob();
+    if (contentType?.includes('application/json')) {
+      return response.json();
+    }
+    return response.blob();
+  }
+
+  // Queue an export to be generated in the background
+  async createJob(request: ExportRequest): Promise<ExportJob> {
+    const response = await fetch('/api/export/jobs', {
+      method: 'POST',
+      headers: this.getHeaders(),
+      body: JSON.stringify(request),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Export failed' }));
+      throw new Error(error.message || 'Export failed');
     }
     return response.json();
   }
 
+  async getJob(id: number): Promise<ExportJob> {
+    const response = await fetch(`/api/export/jobs/${id}`, { headers: this.getHeaders() });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Export failed' }));
+      throw new Error(error.message || 'Export failed');
+    }
+    return response.json();
+  }
+
+  async downloadJob(id: number): Promise<Blob> {
+    const response = await fetch(`/api/export/jobs/${id}/download`, { headers: this.getHeaders() });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Export failed' }));
+      throw new Error(error.message || 'Export failed');
+    }
+    return response.blob();
+  }
+
+  // Poll a queued export until it is finished
+  async waitForJob(id: number): Promise<ExportJob> {
+    for (;;) {
+      const job = await this.getJob(id);
+      if (job.status === 'completed') {
+        return job;
+      }
+      if (job.status === 'failed' || job.status === 'expired') {
+        throw new Error(job.error || 'Export failed');
+      }
+      await new Promise((resolve) => setTimeout(resolve, JOB_POLL_INTERVAL_MS));
+    }
+  }
+
+  // File name the server uses for an export; CSV exports of both data types are zipped
+  fileName(request: ExportRequest): string {
+    const format = request.format ?? 'xlsx';
+    const extension = format === 'csv' && request.data_type === 'both' ? 'zip' : format;
+    return `kkal-export-${request.date_from}-to-${request.date_to}.${extension}`;
+  }
+
   // Helper to trigger download from blob
   downloadBlob(blob: Blob, filename: string) {
     const url = window.URL.createObjectURL(blob);
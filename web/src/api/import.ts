@@ -0,0 +1,96 @@
+export type ImportFormat = 'myfitnesspal' | 'cronometer' | 'loseit' | 'excel';
+
+export type ImportRowStatus = 'new' | 'duplicate' | 'conflict';
+
+export interface ImportOptions {
+  format?: ImportFormat; // Detected from the header when omitted
+  timezone?: string; // IANA name, defaults to the browser's time zone
+  dryRun?: boolean;
+}
+
+export interface ImportEntryPreview {
+  line: number;
+  status: ImportRowStatus;
+  food: string;
+  calories: number;
+  weight: number;
+  quantity?: number;
+  unit?: string;
+  fats?: number;
+  carbs?: number;
+  proteins?: number;
+  meal_datetime: string;
+  conflict_with?: number; // ID of the existing entry that differs
+}
+
+export interface ImportWeightPreview {
+  line: number;
+  status: ImportRowStatus;
+  weight: number;
+  recorded_at: string;
+  conflict_with?: number;
+}
+
+export interface ImportWeightResult {
+  rows: number;
+  created: number;
+  duplicates: number;
+  conflicts: number;
+  entries: ImportWeightPreview[];
+}
+
+export interface ImportRowError {
+  sheet?: string; // Excel files only
+  line: number;
+  message: string;
+}
+
+export interface ImportResult {
+  format: ImportFormat;
+  dry_run: boolean;
+  rows: number;
+  created: number;
+  duplicates: number;
+  conflicts: number;
+  skipped: number;
+  failed: number;
+  ingredients_created: string[];
+  entries: ImportEntryPreview[]; // First 200 rows
+  weights?: ImportWeightResult; // Excel files only
+  skipped_sheets?: string[];
+  errors: ImportRowError[];
+}
+
+class ImportService {
+  private getHeaders() {
+    const token = sessionStorage.getItem('token');
+    return {
+      ...(token && { Authorization: `Bearer ${token}` }),
+    };
+  }
+
+  async importDiary(file: File, options: ImportOptions = {}): Promise<ImportResult> {
+    const form = new FormData();
+    form.append('file', file);
+    form.append('timezone', options.timezone ?? Intl.DateTimeFormat().resolvedOptions().timeZone);
+    form.append('dry_run', String(options.dryRun ?? false));
+    if (options.format) {
+      form.append('format', options.format);
+    }
+
+    const response = await fetch('/api/import', {
+      method: 'POST',
+      headers: this.getHeaders(),
+      body: form,
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Import failed' }));
+      throw new Error(error.message || 'Import failed');
+    }
+
+    return response.json();
+  }
+}
+
+export const importService = new ImportService();
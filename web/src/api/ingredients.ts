@@ -1,3 +1,12 @@
+// Extended nutrients per 100 g keyed by code (e.g. fiber, sodium); missing codes are unknown
+export type Nutrients = Record<string, number>
+
+export interface NutrientInfo {
+  code: string
+  unit: 'g' | 'mg' | 'µg'
+  max: number
+}
+
 export interface Ingredient {
   id: number
   name: string
@@ -5,17 +14,111 @@ export interface Ingredient {
   fats?: number
   carbs?: number
   proteins?: number
+  nutrients?: Nutrients
+  barcode?: string
+  global_ingredient_id?: number
   user_id?: number
   created_at?: string
   updated_at?: string
 }
 
+export type IngredientMatch = 'exact' | 'prefix' | 'word_prefix' | 'substring' | 'fuzzy'
+
+export interface IngredientSearchResult extends Ingredient {
+  use_count: number
+  last_used_at?: string
+  match?: IngredientMatch
+}
+
+export interface IngredientSearchPage {
+  items: IngredientSearchResult[]
+  next_cursor?: string
+}
+
+export interface IngredientFieldChange {
+  field: 'name' | 'kcalPer100g' | 'fats' | 'carbs' | 'proteins' | string // or a nutrient code
+  current: string | number | null
+  global: string | number | null
+}
+
+export interface PendingIngredientUpdate {
+  ingredient_id: number
+  global_ingredient_id: number
+  global_version: number
+  name: string
+  changes: IngredientFieldChange[]
+}
+
+export interface IngredientSyncReport {
+  checked: number
+  updated: number
+  renamed: number
+  pending: number
+  conflicts: number
+}
+
+export interface IngredientRelocalizeReport {
+  language: string
+  renamed: { ingredient_id: number; from: string; to: string }[]
+  kept: { ingredient_id: number; name: string; reason: 'customized' | 'name_taken' | 'no_translation' }[]
+  added: { ingredient_id: number; global_ingredient_id: number; name: string }[]
+}
+
+export interface DuplicateIngredientGroup {
+  reason: 'same_name' | 'similar'
+  suggested_keep_id: number
+  ingredients: (Ingredient & { use_count: number; global_ingredient_id?: number })[]
+}
+
+export interface IngredientMergeResult {
+  ingredient: Ingredient
+  merged_ids: number[]
+  entries_renamed: number
+}
+
+export interface IngredientServing {
+  id?: number
+  name: string
+  grams: number
+}
+
+export interface IngredientUnits {
+  ingredient_id: number
+  density_g_per_ml?: number
+  servings: IngredientServing[]
+  units: string[]
+}
+
+// Catalog product returned by a barcode lookup; names are keyed by language code
+export interface GlobalIngredient {
+  id: number
+  kcalPer100g: number
+  fats?: number
+  carbs?: number
+  proteins?: number
+  nutrients?: Nutrients
+  barcode?: string
+  source: 'manual' | 'openfoodfacts'
+  names: Record<string, string>
+}
+
+export interface BarcodeLookupResult {
+  barcode: string
+  // 'user' when the user already has the ingredient, 'global' for a catalog product
+  source: 'user' | 'global'
+  name: string
+  ingredient?: Ingredient
+  global_ingredient?: GlobalIngredient
+}
+
 export interface CreateIngredientData {
   name: string
   kcalPer100g: number
   fats?: number
   carbs?: number
   proteins?: number
+  nutrients?: Nutrients
+  barcode?: string
 }
 
 export interface UpdateIngredientData {
@@ -24,6 +127,10 @@ export interface UpdateIngredientData {
   fats?: number
   carbs?: number
   proteins?: number
+  // Omit to keep the stored nutrients, send {} to clear them
+  nutrients?: Nutrients
+  // Omit to keep the stored barcode, send '' to clear it
+  barcode?: string
 }
 
 class IngredientService {
@@ -113,6 +220,194 @@ class IngredientService {
     }
   }
 
+  // Search ingredients on the server, ranked by match quality and usage
+  searchIngredientsRemote = async (query: string, limit: number = 20, cursor?: string): Promise<IngredientSearchPage> => {
+    const params = new URLSearchParams({ q: query, limit: String(limit) })
+    if (cursor) {
+      params.set('cursor', cursor)
+    }
+
+    const response = await fetch(`/api/ingredients/search?${params}`, {
+      headers: this.getAuthHeaders(),
+    })
+
+    if (!response.ok) {
+      throw new Error('Failed to search ingredients')
+    }
+
+    return await response.json()
+  }
+
+  // Get the most frequently and recently used ingredients for quick add
+  getRecentIngredients = async (limit: number = 10): Promise<IngredientSearchResult[]> => {
+    const response = await fetch(`/api/ingredients/recent?limit=${limit}`, {
+      headers: this.getAuthHeaders(),
+    })
+
+    if (!response.ok) {
+      throw new Error('Failed to fetch recent ingredients')
+    }
+
+    return await response.json()
+  }
+
+  // Bring catalog copies up to date with the global ingredient catalog
+  syncWithCatalog = async (): Promise<IngredientSyncReport> => {
+    const response = await fetch('/api/ingredients/sync', {
+      method: 'POST',
+      headers: this.getAuthHeaders(),
+    })
+
+    if (!response.ok) {
+      throw new Error('Failed to sync ingredients')
+    }
+
+    const report: IngredientSyncReport = await response.json()
+    if (report.updated > 0 || report.renamed > 0) {
+      await this.loadAndCacheIngredients()
+    }
+    return report
+  }
+
+  // Find an ingredient by barcode; resolves to null when the barcode is unknown
+  lookupBarcode = async (code: string, lang?: string): Promise<BarcodeLookupResult | null> => {
+    const params = lang ? `?lang=${encodeURIComponent(lang)}` : ''
+    const response = await fetch(`/api/ingredients/barcode/${encodeURIComponent(code)}${params}`, {
+      headers: this.getAuthHeaders(),
+    })
+
+    if (response.status === 404) {
+      return null
+    }
+    if (!response.ok) {
+      throw new Error('Failed to look up barcode')
+    }
+
+    return response.json()
+  }
+
+  // Rename catalog ingredients to the given language and add ones missing from the list
+  relocalize = async (language: string): Promise<IngredientRelocalizeReport> => {
+    const response = await fetch('/api/ingredients/relocalize', {
+      method: 'POST',
+      headers: this.getAuthHeaders(),
+      body: JSON.stringify({ language }),
+    })
+
+    if (!response.ok) {
+      throw new Error('Failed to relocalize ingredients')
+    }
+
+    const report: IngredientRelocalizeReport = await response.json()
+    if (report.renamed.length > 0 || report.added.length > 0) {
+      await this.loadAndCacheIngredients()
+    }
+    return report
+  }
+
+  // Get the extended nutrients that can be tracked, with units and limits
+  getNutrientCatalog = async (): Promise<NutrientInfo[]> => {
+    const response = await fetch('/api/ingredients/nutrients', {
+      headers: this.getAuthHeaders(),
+    })
+
+    if (!response.ok) {
+      throw new Error('Failed to fetch nutrient catalog')
+    }
+
+    return await response.json()
+  }
+
+  // Get the servings, density and loggable units of an ingredient
+  getUnits = async (id: number): Promise<IngredientUnits> => {
+    const response = await fetch(`/api/ingredients/${id}/units`, {
+      headers: this.getAuthHeaders(),
+    })
+
+    if (!response.ok) {
+      throw new Error('Failed to fetch ingredient units')
+    }
+
+    return await response.json()
+  }
+
+  // Replace the servings and density of an ingredient
+  setUnits = async (id: number, data: { density_g_per_ml?: number; servings: IngredientServing[] }): Promise<IngredientUnits> => {
+    const response = await fetch(`/api/ingredients/${id}/units`, {
+      method: 'PUT',
+      headers: this.getAuthHeaders(),
+      body: JSON.stringify(data),
+    })
+
+    if (!response.ok) {
+      const error = await response.text()
+      throw new Error(error || 'Failed to save ingredient units')
+    }
+
+    return await response.json()
+  }
+
+  // Find groups of ingredients that likely describe the same food
+  findDuplicates = async (): Promise<DuplicateIngredientGroup[]> => {
+    const response = await fetch('/api/ingredients/duplicates', {
+      headers: this.getAuthHeaders(),
+    })
+
+    if (!response.ok) {
+      throw new Error('Failed to find duplicate ingredients')
+    }
+
+    return await response.json()
+  }
+
+  // Merge duplicates into the kept ingredient; past entries are renamed to it
+  mergeIngredients = async (keepId: number, mergeIds: number[]): Promise<IngredientMergeResult> => {
+    const response = await fetch('/api/ingredients/merge', {
+      method: 'POST',
+      headers: this.getAuthHeaders(),
+      body: JSON.stringify({ keep_id: keepId, merge_ids: mergeIds }),
+    })
+
+    if (!response.ok) {
+      const error = await response.text()
+      throw new Error(error || 'Failed to merge ingredients')
+    }
+
+    const result: IngredientMergeResult = await response.json()
+    await this.loadAndCacheIngredients()
+    return result
+  }
+
+  // Get catalog updates for ingredients the user customized
+  getPendingUpdates = async (): Promise<PendingIngredientUpdate[]> => {
+    const response = await fetch('/api/ingredients/updates', {
+      headers: this.getAuthHeaders(),
+    })
+
+    if (!response.ok) {
+      throw new Error('Failed to fetch pending updates')
+    }
+
+    return await response.json()
+  }
+
+  // Accept or dismiss a pending catalog update
+  resolvePendingUpdate = async (id: number, action: 'apply' | 'dismiss'): Promise<void> => {
+    const response = await fetch(`/api/ingredients/${id}/updates/${action}`, {
+      method: 'POST',
+      headers: this.getAuthHeaders(),
+    })
+
+    if (!response.ok) {
+      const error = await response.text()
+      throw new Error(error || 'Failed to resolve pending update')
+    }
+
+    if (action === 'apply') {
+      await this.loadAndCacheIngredients()
+    }
+  }
+
   // Clear cached ingredients (e.g., on logout)
   clearCache = (): void => {
     sessionStorage.removeItem(this.STORAGE_KEY)
@@ -1,3 +1,4 @@
+import { ingredientService } from '@/api/ingredients';
 import { ProfileData, ProfileUpdateRequest, WeightGoalRequest, WeightGoalProgress } from '@/types/profile';
 
 export type { ProfileData, ProfileUpdateRequest, WeightGoalRequest, WeightGoalProgress };
@@ -35,7 +36,12 @@ class ProfileService {
       throw new Error('Failed to update profile');
     }
 
-    return response.json();
+    const profile: ProfileData = await response.json();
+    if (profile.ingredient_relocalization) {
+      // Ingredient names changed language; reload them on next use
+      ingredientService.clearCache();
+    }
+    return profile;
   }
 
   setWeightGoal = async (data: WeightGoalRequest): Promise<WeightGoalProgress> => {
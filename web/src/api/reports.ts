@@ -10,6 +10,10 @@ export interface WeightDataPoint {
 export interface CalorieDataPoint {
   date: string;
   calories: number;
+  entries: number;
+  // Daily totals of extended nutrients, summed over the entries where they are known
+  nutrients?: Record<string, number>;
+  nutrient_entries?: Record<string, number>;
 }
 
 export interface ReportData {
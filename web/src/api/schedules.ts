@@ -0,0 +1,100 @@
+import { ExportDataType, ExportFormat } from './export';
+
+export type ScheduleKind = 'export' | 'digest';
+export type ScheduleFrequency = 'daily' | 'weekly' | 'monthly';
+
+export interface Schedule {
+  id: number;
+  kind: ScheduleKind;
+  frequency: ScheduleFrequency;
+  day_of_week?: number;
+  day_of_month?: number;
+  hour: number;
+  timezone: string;
+  data_type: ExportDataType;
+  format: ExportFormat;
+  enabled: boolean;
+  next_run_at: string;
+  last_run_at?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface ScheduleRequest {
+  kind: ScheduleKind;
+  frequency: ScheduleFrequency;
+  day_of_week?: number;
+  day_of_month?: number;
+  hour: number;
+  timezone: string;
+  data_type?: ExportDataType;
+  format?: ExportFormat;
+  enabled?: boolean;
+}
+
+class SchedulesService {
+  private getHeaders() {
+    const token = sessionStorage.getItem('token');
+    return {
+      'Content-Type': 'application/json',
+      ...(token && { Authorization: `Bearer ${token}` }),
+    };
+  }
+
+  listSchedules = async (): Promise<Schedule[]> => {
+    const response = await fetch('/api/schedules', {
+      headers: this.getHeaders(),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to list schedules' }));
+      throw new Error(error.message || 'Failed to list schedules');
+    }
+
+    return response.json();
+  };
+
+  createSchedule = async (request: ScheduleRequest): Promise<Schedule> => {
+    const response = await fetch('/api/schedules', {
+      method: 'POST',
+      headers: this.getHeaders(),
+      body: JSON.stringify(request),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to create schedule' }));
+      throw new Error(error.message || 'Failed to create schedule');
+    }
+
+    return response.json();
+  };
+
+  updateSchedule = async (id: number, request: ScheduleRequest): Promise<Schedule> => {
+    const response = await fetch(`/api/schedules/${id}`, {
+      method: 'PUT',
+      headers: this.getHeaders(),
+      body: JSON.stringify(request),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to update schedule' }));
+      throw new Error(error.message || 'Failed to update schedule');
+    }
+
+    return response.json();
+  };
+
+  deleteSchedule = async (id: number): Promise<void> => {
+    const response = await fetch(`/api/schedules/${id}`, {
+      method: 'DELETE',
+      headers: this.getHeaders(),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to delete schedule' }));
+      throw new Error(error.message || 'Failed to delete schedule');
+    }
+  };
+}
+
+export const schedulesService = new SchedulesService();
@@ -0,0 +1,149 @@
+export type WebhookEvent =
+  | 'weight.created'
+  | 'calorie_entry.created'
+  | 'calorie_entry.updated'
+  | 'goal.reached'
+  | 'daily_target.exceeded';
+
+export const WEBHOOK_EVENTS: WebhookEvent[] = [
+  'weight.created',
+  'calorie_entry.created',
+  'calorie_entry.updated',
+  'goal.reached',
+  'daily_target.exceeded',
+];
+
+export interface Webhook {
+  id: number;
+  url: string;
+  events: WebhookEvent[];
+  enabled: boolean;
+  failure_count: number;
+  disabled_at?: string;
+  last_success_at?: string;
+  last_failure_at?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface CreatedWebhook extends Webhook {
+  secret: string;
+}
+
+export interface WebhookRequest {
+  url: string;
+  events: WebhookEvent[];
+  enabled?: boolean;
+}
+
+export type WebhookDeliveryStatus = 'pending' | 'delivered' | 'failed';
+
+export interface WebhookDelivery {
+  id: number;
+  webhook_id: number;
+  event: WebhookEvent;
+  event_id: string;
+  payload: string;
+  status: WebhookDeliveryStatus;
+  attempts: number;
+  next_attempt_at: string;
+  response_status?: number;
+  error?: string;
+  created_at: string;
+  completed_at?: string;
+}
+
+class WebhooksService {
+  private getHeaders() {
+    const token = sessionStorage.getItem('token');
+    return {
+      'Content-Type': 'application/json',
+      ...(token && { Authorization: `Bearer ${token}` }),
+    };
+  }
+
+  listWebhooks = async (): Promise<Webhook[]> => {
+    const response = await fetch('/api/webhooks', {
+      headers: this.getHeaders(),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to list webhooks' }));
+      throw new Error(error.message || 'Failed to list webhooks');
+    }
+
+    return response.json();
+  };
+
+  createWebhook = async (data: WebhookRequest): Promise<CreatedWebhook> => {
+    const response = await fetch('/api/webhooks', {
+      method: 'POST',
+      headers: this.getHeaders(),
+      body: JSON.stringify(data),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to create webhook' }));
+      throw new Error(error.message || 'Failed to create webhook');
+    }
+
+    return response.json();
+  };
+
+  updateWebhook = async (id: number, data: WebhookRequest): Promise<Webhook> => {
+    const response = await fetch(`/api/webhooks/${id}`, {
+      method: 'PUT',
+      headers: this.getHeaders(),
+      body: JSON.stringify(data),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to update webhook' }));
+      throw new Error(error.message || 'Failed to update webhook');
+    }
+
+    return response.json();
+  };
+
+  deleteWebhook = async (id: number): Promise<void> => {
+    const response = await fetch(`/api/webhooks/${id}`, {
+      method: 'DELETE',
+      headers: this.getHeaders(),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to delete webhook' }));
+      throw new Error(error.message || 'Failed to delete webhook');
+    }
+  };
+
+  rotateSecret = async (id: number): Promise<string> => {
+    const response = await fetch(`/api/webhooks/${id}/secret`, {
+      method: 'POST',
+      headers: this.getHeaders(),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to rotate webhook secret' }));
+      throw new Error(error.message || 'Failed to rotate webhook secret');
+    }
+
+    const data = await response.json();
+    return data.secret;
+  };
+
+  listDeliveries = async (id: number): Promise<WebhookDelivery[]> => {
+    const response = await fetch(`/api/webhooks/${id}/deliveries`, {
+      headers: this.getHeaders(),
+    });
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ message: 'Failed to get webhook deliveries' }));
+      throw new Error(error.message || 'Failed to get webhook deliveries');
+    }
+
+    return response.json();
+  };
+}
+
+export const webhooksService = new WebhooksService();
@@ -1,8 +1,13 @@
-import { AnalysisResult } from '@/api/ai';
-import DOMPurify from 'dompurify';
-import { Clock, Coins, Sparkles } from 'lucide-react';
+import { AnalysisResult, RecommendationPriority } from '@/api/ai';
+import { AlertTriangle, Clock, Coins, Database, Sparkles } from 'lucide-react';
 import { useTranslation } from 'react-i18next';
 
+const priorityStyles: Record<RecommendationPriority, string> = {
+  high: 'bg-red-100 text-red-700',
+  medium: 'bg-yellow-100 text-yellow-700',
+  low: 'bg-green-100 text-green-700',
+};
+
 interface AIAnalysisPanelProps {
   result: AnalysisResult | null;
   isLoading: boolean;
@@ -57,17 +62,81 @@ export default function AIAnalysisPanel({
         <h3 className="text-lg font-semibold text-gray-800">{t('ai.analysisResult')}</h3>
       </div>
 
-      <div
-        className="prose prose-sm max-w-none mb-4 text-gray-700 leading-relaxed [&>h3]:text-lg [&>h3]:font-semibold [&>h3]:text-gray-800 [&>h3]:mt-4 [&>h3]:mb-2 [&>p]:mb-3 [&>ul]:list-disc [&>ul]:pl-5 [&>ul]:mb-3 [&>ol]:list-decimal [&>ol]:pl-5 [&>ol]:mb-3"
-        dangerouslySetInnerHTML={{ __html: DOMPurify.sanitize(result.analysis) }}
-      />
+      <p className="mb-4 text-gray-700 leading-relaxed">{result.summary}</p>
+
+      {result.risk_flags.length > 0 && (
+        <div className="mb-4 bg-red-50 border border-red-200 rounded-md p-4">
+          <h4 className="flex items-center gap-2 font-semibold text-red-700 mb-2">
+            <AlertTriangle size={16} />
+            {t('ai.riskFlags')}
+          </h4>
+          <ul className="list-disc pl-5 text-red-700 space-y-1">
+            {result.risk_flags.map((flag, index) => (
+              <li key={index}>{flag.description}</li>
+            ))}
+          </ul>
+        </div>
+      )}
+
+      {result.findings.length > 0 && (
+        <div className="mb-4">
+          <h4 className="text-lg font-semibold text-gray-800 mb-2">{t('ai.findings')}</h4>
+          <ul className="space-y-3">
+            {result.findings.map((finding, index) => (
+              <li key={index} className="text-gray-700">
+                {finding.title && <p className="font-medium text-gray-800">{finding.title}</p>}
+                <p>{finding.detail}</p>
+                {((finding.dates?.length ?? 0) > 0 || (finding.foods?.length ?? 0) > 0) && (
+                  <div className="flex flex-wrap gap-1 mt-1">
+                    {finding.dates?.map((date) => (
+                      <span key={date} className="px-2 py-0.5 rounded bg-blue-50 text-blue-700 text-xs">
+                        {date}
+                      </span>
+                    ))}
+                    {finding.foods?.map((food) => (
+                      <span key={food} className="px-2 py-0.5 rounded bg-gray-100 text-gray-700 text-xs">
+                        {food}
+                      </span>
+                    ))}
+                  </div>
+                )}
+              </li>
+            ))}
+          </ul>
+        </div>
+      )}
+
+      {result.recommendations.length > 0 && (
+        <div className="mb-4">
+          <h4 className="text-lg font-semibold text-gray-800 mb-2">{t('ai.recommendations')}</h4>
+          <ul className="space-y-3">
+            {result.recommendations.map((rec, index) => (
+              <li key={index} className="flex items-start gap-2 text-gray-700">
+                <span className={`shrink-0 px-2 py-0.5 rounded text-xs font-medium ${priorityStyles[rec.priority]}`}>
+                  {t(`ai.priority.${rec.priority}`)}
+                </span>
+                <div>
+                  <p className="font-medium text-gray-800">{rec.action}</p>
+                  {rec.rationale && <p className="text-sm text-gray-600">{rec.rationale}</p>}
+                </div>
+              </li>
+            ))}
+          </ul>
+        </div>
+      )}
 
       <div className="flex flex-wrap gap-4 pt-4 border-t border-gray-100 text-sm text-gray-500">
         <div className="flex items-center gap-1">
           <Clock size={14} />
           <span>{(result.duration_ms / 1000).toFixed(1)}s</span>
         </div>
-        {result.tokens_used && result.tokens_used > 0 && (
+        {result.cached && (
+          <div className="flex items-center gap-1">
+            <Database size={14} />
+            <span>{t('ai.cached')}</span>
+          </div>
+        )}
+        {!result.cached && result.tokens_used && result.tokens_used > 0 && (
           <div className="flex items-center gap-1">
             <Coins size={14} />
             <span>{result.tokens_used} tokens</span>
@@ -0,0 +1,131 @@
+import { accountService } from '@/api/account';
+import { exportService } from '@/api/export';
+import NotificationPopup from '@/components/NotificationPopup';
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query';
+import { format } from 'date-fns';
+import { useState } from 'react';
+import { useTranslation } from 'react-i18next';
+
+export default function AccountTab() {
+  const { t } = useTranslation();
+  const queryClient = useQueryClient();
+
+  const [password, setPassword] = useState('');
+  const [notification, setNotification] = useState<{ type: 'success' | 'error'; message: string } | null>(null);
+
+  const { data: deletion, isLoading } = useQuery({
+    queryKey: ['accountDeletion'],
+    queryFn: accountService.getDeletion,
+  });
+
+  const archiveMutation = useMutation({
+    mutationFn: accountService.downloadArchive,
+    onSuccess: (blob) => {
+      exportService.downloadBlob(blob, `kkal-account-${format(new Date(), 'yyyy-MM-dd')}.zip`);
+    },
+    onError: () => {
+      setNotification({ type: 'error', message: t('settings.account.archiveError') });
+    },
+  });
+
+  const deleteMutation = useMutation({
+    mutationFn: () => accountService.requestDeletion(password),
+    onSuccess: (data) => {
+      setPassword('');
+      queryClient.setQueryData(['accountDeletion'], data);
+    },
+    onError: () => {
+      setNotification({ type: 'error', message: t('settings.account.deleteError') });
+    },
+  });
+
+  const cancelMutation = useMutation({
+    mutationFn: accountService.cancelDeletion,
+    onSuccess: () => {
+      setNotification({ type: 'success', message: t('settings.account.cancelSuccess') });
+      queryClient.setQueryData(['accountDeletion'], { deletion_scheduled_at: null });
+    },
+    onError: () => {
+      setNotification({ type: 'error', message: t('settings.account.error') });
+    },
+  });
+
+  const scheduledAt = deletion?.deletion_scheduled_at;
+
+  return (
+    <div className="space-y-6">
+      {/* Data Archive */}
+      <div className="bg-white rounded-lg shadow-md p-6">
+        <h3 className="text-xl font-semibold text-gray-800 mb-2">{t('settings.account.archiveTitle')}</h3>
+        <p className="text-gray-600 mb-4">{t('settings.account.archiveDescription')}</p>
+
+        <div className="flex justify-end">
+          <button
+            onClick={() => archiveMutation.mutate()}
+            disabled={archiveMutation.isPending}
+            className="px-6 py-2 bg-blue-500 text-white rounded-md hover:bg-blue-600 disabled:bg-gray-400 disabled:cursor-not-allowed transition-colors"
+          >
+            {archiveMutation.isPending ? t('settings.account.archiving') : t('settings.account.archive')}
+          </button>
+        </div>
+      </div>
+
+      {/* Account Deletion */}
+      <div className="bg-white rounded-lg shadow-md p-6">
+        <h3 className="text-xl font-semibold text-gray-800 mb-2">{t('settings.account.deleteTitle')}</h3>
+
+        {isLoading ? (
+          <p className="text-gray-500">{t('common.loading')}</p>
+        ) : scheduledAt ? (
+          <div className="space-y-4">
+            <p className="text-amber-600">
+              {t('settings.account.scheduled', { date: format(new Date(scheduledAt), 'yyyy-MM-dd HH:mm') })}
+            </p>
+            <div className="flex justify-end">
+              <button
+                onClick={() => cancelMutation.mutate()}
+                disabled={cancelMutation.isPending}
+                className="btn-primary px-6 py-2 text-sm font-medium disabled:opacity-50"
+              >
+                {t('settings.account.cancelDeletion')}
+              </button>
+            </div>
+          </div>
+        ) : (
+          <div className="space-y-4">
+            <p className="text-gray-600">{t('settings.account.deleteDescription')}</p>
+            <div>
+              <label className="block text-sm font-medium text-gray-700 mb-1">
+                {t('settings.account.password')}
+              </label>
+              <input
+                type="password"
+                value={password}
+                onChange={(e) => setPassword(e.target.value)}
+                autoComplete="current-password"
+                className="w-full px-3 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-red-500"
+              />
+            </div>
+            <div className="flex justify-end">
+              <button
+                onClick={() => deleteMutation.mutate()}
+                disabled={!password || deleteMutation.isPending}
+                className="px-6 py-2 bg-red-600 text-white rounded-md hover:bg-red-700 disabled:bg-gray-400 disabled:cursor-not-allowed transition-colors"
+              >
+                {deleteMutation.isPending ? t('settings.account.deleting') : t('settings.account.delete')}
+              </button>
+            </div>
+          </div>
+        )}
+      </div>
+
+      {notification && (
+        <NotificationPopup
+          type={notification.type}
+          message={notification.message}
+          onClose={() => setNotification(null)}
+        />
+      )}
+    </div>
+  );
+}
@@ -0,0 +1,216 @@
+import { calendarService, CreatedCalendarFeed } from '@/api/calendar';
+import DeleteConfirmationDialog from '@/components/DeleteConfirmationDialog';
+import NotificationPopup from '@/components/NotificationPopup';
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query';
+import { format } from 'date-fns';
+import { Check, Copy } from 'lucide-react';
+import { useEffect, useState } from 'react';
+import { useTranslation } from 'react-i18next';
+
+const PAST_DAYS_OPTIONS = [7, 30, 90, 180, 365];
+
+export default function CalendarTab() {
+  const { t } = useTranslation();
+  const queryClient = useQueryClient();
+
+  const [pastDays, setPastDays] = useState(30);
+  const [createdFeed, setCreatedFeed] = useState<CreatedCalendarFeed | null>(null);
+  const [copied, setCopied] = useState(false);
+  const [confirmRevoke, setConfirmRevoke] = useState(false);
+  const [notification, setNotification] = useState<{ type: 'success' | 'error'; message: string } | null>(null);
+
+  const timezone = Intl.DateTimeFormat().resolvedOptions().timeZone || 'UTC';
+
+  const { data: feed, isLoading } = useQuery({
+    queryKey: ['calendarFeed'],
+    queryFn: calendarService.getFeed,
+  });
+
+  useEffect(() => {
+    if (feed) {
+      setPastDays(feed.past_days);
+    }
+  }, [feed]);
+
+  const createMutation = useMutation({
+    mutationFn: () => calendarService.createFeed({ timezone, past_days: pastDays }),
+    onSuccess: (data) => {
+      setCreatedFeed(data);
+      queryClient.setQueryData(['calendarFeed'], data);
+    },
+    onError: () => {
+      setNotification({ type: 'error', message: t('settings.calendar.error') });
+    },
+  });
+
+  const updateMutation = useMutation({
+    mutationFn: () => calendarService.updateFeed({ timezone, past_days: pastDays }),
+    onSuccess: (data) => {
+      setNotification({ type: 'success', message: t('settings.calendar.saveSuccess') });
+      queryClient.setQueryData(['calendarFeed'], data);
+    },
+    onError: () => {
+      setNotification({ type: 'error', message: t('settings.calendar.error') });
+    },
+  });
+
+  const revokeMutation = useMutation({
+    mutationFn: calendarService.deleteFeed,
+    onSuccess: () => {
+      setConfirmRevoke(false);
+      setNotification({ type: 'success', message: t('settings.calendar.revokeSuccess') });
+      queryClient.setQueryData(['calendarFeed'], null);
+    },
+    onError: () => {
+      setConfirmRevoke(false);
+      setNotification({ type: 'error', message: t('settings.calendar.error') });
+    },
+  });
+
+  const handleCopy = async (url: string) => {
+    await navigator.clipboard.writeText(url);
+    setCopied(true);
+    setTimeout(() => setCopied(false), 2000);
+  };
+
+  return (
+    <div className="space-y-6">
+      <div className="bg-white rounded-lg shadow-md p-6">
+        <h3 className="text-xl font-semibold text-gray-800 mb-2">{t('settings.calendar.title')}</h3>
+        <p className="text-gray-600 mb-4">{t('settings.calendar.description')}</p>
+
+        {isLoading ? (
+          <p className="text-gray-500">{t('common.loading')}</p>
+        ) : (
+          <div className="space-y-4">
+            <div>
+              <label className="block text-sm font-medium text-gray-700 mb-1">
+                {t('settings.calendar.pastDays')}
+              </label>
+              <select
+                value={pastDays}
+                onChange={(e) => setPastDays(parseInt(e.target.value, 10))}
+                className="w-full px-3 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-blue-500"
+              >
+                {PAST_DAYS_OPTIONS.map((days) => (
+                  <option key={days} value={days}>
+                    {t(`settings.calendar.pastDaysOptions.${days}`)}
+                  </option>
+                ))}
+              </select>
+              <p className="text-sm text-gray-500 mt-1">{t('settings.calendar.timezone', { timezone })}</p>
+            </div>
+
+            {feed ? (
+              <>
+                <div className="text-sm text-gray-600 space-y-1">
+                  <p>
+                    {t('settings.calendar.link')}{' '}
+                    <span className="font-mono">…/api/ical/{feed.token_prefix}…</span> ({feed.timezone})
+                  </p>
+                  <p>
+                    {t('settings.calendar.lastAccessed')}{' '}
+                    {feed.last_accessed_at
+                      ? format(new Date(feed.last_accessed_at), 'yyyy-MM-dd HH:mm')
+                      : t('settings.calendar.never')}
+                  </p>
+                </div>
+                <div className="flex flex-wrap justify-end gap-2">
+                  <button
+                    onClick={() => setConfirmRevoke(true)}
+                    className="px-4 py-2 text-sm font-medium text-red-600 border border-red-300 rounded-md hover:bg-red-50 transition-colors"
+                  >
+                    {t('settings.calendar.revoke')}
+                  </button>
+                  <button
+                    onClick={() => createMutation.mutate()}
+                    disabled={createMutation.isPending}
+                    className="px-4 py-2 text-sm font-medium text-gray-700 border border-gray-300 rounded-md hover:bg-gray-50 disabled:opacity-50 transition-colors"
+                  >
+                    {t('settings.calendar.regenerate')}
+                  </button>
+                  <button
+                    onClick={() => updateMutation.mutate()}
+                    disabled={updateMutation.isPending}
+                    className="btn-primary px-4 py-2 text-sm font-medium disabled:opacity-50"
+                  >
+                    {t('settings.calendar.save')}
+                  </button>
+                </div>
+              </>
+            ) : (
+              <div className="flex justify-end">
+                <button
+                  onClick={() => createMutation.mutate()}
+                  disabled={createMutation.isPending}
+                  className="px-6 py-2 bg-blue-500 text-white rounded-md hover:bg-blue-600 disabled:bg-gray-400 disabled:cursor-not-allowed transition-colors"
+                >
+                  {createMutation.isPending ? t('settings.calendar.creating') : t('settings.calendar.create')}
+                </button>
+              </div>
+            )}
+          </div>
+        )}
+      </div>
+
+      {/* Created Feed Modal */}
+      {createdFeed && (
+        <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50 animate-fadeIn px-4">
+          <div className="bg-white rounded-lg w-full md:w-[600px] lg:w-[700px] p-8 shadow-xl animate-slideUp">
+            <h3 className="text-lg font-semibold text-gray-800 mb-2">{t('settings.calendar.created.title')}</h3>
+            <p className="text-gray-600 mb-1">{t('settings.calendar.created.message')}</p>
+            <p className="text-amber-600 text-sm mb-4">{t('settings.calendar.created.warning')}</p>
+
+            <div className="flex items-center gap-2 mb-4">
+              <input
+                type="text"
+                readOnly
+                value={createdFeed.url}
+                className="flex-1 min-w-0 px-3 py-2 text-sm font-mono bg-gray-50 border border-gray-200 rounded-md"
+                onClick={(e) => (e.target as HTMLInputElement).select()}
+              />
+              <button
+                onClick={() => handleCopy(createdFeed.url)}
+                className="flex-shrink-0 p-2 text-gray-500 hover:text-blue-600 transition-colors"
+                title={t('settings.calendar.created.copy')}
+              >
+                {copied ? <Check size={18} className="text-green-600" /> : <Copy size={18} />}
+              </button>
+            </div>
+
+            {copied && (
+              <p className="text-green-600 text-sm mb-3">{t('settings.calendar.created.copied')}</p>
+            )}
+
+            <div className="flex justify-end">
+              <button
+                onClick={() => setCreatedFeed(null)}
+                className="btn-primary px-4 py-2 text-sm font-medium"
+              >
+                {t('settings.calendar.created.close')}
+              </button>
+            </div>
+          </div>
+        </div>
+      )}
+
+      {confirmRevoke && (
+        <DeleteConfirmationDialog
+          title={t('settings.calendar.revoke')}
+          message={t('settings.calendar.revokeConfirm')}
+          onConfirm={() => revokeMutation.mutate()}
+          onCancel={() => setConfirmRevoke(false)}
+          isDeleting={revokeMutation.isPending}
+        />
+      )}
+
+      {notification && (
+        <NotificationPopup
+          type={notification.type}
+          message={notification.message}
+          onClose={() => setNotification(null)}
+        />
+      )}
+    </div>
+  );
+}
@@ -1,11 +1,14 @@
-import { exportService, ExportDataType, DeliveryType } from '@/api/export';
+import { exportService, ExportDataType, ExportFormat, DeliveryType } from '@/api/export';
 import { profileService } from '@/api/profile';
 import NotificationPopup from '@/components/NotificationPopup';
 import { useMutation, useQuery } from '@tanstack/react-query';
-import { format, subDays } from 'date-fns';
+import { differenceInDays, format, parseISO, subDays } from 'date-fns';
 import { useState } from 'react';
 import { useTranslation } from 'react-i18next';
 
+// Downloads of longer ranges are generated in the background and polled
+const BACKGROUND_EXPORT_DAYS = 366;
+
 export default function ExportTab() {
   const { t } = useTranslation();
 
@@ -13,6 +16,7 @@ export default function ExportTab() {
   const [dateFrom, setDateFrom] = useState(format(subDays(new Date(), 30), 'yyyy-MM-dd'));
   const [dateTo, setDateTo] = useState(format(new Date(), 'yyyy-MM-dd'));
   const [dataType, setDataType] = useState<ExportDataType>('both');
+  const [exportFormat, setExportFormat] = useState<ExportFormat>('xlsx');
   const [deliveryType, setDeliveryType] = useState<DeliveryType>('download');
   const [notification, setNotification] = useState<{ type: 'success' | 'error'; message: string } | null>(null);
 
@@ -22,18 +26,26 @@ export default function ExportTab() {
     queryFn: profileService.getProfile,
   });
 
+  const exportRequest = {
+    date_from: dateFrom,
+    date_to: dateTo,
+    data_type: dataType,
+    format: exportFormat,
+    delivery_type: deliveryType,
+  };
+
   const exportMutation = useMutation({
-    mutationFn: () =>
-      exportService.exportData({
-        date_from: dateFrom,
-        date_to: dateTo,
-        data_type: dataType,
-        delivery_type: deliveryType,
-      }),
+    mutationFn: async () => {
+      const days = differenceInDays(parseISO(dateTo), parseISO(dateFrom));
+      if (deliveryType === 'download' && days > BACKGROUND_EXPORT_DAYS) {
+        const job = await exportService.waitForJob((await exportService.createJob(exportRequest)).id);
+        return exportService.downloadJob(job.id);
+      }
+      return exportService.exportData(exportRequest);
+    },
     onSuccess: (data) => {
       if (data instanceof Blob) {
-        const filename = `kkal-export-${dateFrom}-to-${dateTo}.xlsx`;
-        exportService.downloadBlob(data, filename);
+        exportService.downloadBlob(data, exportService.fileName(exportRequest));
         setNotification({ type: 'success', message: t('settings.export.downloadSuccess') });
       } else {
         setNotification({ type: 'success', message: t('settings.export.emailSuccess') });
@@ -97,6 +109,28 @@ export default function ExportTab() {
           </div>
         </div>
 
+        {/* File Format */}
+        <div>
+          <label className="block text-sm font-medium text-gray-700 mb-2">
+            {t('settings.export.format')}
+          </label>
+          <div className="flex flex-wrap gap-4">
+            {(['xlsx', 'csv', 'ndjson', 'pdf'] as ExportFormat[]).map((value) => (
+              <label key={value} className="flex items-center gap-2 cursor-pointer">
+                <input
+                  type="radio"
+                  name="exportFormat"
+                  value={value}
+                  checked={exportFormat === value}
+                  onChange={() => setExportFormat(value)}
+                  className="w-4 h-4 text-blue-600"
+                />
+                <span>{t(`settings.export.formats.${value}`)}</span>
+              </label>
+            ))}
+          </div>
+        </div>
+
         {/* Delivery Method */}
         <div>
           <label className="block text-sm font-medium text-gray-700 mb-2">
@@ -0,0 +1,331 @@
+import { ExportDataType, ExportFormat } from '@/api/export';
+import { Schedule, ScheduleFrequency, ScheduleKind, ScheduleRequest, schedulesService } from '@/api/schedules';
+import DeleteConfirmationDialog from '@/components/DeleteConfirmationDialog';
+import NotificationPopup from '@/components/NotificationPopup';
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query';
+import { format as formatDate } from 'date-fns';
+import { useState } from 'react';
+import { useTranslation } from 'react-i18next';
+
+const WEEKDAYS = [1, 2, 3, 4, 5, 6, 0];
+const HOURS = Array.from({ length: 24 }, (_, hour) => hour);
+
+// toRequest converts a saved schedule back into a request, e.g. to toggle it
+function toRequest(schedule: Schedule): ScheduleRequest {
+  return {
+    kind: schedule.kind,
+    frequency: schedule.frequency,
+    day_of_week: schedule.day_of_week,
+    day_of_month: schedule.day_of_month,
+    hour: schedule.hour,
+    timezone: schedule.timezone,
+    data_type: schedule.data_type,
+    format: schedule.format,
+    enabled: schedule.enabled,
+  };
+}
+
+export default function SchedulesTab() {
+  const { t } = useTranslation();
+  const queryClient = useQueryClient();
+
+  const [kind, setKind] = useState<ScheduleKind>('digest');
+  const [frequency, setFrequency] = useState<ScheduleFrequency>('daily');
+  const [dayOfWeek, setDayOfWeek] = useState(1);
+  const [dayOfMonth, setDayOfMonth] = useState(1);
+  const [hour, setHour] = useState(8);
+  const [dataType, setDataType] = useState<ExportDataType>('both');
+  const [fileFormat, setFileFormat] = useState<ExportFormat>('xlsx');
+  const [notification, setNotification] = useState<{ type: 'success' | 'error'; message: string } | null>(null);
+  const [deleteScheduleId, setDeleteScheduleId] = useState<number | null>(null);
+
+  const timezone = Intl.DateTimeFormat().resolvedOptions().timeZone || 'UTC';
+
+  const { data: schedules = [], isLoading } = useQuery({
+    queryKey: ['schedules'],
+    queryFn: schedulesService.listSchedules,
+  });
+
+  const createMutation = useMutation({
+    mutationFn: () =>
+      schedulesService.createSchedule({
+        kind,
+        frequency,
+        day_of_week: frequency === 'weekly' ? dayOfWeek : undefined,
+        day_of_month: frequency === 'monthly' ? dayOfMonth : undefined,
+        hour,
+        timezone,
+        data_type: kind === 'export' ? dataType : undefined,
+        format: kind === 'export' ? fileFormat : undefined,
+      }),
+    onSuccess: () => {
+      setNotification({ type: 'success', message: t('settings.schedules.createSuccess') });
+      queryClient.invalidateQueries({ queryKey: ['schedules'] });
+    },
+    onError: () => {
+      setNotification({ type: 'error', message: t('settings.schedules.error') });
+    },
+  });
+
+  const toggleMutation = useMutation({
+    mutationFn: (schedule: Schedule) =>
+      schedulesService.updateSchedule(schedule.id, { ...toRequest(schedule), enabled: !schedule.enabled }),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['schedules'] });
+    },
+    onError: () => {
+      setNotification({ type: 'error', message: t('settings.schedules.error') });
+    },
+  });
+
+  const deleteMutation = useMutation({
+    mutationFn: (id: number) => schedulesService.deleteSchedule(id),
+    onSuccess: () => {
+      setNotification({ type: 'success', message: t('settings.schedules.deleteSuccess') });
+      setDeleteScheduleId(null);
+      queryClient.invalidateQueries({ queryKey: ['schedules'] });
+    },
+    onError: () => {
+      setNotification({ type: 'error', message: t('settings.schedules.error') });
+      setDeleteScheduleId(null);
+    },
+  });
+
+  const describe = (schedule: Schedule) => {
+    const time = `${String(schedule.hour).padStart(2, '0')}:00`;
+    switch (schedule.frequency) {
+      case 'weekly':
+        return t('settings.schedules.describe.weekly', {
+          day: t(`settings.schedules.weekdays.${schedule.day_of_week}`),
+          time,
+        });
+      case 'monthly':
+        return t('settings.schedules.describe.monthly', { day: schedule.day_of_month, time });
+      default:
+        return t('settings.schedules.describe.daily', { time });
+    }
+  };
+
+  const selectClass =
+    'px-3 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-blue-500';
+
+  return (
+    <div className="space-y-6">
+      {/* Create Schedule Form */}
+      <div className="bg-white rounded-lg shadow-md p-6">
+        <h3 className="text-xl font-semibold text-gray-800 mb-2">{t('settings.schedules.title')}</h3>
+        <p className="text-gray-600 mb-4">{t('settings.schedules.description')}</p>
+
+        <div className="space-y-4">
+          <div>
+            <label className="block text-sm font-medium text-gray-700 mb-2">{t('settings.schedules.kind')}</label>
+            <div className="flex flex-wrap gap-4">
+              {(['digest', 'export'] as ScheduleKind[]).map((option) => (
+                <label key={option} className="flex items-center gap-2 cursor-pointer">
+                  <input
+                    type="radio"
+                    name="kind"
+                    value={option}
+                    checked={kind === option}
+                    onChange={() => setKind(option)}
+                    className="w-4 h-4 text-blue-600"
+                  />
+                  <span className="text-sm">{t(`settings.schedules.kinds.${option}`)}</span>
+                </label>
+              ))}
+            </div>
+          </div>
+
+          <div className="flex flex-wrap gap-4">
+            <div>
+              <label className="block text-sm font-medium text-gray-700 mb-1">
+                {t('settings.schedules.frequency')}
+              </label>
+              <select
+                value={frequency}
+                onChange={(e) => setFrequency(e.target.value as ScheduleFrequency)}
+                className={selectClass}
+              >
+                {(['daily', 'weekly', 'monthly'] as ScheduleFrequency[]).map((option) => (
+                  <option key={option} value={option}>
+                    {t(`settings.schedules.frequencies.${option}`)}
+                  </option>
+                ))}
+              </select>
+            </div>
+
+            {frequency === 'weekly' && (
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  {t('settings.schedules.dayOfWeek')}
+                </label>
+                <select
+                  value={dayOfWeek}
+                  onChange={(e) => setDayOfWeek(parseInt(e.target.value, 10))}
+                  className={selectClass}
+                >
+                  {WEEKDAYS.map((day) => (
+                    <option key={day} value={day}>
+                      {t(`settings.schedules.weekdays.${day}`)}
+                    </option>
+                  ))}
+                </select>
+              </div>
+            )}
+
+            {frequency === 'monthly' && (
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  {t('settings.schedules.dayOfMonth')}
+                </label>
+                <input
+                  type="number"
+                  value={dayOfMonth}
+                  onChange={(e) => setDayOfMonth(parseInt(e.target.value, 10) || 1)}
+                  min="1"
+                  max="31"
+                  className={`w-24 ${selectClass}`}
+                />
+              </div>
+            )}
+
+            <div>
+              <label className="block text-sm font-medium text-gray-700 mb-1">{t('settings.schedules.hour')}</label>
+              <select value={hour} onChange={(e) => setHour(parseInt(e.target.value, 10))} className={selectClass}>
+                {HOURS.map((value) => (
+                  <option key={value} value={value}>
+                    {`${String(value).padStart(2, '0')}:00`}
+                  </option>
+                ))}
+              </select>
+            </div>
+          </div>
+
+          {kind === 'export' && (
+            <div className="flex flex-wrap gap-4">
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  {t('settings.export.dataType')}
+                </label>
+                <select
+                  value={dataType}
+                  onChange={(e) => setDataType(e.target.value as ExportDataType)}
+                  className={selectClass}
+                >
+                  {(['both', 'food', 'weight'] as ExportDataType[]).map((option) => (
+                    <option key={option} value={option}>
+                      {t(`settings.export.dataTypes.${option}`)}
+                    </option>
+                  ))}
+                </select>
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-gray-700 mb-1">{t('settings.export.format')}</label>
+                <select
+                  value={fileFormat}
+                  onChange={(e) => setFileFormat(e.target.value as ExportFormat)}
+                  className={selectClass}
+                >
+                  {(['xlsx', 'csv', 'ndjson', 'pdf'] as ExportFormat[]).map((option) => (
+                    <option key={option} value={option}>
+                      {t(`settings.export.formats.${option}`)}
+                    </option>
+                  ))}
+                </select>
+              </div>
+            </div>
+          )}
+
+          <p className="text-sm text-gray-500">{t('settings.schedules.timezone', { timezone })}</p>
+
+          <div className="flex justify-end">
+            <button
+              onClick={() => createMutation.mutate()}
+              disabled={createMutation.isPending}
+              className="px-6 py-2 bg-blue-500 text-white rounded-md hover:bg-blue-600 disabled:bg-gray-400 disabled:cursor-not-allowed transition-colors"
+            >
+              {createMutation.isPending ? t('common.creating') : t('settings.schedules.create')}
+            </button>
+          </div>
+        </div>
+      </div>
+
+      {/* Schedule List */}
+      <div className="bg-white rounded-lg shadow-md p-6">
+        {isLoading ? (
+          <p className="text-gray-500">{t('common.loading')}</p>
+        ) : schedules.length === 0 ? (
+          <p className="text-gray-500">{t('settings.schedules.noSchedules')}</p>
+        ) : (
+          <div className="overflow-x-auto">
+            <table className="w-full text-left">
+              <thead>
+                <tr className="border-b border-gray-200">
+                  <th className="pb-3 text-sm font-medium text-gray-600">{t('settings.schedules.columns.kind')}</th>
+                  <th className="pb-3 text-sm font-medium text-gray-600">{t('settings.schedules.columns.when')}</th>
+                  <th className="pb-3 text-sm font-medium text-gray-600">{t('settings.schedules.columns.nextRun')}</th>
+                  <th className="pb-3 text-sm font-medium text-gray-600">{t('settings.schedules.columns.enabled')}</th>
+                  <th className="pb-3 text-sm font-medium text-gray-600">{t('common.actions')}</th>
+                </tr>
+              </thead>
+              <tbody>
+                {schedules.map((schedule) => (
+                  <tr key={schedule.id} className="border-b border-gray-100">
+                    <td className="py-3 text-sm">
+                      {t(`settings.schedules.kinds.${schedule.kind}`)}
+                      {schedule.kind === 'export' && (
+                        <span className="text-gray-500"> ({schedule.format})</span>
+                      )}
+                    </td>
+                    <td className="py-3 text-sm text-gray-500">
+                      {describe(schedule)} ({schedule.timezone})
+                    </td>
+                    <td className="py-3 text-sm text-gray-500">
+                      {schedule.enabled ? formatDate(new Date(schedule.next_run_at), 'yyyy-MM-dd HH:mm') : '—'}
+                    </td>
+                    <td className="py-3">
+                      <input
+                        type="checkbox"
+                        checked={schedule.enabled}
+                        onChange={() => toggleMutation.mutate(schedule)}
+                        disabled={toggleMutation.isPending}
+                        className="w-4 h-4 text-blue-600"
+                      />
+                    </td>
+                    <td className="py-3">
+                      <button
+                        onClick={() => setDeleteScheduleId(schedule.id)}
+                        className="text-sm text-red-600 hover:text-red-700"
+                      >
+                        {t('common.delete')}
+                      </button>
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          </div>
+        )}
+      </div>
+
+      {/* Confirm Dialog */}
+      {deleteScheduleId !== null && (
+        <DeleteConfirmationDialog
+          title={t('common.delete')}
+          message={t('settings.schedules.deleteConfirm')}
+          onConfirm={() => deleteMutation.mutate(deleteScheduleId)}
+          onCancel={() => setDeleteScheduleId(null)}
+          isDeleting={deleteMutation.isPending}
+        />
+      )}
+
+      {notification && (
+        <NotificationPopup
+          type={notification.type}
+          message={notification.message}
+          onClose={() => setNotification(null)}
+        />
+      )}
+    </div>
+  );
+}
@@ -0,0 +1,362 @@
+import { WEBHOOK_EVENTS, Webhook, WebhookEvent, webhooksService } from '@/api/webhooks';
+import DeleteConfirmationDialog from '@/components/DeleteConfirmationDialog';
+import NotificationPopup from '@/components/NotificationPopup';
+import { useMutation, useQuery, useQueryClient } from '@tanstack/react-query';
+import { format as formatDate } from 'date-fns';
+import { Check, Copy } from 'lucide-react';
+import { useState } from 'react';
+import { useTranslation } from 'react-i18next';
+
+export default function WebhooksTab() {
+  const { t } = useTranslation();
+  const queryClient = useQueryClient();
+
+  const [url, setUrl] = useState('');
+  const [events, setEvents] = useState<WebhookEvent[]>(['weight.created', 'calorie_entry.created']);
+  const [secret, setSecret] = useState<string | null>(null);
+  const [copied, setCopied] = useState(false);
+  const [deliveriesWebhookId, setDeliveriesWebhookId] = useState<number | null>(null);
+  const [deleteWebhookId, setDeleteWebhookId] = useState<number | null>(null);
+  const [notification, setNotification] = useState<{ type: 'success' | 'error'; message: string } | null>(null);
+
+  const { data: webhooks = [], isLoading } = useQuery({
+    queryKey: ['webhooks'],
+    queryFn: webhooksService.listWebhooks,
+  });
+
+  const { data: deliveries = [], isLoading: deliveriesLoading } = useQuery({
+    queryKey: ['webhookDeliveries', deliveriesWebhookId],
+    queryFn: () => webhooksService.listDeliveries(deliveriesWebhookId!),
+    enabled: deliveriesWebhookId !== null,
+  });
+
+  const createMutation = useMutation({
+    mutationFn: () => webhooksService.createWebhook({ url: url.trim(), events }),
+    onSuccess: (data) => {
+      setSecret(data.secret);
+      setUrl('');
+      queryClient.invalidateQueries({ queryKey: ['webhooks'] });
+    },
+    onError: (error: Error) => {
+      setNotification({ type: 'error', message: error.message || t('settings.webhooks.error') });
+    },
+  });
+
+  const toggleMutation = useMutation({
+    mutationFn: (webhook: Webhook) =>
+      webhooksService.updateWebhook(webhook.id, {
+        url: webhook.url,
+        events: webhook.events,
+        enabled: !webhook.enabled,
+      }),
+    onSuccess: () => {
+      queryClient.invalidateQueries({ queryKey: ['webhooks'] });
+    },
+    onError: () => {
+      setNotification({ type: 'error', message: t('settings.webhooks.error') });
+    },
+  });
+
+  const rotateMutation = useMutation({
+    mutationFn: (id: number) => webhooksService.rotateSecret(id),
+    onSuccess: (data) => {
+      setSecret(data);
+    },
+    onError: () => {
+      setNotification({ type: 'error', message: t('settings.webhooks.error') });
+    },
+  });
+
+  const deleteMutation = useMutation({
+    mutationFn: (id: number) => webhooksService.deleteWebhook(id),
+    onSuccess: () => {
+      setNotification({ type: 'success', message: t('settings.webhooks.deleteSuccess') });
+      setDeleteWebhookId(null);
+      queryClient.invalidateQueries({ queryKey: ['webhooks'] });
+    },
+    onError: () => {
+      setNotification({ type: 'error', message: t('settings.webhooks.error') });
+      setDeleteWebhookId(null);
+    },
+  });
+
+  const toggleEvent = (event: WebhookEvent) => {
+    setEvents((current) =>
+      current.includes(event) ? current.filter((e) => e !== event) : [...current, event]
+    );
+  };
+
+  const handleCopy = async (value: string) => {
+    await navigator.clipboard.writeText(value);
+    setCopied(true);
+    setTimeout(() => setCopied(false), 2000);
+  };
+
+  const status = (webhook: Webhook) => {
+    if (webhook.enabled) {
+      return webhook.failure_count > 0
+        ? t('settings.webhooks.statuses.failing', { failures: webhook.failure_count })
+        : t('settings.webhooks.statuses.active');
+    }
+    return t('settings.webhooks.statuses.disabled');
+  };
+
+  return (
+    <div className="space-y-6">
+      {/* Create Webhook Form */}
+      <div className="bg-white rounded-lg shadow-md p-6">
+        <h3 className="text-xl font-semibold text-gray-800 mb-2">{t('settings.webhooks.title')}</h3>
+        <p className="text-gray-600 mb-4">{t('settings.webhooks.description')}</p>
+
+        <div className="space-y-4">
+          <div>
+            <label className="block text-sm font-medium text-gray-700 mb-1">{t('settings.webhooks.url')}</label>
+            <input
+              type="url"
+              value={url}
+              onChange={(e) => setUrl(e.target.value)}
+              placeholder="https://example.com/webhooks/kkal"
+              maxLength={2048}
+              className="w-full px-3 py-2 border border-gray-300 rounded-md focus:outline-none focus:ring-2 focus:ring-blue-500"
+            />
+          </div>
+
+          <div>
+            <label className="block text-sm font-medium text-gray-700 mb-2">{t('settings.webhooks.events')}</label>
+            <div className="space-y-2">
+              {WEBHOOK_EVENTS.map((event) => (
+                <label key={event} className="flex items-start gap-2 cursor-pointer">
+                  <input
+                    type="checkbox"
+                    checked={events.includes(event)}
+                    onChange={() => toggleEvent(event)}
+                    className="w-4 h-4 mt-0.5 text-blue-600"
+                  />
+                  <span className="text-sm">
+                    <span className="font-mono">{event}</span>
+                    <span className="text-gray-500">
+                      {' — '}
+                      {t(`settings.webhooks.eventDescriptions.${event.replace('.', '_')}`)}
+                    </span>
+                  </span>
+                </label>
+              ))}
+            </div>
+          </div>
+
+          <div className="flex justify-end">
+            <button
+              onClick={() => createMutation.mutate()}
+              disabled={createMutation.isPending || !url.trim() || events.length === 0}
+              className="px-6 py-2 bg-blue-500 text-white rounded-md hover:bg-blue-600 disabled:bg-gray-400 disabled:cursor-not-allowed transition-colors"
+            >
+              {createMutation.isPending ? t('common.creating') : t('settings.webhooks.create')}
+            </button>
+          </div>
+        </div>
+      </div>
+
+      {/* Webhook List */}
+      <div className="bg-white rounded-lg shadow-md p-6">
+        {isLoading ? (
+          <p className="text-gray-500">{t('common.loading')}</p>
+        ) : webhooks.length === 0 ? (
+          <p className="text-gray-500">{t('settings.webhooks.noWebhooks')}</p>
+        ) : (
+          <div className="overflow-x-auto">
+            <table className="w-full text-left">
+              <thead>
+                <tr className="border-b border-gray-200">
+                  <th className="pb-3 text-sm font-medium text-gray-600">{t('settings.webhooks.columns.url')}</th>
+                  <th className="pb-3 text-sm font-medium text-gray-600">{t('settings.webhooks.columns.status')}</th>
+                  <th className="pb-3 text-sm font-medium text-gray-600">
+                    {t('settings.webhooks.columns.lastSuccess')}
+                  </th>
+                  <th className="pb-3 text-sm font-medium text-gray-600">{t('settings.webhooks.columns.enabled')}</th>
+                  <th className="pb-3 text-sm font-medium text-gray-600">{t('common.actions')}</th>
+                </tr>
+              </thead>
+              <tbody>
+                {webhooks.map((webhook) => (
+                  <tr key={webhook.id} className="border-b border-gray-100">
+                    <td className="py-3 text-sm">
+                      <div className="font-mono break-all">{webhook.url}</div>
+                      <div className="text-gray-500">{webhook.events.join(', ')}</div>
+                    </td>
+                    <td className={`py-3 text-sm ${webhook.enabled ? 'text-gray-500' : 'text-red-600'}`}>
+                      {status(webhook)}
+                    </td>
+                    <td className="py-3 text-sm text-gray-500">
+                      {webhook.last_success_at
+                        ? formatDate(new Date(webhook.last_success_at), 'yyyy-MM-dd HH:mm')
+                        : t('settings.webhooks.never')}
+                    </td>
+                    <td className="py-3">
+                      <input
+                        type="checkbox"
+                        checked={webhook.enabled}
+                        onChange={() => toggleMutation.mutate(webhook)}
+                        disabled={toggleMutation.isPending}
+                        className="w-4 h-4 text-blue-600"
+                      />
+                    </td>
+                    <td className="py-3">
+                      <div className="flex flex-wrap gap-3">
+                        <button
+                          onClick={() => setDeliveriesWebhookId(webhook.id)}
+                          className="text-sm text-blue-600 hover:text-blue-700"
+                        >
+                          {t('settings.webhooks.deliveries.show')}
+                        </button>
+                        <button
+                          onClick={() => rotateMutation.mutate(webhook.id)}
+                          disabled={rotateMutation.isPending}
+                          className="text-sm text-gray-600 hover:text-gray-700 disabled:opacity-50"
+                        >
+                          {t('settings.webhooks.rotateSecret')}
+                        </button>
+                        <button
+                          onClick={() => setDeleteWebhookId(webhook.id)}
+                          className="text-sm text-red-600 hover:text-red-700"
+                        >
+                          {t('common.delete')}
+                        </button>
+                      </div>
+                    </td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          </div>
+        )}
+      </div>
+
+      {/* Secret Modal */}
+      {secret && (
+        <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50 animate-fadeIn px-4">
+          <div className="bg-white rounded-lg w-full md:w-[600px] lg:w-[700px] p-8 shadow-xl animate-slideUp">
+            <h3 className="text-lg font-semibold text-gray-800 mb-2">{t('settings.webhooks.secret.title')}</h3>
+            <p className="text-gray-600 mb-1">{t('settings.webhooks.secret.message')}</p>
+            <p className="text-amber-600 text-sm mb-4">{t('settings.webhooks.secret.warning')}</p>
+
+            <div className="flex items-center gap-2 mb-4">
+              <input
+                type="text"
+                readOnly
+                value={secret}
+                className="flex-1 min-w-0 px-3 py-2 text-sm font-mono bg-gray-50 border border-gray-200 rounded-md"
+                onClick={(e) => (e.target as HTMLInputElement).select()}
+              />
+              <button
+                onClick={() => handleCopy(secret)}
+                className="flex-shrink-0 p-2 text-gray-500 hover:text-blue-600 transition-colors"
+                title={t('settings.webhooks.secret.copy')}
+              >
+                {copied ? <Check size={18} className="text-green-600" /> : <Copy size={18} />}
+              </button>
+            </div>
+
+            {copied && <p className="text-green-600 text-sm mb-3">{t('settings.webhooks.secret.copied')}</p>}
+
+            <div className="flex justify-end">
+              <button onClick={() => setSecret(null)} className="btn-primary px-4 py-2 text-sm font-medium">
+                {t('settings.webhooks.secret.close')}
+              </button>
+            </div>
+          </div>
+        </div>
+      )}
+
+      {/* Deliveries Modal */}
+      {deliveriesWebhookId !== null && (
+        <div className="fixed inset-0 bg-black bg-opacity-50 flex items-center justify-center z-50 animate-fadeIn px-4">
+          <div className="bg-white rounded-lg w-full md:w-[700px] lg:w-[900px] max-h-[80vh] overflow-y-auto p-8 shadow-xl animate-slideUp">
+            <h3 className="text-lg font-semibold text-gray-800 mb-4">{t('settings.webhooks.deliveries.title')}</h3>
+
+            {deliveriesLoading ? (
+              <p className="text-gray-500">{t('common.loading')}</p>
+            ) : deliveries.length === 0 ? (
+              <p className="text-gray-500">{t('settings.webhooks.deliveries.empty')}</p>
+            ) : (
+              <div className="overflow-x-auto">
+                <table className="w-full text-left">
+                  <thead>
+                    <tr className="border-b border-gray-200">
+                      <th className="pb-3 text-sm font-medium text-gray-600">
+                        {t('settings.webhooks.deliveries.columns.event')}
+                      </th>
+                      <th className="pb-3 text-sm font-medium text-gray-600">
+                        {t('settings.webhooks.deliveries.columns.created')}
+                      </th>
+                      <th className="pb-3 text-sm font-medium text-gray-600">
+                        {t('settings.webhooks.deliveries.columns.status')}
+                      </th>
+                      <th className="pb-3 text-sm font-medium text-gray-600">
+                        {t('settings.webhooks.deliveries.columns.attempts')}
+                      </th>
+                      <th className="pb-3 text-sm font-medium text-gray-600">
+                        {t('settings.webhooks.deliveries.columns.response')}
+                      </th>
+                    </tr>
+                  </thead>
+                  <tbody>
+                    {deliveries.map((delivery) => (
+                      <tr key={delivery.id} className="border-b border-gray-100 align-top">
+                        <td className="py-2 text-sm font-mono">{delivery.event}</td>
+                        <td className="py-2 text-sm text-gray-500">
+                          {formatDate(new Date(delivery.created_at), 'yyyy-MM-dd HH:mm')}
+                        </td>
+                        <td className="py-2 text-sm">
+                          {t(`settings.webhooks.deliveries.statuses.${delivery.status}`)}
+                          {delivery.status === 'pending' && delivery.attempts > 0 && (
+                            <div className="text-gray-500">
+                              {t('settings.webhooks.deliveries.nextAttempt', {
+                                time: formatDate(new Date(delivery.next_attempt_at), 'HH:mm'),
+                              })}
+                            </div>
+                          )}
+                        </td>
+                        <td className="py-2 text-sm text-gray-500">{delivery.attempts}</td>
+                        <td className="py-2 text-sm text-gray-500 break-all">
+                          {delivery.response_status && <span>HTTP {delivery.response_status} </span>}
+                          {delivery.error}
+                        </td>
+                      </tr>
+                    ))}
+                  </tbody>
+                </table>
+              </div>
+            )}
+
+            <div className="flex justify-end mt-4">
+              <button
+                onClick={() => setDeliveriesWebhookId(null)}
+                className="btn-primary px-4 py-2 text-sm font-medium"
+              >
+                {t('settings.webhooks.deliveries.close')}
+              </button>
+            </div>
+          </div>
+        </div>
+      )}
+
+      {deleteWebhookId !== null && (
+        <DeleteConfirmationDialog
+          title={t('common.delete')}
+          message={t('settings.webhooks.deleteConfirm')}
+          onConfirm={() => deleteMutation.mutate(deleteWebhookId)}
+          onCancel={() => setDeleteWebhookId(null)}
+          isDeleting={deleteMutation.isPending}
+        />
+      )}
+
+      {notification && (
+        <NotificationPopup
+          type={notification.type}
+          message={notification.message}
+          onClose={() => setNotification(null)}
+        />
+      )}
+    </div>
+  );
+}
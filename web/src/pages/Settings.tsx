@@ -1,13 +1,17 @@
 import TabNavigation from '@/components/TabNavigation';
+import AccountTab from '@/components/settings/AccountTab';
 import ApiKeysTab from '@/components/settings/ApiKeysTab';
+import CalendarTab from '@/components/settings/CalendarTab';
 import ExportTab from '@/components/settings/ExportTab';
-import { Download, Key } from 'lucide-react';
+import SchedulesTab from '@/components/settings/SchedulesTab';
+import WebhooksTab from '@/components/settings/WebhooksTab';
+import { Calendar, Clock, Download, Key, UserX, Webhook } from 'lucide-react';
 import { useState } from 'react';
 import { useTranslation } from 'react-i18next';
 
 export default function Settings() {
   const { t } = useTranslation();
-  const [activeTab, setActiveTab] = useState<'export' | 'apiKeys'>('export');
+  const [activeTab, setActiveTab] = useState<'export' | 'schedules' | 'calendar' | 'webhooks' | 'apiKeys' | 'account'>('export');
 
   return (
     <div className="max-w-screen-xl mx-auto px-4 py-2 md:px-6 lg:px-8">
@@ -18,14 +22,22 @@ export default function Settings() {
       <TabNavigation
         tabs={[
           { id: 'export', label: t('settings.export.tab'), icon: <Download size={18} /> },
+          { id: 'schedules', label: t('settings.schedules.tab'), icon: <Clock size={18} /> },
+          { id: 'calendar', label: t('settings.calendar.tab'), icon: <Calendar size={18} /> },
+          { id: 'webhooks', label: t('settings.webhooks.tab'), icon: <Webhook size={18} /> },
           { id: 'apiKeys', label: t('settings.apiKeys.tab'), icon: <Key size={18} /> },
+          { id: 'account', label: t('settings.account.tab'), icon: <UserX size={18} /> },
         ]}
         activeTab={activeTab}
-        onTabChange={(tabId) => setActiveTab(tabId as 'export' | 'apiKeys')}
+        onTabChange={(tabId) => setActiveTab(tabId as 'export' | 'schedules' | 'calendar' | 'webhooks' | 'apiKeys' | 'account')}
       />
 
       {activeTab === 'export' && <ExportTab />}
+      {activeTab === 'schedules' && <SchedulesTab />}
+      {activeTab === 'calendar' && <CalendarTab />}
+      {activeTab === 'webhooks' && <WebhooksTab />}
       {activeTab === 'apiKeys' && <ApiKeysTab />}
+      {activeTab === 'account' && <AccountTab />}
     </div>
   );
 }
@@ -1,3 +1,5 @@
+import type { IngredientRelocalizeReport } from '@/api/ingredients';
+
 export interface ProfileData {
     id: number;
     first_name?: string;
@@ -16,6 +18,8 @@ export interface ProfileData {
     target_date?: string; // Format: YYYY-MM-DD, optional
     goal_set_at?: string;
     initial_weight_at_goal?: number; // in kilograms
+    // Present in the update response when the language change renamed catalog ingredients
+    ingredient_relocalization?: IngredientRelocalizeReport;
 }
 
 export interface ProfileUpdateRequest {